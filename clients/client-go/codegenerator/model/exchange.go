@@ -151,10 +151,17 @@ func (exchange *Exchange) generateAPICode(exchangeName string) string {
 	comment += "// \n"
 	comment += "// In addition, this means that you will also get objects in your callback method like *queueevents.TaskDefinedMessage\n"
 	comment += "// rather than just interface{}.\n"
+	comment += "// \n"
+	comment += "// Conversely, the routing key of a message can be parsed into the fields of a binding:\n"
+	comment += "// \n"
+	comment += "//  var binding queueevents.TaskDefined\n"
+	comment += "//  err := binding.ParseRoutingKey(delivery.RoutingKey)\n"
+	comment += "//  fmt.Println(binding.TaskID, binding.WorkerType)\n"
 	content := comment
 	content += "package " + exchange.apiDef.PackageName + "\n"
 	content += `
 import (
+	"fmt"
 	"reflect"
 	"strings"
 	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
@@ -184,6 +191,36 @@ func generateRoutingKey(x interface{}) string {
 	}
 	return strings.Join(p, ".")
 }
+
+func parseRoutingKey(routingKey string, x interface{}) error {
+	val := reflect.ValueOf(x).Elem()
+	words := strings.Split(routingKey, ".")
+	singleWords, multipleWords := 0, false
+	for i := 0; i < val.NumField(); i++ {
+		switch val.Type().Field(i).Tag.Get("mwords") {
+		case "*":
+			singleWords++
+		case "#":
+			multipleWords = true
+		}
+	}
+	if len(words) < singleWords || (!multipleWords && len(words) > singleWords) {
+		return fmt.Errorf("routing key %q does not match %s, which has %d words", routingKey, val.Type().Name(), singleWords)
+	}
+	w := 0
+	for i := 0; i < val.NumField(); i++ {
+		switch val.Type().Field(i).Tag.Get("mwords") {
+		case "*":
+			val.Field(i).SetString(words[w])
+			w++
+		case "#":
+			n := len(words) - singleWords
+			val.Field(i).SetString(strings.Join(words[w:w+n], "."))
+			w += n
+		}
+	}
+	return nil
+}
 `
 	return content
 }
@@ -221,5 +258,9 @@ func (entry *ExchangeEntry) generateAPICode() string {
 	content += "\treturn new(" + entry.Parent.apiDef.schemas.SubSchema(entry.schemaURL).TypeName + ")\n"
 	content += "}\n"
 	content += "\n"
+	content += "func (binding *" + entry.typeName + ") ParseRoutingKey(routingKey string) error {\n"
+	content += "\treturn parseRoutingKey(routingKey, binding)\n"
+	content += "}\n"
+	content += "\n"
 	return content
 }
@@ -59,6 +59,7 @@ func main() {
 		ExportTypes:          true,
 		TypeNameBlacklist:    jsonschema2go.StringSet(map[string]bool{}),
 		DisableNestedStructs: true,
+		DateTime:             &jsonschema2go.TaskclusterDateTime,
 	}
 	result, err := job.Execute()
 	if err != nil {
@@ -202,6 +202,7 @@ func (apiDefs APIDefinitions) GenerateCode(goOutputDir string) {
 			ExportTypes:          true,
 			TypeNameBlacklist:    apiDefs[i].members,
 			DisableNestedStructs: true,
+			DateTime:             &jsonschema2go.TaskclusterDateTime,
 		}
 		result, err := job.Execute()
 		exitOnFail(err)
@@ -96,6 +96,16 @@ type ReducedHTTPClient interface {
 // making multiple requests in various goroutines.
 var defaultHTTPClient ReducedHTTPClient = &http.Client{}
 
+// RetryFunc makes the HTTP requests of API calls, calling httpCall until it
+// succeeds or fails permanently, and returns the last response along with
+// the number of attempts. httpCall returns either a response, a temporary
+// error worth retrying (such as a network error), or a permanent error.
+//
+// It defaults to httpbackoff.Retry, which retries network errors and 5xx
+// responses with exponential backoff for up to 15 minutes, and can be
+// replaced by applications which need another retry policy.
+var RetryFunc = httpbackoff.Retry
+
 // utility function to create a URL object based on given data
 func setURL(client *Client, route string, query url.Values) (u *url.URL, err error) {
 	URL := tcurls.API(client.RootURL, client.ServiceName, client.APIVersion, route)
@@ -164,7 +174,7 @@ func (client *Client) Request(rawPayload []byte, method, route string, query url
 
 	// Make HTTP API calls using an exponential backoff algorithm...
 	var err error
-	callSummary.HTTPResponse, callSummary.Attempts, err = httpbackoff.Retry(httpCall)
+	callSummary.HTTPResponse, callSummary.Attempts, err = RetryFunc(httpCall)
 
 	// read response into memory, so that we can return the body
 	if callSummary.HTTPResponse != nil {
@@ -210,6 +210,34 @@ func TestRequestWithContext(t *testing.T) {
 	}
 }
 
+// Make sure API calls are made through RetryFunc
+func TestRetryFunc(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer s.Close()
+
+	calls := 0
+	defer func(orig func(func() (*http.Response, error, error)) (*http.Response, int, error)) { RetryFunc = orig }(RetryFunc)
+	RetryFunc = func(httpCall func() (*http.Response, error, error)) (*http.Response, int, error) {
+		calls++
+		resp, tempErr, permErr := httpCall()
+		if permErr != nil {
+			return resp, 1, permErr
+		}
+		return resp, 1, tempErr
+	}
+
+	c := Client{RootURL: s.URL}
+	_, cs, err := c.APICall(nil, "GET", "/whatever", nil, nil)
+	if err != nil {
+		t.Fatal("Unexpected error: ", err)
+	}
+	if calls != 1 || cs.Attempts != 1 {
+		t.Fatalf("Expected a single call through RetryFunc, but got %d calls and %d attempts", calls, cs.Attempts)
+	}
+}
+
 // Make sure Content-Type is only set if there is a payload
 func TestContentTypeHeader(t *testing.T) {
 	// This mock service just returns the value of the Content-Type request
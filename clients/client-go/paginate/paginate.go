@@ -0,0 +1,106 @@
+// Package paginate iterates over the results of the list endpoints of
+// Taskcluster services, which return them in pages: each response has a
+// continuation token, until the last one, which is given to the next call to
+// get the next page.
+//
+//	err := paginate.ForEachTaskInGroup(ctx, queue, taskGroupID, func(t tcqueue.TaskDefinitionAndStatus) error {
+//		fmt.Println(t.Status.TaskID, t.Status.State)
+//		return nil
+//	})
+//
+// Pages follows the continuation tokens of any endpoint:
+//
+//	err := paginate.Pages(ctx, func(continuationToken string) (string, error) {
+//		resp, err := index.ListNamespaces(namespace, continuationToken, "")
+//		if err != nil {
+//			return "", err
+//		}
+//		for _, ns := range resp.Namespaces {
+//			fmt.Println(ns.Namespace)
+//		}
+//		return resp.ContinuationToken, nil
+//	})
+package paginate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+)
+
+// Stop can be returned by the callbacks of this package to stop iterating,
+// without an error.
+var Stop = errors.New("stop iterating")
+
+// Pages calls fetch with the continuation token of each page, starting with
+// an empty one, until it returns an empty token, an error, or the context is
+// done. It returns nil if fetch returns Stop, and the error of the context
+// if it is done.
+func Pages(ctx context.Context, fetch func(continuationToken string) (string, error)) error {
+	continuationToken := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		next, err := fetch(continuationToken)
+		if err == Stop {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if next == "" {
+			return nil
+		}
+		continuationToken = next
+	}
+}
+
+// A TaskGroupLister lists the tasks of groups; *tcqueue.Queue implements
+// it.
+type TaskGroupLister interface {
+	ListTaskGroup(taskGroupID, continuationToken, limit string) (*tcqueue.ListTaskGroupResponse, error)
+}
+
+// ForEachTaskInGroup calls fn with each task of a group, following the
+// continuation tokens of the listing until it is exhausted, fn returns an
+// error, or the context is done. It returns nil if fn returns Stop.
+func ForEachTaskInGroup(ctx context.Context, queue TaskGroupLister, taskGroupID string, fn func(tcqueue.TaskDefinitionAndStatus) error) error {
+	return Pages(ctx, func(continuationToken string) (string, error) {
+		ts, err := queue.ListTaskGroup(taskGroupID, continuationToken, "")
+		if err != nil {
+			return "", fmt.Errorf("could not fetch tasks for group %s: %w", taskGroupID, err)
+		}
+		for _, t := range ts.Tasks {
+			if err := fn(t); err != nil {
+				return "", err
+			}
+		}
+		return ts.ContinuationToken, nil
+	})
+}
+
+// A DependentTaskLister lists the tasks which depend on a task;
+// *tcqueue.Queue implements it.
+type DependentTaskLister interface {
+	ListDependentTasks(taskID, continuationToken, limit string) (*tcqueue.ListDependentTasksResponse, error)
+}
+
+// ForEachDependentTask calls fn with each task which depends on the given
+// task, as ForEachTaskInGroup does with the tasks of a group.
+func ForEachDependentTask(ctx context.Context, queue DependentTaskLister, taskID string, fn func(tcqueue.TaskDefinitionAndStatus) error) error {
+	return Pages(ctx, func(continuationToken string) (string, error) {
+		ts, err := queue.ListDependentTasks(taskID, continuationToken, "")
+		if err != nil {
+			return "", fmt.Errorf("could not fetch the dependents of task %s: %w", taskID, err)
+		}
+		for _, t := range ts.Tasks {
+			if err := fn(t); err != nil {
+				return "", err
+			}
+		}
+		return ts.ContinuationToken, nil
+	})
+}
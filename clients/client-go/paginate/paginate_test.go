@@ -0,0 +1,114 @@
+package paginate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcmock"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+)
+
+const (
+	taskID  = "fN1SbArXTPSVFNUvaOlinQ"
+	groupID = "dtwuF2n9S-i83G37V9eBuQ"
+)
+
+func TestPages(t *testing.T) {
+	require := require.New(t)
+	pages := map[string]string{"": "a", "a": "b", "b": ""}
+	var tokens []string
+	err := Pages(context.Background(), func(continuationToken string) (string, error) {
+		tokens = append(tokens, continuationToken)
+		return pages[continuationToken], nil
+	})
+	require.NoError(err)
+	require.Equal([]string{"", "a", "b"}, tokens)
+
+	tokens = nil
+	err = Pages(context.Background(), func(continuationToken string) (string, error) {
+		tokens = append(tokens, continuationToken)
+		return "next", Stop
+	})
+	require.NoError(err)
+	require.Len(tokens, 1)
+
+	err = Pages(context.Background(), func(string) (string, error) {
+		return "next", errors.New("oops")
+	})
+	require.EqualError(err, "oops")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err = Pages(ctx, func(string) (string, error) {
+		calls++
+		cancel()
+		return "next", nil
+	})
+	require.Equal(context.Canceled, err)
+	require.Equal(1, calls)
+}
+
+func tasks(ids ...string) []tcqueue.TaskDefinitionAndStatus {
+	var ts []tcqueue.TaskDefinitionAndStatus
+	for _, id := range ids {
+		var t tcqueue.TaskDefinitionAndStatus
+		t.Status.TaskID = id
+		ts = append(ts, t)
+	}
+	return ts
+}
+
+func TestForEachTaskInGroup(t *testing.T) {
+	require := require.New(t)
+	s := tcmock.NewServer()
+	defer s.Close()
+	s.TaskGroup(groupID, 2, tasks("a", "b", "c")...)
+	q := tcqueue.New(nil, s.URL)
+
+	var ids []string
+	err := ForEachTaskInGroup(context.Background(), q, groupID, func(t tcqueue.TaskDefinitionAndStatus) error {
+		ids = append(ids, t.Status.TaskID)
+		return nil
+	})
+	require.NoError(err)
+	require.Equal([]string{"a", "b", "c"}, ids)
+	require.Len(s.Requests(), 2)
+
+	s.Reset()
+	ids = nil
+	err = ForEachTaskInGroup(context.Background(), q, groupID, func(t tcqueue.TaskDefinitionAndStatus) error {
+		ids = append(ids, t.Status.TaskID)
+		return Stop
+	})
+	require.NoError(err)
+	require.Equal([]string{"a"}, ids)
+	require.Len(s.Requests(), 1)
+
+	err = ForEachTaskInGroup(context.Background(), q, "unknown", func(tcqueue.TaskDefinitionAndStatus) error {
+		return nil
+	})
+	require.Error(err)
+	require.Contains(err.Error(), "could not fetch tasks for group unknown")
+}
+
+func TestForEachDependentTask(t *testing.T) {
+	require := require.New(t)
+	s := tcmock.NewServer()
+	defer s.Close()
+	var items []interface{}
+	for _, t := range tasks("a", "b", "c") {
+		items = append(items, t)
+	}
+	s.Paginate("GET", "/api/queue/v1/task/"+taskID+"/dependents", "tasks", 1, items...)
+
+	var ids []string
+	err := ForEachDependentTask(context.Background(), tcqueue.New(nil, s.URL), taskID, func(t tcqueue.TaskDefinitionAndStatus) error {
+		ids = append(ids, t.Status.TaskID)
+		return nil
+	})
+	require.NoError(err)
+	require.Equal([]string{"a", "b", "c"}, ids)
+	require.Len(s.Requests(), 3)
+}
@@ -0,0 +1,173 @@
+package consumer
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// TLSFiles gives the files of the TLS configuration of amqps:// connections,
+// in PEM format.
+type TLSFiles struct {
+	// CACert is the certificate of the authority the certificate of the
+	// server is verified with, instead of those of the system.
+	CACert string
+	// Cert and Key are the certificate and key of the client, which
+	// authenticate it instead of a password.
+	Cert string
+	Key  string
+	// ServerName is the name the certificate of the server is verified
+	// for, if not the host of the URL.
+	ServerName string
+	// Insecure skips the verification of the certificate of the server.
+	Insecure bool
+}
+
+// TLSConfig returns the TLS configuration given by the files, for
+// Options.TLS.
+func TLSConfig(files TLSFiles) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         files.ServerName,
+		InsecureSkipVerify: files.Insecure,
+	}
+	if files.CACert != "" {
+		pem, err := ioutil.ReadFile(files.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the CA certificate: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate was found in %s", files.CACert)
+		}
+	}
+	if files.Cert != "" || files.Key != "" {
+		if files.Cert == "" || files.Key == "" {
+			return nil, errors.New("the client certificate and key must both be given")
+		}
+		cert, err := tls.LoadX509KeyPair(files.Cert, files.Key)
+		if err != nil {
+			return nil, fmt.Errorf("could not load the client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// ParseURL returns the options of a connection string, an amqp:// or
+// amqps:// URL with the query parameters of RabbitMQ URIs, e.g.
+//
+//	amqps://user@rabbitmq.example.com:5671/vhost?heartbeat=30&cacertfile=ca.pem&certfile=cert.pem&keyfile=key.pem
+//
+// The parameters are:
+//
+//	heartbeat               the heartbeat interval, in seconds
+//	prefetch                the prefetch count of the channel
+//	cacertfile              the CA certificate of the server, in PEM format
+//	certfile, keyfile       the certificate and key of the client
+//	server_name_indication  the name the certificate of the server is verified for
+//	verify                  verify_peer (the default), or verify_none
+//
+// The vhost is the path of the URL, e.g. / for the default vhost; the URL of
+// the options is that of the connection string without its query.
+func ParseURL(connection string) (Options, error) {
+	var opts Options
+	u, err := url.Parse(connection)
+	if err != nil || (u.Scheme != "amqp" && u.Scheme != "amqps") || u.Host == "" {
+		return opts, fmt.Errorf("invalid pulse URL %q: it must be an amqp:// or amqps:// URL", redact(connection))
+	}
+	query := u.Query()
+	u.RawQuery = ""
+	opts.URL = u.String()
+	if len(u.Path) > 1 {
+		opts.VHost, _ = url.PathUnescape(u.Path[1:])
+	}
+
+	var files TLSFiles
+	for name, values := range query {
+		value := values[len(values)-1]
+		invalid := func() (Options, error) {
+			return Options{}, fmt.Errorf("invalid pulse URL %q: invalid %s %q", redact(connection), name, value)
+		}
+		switch name {
+		case "heartbeat":
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds < 0 {
+				return invalid()
+			}
+			opts.Heartbeat = time.Duration(seconds) * time.Second
+		case "prefetch":
+			if opts.Prefetch, err = strconv.Atoi(value); err != nil || opts.Prefetch < 0 {
+				return invalid()
+			}
+		case "cacertfile":
+			files.CACert = value
+		case "certfile":
+			files.Cert = value
+		case "keyfile":
+			files.Key = value
+		case "server_name_indication":
+			files.ServerName = value
+		case "verify":
+			if value != "verify_peer" && value != "verify_none" {
+				return invalid()
+			}
+			files.Insecure = value == "verify_none"
+		default:
+			return Options{}, fmt.Errorf("invalid pulse URL %q: unknown parameter %s", redact(connection), name)
+		}
+	}
+	if files != (TLSFiles{}) {
+		if u.Scheme != "amqps" {
+			return Options{}, fmt.Errorf("invalid pulse URL %q: TLS parameters require an amqps:// URL", redact(connection))
+		}
+		if opts.TLS, err = TLSConfig(files); err != nil {
+			return Options{}, err
+		}
+	}
+	return opts, nil
+}
+
+// redact returns a URL without its password.
+func redact(connection string) string {
+	u, err := url.Parse(connection)
+	if err != nil || u.User == nil {
+		return connection
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	return u.String()
+}
+
+// dialConfig returns the AMQP configuration of the options: connections
+// with a client certificate and no password are authenticated with it.
+func dialConfig(opts Options) amqp.Config {
+	config := amqp.Config{
+		Heartbeat:       opts.Heartbeat,
+		Locale:          "en_US",
+		Vhost:           opts.VHost,
+		TLSClientConfig: opts.TLS,
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil || u.User == nil {
+		return config
+	}
+	if _, ok := u.User.Password(); !ok && opts.TLS != nil && len(opts.TLS.Certificates) > 0 {
+		config.SASL = []amqp.Authentication{externalAuth{}}
+	}
+	return config
+}
+
+// externalAuth is the EXTERNAL SASL mechanism, which authenticates with the
+// client certificate of the TLS connection.
+type externalAuth struct{}
+
+func (externalAuth) Mechanism() string { return "EXTERNAL" }
+func (externalAuth) Response() string  { return "" }
@@ -0,0 +1,59 @@
+package consumer_test
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/taskcluster/pulse-go/pulse"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/pulse/consumer"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueueevents"
+)
+
+func Example_taskclusterSniffer() {
+	c, err := consumer.New(consumer.Options{
+		URL: "amqps://" + os.Getenv("PULSE_USERNAME") + ":" + os.Getenv("PULSE_PASSWORD") + "@pulse.mozilla.org:5671",
+		// a durable queue keeps the messages published while disconnected
+		QueueName: "taskprocessing",
+		Bindings: []pulse.Binding{
+			tcqueueevents.TaskDefined{WorkerType: "gaia", ProvisionerID: "aws-provisioner"},
+			tcqueueevents.TaskRunning{WorkerType: "gaia", ProvisionerID: "aws-provisioner"},
+		},
+		Prefetch: 1, // prefetch 1 message at a time
+		Disconnected: func(err error, retry time.Duration) {
+			fmt.Printf("%v; reconnecting in %s\n", err, retry)
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+	defer c.Close()
+
+	// stop consuming on SIGINT, once the message being handled is
+	// processed
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		_ = c.Close()
+	}()
+
+	// consume until the handler fails, reconnecting whenever the connection
+	// is lost; messages are decoded into the types of the bindings
+	err = c.ConsumeTyped(func(message interface{}, delivery amqp.Delivery) error {
+		switch t := message.(type) {
+		case *tcqueueevents.TaskDefinedMessage:
+			fmt.Println("Task " + t.Status.TaskID + " defined")
+			fmt.Println(string(delivery.Body))
+		case *tcqueueevents.TaskRunningMessage:
+			fmt.Println("Task " + t.Status.TaskID + " running, (taken until " + t.TakenUntil.String() + " by worker " + t.WorkerID + ")")
+		}
+		fmt.Println("===========")
+		return delivery.Ack(false) // acknowledge message *after* processing
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,221 @@
+package consumer
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+var (
+	// ErrHandlerTimeout is the error of handlers exceeding
+	// Options.HandlerTimeout.
+	ErrHandlerTimeout = errors.New("the handler timed out")
+
+	// errAcknowledged is returned when a message is acknowledged, or
+	// rejected, again, e.g. by a handler which timed out.
+	errAcknowledged = errors.New("the message was already acknowledged or rejected")
+)
+
+// A PanicError is the error of a handler which panicked.
+type PanicError struct {
+	Exchange   string
+	RoutingKey string
+	// Value is the value the handler panicked with.
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("the handler panicked on the message of %s with routing key %s: %v", e.Exchange, e.RoutingKey, e.Value)
+}
+
+// handle calls handle with a message which passes the filter of the options,
+// and is not in their store, retrying it as their retry policy says. A
+// message which still fails is requeued, unless the policy dead-letters it.
+func (c *Consumer) handle(s *session, d amqp.Delivery, handle Handler) error {
+	if !c.opts.AutoAck && d.Acknowledger != nil {
+		d.Acknowledger = &onceAcknowledger{Acknowledger: d.Acknowledger}
+	}
+	if c.opts.Filter != nil && !c.opts.Filter(d) {
+		if !c.opts.AutoAck {
+			_ = d.Ack(false)
+		}
+		return nil
+	}
+	var id string
+	if c.opts.Store != nil {
+		id = MessageID(d)
+		seen, err := c.opts.Store.Seen(id)
+		if err != nil {
+			if !c.opts.AutoAck {
+				_ = d.Nack(false, true)
+			}
+			return fmt.Errorf("could not check whether message %s was handled: %w", id, err)
+		}
+		if seen {
+			if !c.opts.AutoAck {
+				_ = d.Ack(false)
+			}
+			return nil
+		}
+	}
+	delay := c.opts.Retry.Backoff
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err := c.attempt(d, handle)
+		c.opts.Metrics.handled(time.Since(start))
+		if err == nil || err == Stop {
+			if c.opts.Store != nil {
+				if err := c.opts.Store.Record(id, time.Now()); err != nil {
+					return fmt.Errorf("could not record message %s: %w", id, err)
+				}
+			}
+			return err
+		}
+		if attempt >= c.opts.Retry.Attempts || isPermanent(err) {
+			return c.failed(s, d, err)
+		}
+		if !c.sleep(delay) {
+			c.release(d)
+			return nil
+		}
+		delay *= 2
+	}
+}
+
+// attempt calls handle, enforcing the timeout of the options.
+func (c *Consumer) attempt(d amqp.Delivery, handle Handler) error {
+	if c.opts.HandlerTimeout == 0 {
+		return call(d, handle)
+	}
+	result := make(chan error, 1)
+	go func() {
+		result <- call(d, handle)
+	}()
+	timer := time.NewTimer(c.opts.HandlerTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-result:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("%w after %s on the message of %s with routing key %s", ErrHandlerTimeout, c.opts.HandlerTimeout, d.Exchange, d.RoutingKey)
+	}
+}
+
+// call calls handle, converting panics to a *PanicError.
+func call(d amqp.Delivery, handle Handler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Exchange: d.Exchange, RoutingKey: d.RoutingKey, Value: r}
+		}
+	}()
+	return handle(d)
+}
+
+// sleep waits for delay, returning false if the consumer is closed
+// meanwhile.
+func (c *Consumer) sleep(delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-c.closing:
+		return false
+	}
+}
+
+// requeue returns a message which failed to the queue, unless it is already
+// acknowledged.
+func (c *Consumer) requeue(d amqp.Delivery) {
+	if !c.opts.AutoAck {
+		_ = d.Nack(false, true)
+	}
+}
+
+// onceAcknowledger only acknowledges, or rejects, a message once, so that a
+// handler which timed out cannot acknowledge the message requeued meanwhile,
+// which would close the channel.
+type onceAcknowledger struct {
+	amqp.Acknowledger
+	once sync.Once
+}
+
+func (a *onceAcknowledger) do(f func() error) error {
+	err := errAcknowledged
+	a.once.Do(func() {
+		err = f()
+	})
+	return err
+}
+
+func (a *onceAcknowledger) Ack(tag uint64, multiple bool) error {
+	return a.do(func() error { return a.Acknowledger.Ack(tag, multiple) })
+}
+
+func (a *onceAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	return a.do(func() error { return a.Acknowledger.Nack(tag, multiple, requeue) })
+}
+
+func (a *onceAcknowledger) Reject(tag uint64, requeue bool) error {
+	return a.do(func() error { return a.Acknowledger.Reject(tag, requeue) })
+}
+
+// A pool bounds how many messages are handled at once, and records the
+// first error of their handlers.
+type pool struct {
+	slots  chan struct{}
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+	failed chan struct{}
+}
+
+func newPool(size int) *pool {
+	return &pool{slots: make(chan struct{}, size), failed: make(chan struct{})}
+}
+
+// acquire waits for a free slot, and returns false if a handler failed
+// meanwhile.
+func (p *pool) acquire() bool {
+	select {
+	case p.slots <- struct{}{}:
+	case <-p.failed:
+		return false
+	}
+	select {
+	case <-p.failed:
+		<-p.slots
+		return false
+	default:
+	}
+	p.wg.Add(1)
+	return true
+}
+
+// done frees the slot of a handler.
+func (p *pool) done() {
+	<-p.slots
+	p.wg.Done()
+}
+
+// fail records the error of a handler.
+func (p *pool) fail(err error) {
+	p.once.Do(func() {
+		p.err = err
+		close(p.failed)
+	})
+}
+
+// wait waits for the handlers running, and returns the first error.
+func (p *pool) wait() error {
+	p.wg.Wait()
+	select {
+	case <-p.failed:
+		return p.err
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,139 @@
+package consumer
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrency(t *testing.T) {
+	require := require.New(t)
+	fake := newFakePulse(1)
+	defer fake.install()()
+
+	for i := 0; i < 6; i++ {
+		fake.deliveries[0] <- amqp.Delivery{}
+	}
+	opts := options()
+	opts.Concurrency = 3
+	c, err := New(opts)
+	require.NoError(err)
+
+	// each handler waits for the two others of its batch to run
+	var mu sync.Mutex
+	running, maxRunning, handled := 0, 0, 0
+	batch := make(chan struct{})
+	err = c.Consume(func(d amqp.Delivery) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		wait := batch
+		if running == 3 {
+			close(batch)
+			batch = make(chan struct{})
+		}
+		mu.Unlock()
+		select {
+		case <-wait:
+		case <-time.After(time.Second):
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		running--
+		if handled++; handled == 6 {
+			return Stop
+		}
+		return nil
+	})
+	require.NoError(err)
+	require.Equal(3, maxRunning)
+	require.Equal(6, handled)
+}
+
+func TestHandlerTimeout(t *testing.T) {
+	require := require.New(t)
+	fake := newFakePulse(1)
+	defer fake.install()()
+
+	ack := &acknowledger{}
+	fake.deliveries[0] <- amqp.Delivery{Exchange: "exchange/e", RoutingKey: "slow", Acknowledger: ack, DeliveryTag: 1}
+	opts := options()
+	opts.HandlerTimeout = 10 * time.Millisecond
+	c, err := New(opts)
+	require.NoError(err)
+
+	acked := make(chan error, 1)
+	err = c.Consume(func(d amqp.Delivery) error {
+		time.Sleep(50 * time.Millisecond)
+		acked <- d.Ack(false)
+		return nil
+	})
+	require.True(errors.Is(err, ErrHandlerTimeout))
+	require.EqualError(err, "the handler timed out after 10ms on the message of exchange/e with routing key slow")
+	require.Equal(errAcknowledged, <-acked)
+	require.Equal([]uint64{1}, ack.requeued)
+	require.Empty(ack.acked)
+}
+
+func TestHandlerPanic(t *testing.T) {
+	require := require.New(t)
+	fake := newFakePulse(1)
+	defer fake.install()()
+
+	ack := &acknowledger{}
+	fake.deliveries[0] <- amqp.Delivery{Exchange: "exchange/e", RoutingKey: "bad", Acknowledger: ack, DeliveryTag: 7}
+	c, err := New(options())
+	require.NoError(err)
+
+	err = c.Consume(func(d amqp.Delivery) error {
+		panic("oops")
+	})
+	var panicErr *PanicError
+	require.True(errors.As(err, &panicErr))
+	require.Equal("oops", panicErr.Value)
+	require.EqualError(err, "the handler panicked on the message of exchange/e with routing key bad: oops")
+	require.Equal([]uint64{7}, ack.requeued)
+}
+
+func TestOnceAcknowledger(t *testing.T) {
+	ack := &acknowledger{}
+	d := amqp.Delivery{Acknowledger: &onceAcknowledger{Acknowledger: ack}, DeliveryTag: 3}
+	require.NoError(t, d.Ack(false))
+	require.Equal(t, errAcknowledged, d.Nack(false, true))
+	require.Equal(t, errAcknowledged, d.Reject(false))
+	require.Equal(t, []uint64{3}, ack.acked)
+	require.Empty(t, ack.requeued)
+}
+
+func TestFilter(t *testing.T) {
+	require := require.New(t)
+	fake := newFakePulse(1)
+	defer fake.install()()
+
+	ack := &acknowledger{}
+	for tag, key := range []string{"a", "b", "c"} {
+		fake.deliveries[0] <- amqp.Delivery{RoutingKey: key, Acknowledger: ack, DeliveryTag: uint64(tag)}
+	}
+	opts := options()
+	opts.Filter = func(d amqp.Delivery) bool { return d.RoutingKey != "b" }
+	c, err := New(opts)
+	require.NoError(err)
+
+	var keys []string
+	err = c.Consume(func(d amqp.Delivery) error {
+		keys = append(keys, d.RoutingKey)
+		if len(keys) == 2 {
+			return Stop
+		}
+		return nil
+	})
+	require.NoError(err)
+	require.Equal([]string{"a", "c"}, keys)
+	require.Equal([]uint64{1}, ack.acked)
+}
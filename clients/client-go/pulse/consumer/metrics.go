@@ -0,0 +1,190 @@
+package consumer
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the buckets of the
+// handler latency histogram, those of the Prometheus client libraries.
+var latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics records the activity of a consumer, and serves it in the
+// Prometheus text exposition format, e.g. with
+//
+//	http.Handle("/metrics", metrics)
+//
+// or with Options.MetricsAddress. The metrics are:
+//
+//	pulse_consumer_messages_total{exchange="..."}  messages delivered, by exchange
+//	pulse_consumer_redeliveries_total              messages delivered again
+//	pulse_consumer_reconnects_total                connections re-established
+//	pulse_consumer_handler_duration_seconds        histogram of the handler latency
+//	pulse_consumer_queue_messages                  messages waiting in the queue
+//
+// A Metrics records the activity of a single consumer; the zero value is not
+// usable, and a nil Metrics records nothing.
+type Metrics struct {
+	mu          sync.Mutex
+	messages    map[string]uint64
+	redelivered uint64
+	reconnects  uint64
+	// buckets counts the handler calls within each latency bucket, and
+	// those exceeding them all last.
+	buckets      []uint64
+	latencyCount uint64
+	latencySum   float64
+	// depth returns the number of messages in the queue, if known.
+	depth func() (int, bool)
+}
+
+// NewMetrics returns metrics to pass in Options.Metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		messages: make(map[string]uint64),
+		buckets:  make([]uint64, len(latencyBuckets)+1),
+	}
+}
+
+func (m *Metrics) delivered(exchange string, redelivered bool) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages[exchange]++
+	if redelivered {
+		m.redelivered++
+	}
+}
+
+func (m *Metrics) reconnected() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reconnects++
+}
+
+func (m *Metrics) handled(d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	seconds := d.Seconds()
+	i := sort.SearchFloat64s(latencyBuckets, seconds)
+	m.buckets[i]++
+	m.latencyCount++
+	m.latencySum += seconds
+}
+
+func (m *Metrics) setDepth(depth func() (int, bool)) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.depth = depth
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = m.write(w)
+}
+
+func (m *Metrics) write(w io.Writer) error {
+	m.mu.Lock()
+	depth := m.depth
+	m.mu.Unlock()
+	// the queue is inspected on the connection of the consumer, which may
+	// take a while
+	messages, haveDepth := 0, false
+	if depth != nil {
+		messages, haveDepth = depth()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var b strings.Builder
+	header(&b, "pulse_consumer_messages_total", "counter", "Messages delivered, by exchange.")
+	exchanges := make([]string, 0, len(m.messages))
+	for exchange := range m.messages {
+		exchanges = append(exchanges, exchange)
+	}
+	sort.Strings(exchanges)
+	for _, exchange := range exchanges {
+		fmt.Fprintf(&b, "pulse_consumer_messages_total{exchange=%s} %d\n", quoteLabel(exchange), m.messages[exchange])
+	}
+	header(&b, "pulse_consumer_redeliveries_total", "counter", "Messages delivered again, after not being acknowledged.")
+	fmt.Fprintf(&b, "pulse_consumer_redeliveries_total %d\n", m.redelivered)
+	header(&b, "pulse_consumer_reconnects_total", "counter", "Connections to pulse re-established after being lost.")
+	fmt.Fprintf(&b, "pulse_consumer_reconnects_total %d\n", m.reconnects)
+	header(&b, "pulse_consumer_handler_duration_seconds", "histogram", "Time taken by the handler of each message.")
+	var cumulative uint64
+	for i, bound := range latencyBuckets {
+		cumulative += m.buckets[i]
+		fmt.Fprintf(&b, "pulse_consumer_handler_duration_seconds_bucket{le=\"%s\"} %d\n", formatFloat(bound), cumulative)
+	}
+	fmt.Fprintf(&b, "pulse_consumer_handler_duration_seconds_bucket{le=\"+Inf\"} %d\n", m.latencyCount)
+	fmt.Fprintf(&b, "pulse_consumer_handler_duration_seconds_sum %s\n", formatFloat(m.latencySum))
+	fmt.Fprintf(&b, "pulse_consumer_handler_duration_seconds_count %d\n", m.latencyCount)
+	if haveDepth {
+		header(&b, "pulse_consumer_queue_messages", "gauge", "Messages waiting in the queue of the consumer.")
+		fmt.Fprintf(&b, "pulse_consumer_queue_messages %d\n", messages)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func header(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// quoteLabel quotes a label value, escaping backslashes, double quotes and
+// line feeds.
+func quoteLabel(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// serveMetrics serves the metrics of the consumer at /metrics on the given
+// address, until the consumer is closed.
+func (c *Consumer) serveMetrics(address string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", c.opts.Metrics)
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("could not serve the metrics on %s: %w", address, err)
+	}
+	c.metricsServer = &http.Server{Handler: mux}
+	c.metricsAddress = l.Addr().String()
+	go func() {
+		_ = c.metricsServer.Serve(l)
+	}()
+	return nil
+}
+
+// queueDepth returns the number of messages in the queue, if connected.
+func (c *Consumer) queueDepth() (int, bool) {
+	c.mu.Lock()
+	s := c.current
+	c.mu.Unlock()
+	if s == nil || s.inspect == nil {
+		return 0, false
+	}
+	n, err := s.inspect()
+	return n, err == nil
+}
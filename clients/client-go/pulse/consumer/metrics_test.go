@@ -0,0 +1,97 @@
+package consumer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	require := require.New(t)
+	fake := newFakePulse(2)
+	defer fake.install()()
+
+	fake.deliveries[0] <- amqp.Delivery{Exchange: "exchange/a"}
+	fake.deliveries[0] <- amqp.Delivery{Exchange: "exchange/a", Redelivered: true}
+	fake.lose(0, nil)
+	fake.deliveries[1] <- amqp.Delivery{Exchange: `exchange/"b"`}
+	fake.deliveries[1] <- amqp.Delivery{Exchange: "exchange/a"}
+
+	opts := options()
+	opts.Metrics = NewMetrics()
+	c, err := New(opts)
+	require.NoError(err)
+	require.Equal(opts.Metrics, c.Metrics())
+
+	out := &bytes.Buffer{}
+	require.NoError(c.Metrics().write(out))
+	require.Contains(out.String(), "# TYPE pulse_consumer_queue_messages gauge\npulse_consumer_queue_messages 2\n")
+
+	handled := 0
+	err = c.Consume(func(d amqp.Delivery) error {
+		if handled++; handled == 4 {
+			return Stop
+		}
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+	require.NoError(err)
+
+	out.Reset()
+	require.NoError(c.Metrics().write(out))
+	metrics := out.String()
+	for _, line := range []string{
+		"# TYPE pulse_consumer_messages_total counter",
+		`pulse_consumer_messages_total{exchange="exchange/\"b\""} 1`,
+		`pulse_consumer_messages_total{exchange="exchange/a"} 3`,
+		"pulse_consumer_redeliveries_total 1",
+		"pulse_consumer_reconnects_total 1",
+		"# TYPE pulse_consumer_handler_duration_seconds histogram",
+		`pulse_consumer_handler_duration_seconds_bucket{le="0.005"} 1`,
+		`pulse_consumer_handler_duration_seconds_bucket{le="10"} 4`,
+		`pulse_consumer_handler_duration_seconds_bucket{le="+Inf"} 4`,
+		"pulse_consumer_handler_duration_seconds_count 4",
+	} {
+		require.Contains(metrics, line+"\n")
+	}
+	// once disconnected, the depth of the queue is not known
+	require.NotContains(metrics, "pulse_consumer_queue_messages")
+}
+
+func TestMetricsAddress(t *testing.T) {
+	require := require.New(t)
+	fake := newFakePulse(2)
+	defer fake.install()()
+
+	fake.deliveries[0] <- amqp.Delivery{Exchange: "exchange/a"}
+	opts := options()
+	opts.MetricsAddress = "127.0.0.1:0"
+	c, err := New(opts)
+	require.NoError(err)
+	require.NotNil(c.Metrics())
+
+	url := "http://" + c.metricsAddress + "/metrics"
+	res, err := http.Get(url)
+	require.NoError(err)
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	require.NoError(err)
+	require.Equal("text/plain; version=0.0.4; charset=utf-8", res.Header.Get("Content-Type"))
+	require.True(strings.HasPrefix(string(body), "# HELP pulse_consumer_messages_total "), string(body))
+	require.Contains(string(body), "pulse_consumer_queue_messages 1\n")
+
+	require.NoError(c.Close())
+	_, err = http.Get(url)
+	require.Error(err)
+
+	opts.MetricsAddress = "127.0.0.1:-1"
+	_, err = New(opts)
+	require.Error(err)
+	require.Contains(err.Error(), "could not serve the metrics on 127.0.0.1:-1: ")
+}
@@ -0,0 +1,83 @@
+package consumer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/taskcluster/slugid-go/slugid"
+)
+
+// QueueOptions configures the lifetime and limits of the queue of a
+// consumer. Declaring a named queue which already exists with other options
+// fails, so they can only be changed once the queue is deleted.
+type QueueOptions struct {
+	// Namespace, if set, is inserted in the name of the queue, e.g.
+	// queue/<user>/<namespace>/<name>, to group the queues of an
+	// application.
+	Namespace string
+	// Transient, for named queues, loses the queue, and its messages, when
+	// the server restarts; named queues are durable by default.
+	Transient bool
+	// AutoDelete, for named queues, deletes the queue once its last
+	// consumer is gone, e.g. when the consumer is closed.
+	AutoDelete bool
+	// Exclusive, for named queues, consumes from the queue exclusively:
+	// other consumers of the queue are refused while the consumer is
+	// connected.
+	Exclusive bool
+	// Expires, if not 0, deletes the queue once it is unused this long,
+	// e.g. after a consumer of a named queue is gone for good.
+	Expires time.Duration
+	// MessageTTL, if not 0, discards, or dead-letters, the messages which
+	// stay this long in the queue.
+	MessageTTL time.Duration
+	// MaxLength and MaxLengthBytes, if not 0, are how many messages, and
+	// bytes of bodies, the queue holds at most; the oldest messages are
+	// discarded, or dead-lettered, beyond.
+	MaxLength      int
+	MaxLengthBytes int
+}
+
+// queueName returns the name of the queue of user; Pulse only allows queues
+// named queue/<user>/... A random name is generated if none is given.
+func queueName(user, namespace, name string) string {
+	if name == "" {
+		name = slugid.Nice()
+	}
+	if namespace != "" {
+		name = namespace + "/" + name
+	}
+	return fmt.Sprintf("queue/%s/%s", user, name)
+}
+
+// queueFlags returns how the queue of the options is declared and consumed
+// from: queues without a name are exclusive to the connection.
+func queueFlags(opts Options) (durable, autoDelete, exclusive, exclusiveConsumer bool) {
+	if opts.QueueName == "" {
+		return false, true, true, true
+	}
+	return !opts.Queue.Transient, opts.Queue.AutoDelete, false, opts.Queue.Exclusive
+}
+
+// queueArgs returns the arguments of the declaration of the queue of the
+// options.
+func queueArgs(opts QueueOptions) amqp.Table {
+	args := amqp.Table{}
+	if opts.Expires > 0 {
+		args["x-expires"] = int32(opts.Expires / time.Millisecond)
+	}
+	if opts.MessageTTL > 0 {
+		args["x-message-ttl"] = int32(opts.MessageTTL / time.Millisecond)
+	}
+	if opts.MaxLength > 0 {
+		args["x-max-length"] = int32(opts.MaxLength)
+	}
+	if opts.MaxLengthBytes > 0 {
+		args["x-max-length-bytes"] = int32(opts.MaxLengthBytes)
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	return args
+}
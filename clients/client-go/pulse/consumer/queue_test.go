@@ -0,0 +1,58 @@
+package consumer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueName(t *testing.T) {
+	require.Equal(t, "queue/me/listener", queueName("me", "", "listener"))
+	require.Equal(t, "queue/me/ci/listener", queueName("me", "ci", "listener"))
+	require.Regexp(t, `^queue/me/[A-Za-z0-9_-]{22}$`, queueName("me", "", ""))
+	require.Regexp(t, `^queue/me/ci/[A-Za-z0-9_-]{22}$`, queueName("me", "ci", ""))
+}
+
+func TestQueueFlags(t *testing.T) {
+	for _, tc := range []struct {
+		opts                                              Options
+		durable, autoDelete, exclusive, exclusiveConsumer bool
+	}{
+		// ad-hoc queues are deleted with the connection, whatever the options
+		{Options{Queue: QueueOptions{AutoDelete: false}}, false, true, true, true},
+		{Options{QueueName: "q"}, true, false, false, false},
+		{Options{QueueName: "q", Queue: QueueOptions{Transient: true, AutoDelete: true}}, false, true, false, false},
+		{Options{QueueName: "q", Queue: QueueOptions{Exclusive: true}}, true, false, false, true},
+	} {
+		durable, autoDelete, exclusive, exclusiveConsumer := queueFlags(tc.opts)
+		require.Equal(t,
+			[]bool{tc.durable, tc.autoDelete, tc.exclusive, tc.exclusiveConsumer},
+			[]bool{durable, autoDelete, exclusive, exclusiveConsumer}, "%+v", tc.opts)
+	}
+}
+
+func TestQueueArgs(t *testing.T) {
+	require.Nil(t, queueArgs(QueueOptions{Transient: true}))
+	require.Equal(t, amqp.Table{
+		"x-expires":          int32(86400000),
+		"x-message-ttl":      int32(60000),
+		"x-max-length":       int32(1000),
+		"x-max-length-bytes": int32(1 << 20),
+	}, queueArgs(QueueOptions{Expires: 24 * time.Hour, MessageTTL: time.Minute, MaxLength: 1000, MaxLengthBytes: 1 << 20}))
+}
+
+func TestQueueNamespace(t *testing.T) {
+	fake := newFakePulse(1)
+	defer fake.install()()
+
+	opts := options()
+	opts.QueueName = "listener"
+	opts.Queue.Namespace = "ci"
+	c, err := New(opts)
+	require.NoError(t, err)
+	require.Equal(t, "queue/me/ci/listener", c.Queue())
+	require.Equal(t, c.Queue(), fake.queue)
+	require.NoError(t, c.Close())
+}
@@ -0,0 +1,83 @@
+package consumer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/streadway/amqp"
+	"github.com/taskcluster/pulse-go/pulse"
+)
+
+// ErrUnknownExchange is returned by Registry.Decode for the messages of an
+// exchange no binding was registered for.
+var ErrUnknownExchange = errors.New("no message type is registered for the exchange")
+
+// A Registry maps exchange names to the types of their messages, given by
+// the NewPayloadObject method of their bindings: the bindings of the tc*events
+// packages, such as tcqueueevents.TaskCompleted, decode their messages into
+// the generated types, such as *tcqueueevents.TaskCompletedMessage.
+type Registry struct {
+	mu    sync.RWMutex
+	types map[string]func() interface{}
+}
+
+// NewRegistry returns a registry of the message types of the exchanges of
+// the given bindings; their routing keys do not matter, so zero values such
+// as tcqueueevents.TaskFailed{} can be given.
+func NewRegistry(bindings ...pulse.Binding) *Registry {
+	r := &Registry{types: make(map[string]func() interface{})}
+	r.Register(bindings...)
+	return r
+}
+
+// Register adds the message types of the exchanges of the given bindings,
+// replacing those already registered for the same exchanges.
+func (r *Registry) Register(bindings ...pulse.Binding) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, b := range bindings {
+		r.types[b.ExchangeName()] = b.NewPayloadObject
+	}
+}
+
+// Decode unmarshals the body of a delivery into a new value of the type
+// registered for its exchange, e.g. a *tcqueueevents.TaskCompletedMessage.
+// Its errors are permanent: such messages are not retried.
+func (r *Registry) Decode(d amqp.Delivery) (interface{}, error) {
+	r.mu.RLock()
+	newPayload, ok := r.types[d.Exchange]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, Permanent(fmt.Errorf("%w %s", ErrUnknownExchange, d.Exchange))
+	}
+	payload := newPayload()
+	if err := json.Unmarshal(d.Body, payload); err != nil {
+		return nil, Permanent(fmt.Errorf("could not decode the message of %s: %w", d.Exchange, err))
+	}
+	return payload, nil
+}
+
+// A TypedHandler is called with each message, decoded into the type
+// registered for its exchange, and the delivery, and returns as a Handler
+// does.
+type TypedHandler func(message interface{}, d amqp.Delivery) error
+
+// Handler returns a Handler which decodes the messages and calls handle;
+// messages which cannot be decoded stop consuming with the error of Decode.
+func (r *Registry) Handler(handle TypedHandler) Handler {
+	return func(d amqp.Delivery) error {
+		message, err := r.Decode(d)
+		if err != nil {
+			return err
+		}
+		return handle(message, d)
+	}
+}
+
+// ConsumeTyped consumes the messages as Consume does, decoding them into the
+// types of the bindings of the consumer.
+func (c *Consumer) ConsumeTyped(handle TypedHandler) error {
+	return c.Consume(NewRegistry(c.opts.Bindings...).Handler(handle))
+}
@@ -0,0 +1,67 @@
+package consumer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/pulse-go/pulse"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueueevents"
+)
+
+func TestRegistryDecode(t *testing.T) {
+	require := require.New(t)
+	r := NewRegistry(tcqueueevents.TaskCompleted{}, tcqueueevents.TaskFailed{TaskGroupID: "g"})
+
+	message, err := r.Decode(amqp.Delivery{
+		Exchange: "exchange/taskcluster-queue/v1/task-completed",
+		Body:     []byte(`{"runId": 1, "status": {"taskId": "fN1SbArXTPSVFNUvaOlinQ"}}`),
+	})
+	require.NoError(err)
+	completed, ok := message.(*tcqueueevents.TaskCompletedMessage)
+	require.True(ok, "got %T", message)
+	require.Equal(int64(1), completed.RunID)
+	require.Equal("fN1SbArXTPSVFNUvaOlinQ", completed.Status.TaskID)
+
+	message, err = r.Decode(amqp.Delivery{Exchange: "exchange/taskcluster-queue/v1/task-failed", Body: []byte(`{}`)})
+	require.NoError(err)
+	require.IsType(&tcqueueevents.TaskFailedMessage{}, message)
+
+	_, err = r.Decode(amqp.Delivery{Exchange: "exchange/taskcluster-queue/v1/task-pending", Body: []byte(`{}`)})
+	require.True(errors.Is(err, ErrUnknownExchange))
+	require.EqualError(err, "no message type is registered for the exchange exchange/taskcluster-queue/v1/task-pending")
+
+	_, err = r.Decode(amqp.Delivery{Exchange: "exchange/taskcluster-queue/v1/task-failed", Body: []byte(`not json`)})
+	require.Error(err)
+
+	r.Register(tcqueueevents.TaskPending{})
+	message, err = r.Decode(amqp.Delivery{Exchange: "exchange/taskcluster-queue/v1/task-pending", Body: []byte(`{}`)})
+	require.NoError(err)
+	require.IsType(&tcqueueevents.TaskPendingMessage{}, message)
+}
+
+func TestConsumeTyped(t *testing.T) {
+	require := require.New(t)
+	fake := newFakePulse(1)
+	defer fake.install()()
+
+	fake.deliveries[0] <- amqp.Delivery{
+		Exchange: "exchange/taskcluster-queue/v1/task-running",
+		Body:     []byte(`{"workerId": "wid", "status": {"taskId": "fN1SbArXTPSVFNUvaOlinQ"}}`),
+	}
+	fake.deliveries[0] <- amqp.Delivery{Exchange: "exchange/other", Body: []byte(`{}`)}
+
+	opts := options()
+	opts.Bindings = []pulse.Binding{tcqueueevents.TaskRunning{ProvisionerID: "proj"}}
+	c, err := New(opts)
+	require.NoError(err)
+
+	var workers []string
+	err = c.ConsumeTyped(func(message interface{}, d amqp.Delivery) error {
+		workers = append(workers, message.(*tcqueueevents.TaskRunningMessage).WorkerID)
+		return nil
+	})
+	require.True(errors.Is(err, ErrUnknownExchange))
+	require.Equal([]string{"wid"}, workers)
+}
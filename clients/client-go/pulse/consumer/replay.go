@@ -0,0 +1,78 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/paginate"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+)
+
+// A TaskLister fetches the state of tasks; *tcqueue.Queue implements it.
+type TaskLister interface {
+	ListTaskGroup(taskGroupID, continuationToken, limit string) (*tcqueue.ListTaskGroupResponse, error)
+	Status(taskID string) (*tcqueue.TaskStatusResponse, error)
+}
+
+// ReplayTasks returns a function to use as Options.Replay, which fetches the
+// status of the tasks of the given groups, and of the given tasks, from the
+// Queue, and calls handle with those of the tasks whose runs were
+// scheduled, started or resolved since the checkpoint, i.e. whose messages
+// may have been missed.
+func ReplayTasks(queue TaskLister, taskGroupIDs, taskIDs []string, handle func(tcqueue.TaskStatusStructure) error) func(since time.Time) error {
+	return func(since time.Time) error {
+		for _, groupID := range taskGroupIDs {
+			err := paginate.ForEachTaskInGroup(context.Background(), queue, groupID, func(t tcqueue.TaskDefinitionAndStatus) error {
+				if changedSince(t.Status, since) {
+					return handle(t.Status)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for _, taskID := range taskIDs {
+			s, err := queue.Status(taskID)
+			if err != nil {
+				return fmt.Errorf("could not fetch the status of task %s: %w", taskID, err)
+			}
+			if changedSince(s.Status, since) {
+				if err := handle(s.Status); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// changedSince returns whether a run of a task was scheduled, started or
+// resolved after the given time.
+func changedSince(status tcqueue.TaskStatusStructure, since time.Time) bool {
+	for _, run := range status.Runs {
+		for _, t := range []time.Time{time.Time(run.Scheduled), time.Time(run.Started), time.Time(run.Resolved)} {
+			if t.After(since) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// replay calls the Replay function of the options with the checkpoint of
+// their store, if any.
+func (c *Consumer) replay() error {
+	if c.opts.Replay == nil || c.opts.Store == nil {
+		return nil
+	}
+	since, err := c.opts.Store.Checkpoint()
+	if err != nil {
+		return fmt.Errorf("could not read the checkpoint of the store: %w", err)
+	}
+	if since.IsZero() {
+		return nil
+	}
+	return c.opts.Replay(since)
+}
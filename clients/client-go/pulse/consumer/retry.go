@@ -0,0 +1,93 @@
+package consumer
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// A RetryPolicy says what becomes of the messages whose handler fails.
+type RetryPolicy struct {
+	// Attempts is how many times a message is handled, at most, before it
+	// is given up on; 0 or 1 not to retry messages.
+	Attempts int
+	// Backoff is the delay before retrying a message, which doubles after
+	// each attempt (default 1s).
+	Backoff time.Duration
+	// DeadLetterExchange, if set, is the topic exchange the messages given
+	// up on are published to, with their routing key, and the error of the
+	// handler in the x-error header; Pulse only allows publishing to the
+	// exchanges named exchange/<user>/... The exchange is declared if it
+	// does not exist.
+	DeadLetterExchange string
+	// Failed, if set, is called with the messages given up on, and the
+	// error of the handler, once they are dead-lettered, if
+	// DeadLetterExchange is set.
+	Failed func(d amqp.Delivery, err error)
+}
+
+// deadLetters returns whether the policy disposes of the messages given up
+// on; otherwise, the error of their handler stops consuming.
+func (r RetryPolicy) deadLetters() bool {
+	return r.DeadLetterExchange != "" || r.Failed != nil
+}
+
+// permanentError is an error which retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks the error of a handler as one which retrying the message
+// cannot fix, e.g. an invalid message, so that it is given up on at once.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// failed gives up on a message whose handler failed with err, as the retry
+// policy says: the message is dead-lettered and acknowledged, or requeued,
+// and err returned.
+func (c *Consumer) failed(s *session, d amqp.Delivery, err error) error {
+	r := c.opts.Retry
+	if !r.deadLetters() {
+		c.requeue(d)
+		return err
+	}
+	if r.DeadLetterExchange != "" {
+		headers := amqp.Table{}
+		for k, v := range d.Headers {
+			headers[k] = v
+		}
+		headers["x-error"] = err.Error()
+		headers["x-exchange"] = d.Exchange
+		msg := amqp.Publishing{
+			Headers:         headers,
+			ContentType:     d.ContentType,
+			ContentEncoding: d.ContentEncoding,
+			DeliveryMode:    amqp.Persistent,
+			MessageId:       d.MessageId,
+			Timestamp:       d.Timestamp,
+			Body:            d.Body,
+		}
+		if perr := s.publish(r.DeadLetterExchange, d.RoutingKey, msg); perr != nil {
+			c.requeue(d)
+			return fmt.Errorf("could not publish the message of %s to %s: %w", d.Exchange, r.DeadLetterExchange, perr)
+		}
+	}
+	if r.Failed != nil {
+		r.Failed(d, err)
+	}
+	if !c.opts.AutoAck {
+		_ = d.Ack(false)
+	}
+	return nil
+}
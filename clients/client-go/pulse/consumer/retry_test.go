@@ -0,0 +1,124 @@
+package consumer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetry(t *testing.T) {
+	require := require.New(t)
+	fake := newFakePulse(1)
+	defer fake.install()()
+
+	ack := &acknowledger{}
+	fake.deliveries[0] <- amqp.Delivery{Acknowledger: ack, DeliveryTag: 1}
+	opts := options()
+	opts.Retry = RetryPolicy{Attempts: 3, Backoff: time.Millisecond}
+	c, err := New(opts)
+	require.NoError(err)
+
+	attempts := 0
+	err = c.Consume(func(d amqp.Delivery) error {
+		if attempts++; attempts < 3 {
+			return errors.New("try again")
+		}
+		_ = d.Ack(false)
+		return Stop
+	})
+	require.NoError(err)
+	require.Equal(3, attempts)
+	require.Equal([]uint64{1}, ack.acked)
+}
+
+func TestRetryExhausted(t *testing.T) {
+	require := require.New(t)
+	fake := newFakePulse(1)
+	defer fake.install()()
+
+	ack := &acknowledger{}
+	fake.deliveries[0] <- amqp.Delivery{Acknowledger: ack, DeliveryTag: 1}
+	opts := options()
+	opts.Retry = RetryPolicy{Attempts: 2, Backoff: time.Millisecond}
+	c, err := New(opts)
+	require.NoError(err)
+
+	attempts := 0
+	err = c.Consume(func(d amqp.Delivery) error {
+		attempts++
+		return errors.New("oops")
+	})
+	require.EqualError(err, "oops")
+	require.Equal(2, attempts)
+	require.Equal([]uint64{1}, ack.requeued)
+}
+
+func TestDeadLetter(t *testing.T) {
+	require := require.New(t)
+	fake := newFakePulse(1)
+	defer fake.install()()
+
+	ack := &acknowledger{}
+	fake.deliveries[0] <- amqp.Delivery{Exchange: "exchange/e", RoutingKey: "a.b", Body: []byte("bad"), Acknowledger: ack, DeliveryTag: 1}
+	fake.deliveries[0] <- amqp.Delivery{Exchange: "exchange/e", RoutingKey: "c", Body: []byte("good"), Acknowledger: ack, DeliveryTag: 2}
+	var failed []string
+	opts := options()
+	opts.Retry = RetryPolicy{
+		Attempts:           3,
+		Backoff:            time.Millisecond,
+		DeadLetterExchange: "exchange/me/dead-letters",
+		Failed: func(d amqp.Delivery, err error) {
+			failed = append(failed, string(d.Body)+": "+err.Error())
+		},
+	}
+	c, err := New(opts)
+	require.NoError(err)
+
+	attempts := 0
+	err = c.Consume(func(d amqp.Delivery) error {
+		if string(d.Body) == "bad" {
+			attempts++
+			// not worth retrying
+			return Permanent(errors.New("invalid message"))
+		}
+		_ = d.Ack(false)
+		return Stop
+	})
+	require.NoError(err)
+	require.Equal(1, attempts)
+	require.Equal([]string{"exchange/me/dead-letters a.b bad invalid message"}, fake.published)
+	require.Equal([]string{"bad: invalid message"}, failed)
+	require.Equal([]uint64{1, 2}, ack.acked)
+}
+
+func TestRetryClosed(t *testing.T) {
+	require := require.New(t)
+	fake := newFakePulse(1)
+	defer fake.install()()
+
+	ack := &acknowledger{}
+	fake.deliveries[0] <- amqp.Delivery{Acknowledger: ack, DeliveryTag: 1}
+	opts := options()
+	opts.Retry = RetryPolicy{Attempts: 3, Backoff: time.Hour}
+	c, err := New(opts)
+	require.NoError(err)
+
+	err = c.Consume(func(d amqp.Delivery) error {
+		go func() {
+			_ = c.Close()
+		}()
+		return errors.New("oops")
+	})
+	require.NoError(err)
+	require.Equal([]uint64{1}, ack.requeued)
+}
+
+func TestPermanent(t *testing.T) {
+	err := Permanent(ErrUnknownExchange)
+	require.True(t, isPermanent(err))
+	require.True(t, errors.Is(err, ErrUnknownExchange))
+	require.False(t, isPermanent(ErrUnknownExchange))
+}
@@ -0,0 +1,181 @@
+package consumer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// A Store records the messages a consumer handled, so that they are not
+// handled again once redelivered, and when, so that the state changes
+// missed while the consumer was down can be replayed, see Options.Replay.
+// Stores backed by databases, such as bolt or sqlite, can implement it; the
+// package provides NewMemoryStore and NewFileStore.
+type Store interface {
+	// Seen returns whether the message with the given ID was recorded.
+	Seen(id string) (bool, error)
+	// Record records that the message with the given ID was handled at the
+	// given time.
+	Record(id string, at time.Time) error
+	// Checkpoint returns the latest time a message was recorded at, or the
+	// zero time if none was.
+	Checkpoint() (time.Time, error)
+}
+
+// MessageID returns the ID a message is recorded with in a Store: its AMQP
+// message ID, if it has one, or else a digest of its exchange, routing key
+// and body.
+func MessageID(d amqp.Delivery) string {
+	if d.MessageId != "" {
+		return d.MessageId
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", d.Exchange, d.RoutingKey)
+	h.Write(d.Body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// storeState is the state of the stores of this package.
+type storeState struct {
+	Checkpoint time.Time            `json:"checkpoint"`
+	Messages   map[string]time.Time `json:"messages"`
+}
+
+// record records a message, and forgets those recorded more than retention
+// before the checkpoint, if retention is not 0.
+func (s *storeState) record(id string, at time.Time, retention time.Duration) {
+	s.Messages[id] = at
+	if at.After(s.Checkpoint) {
+		s.Checkpoint = at
+	}
+	if retention == 0 {
+		return
+	}
+	for id, at := range s.Messages {
+		if s.Checkpoint.Sub(at) > retention {
+			delete(s.Messages, id)
+		}
+	}
+}
+
+type memoryStore struct {
+	mu    sync.Mutex
+	state storeState
+}
+
+// NewMemoryStore returns a Store which keeps the messages it records in
+// memory, e.g. to handle redelivered messages once.
+func NewMemoryStore() Store {
+	return &memoryStore{state: storeState{Messages: make(map[string]time.Time)}}
+}
+
+func (s *memoryStore) Seen(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.state.Messages[id]
+	return ok, nil
+}
+
+func (s *memoryStore) Record(id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.record(id, at, 0)
+	return nil
+}
+
+func (s *memoryStore) Checkpoint() (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state.Checkpoint, nil
+}
+
+// A FileStore is a Store which keeps the messages it records in a JSON
+// file, rewritten as each message is recorded.
+type FileStore struct {
+	path      string
+	retention time.Duration
+
+	mu    sync.Mutex
+	state storeState
+}
+
+// NewFileStore returns a FileStore reading and writing the given file, which
+// is created once a message is recorded. The messages recorded more than
+// retention before the checkpoint are forgotten, unless retention is 0.
+func NewFileStore(path string, retention time.Duration) (*FileStore, error) {
+	s := &FileStore{
+		path:      path,
+		retention: retention,
+		state:     storeState{Messages: make(map[string]time.Time)},
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read the store %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return nil, fmt.Errorf("could not decode the store %s: %w", path, err)
+	}
+	if s.state.Messages == nil {
+		s.state.Messages = make(map[string]time.Time)
+	}
+	return s, nil
+}
+
+// Seen implements Store.
+func (s *FileStore) Seen(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.state.Messages[id]
+	return ok, nil
+}
+
+// Record implements Store.
+func (s *FileStore) Record(id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.record(id, at, s.retention)
+	return s.write()
+}
+
+// Checkpoint implements Store.
+func (s *FileStore) Checkpoint() (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state.Checkpoint, nil
+}
+
+// write replaces the file of the store, so that it is never partially
+// written.
+func (s *FileStore) write() error {
+	data, err := json.Marshal(s.state)
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("could not write the store %s: %w", s.path, err)
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), s.path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("could not write the store %s: %w", s.path, err)
+	}
+	return nil
+}
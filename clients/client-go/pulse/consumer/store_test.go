@@ -0,0 +1,155 @@
+package consumer
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+)
+
+func TestMessageID(t *testing.T) {
+	require := require.New(t)
+	require.Equal("m1", MessageID(amqp.Delivery{MessageId: "m1", Body: []byte("a")}))
+	a := MessageID(amqp.Delivery{Exchange: "exchange/e", RoutingKey: "k", Body: []byte("a")})
+	require.Len(a, 64)
+	require.Equal(a, MessageID(amqp.Delivery{Exchange: "exchange/e", RoutingKey: "k", Body: []byte("a"), Redelivered: true}))
+	require.NotEqual(a, MessageID(amqp.Delivery{Exchange: "exchange/e", RoutingKey: "k", Body: []byte("b")}))
+}
+
+func TestFileStore(t *testing.T) {
+	require := require.New(t)
+	dir, err := ioutil.TempDir("", "store")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store.json")
+
+	s, err := NewFileStore(path, time.Hour)
+	require.NoError(err)
+	checkpoint, err := s.Checkpoint()
+	require.NoError(err)
+	require.True(checkpoint.IsZero())
+
+	now := time.Date(2020, 4, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(s.Record("old", now.Add(-2*time.Hour)))
+	require.NoError(s.Record("a", now))
+	require.NoError(s.Record("b", now.Add(-time.Minute)))
+
+	s, err = NewFileStore(path, time.Hour)
+	require.NoError(err)
+	checkpoint, err = s.Checkpoint()
+	require.NoError(err)
+	require.True(now.Equal(checkpoint), checkpoint.String())
+	for id, seen := range map[string]bool{"a": true, "b": true, "old": false, "c": false} {
+		ok, err := s.Seen(id)
+		require.NoError(err)
+		require.Equal(seen, ok, id)
+	}
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(err)
+	require.Len(files, 1)
+
+	require.NoError(ioutil.WriteFile(path, []byte("not json"), 0644))
+	_, err = NewFileStore(path, 0)
+	require.Error(err)
+	require.Contains(err.Error(), "could not decode the store "+path)
+}
+
+func TestConsumeStore(t *testing.T) {
+	require := require.New(t)
+	fake := newFakePulse(2)
+	defer fake.install()()
+
+	ack := &acknowledger{}
+	fake.deliveries[0] <- amqp.Delivery{MessageId: "a", Acknowledger: ack, DeliveryTag: 1}
+	fake.lose(0, nil)
+	fake.deliveries[1] <- amqp.Delivery{MessageId: "a", Redelivered: true, Acknowledger: ack, DeliveryTag: 2}
+	fake.deliveries[1] <- amqp.Delivery{MessageId: "b", Acknowledger: ack, DeliveryTag: 3}
+
+	var replays []time.Time
+	opts := options()
+	opts.Store = NewMemoryStore()
+	opts.Replay = func(since time.Time) error {
+		replays = append(replays, since)
+		return nil
+	}
+	c, err := New(opts)
+	require.NoError(err)
+
+	var ids []string
+	err = c.Consume(func(d amqp.Delivery) error {
+		ids = append(ids, d.MessageId)
+		if err := d.Ack(false); err != nil {
+			return err
+		}
+		if len(ids) == 2 {
+			return Stop
+		}
+		return nil
+	})
+	require.NoError(err)
+	require.Equal([]string{"a", "b"}, ids)
+	require.Equal([]uint64{1, 2, 3}, ack.acked)
+	// there is no checkpoint to replay from on the first connection
+	require.Len(replays, 1)
+	seen, err := opts.Store.Seen("b")
+	require.NoError(err)
+	require.True(seen)
+}
+
+func TestReplayTasks(t *testing.T) {
+	require := require.New(t)
+	since := time.Date(2020, 4, 1, 12, 0, 0, 0, time.UTC)
+	status := func(taskID string, scheduled time.Duration) tcqueue.TaskStatusStructure {
+		return tcqueue.TaskStatusStructure{
+			TaskID: taskID,
+			Runs:   []tcqueue.RunInformation{{Scheduled: tcclient.Time(since.Add(scheduled))}},
+		}
+	}
+	queue := &fakeQueue{
+		groups: map[string][]tcqueue.ListTaskGroupResponse{"g": {
+			{Tasks: []tcqueue.TaskDefinitionAndStatus{{Status: status("t1", time.Minute)}}, ContinuationToken: "next"},
+			{Tasks: []tcqueue.TaskDefinitionAndStatus{{Status: status("t2", -time.Minute)}, {Status: status("t3", time.Second)}}},
+		}},
+		tasks: map[string]tcqueue.TaskStatusStructure{"t4": status("t4", time.Hour), "t5": status("t5", 0)},
+	}
+
+	var replayed []string
+	replay := ReplayTasks(queue, []string{"g"}, []string{"t4", "t5"}, func(s tcqueue.TaskStatusStructure) error {
+		replayed = append(replayed, s.TaskID)
+		return nil
+	})
+	require.NoError(replay(since))
+	require.Equal([]string{"t1", "t3", "t4"}, replayed)
+
+	err := ReplayTasks(queue, nil, []string{"missing"}, nil)(since)
+	require.EqualError(err, "could not fetch the status of task missing: task not found")
+}
+
+// fakeQueue serves the pages of the groups, and the statuses of the tasks.
+type fakeQueue struct {
+	groups map[string][]tcqueue.ListTaskGroupResponse
+	tasks  map[string]tcqueue.TaskStatusStructure
+}
+
+func (q *fakeQueue) ListTaskGroup(taskGroupID, continuationToken, limit string) (*tcqueue.ListTaskGroupResponse, error) {
+	page := 0
+	if continuationToken != "" {
+		page = 1
+	}
+	return &q.groups[taskGroupID][page], nil
+}
+
+func (q *fakeQueue) Status(taskID string) (*tcqueue.TaskStatusResponse, error) {
+	status, ok := q.tasks[taskID]
+	if !ok {
+		return nil, errors.New("task not found")
+	}
+	return &tcqueue.TaskStatusResponse{Status: status}, nil
+}
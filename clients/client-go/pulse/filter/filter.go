@@ -0,0 +1,251 @@
+// Package filter implements the expressions filtering Pulse messages, e.g.
+//
+//	payload.status.workerType == "github-worker" && payload.status.state in ["failed", "exception"]
+//
+// An expression is evaluated against the fields of a message: exchange,
+// routingKey, routes (the routes it was CC'ed to), redelivered and payload,
+// its decoded JSON body. Fields are selected with dots, and list items with
+// brackets, e.g. payload.status.runs[0].reasonResolved; missing fields are
+// null.
+//
+// The operators are, by increasing precedence: ||, &&, the comparisons ==,
+// !=, <, <=, >, >=, in (a list, or a substring of a string) and =~ (a regular
+// expression), and !. Literals are strings, in double or single quotes,
+// numbers, true, false, null and lists such as ["a", "b"]; parentheses group
+// expressions. A value which is not a boolean is true unless it is null, 0,
+// "", or empty.
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/streadway/amqp"
+)
+
+// A Filter is a parsed filter expression.
+type Filter struct {
+	expr string
+	root node
+}
+
+// Parse parses a filter expression.
+func Parse(expr string) (*Filter, error) {
+	p := &parser{lexer: &lexer{input: expr}}
+	if err := p.next(); err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+	}
+	root, err := p.parseOr()
+	if err == nil && p.tok.kind != tokEOF {
+		err = fmt.Errorf("unexpected %s at offset %d", p.tok, p.tok.pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+	}
+	return &Filter{expr: expr, root: root}, nil
+}
+
+// String returns the expression of the filter.
+func (f *Filter) String() string {
+	return f.expr
+}
+
+// Match evaluates the filter against the given fields, which are the values
+// of JSON documents decoded into interface{}.
+func (f *Filter) Match(fields map[string]interface{}) bool {
+	return truthy(f.root.eval(fields))
+}
+
+// MatchDelivery evaluates the filter against the fields of a message; a body
+// which is not JSON is a string.
+func (f *Filter) MatchDelivery(d amqp.Delivery) bool {
+	return f.Match(Fields(d))
+}
+
+// Fields returns the fields of a message filters are evaluated against.
+func Fields(d amqp.Delivery) map[string]interface{} {
+	var payload interface{}
+	if err := json.Unmarshal(d.Body, &payload); err != nil {
+		payload = string(d.Body)
+	}
+	var routes interface{}
+	if cc, ok := d.Headers["CC"].([]interface{}); ok {
+		routes = cc
+	}
+	return map[string]interface{}{
+		"exchange":    d.Exchange,
+		"routingKey":  d.RoutingKey,
+		"routes":      routes,
+		"redelivered": d.Redelivered,
+		"payload":     payload,
+	}
+}
+
+// A node is a node of the syntax tree of an expression.
+type node interface {
+	eval(fields map[string]interface{}) interface{}
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (n literal) eval(map[string]interface{}) interface{} {
+	return n.value
+}
+
+type list struct {
+	items []node
+}
+
+func (n list) eval(fields map[string]interface{}) interface{} {
+	values := make([]interface{}, len(n.items))
+	for i, item := range n.items {
+		values[i] = item.eval(fields)
+	}
+	return values
+}
+
+// path selects a field; its elements are strings for object keys, and ints
+// for list indexes.
+type path struct {
+	elements []interface{}
+}
+
+func (n path) eval(fields map[string]interface{}) interface{} {
+	var value interface{} = fields
+	for _, e := range n.elements {
+		switch e := e.(type) {
+		case string:
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			value = object[e]
+		case int:
+			items, ok := value.([]interface{})
+			if !ok || e >= len(items) {
+				return nil
+			}
+			value = items[e]
+		}
+	}
+	return value
+}
+
+type not struct {
+	operand node
+}
+
+func (n not) eval(fields map[string]interface{}) interface{} {
+	return !truthy(n.operand.eval(fields))
+}
+
+type logical struct {
+	and         bool
+	left, right node
+}
+
+func (n logical) eval(fields map[string]interface{}) interface{} {
+	if truthy(n.left.eval(fields)) != n.and {
+		// false && ..., or true || ...
+		return !n.and
+	}
+	return truthy(n.right.eval(fields))
+}
+
+type comparison struct {
+	op          string
+	left, right node
+	// re is the compiled regular expression of =~
+	re *regexp.Regexp
+}
+
+func (n comparison) eval(fields map[string]interface{}) interface{} {
+	left, right := n.left.eval(fields), n.right.eval(fields)
+	switch n.op {
+	case "==":
+		return equal(left, right)
+	case "!=":
+		return !equal(left, right)
+	case "in":
+		switch right := right.(type) {
+		case []interface{}:
+			for _, item := range right {
+				if equal(left, item) {
+					return true
+				}
+			}
+		case string:
+			s, ok := left.(string)
+			return ok && strings.Contains(right, s)
+		}
+		return false
+	case "=~":
+		s, ok := left.(string)
+		return ok && n.re.MatchString(s)
+	}
+	// ordering comparisons only apply to two numbers, or two strings
+	var c int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return false
+		}
+		c = compareFloats(l, r)
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return false
+		}
+		c = strings.Compare(l, r)
+	default:
+		return false
+	}
+	switch n.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func equal(a, b interface{}) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	return true
+}
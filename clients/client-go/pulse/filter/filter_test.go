@@ -0,0 +1,85 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/require"
+)
+
+var delivery = amqp.Delivery{
+	Exchange:   "exchange/taskcluster-queue/v1/task-failed",
+	RoutingKey: "primary.fN1SbArXTPSVFNUvaOlinQ.0.wg.wid.proj-foo.github-worker.sched.grp._",
+	Headers:    amqp.Table{"CC": []interface{}{"route.index.project.foo.latest"}},
+	Body: []byte(`{"runId": 0, "status": {"taskId": "fN1SbArXTPSVFNUvaOlinQ", "workerType": "github-worker",
+		"state": "failed", "retriesLeft": 5, "runs": [{"reasonResolved": "failed"}]}}`),
+}
+
+func TestMatchDelivery(t *testing.T) {
+	for _, tc := range []struct {
+		expr  string
+		match bool
+	}{
+		{`payload.status.workerType == "github-worker" && payload.status.state in ["failed","exception"]`, true},
+		{`payload.status.workerType == "github-worker" && payload.status.state in ["exception"]`, false},
+		{`payload.status.state == 'completed' || payload.status.state == 'failed'`, true},
+		{`payload.status.state != "failed"`, false},
+		{`!(payload.status.state == "failed")`, false},
+		{`payload.status.retriesLeft >= 5 && payload.status.retriesLeft < 6`, true},
+		{`payload.status.retriesLeft > 5`, false},
+		{`payload.runId == 0`, true},
+		{`payload.status.runs[0].reasonResolved == "failed"`, true},
+		{`payload.status.runs[1].reasonResolved == null`, true},
+		{`payload.status.missing`, false},
+		{`payload.status.missing == null`, true},
+		{`!payload.status.missing`, true},
+		{`payload.status.runs`, true},
+		{`exchange =~ "task-(failed|exception)$"`, true},
+		{`routingKey =~ "^route\\."`, false},
+		{`"route.index.project.foo.latest" in routes`, true},
+		{`"github" in payload.status.workerType`, true},
+		{`"gecko" in payload.status.workerType`, false},
+		{`redelivered`, false},
+		{`payload.status.state < 5`, false},
+		{`payload.status.state > "exception"`, true},
+		{`true && (false || payload.status.taskId == "fN1SbArXTPSVFNUvaOlinQ")`, true},
+	} {
+		f, err := Parse(tc.expr)
+		require.NoError(t, err, tc.expr)
+		require.Equal(t, tc.match, f.MatchDelivery(delivery), tc.expr)
+	}
+}
+
+func TestMatchNotJSON(t *testing.T) {
+	f, err := Parse(`payload == "hello"`)
+	require.NoError(t, err)
+	require.True(t, f.MatchDelivery(amqp.Delivery{Body: []byte("hello")}))
+}
+
+func TestParseErrors(t *testing.T) {
+	for expr, msg := range map[string]string{
+		``:                        `invalid filter "": unexpected end of expression`,
+		`payload.state ==`:        `invalid filter "payload.state ==": unexpected end of expression`,
+		`payload.state == "a`:     `invalid filter "payload.state == \"a": unterminated string at offset 17`,
+		`payload.state = "a"`:     `invalid filter "payload.state = \"a\"": unexpected '=' at offset 14`,
+		`(payload.state == "a"`:   `invalid filter "(payload.state == \"a\"": expected ")" at offset 21, got end of expression`,
+		`payload.runs[x]`:         `invalid filter "payload.runs[x]": expected an index at offset 13, got "x"`,
+		`payload. == 1`:           `invalid filter "payload. == 1": expected a field name at offset 9, got "=="`,
+		`payload.state =~ x`:      `invalid filter "payload.state =~ x": =~ at offset 14 must be followed by a string`,
+		`payload.state =~ "("`:    "invalid filter \"payload.state =~ \\\"(\\\"\": error parsing regexp: missing closing ): `(`",
+		`payload.state "a"`:       `invalid filter "payload.state \"a\"": unexpected "a" at offset 14`,
+		`payload.state in [1, 2`:  `invalid filter "payload.state in [1, 2": expected "]" at offset 22, got end of expression`,
+		`payload.runId == 1.2.3`:  `invalid filter "payload.runId == 1.2.3": invalid number "1.2.3" at offset 17`,
+		`payload.state == "a" )`:  `invalid filter "payload.state == \"a\" )": unexpected ")" at offset 21`,
+		`payload.state == $`:      `invalid filter "payload.state == $": unexpected '$' at offset 17`,
+		`payload.state in []`:     ``,
+		`payload.state in ["a",]`: `invalid filter "payload.state in [\"a\",]": unexpected "]" at offset 22`,
+	} {
+		_, err := Parse(expr)
+		if msg == "" {
+			require.NoError(t, err, expr)
+		} else {
+			require.EqualError(t, err, msg, expr)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return strconv.Quote(t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// operators are the operators and punctuation, longest first.
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "<", ">", "!", "(", ")", "[", "]", ",", "."}
+
+type lexer struct {
+	input string
+	pos   int
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.input) && unicode.IsSpace(rune(l.input[l.pos])) {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos >= len(l.input) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+	c := l.input[l.pos]
+	switch {
+	case c == '"' || c == '\'':
+		var b strings.Builder
+		for l.pos++; l.pos < len(l.input); l.pos++ {
+			switch l.input[l.pos] {
+			case c:
+				l.pos++
+				return token{kind: tokString, text: b.String(), pos: start}, nil
+			case '\\':
+				if l.pos++; l.pos < len(l.input) {
+					b.WriteByte(l.input[l.pos])
+				}
+			default:
+				b.WriteByte(l.input[l.pos])
+			}
+		}
+		return token{}, fmt.Errorf("unterminated string at offset %d", start)
+	case c == '-' || (c >= '0' && c <= '9'):
+		for l.pos++; l.pos < len(l.input) && strings.IndexByte("0123456789.eE+-", l.input[l.pos]) >= 0; l.pos++ {
+		}
+		return token{kind: tokNumber, text: l.input[start:l.pos], pos: start}, nil
+	case c == '_' || unicode.IsLetter(rune(c)):
+		for l.pos++; l.pos < len(l.input) && (l.input[l.pos] == '_' || unicode.IsLetter(rune(l.input[l.pos])) || unicode.IsDigit(rune(l.input[l.pos]))); l.pos++ {
+		}
+		return token{kind: tokIdent, text: l.input[start:l.pos], pos: start}, nil
+	}
+	for _, op := range operators {
+		if strings.HasPrefix(l.input[l.pos:], op) {
+			l.pos += len(op)
+			return token{kind: tokOp, text: op, pos: start}, nil
+		}
+	}
+	return token{}, fmt.Errorf("unexpected %q at offset %d", c, start)
+}
+
+// parser is a recursive descent parser of expressions.
+type parser struct {
+	lexer *lexer
+	tok   token
+}
+
+func (p *parser) next() error {
+	t, err := p.lexer.next()
+	p.tok = t
+	return err
+}
+
+// accept consumes the current token if it is the operator op.
+func (p *parser) accept(op string) (bool, error) {
+	if p.tok.kind != tokOp || p.tok.text != op {
+		return false, nil
+	}
+	return true, p.next()
+}
+
+func (p *parser) expect(op string) error {
+	ok, err := p.accept(op)
+	if err == nil && !ok {
+		err = fmt.Errorf("expected %q at offset %d, got %s", op, p.tok.pos, p.tok)
+	}
+	return err
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	for err == nil {
+		var ok bool
+		if ok, err = p.accept("||"); !ok || err != nil {
+			break
+		}
+		var right node
+		if right, err = p.parseAnd(); err == nil {
+			left = logical{and: false, left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseComparison()
+	for err == nil {
+		var ok bool
+		if ok, err = p.accept("&&"); !ok || err != nil {
+			break
+		}
+		var right node
+		if right, err = p.parseComparison(); err == nil {
+			left = logical{and: true, left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	op := ""
+	switch {
+	case p.tok.kind == tokOp && strings.Contains(" == != < <= > >= =~ ", " "+p.tok.text+" "):
+		op = p.tok.text
+	case p.tok.kind == tokIdent && p.tok.text == "in":
+		op = "in"
+	default:
+		return left, nil
+	}
+	pos := p.tok.pos
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	right, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	n := comparison{op: op, left: left, right: right}
+	if op == "=~" {
+		pattern, ok := right.(literal)
+		s, isString := pattern.value.(string)
+		if !ok || !isString {
+			return nil, fmt.Errorf("=~ at offset %d must be followed by a string", pos)
+		}
+		if n.re, err = regexp.Compile(s); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if ok, err := p.accept("!"); err != nil {
+		return nil, err
+	} else if ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return not{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.tok
+	switch t.kind {
+	case tokString:
+		return literal{value: t.text}, p.next()
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", t.text, t.pos)
+		}
+		return literal{value: f}, p.next()
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return literal{value: true}, p.next()
+		case "false":
+			return literal{value: false}, p.next()
+		case "null":
+			return literal{value: nil}, p.next()
+		}
+		return p.parsePath()
+	case tokOp:
+		switch t.text {
+		case "(":
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			n, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		case "[":
+			return p.parseList()
+		}
+	case tokEOF:
+		return nil, errors.New("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %s at offset %d", t, t.pos)
+}
+
+func (p *parser) parsePath() (node, error) {
+	n := path{elements: []interface{}{p.tok.text}}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	for {
+		if ok, err := p.accept("."); err != nil {
+			return nil, err
+		} else if ok {
+			if p.tok.kind != tokIdent {
+				return nil, fmt.Errorf("expected a field name at offset %d, got %s", p.tok.pos, p.tok)
+			}
+			n.elements = append(n.elements, p.tok.text)
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if ok, err := p.accept("["); err != nil {
+			return nil, err
+		} else if ok {
+			index, err := strconv.Atoi(p.tok.text)
+			if p.tok.kind != tokNumber || err != nil || index < 0 {
+				return nil, fmt.Errorf("expected an index at offset %d, got %s", p.tok.pos, p.tok)
+			}
+			n.elements = append(n.elements, index)
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return n, nil
+	}
+}
+
+func (p *parser) parseList() (node, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	n := list{}
+	if ok, err := p.accept("]"); ok || err != nil {
+		return n, err
+	}
+	for {
+		item, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		n.items = append(n.items, item)
+		if ok, err := p.accept(","); err != nil {
+			return nil, err
+		} else if !ok {
+			return n, p.expect("]")
+		}
+	}
+}
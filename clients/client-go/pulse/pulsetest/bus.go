@@ -0,0 +1,146 @@
+package pulsetest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/streadway/amqp"
+	"github.com/taskcluster/pulse-go/pulse"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/pulse/consumer"
+)
+
+// A Bus routes the messages published to it to the queues bound to them, in
+// memory.
+type Bus struct {
+	mu     sync.Mutex
+	queues map[*Queue]struct{}
+}
+
+// NewBus returns a Bus without queues.
+func NewBus() *Bus {
+	return &Bus{queues: make(map[*Queue]struct{})}
+}
+
+// Bind returns a queue receiving the messages published to the bus from now
+// on which match one of the bindings.
+func (b *Bus) Bind(bindings ...pulse.Binding) *Queue {
+	q := &Queue{bus: b, bindings: bindings, ready: make(chan struct{}, 1)}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.queues[q] = struct{}{}
+	return q
+}
+
+// Publish publishes the message of a binding, see Message.
+func (b *Bus) Publish(binding pulse.Binding, payload interface{}) error {
+	d, err := Message(binding, payload)
+	if err != nil {
+		return err
+	}
+	b.Deliver(d)
+	return nil
+}
+
+// Deliver publishes a message as is, e.g. one recorded from Pulse.
+func (b *Bus) Deliver(d amqp.Delivery) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for q := range b.queues {
+		q.deliver(d)
+	}
+}
+
+// A Queue holds the messages of a Bus matching its bindings until they are
+// handled.
+type Queue struct {
+	bus      *Bus
+	bindings []pulse.Binding
+
+	mu       sync.Mutex
+	messages []amqp.Delivery
+	// ready receives a value when messages are added.
+	ready chan struct{}
+}
+
+func (q *Queue) deliver(d amqp.Delivery) {
+	for _, b := range q.bindings {
+		if b.ExchangeName() == d.Exchange && Matches(b.RoutingKey(), d.RoutingKey) {
+			q.mu.Lock()
+			q.messages = append(q.messages, d)
+			q.mu.Unlock()
+			select {
+			case q.ready <- struct{}{}:
+			default:
+			}
+			return
+		}
+	}
+}
+
+// Len returns the number of messages waiting in the queue.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.messages)
+}
+
+// next removes the first message of the queue, if any.
+func (q *Queue) next() (amqp.Delivery, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.messages) == 0 {
+		return amqp.Delivery{}, false
+	}
+	d := q.messages[0]
+	q.messages = q.messages[1:]
+	return d, true
+}
+
+// Drain hands the messages waiting in the queue to a handler, in the order
+// they were published, until there are none left or the handler fails; the
+// message it fails to handle is dropped. It returns nil if the handler
+// returns consumer.Stop.
+func (q *Queue) Drain(handle consumer.Handler) error {
+	for {
+		d, ok := q.next()
+		if !ok {
+			return nil
+		}
+		if err := handle(d); err != nil {
+			if err == consumer.Stop {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// Run hands the messages of the queue to a handler as they are published,
+// as consumer.Consumer.Run does, until the context is done, whose error it
+// then returns, or the handler fails or returns consumer.Stop.
+func (q *Queue) Run(ctx context.Context, handle consumer.Handler) error {
+	for {
+		d, ok := q.next()
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-q.ready:
+			}
+			continue
+		}
+		if err := handle(d); err != nil {
+			if err == consumer.Stop {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// Close unbinds the queue from the bus.
+func (q *Queue) Close() {
+	q.bus.mu.Lock()
+	defer q.bus.mu.Unlock()
+	delete(q.bus.queues, q)
+}
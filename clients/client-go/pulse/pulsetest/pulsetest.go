@@ -0,0 +1,108 @@
+// Package pulsetest publishes synthetic Pulse messages, to test the code
+// consuming them without the credentials of a production Pulse instance:
+// to an in-memory Bus, whose queues hand them to a consumer.Handler, or to a
+// local RabbitMQ with a Publisher, which a consumer.Consumer can connect to,
+// e.g. docker run -p 5672:5672 rabbitmq.
+//
+// The messages are given by the bindings of the tc*events packages, whose
+// fields are the words of their routing keys, and their payloads, which are
+// checked against the types of the messages of their exchanges:
+//
+//	bus := pulsetest.NewBus()
+//	q := bus.Bind(tcqueueevents.TaskCompleted{TaskGroupID: taskGroupID})
+//	err := bus.Publish(
+//		tcqueueevents.TaskCompleted{RoutingKeyKind: "primary", TaskID: taskID, RunID: "0", TaskGroupID: taskGroupID},
+//		tcqueueevents.TaskCompletedMessage{RunID: 0, Status: status},
+//	)
+//	...
+//	err = q.Drain(handler)
+package pulsetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/streadway/amqp"
+	"github.com/taskcluster/pulse-go/pulse"
+)
+
+// Message returns the message published to the exchange of a binding, with
+// its routing key, and a payload marshaled as JSON. The words of the routing
+// key which the binding leaves out, * or #, are _, as Taskcluster services
+// publish for missing values.
+//
+// The payload must decode into the type of the messages of the exchange,
+// given by the NewPayloadObject method of the binding, without unknown
+// fields; the generated types do not say which fields are required, so it
+// is not checked that they are given.
+func Message(b pulse.Binding, payload interface{}) (amqp.Delivery, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return amqp.Delivery{}, fmt.Errorf("could not encode the message of %s: %w", b.ExchangeName(), err)
+	}
+	if err := Validate(b, body); err != nil {
+		return amqp.Delivery{}, err
+	}
+	return amqp.Delivery{
+		Exchange:    b.ExchangeName(),
+		RoutingKey:  RoutingKey(b),
+		ContentType: "application/json",
+		Body:        body,
+	}, nil
+}
+
+// Validate checks that the body of a message decodes into the type of the
+// messages of the exchange of a binding, without unknown fields.
+func Validate(b pulse.Binding, body []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(b.NewPayloadObject()); err != nil {
+		return fmt.Errorf("invalid message of %s: %w", b.ExchangeName(), err)
+	}
+	return nil
+}
+
+// RoutingKey returns the routing key of the messages of a binding, with _
+// for the words it leaves out.
+func RoutingKey(b pulse.Binding) string {
+	words := strings.Split(b.RoutingKey(), ".")
+	for i, word := range words {
+		if word == "*" || word == "#" {
+			words[i] = "_"
+		}
+	}
+	return strings.Join(words, ".")
+}
+
+// Matches returns whether a routing key matches the pattern of a binding,
+// where * stands for a word and # for any number of words, as AMQP topic
+// exchanges route messages.
+func Matches(pattern, routingKey string) bool {
+	return matchWords(strings.Split(pattern, "."), strings.Split(routingKey, "."))
+}
+
+func matchWords(pattern, words []string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case "#":
+			for i := 0; i <= len(words); i++ {
+				if matchWords(pattern[1:], words[i:]) {
+					return true
+				}
+			}
+			return false
+		case "*":
+		default:
+			if len(words) == 0 || words[0] != pattern[0] {
+				return false
+			}
+		}
+		if len(words) == 0 {
+			return false
+		}
+		pattern, words = pattern[1:], words[1:]
+	}
+	return len(words) == 0
+}
@@ -0,0 +1,125 @@
+package pulsetest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/pulse-go/pulse"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/pulse/consumer"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueueevents"
+)
+
+const (
+	taskID  = "fN1SbArXTPSVFNUvaOlinQ"
+	groupID = "dtwuF2n9S-i83G37V9eBuQ"
+)
+
+func completed(taskID string) (pulse.Binding, tcqueueevents.TaskCompletedMessage) {
+	var payload tcqueueevents.TaskCompletedMessage
+	payload.Status.TaskID = taskID
+	payload.Status.TaskGroupID = groupID
+	payload.Status.State = "completed"
+	payload.WorkerGroup = "wg"
+	payload.WorkerID = "wid"
+	return tcqueueevents.TaskCompleted{RoutingKeyKind: "primary", TaskID: taskID, RunID: "0", TaskGroupID: groupID}, payload
+}
+
+func TestMessage(t *testing.T) {
+	require := require.New(t)
+	d, err := Message(completed(taskID))
+	require.NoError(err)
+	require.Equal("exchange/taskcluster-queue/v1/task-completed", d.Exchange)
+	require.Equal("primary."+taskID+".0._._._._._."+groupID+"._", d.RoutingKey)
+	require.Equal("application/json", d.ContentType)
+	require.Contains(string(d.Body), `"workerGroup":"wg"`)
+
+	_, err = Message(tcqueueevents.TaskCompleted{}, map[string]interface{}{"status": map[string]interface{}{"taskId": taskID}, "color": "red"})
+	require.EqualError(err, `invalid message of exchange/taskcluster-queue/v1/task-completed: json: unknown field "color"`)
+	_, err = Message(tcqueueevents.TaskCompleted{}, map[string]interface{}{"runId": "zero"})
+	require.Error(err)
+}
+
+func TestMatches(t *testing.T) {
+	for _, c := range []struct {
+		pattern, routingKey string
+		matches             bool
+	}{
+		{"a.b", "a.b", true},
+		{"a.b", "a.c", false},
+		{"a.*", "a.b", true},
+		{"a.*", "a", false},
+		{"a.*", "a.b.c", false},
+		{"#", "", true},
+		{"#", "a.b", true},
+		{"a.#", "a", true},
+		{"a.#.c", "a.b.b.c", true},
+		{"a.#.c", "a.b.d", false},
+		{"*.#", "a.b", true},
+	} {
+		require.Equal(t, c.matches, Matches(c.pattern, c.routingKey), "%s %s", c.pattern, c.routingKey)
+	}
+}
+
+func TestBus(t *testing.T) {
+	require := require.New(t)
+	bus := NewBus()
+	task := bus.Bind(tcqueueevents.TaskCompleted{TaskID: taskID})
+	group := bus.Bind(tcqueueevents.TaskCompleted{TaskGroupID: groupID}, tcqueueevents.TaskFailed{TaskGroupID: groupID})
+
+	require.NoError(bus.Publish(completed(taskID)))
+	require.NoError(bus.Publish(completed("other")))
+	bus.Deliver(amqp.Delivery{Exchange: "exchange/taskcluster-queue/v1/task-pending", RoutingKey: "primary." + taskID})
+	require.Equal(1, task.Len())
+	require.Equal(2, group.Len())
+
+	var keys []string
+	handle := func(d amqp.Delivery) error {
+		keys = append(keys, d.RoutingKey)
+		return nil
+	}
+	require.NoError(group.Drain(handle))
+	require.Len(keys, 2)
+	require.Contains(keys[1], ".other.")
+	require.Equal(0, group.Len())
+
+	task.Close()
+	require.NoError(bus.Publish(completed(taskID)))
+	require.Equal(1, task.Len())
+
+	err := task.Drain(func(amqp.Delivery) error { return errors.New("oops") })
+	require.EqualError(err, "oops")
+	require.Equal(0, task.Len())
+}
+
+func TestQueueRun(t *testing.T) {
+	require := require.New(t)
+	bus := NewBus()
+	q := bus.Bind(tcqueueevents.TaskCompleted{})
+
+	handled := make(chan string)
+	done := make(chan error)
+	go func() {
+		done <- q.Run(context.Background(), func(d amqp.Delivery) error {
+			handled <- d.RoutingKey
+			if strings.Contains(d.RoutingKey, ".b.") {
+				return consumer.Stop
+			}
+			return nil
+		})
+	}()
+	for _, id := range []string{"a", "b"} {
+		b, payload := completed(id)
+		require.NoError(bus.Publish(b, payload))
+		<-handled
+	}
+	require.NoError(<-done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.Equal(context.DeadlineExceeded, q.Run(ctx, func(amqp.Delivery) error { return nil }))
+}
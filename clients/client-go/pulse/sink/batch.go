@@ -0,0 +1,131 @@
+package sink
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRecords = 1000
+	defaultMaxAge     = 5 * time.Minute
+)
+
+// An Uploader stores the batches of a Batch sink as objects, e.g. S3.
+type Uploader interface {
+	// Upload stores an object of JSON lines with the given name.
+	Upload(name string, body []byte) error
+}
+
+// BatchOptions configures a Batch.
+type BatchOptions struct {
+	Uploader Uploader
+	// MaxRecords is how many records a batch holds at most (default 1000).
+	MaxRecords int
+	// MaxAge is how long the records of a batch are held at most before it
+	// is uploaded (default 5m).
+	MaxAge time.Duration
+}
+
+// Batch is a Sink uploading the records in batches, as an object of JSON
+// lines per batch, named after the time its first record was received,
+// e.g. 2020-04-01/120000.000000000Z.jsonl.
+type Batch struct {
+	opts BatchOptions
+
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	count int
+	first time.Time
+	timer *time.Timer
+	// err is the error of the last upload made when a batch expired, which
+	// is returned by the next call.
+	err    error
+	closed bool
+}
+
+// NewBatch returns a Batch sink.
+func NewBatch(opts BatchOptions) (*Batch, error) {
+	if opts.Uploader == nil {
+		return nil, errors.New("an uploader is required")
+	}
+	if opts.MaxRecords == 0 {
+		opts.MaxRecords = defaultMaxRecords
+	}
+	if opts.MaxAge == 0 {
+		opts.MaxAge = defaultMaxAge
+	}
+	return &Batch{opts: opts}, nil
+}
+
+// Write implements Sink. The batch is uploaded once it is full; a batch
+// which cannot be uploaded is kept, and uploaded again with the next one.
+func (b *Batch) Write(r Record) error {
+	data, err := line(r)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return errors.New("the batch sink is closed")
+	}
+	if err := b.err; err != nil {
+		b.err = nil
+		return err
+	}
+	if b.count == 0 {
+		b.first = r.Received
+		b.timer = time.AfterFunc(b.opts.MaxAge, b.expire)
+	}
+	b.buf.Write(data)
+	if b.count++; b.count >= b.opts.MaxRecords {
+		return b.upload()
+	}
+	return nil
+}
+
+// expire uploads a batch once it is too old.
+func (b *Batch) expire() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.count > 0 && !b.closed {
+		b.err = b.upload()
+	}
+}
+
+// upload uploads the batch, and starts a new one if it succeeds.
+func (b *Batch) upload() error {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	name := b.first.UTC().Format("2006-01-02/150405.000000000Z") + ".jsonl"
+	if err := b.opts.Uploader.Upload(name, b.buf.Bytes()); err != nil {
+		// the records are kept, and uploaded with the next batch
+		b.timer = time.AfterFunc(b.opts.MaxAge, b.expire)
+		return fmt.Errorf("could not upload %s: %w", name, err)
+	}
+	b.buf.Reset()
+	b.count = 0
+	b.timer = nil
+	return nil
+}
+
+// Close implements Sink, uploading the last batch.
+func (b *Batch) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	if b.count > 0 {
+		return b.upload()
+	}
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package sink
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/stretchr/testify/require"
+)
+
+// uploader records the objects it uploads, failing while err is set.
+type uploader struct {
+	mu      sync.Mutex
+	err     error
+	objects map[string]string
+}
+
+func (u *uploader) Upload(name string, body []byte) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.err != nil {
+		return u.err
+	}
+	if u.objects == nil {
+		u.objects = map[string]string{}
+	}
+	u.objects[name] = string(body)
+	return nil
+}
+
+func (u *uploader) lines() map[string]int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	lines := map[string]int{}
+	for name, body := range u.objects {
+		lines[name] = strings.Count(body, "\n")
+	}
+	return lines
+}
+
+func TestBatch(t *testing.T) {
+	require := require.New(t)
+	u := &uploader{}
+	b, err := NewBatch(BatchOptions{Uploader: u, MaxRecords: 2, MaxAge: time.Hour})
+	require.NoError(err)
+
+	received := time.Date(2020, 4, 1, 12, 0, 0, 0, time.UTC)
+	record := func(offset time.Duration) Record {
+		return Record{Exchange: "exchange/e", Received: received.Add(offset), Payload: []byte(`{}`)}
+	}
+	require.NoError(b.Write(record(0)))
+	require.Empty(u.lines())
+	require.NoError(b.Write(record(time.Second)))
+	require.Equal(map[string]int{"2020-04-01/120000.000000000Z.jsonl": 2}, u.lines())
+
+	// a batch which cannot be uploaded is kept
+	u.err = errors.New("access denied")
+	require.NoError(b.Write(record(2 * time.Second)))
+	err = b.Write(record(3 * time.Second))
+	require.EqualError(err, "could not upload 2020-04-01/120002.000000000Z.jsonl: access denied")
+	u.err = nil
+	require.NoError(b.Close())
+	require.Equal(map[string]int{
+		"2020-04-01/120000.000000000Z.jsonl": 2,
+		"2020-04-01/120002.000000000Z.jsonl": 2,
+	}, u.lines())
+	require.Error(b.Write(record(0)))
+
+	_, err = NewBatch(BatchOptions{})
+	require.EqualError(err, "an uploader is required")
+}
+
+func TestBatchMaxAge(t *testing.T) {
+	require := require.New(t)
+	u := &uploader{}
+	b, err := NewBatch(BatchOptions{Uploader: u, MaxAge: 10 * time.Millisecond})
+	require.NoError(err)
+	defer b.Close()
+
+	require.NoError(b.Write(Record{Received: time.Date(2020, 4, 1, 12, 0, 0, 0, time.UTC), Payload: []byte(`{}`)}))
+	deadline := time.Now().Add(5 * time.Second)
+	for len(u.lines()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	require.Equal(map[string]int{"2020-04-01/120000.000000000Z.jsonl": 1}, u.lines())
+}
+
+// s3Client records the objects put.
+type s3Client struct {
+	s3iface.S3API
+	puts []string
+}
+
+func (c *s3Client) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	body, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.puts = append(c.puts, aws.StringValue(input.Bucket)+" "+aws.StringValue(input.Key)+" "+aws.StringValue(input.ContentType)+" "+string(body))
+	return &s3.PutObjectOutput{}, nil
+}
+
+func TestS3(t *testing.T) {
+	client := &s3Client{}
+	u := &S3{Client: client, Bucket: "archive", Prefix: "events/"}
+	require.NoError(t, u.Upload("2020-04-01/120000.000000000Z.jsonl", []byte("{}\n")))
+	require.Equal(t, []string{"archive events/2020-04-01/120000.000000000Z.jsonl application/x-ndjson {}\n"}, client.puts)
+}
@@ -0,0 +1,124 @@
+package sink
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileOptions configures a File.
+type FileOptions struct {
+	// Path is the file the records are appended to.
+	Path string
+	// MaxSize, if not 0, is the size in bytes past which the file is
+	// rotated.
+	MaxSize int64
+	// MaxAge, if not 0, is how long records are appended to the file
+	// before it is rotated.
+	MaxAge time.Duration
+}
+
+// File is a Sink appending a line per record to a file. Once rotated, the
+// file is renamed with the time it was opened at, e.g. events.jsonl to
+// events-20200401T120000.000Z.jsonl, and a new file is started.
+type File struct {
+	opts FileOptions
+
+	mu     sync.Mutex
+	f      *os.File
+	size   int64
+	opened time.Time
+}
+
+// NewFile opens a File sink, appending to the file if it exists.
+func NewFile(opts FileOptions) (*File, error) {
+	s := &File{opts: opts}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *File) open() error {
+	f, err := os.OpenFile(s.opts.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open %s: %w", s.opts.Path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("could not open %s: %w", s.opts.Path, err)
+	}
+	s.f, s.size, s.opened = f, info.Size(), now()
+	return nil
+}
+
+// now is replaced in tests.
+var now = time.Now
+
+// Write implements Sink.
+func (s *File) Write(r Record) error {
+	data, err := line(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return fmt.Errorf("%s is closed", s.opts.Path)
+	}
+	if s.size > 0 && s.full(len(data)) {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.f.Write(data)
+	s.size += int64(n)
+	return err
+}
+
+// full returns whether the file must be rotated before writing n bytes.
+func (s *File) full(n int) bool {
+	return (s.opts.MaxSize > 0 && s.size+int64(n) > s.opts.MaxSize) ||
+		(s.opts.MaxAge > 0 && now().Sub(s.opened) >= s.opts.MaxAge)
+}
+
+func (s *File) rotate() error {
+	if err := s.f.Close(); err != nil {
+		return err
+	}
+	s.f = nil
+	if err := os.Rename(s.opts.Path, rotatedPath(s.opts.Path, s.opened)); err != nil {
+		return fmt.Errorf("could not rotate %s: %w", s.opts.Path, err)
+	}
+	return s.open()
+}
+
+// rotatedPath returns the name of a file opened at the given time, once
+// rotated; a number is appended if files were rotated with the same time.
+func rotatedPath(path string, opened time.Time) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext) + "-" + opened.UTC().Format("20060102T150405.000Z")
+	rotated := base + ext
+	for i := 1; ; i++ {
+		if _, err := os.Stat(rotated); os.IsNotExist(err) {
+			return rotated
+		}
+		rotated = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+}
+
+// Close implements Sink.
+func (s *File) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
@@ -0,0 +1,35 @@
+package sink
+
+import (
+	"io"
+	"sync"
+)
+
+// Lines is a Sink writing a line per record to an io.Writer, e.g.
+// os.Stdout.
+type Lines struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewLines returns a Sink writing to w, which it does not close.
+func NewLines(w io.Writer) *Lines {
+	return &Lines{w: w}
+}
+
+// Write implements Sink.
+func (l *Lines) Write(r Record) error {
+	data, err := line(r)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.w.Write(data)
+	return err
+}
+
+// Close implements Sink.
+func (l *Lines) Close() error {
+	return nil
+}
@@ -0,0 +1,31 @@
+package sink
+
+import (
+	"bytes"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// S3 is an Uploader storing the batches in an S3 bucket. Google Cloud
+// Storage buckets can be used through its S3 compatible API, with a client
+// whose endpoint is https://storage.googleapis.com, and HMAC keys as AWS
+// credentials.
+type S3 struct {
+	Client s3iface.S3API
+	Bucket string
+	// Prefix is prepended to the names of the objects, e.g. events/.
+	Prefix string
+}
+
+// Upload implements Uploader.
+func (u *S3) Upload(name string, body []byte) error {
+	_, err := u.Client.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(u.Bucket),
+		Key:         aws.String(u.Prefix + name),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/x-ndjson"),
+	})
+	return err
+}
@@ -0,0 +1,93 @@
+// Package sink writes consumed Pulse messages as JSON lines to files, object
+// stores such as S3, or any io.Writer, e.g. to archive the state transitions
+// of tasks for later analysis:
+//
+//	archive, err := sink.NewFile(sink.FileOptions{Path: "events.jsonl", MaxAge: 24 * time.Hour})
+//	if err != nil {
+//		return err
+//	}
+//	defer archive.Close()
+//	return c.Run(ctx, sink.Handler(archive, sink.NewLines(os.Stdout)))
+//
+// Each line is a Record.
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/pulse/consumer"
+)
+
+// A Record is the JSON representation of a message.
+type Record struct {
+	Exchange   string `json:"exchange"`
+	RoutingKey string `json:"routingKey"`
+	// Routes are the additional routing keys of the message, such as the
+	// routes of a task.
+	Routes      []string  `json:"routes,omitempty"`
+	Redelivered bool      `json:"redelivered"`
+	Received    time.Time `json:"received"`
+	// Payload is the body of the message, or a JSON string of it if it is
+	// not JSON.
+	Payload json.RawMessage `json:"payload"`
+}
+
+// NewRecord returns the record of a message received now.
+func NewRecord(d amqp.Delivery) Record {
+	r := Record{
+		Exchange:    d.Exchange,
+		RoutingKey:  d.RoutingKey,
+		Redelivered: d.Redelivered,
+		Received:    time.Now().UTC(),
+		Payload:     d.Body,
+	}
+	if cc, ok := d.Headers["CC"].([]interface{}); ok {
+		for _, route := range cc {
+			if s, ok := route.(string); ok {
+				r.Routes = append(r.Routes, s)
+			}
+		}
+	}
+	if !json.Valid(d.Body) {
+		r.Payload, _ = json.Marshal(string(d.Body))
+	}
+	return r
+}
+
+// A Sink writes records somewhere. Its methods can be called concurrently.
+type Sink interface {
+	Write(Record) error
+	// Close writes the records which are buffered, if any, and releases
+	// the resources of the sink.
+	Close() error
+}
+
+// Handler returns a consumer.Handler which writes each message to the
+// sinks; it fails if one of them does.
+func Handler(sinks ...Sink) consumer.Handler {
+	return func(d amqp.Delivery) error {
+		return Write(NewRecord(d), sinks...)
+	}
+}
+
+// Write writes a record to each sink, and returns the first error.
+func Write(r Record, sinks ...Sink) error {
+	for _, s := range sinks {
+		if err := s.Write(r); err != nil {
+			return fmt.Errorf("could not write the message of %s to a sink: %w", r.Exchange, err)
+		}
+	}
+	return nil
+}
+
+// line returns a record as a line of JSON.
+func line(r Record) ([]byte, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
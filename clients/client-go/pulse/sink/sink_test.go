@@ -0,0 +1,99 @@
+package sink
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRecord(t *testing.T) {
+	require := require.New(t)
+	r := NewRecord(amqp.Delivery{
+		Exchange:   "exchange/taskcluster-queue/v1/task-completed",
+		RoutingKey: "primary.fN1SbArXTPSVFNUvaOlinQ",
+		Headers:    amqp.Table{"CC": []interface{}{"route.index.project.app.latest"}},
+		Body:       []byte(`{"runId": 0}`),
+	})
+	require.Equal([]string{"route.index.project.app.latest"}, r.Routes)
+	require.Equal(`{"runId": 0}`, string(r.Payload))
+	require.WithinDuration(time.Now(), r.Received, time.Minute)
+
+	r = NewRecord(amqp.Delivery{Body: []byte("not json")})
+	require.Equal(`"not json"`, string(r.Payload))
+	require.Nil(r.Routes)
+}
+
+func TestHandler(t *testing.T) {
+	require := require.New(t)
+	out := &bytes.Buffer{}
+	handle := Handler(NewLines(out))
+	require.NoError(handle(amqp.Delivery{Exchange: "exchange/e", RoutingKey: "a", Body: []byte(`{}`)}))
+	require.NoError(handle(amqp.Delivery{Exchange: "exchange/e", RoutingKey: "b", Redelivered: true, Body: []byte(`[1]`)}))
+	require.Regexp(`^{"exchange":"exchange/e","routingKey":"a","redelivered":false,"received":"[^"]+","payload":{}}
+{"exchange":"exchange/e","routingKey":"b","redelivered":true,"received":"[^"]+","payload":\[1\]}
+$`, out.String())
+
+	err := Handler(NewLines(out), &failing{})(amqp.Delivery{Exchange: "exchange/e", Body: []byte(`{}`)})
+	require.EqualError(err, "could not write the message of exchange/e to a sink: disk full")
+}
+
+type failing struct{}
+
+func (failing) Write(Record) error { return errors.New("disk full") }
+func (failing) Close() error       { return nil }
+
+func TestFile(t *testing.T) {
+	require := require.New(t)
+	dir, err := ioutil.TempDir("", "sink")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	clock := time.Date(2020, 4, 1, 12, 0, 0, 0, time.UTC)
+	oldNow := now
+	now = func() time.Time { return clock }
+	defer func() { now = oldNow }()
+
+	path := filepath.Join(dir, "events.jsonl")
+	r := Record{Exchange: "exchange/e", Received: clock, Payload: []byte(`{}`)}
+	size := int64(len(mustLine(t, r)))
+	s, err := NewFile(FileOptions{Path: path, MaxSize: 2 * size, MaxAge: time.Hour})
+	require.NoError(err)
+
+	// rotated by size, after two records
+	for i := 0; i < 3; i++ {
+		require.NoError(s.Write(r))
+	}
+	// rotated by age
+	clock = clock.Add(time.Hour)
+	require.NoError(s.Write(r))
+	require.NoError(s.Close())
+	require.Error(s.Write(r))
+
+	files := map[string]int{}
+	infos, err := ioutil.ReadDir(dir)
+	require.NoError(err)
+	for _, info := range infos {
+		data, err := ioutil.ReadFile(filepath.Join(dir, info.Name()))
+		require.NoError(err)
+		files[info.Name()] = strings.Count(string(data), "\n")
+	}
+	require.Equal(map[string]int{
+		"events-20200401T120000.000Z.jsonl": 2,
+		// opened at the same time as the first file
+		"events-20200401T120000.000Z-1.jsonl": 1,
+		"events.jsonl":                        1,
+	}, files)
+}
+
+func mustLine(t *testing.T, r Record) []byte {
+	data, err := line(r)
+	require.NoError(t, err)
+	return data
+}
@@ -0,0 +1,60 @@
+package tracker
+
+import (
+	"sync"
+)
+
+// A Store keeps the latest known state of the tasks of a Tracker. Stores
+// backed by databases or caches shared by several processes can implement
+// it; the package provides NewMemoryStore.
+type Store interface {
+	// Task returns the state of a task, and whether it is known.
+	Task(taskID string) (Task, bool, error)
+	// Put records the state of a task, replacing the previous one.
+	Put(t Task) error
+	// Group returns the state of the known tasks of a group, in any order.
+	Group(taskGroupID string) ([]Task, error)
+}
+
+type memoryStore struct {
+	mu     sync.RWMutex
+	tasks  map[string]Task
+	groups map[string]map[string]struct{}
+}
+
+// NewMemoryStore returns a Store which keeps the state of the tasks in
+// memory.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		tasks:  make(map[string]Task),
+		groups: make(map[string]map[string]struct{}),
+	}
+}
+
+func (s *memoryStore) Task(taskID string) (Task, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.tasks[taskID]
+	return t, ok, nil
+}
+
+func (s *memoryStore) Put(t Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks[t.TaskID] = t
+	if s.groups[t.TaskGroupID] == nil {
+		s.groups[t.TaskGroupID] = make(map[string]struct{})
+	}
+	s.groups[t.TaskGroupID][t.TaskID] = struct{}{}
+	return nil
+}
+
+func (s *memoryStore) Group(taskGroupID string) ([]Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tasks := make([]Task, 0, len(s.groups[taskGroupID]))
+	for taskID := range s.groups[taskGroupID] {
+		tasks = append(tasks, s.tasks[taskID])
+	}
+	return tasks, nil
+}
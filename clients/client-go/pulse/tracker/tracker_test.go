@@ -0,0 +1,174 @@
+package tracker
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+)
+
+const groupID = "dtwuF2n9S-i83G37V9eBuQ"
+
+// message returns a message of the queue about a state transition of a task
+// of the group, with the given number of runs, the last of them resolved
+// with reason if it is not empty.
+func message(event, taskID, state string, runs int, reason string) amqp.Delivery {
+	runsJSON := ""
+	for i := 0; i < runs; i++ {
+		if i > 0 {
+			runsJSON += ", "
+		}
+		run := fmt.Sprintf(`{"runId": %d, "scheduled": "2020-04-01T12:00:0%dZ"`, i, i)
+		if i == runs-1 && reason != "" {
+			run += fmt.Sprintf(`, "reasonResolved": %q, "resolved": "2020-04-01T12:01:0%dZ"`, reason, i)
+		}
+		runsJSON += run + "}"
+	}
+	return amqp.Delivery{
+		Exchange: "exchange/taskcluster-queue/v1/task-" + event,
+		Body:     []byte(fmt.Sprintf(`{"status": {"taskId": %q, "taskGroupId": %q, "state": %q, "runs": [%s]}}`, taskID, groupID, state, runsJSON)),
+	}
+}
+
+func TestTracker(t *testing.T) {
+	require := require.New(t)
+	tr := New(nil)
+
+	var tasks, groups, resolved []string
+	tr.OnTask(func(t Task) {
+		tasks = append(tasks, fmt.Sprintf("%s %s %d %s", t.TaskID, t.State, t.Runs, t.Reason))
+	})
+	tr.OnGroup(groupID, func(g Group) {
+		groups = append(groups, fmt.Sprintf("%d/%d", g.Tasks()-g.Unresolved(), g.Tasks()))
+	})
+	tr.OnGroup("other", func(g Group) {
+		t.Errorf("unexpected change of group %s", g.TaskGroupID)
+	})
+	cancel := tr.OnGroupResolved("", func(g Group) {
+		resolved = append(resolved, fmt.Sprintf("%s %d failed", g.TaskGroupID, g.Failed()))
+	})
+
+	for _, d := range []amqp.Delivery{
+		message("defined", "a", "unscheduled", 0, ""),
+		message("pending", "a", "pending", 1, ""),
+		message("pending", "b", "pending", 1, ""),
+		message("running", "a", "running", 1, ""),
+		// delivered again
+		message("pending", "a", "pending", 1, ""),
+		message("completed", "a", "completed", 1, "completed"),
+		// retried
+		message("exception", "b", "pending", 2, "worker-shutdown"),
+		message("exception", "b", "exception", 2, "deadline-exceeded"),
+		{Exchange: "exchange/taskcluster-queue/v1/task-group-resolved", Body: []byte(`{"taskGroupId": "` + groupID + `"}`)},
+		{Exchange: "exchange/taskcluster-queue/v1/artifact-created", Body: []byte(`{}`)},
+	} {
+		require.NoError(tr.Handle(d))
+	}
+	require.Equal([]string{
+		"a unscheduled 0 ",
+		"a pending 1 ",
+		"b pending 1 ",
+		"a running 1 ",
+		"a completed 1 completed",
+		"b pending 2 worker-shutdown",
+		"b exception 2 deadline-exceeded",
+	}, tasks)
+	require.Equal([]string{"0/1", "0/1", "0/2", "0/2", "1/2", "1/2", "2/2"}, groups)
+	require.Equal([]string{groupID + " 1 failed"}, resolved)
+
+	task, ok, err := tr.Task("b")
+	require.NoError(err)
+	require.True(ok)
+	require.Equal("2020-04-01T12:01:01Z", task.Updated.Format("2006-01-02T15:04:05Z07:00"))
+	g, err := tr.Group(groupID)
+	require.NoError(err)
+	require.Equal(map[string]int{"completed": 1, "exception": 1}, g.Counts)
+	require.True(g.Resolved)
+
+	// a rerun reopens the group
+	cancel()
+	require.NoError(tr.Handle(message("pending", "a", "pending", 2, "")))
+	g, err = tr.Group(groupID)
+	require.NoError(err)
+	require.False(g.Resolved)
+	require.NoError(tr.Handle(message("completed", "a", "completed", 2, "completed")))
+	require.Len(resolved, 1)
+
+	err = tr.Handle(amqp.Delivery{Exchange: "exchange/taskcluster-queue/v1/task-failed", Body: []byte("not json")})
+	require.Contains(err.Error(), "could not decode the message of exchange/taskcluster-queue/v1/task-failed")
+}
+
+func TestGroupResolvedMessage(t *testing.T) {
+	require := require.New(t)
+	tr := New(nil)
+	var resolved int
+	tr.OnGroupResolved(groupID, func(g Group) {
+		resolved++
+	})
+	require.NoError(tr.Handle(message("pending", "a", "pending", 1, "")))
+	// the queue knows better, e.g. if the tracker missed messages
+	d := amqp.Delivery{Exchange: "exchange/taskcluster-queue/v1/task-group-resolved", Body: []byte(`{"taskGroupId": "` + groupID + `"}`)}
+	require.NoError(tr.Handle(d))
+	require.NoError(tr.Handle(d))
+	require.Equal(1, resolved)
+	g, err := tr.Group(groupID)
+	require.NoError(err)
+	require.True(g.Resolved)
+	require.Equal(1, g.Unresolved())
+}
+
+type fakeQueue struct {
+	pages []tcqueue.ListTaskGroupResponse
+	err   error
+}
+
+func (q *fakeQueue) ListTaskGroup(taskGroupID, continuationToken, limit string) (*tcqueue.ListTaskGroupResponse, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	i := 0
+	if continuationToken != "" {
+		fmt.Sscan(continuationToken, &i)
+	}
+	return &q.pages[i], nil
+}
+
+func (q *fakeQueue) Status(taskID string) (*tcqueue.TaskStatusResponse, error) {
+	return nil, errors.New("unexpected call")
+}
+
+func status(taskID, state string) tcqueue.TaskDefinitionAndStatus {
+	var t tcqueue.TaskDefinitionAndStatus
+	t.Status.TaskID = taskID
+	t.Status.TaskGroupID = groupID
+	t.Status.State = state
+	return t
+}
+
+func TestLoad(t *testing.T) {
+	require := require.New(t)
+	store := NewMemoryStore()
+	tr := New(store)
+	var resolved []Group
+	tr.OnGroupResolved(groupID, func(g Group) {
+		resolved = append(resolved, g)
+	})
+	require.NoError(tr.Load(&fakeQueue{pages: []tcqueue.ListTaskGroupResponse{
+		{Tasks: []tcqueue.TaskDefinitionAndStatus{status("a", "completed")}, ContinuationToken: "1"},
+		{Tasks: []tcqueue.TaskDefinitionAndStatus{status("b", "failed")}},
+	}}, groupID))
+	require.Len(resolved, 1)
+	require.Equal(map[string]int{"completed": 1, "failed": 1}, resolved[0].Counts)
+
+	// the tasks of the store are counted by new trackers
+	g, err := New(store).Group(groupID)
+	require.NoError(err)
+	require.Equal(2, g.Tasks())
+	require.True(g.Resolved)
+
+	err = tr.Load(&fakeQueue{err: errors.New("boom")}, groupID)
+	require.EqualError(err, "could not fetch tasks for group "+groupID+": boom")
+}
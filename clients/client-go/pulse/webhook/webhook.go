@@ -0,0 +1,216 @@
+// Package webhook forwards Pulse messages to HTTP endpoints, bridging Pulse
+// to the systems which only receive webhooks.
+//
+// The JSON payload of each message is POSTed to each endpoint, with the
+// headers
+//
+//	X-Pulse-Exchange:    the exchange of the message
+//	X-Pulse-Routing-Key: its routing key
+//	X-Pulse-Signature:   sha256=<hex>, the HMAC-SHA256 of the body with the
+//	                     secret, if there is one
+//
+// Forward is a consumer.Handler:
+//
+//	f, err := webhook.New(webhook.Options{
+//		Endpoints: []string{"https://ci.example.com/hooks/taskcluster"},
+//		Secret:    os.Getenv("WEBHOOK_SECRET"),
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	return c.Run(ctx, f.Forward)
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	defaultAttempts = 3
+	defaultBackoff  = time.Second
+	defaultTimeout  = 30 * time.Second
+
+	// SignatureHeader is the header of the signature of the requests.
+	SignatureHeader = "X-Pulse-Signature"
+)
+
+// Options configures a Forwarder.
+type Options struct {
+	// Endpoints are the http:// or https:// URLs the messages are POSTed
+	// to.
+	Endpoints []string
+	// Secret, if set, is the key of the signatures of the requests.
+	Secret string
+	// Attempts is how many times a request is made before giving up on an
+	// endpoint (default 3); requests are retried when the endpoint cannot
+	// be reached, or responds with a 429 or 5xx status.
+	Attempts int
+	// Backoff is the delay before the second attempt, which doubles with
+	// each attempt (default 1s).
+	Backoff time.Duration
+	// Timeout is how long a request can take (default 30s).
+	Timeout time.Duration
+	// Client, if set, makes the requests instead of a new http.Client.
+	Client *http.Client
+	// Log, if set, is called with the outcome of each attempt.
+	Log func(Attempt)
+}
+
+// An Attempt is an attempt at delivering a message to an endpoint.
+type Attempt struct {
+	Endpoint   string
+	Exchange   string
+	RoutingKey string
+	// Number is 1 for the first attempt.
+	Number int
+	// Status is the status of the response, or 0 if there was none.
+	Status   int
+	Duration time.Duration
+	// Err is nil if the message was delivered.
+	Err error
+}
+
+func (a Attempt) String() string {
+	outcome := "delivered"
+	if a.Err != nil {
+		outcome = a.Err.Error()
+	}
+	return fmt.Sprintf("%s %s -> %s (attempt %d, %s): %s", a.Exchange, a.RoutingKey, a.Endpoint, a.Number, a.Duration.Round(time.Millisecond), outcome)
+}
+
+// A Forwarder POSTs messages to the endpoints of its options.
+type Forwarder struct {
+	opts Options
+}
+
+// New returns a Forwarder, once its options are checked.
+func New(opts Options) (*Forwarder, error) {
+	if len(opts.Endpoints) == 0 {
+		return nil, errors.New("at least one endpoint is required")
+	}
+	for _, endpoint := range opts.Endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid endpoint %q: it must be an http:// or https:// URL", endpoint)
+		}
+	}
+	if opts.Attempts == 0 {
+		opts.Attempts = defaultAttempts
+	}
+	if opts.Backoff == 0 {
+		opts.Backoff = defaultBackoff
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{}
+	}
+	return &Forwarder{opts: opts}, nil
+}
+
+// Forward POSTs the payload of a message to each endpoint, retrying as the
+// options say. It returns an error if the message could not be delivered
+// to one of them, once it is delivered to the others; retrying the message
+// delivers it again to all of them.
+func (f *Forwarder) Forward(d amqp.Delivery) error {
+	var failed []string
+	for _, endpoint := range f.opts.Endpoints {
+		if err := f.deliver(endpoint, d); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", endpoint, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("could not deliver the message of %s with routing key %s to %s", d.Exchange, d.RoutingKey, strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// deliver POSTs a message to an endpoint, until it succeeds, fails
+// permanently, or the attempts are exhausted.
+func (f *Forwarder) deliver(endpoint string, d amqp.Delivery) error {
+	delay := f.opts.Backoff
+	for number := 1; ; number++ {
+		start := time.Now()
+		status, err := f.post(endpoint, d)
+		if f.opts.Log != nil {
+			f.opts.Log(Attempt{
+				Endpoint:   endpoint,
+				Exchange:   d.Exchange,
+				RoutingKey: d.RoutingKey,
+				Number:     number,
+				Status:     status,
+				Duration:   time.Since(start),
+				Err:        err,
+			})
+		}
+		if err == nil {
+			return nil
+		}
+		if number >= f.opts.Attempts || !retryable(status) {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// retryable returns whether a request with a response of the given status,
+// or none for 0, can succeed if made again.
+func retryable(status int) bool {
+	return status == 0 || status == http.StatusTooManyRequests || status >= 500
+}
+
+func (f *Forwarder) post(endpoint string, d amqp.Delivery) (int, error) {
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(d.Body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Pulse-Exchange", d.Exchange)
+	req.Header.Set("X-Pulse-Routing-Key", d.RoutingKey)
+	if f.opts.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(f.opts.Secret, d.Body))
+	}
+	client := *f.opts.Client
+	client.Timeout = f.opts.Timeout
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	// the body is read so that the connection is reused
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(res.Body, 1<<20))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("the endpoint responded with %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+// Sign returns the signature of a body with a secret, as sent in the
+// X-Pulse-Signature header.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns whether a signature, from the X-Pulse-Signature header of a
+// request, is that of its body with the secret, for the receivers of the
+// requests.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/require"
+)
+
+var delivery = amqp.Delivery{
+	Exchange:   "exchange/taskcluster-queue/v1/task-completed",
+	RoutingKey: "primary.fN1SbArXTPSVFNUvaOlinQ",
+	Body:       []byte(`{"status": {"taskId": "fN1SbArXTPSVFNUvaOlinQ"}}`),
+}
+
+// endpoint records the requests it receives, and responds with the given
+// statuses in turn, then 200.
+type endpoint struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (e *endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.requests = append(e.requests, r)
+	e.bodies = append(e.bodies, string(body))
+	if len(e.statuses) > 0 {
+		w.WriteHeader(e.statuses[0])
+		e.statuses = e.statuses[1:]
+	}
+}
+
+func TestForward(t *testing.T) {
+	require := require.New(t)
+	a, b := &endpoint{}, &endpoint{statuses: []int{503, 429}}
+	serverA, serverB := httptest.NewServer(a), httptest.NewServer(b)
+	defer serverA.Close()
+	defer serverB.Close()
+
+	var attempts []string
+	f, err := New(Options{
+		Endpoints: []string{serverA.URL, serverB.URL + "/hook"},
+		Secret:    "s3cr3t",
+		Backoff:   time.Millisecond,
+		Log: func(a Attempt) {
+			attempts = append(attempts, fmt.Sprintf("%s %d %d %v", strings.TrimPrefix(a.Endpoint, serverB.URL), a.Number, a.Status, a.Err))
+		},
+	})
+	require.NoError(err)
+	require.NoError(f.Forward(delivery))
+
+	require.Len(a.requests, 1)
+	r := a.requests[0]
+	require.Equal("POST", r.Method)
+	require.Equal("application/json", r.Header.Get("Content-Type"))
+	require.Equal(delivery.Exchange, r.Header.Get("X-Pulse-Exchange"))
+	require.Equal(delivery.RoutingKey, r.Header.Get("X-Pulse-Routing-Key"))
+	require.True(Verify("s3cr3t", []byte(a.bodies[0]), r.Header.Get(SignatureHeader)))
+	require.False(Verify("other", []byte(a.bodies[0]), r.Header.Get(SignatureHeader)))
+	require.Equal(string(delivery.Body), a.bodies[0])
+
+	require.Len(b.requests, 3)
+	require.Equal("/hook", b.requests[2].URL.Path)
+	require.Equal([]string{
+		serverA.URL + " 1 200 <nil>",
+		"/hook 1 503 the endpoint responded with 503 Service Unavailable",
+		"/hook 2 429 the endpoint responded with 429 Too Many Requests",
+		"/hook 3 200 <nil>",
+	}, attempts)
+}
+
+func TestForwardFailure(t *testing.T) {
+	require := require.New(t)
+	e := &endpoint{statuses: []int{500, 500, 500, 400}}
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	var statuses []int
+	f, err := New(Options{
+		Endpoints: []string{server.URL},
+		Backoff:   time.Millisecond,
+		Log: func(a Attempt) {
+			statuses = append(statuses, a.Status)
+		},
+	})
+	require.NoError(err)
+	err = f.Forward(delivery)
+	require.EqualError(err, "could not deliver the message of exchange/taskcluster-queue/v1/task-completed with routing key primary.fN1SbArXTPSVFNUvaOlinQ to "+
+		server.URL+": the endpoint responded with 500 Internal Server Error")
+	require.Equal([]int{500, 500, 500}, statuses)
+	require.Empty(e.requests[0].Header.Get(SignatureHeader))
+
+	// client errors are not retried
+	statuses = nil
+	require.Error(f.Forward(delivery))
+	require.Equal([]int{400}, statuses)
+}
+
+func TestNewErrors(t *testing.T) {
+	_, err := New(Options{})
+	require.EqualError(t, err, "at least one endpoint is required")
+	_, err = New(Options{Endpoints: []string{"ftp://example.com"}})
+	require.EqualError(t, err, `invalid endpoint "ftp://example.com": it must be an http:// or https:// URL`)
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{}' | openssl dgst -sha256 -hmac key
+	require.Equal(t, "sha256=a777724d943eb48dc69bca8a4a6d57a04db3f9ec7e1de4e581e860265bdf3032", Sign("key", []byte("{}")))
+}
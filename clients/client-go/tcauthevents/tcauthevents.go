@@ -41,9 +41,16 @@
 //
 // In addition, this means that you will also get objects in your callback method like *queueevents.TaskDefinedMessage
 // rather than just interface{}.
+//
+// Conversely, the routing key of a message can be parsed into the fields of a binding:
+//
+//  var binding queueevents.TaskDefined
+//  err := binding.ParseRoutingKey(delivery.RoutingKey)
+//  fmt.Println(binding.TaskID, binding.WorkerType)
 package tcauthevents
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 )
@@ -67,6 +74,10 @@ func (binding ClientCreated) NewPayloadObject() interface{} {
 	return new(ClientMessage)
 }
 
+func (binding *ClientCreated) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 // Message that a new client has been updated.
 //
 // See #clientUpdated
@@ -86,6 +97,10 @@ func (binding ClientUpdated) NewPayloadObject() interface{} {
 	return new(ClientMessage)
 }
 
+func (binding *ClientUpdated) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 // Message that a new client has been deleted.
 //
 // See #clientDeleted
@@ -105,6 +120,10 @@ func (binding ClientDeleted) NewPayloadObject() interface{} {
 	return new(ClientMessage)
 }
 
+func (binding *ClientDeleted) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 // Message that a new role has been created.
 //
 // See #roleCreated
@@ -124,6 +143,10 @@ func (binding RoleCreated) NewPayloadObject() interface{} {
 	return new(RoleMessage)
 }
 
+func (binding *RoleCreated) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 // Message that a new role has been updated.
 //
 // See #roleUpdated
@@ -143,6 +166,10 @@ func (binding RoleUpdated) NewPayloadObject() interface{} {
 	return new(RoleMessage)
 }
 
+func (binding *RoleUpdated) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 // Message that a new role has been deleted.
 //
 // See #roleDeleted
@@ -162,6 +189,10 @@ func (binding RoleDeleted) NewPayloadObject() interface{} {
 	return new(RoleMessage)
 }
 
+func (binding *RoleDeleted) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 func generateRoutingKey(x interface{}) string {
 	val := reflect.ValueOf(x).Elem()
 	p := make([]string, 0, val.NumField())
@@ -179,3 +210,33 @@ func generateRoutingKey(x interface{}) string {
 	}
 	return strings.Join(p, ".")
 }
+
+func parseRoutingKey(routingKey string, x interface{}) error {
+	val := reflect.ValueOf(x).Elem()
+	words := strings.Split(routingKey, ".")
+	singleWords, multipleWords := 0, false
+	for i := 0; i < val.NumField(); i++ {
+		switch val.Type().Field(i).Tag.Get("mwords") {
+		case "*":
+			singleWords++
+		case "#":
+			multipleWords = true
+		}
+	}
+	if len(words) < singleWords || (!multipleWords && len(words) > singleWords) {
+		return fmt.Errorf("routing key %q does not match %s, which has %d words", routingKey, val.Type().Name(), singleWords)
+	}
+	w := 0
+	for i := 0; i < val.NumField(); i++ {
+		switch val.Type().Field(i).Tag.Get("mwords") {
+		case "*":
+			val.Field(i).SetString(words[w])
+			w++
+		case "#":
+			n := len(words) - singleWords
+			val.Field(i).SetString(strings.Join(words[w:w+n], "."))
+			w += n
+		}
+	}
+	return nil
+}
@@ -39,9 +39,16 @@
 //
 // In addition, this means that you will also get objects in your callback method like *queueevents.TaskDefinedMessage
 // rather than just interface{}.
+//
+// Conversely, the routing key of a message can be parsed into the fields of a binding:
+//
+//  var binding queueevents.TaskDefined
+//  err := binding.ParseRoutingKey(delivery.RoutingKey)
+//  fmt.Println(binding.TaskID, binding.WorkerType)
 package tcgithubevents
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 )
@@ -70,6 +77,10 @@ func (binding PullRequest) NewPayloadObject() interface{} {
 	return new(GitHubPullRequestMessage)
 }
 
+func (binding *PullRequest) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 // When a GitHub push event is posted it will be broadcast on this
 // exchange with the designated `organization` and `repository`
 // in the routing-key along with event specific metadata in the payload.
@@ -93,6 +104,10 @@ func (binding Push) NewPayloadObject() interface{} {
 	return new(GitHubPushMessage)
 }
 
+func (binding *Push) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 // When a GitHub release event is posted it will be broadcast on this
 // exchange with the designated `organization` and `repository`
 // in the routing-key along with event specific metadata in the payload.
@@ -116,6 +131,10 @@ func (binding Release) NewPayloadObject() interface{} {
 	return new(GitHubReleaseMessage)
 }
 
+func (binding *Release) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 // supposed to signal that taskCreate API has been called for every task in the task group
 // for this particular repo and this particular organization
 // currently used for creating initial status indicators in GitHub UI using Statuses API.
@@ -142,6 +161,10 @@ func (binding TaskGroupCreationRequested) NewPayloadObject() interface{} {
 	return new(TaskGroupDefinedCreateStatus)
 }
 
+func (binding *TaskGroupCreationRequested) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 func generateRoutingKey(x interface{}) string {
 	val := reflect.ValueOf(x).Elem()
 	p := make([]string, 0, val.NumField())
@@ -159,3 +182,33 @@ func generateRoutingKey(x interface{}) string {
 	}
 	return strings.Join(p, ".")
 }
+
+func parseRoutingKey(routingKey string, x interface{}) error {
+	val := reflect.ValueOf(x).Elem()
+	words := strings.Split(routingKey, ".")
+	singleWords, multipleWords := 0, false
+	for i := 0; i < val.NumField(); i++ {
+		switch val.Type().Field(i).Tag.Get("mwords") {
+		case "*":
+			singleWords++
+		case "#":
+			multipleWords = true
+		}
+	}
+	if len(words) < singleWords || (!multipleWords && len(words) > singleWords) {
+		return fmt.Errorf("routing key %q does not match %s, which has %d words", routingKey, val.Type().Name(), singleWords)
+	}
+	w := 0
+	for i := 0; i < val.NumField(); i++ {
+		switch val.Type().Field(i).Tag.Get("mwords") {
+		case "*":
+			val.Field(i).SetString(words[w])
+			w++
+		case "#":
+			n := len(words) - singleWords
+			val.Field(i).SetString(strings.Join(words[w:w+n], "."))
+			w += n
+		}
+	}
+	return nil
+}
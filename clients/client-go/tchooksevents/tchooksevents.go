@@ -34,9 +34,16 @@
 //
 // In addition, this means that you will also get objects in your callback method like *queueevents.TaskDefinedMessage
 // rather than just interface{}.
+//
+// Conversely, the routing key of a message can be parsed into the fields of a binding:
+//
+//  var binding queueevents.TaskDefined
+//  err := binding.ParseRoutingKey(delivery.RoutingKey)
+//  fmt.Println(binding.TaskID, binding.WorkerType)
 package tchooksevents
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 )
@@ -60,6 +67,10 @@ func (binding HookCreated) NewPayloadObject() interface{} {
 	return new(HookChangedMessage)
 }
 
+func (binding *HookCreated) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 // Whenever the api receives a request to update apulse based hook, a message is posted to this exchange andthe receiver updates the listener associated with that hook.
 //
 // See #hookUpdated
@@ -79,6 +90,10 @@ func (binding HookUpdated) NewPayloadObject() interface{} {
 	return new(HookChangedMessage)
 }
 
+func (binding *HookUpdated) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 // Whenever the api receives a request to delete apulse based hook, a message is posted to this exchange andthe receiver deletes the listener associated with that hook.
 //
 // See #hookDeleted
@@ -98,6 +113,10 @@ func (binding HookDeleted) NewPayloadObject() interface{} {
 	return new(HookChangedMessage)
 }
 
+func (binding *HookDeleted) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 func generateRoutingKey(x interface{}) string {
 	val := reflect.ValueOf(x).Elem()
 	p := make([]string, 0, val.NumField())
@@ -115,3 +134,33 @@ func generateRoutingKey(x interface{}) string {
 	}
 	return strings.Join(p, ".")
 }
+
+func parseRoutingKey(routingKey string, x interface{}) error {
+	val := reflect.ValueOf(x).Elem()
+	words := strings.Split(routingKey, ".")
+	singleWords, multipleWords := 0, false
+	for i := 0; i < val.NumField(); i++ {
+		switch val.Type().Field(i).Tag.Get("mwords") {
+		case "*":
+			singleWords++
+		case "#":
+			multipleWords = true
+		}
+	}
+	if len(words) < singleWords || (!multipleWords && len(words) > singleWords) {
+		return fmt.Errorf("routing key %q does not match %s, which has %d words", routingKey, val.Type().Name(), singleWords)
+	}
+	w := 0
+	for i := 0; i < val.NumField(); i++ {
+		switch val.Type().Field(i).Tag.Get("mwords") {
+		case "*":
+			val.Field(i).SetString(words[w])
+			w++
+		case "#":
+			n := len(words) - singleWords
+			val.Field(i).SetString(strings.Join(words[w:w+n], "."))
+			w += n
+		}
+	}
+	return nil
+}
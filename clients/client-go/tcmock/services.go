@@ -0,0 +1,58 @@
+package tcmock
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcauth"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcindex"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+)
+
+// Task registers the definition of a task, returned by tcqueue.Queue.Task.
+func (s *Server) Task(taskID string, definition tcqueue.TaskDefinitionResponse) {
+	s.Respond("GET", "/api/queue/v1/task/"+taskID, http.StatusOK, definition)
+}
+
+// TaskStatus registers the status of a task, returned by
+// tcqueue.Queue.Status.
+func (s *Server) TaskStatus(status tcqueue.TaskStatusStructure) {
+	s.Respond("GET", "/api/queue/v1/task/"+status.TaskID+"/status", http.StatusOK, tcqueue.TaskStatusResponse{Status: status})
+}
+
+// TaskGroup registers the tasks of a group, listed by
+// tcqueue.Queue.ListTaskGroup in pages of the given size, or in one page if
+// it is 0.
+func (s *Server) TaskGroup(taskGroupID string, pageSize int, tasks ...tcqueue.TaskDefinitionAndStatus) {
+	items := make([]interface{}, len(tasks))
+	for i, t := range tasks {
+		items[i] = t
+	}
+	s.paginate("GET", "/api/queue/v1/task-group/"+taskGroupID+"/list", "tasks", pageSize, map[string]interface{}{"taskGroupId": taskGroupID}, items)
+}
+
+// Artifact registers the content of an artifact of the latest run of a task,
+// or of the given run if it is not negative; the name is that of the
+// artifact, e.g. public/logs/live.log, not escaped.
+func (s *Server) Artifact(taskID string, runID int, name, contentType string, content []byte) {
+	path := "/api/queue/v1/task/" + taskID + "/artifacts/" + name
+	if runID >= 0 {
+		path = "/api/queue/v1/task/" + taskID + "/runs/" + strconv.Itoa(runID) + "/artifacts/" + name
+	}
+	s.Handle("GET", path, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(content)
+	}))
+}
+
+// CurrentScopes registers the scopes of the credentials of the requests,
+// returned by tcauth.Auth.CurrentScopes.
+func (s *Server) CurrentScopes(scopes ...string) {
+	s.Respond("GET", "/api/auth/v1/scopes/current", http.StatusOK, tcauth.SetOfScopes{Scopes: scopes})
+}
+
+// IndexedTask registers the task indexed at a namespace, returned by
+// tcindex.Index.FindTask.
+func (s *Server) IndexedTask(task tcindex.IndexedTaskResponse) {
+	s.Respond("GET", "/api/index/v1/task/"+task.Namespace, http.StatusOK, task)
+}
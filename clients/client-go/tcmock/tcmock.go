@@ -0,0 +1,255 @@
+// Package tcmock provides a fake Taskcluster deployment for tests: an HTTP
+// server answering API calls with canned or scripted responses, which
+// records the requests it receives, and can be slowed down or made to fail.
+//
+//	s := tcmock.NewServer()
+//	defer s.Close()
+//	s.TaskStatus(tcqueue.TaskStatusStructure{TaskID: taskID, State: "completed"})
+//	s.Fail("GET", "/api/queue/v1/task/"+taskID+"/status", 500)
+//
+//	q := tcqueue.New(nil, s.URL)
+//	status, err := q.Status(taskID)
+//
+// Requests without a handler get a 404 response, as Taskcluster services
+// respond to unknown resources.
+package tcmock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Request is a request received by a Server.
+type Request struct {
+	Method string
+	// Path is the path of the URL, e.g. /api/queue/v1/task/<taskId>/status.
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// JSON decodes the body of the request into v.
+func (r Request) JSON(v interface{}) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+type route struct {
+	method  string
+	path    string
+	handler http.Handler
+}
+
+// matches returns whether the route handles a request; routes whose path
+// ends with / handle the paths it prefixes, as those of an http.ServeMux.
+func (rt route) matches(method, path string) bool {
+	if rt.method != "" && rt.method != method {
+		return false
+	}
+	if strings.HasSuffix(rt.path, "/") {
+		return strings.HasPrefix(path, rt.path)
+	}
+	return path == rt.path
+}
+
+// A Server is a fake Taskcluster deployment, whose root URL is its URL.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	routes   []route
+	requests []Request
+	latency  time.Duration
+	// faults are the statuses the next requests to a route respond with,
+	// by method and path.
+	faults map[string][]int
+}
+
+// NewServer starts a Server without handlers; it must be closed.
+func NewServer() *Server {
+	s := &Server{faults: make(map[string][]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Handle registers a handler for the requests with the given method, or any
+// method if it is empty, and path, or the paths it prefixes if it ends with
+// /. The handlers registered last take precedence.
+func (s *Server) Handle(method, path string, handler http.Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes = append(s.routes, route{method: method, path: path, handler: handler})
+}
+
+// HandleFunc registers a handler function, as Handle does, for any method.
+func (s *Server) HandleFunc(path string, handler func(http.ResponseWriter, *http.Request)) {
+	s.Handle("", path, http.HandlerFunc(handler))
+}
+
+// Respond registers a handler responding with the given status and body: a
+// string or []byte is written as is, and other values as JSON.
+func (s *Server) Respond(method, path string, status int, body interface{}) {
+	s.Handle(method, path, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, status, body)
+	}))
+}
+
+// Paginate registers a handler listing items in pages of the given size, as
+// the list endpoints of Taskcluster do: each page is a JSON object with the
+// items of the page in the given field, and a continuationToken if there is
+// a next page. A limit query parameter overrides the size of the pages.
+func (s *Server) Paginate(method, path, field string, pageSize int, items ...interface{}) {
+	s.paginate(method, path, field, pageSize, nil, items)
+}
+
+func (s *Server) paginate(method, path, field string, pageSize int, extra map[string]interface{}, items []interface{}) {
+	s.Handle(method, path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size := pageSize
+		if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 {
+			size = limit
+		}
+		start := 0
+		if token := r.URL.Query().Get("continuationToken"); token != "" {
+			var err error
+			if start, err = strconv.Atoi(token); err != nil || start < 0 || start > len(items) {
+				writeJSON(w, http.StatusBadRequest, errorBody("InputError", "invalid continuationToken "+token))
+				return
+			}
+		}
+		end := len(items)
+		if size > 0 && start+size < end {
+			end = start + size
+		}
+		page := map[string]interface{}{field: items[start:end]}
+		for k, v := range extra {
+			page[k] = v
+		}
+		if end < len(items) {
+			page["continuationToken"] = strconv.Itoa(end)
+		}
+		writeJSON(w, http.StatusOK, page)
+	}))
+}
+
+// SetLatency delays the responses to the requests received from now on.
+func (s *Server) SetLatency(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = latency
+}
+
+// Fail makes the next requests with the given method, or any method if it is
+// empty, and path respond with the given statuses, one request each, before
+// they are handled again, e.g. to test retries.
+func (s *Server) Fail(method, path string, statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := method + " " + path
+	s.faults[key] = append(s.faults[key], statuses...)
+}
+
+// Requests returns the requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestsTo returns the requests received so far with the given path.
+func (s *Server) RequestsTo(path string) []Request {
+	var requests []Request
+	for _, r := range s.Requests() {
+		if r.Path == path {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+// Reset forgets the requests received, the latency and the faults, but not
+// the handlers.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+	s.latency = 0
+	s.faults = make(map[string][]int)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	latency := s.latency
+	fault := s.fault(r.Method, r.URL.Path)
+	var handler http.Handler
+	for i := len(s.routes) - 1; i >= 0; i-- {
+		if s.routes[i].matches(r.Method, r.URL.Path) {
+			handler = s.routes[i].handler
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	time.Sleep(latency)
+	switch {
+	case fault != 0:
+		writeJSON(w, fault, errorBody("InjectedFault", fmt.Sprintf("injected fault: %d %s", fault, http.StatusText(fault))))
+	case handler == nil:
+		writeJSON(w, http.StatusNotFound, errorBody("ResourceNotFound", fmt.Sprintf("no handler for %s %s", r.Method, r.URL.Path)))
+	default:
+		handler.ServeHTTP(w, r)
+	}
+}
+
+// fault returns the status of the next fault of a request, if any, or 0.
+func (s *Server) fault(method, path string) int {
+	for _, key := range []string{method + " " + path, " " + path} {
+		if statuses := s.faults[key]; len(statuses) > 0 {
+			s.faults[key] = statuses[1:]
+			return statuses[0]
+		}
+	}
+	return 0
+}
+
+// errorBody returns the body of the error responses of Taskcluster services.
+func errorBody(code, message string) map[string]interface{} {
+	return map[string]interface{}{"code": code, "message": message}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	var data []byte
+	switch b := body.(type) {
+	case string:
+		data = []byte(b)
+	case []byte:
+		data = b
+	default:
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,146 @@
+package tcmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcauth"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcindex"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+)
+
+const (
+	taskID  = "fN1SbArXTPSVFNUvaOlinQ"
+	groupID = "dtwuF2n9S-i83G37V9eBuQ"
+)
+
+func get(t *testing.T, url string) (int, string) {
+	res, err := http.Get(url)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res.StatusCode, string(body)
+}
+
+func TestCannedHandlers(t *testing.T) {
+	require := require.New(t)
+	s := NewServer()
+	defer s.Close()
+
+	s.TaskStatus(tcqueue.TaskStatusStructure{TaskID: taskID, State: "completed"})
+	s.CurrentScopes("queue:create-task:*")
+	s.IndexedTask(tcindex.IndexedTaskResponse{Namespace: "project.app.latest", TaskID: taskID})
+
+	q := tcqueue.New(nil, s.URL)
+	status, err := q.Status(taskID)
+	require.NoError(err)
+	require.Equal("completed", status.Status.State)
+
+	scopes, err := tcauth.New(nil, s.URL).CurrentScopes()
+	require.NoError(err)
+	require.Equal([]string{"queue:create-task:*"}, scopes.Scopes)
+
+	task, err := tcindex.New(nil, s.URL).FindTask("project.app.latest")
+	require.NoError(err)
+	require.Equal(taskID, task.TaskID)
+
+	_, err = q.Status("unknown")
+	require.Error(err)
+	require.Len(s.RequestsTo("/api/queue/v1/task/unknown/status"), 1)
+}
+
+func TestTaskGroup(t *testing.T) {
+	require := require.New(t)
+	s := NewServer()
+	defer s.Close()
+
+	var tasks []tcqueue.TaskDefinitionAndStatus
+	for _, id := range []string{"a", "b", "c"} {
+		var task tcqueue.TaskDefinitionAndStatus
+		task.Status.TaskID = id
+		tasks = append(tasks, task)
+	}
+	s.TaskGroup(groupID, 2, tasks...)
+
+	q := tcqueue.New(nil, s.URL)
+	page, err := q.ListTaskGroup(groupID, "", "")
+	require.NoError(err)
+	require.Equal(groupID, page.TaskGroupID)
+	require.Len(page.Tasks, 2)
+	require.NotEmpty(page.ContinuationToken)
+	page, err = q.ListTaskGroup(groupID, page.ContinuationToken, "")
+	require.NoError(err)
+	require.Len(page.Tasks, 1)
+	require.Equal("c", page.Tasks[0].Status.TaskID)
+	require.Empty(page.ContinuationToken)
+
+	page, err = q.ListTaskGroup(groupID, "", "3")
+	require.NoError(err)
+	require.Len(page.Tasks, 3)
+
+	requests := s.Requests()
+	require.Len(requests, 3)
+	require.Equal("GET", requests[1].Method)
+	require.Equal("2", requests[1].Query.Get("continuationToken"))
+}
+
+func TestFaults(t *testing.T) {
+	require := require.New(t)
+	s := NewServer()
+	defer s.Close()
+	s.Respond("", "/api/queue/v1/ping", http.StatusOK, `{"alive": true}`)
+	s.Fail("GET", "/api/queue/v1/ping", 503, 500)
+
+	code, body := get(t, s.URL+"/api/queue/v1/ping")
+	require.Equal(503, code)
+	require.Contains(body, `"code":"InjectedFault"`)
+	code, _ = get(t, s.URL+"/api/queue/v1/ping")
+	require.Equal(500, code)
+	code, body = get(t, s.URL+"/api/queue/v1/ping")
+	require.Equal(200, code)
+	require.Equal(`{"alive": true}`, body)
+
+	// other methods are not affected
+	s.Fail("GET", "/api/queue/v1/ping", 500)
+	res, err := http.Post(s.URL+"/api/queue/v1/ping", "application/json", strings.NewReader(`{"a": 1}`))
+	require.NoError(err)
+	res.Body.Close()
+	require.Equal(200, res.StatusCode)
+	requests := s.RequestsTo("/api/queue/v1/ping")
+	var payload map[string]int
+	require.NoError(requests[len(requests)-1].JSON(&payload))
+	require.Equal(map[string]int{"a": 1}, payload)
+
+	s.Reset()
+	require.Empty(s.Requests())
+	code, _ = get(t, s.URL+"/api/queue/v1/ping")
+	require.Equal(200, code)
+}
+
+func TestLatency(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.SetLatency(50 * time.Millisecond)
+	start := time.Now()
+	code, _ := get(t, s.URL+"/api/queue/v1/ping")
+	require.Equal(t, 404, code)
+	require.True(t, time.Since(start) >= 50*time.Millisecond)
+}
+
+func TestPrefixRoutes(t *testing.T) {
+	require := require.New(t)
+	s := NewServer()
+	defer s.Close()
+	s.Respond("GET", "/api/queue/v1/task/", http.StatusOK, "any task")
+	s.Artifact(taskID, 1, "public/logs/live.log", "text/plain", []byte("log"))
+
+	_, body := get(t, s.URL+"/api/queue/v1/task/"+taskID)
+	require.Equal("any task", body)
+	_, body = get(t, s.URL+"/api/queue/v1/task/"+taskID+"/runs/1/artifacts/public/logs/live.log")
+	require.Equal("log", body)
+}
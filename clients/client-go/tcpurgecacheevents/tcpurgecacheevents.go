@@ -39,9 +39,16 @@
 //
 // In addition, this means that you will also get objects in your callback method like *queueevents.TaskDefinedMessage
 // rather than just interface{}.
+//
+// Conversely, the routing key of a message can be parsed into the fields of a binding:
+//
+//  var binding queueevents.TaskDefined
+//  err := binding.ParseRoutingKey(delivery.RoutingKey)
+//  fmt.Println(binding.TaskID, binding.WorkerType)
 package tcpurgecacheevents
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 )
@@ -69,6 +76,10 @@ func (binding PurgeCache) NewPayloadObject() interface{} {
 	return new(PurgeCacheMessage)
 }
 
+func (binding *PurgeCache) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 func generateRoutingKey(x interface{}) string {
 	val := reflect.ValueOf(x).Elem()
 	p := make([]string, 0, val.NumField())
@@ -86,3 +97,33 @@ func generateRoutingKey(x interface{}) string {
 	}
 	return strings.Join(p, ".")
 }
+
+func parseRoutingKey(routingKey string, x interface{}) error {
+	val := reflect.ValueOf(x).Elem()
+	words := strings.Split(routingKey, ".")
+	singleWords, multipleWords := 0, false
+	for i := 0; i < val.NumField(); i++ {
+		switch val.Type().Field(i).Tag.Get("mwords") {
+		case "*":
+			singleWords++
+		case "#":
+			multipleWords = true
+		}
+	}
+	if len(words) < singleWords || (!multipleWords && len(words) > singleWords) {
+		return fmt.Errorf("routing key %q does not match %s, which has %d words", routingKey, val.Type().Name(), singleWords)
+	}
+	w := 0
+	for i := 0; i < val.NumField(); i++ {
+		switch val.Type().Field(i).Tag.Get("mwords") {
+		case "*":
+			val.Field(i).SetString(words[w])
+			w++
+		case "#":
+			n := len(words) - singleWords
+			val.Field(i).SetString(strings.Join(words[w:w+n], "."))
+			w += n
+		}
+	}
+	return nil
+}
@@ -48,3 +48,24 @@ func Example_taskclusterSniffer() {
 	forever := make(chan bool)
 	<-forever
 }
+
+func Example_parseRoutingKey() {
+	// the primary routing key of a message of the task-completed exchange
+	var binding tcqueueevents.TaskCompleted
+	err := binding.ParseRoutingKey("primary.fN1SbArXTPSVFNUvaOlinQ.0.us-west-2.i-06936339d4f83059a.proj-foo.linux.taskcluster-github.e4WPAAeSdaSdKxeWzDCBA._")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("Task " + binding.TaskID + " run " + binding.RunID + " completed on " + binding.WorkerType)
+
+	// a binding of the parsed fields matches the messages of the same task
+	fmt.Println(tcqueueevents.TaskCompleted{TaskID: binding.TaskID}.RoutingKey())
+
+	err = binding.ParseRoutingKey("primary.fN1SbArXTPSVFNUvaOlinQ")
+	fmt.Println(err)
+
+	// Output:
+	// Task fN1SbArXTPSVFNUvaOlinQ run 0 completed on linux
+	// *.fN1SbArXTPSVFNUvaOlinQ.*.*.*.*.*.*.*.#
+	// routing key "primary.fN1SbArXTPSVFNUvaOlinQ" does not match TaskCompleted, which has 9 words
+}
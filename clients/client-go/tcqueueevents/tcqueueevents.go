@@ -80,9 +80,16 @@
 //
 // In addition, this means that you will also get objects in your callback method like *queueevents.TaskDefinedMessage
 // rather than just interface{}.
+//
+// Conversely, the routing key of a message can be parsed into the fields of a binding:
+//
+//  var binding queueevents.TaskDefined
+//  err := binding.ParseRoutingKey(delivery.RoutingKey)
+//  fmt.Println(binding.TaskID, binding.WorkerType)
 package tcqueueevents
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 )
@@ -120,6 +127,10 @@ func (binding TaskDefined) NewPayloadObject() interface{} {
 	return new(TaskDefinedMessage)
 }
 
+func (binding *TaskDefined) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 // When a task becomes `pending` a message is posted to this exchange.
 //
 // This is useful for workers who doesn't want to constantly poll the queue
@@ -154,6 +165,10 @@ func (binding TaskPending) NewPayloadObject() interface{} {
 	return new(TaskPendingMessage)
 }
 
+func (binding *TaskPending) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 // Whenever a task is claimed by a worker, a run is started on the worker,
 // and a message is posted on this exchange.
 //
@@ -183,6 +198,10 @@ func (binding TaskRunning) NewPayloadObject() interface{} {
 	return new(TaskRunningMessage)
 }
 
+func (binding *TaskRunning) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 // Whenever the `createArtifact` end-point is called, the queue will create
 // a record of the artifact and post a message on this exchange. All of this
 // happens before the queue returns a signed URL for the caller to upload
@@ -231,6 +250,10 @@ func (binding ArtifactCreated) NewPayloadObject() interface{} {
 	return new(ArtifactCreatedMessage)
 }
 
+func (binding *ArtifactCreated) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 // When a task is successfully completed by a worker a message is posted
 // this exchange.
 // This message is routed using the `runId`, `workerGroup` and `workerId`
@@ -263,6 +286,10 @@ func (binding TaskCompleted) NewPayloadObject() interface{} {
 	return new(TaskCompletedMessage)
 }
 
+func (binding *TaskCompleted) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 // When a task ran, but failed to complete successfully a message is posted
 // to this exchange. This is same as worker ran task-specific code, but the
 // task specific code exited non-zero.
@@ -293,6 +320,10 @@ func (binding TaskFailed) NewPayloadObject() interface{} {
 	return new(TaskFailedMessage)
 }
 
+func (binding *TaskFailed) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 // Whenever Taskcluster fails to run a message is posted to this exchange.
 // This happens if the task isn't completed before its `deadlìne`,
 // all retries failed (i.e. workers stopped responding), the task was
@@ -327,6 +358,10 @@ func (binding TaskException) NewPayloadObject() interface{} {
 	return new(TaskExceptionMessage)
 }
 
+func (binding *TaskException) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 // A message is published on task-group-resolved whenever all submitted
 // tasks (whether scheduled or unscheduled) for a given task group have
 // been resolved, regardless of whether they resolved as successful or
@@ -353,6 +388,10 @@ func (binding TaskGroupResolved) NewPayloadObject() interface{} {
 	return new(TaskGroupResolvedMessage)
 }
 
+func (binding *TaskGroupResolved) ParseRoutingKey(routingKey string) error {
+	return parseRoutingKey(routingKey, binding)
+}
+
 func generateRoutingKey(x interface{}) string {
 	val := reflect.ValueOf(x).Elem()
 	p := make([]string, 0, val.NumField())
@@ -370,3 +409,33 @@ func generateRoutingKey(x interface{}) string {
 	}
 	return strings.Join(p, ".")
 }
+
+func parseRoutingKey(routingKey string, x interface{}) error {
+	val := reflect.ValueOf(x).Elem()
+	words := strings.Split(routingKey, ".")
+	singleWords, multipleWords := 0, false
+	for i := 0; i < val.NumField(); i++ {
+		switch val.Type().Field(i).Tag.Get("mwords") {
+		case "*":
+			singleWords++
+		case "#":
+			multipleWords = true
+		}
+	}
+	if len(words) < singleWords || (!multipleWords && len(words) > singleWords) {
+		return fmt.Errorf("routing key %q does not match %s, which has %d words", routingKey, val.Type().Name(), singleWords)
+	}
+	w := 0
+	for i := 0; i < val.NumField(); i++ {
+		switch val.Type().Field(i).Tag.Get("mwords") {
+		case "*":
+			val.Field(i).SetString(words[w])
+			w++
+		case "#":
+			n := len(words) - singleWords
+			val.Field(i).SetString(strings.Join(words[w:w+n], "."))
+			w += n
+		}
+	}
+	return nil
+}
@@ -187,10 +187,10 @@ func execute(
 	method := strings.ToUpper(entry.Method)
 	url := tcurls.API(config.RootURL(), serviceName, apiVersion, route+q)
 
-	// Try to make the request up to 5 times using go-got
+	// Retry the request as given by --retries, using go-got
 	// Allow unlimited responses.
 	g := got.New()
-	g.Retries = 5
+	g.Retries = client.Retries
 	g.MaxSize = 0
 
 	req := g.NewRequest(method, url, input)
@@ -219,6 +219,10 @@ func execute(
 
 	res, err := req.Send()
 	if err != nil {
+		// Include the body of error responses, which explains what went wrong
+		if e, ok := err.(got.BadResponseCodeError); ok && e.Response != nil {
+			return fmt.Errorf("Request failed with status code %d: %s", e.StatusCode, bytes.TrimSpace(e.Body))
+		}
 		return fmt.Errorf("Request failed: %s", err)
 	}
 
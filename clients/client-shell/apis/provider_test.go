@@ -63,6 +63,24 @@ func TestCommandGeneration(t *testing.T) {
 	assert.Equal(val, actual, "request sent to test server was invalid, replied: %s", actual)
 }
 
+// TestCommandErrorResponse checks that the body of an error response is
+// returned in the error, so that it is reported on stderr
+func TestCommandErrorResponse(t *testing.T) {
+	assert := assert.New(t)
+
+	providerServer := apiServer()
+	config.SetRootURL(providerServer.URL)
+	defer providerServer.Close()
+
+	cmd := makeCmdFromDefinition("Test", servicesTest["Test"])
+	buf := &bytes.Buffer{}
+	cmd.SetOutput(buf)
+
+	cmd.SetArgs([]string{"test", "test", "--key", "missing"})
+	err := cmd.Execute()
+	assert.EqualError(err, `Request failed with status code 404: {"code": "ResourceNotFound"}`)
+}
+
 // the code from which we generate the test command
 var servicesTest = map[string]definitions.Service{
 	"Test": definitions.Service{
@@ -97,10 +115,14 @@ func apiServer() *httptest.Server {
 // apiHandler checks that the received request is valid, and replies
 func apiHandler(w http.ResponseWriter, r *http.Request) {
 	// If the "key" parameter is not present, we simply return "true"
-	// However, if the "key" parameter is present, we echo back the value given.
+	// However, if the "key" parameter is present, we echo back the value given,
+	// unless it is "missing", in which case we reply with a 404.
 	query := r.URL.Query()
 
-	if _, ok := query["key"]; ok {
+	if query.Get("key") == "missing" {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"code": "ResourceNotFound"}`+"\n")
+	} else if _, ok := query["key"]; ok {
 		_, _ = io.WriteString(w, query.Get("key"))
 	} else {
 		_, _ = io.WriteString(w, "true")
@@ -0,0 +1,3 @@
+// Package artifacts transfers task artifacts between the local filesystem
+// and the queue, for use by the artifact commands and other tooling.
+package artifacts
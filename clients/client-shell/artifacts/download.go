@@ -0,0 +1,285 @@
+package artifacts
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	tcurls "github.com/taskcluster/taskcluster-lib-urls"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v27/internal/progress"
+)
+
+// retryDelay is the delay before the first retry of a failed download; it
+// doubles with every further retry.
+var retryDelay = time.Second
+
+// Downloader downloads the artifacts of tasks from the queue.
+type Downloader struct {
+	// Queue is the queue the artifacts are downloaded from; its credentials
+	// are used to sign the requests for private artifacts.
+	Queue *tcqueue.Queue
+	// Retries is the number of times a failed download is retried, resuming
+	// where it stopped when possible.
+	Retries int
+	// Concurrency is the number of artifacts DownloadAll downloads at once.
+	Concurrency int
+	// Progress, if set, is told about the downloads of DownloadAll, and
+	// the retries of Download.
+	Progress progress.Reporter
+}
+
+// Result is the outcome of the download of a single artifact.
+type Result struct {
+	Name string
+	Path string
+	Err  error
+}
+
+// List returns all the artifacts of the given run of a task.
+func List(q *tcqueue.Queue, taskID, runID string) ([]tcqueue.Artifact, error) {
+	artifacts := make([]tcqueue.Artifact, 0)
+	continuation := ""
+	for {
+		resp, err := q.ListArtifacts(taskID, runID, continuation, "")
+		if err != nil {
+			return nil, fmt.Errorf("could not list the artifacts of task %s run %s: %w", taskID, runID, err)
+		}
+		artifacts = append(artifacts, resp.Artifacts...)
+		continuation = resp.ContinuationToken
+		if continuation == "" {
+			return artifacts, nil
+		}
+	}
+}
+
+// Path returns the path under dir that the artifact with the given name is
+// downloaded to, which mirrors the slash-separated structure of the name.
+func Path(dir, name string) (string, error) {
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("artifact name %q is not a valid path", name)
+	}
+	return path, nil
+}
+
+// DownloadAll downloads the named artifacts into dir, Concurrency at a time,
+// and returns the outcome of each download in the order of names. A failed
+// download does not stop the others.
+func (d *Downloader) DownloadAll(taskID, runID string, names []string, dir string) []Result {
+	concurrency := d.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	p := d.progress()
+	p.Started(len(names))
+	defer p.Done()
+
+	results := make([]Result, len(names))
+	todo := make(chan int)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < concurrency && i < len(names); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range todo {
+				result := Result{Name: names[i]}
+				result.Path, result.Err = Path(dir, names[i])
+				if result.Err == nil {
+					result.Err = d.Download(taskID, runID, names[i], result.Path)
+				}
+				results[i] = result
+				p.Completed(names[i], result.Err)
+			}
+		}()
+	}
+	for i := range names {
+		todo <- i
+	}
+	close(todo)
+	wg.Wait()
+
+	return results
+}
+
+// Download downloads an artifact to dest, creating its directory if needed.
+//
+// The content is written to dest.partial first, and renamed to dest once it
+// is complete and matches the SHA256 announced by the queue, if any. Failed
+// downloads are retried, asking for the remainder of the partial file with a
+// Range request, so that large artifacts survive flaky connections.
+func (d *Downloader) Download(taskID, runID, name, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("could not create directory for %s: %w", dest, err)
+	}
+	partial := dest + ".partial"
+
+	var err error
+	for attempt := 0; attempt <= d.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(retryDelay << uint(attempt-1))
+		}
+		var retry bool
+		retry, err = d.fetch(taskID, runID, name, partial)
+		if err == nil {
+			return os.Rename(partial, dest)
+		}
+		if !retry || attempt == d.Retries {
+			break
+		}
+		d.progress().Retry(name, attempt+1, err)
+	}
+	return fmt.Errorf("could not download artifact %s of task %s: %w", name, taskID, err)
+}
+
+// progress returns the Reporter of the downloads.
+func (d *Downloader) progress() progress.Reporter {
+	if d.Progress == nil {
+		return progress.Discard
+	}
+	return d.Progress
+}
+
+// artifactURL returns the URL of an artifact, signed if the queue has
+// credentials.
+func (d *Downloader) artifactURL(taskID, runID, name string) (string, error) {
+	if d.Queue.Credentials == nil {
+		return tcurls.API(d.Queue.RootURL, "queue", "v1", "task/"+url.QueryEscape(taskID)+"/runs/"+url.QueryEscape(runID)+"/artifacts/"+url.QueryEscape(name)), nil
+	}
+	u, err := d.Queue.GetArtifact_SignedURL(taskID, runID, name, time.Hour)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// fetch makes one attempt at downloading an artifact to the partial file,
+// appending to what it already holds. It reports whether a failure is worth
+// retrying.
+func (d *Downloader) fetch(taskID, runID, name, partial string) (retry bool, err error) {
+	f, err := os.OpenFile(partial, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return false, err
+	}
+
+	u, err := d.artifactURL(taskID, runID, name)
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return false, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	// the queue describes the artifact in the headers of its redirect to
+	// the storage backend, so keep those
+	var location http.Header
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if location == nil && req.Response != nil {
+				location = req.Response.Header
+			}
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return nil
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if location == nil {
+		location = resp.Header
+	}
+
+	// Go only decodes gzip transparently when it asked for it, which it
+	// does not do for Range requests.
+	gzipped := resp.Header.Get("Content-Encoding") == "gzip"
+
+	var body io.Reader = resp.Body
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0 && !gzipped:
+		// resuming where the last attempt stopped
+	case resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusPartialContent || resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// the whole artifact is (or has to be) sent again
+		if err := restart(f); err != nil {
+			return false, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return true, fmt.Errorf("could not resume download: %s", resp.Status)
+		}
+		if gzipped {
+			gz, err := gzip.NewReader(resp.Body)
+			if err != nil {
+				return true, err
+			}
+			defer gz.Close()
+			body = gz
+		}
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode/100 == 5:
+		return true, fmt.Errorf("received unexpected response: %s", resp.Status)
+	default:
+		return false, fmt.Errorf("received unexpected response: %s", resp.Status)
+	}
+
+	if _, err := io.Copy(f, body); err != nil {
+		return true, err
+	}
+
+	if want := location.Get("X-Taskcluster-Location-Content-Sha256"); want != "" {
+		got, err := fileSHA256(f)
+		if err != nil {
+			return false, err
+		}
+		if !strings.EqualFold(got, want) {
+			if err := restart(f); err != nil {
+				return false, err
+			}
+			return true, fmt.Errorf("SHA256 of the content is %s, expected %s", got, want)
+		}
+	}
+	return false, nil
+}
+
+// restart empties the partial file, to download the artifact from scratch.
+func restart(f *os.File) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err := f.Seek(0, io.SeekStart)
+	return err
+}
+
+// fileSHA256 returns the hex-encoded SHA256 of the content of f.
+func fileSHA256(f *os.File) (string, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,175 @@
+package artifacts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v27/internal/progress"
+)
+
+const taskID = "ANnmjMocTymeTID0tlNJAw"
+
+// fakeStorage serves artifacts like the queue does: the queue redirects to
+// the storage backend, announcing the SHA256 of the content.
+type fakeStorage struct {
+	sync.Mutex
+	content map[string][]byte
+	// failures is the number of requests to fail for each artifact
+	failures map[string]int
+	// ranges records the Range header of each request
+	ranges []string
+}
+
+func (s *fakeStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	if strings.HasPrefix(r.URL.Path, "/api/queue/v1/task/"+taskID+"/runs/0/artifacts") {
+		prefix := "/api/queue/v1/task/" + taskID + "/runs/0/artifacts/"
+		if !strings.HasPrefix(r.URL.Path, prefix) {
+			_, _ = io.WriteString(w, `{"artifacts": [{"name": "public/a.txt"}, {"name": "public/logs/b.txt"}]}`)
+			return
+		}
+		name := strings.TrimPrefix(r.URL.Path, prefix)
+		content, ok := s.content[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		sum := sha256.Sum256(content)
+		w.Header().Set("X-Taskcluster-Location-Content-Sha256", hex.EncodeToString(sum[:]))
+		http.Redirect(w, r, "/storage/"+name, http.StatusSeeOther)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/storage/")
+	s.ranges = append(s.ranges, r.Header.Get("Range"))
+	if s.failures[name] > 0 {
+		s.failures[name]--
+		// send half of the content, then break the connection
+		content := s.content[name]
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(content[:len(content)/2])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(s.content[name]))
+}
+
+func setUp(t *testing.T, storage *fakeStorage) (*Downloader, string, func()) {
+	server := httptest.NewServer(storage)
+	dir, err := ioutil.TempDir("", "artifacts")
+	require.NoError(t, err)
+	retryDelay = time.Millisecond
+	d := &Downloader{Queue: tcqueue.New(nil, server.URL), Retries: 2, Concurrency: 2}
+	return d, dir, func() {
+		server.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestDownloadAll(t *testing.T) {
+	storage := &fakeStorage{content: map[string][]byte{
+		"public/a.txt":      []byte("hello"),
+		"public/logs/b.txt": []byte("world"),
+	}}
+	d, dir, tearDown := setUp(t, storage)
+	defer tearDown()
+
+	artifacts, err := List(d.Queue, taskID, "0")
+	require.NoError(t, err)
+	require.Len(t, artifacts, 2)
+
+	results := d.DownloadAll(taskID, "0", []string{"public/a.txt", "public/logs/b.txt", "public/missing"}, dir)
+	require.Len(t, results, 3)
+	require.NoError(t, results[0].Err)
+	require.NoError(t, results[1].Err)
+	require.Error(t, results[2].Err)
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "public", "logs", "b.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "world", string(content))
+	require.Equal(t, filepath.Join(dir, "public", "a.txt"), results[0].Path)
+}
+
+func TestDownloadProgress(t *testing.T) {
+	storage := &fakeStorage{
+		content:  map[string][]byte{"public/a.txt": []byte("hello")},
+		failures: map[string]int{"public/a.txt": 1},
+	}
+	d, dir, tearDown := setUp(t, storage)
+	defer tearDown()
+	out := &bytes.Buffer{}
+	d.Progress = progress.NewLog(out, "downloading")
+	d.Concurrency = 1
+
+	d.DownloadAll(taskID, "0", []string{"public/a.txt", "public/missing"}, dir)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 5)
+	require.Equal(t, "downloading: started, 2 in total", lines[0])
+	require.True(t, strings.HasPrefix(lines[1], "downloading: retrying public/a.txt after attempt 1 failed: "), lines[1])
+	require.Equal(t, "downloading: [1/2] public/a.txt", lines[2])
+	require.True(t, strings.HasPrefix(lines[3], "downloading: [2/2] public/missing failed: "), lines[3])
+	require.Equal(t, "downloading: done, 1 of 2 succeeded (1 failed)", lines[4])
+}
+
+func TestDownloadResume(t *testing.T) {
+	storage := &fakeStorage{
+		content:  map[string][]byte{"public/big.txt": []byte("0123456789")},
+		failures: map[string]int{"public/big.txt": 1},
+	}
+	d, dir, tearDown := setUp(t, storage)
+	defer tearDown()
+
+	dest := filepath.Join(dir, "big.txt")
+	require.NoError(t, d.Download(taskID, "0", "public/big.txt", dest))
+
+	content, err := ioutil.ReadFile(dest)
+	require.NoError(t, err)
+	require.Equal(t, "0123456789", string(content))
+	require.Equal(t, []string{"", "bytes=5-"}, storage.ranges)
+	_, err = os.Stat(dest + ".partial")
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestDownloadCorruptPartial(t *testing.T) {
+	storage := &fakeStorage{content: map[string][]byte{"public/a.txt": []byte("hello")}}
+	d, dir, tearDown := setUp(t, storage)
+	defer tearDown()
+
+	// a partial file left over from a different artifact fails the hash
+	// check, and the artifact is downloaded from scratch
+	dest := filepath.Join(dir, "a.txt")
+	require.NoError(t, ioutil.WriteFile(dest+".partial", []byte("xx"), 0644))
+	require.NoError(t, d.Download(taskID, "0", "public/a.txt", dest))
+
+	content, err := ioutil.ReadFile(dest)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(content))
+}
+
+func TestPath(t *testing.T) {
+	path, err := Path("out", "public/logs/live.log")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join("out", "public", "logs", "live.log"), path)
+
+	_, err = Path("out", "../escape")
+	require.Error(t, err)
+	_, err = Path("out", "public/../../escape")
+	require.Error(t, err)
+}
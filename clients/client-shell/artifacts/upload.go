@@ -0,0 +1,145 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+)
+
+// Uploader uploads files as artifacts of tasks.
+type Uploader struct {
+	// Queue is the queue the artifacts are created with; its credentials
+	// need the scopes to create artifacts for the task.
+	Queue *tcqueue.Queue
+	// Retries is the number of times a failed upload is retried.
+	Retries int
+	// Progress, if not nil, receives a progress line for each upload, which
+	// is rewritten in place as the upload goes on.
+	Progress io.Writer
+}
+
+// ContentType returns the content type of a file, based on its extension.
+func ContentType(path string) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(path)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// Upload uploads the file at path as the artifact with the given name of a
+// run of a task, which expires at the given time.
+//
+// The queue is asked for an S3 artifact, and the content is sent to the
+// signed URL it returns, with the given content type.
+func (u *Uploader) Upload(taskID, runID, name, path, contentType string, expires time.Time) error {
+	var err error
+	for attempt := 0; attempt <= u.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(retryDelay << uint(attempt-1))
+		}
+		var retry bool
+		retry, err = u.put(taskID, runID, name, path, contentType, expires)
+		if err == nil || !retry {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("could not upload %s as artifact %s of task %s: %w", path, name, taskID, err)
+	}
+	return nil
+}
+
+// put makes one attempt at uploading an artifact. It reports whether a
+// failure is worth retrying.
+func (u *Uploader) put(taskID, runID, name, path, contentType string, expires time.Time) (retry bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	// a new signed URL is requested for every attempt, as it may expire
+	// during a long upload
+	payload, err := json.Marshal(tcqueue.S3ArtifactRequest{
+		ContentType: contentType,
+		Expires:     tcclient.Time(expires),
+		StorageType: "s3",
+	})
+	if err != nil {
+		return false, err
+	}
+	req := tcqueue.PostArtifactRequest(payload)
+	resp, err := u.Queue.CreateArtifact(taskID, runID, name, &req)
+	if err != nil {
+		return false, fmt.Errorf("could not create artifact: %w", err)
+	}
+	var s3 tcqueue.S3ArtifactResponse
+	if err := json.Unmarshal(*resp, &s3); err != nil {
+		return false, fmt.Errorf("could not parse the response of the queue: %w", err)
+	}
+
+	var body io.Reader = f
+	if u.Progress != nil {
+		body = &progressReader{Reader: f, out: u.Progress, name: name, total: info.Size()}
+	}
+	putReq, err := http.NewRequest("PUT", s3.PutURL, body)
+	if err != nil {
+		return false, err
+	}
+	putReq.ContentLength = info.Size()
+	putReq.Header.Set("Content-Type", contentType)
+
+	putResp, err := http.DefaultClient.Do(putReq)
+	if u.Progress != nil {
+		fmt.Fprintln(u.Progress)
+	}
+	if err != nil {
+		return true, err
+	}
+	defer putResp.Body.Close()
+	switch {
+	case putResp.StatusCode/100 == 2:
+		return false, nil
+	case putResp.StatusCode == http.StatusTooManyRequests || putResp.StatusCode/100 == 5:
+		return true, fmt.Errorf("received unexpected response: %s", putResp.Status)
+	default:
+		return false, fmt.Errorf("received unexpected response: %s", putResp.Status)
+	}
+}
+
+// progressReader reports the progress of the reads of an upload, rewriting
+// the line whenever another percent of the content is read.
+type progressReader struct {
+	io.Reader
+	out     io.Writer
+	name    string
+	total   int64
+	read    int64
+	percent int64
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.read += int64(n)
+	percent := int64(100)
+	if r.total > 0 {
+		percent = r.read * 100 / r.total
+	}
+	if r.read == int64(n) || percent != r.percent {
+		r.percent = percent
+		fmt.Fprintf(r.out, "\r%s: %3d%% (%d of %d bytes)", r.name, percent, r.read, r.total)
+	}
+	return n, err
+}
@@ -0,0 +1,117 @@
+package artifacts
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+)
+
+// fakeUploadStorage hands out S3 artifacts like the queue does, and stores
+// what is PUT to their URLs.
+type fakeUploadStorage struct {
+	sync.Mutex
+	server *httptest.Server
+	// requests holds the artifact requests made to the queue, by name
+	requests map[string]tcqueue.S3ArtifactRequest
+	// content and contentTypes hold the uploads, by name
+	content      map[string][]byte
+	contentTypes map[string]string
+	// failures is the number of uploads to reject with a 503
+	failures int
+}
+
+func (s *fakeUploadStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	prefix := "/api/queue/v1/task/" + taskID + "/runs/0/artifacts/"
+	if strings.HasPrefix(r.URL.Path, prefix) {
+		name := strings.TrimPrefix(r.URL.Path, prefix)
+		var req tcqueue.S3ArtifactRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.requests[name] = req
+		_ = json.NewEncoder(w).Encode(tcqueue.S3ArtifactResponse{
+			ContentType: req.ContentType,
+			Expires:     req.Expires,
+			PutURL:      s.server.URL + "/storage/" + name,
+			StorageType: "s3",
+		})
+		return
+	}
+
+	if s.failures > 0 {
+		s.failures--
+		http.Error(w, "slow down", http.StatusServiceUnavailable)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/storage/")
+	content, _ := ioutil.ReadAll(r.Body)
+	s.content[name] = content
+	s.contentTypes[name] = r.Header.Get("Content-Type")
+}
+
+func TestUpload(t *testing.T) {
+	storage := &fakeUploadStorage{
+		requests:     map[string]tcqueue.S3ArtifactRequest{},
+		content:      map[string][]byte{},
+		contentTypes: map[string]string{},
+		failures:     1,
+	}
+	storage.server = httptest.NewServer(storage)
+	defer storage.server.Close()
+	_, dir, tearDown := setUp(t, &fakeStorage{})
+	defer tearDown()
+
+	path := filepath.Join(dir, "report.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"passed": true}`), 0644))
+
+	progress := &bytes.Buffer{}
+	u := &Uploader{Queue: tcqueue.New(nil, storage.server.URL), Retries: 1, Progress: progress}
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Millisecond)
+	require.NoError(t, u.Upload(taskID, "0", "public/report.json", path, ContentType(path), expires))
+
+	require.Equal(t, `{"passed": true}`, string(storage.content["public/report.json"]))
+	require.Equal(t, "application/json", storage.contentTypes["public/report.json"])
+	require.Equal(t, "s3", storage.requests["public/report.json"].StorageType)
+	require.True(t, expires.Equal(time.Time(storage.requests["public/report.json"].Expires)))
+	require.True(t, strings.HasSuffix(progress.String(), "\rpublic/report.json: 100% (16 of 16 bytes)\n"), progress.String())
+}
+
+func TestUploadFailure(t *testing.T) {
+	storage := &fakeUploadStorage{
+		requests:     map[string]tcqueue.S3ArtifactRequest{},
+		content:      map[string][]byte{},
+		contentTypes: map[string]string{},
+		failures:     2,
+	}
+	storage.server = httptest.NewServer(storage)
+	defer storage.server.Close()
+	_, dir, tearDown := setUp(t, &fakeStorage{})
+	defer tearDown()
+
+	path := filepath.Join(dir, "data")
+	require.NoError(t, ioutil.WriteFile(path, []byte("data"), 0644))
+
+	u := &Uploader{Queue: tcqueue.New(nil, storage.server.URL), Retries: 1}
+	require.Error(t, u.Upload(taskID, "0", "public/data", path, ContentType(path), time.Now().Add(time.Hour)))
+	require.Empty(t, storage.content)
+}
+
+func TestContentType(t *testing.T) {
+	require.Equal(t, "application/json", ContentType("a/report.json"))
+	require.True(t, strings.HasPrefix(ContentType("index.html"), "text/html"))
+	require.Equal(t, "application/octet-stream", ContentType("target.unknownextension"))
+}
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// CacheTTL is how long the responses of cacheable API calls are reused
+// for, as given by --cache-ttl; 0 disables the cache. It is read by
+// ConfigureTransport.
+var CacheTTL time.Duration
+
+// cacheDir returns the directory responses are cached in.
+var cacheDir = func() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "taskcluster", "responses"), nil
+}
+
+// cacheable matches the paths of the read-only API calls whose responses
+// may be cached: task definitions, which never change, and task group,
+// dependents and index listings, which are fine to be slightly stale while
+// triaging.
+var cacheable = []*regexp.Regexp{
+	regexp.MustCompile(`^/api/queue/v1/task/[^/]+$`),
+	regexp.MustCompile(`^/api/queue/v1/task/[^/]+/dependents$`),
+	regexp.MustCompile(`^/api/queue/v1/task-group/[^/]+/list$`),
+	regexp.MustCompile(`^/api/index/v1/(task|tasks|namespaces)/[^/]+$`),
+}
+
+// cacheTransport serves the responses of cacheable GET requests from files
+// in dir, for as long as they are younger than ttl, and otherwise stores
+// the successful responses of next.
+type cacheTransport struct {
+	next http.RoundTripper
+	dir  string
+	ttl  time.Duration
+	// log, if not nil, receives a line for each response served from the
+	// cache, as those do not go through the debugTransport.
+	log io.Writer
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" || !isCacheable(req.URL.Path) {
+		return t.next.RoundTrip(req)
+	}
+	file := filepath.Join(t.dir, cacheKey(req))
+
+	if resp := t.load(file, req); resp != nil {
+		if t.log != nil {
+			fmt.Fprintf(t.log, "<-- %s %s: %s (cached)\n", req.Method, redactURL(req.URL), resp.Status)
+		}
+		return resp, nil
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	// the cache is only an optimization, so failing to write it is ignored
+	_ = t.store(file, resp)
+	return resp, nil
+}
+
+// load returns the cached response of req from file, or nil if there is
+// none, or it has expired.
+func (t *cacheTransport) load(file string, req *http.Request) *http.Response {
+	info, err := os.Stat(file)
+	if err != nil || time.Since(info.ModTime()) > t.ttl {
+		return nil
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+	if err != nil {
+		return nil
+	}
+	return resp
+}
+
+// store writes resp to file, replacing its body with one that can still be
+// read by the caller.
+func (t *cacheTransport) store(file string, resp *http.Response) error {
+	data, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(t.dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(t.dir, "tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// cacheKey names the file caching the response of req: a hash of its URL
+// and of the credentials it is signed with, so that a response is never
+// served to another client, e.g. of another profile, or to an
+// unauthenticated call. The attributes of Hawk headers which change with
+// every request, such as the signature, are left out.
+func cacheKey(req *http.Request) string {
+	h := sha256.New()
+	io.WriteString(h, req.URL.String())
+	if auth := req.Header.Get("Authorization"); strings.HasPrefix(auth, "Hawk ") {
+		for _, m := range hawkAttribute.FindAllStringSubmatch(auth, -1) {
+			if m[1] == "id" || m[1] == "ext" {
+				fmt.Fprintf(h, "\n%s=%s", m[1], m[2])
+			}
+		}
+	} else if auth != "" {
+		fmt.Fprintf(h, "\n%s", auth)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func isCacheable(path string) bool {
+	for _, pattern := range cacheable {
+		if pattern.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
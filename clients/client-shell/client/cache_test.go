@@ -0,0 +1,79 @@
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestCacheTransport(t *testing.T) {
+	assert := assert.New(t)
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/queue/v1/task/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		fmt.Fprintf(w, `{"calls": %d}`, calls)
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "taskcluster-cache")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	client := &http.Client{Transport: &cacheTransport{next: http.DefaultTransport, dir: dir, ttl: time.Minute}}
+
+	get := func(path string) string {
+		resp, err := client.Get(server.URL + path)
+		assert.NoError(err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(err)
+		return string(body)
+	}
+
+	assert.Equal(`{"calls": 1}`, get("/api/queue/v1/task/abc"))
+	assert.Equal(`{"calls": 1}`, get("/api/queue/v1/task/abc"), "the task definition should be cached")
+	assert.Equal(1, calls)
+
+	assert.Equal(`{"calls": 2}`, get("/api/queue/v1/task/abc/status"))
+	assert.Equal(`{"calls": 3}`, get("/api/queue/v1/task/abc/status"), "the task status should not be cached")
+
+	assert.Equal(`{"calls": 4}`, get("/api/queue/v1/task/missing"))
+	assert.Equal(`{"calls": 5}`, get("/api/queue/v1/task/missing"), "errors should not be cached")
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(err)
+	assert.Len(files, 1)
+	old := time.Now().Add(-2 * time.Minute)
+	assert.NoError(os.Chtimes(dir+"/"+files[0].Name(), old, old))
+	assert.Equal(`{"calls": 6}`, get("/api/queue/v1/task/abc"), "expired responses should not be used")
+}
+
+func TestCacheKey(t *testing.T) {
+	assert := assert.New(t)
+
+	request := func(auth string) *http.Request {
+		req, err := http.NewRequest("GET", "https://tc.example.com/api/queue/v1/task/abc", nil)
+		assert.NoError(err)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		return req
+	}
+
+	anonymous := cacheKey(request(""))
+	alice := cacheKey(request(`Hawk id="alice", ts="1", nonce="n1", mac="m1"`))
+	assert.Equal(alice, cacheKey(request(`Hawk id="alice", ts="2", nonce="n2", mac="m2"`)), "the key should not change with every request")
+	assert.NotEqual(anonymous, alice)
+	assert.NotEqual(alice, cacheKey(request(`Hawk id="bob", ts="1", nonce="n1", mac="m1"`)))
+	assert.NotEqual(alice, cacheKey(request(`Hawk id="alice", ts="1", nonce="n1", mac="m1", ext="e30="`)), "authorized scopes should change the key")
+}
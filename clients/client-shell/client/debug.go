@@ -0,0 +1,146 @@
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Debug is where HTTP requests are logged, as enabled by --debug; nil
+// disables logging. It is read by ConfigureTransport.
+var Debug io.Writer
+
+const redacted = "<redacted>"
+
+var (
+	// hawkAttribute matches the attributes of Hawk Authorization headers.
+	hawkAttribute = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+	// secretHeaders are the headers whose values are never logged.
+	secretHeaders = map[string]bool{
+		"Cookie":               true,
+		"Proxy-Authorization":  true,
+		"Set-Cookie":           true,
+		"X-Amz-Security-Token": true,
+	}
+
+	// secretParams are the query parameters of signed URLs, such as those
+	// of the queue (bewit) and of S3 or Azure storage.
+	secretParams = []string{"bewit", "X-Amz-Signature", "X-Amz-Credential", "X-Amz-Security-Token", "Signature", "sig"}
+)
+
+// debugTransport logs the requests made through it, and their responses,
+// without the secrets they carry.
+type debugTransport struct {
+	next http.RoundTripper
+	log  io.Writer
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fmt.Fprintf(t.log, "--> %s %s\n", req.Method, redactURL(req.URL))
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			fmt.Fprintf(t.log, "    %s: %s\n", name, redactHeader(name, value))
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	duration := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		fmt.Fprintf(t.log, "<-- %s %s: %v (%s)\n", req.Method, redactURL(req.URL), err, duration)
+		return resp, err
+	}
+	fmt.Fprintf(t.log, "<-- %s %s: %s (%s)\n", req.Method, redactURL(req.URL), resp.Status, duration)
+	if location := resp.Header.Get("Location"); location != "" {
+		if u, err := url.Parse(location); err == nil {
+			fmt.Fprintf(t.log, "    Location: %s\n", redactURL(u))
+		}
+	}
+	return resp, nil
+}
+
+// redactURL returns u without the signatures of signed URLs.
+func redactURL(u *url.URL) string {
+	redactedURL := *u
+	query := u.Query()
+	changed := false
+	for _, param := range secretParams {
+		if _, ok := query[param]; ok {
+			query.Set(param, redacted)
+			changed = true
+		}
+	}
+	if changed {
+		redactedURL.RawQuery = query.Encode()
+	}
+	if redactedURL.User != nil {
+		redactedURL.User = url.User(redacted)
+	}
+	return redactedURL.String()
+}
+
+// redactHeader returns the value of a request header without its secrets.
+// Hawk Authorization headers keep the clientId, and the authorized scopes
+// and certificate, without its signature, as they explain scope errors.
+func redactHeader(name, value string) string {
+	if secretHeaders[http.CanonicalHeaderKey(name)] {
+		return redacted
+	}
+	if http.CanonicalHeaderKey(name) != "Authorization" {
+		return value
+	}
+	if !strings.HasPrefix(value, "Hawk ") {
+		return redacted
+	}
+	return "Hawk " + hawkAttribute.ReplaceAllStringFunc(value[len("Hawk "):], func(attribute string) string {
+		m := hawkAttribute.FindStringSubmatch(attribute)
+		switch m[1] {
+		case "id", "ts":
+			return attribute
+		case "ext":
+			return `ext=` + redactExt(m[2])
+		}
+		return m[1] + `="` + redacted + `"`
+	})
+}
+
+// redactExt decodes the ext attribute of a Hawk header, and removes the
+// signature and seed of its certificate.
+func redactExt(ext string) string {
+	data, err := base64.StdEncoding.DecodeString(ext)
+	if err != nil {
+		return redacted
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return redacted
+	}
+	if cert, ok := decoded["certificate"].(map[string]interface{}); ok {
+		for _, secret := range []string{"signature", "seed"} {
+			if _, ok := cert[secret]; ok {
+				cert[secret] = redacted
+			}
+		}
+	}
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(decoded); err != nil {
+		return redacted
+	}
+	return strings.TrimSpace(buf.String())
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+)
+
+// Error is the machine-readable form of the error of a command, as rendered
+// with `-o json`. The fields describing the API call are only set for
+// errors of API calls.
+type Error struct {
+	// Service is the name of the service called, e.g. queue.
+	Service string `json:"service,omitempty"`
+	// Endpoint is the method and path of the call, relative to the API of
+	// the service, e.g. "GET /task/fNSvgZZ2TMKu4xM0Q4TNTA/status". The path
+	// is that of the request, with its ids, rather than the route pattern.
+	Endpoint string `json:"endpoint,omitempty"`
+	// StatusCode is the HTTP status of the response, if one was received.
+	StatusCode int `json:"statusCode,omitempty"`
+	// RequestID identifies the request in the logs of the deployment.
+	RequestID string `json:"requestId,omitempty"`
+	// Code is the error code given by the service, e.g. ResourceNotFound.
+	Code string `json:"code,omitempty"`
+	// Message is the error message of the service, or that of the command
+	// for other errors.
+	Message string `json:"message"`
+	// Error is the error reported by the command, without the summary of
+	// the failed API call, if any.
+	Error string `json:"error"`
+}
+
+// NewError returns the machine-readable form of err, with the details of
+// the failed API call it wraps, if any.
+func NewError(err error) *Error {
+	summary := strings.SplitN(err.Error(), "\n", 2)[0]
+	e := &Error{Message: summary, Error: summary}
+
+	var apiErr *tcclient.APICallException
+	if !errors.As(err, &apiErr) || apiErr.CallSummary == nil {
+		return e
+	}
+	if context := strings.TrimSuffix(err.Error(), apiErr.Error()); context != err.Error() {
+		// what the command was doing, e.g. "could not get task abc"
+		e.Error = strings.TrimRight(context, ": ")
+	}
+	cs := apiErr.CallSummary
+	if req := cs.HTTPRequest; req != nil {
+		e.RequestID = req.Header.Get("X-Request-Id")
+		e.Service, e.Endpoint = endpoint(req.Method, req.URL.Path)
+	}
+	if resp := cs.HTTPResponse; resp != nil {
+		e.StatusCode = resp.StatusCode
+		if id := resp.Header.Get("X-Request-Id"); id != "" {
+			e.RequestID = id
+		}
+	}
+	var body struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal([]byte(cs.HTTPResponseBody), &body) == nil && body.Message != "" {
+		e.Code = body.Code
+		// the message of the service ends with the details of the request,
+		// which are already in the other fields
+		e.Message = strings.TrimSpace(strings.SplitN(body.Message, "\n---\n", 2)[0])
+	} else if apiErr.RootCause != nil {
+		e.Message = apiErr.RootCause.Error()
+	}
+	return e
+}
+
+// endpoint splits the path of an API call, /api/<service>/<version>/<path>,
+// into the name of the service and "<method> /<path>".
+func endpoint(method, path string) (service, endpoint string) {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 4)
+	if len(parts) < 3 || parts[0] != "api" {
+		return "", method + " " + path
+	}
+	if len(parts) == 3 {
+		return parts[1], method + " /"
+	}
+	return parts[1], method + " /" + parts[3]
+}
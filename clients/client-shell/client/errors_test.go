@@ -0,0 +1,39 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+)
+
+func TestNewError(t *testing.T) {
+	assert := assert.New(t)
+
+	req, _ := http.NewRequest("GET", "https://tc.example.com/api/queue/v1/task/abc/status", nil)
+	req.Header.Set("X-Request-Id", "req-1")
+	apiErr := &tcclient.APICallException{
+		CallSummary: &tcclient.CallSummary{
+			HTTPRequest:      req,
+			HTTPResponse:     &http.Response{StatusCode: 404, Header: http.Header{}},
+			HTTPResponseBody: `{"code": "ResourceNotFound", "message": "Task abc not found\n\n---\n\n* method: status"}`,
+		},
+		RootCause: errors.New("(Intermittent) HTTP response code 404"),
+	}
+	err := fmt.Errorf("could not get the status of task abc: %w", apiErr)
+
+	assert.Equal(&Error{
+		Service:    "queue",
+		Endpoint:   "GET /task/abc/status",
+		StatusCode: 404,
+		RequestID:  "req-1",
+		Code:       "ResourceNotFound",
+		Message:    "Task abc not found",
+		Error:      "could not get the status of task abc",
+	}, NewError(err))
+
+	assert.Equal(&Error{Message: "invalid --expires", Error: "invalid --expires"}, NewError(errors.New("invalid --expires")))
+}
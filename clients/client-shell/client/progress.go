@@ -0,0 +1,30 @@
+package client
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/taskcluster/taskcluster/v27/internal/progress"
+)
+
+// Quiet suppresses progress output, as enabled by --quiet.
+var Quiet bool
+
+// Progress returns the writer progress output is written to: out, or
+// ioutil.Discard with --quiet. Results and errors are never suppressed.
+func Progress(out io.Writer) io.Writer {
+	if Quiet {
+		return ioutil.Discard
+	}
+	return out
+}
+
+// Reporter returns the progress.Reporter of an operation, writing to out: a
+// progress bar if out is a terminal, a line per event otherwise, or nothing
+// with --quiet.
+func Reporter(out io.Writer, operation string) progress.Reporter {
+	if Quiet {
+		return progress.Discard
+	}
+	return progress.New(out, operation)
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/taskcluster/httpbackoff/v3"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+)
+
+const (
+	// DefaultRetries is the number of times failed requests are retried,
+	// unless given with --retries.
+	DefaultRetries = 5
+
+	// maxRetryAfter bounds the delays requested by Retry-After headers.
+	maxRetryAfter = 5 * time.Minute
+)
+
+var (
+	// Retries is the number of times failed requests are retried, as set
+	// by UseRetries.
+	Retries = DefaultRetries
+
+	// sleep waits between attempts; it is replaced in tests.
+	sleep = time.Sleep
+)
+
+// A Retrier retries HTTP requests which fail with a network error, a 5xx
+// response or a 429 (Too Many Requests) response, with exponential backoff
+// and jitter. The delay requested by the Retry-After header of a response
+// is honored.
+type Retrier struct {
+	// Retries is the maximum number of retries of a request.
+	Retries int
+	// InitialDelay is the delay before the first retry, which doubles with
+	// every further retry, up to MaxDelay.
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+}
+
+// UseRetries makes all API calls retry failed requests the given number of
+// times.
+func UseRetries(retries int) {
+	Retries = retries
+	r := &Retrier{
+		Retries:      retries,
+		InitialDelay: 500 * time.Millisecond,
+		MaxDelay:     30 * time.Second,
+	}
+	tcclient.RetryFunc = r.Retry
+}
+
+// Retry calls httpCall until it succeeds, fails permanently, or the retries
+// are exhausted; it can be used as tcclient.RetryFunc. Non-2xx responses
+// are reported with an httpbackoff.BadHttpResponseCode error, as by
+// httpbackoff.Retry.
+func (r *Retrier) Retry(httpCall func() (*http.Response, error, error)) (*http.Response, int, error) {
+	var resp *http.Response
+	var tempErr, permErr error
+	attempts := 0
+	for {
+		attempts++
+		resp, tempErr, permErr = httpCall()
+		if permErr != nil {
+			return resp, attempts, permErr
+		}
+		var delay time.Duration
+		switch {
+		case tempErr != nil:
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode/100 == 5:
+			delay = retryAfter(resp)
+		case resp.StatusCode/100 != 2:
+			return resp, attempts, badResponse(resp)
+		default:
+			return resp, attempts, nil
+		}
+
+		if attempts > r.Retries {
+			if tempErr != nil {
+				return resp, attempts, tempErr
+			}
+			return resp, attempts, badResponse(resp)
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if delay == 0 {
+			delay = r.backoff(attempts)
+		}
+		if Debug != nil {
+			fmt.Fprintf(Debug, "    attempt %d of %d failed, retrying in %s\n", attempts, r.Retries+1, delay.Round(time.Millisecond))
+		}
+		sleep(delay)
+	}
+}
+
+// backoff returns the delay before the given retry: the exponential delay,
+// randomized between half of it and all of it.
+func (r *Retrier) backoff(retry int) time.Duration {
+	delay := r.InitialDelay
+	for i := 1; i < retry && delay < r.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > r.MaxDelay {
+		delay = r.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// retryAfter returns the delay requested by the Retry-After header of resp,
+// given either in seconds or as a date, or 0 if there is none.
+func retryAfter(resp *http.Response) time.Duration {
+	header := resp.Header.Get("Retry-After")
+	if header == "" {
+		return 0
+	}
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(header); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(header); err == nil {
+		delay = time.Until(date)
+	}
+	switch {
+	case delay < 0:
+		return 0
+	case delay > maxRetryAfter:
+		return maxRetryAfter
+	}
+	return delay
+}
+
+func badResponse(resp *http.Response) error {
+	return httpbackoff.BadHttpResponseCode{
+		HttpResponseCode: resp.StatusCode,
+		Message:          resp.Status,
+	}
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+	"github.com/taskcluster/httpbackoff/v3"
+)
+
+// responses returns an httpCall returning responses with the given status
+// codes in turn, and the number of calls made.
+func responses(codes ...int) (func() (*http.Response, error, error), *int) {
+	calls := 0
+	return func() (*http.Response, error, error) {
+		code := codes[calls]
+		calls++
+		resp := &http.Response{
+			StatusCode: code,
+			Status:     http.StatusText(code),
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}
+		if code == http.StatusTooManyRequests {
+			resp.Header.Set("Retry-After", "7")
+		}
+		return resp, nil, nil
+	}, &calls
+}
+
+func TestRetry(t *testing.T) {
+	assert := assert.New(t)
+	var delays []time.Duration
+	defer func(orig func(time.Duration)) { sleep = orig }(sleep)
+	sleep = func(d time.Duration) { delays = append(delays, d) }
+
+	r := &Retrier{Retries: 3, InitialDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	call, calls := responses(500, 429, 200)
+	resp, attempts, err := r.Retry(call)
+	assert.NoError(err)
+	assert.Equal(200, resp.StatusCode)
+	assert.Equal(3, attempts)
+	assert.Equal(3, *calls)
+	assert.Len(delays, 2)
+	assert.True(delays[0] >= 500*time.Millisecond && delays[0] <= time.Second, "unexpected delay "+delays[0].String())
+	assert.Equal(7*time.Second, delays[1], "Retry-After should be honored")
+
+	// client errors are not retried
+	call, calls = responses(404)
+	_, attempts, err = r.Retry(call)
+	assert.Equal(1, attempts)
+	assert.Equal(404, err.(httpbackoff.BadHttpResponseCode).HttpResponseCode)
+
+	// retries are limited
+	call, calls = responses(503, 503, 503, 503, 200)
+	_, attempts, err = r.Retry(call)
+	assert.Equal(4, attempts)
+	assert.Equal(4, *calls)
+	assert.Equal(503, err.(httpbackoff.BadHttpResponseCode).HttpResponseCode)
+}
+
+func TestBackoff(t *testing.T) {
+	assert := assert.New(t)
+	r := &Retrier{InitialDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	for i := 0; i < 100; i++ {
+		d := r.backoff(3)
+		assert.True(d >= 2*time.Second && d <= 4*time.Second, "unexpected delay for the third retry: "+d.String())
+		d = r.backoff(10)
+		assert.True(d >= 2500*time.Millisecond && d <= 5*time.Second, "unexpected delay, above the maximum: "+d.String())
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	assert := assert.New(t)
+	resp := &http.Response{Header: http.Header{}}
+	assert.Equal(time.Duration(0), retryAfter(resp))
+
+	resp.Header.Set("Retry-After", "120")
+	assert.Equal(2*time.Minute, retryAfter(resp))
+
+	resp.Header.Set("Retry-After", "86400")
+	assert.Equal(maxRetryAfter, retryAfter(resp))
+
+	resp.Header.Set("Retry-After", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.InDelta(float64(time.Minute), float64(retryAfter(resp)), float64(2*time.Second))
+}
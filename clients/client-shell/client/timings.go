@@ -0,0 +1,132 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Timings records the latency of the HTTP requests made, as enabled by
+// --profile-cli; nil disables recording. It is read by ConfigureTransport.
+// Timings are only ever written to stderr, and never leave the machine.
+var Timings *Recorder
+
+// idSegment matches the segments of API paths which are ids, such as
+// slugids and run ids, so that calls to the same endpoint are grouped.
+var idSegment = regexp.MustCompile(`^([A-Za-z0-9_-]{22}|[0-9]+)$`)
+
+// Recorder accumulates the latency of HTTP requests, by endpoint.
+type Recorder struct {
+	mu        sync.Mutex
+	start     time.Time
+	endpoints map[string]*endpointTimings
+}
+
+type endpointTimings struct {
+	calls, errors int
+	total, max    time.Duration
+}
+
+// NewRecorder returns a Recorder measuring the duration of the command from
+// now.
+func NewRecorder() *Recorder {
+	return &Recorder{start: time.Now(), endpoints: make(map[string]*endpointTimings)}
+}
+
+func (r *Recorder) record(endpoint string, duration time.Duration, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.endpoints[endpoint]
+	if !ok {
+		e = &endpointTimings{}
+		r.endpoints[endpoint] = e
+	}
+	e.calls++
+	e.total += duration
+	if duration > e.max {
+		e.max = duration
+	}
+	if failed {
+		e.errors++
+	}
+}
+
+// WriteReport writes the duration of the command, and the number and
+// latency of the requests made to each endpoint, slowest first.
+func (r *Recorder) WriteReport(out io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	endpoints := make([]string, 0, len(r.endpoints))
+	calls, total := 0, time.Duration(0)
+	for endpoint, e := range r.endpoints {
+		endpoints = append(endpoints, endpoint)
+		calls += e.calls
+		total += e.total
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		a, b := r.endpoints[endpoints[i]], r.endpoints[endpoints[j]]
+		if a.total != b.total {
+			return a.total > b.total
+		}
+		return endpoints[i] < endpoints[j]
+	})
+
+	fmt.Fprintf(out, "\nProfile: the command took %s, of which %s in %d HTTP requests.\n",
+		round(time.Since(r.start)), round(total), calls)
+	if calls == 0 {
+		return
+	}
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "ENDPOINT\tCALLS\tTOTAL\tMEAN\tMAX\tERRORS")
+	for _, endpoint := range endpoints {
+		e := r.endpoints[endpoint]
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%d\n",
+			endpoint, e.calls, round(e.total), round(e.total/time.Duration(e.calls)), round(e.max), e.errors)
+	}
+	w.Flush()
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(time.Millisecond)
+}
+
+// timingKey names the endpoint of a request, e.g. "queue GET /task/<id>/status"
+// for API calls, without their ids, or "GET s3.amazonaws.com" for others,
+// such as artifact downloads.
+func timingKey(req *http.Request) string {
+	service, call := endpoint(req.Method, req.URL.EscapedPath())
+	if service == "" {
+		return req.Method + " " + req.URL.Host
+	}
+	segments := strings.Split(call, "/")
+	for i, segment := range segments {
+		if segment == "artifacts" && i < len(segments)-1 {
+			segments = append(segments[:i+1], "<name>")
+			break
+		}
+		if idSegment.MatchString(segment) {
+			segments[i] = "<id>"
+		}
+	}
+	return service + " " + strings.Join(segments, "/")
+}
+
+// timingTransport records the latency of the requests made through it.
+type timingTransport struct {
+	next    http.RoundTripper
+	timings *Recorder
+}
+
+func (t *timingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	t.timings.record(timingKey(req), time.Since(start), err != nil || resp.StatusCode >= 400)
+	return resp, err
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestTimingKey(t *testing.T) {
+	assert := assert.New(t)
+
+	for url, expected := range map[string]string{
+		"https://tc.example.com/api/queue/v1/task/fN1SbArXTPSVFNUvaOlinQ/status":                      "queue GET /task/<id>/status",
+		"https://tc.example.com/api/queue/v1/task/fN1SbArXTPSVFNUvaOlinQ/runs/0/artifacts/public%2Fx": "queue GET /task/<id>/runs/<id>/artifacts/<name>",
+		"https://tc.example.com/api/index/v1/task/project.app.latest":                                 "index GET /task/project.app.latest",
+		"https://tc.example.com/api/auth/v1/ping":                                                     "auth GET /ping",
+		"https://bucket.s3.amazonaws.com/some/object?X-Amz-Signature=sig":                             "GET bucket.s3.amazonaws.com",
+	} {
+		req, _ := http.NewRequest("GET", url, nil)
+		assert.Equal(expected, timingKey(req), url)
+	}
+}
+
+func TestTimingTransport(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/queue/v1/task/fN1SbArXTPSVFNUvaOlinQ" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	timings := NewRecorder()
+	client := &http.Client{Transport: &timingTransport{next: http.DefaultTransport, timings: timings}}
+	for _, path := range []string{
+		"/api/queue/v1/task/fN1SbArXTPSVFNUvaOlinQ/status",
+		"/api/queue/v1/task/c8iC4L1_S9uqvRWw5Bb6ew/status",
+		"/api/queue/v1/task/fN1SbArXTPSVFNUvaOlinQ",
+	} {
+		resp, err := client.Get(server.URL + path)
+		assert.NoError(err)
+		resp.Body.Close()
+	}
+
+	assert.Equal(2, timings.endpoints["queue GET /task/<id>/status"].calls)
+	assert.Equal(0, timings.endpoints["queue GET /task/<id>/status"].errors)
+	assert.Equal(1, timings.endpoints["queue GET /task/<id>"].errors)
+
+	out := &bytes.Buffer{}
+	timings.WriteReport(out)
+	assert.Regexp(`^
+Profile: the command took [0-9.]+m?s, of which [0-9.]+m?s in 3 HTTP requests.
+ENDPOINT +CALLS +TOTAL +MEAN +MAX +ERRORS
+`, out.String())
+	assert.Regexp(`queue GET /task/<id>/status +2 .* 0\n`, out.String())
+	assert.Regexp(`queue GET /task/<id> +1 .* 1\n`, out.String())
+}
+
+func TestWriteReportOrder(t *testing.T) {
+	assert := assert.New(t)
+
+	timings := NewRecorder()
+	timings.record("queue GET /fast", time.Millisecond, false)
+	timings.record("queue GET /slow", time.Second, false)
+	timings.record("queue GET /slow", 3*time.Second, false)
+
+	out := &bytes.Buffer{}
+	timings.WriteReport(out)
+	assert.Contains(out.String(), `
+ENDPOINT         CALLS  TOTAL  MEAN  MAX  ERRORS
+queue GET /slow  2      4s     2s    3s   0
+queue GET /fast  1      1ms    1ms   1ms  0
+`)
+}
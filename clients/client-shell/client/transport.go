@@ -0,0 +1,75 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// defaultTransport is the original http.DefaultTransport, which
+// ConfigureTransport starts from.
+var defaultTransport = http.DefaultTransport.(*http.Transport)
+
+// ConfigureTransport sets up http.DefaultTransport, which all API calls and
+// downloads go through. Proxies are given by the HTTPS_PROXY, HTTP_PROXY
+// and NO_PROXY environment variables. caCertFile is the path to a file of
+// PEM certificates to trust in addition to the system ones, if not empty;
+// insecure disables the verification of certificates altogether. Requests
+// are logged if Debug is set, responses cached if CacheTTL is, and the
+// latency of requests, including cached ones, recorded if Timings is. The
+// requests and responses are recorded to RecordFile if it is set, and those
+// of ReplayFile served instead of making requests if it is.
+func ConfigureTransport(caCertFile string, insecure bool) error {
+	transport := defaultTransport.Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	tlsConfig := &tls.Config{InsecureSkipVerify: insecure}
+
+	if caCertFile != "" {
+		pem, err := ioutil.ReadFile(caCertFile)
+		if err != nil {
+			return fmt.Errorf("could not read CA certificates: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			// e.g. on Windows, where the system pool is not available
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no PEM certificates found in %s", caCertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	var roundTripper http.RoundTripper = transport
+	if Debug != nil {
+		roundTripper = &debugTransport{next: roundTripper, log: Debug}
+	}
+	if CacheTTL > 0 {
+		dir, err := cacheDir()
+		if err != nil {
+			return fmt.Errorf("could not find the cache directory: %w", err)
+		}
+		roundTripper = &cacheTransport{next: roundTripper, dir: dir, ttl: CacheTTL, log: Debug}
+	}
+	if RecordFile != "" && ReplayFile != "" {
+		return fmt.Errorf("requests cannot be both recorded and replayed")
+	}
+	if RecordFile != "" {
+		roundTripper = &recordTransport{next: roundTripper, path: RecordFile}
+	}
+	if ReplayFile != "" {
+		replay, err := newReplayTransport(ReplayFile)
+		if err != nil {
+			return err
+		}
+		roundTripper = replay
+	}
+	if Timings != nil {
+		roundTripper = &timingTransport{next: roundTripper, timings: Timings}
+	}
+	http.DefaultTransport = roundTripper
+	return nil
+}
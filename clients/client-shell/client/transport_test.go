@@ -0,0 +1,49 @@
+package client
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestConfigureTransport(t *testing.T) {
+	assert := assert.New(t)
+	defer func(orig http.RoundTripper) { http.DefaultTransport = orig }(http.DefaultTransport)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "taskcluster-ca")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	caCert := filepath.Join(dir, "ca.pem")
+	assert.NoError(ioutil.WriteFile(caCert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0644))
+
+	get := func() error {
+		resp, err := (&http.Client{}).Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	assert.NoError(ConfigureTransport("", false))
+	assert.Error(get(), "the certificate of the server should not be trusted")
+
+	assert.NoError(ConfigureTransport(caCert, false))
+	assert.NoError(get(), "the certificate given as CA should be trusted")
+
+	assert.NoError(ConfigureTransport("", true))
+	assert.NoError(get(), "certificates should not be verified")
+
+	assert.Error(ConfigureTransport(filepath.Join(dir, "missing.pem"), false))
+	notPEM := filepath.Join(dir, "not.pem")
+	assert.NoError(ioutil.WriteFile(notPEM, []byte("hello"), 0644))
+	assert.Error(ConfigureTransport(notPEM, false))
+}
@@ -0,0 +1,322 @@
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+)
+
+// RecordFile is the file the HTTP interactions are recorded to, as given by
+// --record; empty disables recording. It is read by ConfigureTransport.
+var RecordFile string
+
+// ReplayFile is the file of recorded HTTP interactions whose responses are
+// served instead of making requests, as given by --replay; empty disables
+// replaying. It is read by ConfigureTransport.
+var ReplayFile string
+
+// secretFields are the fields of JSON bodies whose values are never
+// recorded: credentials, as returned by the auth service, and the values of
+// secrets.
+var secretFields = map[string]bool{
+	"accessToken": true,
+	"certificate": true,
+	"password":    true,
+	"secret":      true,
+}
+
+// A Recording is a sequence of HTTP interactions, as written by --record.
+type Recording struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// An Interaction is a request and its response, without their secrets.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request of an Interaction; its URL has no signature,
+// and its headers are those the debug log shows.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response of an Interaction.
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// A Body is the body of a request or response; it is recorded as a string if
+// it is text, and in base64 otherwise.
+type Body []byte
+
+// MarshalJSON implements json.Marshaler.
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*b = Body(text)
+		return nil
+	}
+	var encoded struct {
+		Base64 string `json:"base64"`
+	}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded.Base64)
+	*b = decoded
+	return err
+}
+
+// scrubBody returns a body without the values of the secret fields, if it
+// is a JSON document.
+func scrubBody(body []byte) []byte {
+	var decoded interface{}
+	if len(body) == 0 || json.Unmarshal(body, &decoded) != nil {
+		return body
+	}
+	scrubbed, err := json.Marshal(scrubValue(decoded))
+	if err != nil {
+		return body
+	}
+	return scrubbed
+}
+
+func scrubValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if secretFields[key] {
+				v[key] = redacted
+			} else {
+				v[key] = scrubValue(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = scrubValue(value)
+		}
+	}
+	return v
+}
+
+// scrubHeader returns the headers without their secrets.
+func scrubHeader(header http.Header) http.Header {
+	scrubbed := make(http.Header, len(header))
+	for name, values := range header {
+		for _, value := range values {
+			scrubbed.Add(name, redactHeader(name, value))
+		}
+	}
+	return scrubbed
+}
+
+// recordTransport records the requests made through it, and their
+// responses, rewriting the file of the recording after each of them, so
+// that it is complete even if the command fails. Responses are recorded once
+// their body has been read to the end or closed, so that they still stream,
+// e.g. for task log --follow.
+type recordTransport struct {
+	next http.RoundTripper
+	path string
+
+	mu        sync.Mutex
+	recording Recording
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err == nil {
+			reqBody, _ = ioutil.ReadAll(body)
+			body.Close()
+		}
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	// the length of the body changes once it is scrubbed
+	responseHeader := scrubHeader(resp.Header)
+	responseHeader.Del("Content-Length")
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    redactURL(req.URL),
+			Header: scrubHeader(req.Header),
+			Body:   scrubBody(reqBody),
+		},
+		Response: RecordedResponse{
+			Status: resp.StatusCode,
+			Header: responseHeader,
+		},
+	}
+	resp.Body = &recordingBody{body: resp.Body, record: func(body []byte) error {
+		interaction.Response.Body = scrubBody(body)
+		return t.add(interaction)
+	}}
+	return resp, nil
+}
+
+// add appends an interaction to the recording, and writes it.
+func (t *recordTransport) add(interaction Interaction) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.recording.Interactions = append(t.recording.Interactions, interaction)
+	return t.write()
+}
+
+// recordingBody is the body of a recorded response, which copies what is
+// read from it, and passes it to record at the end of the body, or when it
+// is closed.
+type recordingBody struct {
+	body   io.ReadCloser
+	buf    bytes.Buffer
+	record func([]byte) error
+
+	recorded bool
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.buf.Write(p[:n])
+	if err == io.EOF {
+		if recordErr := b.finish(); recordErr != nil {
+			return n, recordErr
+		}
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	err := b.body.Close()
+	if recordErr := b.finish(); err == nil {
+		err = recordErr
+	}
+	return err
+}
+
+// finish records the body read so far, the first time it is called.
+func (b *recordingBody) finish() error {
+	if b.recorded {
+		return nil
+	}
+	b.recorded = true
+	return b.record(b.buf.Bytes())
+}
+
+// write replaces the file of the recording, so that it is never partially
+// written.
+func (t *recordTransport) write() error {
+	data, err := json.MarshalIndent(t.recording, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(t.path), filepath.Base(t.path)+".*")
+	if err != nil {
+		return fmt.Errorf("could not write the recording %s: %w", t.path, err)
+	}
+	_, err = f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), t.path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("could not write the recording %s: %w", t.path, err)
+	}
+	return nil
+}
+
+// replayTransport serves the responses of a recording, without making any
+// request: each request gets the response of the next interaction with its
+// method and URL, in the order they were recorded, or a 404 response if
+// there is none, which is not retried as an error would be.
+type replayTransport struct {
+	path string
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// newReplayTransport reads a recording.
+func newReplayTransport(path string) (*replayTransport, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the recording: %w", err)
+	}
+	var recording Recording
+	if err := json.Unmarshal(data, &recording); err != nil {
+		return nil, fmt.Errorf("could not decode the recording %s: %w", path, err)
+	}
+	return &replayTransport{
+		path:         path,
+		interactions: recording.Interactions,
+		used:         make([]bool, len(recording.Interactions)),
+	}, nil
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	u := redactURL(req.URL)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, interaction := range t.interactions {
+		if t.used[i] || interaction.Request.Method != req.Method || interaction.Request.URL != u {
+			continue
+		}
+		t.used[i] = true
+		return replayResponse(req, interaction.Response.Status, interaction.Response.Header, interaction.Response.Body), nil
+	}
+	body, _ := json.Marshal(map[string]string{
+		"code":    "ResourceNotFound",
+		"message": fmt.Sprintf("no recorded response to %s %s in %s", req.Method, u, t.path),
+	})
+	return replayResponse(req, http.StatusNotFound, http.Header{"Content-Type": {"application/json"}}, body), nil
+}
+
+func replayResponse(req *http.Request, status int, header http.Header, body []byte) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
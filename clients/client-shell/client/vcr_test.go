@@ -0,0 +1,130 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestRecordReplay(t *testing.T) {
+	assert := assert.New(t)
+	defer func(orig http.RoundTripper) { http.DefaultTransport = orig }(http.DefaultTransport)
+	defer func() { RecordFile, ReplayFile = "", "" }()
+
+	dir, err := ioutil.TempDir("", "taskcluster-vcr")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "interactions.json")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/auth/v1/clients/me":
+			_, _ = w.Write([]byte(`{"clientId": "me", "accessToken": "s3cr3t", "scopes": ["a"]}`))
+		case "/artifact":
+			_, _ = w.Write([]byte{0xff, 0x00, 0x01})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	do := func(method, url, body string) (int, string) {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		assert.NoError(err)
+		req.Header.Set("Authorization", `Hawk id="me", mac="secretmac="`)
+		resp, err := (&http.Client{}).Do(req)
+		assert.NoError(err)
+		defer resp.Body.Close()
+		data, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(err)
+		return resp.StatusCode, string(data)
+	}
+
+	RecordFile = path
+	assert.NoError(ConfigureTransport("", false))
+	status, body := do("PUT", server.URL+"/api/auth/v1/clients/me", `{"scopes": ["a"]}`)
+	assert.Equal(200, status)
+	assert.Contains(body, "s3cr3t", "the response should not be altered")
+	status, body = do("GET", server.URL+"/artifact?bewit=abc", "")
+	assert.Equal(200, status)
+	assert.Equal("\xff\x00\x01", body)
+	status, _ = do("GET", server.URL+"/missing", "")
+	assert.Equal(404, status)
+	server.Close()
+
+	recording, err := ioutil.ReadFile(path)
+	assert.NoError(err)
+	for _, secret := range []string{"s3cr3t", "secretmac", "bewit=abc"} {
+		assert.NotContains(string(recording), secret)
+	}
+	assert.Contains(string(recording), `"clientId\":\"me\"`)
+
+	RecordFile, ReplayFile = "", path
+	assert.NoError(ConfigureTransport("", false))
+	status, body = do("PUT", server.URL+"/api/auth/v1/clients/me", "")
+	assert.Equal(200, status)
+	assert.JSONEq(`{"clientId": "me", "accessToken": "<redacted>", "scopes": ["a"]}`, body)
+	status, body = do("GET", server.URL+"/artifact?bewit=other", "")
+	assert.Equal(200, status)
+	assert.Equal("\xff\x00\x01", body)
+	status, _ = do("GET", server.URL+"/missing", "")
+	assert.Equal(404, status)
+
+	// each interaction is replayed once
+	status, body = do("GET", server.URL+"/artifact?bewit=other", "")
+	assert.Equal(404, status)
+	assert.Contains(body, "no recorded response to GET "+server.URL+"/artifact?bewit=%3Credacted%3E in "+path)
+
+	RecordFile = path
+	assert.Error(ConfigureTransport("", false), "recording and replaying are exclusive")
+	RecordFile, ReplayFile = "", filepath.Join(dir, "missing.json")
+	assert.Error(ConfigureTransport("", false))
+}
+
+func TestRecordStreamsResponses(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "taskcluster-vcr")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "interactions.json")
+
+	// the log only ends once the first line has been read by the client
+	firstLineRead := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("first line\n"))
+		w.(http.Flusher).Flush()
+		<-firstLineRead
+		_, _ = w.Write([]byte("second line\n"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &recordTransport{next: http.DefaultTransport, path: path}}
+	resp, err := client.Get(server.URL + "/log")
+	assert.NoError(err)
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	assert.NoError(err)
+	assert.Equal("first line\n", line)
+	_, err = os.Stat(path)
+	assert.True(os.IsNotExist(err), "the response should not be recorded before it is read")
+
+	close(firstLineRead)
+	rest, err := ioutil.ReadAll(reader)
+	assert.NoError(err)
+	assert.Equal("second line\n", string(rest))
+	assert.NoError(resp.Body.Close())
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(err)
+	var recording Recording
+	assert.NoError(json.Unmarshal(data, &recording))
+	assert.Len(recording.Interactions, 1)
+	assert.Equal("first line\nsecond line\n", string(recording.Interactions[0].Response.Body))
+}
@@ -0,0 +1,226 @@
+// Package artifact implements the artifact subcommands.
+package artifact
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/artifacts"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/client"
+	fromNow "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/from-now"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+var (
+	// Command is the root of the artifact subtree.
+	Command = &cobra.Command{
+		Use:   "artifact",
+		Short: "Provides artifact-related actions.",
+	}
+)
+
+func init() {
+	downloadCmd := &cobra.Command{
+		Use:   "download <taskId> [<name>...]",
+		Short: "Download artifacts of a task.",
+		Long: `Download the named artifacts of a task, or all of them with --all, into the
+destination directory. The directory structure of the artifact names is
+preserved, e.g. public/logs/live.log is downloaded to <dest>/public/logs/live.log.
+
+Artifacts are downloaded concurrently, and failed downloads are retried,
+resuming where they stopped. The content is checked against the SHA256
+announced by the queue, when there is one.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var creds *tcclient.Credentials
+			if config.Credentials != nil {
+				creds = config.Credentials.ToClientCredentials()
+			}
+			return runDownload(creds, args, cmd.OutOrStdout(), cmd.Flags())
+		},
+	}
+	downloadCmd.Flags().BoolP("all", "a", false, "Download all the artifacts of the run.")
+	downloadCmd.Flags().IntP("run", "r", -1, "RunId of the run to download the artifacts of (defaults to the latest run).")
+	downloadCmd.Flags().StringP("dest", "d", ".", "Directory to download the artifacts to.")
+	downloadCmd.Flags().IntP("concurrency", "j", 4, "Maximum number of concurrent downloads.")
+	downloadCmd.Flags().Int("retries", 5, "Number of times a failed download is retried.")
+
+	Command.AddCommand(downloadCmd)
+
+	uploadCmd := &cobra.Command{
+		Use:   "upload <taskId> <runId> <path>",
+		Short: "Upload a file or directory as artifacts of a task.",
+		Long: `Upload a file as an artifact of a run of a task, or all the files under a
+directory as artifacts whose names mirror the directory structure.
+
+The artifact is named after the file, in public/, unless --name is given; for
+directories, --name is the prefix of the artifact names. The content type is
+guessed from the file extension, unless --content-type is given. The artifacts
+expire with the task, unless --expires is given.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var creds *tcclient.Credentials
+			if config.Credentials != nil {
+				creds = config.Credentials.ToClientCredentials()
+			}
+			return runUpload(creds, args, cmd.OutOrStdout(), cmd.Flags())
+		},
+	}
+	uploadCmd.Flags().StringP("name", "n", "", "Name of the artifact, or prefix of the artifact names for a directory.")
+	uploadCmd.Flags().String("content-type", "", "Content type of the artifacts (guessed from the file extensions by default).")
+	uploadCmd.Flags().String("expires", "", "Lifetime of the artifacts, e.g. '1 year' (defaults to the expiry of the task).")
+	uploadCmd.Flags().Int("retries", 5, "Number of times a failed upload is retried.")
+
+	Command.AddCommand(uploadCmd)
+	root.Command.AddCommand(Command)
+}
+
+func makeQueue(credentials *tcclient.Credentials) *tcqueue.Queue {
+	return tcqueue.New(credentials, config.RootURL())
+}
+
+// runDownload downloads the artifacts of a task given in args, or all of
+// them with --all.
+func runDownload(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	if len(args) < 1 {
+		return errors.New("download expects argument <taskId>")
+	}
+	taskID, names := args[0], args[1:]
+	all, _ := flags.GetBool("all")
+	if all == (len(names) > 0) {
+		return errors.New("either artifact names or --all must be given")
+	}
+
+	q := makeQueue(credentials)
+	runID, _ := flags.GetInt("run")
+	if runID < 0 {
+		s, err := q.Status(taskID)
+		if err != nil {
+			return fmt.Errorf("could not get the status of the task %s: %w", taskID, err)
+		}
+		if len(s.Status.Runs) == 0 {
+			return fmt.Errorf("task %s has no runs", taskID)
+		}
+		runID = int(s.Status.Runs[len(s.Status.Runs)-1].RunID)
+	}
+	run := strconv.Itoa(runID)
+
+	if all {
+		list, err := artifacts.List(q, taskID, run)
+		if err != nil {
+			return err
+		}
+		for _, a := range list {
+			// error artifacts only record that an artifact is missing
+			if a.StorageType == "error" {
+				fmt.Fprintf(out, "skipping error artifact %s\n", a.Name)
+				continue
+			}
+			names = append(names, a.Name)
+		}
+	}
+
+	d := &artifacts.Downloader{Queue: q, Progress: client.Reporter(os.Stderr, "downloading artifacts")}
+	d.Concurrency, _ = flags.GetInt("concurrency")
+	d.Retries, _ = flags.GetInt("retries")
+	dest, _ := flags.GetString("dest")
+
+	failed := 0
+	for _, result := range d.DownloadAll(taskID, run, names, dest) {
+		if result.Err != nil {
+			failed++
+			fmt.Fprintln(out, result.Err)
+			continue
+		}
+		fmt.Fprintf(out, "downloaded %s to %s\n", result.Name, result.Path)
+	}
+	if failed > 0 {
+		return fmt.Errorf("could not download %d of %d artifacts", failed, len(names))
+	}
+	return nil
+}
+
+// runUpload uploads the file or directory given in args as artifacts of a
+// task.
+func runUpload(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	if len(args) != 3 {
+		return errors.New("upload expects arguments <taskId> <runId> <path>")
+	}
+	taskID, runID, path := args[0], args[1], args[2]
+	name, _ := flags.GetString("name")
+	contentType, _ := flags.GetString("content-type")
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	// map artifact names to the files to upload
+	files := make(map[string]string)
+	var names []string
+	if info.IsDir() {
+		if name == "" {
+			name = "public/"
+		} else if !strings.HasSuffix(name, "/") {
+			name += "/"
+		}
+		err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(path, file)
+			if err != nil {
+				return err
+			}
+			names = append(names, name+filepath.ToSlash(rel))
+			files[name+filepath.ToSlash(rel)] = file
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("could not list the files under %s: %w", path, err)
+		}
+	} else {
+		if name == "" {
+			name = "public/" + filepath.Base(path)
+		}
+		names = append(names, name)
+		files[name] = path
+	}
+
+	q := makeQueue(credentials)
+	var expires time.Time
+	if e, _ := flags.GetString("expires"); e != "" {
+		if expires, err = fromNow.Parse(e); err != nil {
+			return fmt.Errorf("invalid --expires: %w", err)
+		}
+	} else {
+		task, err := q.Task(taskID)
+		if err != nil {
+			return fmt.Errorf("could not get the definition of task %s: %w", taskID, err)
+		}
+		expires = time.Time(task.Expires)
+	}
+
+	u := &artifacts.Uploader{Queue: q, Progress: client.Progress(os.Stderr)}
+	u.Retries, _ = flags.GetInt("retries")
+	for _, name := range names {
+		ct := contentType
+		if ct == "" {
+			ct = artifacts.ContentType(files[name])
+		}
+		if err := u.Upload(taskID, runID, name, files[name], ct, expires); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "uploaded %s as %s\n", files[name], name)
+	}
+	return nil
+}
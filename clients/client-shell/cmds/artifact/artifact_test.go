@@ -0,0 +1,136 @@
+package artifact
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+const taskID = "ANnmjMocTymeTID0tlNJAw"
+
+func setUpServer(t *testing.T) (string, func()) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/queue/v1/task/"+taskID+"/status", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"status": {"taskId": "`+taskID+`", "state": "completed", "runs": [{"runId": 0}, {"runId": 1}]}}`)
+	})
+	handler.HandleFunc("/api/queue/v1/task/"+taskID+"/runs/1/artifacts", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"artifacts": [
+			{"name": "public/build/target.tar.gz", "storageType": "s3"},
+			{"name": "public/missing.txt", "storageType": "error"},
+			{"name": "public/logs/live.log", "storageType": "reference"}
+		]}`)
+	})
+	handler.HandleFunc("/api/queue/v1/task/"+taskID+"/runs/1/artifacts/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "content of "+filepath.Base(r.URL.Path))
+	})
+	server := httptest.NewServer(handler)
+	config.SetRootURL(server.URL)
+
+	dir, err := ioutil.TempDir("", "artifact-download")
+	require.NoError(t, err)
+
+	return dir, func() {
+		server.Close()
+		config.SetRootURL("")
+		os.RemoveAll(dir)
+	}
+}
+
+func downloadFlags(dest string, all bool) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("download", pflag.ContinueOnError)
+	flags.Bool("all", all, "")
+	flags.Int("run", -1, "")
+	flags.String("dest", dest, "")
+	flags.Int("concurrency", 2, "")
+	flags.Int("retries", 0, "")
+	return flags
+}
+
+func TestDownloadAll(t *testing.T) {
+	dir, tearDown := setUpServer(t)
+	defer tearDown()
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, runDownload(nil, []string{taskID}, buf, downloadFlags(dir, true)))
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "public", "logs", "live.log"))
+	require.NoError(t, err)
+	require.Equal(t, "content of live.log", string(content))
+	require.Equal(t, "skipping error artifact public/missing.txt\n"+
+		"downloaded public/build/target.tar.gz to "+filepath.Join(dir, "public", "build", "target.tar.gz")+"\n"+
+		"downloaded public/logs/live.log to "+filepath.Join(dir, "public", "logs", "live.log")+"\n", buf.String())
+}
+
+func TestDownloadNamed(t *testing.T) {
+	dir, tearDown := setUpServer(t)
+	defer tearDown()
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, runDownload(nil, []string{taskID, "public/logs/live.log"}, buf, downloadFlags(dir, false)))
+
+	_, err := os.Stat(filepath.Join(dir, "public", "logs", "live.log"))
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "public", "build"))
+	require.True(t, os.IsNotExist(err), "only the named artifact should be downloaded")
+}
+
+func TestDownloadArguments(t *testing.T) {
+	require.Error(t, runDownload(nil, []string{taskID}, ioutil.Discard, downloadFlags(".", false)),
+		"download should error without names or --all")
+	require.Error(t, runDownload(nil, []string{taskID, "public/a"}, ioutil.Discard, downloadFlags(".", true)),
+		"download should error with both names and --all")
+}
+
+func TestUploadDirectory(t *testing.T) {
+	uploads := make(map[string]string)
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/queue/v1/task/"+taskID, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"expires": "2030-01-01T00:00:00.000Z"}`)
+	})
+	handler.HandleFunc("/api/queue/v1/task/"+taskID+"/runs/0/artifacts/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/api/queue/v1/task/"+taskID+"/runs/0/artifacts/")
+		body, _ := ioutil.ReadAll(r.Body)
+		uploads[name] = string(body)
+		_, _ = io.WriteString(w, `{"storageType": "s3", "putUrl": "http://`+r.Host+`/storage/`+name+`"}`)
+	})
+	handler.HandleFunc("/storage/", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		uploads[r.URL.Path] = string(body)
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	config.SetRootURL(server.URL)
+	defer config.SetRootURL("")
+
+	dir, err := ioutil.TempDir("", "artifact-upload")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "logs"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "logs", "test.log"), []byte("ok"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "summary.txt"), []byte("1 passed"), 0644))
+
+	flags := pflag.NewFlagSet("upload", pflag.ContinueOnError)
+	flags.String("name", "private/results", "")
+	flags.String("content-type", "", "")
+	flags.String("expires", "", "")
+	flags.Int("retries", 0, "")
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, runUpload(nil, []string{taskID, "0", dir}, buf, flags))
+
+	require.Equal(t, "uploaded "+filepath.Join(dir, "logs", "test.log")+" as private/results/logs/test.log\n"+
+		"uploaded "+filepath.Join(dir, "summary.txt")+" as private/results/summary.txt\n", buf.String())
+	require.Equal(t, "ok", uploads["/storage/private/results/logs/test.log"])
+	require.Equal(t, "1 passed", uploads["/storage/private/results/summary.txt"])
+	require.JSONEq(t, `{"storageType": "s3", "contentType": "text/plain; charset=utf-8", "expires": "2030-01-01T00:00:00.000Z"}`, uploads["private/results/summary.txt"])
+}
@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcauth"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tchooks"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/formatter"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+func init() {
+	auditCmd := &cobra.Command{
+		Use:   "audit --scope-pattern <pattern>",
+		Short: "List the clients, roles and hooks which can obtain scopes matching a pattern.",
+		Long: `List the clients, roles and hooks which can obtain scopes matching any of
+the --scope-pattern patterns, once the roles they assume are expanded, along
+with the matching scopes.
+
+A scope matches a pattern if they have a scope in common: queue:create-task:*
+matches queue:create-task:highest:proj/ci, and the other way around.
+
+Hooks obtain the scopes of the role hook-id:<hookGroupId>/<hookId>. Disabled
+clients are skipped, unless --include-disabled is given.
+
+Use -o json or -o csv to process the results in a security review.`,
+		RunE: root.ExecuteHelperE(runAudit, 0, 0),
+	}
+	auditCmd.Flags().StringArray("scope-pattern", nil, "(can be repeated) Scope pattern to look for, e.g. 'queue:create-task:*'.")
+	auditCmd.Flags().Bool("include-disabled", false, "Include disabled clients.")
+	formatter.RegisterFlag(auditCmd.Flags())
+
+	Command.AddCommand(auditCmd)
+}
+
+// auditEntry is an entity found by runAudit, with its matching scopes.
+type auditEntry struct {
+	// Kind is client, role or hook.
+	Kind   string   `json:"kind"`
+	ID     string   `json:"id"`
+	Scopes []string `json:"scopes"`
+}
+
+// runAudit walks all clients, roles and hooks, and lists those which can
+// obtain scopes matching the --scope-pattern patterns.
+func runAudit(credentials *tcclient.Credentials, _ []string, out io.Writer, flags *pflag.FlagSet) error {
+	patterns, _ := flags.GetStringArray("scope-pattern")
+	if len(patterns) == 0 {
+		return errors.New("scope patterns must be given with --scope-pattern")
+	}
+	format, err := formatter.FromFlags(flags)
+	if err != nil {
+		return err
+	}
+	includeDisabled, _ := flags.GetBool("include-disabled")
+
+	a := makeAuth(credentials)
+	entries := []auditEntry{}
+	add := func(kind, id string, expanded []string) {
+		if matching := matchingScopes(expanded, patterns); len(matching) > 0 {
+			entries = append(entries, auditEntry{Kind: kind, ID: id, Scopes: matching})
+		}
+	}
+
+	continuation := ""
+	for {
+		resp, err := a.ListClients(continuation, "", "")
+		if err != nil {
+			return fmt.Errorf("could not list clients: %w", err)
+		}
+		for _, client := range resp.Clients {
+			if !client.Disabled || includeDisabled {
+				add("client", client.ClientID, client.ExpandedScopes)
+			}
+		}
+		if continuation = resp.ContinuationToken; continuation == "" {
+			break
+		}
+	}
+
+	for {
+		resp, err := a.ListRoles2(continuation, "")
+		if err != nil {
+			return fmt.Errorf("could not list roles: %w", err)
+		}
+		for _, role := range resp.Roles {
+			add("role", role.RoleID, role.ExpandedScopes)
+		}
+		if continuation = resp.ContinuationToken; continuation == "" {
+			break
+		}
+	}
+
+	hookIDs, err := listHookIDs(credentials)
+	if err != nil {
+		return err
+	}
+	for _, hookID := range hookIDs {
+		expanded, err := a.ExpandScopes(&tcauth.SetOfScopes{Scopes: []string{"assume:hook-id:" + hookID}})
+		if err != nil {
+			return fmt.Errorf("could not expand the scopes of hook %s: %w", hookID, err)
+		}
+		add("hook", hookID, expanded.Scopes)
+	}
+
+	rows := formatter.Rows{Header: []string{"KIND", "ID", "SCOPE"}}
+	for _, e := range entries {
+		for _, scope := range e.Scopes {
+			rows.Rows = append(rows.Rows, []string{e.Kind, e.ID, scope})
+		}
+	}
+	if format == formatter.Text {
+		if len(entries) == 0 {
+			fmt.Fprintf(out, "No clients, roles or hooks can obtain scopes matching %s.\n", strings.Join(patterns, ", "))
+			return nil
+		}
+		format = formatter.Table
+	}
+	return formatter.Write(out, format, entries, rows)
+}
+
+// listHookIDs returns the ids of all hooks, as <hookGroupId>/<hookId>.
+func listHookIDs(credentials *tcclient.Credentials) ([]string, error) {
+	h := tchooks.New(credentials, config.RootURL())
+	groups, err := h.ListHookGroups()
+	if err != nil {
+		return nil, fmt.Errorf("could not list hook groups: %w", err)
+	}
+	var hookIDs []string
+	for _, group := range groups.Groups {
+		hooks, err := h.ListHooks(group)
+		if err != nil {
+			return nil, fmt.Errorf("could not list the hooks of %s: %w", group, err)
+		}
+		for _, hook := range hooks.Hooks {
+			hookIDs = append(hookIDs, hook.HookGroupID+"/"+hook.HookID)
+		}
+	}
+	sort.Strings(hookIDs)
+	return hookIDs, nil
+}
+
+// matchingScopes returns the scopes which match any of the patterns.
+func matchingScopes(scopes, patterns []string) []string {
+	var matching []string
+	for _, scope := range scopes {
+		for _, pattern := range patterns {
+			if scopesOverlap(scope, pattern) {
+				matching = append(matching, scope)
+				break
+			}
+		}
+	}
+	return matching
+}
+
+// scopesOverlap returns whether two scopes, which end with * to match any
+// suffix, have a scope in common.
+func scopesOverlap(a, b string) bool {
+	aPrefix, aStar := strings.TrimSuffix(a, "*"), strings.HasSuffix(a, "*")
+	bPrefix, bStar := strings.TrimSuffix(b, "*"), strings.HasSuffix(b, "*")
+	switch {
+	case aStar && bStar:
+		return strings.HasPrefix(aPrefix, bPrefix) || strings.HasPrefix(bPrefix, aPrefix)
+	case aStar:
+		return strings.HasPrefix(b, aPrefix)
+	case bStar:
+		return strings.HasPrefix(a, bPrefix)
+	}
+	return a == b
+}
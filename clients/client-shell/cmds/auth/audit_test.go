@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/formatter"
+)
+
+func auditFlags(output string, patterns ...string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("audit", pflag.ContinueOnError)
+	flags.StringArray("scope-pattern", nil, "")
+	flags.Bool("include-disabled", false, "")
+	formatter.RegisterFlag(flags)
+	_ = flags.Set("output", output)
+	for _, pattern := range patterns {
+		_ = flags.Set("scope-pattern", pattern)
+	}
+	return flags
+}
+
+func (suite *FakeServerSuite) TestAudit() {
+	buf := &bytes.Buffer{}
+	suite.NoError(runAudit(&tcclient.Credentials{}, nil, buf, auditFlags("text", "queue:create-task:highest:*")))
+	suite.Equal("KIND    ID           SCOPE\n"+
+		"client  ci           queue:create-task:*\n"+
+		"role    project:app  queue:create-task:highest:proj-app/*\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestAuditCSV() {
+	buf := &bytes.Buffer{}
+	suite.NoError(runAudit(&tcclient.Credentials{}, nil, buf, auditFlags("csv", "queue:create-task:*", "secrets:get:project/docs/*")))
+	suite.Equal("KIND,ID,SCOPE\n"+
+		"client,ci,queue:create-task:*\n"+
+		"role,project:app,queue:create-task:highest:proj-app/*\n"+
+		"role,project:docs,secrets:get:project/docs/*\n"+
+		"hook,project-app/nightly,queue:create-task:lowest:proj-app/nightly\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestAuditJSON() {
+	buf := &bytes.Buffer{}
+	suite.NoError(runAudit(&tcclient.Credentials{}, nil, buf, auditFlags("json", "queue:create-task:lowest:proj-app/nightly")))
+	suite.JSONEq(`[
+		{"kind": "client", "id": "ci", "scopes": ["queue:create-task:*"]},
+		{"kind": "hook", "id": "project-app/nightly", "scopes": ["queue:create-task:lowest:proj-app/nightly"]}
+	]`, buf.String())
+}
+
+func (suite *FakeServerSuite) TestAuditNoMatch() {
+	buf := &bytes.Buffer{}
+	suite.NoError(runAudit(&tcclient.Credentials{}, nil, buf, auditFlags("text", "hooks:*")))
+	suite.Equal("No clients, roles or hooks can obtain scopes matching hooks:*.\n", buf.String())
+}
+
+func TestScopesOverlap(t *testing.T) {
+	for _, c := range []struct {
+		a, b    string
+		overlap bool
+	}{
+		{"queue:create-task:highest:proj/ci", "queue:create-task:highest:proj/ci", true},
+		{"queue:create-task:highest:proj/ci", "queue:create-task:lowest:proj/ci", false},
+		{"queue:create-task:*", "queue:create-task:highest:proj/ci", true},
+		{"queue:create-task:highest:proj/ci", "queue:create-task:*", true},
+		{"queue:create-task:highest:*", "queue:create-task:*", true},
+		{"queue:*", "secrets:*", false},
+		{"*", "secrets:get:x", true},
+		{"queue:create-task", "queue:create-task:*", false},
+	} {
+		assert.Equal(t, c.overlap, scopesOverlap(c.a, c.b), c.a+" and "+c.b)
+	}
+}
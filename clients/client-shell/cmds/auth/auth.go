@@ -0,0 +1,160 @@
+// Package auth implements the auth subcommands.
+package auth
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcauth"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+	"github.com/taskcluster/taskcluster/v27/internal/scopes"
+)
+
+var (
+	// Command is the root of the auth subtree.
+	Command = &cobra.Command{
+		Use:   "auth",
+		Short: "Provides auth-related actions and commands.",
+	}
+)
+
+func init() {
+	currentScopesCmd := &cobra.Command{
+		Use:   "current-scopes",
+		Short: "List the scopes of the current credentials.",
+		RunE:  root.ExecuteHelperE(runCurrentScopes, 0, 0),
+	}
+
+	expandScopesCmd := &cobra.Command{
+		Use:   "expand-scopes",
+		Short: "Expand scopes, replacing the roles they assume with the scopes of the roles.",
+		RunE:  root.ExecuteHelperE(runExpandScopes, 0, 0),
+	}
+	expandScopesCmd.Flags().StringArrayP("scope", "s", nil, "(can be repeated) Scope to expand.")
+	expandScopesCmd.Flags().StringP("file", "f", "", "File with scopes to expand, one per line.")
+
+	satisfiesCmd := &cobra.Command{
+		Use:   "satisfies",
+		Short: "Check whether scopes satisfy the needed scopes.",
+		Long: `Check whether the --have scopes (by default, the scopes of the current
+credentials) satisfy all of the --need scopes. A scope is satisfied by the
+same scope, or by a scope ending with * which is a prefix of it, e.g.
+queue:create-task:* satisfies queue:create-task:highest:proj/ci. Roles assumed
+by the --have scopes are expanded if needed.
+
+The needed scopes which are not satisfied are listed, and the exit status is
+non-zero unless all of them are satisfied.`,
+		RunE: root.ExecuteHelperE(runSatisfies, 0, 0),
+	}
+	satisfiesCmd.Flags().StringArray("have", nil, "(can be repeated) Scope which is held (defaults to the current scopes).")
+	satisfiesCmd.Flags().StringArray("need", nil, "(can be repeated) Scope which is needed.")
+
+	Command.AddCommand(currentScopesCmd, expandScopesCmd, satisfiesCmd)
+	root.Command.AddCommand(Command)
+}
+
+func makeAuth(credentials *tcclient.Credentials) *tcauth.Auth {
+	return tcauth.New(credentials, config.RootURL())
+}
+
+// runCurrentScopes lists the scopes of the current credentials.
+func runCurrentScopes(credentials *tcclient.Credentials, _ []string, out io.Writer, _ *pflag.FlagSet) error {
+	current, err := makeAuth(credentials).CurrentScopes()
+	if err != nil {
+		return fmt.Errorf("could not get the current scopes: %w", err)
+	}
+	for _, scope := range current.Scopes {
+		fmt.Fprintln(out, scope)
+	}
+	return nil
+}
+
+// readScopes reads scopes from a file, one per line, ignoring blank lines
+// and comments starting with #.
+func readScopes(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var result []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			result = append(result, line)
+		}
+	}
+	return result, scanner.Err()
+}
+
+// runExpandScopes expands the scopes given with --scope and --file.
+func runExpandScopes(credentials *tcclient.Credentials, _ []string, out io.Writer, flags *pflag.FlagSet) error {
+	given, _ := flags.GetStringArray("scope")
+	if file, _ := flags.GetString("file"); file != "" {
+		fromFile, err := readScopes(file)
+		if err != nil {
+			return fmt.Errorf("could not read scopes: %w", err)
+		}
+		given = append(given, fromFile...)
+	}
+	if len(given) == 0 {
+		return errors.New("scopes must be given with --scope or --file")
+	}
+
+	expanded, err := makeAuth(credentials).ExpandScopes(&tcauth.SetOfScopes{Scopes: given})
+	if err != nil {
+		return fmt.Errorf("could not expand scopes: %w", err)
+	}
+	for _, scope := range expanded.Scopes {
+		fmt.Fprintln(out, scope)
+	}
+	return nil
+}
+
+// runSatisfies checks that the --have scopes satisfy all the --need scopes,
+// listing those which are not.
+func runSatisfies(credentials *tcclient.Credentials, _ []string, out io.Writer, flags *pflag.FlagSet) error {
+	need, _ := flags.GetStringArray("need")
+	if len(need) == 0 {
+		return errors.New("needed scopes must be given with --need")
+	}
+	a := makeAuth(credentials)
+	have, _ := flags.GetStringArray("have")
+	if !flags.Changed("have") {
+		current, err := a.CurrentScopes()
+		if err != nil {
+			return fmt.Errorf("could not get the current scopes: %w", err)
+		}
+		have = current.Scopes
+	}
+
+	missing := make([]string, 0)
+	for _, scope := range need {
+		ok, err := scopes.Given(have).Satisfies(scopes.Required{{scope}}, a)
+		if err != nil {
+			return fmt.Errorf("could not expand scopes: %w", err)
+		}
+		if !ok {
+			missing = append(missing, scope)
+		}
+	}
+
+	if len(missing) > 0 {
+		for _, scope := range missing {
+			fmt.Fprintf(out, "missing: %s\n", scope)
+		}
+		return fmt.Errorf("%d of %d needed scopes are not satisfied", len(missing), len(need))
+	}
+	fmt.Fprintln(out, "All needed scopes are satisfied.")
+	return nil
+}
@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/suite"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+type FakeServerSuite struct {
+	suite.Suite
+	testServer *httptest.Server
+	// requests records the method and path of the requests for roles and
+	// clients, and body the body of the last one
+	requests []string
+	body     map[string]interface{}
+}
+
+func (suite *FakeServerSuite) SetupSuite() {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/auth/v1/scopes/current", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"scopes": ["assume:project:app", "queue:route:index.project.app.*"]}`)
+	})
+	// expands assume:project:app, and leaves other scopes alone
+	handler.HandleFunc("/api/auth/v1/scopes/expand", func(w http.ResponseWriter, r *http.Request) {
+		var given struct {
+			Scopes []string `json:"scopes"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&given)
+		expanded := []string{}
+		for _, scope := range given.Scopes {
+			expanded = append(expanded, scope)
+			switch scope {
+			case "assume:project:app":
+				expanded = append(expanded, "queue:create-task:highest:proj-app/*", "secrets:get:project/app/*")
+			case "assume:hook-id:project-app/nightly":
+				expanded = append(expanded, "queue:create-task:lowest:proj-app/nightly")
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string][]string{"scopes": expanded})
+	})
+
+	handler.HandleFunc("/api/auth/v1/roleids/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("continuationToken") == "" {
+			_, _ = io.WriteString(w, `{"roleIds": ["project:app"], "continuationToken": "next"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"roleIds": ["repo:github.com/org/app:*"]}`)
+	})
+	handler.HandleFunc("/api/auth/v1/roles/", suite.entityHandler(`{"roleId": "project:app", "description": "App", "scopes": ["secrets:get:project/app/*"]}`))
+	handler.HandleFunc("/api/auth/v1/roles2/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"roles": [
+			{"roleId": "project:app", "expandedScopes": ["queue:create-task:highest:proj-app/*", "secrets:get:project/app/*"]},
+			{"roleId": "project:docs", "expandedScopes": ["secrets:get:project/docs/*"]}
+		]}`)
+	})
+	handler.HandleFunc("/api/hooks/v1/hooks", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"groups": ["project-app"]}`)
+	})
+	handler.HandleFunc("/api/hooks/v1/hooks/project-app", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"hooks": [{"hookGroupId": "project-app", "hookId": "nightly"}]}`)
+	})
+	handler.HandleFunc("/api/auth/v1/clients/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/auth/v1/clients/" {
+			_, _ = io.WriteString(w, `{"clients": [{"clientId": "`+r.URL.Query().Get("prefix")+`ci", "expandedScopes": ["assume:project:app", "queue:create-task:*"]}]}`)
+			return
+		}
+		suite.entityHandler(`{"clientId": "project/app/ci", "accessToken": "new-token", "scopes": []}`)(w, r)
+	})
+
+	suite.testServer = httptest.NewServer(handler)
+	config.SetRootURL(suite.testServer.URL)
+}
+
+// entityHandler records the requests for an entity, and replies with it.
+func (suite *FakeServerSuite) entityHandler(entity string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		suite.requests = append(suite.requests, r.Method+" "+r.URL.Path)
+		suite.body = nil
+		_ = json.NewDecoder(r.Body).Decode(&suite.body)
+		if r.Method != "DELETE" {
+			_, _ = io.WriteString(w, entity)
+		}
+	}
+}
+
+func (suite *FakeServerSuite) SetupTest() {
+	suite.requests = nil
+}
+
+func (suite *FakeServerSuite) TearDownSuite() {
+	suite.testServer.Close()
+	config.SetRootURL("")
+}
+
+func TestFakeServerSuite(t *testing.T) {
+	suite.Run(t, new(FakeServerSuite))
+}
+
+func satisfiesFlags(have, need []string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("satisfies", pflag.ContinueOnError)
+	flags.StringArray("have", nil, "")
+	flags.StringArray("need", nil, "")
+	for _, scope := range have {
+		_ = flags.Set("have", scope)
+	}
+	for _, scope := range need {
+		_ = flags.Set("need", scope)
+	}
+	return flags
+}
+
+func (suite *FakeServerSuite) TestCurrentScopes() {
+	buf := &bytes.Buffer{}
+	suite.NoError(runCurrentScopes(nil, nil, buf, nil))
+	suite.Equal("assume:project:app\nqueue:route:index.project.app.*\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestExpandScopes() {
+	dir, err := ioutil.TempDir("", "auth")
+	suite.Require().NoError(err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "scopes.txt")
+	suite.Require().NoError(ioutil.WriteFile(file, []byte("# roles\nassume:project:app\n\n"), 0644))
+
+	flags := pflag.NewFlagSet("expand-scopes", pflag.ContinueOnError)
+	flags.StringArray("scope", []string{"index:insert-task:project.app.*"}, "")
+	flags.String("file", file, "")
+
+	buf := &bytes.Buffer{}
+	suite.NoError(runExpandScopes(nil, nil, buf, flags))
+	suite.Equal("index:insert-task:project.app.*\nassume:project:app\nqueue:create-task:highest:proj-app/*\nsecrets:get:project/app/*\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestSatisfies() {
+	buf := &bytes.Buffer{}
+	flags := satisfiesFlags([]string{"queue:create-task:*", "secrets:get:project/app"}, []string{"queue:create-task:highest:proj-app/ci", "secrets:get:project/app"})
+	suite.NoError(runSatisfies(nil, nil, buf, flags))
+	suite.Equal("All needed scopes are satisfied.\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestSatisfiesMissing() {
+	buf := &bytes.Buffer{}
+	flags := satisfiesFlags([]string{"secrets:get:project/app/*"}, []string{"secrets:get:project/app/deploy", "secrets:get:project/lib", "secrets:set:project/app/deploy"})
+	suite.Error(runSatisfies(nil, nil, buf, flags))
+	suite.Equal("missing: secrets:get:project/lib\nmissing: secrets:set:project/app/deploy\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestSatisfiesCurrentScopes() {
+	// the current scopes assume a role which grants the needed scope
+	buf := &bytes.Buffer{}
+	flags := satisfiesFlags(nil, []string{"secrets:get:project/app/deploy"})
+	suite.NoError(runSatisfies(nil, nil, buf, flags))
+	suite.Equal("All needed scopes are satisfied.\n", buf.String())
+}
@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcauth"
+	fromNow "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/from-now"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
+)
+
+// stdin is where definitions given as - are read from.
+var stdin io.Reader = os.Stdin
+
+func init() {
+	roleCmd := &cobra.Command{
+		Use:   "role",
+		Short: "Manage roles.",
+	}
+	roleCmd.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List the roleIds of all roles.",
+			RunE:  root.ExecuteHelperE(runRoleList, 0, 0),
+		},
+		&cobra.Command{
+			Use:   "get <roleId>",
+			Short: "Get a role, as YAML.",
+			RunE:  root.ExecuteHelperE(runRoleGet, 1, 1),
+		},
+		definitionCmd(&cobra.Command{
+			Use:   "create <roleId> -f <file>",
+			Short: "Create a role from a YAML or JSON definition with description and scopes.",
+			RunE:  root.ExecuteHelperE(runRoleCreate, 1, 1),
+		}),
+		definitionCmd(&cobra.Command{
+			Use:   "update <roleId> -f <file>",
+			Short: "Update a role from a YAML or JSON definition with description and scopes.",
+			RunE:  root.ExecuteHelperE(runRoleUpdate, 1, 1),
+		}),
+		&cobra.Command{
+			Use:   "delete <roleId>",
+			Short: "Delete a role.",
+			RunE:  root.ExecuteHelperE(runRoleDelete, 1, 1),
+		},
+	)
+
+	clientListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the clientIds of all clients.",
+		RunE:  root.ExecuteHelperE(runClientList, 0, 0),
+	}
+	clientListCmd.Flags().String("prefix", "", "Only list clients whose clientId starts with this prefix.")
+
+	clientCreateCmd := definitionCmd(&cobra.Command{
+		Use:   "create <clientId> -f <file>",
+		Short: "Create a client from a YAML or JSON definition, and print it with its accessToken.",
+		RunE:  root.ExecuteHelperE(runClientCreate, 1, 1),
+	})
+	clientCreateCmd.Flags().String("expires", "", "Lifetime of the client, e.g. '1 year', overriding the expires of the definition.")
+
+	clientCmd := &cobra.Command{
+		Use:   "client",
+		Short: "Manage clients.",
+	}
+	clientCmd.AddCommand(
+		clientListCmd,
+		&cobra.Command{
+			Use:   "get <clientId>",
+			Short: "Get a client, as YAML.",
+			RunE:  root.ExecuteHelperE(runClientGet, 1, 1),
+		},
+		clientCreateCmd,
+		&cobra.Command{
+			Use:   "reset-token <clientId>",
+			Short: "Reset the accessToken of a client, and print it with the new accessToken.",
+			RunE:  root.ExecuteHelperE(runClientResetToken, 1, 1),
+		},
+		&cobra.Command{
+			Use:   "disable <clientId>",
+			Short: "Disable a client.",
+			RunE:  root.ExecuteHelperE(runClientDisable, 1, 1),
+		},
+	)
+
+	Command.AddCommand(roleCmd, clientCmd)
+}
+
+// definitionCmd adds the --file flag to a command which reads a definition.
+func definitionCmd(cmd *cobra.Command) *cobra.Command {
+	cmd.Flags().StringP("file", "f", "", "File holding the definition, or - to read it from stdin.")
+	return cmd
+}
+
+// readDefinition unmarshals the YAML or JSON definition given with --file
+// into v.
+func readDefinition(flags *pflag.FlagSet, v interface{}) error {
+	file, _ := flags.GetString("file")
+	var data []byte
+	var err error
+	switch file {
+	case "":
+		return errors.New("a definition must be given with --file")
+	case "-":
+		data, err = ioutil.ReadAll(stdin)
+	default:
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return fmt.Errorf("could not read definition: %w", err)
+	}
+	if err := yaml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("could not parse definition: %w", err)
+	}
+	return nil
+}
+
+// writeYAML writes v to out as YAML.
+func writeYAML(out io.Writer, v interface{}) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("could not render yaml: %w", err)
+	}
+	_, err = out.Write(data)
+	return err
+}
+
+// runRoleList lists the roleIds of all roles, following continuation
+// tokens.
+func runRoleList(credentials *tcclient.Credentials, _ []string, out io.Writer, _ *pflag.FlagSet) error {
+	a := makeAuth(credentials)
+	continuation := ""
+	for {
+		resp, err := a.ListRoleIds(continuation, "")
+		if err != nil {
+			return fmt.Errorf("could not list roles: %w", err)
+		}
+		for _, roleID := range resp.RoleIds {
+			fmt.Fprintln(out, roleID)
+		}
+		if continuation = resp.ContinuationToken; continuation == "" {
+			return nil
+		}
+	}
+}
+
+// runRoleGet prints a role as YAML.
+func runRoleGet(credentials *tcclient.Credentials, args []string, out io.Writer, _ *pflag.FlagSet) error {
+	role, err := makeAuth(credentials).Role(args[0])
+	if err != nil {
+		return fmt.Errorf("could not get role %s: %w", args[0], err)
+	}
+	return writeYAML(out, role)
+}
+
+// runRoleCreate creates a role from the definition given with --file.
+func runRoleCreate(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	def := &tcauth.CreateRoleRequest{}
+	if err := readDefinition(flags, def); err != nil {
+		return err
+	}
+	role, err := makeAuth(credentials).CreateRole(args[0], def)
+	if err != nil {
+		return fmt.Errorf("could not create role %s: %w", args[0], err)
+	}
+	return writeYAML(out, role)
+}
+
+// runRoleUpdate updates a role from the definition given with --file.
+func runRoleUpdate(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	def := &tcauth.CreateRoleRequest{}
+	if err := readDefinition(flags, def); err != nil {
+		return err
+	}
+	role, err := makeAuth(credentials).UpdateRole(args[0], def)
+	if err != nil {
+		return fmt.Errorf("could not update role %s: %w", args[0], err)
+	}
+	return writeYAML(out, role)
+}
+
+// runRoleDelete deletes a role.
+func runRoleDelete(credentials *tcclient.Credentials, args []string, out io.Writer, _ *pflag.FlagSet) error {
+	if err := makeAuth(credentials).DeleteRole(args[0]); err != nil {
+		return fmt.Errorf("could not delete role %s: %w", args[0], err)
+	}
+	fmt.Fprintf(out, "Role %s deleted\n", args[0])
+	return nil
+}
+
+// runClientList lists the clientIds of all clients, following continuation
+// tokens.
+func runClientList(credentials *tcclient.Credentials, _ []string, out io.Writer, flags *pflag.FlagSet) error {
+	prefix, _ := flags.GetString("prefix")
+	a := makeAuth(credentials)
+	continuation := ""
+	for {
+		resp, err := a.ListClients(continuation, "", prefix)
+		if err != nil {
+			return fmt.Errorf("could not list clients: %w", err)
+		}
+		for _, client := range resp.Clients {
+			fmt.Fprintln(out, client.ClientID)
+		}
+		if continuation = resp.ContinuationToken; continuation == "" {
+			return nil
+		}
+	}
+}
+
+// runClientGet prints a client as YAML.
+func runClientGet(credentials *tcclient.Credentials, args []string, out io.Writer, _ *pflag.FlagSet) error {
+	client, err := makeAuth(credentials).Client(args[0])
+	if err != nil {
+		return fmt.Errorf("could not get client %s: %w", args[0], err)
+	}
+	return writeYAML(out, client)
+}
+
+// runClientCreate creates a client from the definition given with --file,
+// and prints it with its accessToken.
+func runClientCreate(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	def := &tcauth.CreateClientRequest{}
+	if err := readDefinition(flags, def); err != nil {
+		return err
+	}
+	if expires, _ := flags.GetString("expires"); expires != "" {
+		expiry, err := fromNow.Parse(expires)
+		if err != nil {
+			return fmt.Errorf("invalid --expires: %w", err)
+		}
+		def.Expires = tcclient.Time(expiry)
+	}
+	if time.Time(def.Expires).IsZero() {
+		return errors.New("the client must expire; give expires in the definition or with --expires")
+	}
+
+	client, err := makeAuth(credentials).CreateClient(args[0], def)
+	if err != nil {
+		return fmt.Errorf("could not create client %s: %w", args[0], err)
+	}
+	return writeYAML(out, client)
+}
+
+// runClientResetToken resets the accessToken of a client, and prints it with
+// the new accessToken.
+func runClientResetToken(credentials *tcclient.Credentials, args []string, out io.Writer, _ *pflag.FlagSet) error {
+	client, err := makeAuth(credentials).ResetAccessToken(args[0])
+	if err != nil {
+		return fmt.Errorf("could not reset the accessToken of client %s: %w", args[0], err)
+	}
+	return writeYAML(out, client)
+}
+
+// runClientDisable disables a client.
+func runClientDisable(credentials *tcclient.Credentials, args []string, out io.Writer, _ *pflag.FlagSet) error {
+	if _, err := makeAuth(credentials).DisableClient(args[0]); err != nil {
+		return fmt.Errorf("could not disable client %s: %w", args[0], err)
+	}
+	fmt.Fprintf(out, "Client %s disabled\n", args[0])
+	return nil
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+func fileFlags(file string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("definition", pflag.ContinueOnError)
+	flags.String("file", file, "")
+	flags.String("expires", "", "")
+	flags.String("prefix", "project/", "")
+	return flags
+}
+
+func (suite *FakeServerSuite) TestRoleList() {
+	buf := &bytes.Buffer{}
+	suite.NoError(runRoleList(nil, nil, buf, nil))
+	suite.Equal("project:app\nrepo:github.com/org/app:*\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestRoleGet() {
+	buf := &bytes.Buffer{}
+	suite.NoError(runRoleGet(nil, []string{"project:app"}, buf, nil))
+	suite.Contains(buf.String(), "roleId: project:app\n")
+	suite.Contains(buf.String(), "- secrets:get:project/app/*\n")
+}
+
+func (suite *FakeServerSuite) TestRoleCreateUpdateDelete() {
+	defer func(orig io.Reader) { stdin = orig }(stdin)
+
+	stdin = strings.NewReader(`{"description": "App", "scopes": ["secrets:get:project/app/*"]}`)
+	suite.NoError(runRoleCreate(nil, []string{"project:app"}, &bytes.Buffer{}, fileFlags("-")))
+	suite.Equal("App", suite.body["description"])
+
+	stdin = strings.NewReader(`{"description": "Updated", "scopes": []}`)
+	suite.NoError(runRoleUpdate(nil, []string{"project:app"}, &bytes.Buffer{}, fileFlags("-")))
+	suite.Equal("Updated", suite.body["description"])
+
+	buf := &bytes.Buffer{}
+	suite.NoError(runRoleDelete(nil, []string{"project:app"}, buf, nil))
+	suite.Equal("Role project:app deleted\n", buf.String())
+
+	suite.Equal([]string{
+		"PUT /api/auth/v1/roles/project:app",
+		"POST /api/auth/v1/roles/project:app",
+		"DELETE /api/auth/v1/roles/project:app",
+	}, suite.requests)
+
+	suite.Error(runRoleCreate(nil, []string{"project:app"}, &bytes.Buffer{}, fileFlags("")), "create should error without --file")
+}
+
+func (suite *FakeServerSuite) TestClientList() {
+	buf := &bytes.Buffer{}
+	suite.NoError(runClientList(nil, nil, buf, fileFlags("")))
+	suite.Equal("project/ci\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestClientCreate() {
+	defer func(orig io.Reader) { stdin = orig }(stdin)
+
+	stdin = strings.NewReader(`{"description": "CI", "scopes": ["queue:create-task:*"]}`)
+	suite.Error(runClientCreate(nil, []string{"project/app/ci"}, &bytes.Buffer{}, fileFlags("-")), "create should error without expires")
+
+	stdin = strings.NewReader(`{"description": "CI", "scopes": ["queue:create-task:*"]}`)
+	flags := fileFlags("-")
+	suite.NoError(flags.Set("expires", "1 year"))
+	buf := &bytes.Buffer{}
+	suite.NoError(runClientCreate(nil, []string{"project/app/ci"}, buf, flags))
+	suite.Contains(buf.String(), "accessToken: new-token\n")
+	suite.Equal("CI", suite.body["description"])
+	suite.NotEmpty(suite.body["expires"])
+}
+
+func (suite *FakeServerSuite) TestClientResetTokenAndDisable() {
+	buf := &bytes.Buffer{}
+	suite.NoError(runClientResetToken(nil, []string{"project/app/ci"}, buf, nil))
+	suite.Contains(buf.String(), "accessToken: new-token\n")
+
+	buf.Reset()
+	suite.NoError(runClientDisable(nil, []string{"project/app/ci"}, buf, nil))
+	suite.Equal("Client project/app/ci disabled\n", buf.String())
+
+	suite.Equal([]string{
+		"POST /api/auth/v1/clients/project/app/ci/reset",
+		"POST /api/auth/v1/clients/project/app/ci/disable",
+	}, suite.requests)
+}
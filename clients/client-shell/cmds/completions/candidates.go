@@ -0,0 +1,284 @@
+package completions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tchooks"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcworkermanager"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/slugid"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+// sources list the values of the arguments which can be completed, by
+// kind; see argKind. They are replaced in tests.
+var sources = map[string]func() ([]string, error){
+	"taskId":       func() ([]string, error) { return config.RecentIDs("taskId") },
+	"taskGroupId":  func() ([]string, error) { return config.RecentIDs("taskGroupId") },
+	"workerType":   workerTypes,
+	"workerPoolId": workerPools,
+	"hookGroupId":  hookGroups,
+	"hookId":       hooks,
+}
+
+// argKind returns the kind of the values of an argument, given its
+// placeholder in the Use of its command, such as <taskId>, or "" if the
+// values are not known.
+func argKind(placeholder string) string {
+	for _, kind := range []string{"taskGroupId", "taskId", "workerType", "workerPoolId", "hookId", "hookGroupId"} {
+		if strings.Contains(placeholder, "<"+kind+">") {
+			return kind
+		}
+	}
+	return ""
+}
+
+// argPlaceholder returns the placeholder of the i-th argument of cmd in its
+// Use, skipping flags and their values.
+func argPlaceholder(cmd *cobra.Command, i int) string {
+	var placeholders []string
+	fields := strings.Fields(cmd.Use)
+	for j := 1; j < len(fields); j++ {
+		if strings.HasPrefix(fields[j], "-") || strings.HasPrefix(fields[j], "(") {
+			j++
+			continue
+		}
+		placeholders = append(placeholders, fields[j])
+	}
+	switch {
+	case i < len(placeholders):
+		return placeholders[i]
+	case len(placeholders) > 0 && strings.Contains(placeholders[len(placeholders)-1], "..."):
+		return placeholders[len(placeholders)-1]
+	}
+	return ""
+}
+
+// candidates returns the completions of current, given the words which
+// precede it: subcommands, flags, or the values of the argument being
+// typed.
+func candidates(rootCmd *cobra.Command, previous []string, current string) []string {
+	cmd := rootCmd
+	var args []string
+	for i := 0; i < len(previous); i++ {
+		w := previous[i]
+		if strings.HasPrefix(w, "-") {
+			if f := lookupFlag(cmd, w); f != nil && f.Value.Type() != "bool" && !strings.Contains(w, "=") {
+				// skip the value of the flag
+				i++
+			}
+			continue
+		}
+		if sub := subcommand(cmd, w); sub != nil && len(args) == 0 {
+			cmd = sub
+		} else {
+			args = append(args, w)
+		}
+	}
+	if len(previous) > 0 {
+		// the value of a flag is not completed, except as a file name
+		last := previous[len(previous)-1]
+		if f := lookupFlag(cmd, last); f != nil && f.Value.Type() != "bool" && !strings.Contains(last, "=") {
+			return nil
+		}
+	}
+
+	var all []string
+	switch {
+	case strings.HasPrefix(current, "-"):
+		add := func(f *pflag.Flag) {
+			if !f.Hidden {
+				all = append(all, "--"+f.Name)
+			}
+		}
+		// cmd.Flags() also holds the inherited flags once they have been
+		// merged into it, so the local and inherited flags are visited
+		// separately to list each once
+		cmd.LocalFlags().VisitAll(add)
+		cmd.InheritedFlags().VisitAll(add)
+	case cmd.HasSubCommands() && len(args) == 0:
+		for _, sub := range cmd.Commands() {
+			if sub.IsAvailableCommand() {
+				all = append(all, sub.Name())
+			}
+		}
+	default:
+		source := sources[argKind(argPlaceholder(cmd, len(args)))]
+		if source == nil {
+			return nil
+		}
+		// completion is best-effort: without values, file names are
+		// completed instead
+		all, _ = source()
+	}
+
+	var result []string
+	for _, c := range all {
+		if strings.HasPrefix(c, current) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// lookupFlag returns the flag given by word, such as --name, --name=value
+// or -n, or nil if cmd has no such flag.
+func lookupFlag(cmd *cobra.Command, word string) *pflag.Flag {
+	name := strings.SplitN(strings.TrimLeft(word, "-"), "=", 2)[0]
+	for _, flags := range []*pflag.FlagSet{cmd.LocalFlags(), cmd.InheritedFlags()} {
+		if strings.HasPrefix(word, "--") {
+			if f := flags.Lookup(name); f != nil {
+				return f
+			}
+		} else if len(name) == 1 {
+			if f := flags.ShorthandLookup(name); f != nil {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// subcommand returns the subcommand of cmd with the given name or alias.
+func subcommand(cmd *cobra.Command, name string) *cobra.Command {
+	for _, sub := range cmd.Commands() {
+		if sub.Name() == name {
+			return sub
+		}
+		for _, alias := range sub.Aliases {
+			if alias == name {
+				return sub
+			}
+		}
+	}
+	return nil
+}
+
+// rememberIDsOnRun makes the commands under rootCmd remember the taskIds
+// and taskGroupIds they are given, so that they can be completed later.
+func rememberIDsOnRun(rootCmd *cobra.Command) {
+	configure := rootCmd.PersistentPreRunE
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if configure != nil {
+			if err := configure(cmd, args); err != nil {
+				return err
+			}
+		}
+		rememberIDs(cmd, args)
+		return nil
+	}
+}
+
+// rememberIDs records the taskIds and taskGroupIds among args. Failing to
+// record them only affects completion, so errors are ignored.
+func rememberIDs(cmd *cobra.Command, args []string) {
+	for i, arg := range args {
+		kind := argKind(argPlaceholder(cmd, i))
+		if (kind == "taskId" || kind == "taskGroupId") && slugid.RegexpSlugV4.MatchString(arg) {
+			_ = config.RememberIDs(kind, arg)
+		}
+	}
+}
+
+func credentials() *tcclient.Credentials {
+	if config.Credentials != nil {
+		return config.Credentials.ToClientCredentials()
+	}
+	return nil
+}
+
+// workerTypes returns the worker types known to the queue, as
+// <provisionerId>/<workerType>.
+func workerTypes() ([]string, error) {
+	q := tcqueue.New(credentials(), config.RootURL())
+	var provisioners []string
+	continuation := ""
+	for {
+		resp, err := q.ListProvisioners(continuation, "")
+		if err != nil {
+			return nil, fmt.Errorf("could not list provisioners: %w", err)
+		}
+		for _, p := range resp.Provisioners {
+			provisioners = append(provisioners, p.ProvisionerID)
+		}
+		if continuation = resp.ContinuationToken; continuation == "" {
+			break
+		}
+	}
+
+	var workerTypes []string
+	for _, provisionerID := range provisioners {
+		continuation := ""
+		for {
+			resp, err := q.ListWorkerTypes(provisionerID, continuation, "")
+			if err != nil {
+				return nil, fmt.Errorf("could not list the worker types of %s: %w", provisionerID, err)
+			}
+			for _, wt := range resp.WorkerTypes {
+				workerTypes = append(workerTypes, provisionerID+"/"+wt.WorkerType)
+			}
+			if continuation = resp.ContinuationToken; continuation == "" {
+				break
+			}
+		}
+	}
+	sort.Strings(workerTypes)
+	return workerTypes, nil
+}
+
+// workerPools returns the ids of the worker pools of the worker-manager.
+func workerPools() ([]string, error) {
+	wm := tcworkermanager.New(credentials(), config.RootURL())
+	var pools []string
+	continuation := ""
+	for {
+		resp, err := wm.ListWorkerPools(continuation, "")
+		if err != nil {
+			return nil, fmt.Errorf("could not list worker pools: %w", err)
+		}
+		for _, p := range resp.WorkerPools {
+			pools = append(pools, p.WorkerPoolID)
+		}
+		if continuation = resp.ContinuationToken; continuation == "" {
+			break
+		}
+	}
+	sort.Strings(pools)
+	return pools, nil
+}
+
+// hookGroups returns the hook groups.
+func hookGroups() ([]string, error) {
+	resp, err := tchooks.New(credentials(), config.RootURL()).ListHookGroups()
+	if err != nil {
+		return nil, fmt.Errorf("could not list hook groups: %w", err)
+	}
+	sort.Strings(resp.Groups)
+	return resp.Groups, nil
+}
+
+// hooks returns the hooks of all hook groups, as <hookGroupId>/<hookId>.
+func hooks() ([]string, error) {
+	h := tchooks.New(credentials(), config.RootURL())
+	groups, err := h.ListHookGroups()
+	if err != nil {
+		return nil, fmt.Errorf("could not list hook groups: %w", err)
+	}
+	var hooks []string
+	for _, group := range groups.Groups {
+		resp, err := h.ListHooks(group)
+		if err != nil {
+			return nil, fmt.Errorf("could not list the hooks of %s: %w", group, err)
+		}
+		for _, hook := range resp.Hooks {
+			hooks = append(hooks, group+"/"+hook.HookID)
+		}
+	}
+	sort.Strings(hooks)
+	return hooks, nil
+}
@@ -2,12 +2,32 @@
 package completions
 
 import (
+	"fmt"
+	"io"
+	"strings"
+
 	"github.com/spf13/cobra"
 	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
 )
 
 var (
 	defaultFilename = "bash_completion.sh"
+
+	// Command is the root of the completion subtree.
+	Command = &cobra.Command{
+		Use:   "completion",
+		Short: "Output shell completion scripts.",
+		Long: `Output a completion script for the given shell, which completes the
+commands and flags of taskcluster, as well as worker types, hooks, and the
+taskIds and taskGroupIds recently given to commands.
+
+To use it, add the following to your shell's startup script:
+
+  bash:        source <(taskcluster completion bash)
+  zsh:         source <(taskcluster completion zsh)
+  fish:        taskcluster completion fish | source
+  powershell:  taskcluster completion powershell | Out-String | Invoke-Expression`,
+	}
 )
 
 func init() {
@@ -22,10 +42,30 @@ To use, do one of the following:
 Add 'source bash_completion.sh' to your bash login scripts
 On Linux you can also copy it to /etc/bash_completion.d/ so that future bash shells have it active.
         `,
-		RunE: genCompletion,
-		Use:  use,
+		RunE:       genCompletion,
+		Use:        use,
+		Deprecated: "use `taskcluster completion bash` instead.",
 	}
 	root.Command.AddCommand(completionsCommand)
+
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		Command.AddCommand(&cobra.Command{
+			Use:   shell,
+			Short: "Output the completion script for " + shell + ".",
+			RunE:  genScript(scripts[shell]),
+		})
+	}
+	candidatesCmd := &cobra.Command{
+		Use:    "candidates --current <word> -- <previous words>...",
+		Short:  "Output the completions of a word; used by the completion scripts.",
+		Hidden: true,
+		RunE:   runCandidates,
+	}
+	candidatesCmd.Flags().String("current", "", "The word to complete.")
+	Command.AddCommand(candidatesCmd)
+	root.Command.AddCommand(Command)
+
+	rememberIDsOnRun(root.Command)
 }
 
 func genCompletion(cmd *cobra.Command, args []string) error {
@@ -36,3 +76,24 @@ func genCompletion(cmd *cobra.Command, args []string) error {
 
 	return root.Command.GenBashCompletionFile(filename)
 }
+
+// genScript returns a command outputting the given completion script.
+func genScript(script string) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 {
+			return fmt.Errorf("%s expects no arguments", cmd.Name())
+		}
+		_, err := io.WriteString(cmd.OutOrStdout(), strings.Replace(script, "PROGRAM", root.Command.Name(), -1))
+		return err
+	}
+}
+
+// runCandidates outputs the completions of --current, one per line, given
+// the words which precede it on the command line.
+func runCandidates(cmd *cobra.Command, args []string) error {
+	current, _ := cmd.Flags().GetString("current")
+	for _, c := range candidates(root.Command, args, current) {
+		fmt.Fprintln(cmd.OutOrStdout(), c)
+	}
+	return nil
+}
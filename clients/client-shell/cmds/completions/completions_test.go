@@ -0,0 +1,114 @@
+package completions
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	assert "github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+// testTree returns a small command tree, with values for its arguments.
+func testTree() *cobra.Command {
+	rootCmd := &cobra.Command{Use: "taskcluster"}
+	rootCmd.PersistentFlags().String("root-url", "", "")
+
+	taskCmd := &cobra.Command{Use: "task"}
+	statusCmd := &cobra.Command{Use: "status <taskId>", Run: func(*cobra.Command, []string) {}}
+	statusCmd.Flags().BoolP("all", "a", false, "")
+	statusCmd.Flags().StringP("output", "o", "", "")
+	taskCmd.AddCommand(statusCmd, &cobra.Command{Use: "cancel <taskId>", Run: func(*cobra.Command, []string) {}})
+
+	workerCmd := &cobra.Command{Use: "worker"}
+	workerCmd.AddCommand(&cobra.Command{
+		Use: "show <provisionerId>/<workerType> <workerGroup>/<workerId>",
+		Run: func(*cobra.Command, []string) {},
+	})
+	rootCmd.AddCommand(taskCmd, workerCmd, &cobra.Command{Use: "hidden", Hidden: true})
+	return rootCmd
+}
+
+func TestCandidates(t *testing.T) {
+	assert := assert.New(t)
+	defer func(orig map[string]func() ([]string, error)) { sources = orig }(sources)
+	sources = map[string]func() ([]string, error){
+		"taskId":     func() ([]string, error) { return []string{"FOH9mI0mQ1C90yoMo3ajsg", "fNSvgZZ2TMKu4xM0Q4TNTA"}, nil },
+		"workerType": func() ([]string, error) { return []string{"proj-app/ci", "proj-app/gpu", "other/ci"}, nil },
+	}
+	rootCmd := testTree()
+
+	assert.Equal([]string{"task", "worker"}, candidates(rootCmd, nil, ""))
+	assert.Equal([]string{"status"}, candidates(rootCmd, []string{"task"}, "st"))
+	assert.Equal([]string{"--all", "--output", "--root-url"}, candidates(rootCmd, []string{"task", "status"}, "--"))
+	assert.Equal([]string{"--root-url"}, candidates(rootCmd, []string{"task", "status"}, "--r"))
+	assert.Equal([]string{"fNSvgZZ2TMKu4xM0Q4TNTA"}, candidates(rootCmd, []string{"task", "status", "--all"}, "f"))
+	assert.Equal([]string{"fNSvgZZ2TMKu4xM0Q4TNTA"}, candidates(rootCmd, []string{"task", "status", "-o", "json"}, "f"))
+	assert.Nil(candidates(rootCmd, []string{"task", "status", "--output"}, ""), "flag values should not be completed")
+	assert.Equal([]string{"proj-app/ci", "proj-app/gpu"}, candidates(rootCmd, []string{"worker", "show"}, "proj"))
+	assert.Nil(candidates(rootCmd, []string{"worker", "show", "proj-app/ci"}, ""), "workers are not known")
+}
+
+func TestInheritedFlagsListedOnce(t *testing.T) {
+	assert := assert.New(t)
+	rootCmd := testTree()
+
+	// the persistent flags of the root command are merged into the flags
+	// of the subcommand by the first completion, and must not be listed
+	// twice by the later ones
+	for i := 0; i < 2; i++ {
+		assert.Equal([]string{"--all", "--output", "--root-url"}, candidates(rootCmd, []string{"task", "status"}, "--"))
+		assert.Equal([]string{"--root-url"}, candidates(rootCmd, []string{"task", "status"}, "--root"))
+	}
+}
+
+func TestArgPlaceholder(t *testing.T) {
+	assert := assert.New(t)
+
+	cmd := &cobra.Command{Use: "create <hookGroupId>/<hookId> -f <file> [<taskId>...]"}
+	assert.Equal("<hookGroupId>/<hookId>", argPlaceholder(cmd, 0))
+	assert.Equal("[<taskId>...]", argPlaceholder(cmd, 1))
+	assert.Equal("[<taskId>...]", argPlaceholder(cmd, 3))
+	assert.Equal("hookId", argKind(argPlaceholder(cmd, 0)))
+	assert.Equal("taskId", argKind(argPlaceholder(cmd, 2)))
+	assert.Equal("", argPlaceholder(&cobra.Command{Use: "status <taskId>"}, 1))
+	assert.Equal("taskGroupId", argKind("<taskGroupId>"))
+	assert.Equal("hookGroupId", argKind("[<hookGroupId>]"))
+}
+
+func TestRememberIDs(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "taskcluster-completion")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	assert.NoError(os.Setenv("XDG_CONFIG_HOME", dir))
+
+	rememberIDs(&cobra.Command{Use: "status <taskId>"}, []string{"FOH9mI0mQ1C90yoMo3ajsg"})
+	rememberIDs(&cobra.Command{Use: "status <taskId>"}, []string{"not-a-slugid"})
+	rememberIDs(&cobra.Command{Use: "watch <taskGroupId>"}, []string{"fNSvgZZ2TMKu4xM0Q4TNTA"})
+
+	ids, err := config.RecentIDs("taskId")
+	assert.NoError(err)
+	assert.Equal([]string{"FOH9mI0mQ1C90yoMo3ajsg"}, ids)
+	ids, err = config.RecentIDs("taskGroupId")
+	assert.NoError(err)
+	assert.Equal([]string{"fNSvgZZ2TMKu4xM0Q4TNTA"}, ids)
+}
+
+func TestScripts(t *testing.T) {
+	assert := assert.New(t)
+
+	for shell, script := range scripts {
+		buf := &bytes.Buffer{}
+		cmd := &cobra.Command{Use: shell}
+		cmd.SetOutput(buf)
+		assert.NoError(genScript(script)(cmd, nil))
+		assert.NotContains(buf.String(), "PROGRAM")
+		assert.True(strings.Contains(buf.String(), "taskcluster completion candidates "), shell+" script should ask for candidates")
+	}
+}
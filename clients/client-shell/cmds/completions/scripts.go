@@ -0,0 +1,51 @@
+package completions
+
+// scripts are the completion scripts, by shell. They ask `completion
+// candidates` for the completions of the current word, and fall back to the
+// completion of file names when there are none. PROGRAM stands for the name
+// of the executable.
+var scripts = map[string]string{
+	"bash": `# bash completion for PROGRAM; generated by ` + "`PROGRAM completion bash`" + `
+__PROGRAM_complete() {
+    local IFS=$'\n'
+    COMPREPLY=($(PROGRAM completion candidates --current="${COMP_WORDS[COMP_CWORD]}" -- "${COMP_WORDS[@]:1:COMP_CWORD-1}" 2>/dev/null))
+}
+complete -o default -F __PROGRAM_complete PROGRAM
+`,
+
+	"zsh": `#compdef PROGRAM
+# zsh completion for PROGRAM; generated by ` + "`PROGRAM completion zsh`" + `
+__PROGRAM_complete() {
+    local -a candidates
+    candidates=("${(@f)$(PROGRAM completion candidates --current="${words[CURRENT]}" -- "${(@)words[2,CURRENT-1]}" 2>/dev/null)}")
+    candidates=(${candidates:#})
+    if (( ${#candidates} )); then
+        compadd -a candidates
+    else
+        _files
+    fi
+}
+compdef __PROGRAM_complete PROGRAM
+`,
+
+	"fish": `# fish completion for PROGRAM; generated by ` + "`PROGRAM completion fish`" + `
+function __PROGRAM_candidates
+    set -l previous (commandline -opc)
+    PROGRAM completion candidates --current=(commandline -ct) -- $previous[2..-1] 2>/dev/null
+end
+complete -c PROGRAM -f -n 'test -n "$(__PROGRAM_candidates)"' -a '(__PROGRAM_candidates)'
+`,
+
+	"powershell": `# powershell completion for PROGRAM; generated by ` + "`PROGRAM completion powershell`" + `
+Register-ArgumentCompleter -Native -CommandName 'PROGRAM' -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $previous = @($commandAst.CommandElements | Select-Object -Skip 1 | ForEach-Object { $_.ToString() })
+    if ($wordToComplete -ne '' -and $previous.Count -gt 0) {
+        $previous = @($previous | Select-Object -First ($previous.Count - 1))
+    }
+    PROGRAM completion candidates "--current=$wordToComplete" -- @previous 2>$null | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`,
+}
@@ -0,0 +1,122 @@
+// Package creds implements the creds subcommands.
+package creds
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	fromNow "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/from-now"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+var (
+	// Command is the root of the creds subtree.
+	Command = &cobra.Command{
+		Use:   "creds",
+		Short: "Provides credential-related actions.",
+	}
+)
+
+func init() {
+	createTempCmd := &cobra.Command{
+		Use:   "create-temp",
+		Short: "Create temporary credentials from the current credentials.",
+		Long: `Create temporary credentials from the current credentials, which must be
+permanent credentials. The temporary credentials only have the given scopes,
+which must be satisfied by the current credentials, and expire after the given
+duration, of at most 31 days.
+
+By default, the credentials are output as environment variables, for use like this:
+
+$ eval ` + "`taskcluster creds create-temp --scope queue:create-task:* --expires 2h`",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var creds *tcclient.Credentials
+			if config.Credentials != nil {
+				creds = config.Credentials.ToClientCredentials()
+			}
+			return runCreateTemp(creds, cmd.OutOrStdout(), cmd.Flags())
+		},
+	}
+	createTempCmd.Flags().StringArrayP("scope", "s", nil, "(can be repeated) Scopes of the temporary credentials.")
+	createTempCmd.Flags().String("expires", "1h", "Lifetime of the temporary credentials, e.g. 2h or '1 day'.")
+	createTempCmd.Flags().StringP("name", "n", "", "ClientId of the temporary credentials, making them named temporary credentials.")
+	createTempCmd.Flags().StringArray("authorized-scope", nil, "(can be repeated) Restrict the temporary credentials to these scopes when making requests (requires --json).")
+	createTempCmd.Flags().Bool("csh", false, "Output csh-style environment variables (default is Bourne shell).")
+	createTempCmd.Flags().Bool("json", false, "Output the credentials as JSON.")
+
+	Command.AddCommand(createTempCmd)
+	root.Command.AddCommand(Command)
+}
+
+// tempCredentials is the JSON output of create-temp.
+type tempCredentials struct {
+	RootURL string `json:"rootUrl"`
+	tcclient.Credentials
+	AuthorizedScopes []string      `json:"authorizedScopes,omitempty"`
+	Expires          tcclient.Time `json:"expires"`
+}
+
+// runCreateTemp creates temporary credentials from the given credentials,
+// and writes them to out.
+func runCreateTemp(credentials *tcclient.Credentials, out io.Writer, flags *pflag.FlagSet) error {
+	if credentials == nil || credentials.ClientID == "" {
+		return errors.New("creating temporary credentials requires credentials; set TASKCLUSTER_CLIENT_ID and TASKCLUSTER_ACCESS_TOKEN")
+	}
+
+	scopes, _ := flags.GetStringArray("scope")
+	if len(scopes) == 0 {
+		return errors.New("at least one --scope must be given")
+	}
+	expires, _ := flags.GetString("expires")
+	expiry, err := fromNow.Parse(expires)
+	if err != nil {
+		return fmt.Errorf("invalid --expires: %s", err)
+	}
+	name, _ := flags.GetString("name")
+	authorizedScopes, _ := flags.GetStringArray("authorized-scope")
+	asJSON, _ := flags.GetBool("json")
+	csh, _ := flags.GetBool("csh")
+
+	if len(authorizedScopes) > 0 && !asJSON {
+		return errors.New("--authorized-scope cannot be expressed as environment variables; use --json")
+	}
+
+	tempCreds, err := credentials.CreateNamedTemporaryCredentials(name, time.Until(expiry), scopes...)
+	if err != nil {
+		return fmt.Errorf("could not create temporary credentials: %s", err)
+	}
+
+	if asJSON {
+		if len(authorizedScopes) == 0 {
+			authorizedScopes = tempCreds.AuthorizedScopes
+		}
+		data, err := json.MarshalIndent(tempCredentials{
+			RootURL:          config.RootURL(),
+			Credentials:      *tempCreds,
+			AuthorizedScopes: authorizedScopes,
+			Expires:          tcclient.Time(expiry),
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("could not render credentials as JSON: %s", err)
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	}
+
+	format := "export %s='%s'\n"
+	if csh {
+		format = "setenv %s '%s'\n"
+	}
+	fmt.Fprintf(out, format, "TASKCLUSTER_CLIENT_ID", tempCreds.ClientID)
+	fmt.Fprintf(out, format, "TASKCLUSTER_ACCESS_TOKEN", tempCreds.AccessToken)
+	fmt.Fprintf(out, format, "TASKCLUSTER_CERTIFICATE", tempCreds.Certificate)
+	fmt.Fprintf(out, format, "TASKCLUSTER_ROOT_URL", config.RootURL())
+	return nil
+}
@@ -0,0 +1,84 @@
+package creds
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/spf13/pflag"
+	assert "github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+var permaCreds = &tcclient.Credentials{
+	ClientID:    "tester",
+	AccessToken: "no-secret",
+}
+
+func createTempFlags(args ...string) *pflag.FlagSet {
+	fs := pflag.NewFlagSet("create-temp", pflag.ContinueOnError)
+	fs.StringArrayP("scope", "s", nil, "")
+	fs.String("expires", "1h", "")
+	fs.StringP("name", "n", "", "")
+	fs.StringArray("authorized-scope", nil, "")
+	fs.Bool("csh", false, "")
+	fs.Bool("json", false, "")
+	if err := fs.Parse(args); err != nil {
+		panic(err)
+	}
+	return fs
+}
+
+func TestCreateTempEnv(t *testing.T) {
+	assert := assert.New(t)
+	config.SetRootURL("https://tc.example.com")
+	defer config.SetRootURL("")
+
+	buf := &bytes.Buffer{}
+	assert.NoError(runCreateTemp(permaCreds, buf, createTempFlags("--scope", "queue:get-artifact:*", "--expires", "2h")))
+
+	assert.Regexp(regexp.MustCompile(`^export TASKCLUSTER_CLIENT_ID='tester'
+export TASKCLUSTER_ACCESS_TOKEN='[^']+'
+export TASKCLUSTER_CERTIFICATE='\{[^']+\}'
+export TASKCLUSTER_ROOT_URL='https://tc.example.com'
+$`), buf.String())
+}
+
+func TestCreateTempJSON(t *testing.T) {
+	assert := assert.New(t)
+	config.SetRootURL("https://tc.example.com")
+	defer config.SetRootURL("")
+
+	buf := &bytes.Buffer{}
+	flags := createTempFlags("-s", "a", "-s", "b", "--name", "tester/temp", "--authorized-scope", "a", "--json")
+	assert.NoError(runCreateTemp(permaCreds, buf, flags))
+
+	var result struct {
+		ClientID         string   `json:"clientId"`
+		Certificate      string   `json:"certificate"`
+		AuthorizedScopes []string `json:"authorizedScopes"`
+		RootURL          string   `json:"rootUrl"`
+	}
+	assert.NoError(json.Unmarshal(buf.Bytes(), &result))
+	assert.Equal("tester/temp", result.ClientID)
+	assert.Equal([]string{"a"}, result.AuthorizedScopes)
+	assert.Equal("https://tc.example.com", result.RootURL)
+
+	var cert tcclient.Certificate
+	assert.NoError(json.Unmarshal([]byte(result.Certificate), &cert))
+	assert.Equal([]string{"a", "b"}, cert.Scopes)
+	assert.Equal("tester", cert.Issuer)
+}
+
+func TestCreateTempErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	assert.Error(runCreateTemp(nil, buf, createTempFlags("-s", "a")), "credentials are required")
+	assert.Error(runCreateTemp(permaCreds, buf, createTempFlags()), "a scope is required")
+	assert.Error(runCreateTemp(permaCreds, buf, createTempFlags("-s", "a", "--expires", "soon")), "expires must be valid")
+	assert.Error(runCreateTemp(permaCreds, buf, createTempFlags("-s", "a", "--authorized-scope", "a")), "authorized scopes require json")
+	assert.Empty(buf.String())
+}
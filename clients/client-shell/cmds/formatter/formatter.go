@@ -0,0 +1,104 @@
+// Package formatter renders the results of subcommands in the output format
+// requested with the --output flag, so that they can be consumed by tools
+// such as jq.
+package formatter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/pflag"
+)
+
+// Format is an output format.
+type Format string
+
+const (
+	// Text is the ad-hoc, human-readable output of each subcommand.
+	Text Format = "text"
+	// JSON renders the result as indented json.
+	JSON Format = "json"
+	// YAML renders the result as yaml.
+	YAML Format = "yaml"
+	// Table renders the result as a table with aligned columns.
+	Table Format = "table"
+	// CSV renders the rows of the table as comma-separated values.
+	CSV Format = "csv"
+)
+
+// Formats are all supported output formats.
+var Formats = []Format{Text, JSON, YAML, Table, CSV}
+
+// RegisterFlag adds the --output/-o flag to flags.
+func RegisterFlag(flags *pflag.FlagSet) {
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	flags.StringP("output", "o", string(Text), "Output format, one of: "+strings.Join(names, ", ")+".")
+}
+
+// FromFlags returns the output format given by the --output flag, or Text
+// if flags has no such flag.
+func FromFlags(flags *pflag.FlagSet) (Format, error) {
+	value, err := flags.GetString("output")
+	if err != nil || value == "" {
+		return Text, nil
+	}
+	for _, f := range Formats {
+		if Format(value) == f {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown output format %q", value)
+}
+
+// Rows is the tabular representation of a result, with a header row.
+type Rows struct {
+	Header []string
+	Rows   [][]string
+}
+
+// Write renders a result to out in the given format: value is rendered for
+// JSON and YAML, and rows for Table and CSV. Text is left to the caller, so it is an
+// error to pass it.
+func Write(out io.Writer, format Format, value interface{}, rows Rows) error {
+	switch format {
+	case JSON:
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return fmt.Errorf("could not render json: %w", err)
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	case YAML:
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("could not render yaml: %w", err)
+		}
+		_, err = out.Write(data)
+		return err
+	case Table:
+		w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, strings.Join(rows.Header, "\t"))
+		for _, row := range rows.Rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	case CSV:
+		w := csv.NewWriter(out)
+		if err := w.Write(rows.Header); err != nil {
+			return fmt.Errorf("could not render csv: %w", err)
+		}
+		if err := w.WriteAll(rows.Rows); err != nil {
+			return fmt.Errorf("could not render csv: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("output format %q must be rendered by the caller", format)
+}
@@ -0,0 +1,81 @@
+package formatter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/pflag"
+	assert "github.com/stretchr/testify/require"
+)
+
+type result struct {
+	TaskID string `json:"taskId"`
+	State  string `json:"state"`
+}
+
+func TestFromFlags(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := pflag.NewFlagSet("TestFromFlags", pflag.ContinueOnError)
+	format, err := FromFlags(fs)
+	assert.NoError(err)
+	assert.Equal(Text, format, "no --output flag should mean text")
+
+	RegisterFlag(fs)
+	format, err = FromFlags(fs)
+	assert.NoError(err)
+	assert.Equal(Text, format)
+
+	assert.NoError(fs.Parse([]string{"-o", "yaml"}))
+	format, err = FromFlags(fs)
+	assert.NoError(err)
+	assert.Equal(YAML, format)
+
+	assert.NoError(fs.Set("output", "xml"))
+	_, err = FromFlags(fs)
+	assert.Error(err)
+}
+
+func TestWriteJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(Write(buf, JSON, []result{{"abc", "running"}}, Rows{}))
+	assert.Equal("[\n  {\n    \"taskId\": \"abc\",\n    \"state\": \"running\"\n  }\n]\n", buf.String())
+}
+
+func TestWriteTable(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	rows := Rows{
+		Header: []string{"TASK ID", "STATE"},
+		Rows: [][]string{
+			{"abc", "running"},
+			{"abcdefghijk", "completed"},
+		},
+	}
+	assert.NoError(Write(buf, Table, nil, rows))
+	assert.Equal("TASK ID      STATE\nabc          running\nabcdefghijk  completed\n", buf.String())
+}
+
+func TestWriteText(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Error(Write(&bytes.Buffer{}, Text, nil, Rows{}))
+}
+
+func TestWriteCSV(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	rows := Rows{
+		Header: []string{"TASK ID", "NAME"},
+		Rows: [][]string{
+			{"abc", "build, test"},
+			{"def", `say "hi"`},
+		},
+	}
+	assert.NoError(Write(buf, CSV, nil, rows))
+	assert.Equal("TASK ID,NAME\nabc,\"build, test\"\ndef,\"say \"\"hi\"\"\"\n", buf.String())
+}
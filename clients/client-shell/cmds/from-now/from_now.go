@@ -14,11 +14,20 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// TimeFormat is the format of the timestamps accepted by the Queue, as
+// produced by JSON.stringify in the JS client.
+const TimeFormat = "2006-01-02T15:04:05.000Z"
+
 func init() {
 	root.Command.AddCommand(&cobra.Command{
 		Use:   "from-now <duration>",
 		Short: "Returns a timestamp which is <duration> ahead in the future.",
-		RunE:  fromNow,
+		Long: `Returns a timestamp which is <duration> ahead in the future, in the
+format of the timestamps of task definitions, e.g. 2020-01-02T03:04:05.678Z.
+
+The duration is given as in the JS client, such as "2 hours" or "1d2h", and
+may be negative, e.g. from-now -- -1 day.`,
+		RunE: fromNow,
 	})
 }
 
@@ -28,10 +37,28 @@ func fromNow(cmd *cobra.Command, args []string) error {
 	}
 	duration := strings.Join(args, " ")
 
+	timein, err := Parse(duration)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), timein.UTC().Format(TimeFormat))
+
+	return nil
+}
+
+// Parse returns the time which is the given duration ahead in the future,
+// such as "1 day 2 hours" or "1d2h", or in the past for "-1 day". Go
+// durations such as "30m" (which parseTime would reject, as "m" is
+// ambiguous) are accepted too.
+func Parse(duration string) (time.Time, error) {
 	offset, err := parseTime(duration)
 
 	if err != nil {
-		return fmt.Errorf("string '%s' is not a valid time expression", duration)
+		if d, goErr := time.ParseDuration(strings.TrimSpace(duration)); goErr == nil {
+			return time.Now().Add(d), nil
+		}
+		return time.Time{}, fmt.Errorf("string '%s' is not a valid time expression", duration)
 	}
 
 	// logic taken from github.com/taskcluster/taskcluster-client/blob/master/lib/utils.js
@@ -41,15 +68,13 @@ func fromNow(cmd *cobra.Command, args []string) error {
 		time.Minute*time.Duration(offset.minutes) +
 		time.Second*time.Duration(offset.seconds)
 
-	timein := time.Now().Add(timeToAdd)
-	timein = timein.AddDate(offset.years, offset.months, 0)
-
-	fmt.Fprintln(cmd.OutOrStdout(), timein.Format(time.RFC3339))
-
-	return nil
+	timein := time.Now().Add(time.Duration(offset.sign) * timeToAdd)
+	return timein.AddDate(offset.sign*offset.years, offset.sign*offset.months, 0), nil
 }
 
 type timeOffset struct {
+	// sign is -1 for offsets in the past, and 1 otherwise
+	sign    int
 	years   int
 	months  int
 	weeks   int
@@ -68,7 +93,7 @@ type timeOffset struct {
  * Returns a parse_time object with all of the fields filled in with the correct values.
  */
 func parseTime(str string) (timeOffset, error) {
-	offset := timeOffset{}
+	offset := timeOffset{sign: 1}
 
 	// Regexp taken from github.com/taskcluster/taskcluster-client/blob/master/lib/parsetime.js
 	re := regexp.MustCompile(
@@ -98,11 +123,9 @@ func parseTime(str string) (timeOffset, error) {
 
 	groupMatches := re.FindAllStringSubmatch(strings.TrimSpace(str), -1)
 
-	// Add negative support after we figure out what we are doing with docopt because it complains about the '-'
-	// neg := 1
-	// if groupMatches[0][1] == "-" {
-	// 	neg = -1
-	// }
+	if groupMatches[0][2] == "-" {
+		offset.sign = -1
+	}
 
 	offset.years = atoiHelper(groupMatches[0][4])
 	offset.months = atoiHelper(groupMatches[0][8])
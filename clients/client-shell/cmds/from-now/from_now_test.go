@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	assert "github.com/stretchr/testify/require"
@@ -19,8 +20,10 @@ var (
 			`20[0-9]{2}-(1[0-2]|0[0-9])-(3[0-1]|[0-2][0-9])` +
 			// T then hours:minutes:seconds (0 padded)
 			`T(2[0-3]|[01][0-9])(:[0-5][0-9]){2}` +
-			// Z or timezone offset
-			`(Z|(\+|-)(2[0-3]|[01][0-9]):[0-5][0-9])` +
+			// milliseconds
+			`\.[0-9]{3}` +
+			// UTC
+			`Z` +
 			// end of the line
 			`$`,
 	)
@@ -56,6 +59,20 @@ func TestParseTimeIncomplete(t *testing.T) {
 	assert.Equal(offset.seconds, 0)
 }
 
+func TestParseTimeNegative(t *testing.T) {
+	assert := assert.New(t)
+
+	offset, err := parseTime("-1 day 2 hours")
+	assert.NoError(err, "the string should parse without error")
+	assert.Equal(-1, offset.sign)
+	assert.Equal(1, offset.days)
+
+	before := time.Now()
+	timein, err := Parse("- 1 day")
+	assert.NoError(err, "a negative offset should parse without error")
+	assert.WithinDuration(before.Add(-24*time.Hour), timein, time.Second)
+}
+
 func TestParseTimeInvalid(t *testing.T) {
 	assert := assert.New(t)
 
@@ -134,3 +151,14 @@ func TestFromNowSplitValid(t *testing.T) {
 	assert.NoError(err, "error when given a valid input")
 	assert.True(match, "the command did not return a valid timestamp")
 }
+
+// TestParseGoDuration tests that Parse accepts Go durations.
+func TestParseGoDuration(t *testing.T) {
+	assert := assert.New(t)
+
+	before := time.Now()
+	timein, err := Parse("30m")
+
+	assert.NoError(err, "a Go duration should parse without error")
+	assert.WithinDuration(before.Add(30*time.Minute), timein, time.Second)
+}
@@ -0,0 +1,268 @@
+// Package github implements the github subcommands.
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ghodss/yaml"
+	jsone "github.com/json-e/json-e/v4"
+	"github.com/json-e/json-e/v4/interpreter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	sluglib "github.com/taskcluster/slugid-go/slugid"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcgithub"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+var (
+	// Command is the root of the github subtree.
+	Command = &cobra.Command{
+		Use:   "github",
+		Short: "Provides actions to debug the GitHub integration of Taskcluster.",
+	}
+
+	// newSlugID generates the taskIds of rendered tasks; it is replaced in
+	// tests.
+	newSlugID = sluglib.Nice
+)
+
+func init() {
+	renderCmd := &cobra.Command{
+		Use:   "render-tc-yml --event <file>",
+		Short: "Render .taskcluster.yml for a GitHub event, as the GitHub service would.",
+		Long: `Render a version 1 .taskcluster.yml for a GitHub event, and print the tasks
+which the GitHub service would create, along with the scopes it would use to
+create them.
+
+The event is the JSON body of a GitHub webhook, as shown in the settings of
+the repository. The kind of event (github-push, github-pull-request or
+github-release) is guessed from its content, unless given with --tasks-for.`,
+		RunE: root.ExecuteHelperE(runRender, 0, 0),
+	}
+	renderCmd.Flags().StringP("file", "f", ".taskcluster.yml", "The .taskcluster.yml file to render.")
+	renderCmd.Flags().StringP("event", "e", "", "File holding the JSON body of the GitHub event.")
+	renderCmd.Flags().String("tasks-for", "", "Kind of event: github-push, github-pull-request or github-release.")
+
+	buildsCmd := &cobra.Command{
+		Use:   "builds <organization>/<repository>",
+		Short: "List the recent builds of a repository, with their states.",
+		RunE:  root.ExecuteHelperE(runBuilds, 1, 1),
+	}
+	buildsCmd.Flags().String("sha", "", "Only list the builds of this commit.")
+	buildsCmd.Flags().IntP("limit", "l", 20, "Number of builds to show (0 for all).")
+
+	Command.AddCommand(renderCmd, buildsCmd)
+	root.Command.AddCommand(Command)
+}
+
+func makeGithub(credentials *tcclient.Credentials) *tcgithub.Github {
+	return tcgithub.New(credentials, config.RootURL())
+}
+
+// rendering is the output of render-tc-yml.
+type rendering struct {
+	Scopes []string      `json:"scopes"`
+	Tasks  []interface{} `json:"tasks"`
+}
+
+// runRender renders .taskcluster.yml for the event given with --event.
+func runRender(_ *tcclient.Credentials, _ []string, out io.Writer, flags *pflag.FlagSet) error {
+	file, _ := flags.GetString("file")
+	eventFile, _ := flags.GetString("event")
+	tasksFor, _ := flags.GetString("tasks-for")
+	if eventFile == "" {
+		return errors.New("the GitHub event must be given with --event")
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("could not read %s: %w", file, err)
+	}
+	var tcyml map[string]interface{}
+	if err := yaml.Unmarshal(data, &tcyml); err != nil {
+		return fmt.Errorf("could not parse %s: %w", file, err)
+	}
+	if version, _ := tcyml["version"].(float64); version != 1 {
+		return fmt.Errorf("%s must have version: 1, only version 1 can be rendered", file)
+	}
+	delete(tcyml, "version")
+
+	data, err = ioutil.ReadFile(eventFile)
+	if err != nil {
+		return fmt.Errorf("could not read event: %w", err)
+	}
+	var event map[string]interface{}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return fmt.Errorf("could not parse event: %w", err)
+	}
+	if tasksFor == "" {
+		if tasksFor = guessTasksFor(event); tasksFor == "" {
+			return errors.New("could not tell the kind of event, give it with --tasks-for")
+		}
+	}
+
+	slugids := map[string]string{}
+	asSlugID := func(label string) string {
+		if _, ok := slugids[label]; !ok {
+			slugids[label] = newSlugID()
+		}
+		return slugids[label]
+	}
+
+	result, err := jsone.Render(tcyml, map[string]interface{}{
+		"taskcluster_root_url": config.RootURL(),
+		"tasks_for":            tasksFor,
+		"event":                event,
+		"as_slugid":            interpreter.WrapFunction(asSlugID),
+	})
+	if err != nil {
+		return fmt.Errorf("could not render %s: %w", file, err)
+	}
+	rendered, _ := result.(map[string]interface{})
+	tasks, _ := rendered["tasks"].([]interface{})
+
+	scopes, err := repoScopes(event, tasksFor)
+	if err != nil {
+		return err
+	}
+	return writeYAML(out, rendering{
+		Scopes: scopes,
+		Tasks:  withTaskIDs(tasks),
+	})
+}
+
+// guessTasksFor returns the kind of the event, given the properties which
+// are specific to each kind.
+func guessTasksFor(event map[string]interface{}) string {
+	switch {
+	case event["pull_request"] != nil:
+		return "github-pull-request"
+	case event["release"] != nil:
+		return "github-release"
+	case event["ref"] != nil:
+		return "github-push"
+	}
+	return ""
+}
+
+// withTaskIDs gives the tasks the default taskId and taskGroupId that the
+// GitHub service gives them: a single task is its own task group, while
+// several tasks share a new task group.
+func withTaskIDs(tasks []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(tasks))
+	groupID := newSlugID()
+	for _, t := range tasks {
+		task, ok := t.(map[string]interface{})
+		if !ok {
+			result = append(result, t)
+			continue
+		}
+		taskID, _ := task["taskId"].(string)
+		if taskID == "" {
+			taskID = newSlugID()
+		}
+		if len(tasks) == 1 {
+			groupID = taskID
+		}
+		def := make(map[string]interface{}, len(task))
+		for k, v := range task {
+			if k != "taskId" {
+				def[k] = v
+			}
+		}
+		if _, ok := def["taskGroupId"]; !ok {
+			def["taskGroupId"] = groupID
+		}
+		result = append(result, map[string]interface{}{"taskId": taskID, "task": def})
+	}
+	return result
+}
+
+// repoScopes returns the repository scopes which the GitHub service assumes
+// to create the tasks of the event.
+func repoScopes(event map[string]interface{}, tasksFor string) ([]string, error) {
+	repo, _ := event["repository"].(map[string]interface{})
+	owner, _ := repo["owner"].(map[string]interface{})
+	organization, _ := owner["login"].(string)
+	repository, _ := repo["name"].(string)
+	if organization == "" || repository == "" {
+		return nil, errors.New("the event has no repository.owner.login and repository.name")
+	}
+	prefix := "assume:repo:github.com/" + organization + "/" + repository
+
+	switch tasksFor {
+	case "github-pull-request":
+		return []string{prefix + ":pull-request"}, nil
+	case "github-release":
+		return []string{prefix + ":release"}, nil
+	case "github-push":
+		ref, _ := event["ref"].(string)
+		if tag := strings.TrimPrefix(ref, "refs/tags/"); tag != ref {
+			return []string{prefix + ":tag:" + tag}, nil
+		}
+		return []string{prefix + ":branch:" + strings.TrimPrefix(ref, "refs/heads/")}, nil
+	}
+	return nil, fmt.Errorf("unknown kind of event %q", tasksFor)
+}
+
+// writeYAML writes v to out as YAML.
+func writeYAML(out io.Writer, v interface{}) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("could not render yaml: %w", err)
+	}
+	_, err = out.Write(data)
+	return err
+}
+
+// runBuilds lists the most recent builds of a repository, latest first.
+func runBuilds(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	parts := strings.Split(args[0], "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("invalid repository %q, expected <organization>/<repository>", args[0])
+	}
+	sha, _ := flags.GetString("sha")
+	limit, _ := flags.GetInt("limit")
+
+	gh := makeGithub(credentials)
+	var builds []tcgithub.Build
+	continuation := ""
+	for {
+		resp, err := gh.Builds(continuation, "", parts[0], parts[1], sha)
+		if err != nil {
+			return fmt.Errorf("could not list the builds of %s: %w", args[0], err)
+		}
+		builds = append(builds, resp.Builds...)
+		if continuation = resp.ContinuationToken; continuation == "" {
+			break
+		}
+	}
+
+	sort.SliceStable(builds, func(i, j int) bool {
+		return time.Time(builds[i].Created).After(time.Time(builds[j].Created))
+	})
+	if limit > 0 && len(builds) > limit {
+		builds = builds[:limit]
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CREATED\tSHA\tEVENT\tSTATE\tTASK GROUP")
+	for _, b := range builds {
+		shortSHA := b.Sha
+		if len(shortSHA) > 12 {
+			shortSHA = shortSHA[:12]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", time.Time(b.Created).UTC().Format(time.RFC3339), shortSHA, b.EventType, b.State, b.TaskGroupID)
+	}
+	return w.Flush()
+}
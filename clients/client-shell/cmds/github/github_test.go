@@ -0,0 +1,182 @@
+package github
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/suite"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+const tcyml = `{
+  "version": 1,
+  "tasks": {
+    "$let": {"head": {"$if": "tasks_for == 'github-pull-request'", "then": "${event.pull_request.head.sha}", "else": "${event.after}"}},
+    "in": [
+      {
+        "taskId": {"$eval": "as_slugid('decision')"},
+        "provisionerId": "proj-app",
+        "workerType": "ci",
+        "payload": {"command": ["build", "${head}"]},
+        "metadata": {"name": "build ${event.repository.name}"}
+      },
+      {
+        "$if": "tasks_for == 'github-push'",
+        "then": {
+          "dependencies": [{"$eval": "as_slugid('decision')"}],
+          "payload": {"command": ["deploy"]},
+          "metadata": {"name": "deploy"}
+        }
+      }
+    ]
+  }
+}`
+
+const pushEvent = `{"ref": "refs/heads/main", "after": "abc123", "repository": {"name": "app", "owner": {"login": "org"}}}`
+
+const pullRequestEvent = `{"action": "opened", "pull_request": {"head": {"sha": "def456"}}, "repository": {"name": "app", "owner": {"login": "org"}}}`
+
+type FakeServerSuite struct {
+	suite.Suite
+	testServer *httptest.Server
+	dir        string
+}
+
+func (suite *FakeServerSuite) SetupSuite() {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/github/v1/builds", func(w http.ResponseWriter, r *http.Request) {
+		suite.Equal("org", r.URL.Query().Get("organization"))
+		suite.Equal("app", r.URL.Query().Get("repository"))
+		if r.URL.Query().Get("continuationToken") == "" {
+			_, _ = io.WriteString(w, `{"builds": [{"created": "2020-01-01T00:00:00.000Z", "sha": "1111111111111111111111111111111111111111", "eventType": "push", "state": "success", "taskGroupId": "AAAAAAAAQQCAAAAAAAAAAA"}], "continuationToken": "next"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"builds": [{"created": "2020-01-02T00:00:00.000Z", "sha": "2222222222222222222222222222222222222222", "eventType": "pull_request.opened", "state": "failure", "taskGroupId": "BBBBBBBBQQCBBBBBBBBBBA"}]}`)
+	})
+	suite.testServer = httptest.NewServer(handler)
+	config.SetRootURL(suite.testServer.URL)
+
+	n := 0
+	newSlugID = func() string {
+		n++
+		return fmt.Sprintf("slug%d", n)
+	}
+}
+
+func (suite *FakeServerSuite) SetupTest() {
+	var err error
+	suite.dir, err = ioutil.TempDir("", "github")
+	suite.Require().NoError(err)
+	suite.Require().NoError(ioutil.WriteFile(filepath.Join(suite.dir, ".taskcluster.yml"), []byte(tcyml), 0644))
+	suite.Require().NoError(ioutil.WriteFile(filepath.Join(suite.dir, "push.json"), []byte(pushEvent), 0644))
+	suite.Require().NoError(ioutil.WriteFile(filepath.Join(suite.dir, "pr.json"), []byte(pullRequestEvent), 0644))
+}
+
+func (suite *FakeServerSuite) TearDownTest() {
+	os.RemoveAll(suite.dir)
+}
+
+func (suite *FakeServerSuite) TearDownSuite() {
+	suite.testServer.Close()
+	config.SetRootURL("")
+}
+
+func TestFakeServerSuite(t *testing.T) {
+	suite.Run(t, new(FakeServerSuite))
+}
+
+func (suite *FakeServerSuite) renderFlags(event string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("render-tc-yml", pflag.ContinueOnError)
+	flags.String("file", filepath.Join(suite.dir, ".taskcluster.yml"), "")
+	flags.String("event", filepath.Join(suite.dir, event), "")
+	flags.String("tasks-for", "", "")
+	return flags
+}
+
+func (suite *FakeServerSuite) render(event string) rendering {
+	buf := &bytes.Buffer{}
+	err := runRender(&tcclient.Credentials{}, nil, buf, suite.renderFlags(event))
+	suite.Require().NoError(err)
+
+	var r rendering
+	suite.Require().NoError(yaml.Unmarshal(buf.Bytes(), &r))
+	return r
+}
+
+func (suite *FakeServerSuite) TestRenderPush() {
+	r := suite.render("push.json")
+	suite.Equal([]string{"assume:repo:github.com/org/app:branch:main"}, r.Scopes)
+	suite.Require().Len(r.Tasks, 2)
+
+	decision := r.Tasks[0].(map[string]interface{})
+	task := decision["task"].(map[string]interface{})
+	suite.Equal([]interface{}{"build", "abc123"}, task["payload"].(map[string]interface{})["command"])
+	suite.Equal("build app", task["metadata"].(map[string]interface{})["name"])
+
+	// several tasks share a new task group, and as_slugid is stable
+	deploy := r.Tasks[1].(map[string]interface{})
+	suite.Equal([]interface{}{decision["taskId"]}, deploy["task"].(map[string]interface{})["dependencies"])
+	suite.Equal(task["taskGroupId"], deploy["task"].(map[string]interface{})["taskGroupId"])
+	suite.NotEqual(decision["taskId"], task["taskGroupId"])
+}
+
+func (suite *FakeServerSuite) TestRenderPullRequest() {
+	r := suite.render("pr.json")
+	suite.Equal([]string{"assume:repo:github.com/org/app:pull-request"}, r.Scopes)
+	suite.Require().Len(r.Tasks, 1)
+
+	// a single task is its own task group
+	decision := r.Tasks[0].(map[string]interface{})
+	task := decision["task"].(map[string]interface{})
+	suite.Equal([]interface{}{"build", "def456"}, task["payload"].(map[string]interface{})["command"])
+	suite.Equal(decision["taskId"], task["taskGroupId"])
+}
+
+func (suite *FakeServerSuite) TestRenderSlugIDs() {
+	suite.Require().NoError(ioutil.WriteFile(filepath.Join(suite.dir, ".taskcluster.yml"), []byte(`{
+  "version": 1,
+  "tasks": [{
+    "taskId": {"$eval": "as_slugid('a')"},
+    "dependencies": [{"$eval": "as_slugid('a')"}, {"$eval": "as_slugid('b')"}, {"$eval": "as_slugid('b')"}]
+  }]
+}`), 0644))
+	r := suite.render("push.json")
+	suite.Require().Len(r.Tasks, 1)
+
+	// the same label always gives the same slugid, and a new label a new one
+	decision := r.Tasks[0].(map[string]interface{})
+	dependencies := decision["task"].(map[string]interface{})["dependencies"].([]interface{})
+	suite.Equal(decision["taskId"], dependencies[0])
+	suite.Equal(dependencies[1], dependencies[2])
+	suite.NotEqual(dependencies[0], dependencies[1])
+}
+
+func (suite *FakeServerSuite) TestRenderError() {
+	suite.Require().NoError(ioutil.WriteFile(filepath.Join(suite.dir, ".taskcluster.yml"), []byte(`{"version": 1, "tasks": ["${event.nope.x}"]}`), 0644))
+
+	err := runRender(&tcclient.Credentials{}, nil, &bytes.Buffer{}, suite.renderFlags("push.json"))
+	suite.EqualError(err, "could not render "+filepath.Join(suite.dir, ".taskcluster.yml")+": at template.tasks[0]: cannot access property x of null")
+}
+
+func (suite *FakeServerSuite) TestBuilds() {
+	flags := pflag.NewFlagSet("builds", pflag.ContinueOnError)
+	flags.String("sha", "", "")
+	flags.Int("limit", 20, "")
+
+	buf := &bytes.Buffer{}
+	err := runBuilds(&tcclient.Credentials{}, []string{"org/app"}, buf, flags)
+	suite.NoError(err)
+	suite.Equal("CREATED               SHA           EVENT                STATE    TASK GROUP\n"+
+		"2020-01-02T00:00:00Z  222222222222  pull_request.opened  failure  BBBBBBBBQQCBBBBBBBBBBA\n"+
+		"2020-01-01T00:00:00Z  111111111111  push                 success  AAAAAAAAQQCAAAAAAAAAAA\n", buf.String())
+}
@@ -1,10 +1,11 @@
 package group
 
 import (
-	"context"
 	"fmt"
 	"html/template"
 	"io"
+	"os"
+	"sort"
 	"strings"
 	"sync"
 
@@ -12,11 +13,17 @@ import (
 	"github.com/spf13/pflag"
 	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
 	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/client"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/formatter"
 	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
 )
 
 var listFormat string
 
+// progressOut is where the progress of cancellations is reported; it is
+// replaced in tests.
+var progressOut io.Writer = os.Stderr
+
 func init() {
 	cancelCmd := &cobra.Command{
 		Use:   "cancel <taskGroupId>",
@@ -25,6 +32,8 @@ func init() {
 	}
 	cancelCmd.Flags().StringP("worker-type", "w", "", "Only cancel tasks with a certain worker type.")
 	cancelCmd.Flags().BoolP("force", "f", false, "Skip cancellation confirmation.")
+	cancelCmd.Flags().StringArray("filter", nil, "Only cancel tasks matching a filter, e.g. state=pending,running (can be repeated).")
+	cancelCmd.Flags().IntP("concurrency", "j", 20, "Maximum number of concurrent cancellations.")
 
 	Command.AddCommand(cancelCmd)
 
@@ -34,6 +43,8 @@ func init() {
 		RunE:  executeHelperE(runStatus),
 	}
 
+	statusCmd.Flags().IntP("limit", "l", 0, "Only consider the first <limit> tasks of the group (0 for all).")
+
 	Command.AddCommand(statusCmd)
 
 	listCmd := &cobra.Command{
@@ -50,6 +61,8 @@ func init() {
 	listCmd.Flags().BoolP("unscheduled", "u", false, "Include unscheduled tasks.")
 	listCmd.Flags().BoolP("pending", "p", false, "Include pending tasks.")
 
+	listCmd.Flags().IntP("limit", "l", 0, "Only consider the first <limit> tasks of the group (0 for all).")
+
 	listCmd.Flags().StringVar(&listFormat, "format-string", "{{ .Status.TaskID }} {{ .Task.Metadata.Name }} {{ .Status.State }}", "Go Template string for output")
 
 	Command.AddCommand(listCmd)
@@ -62,39 +75,39 @@ func makeQueue(credentials *tcclient.Credentials) *tcqueue.Queue {
 // runCancel cancels all tasks of a group.
 //
 // It first fetches the list of all tasks associated with the given group,
-// then filters for only cancellable tasks (unscheduled, pending, running),
-// and finally runs all cancellations concurrently, because they are
-// independent of each other.
+// then filters for only cancellable tasks (by default unscheduled, pending,
+// running) matching the given filters, and finally runs the cancellations
+// concurrently, because they are independent of each other. The number of
+// concurrent cancellations is bounded by --concurrency, so that groups of
+// thousands of tasks can be cancelled without flooding the queue.
 func runCancel(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
 	q := makeQueue(credentials)
 	groupID := args[0]
 
-	// Because the list of tasks can be arbitrarily long, we have to loop until
-	// we are told not to.
-	tasks := make([]string, 0)
-	tasksNames := make([]string, 0)
-	cont := ""
+	states, err := stateFilter(flags)
+	if err != nil {
+		return err
+	}
 
-	for {
-		// get next TaskGroup for groupID
-		ts, err := q.ListTaskGroup(groupID, cont, "")
-		if err != nil {
-			return fmt.Errorf("could not fetch tasks for group %s: %v", groupID, err)
-		}
+	concurrency, _ := flags.GetInt("concurrency")
+	if concurrency < 1 {
+		concurrency = 1
+	}
 
-		// set tasks that meet the criteria (see filterTask) to be deleted
-		for _, t := range ts.Tasks {
-			if filterTask(t.Status, flags) {
-				// add id to be deleted, and name for cancellation
-				tasks = append(tasks, t.Status.TaskID)
-				tasksNames = append(tasksNames, t.Task.Metadata.Name)
-			}
-		}
+	tasks := make([]string, 0)
+	tasksNames := make([]string, 0)
 
-		// break if there are no more tasks for that groupID
-		if cont = ts.ContinuationToken; cont == "" {
-			break
+	// set tasks that meet the criteria (see filterTask) to be deleted
+	err = forEachTask(q, groupID, 0, func(t tcqueue.TaskDefinitionAndStatus) error {
+		if filterTask(t.Status, states, flags) {
+			// add id to be deleted, and name for cancellation
+			tasks = append(tasks, t.Status.TaskID)
+			tasksNames = append(tasksNames, t.Task.Metadata.Name)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	if len(tasks) == 0 {
@@ -108,72 +121,103 @@ func runCancel(credentials *tcclient.Credentials, args []string, out io.Writer,
 		return nil
 	}
 
-	// Here we use a waitgroup to ensure that we return once all the tasks have
-	// been completed.
+	// A fixed pool of workers takes task IDs from the todo channel, and
+	// reports the outcome of each cancellation on the results channel. A
+	// failed cancellation does not stop the others.
+	todo := make(chan string)
+	results := make(chan cancelResult)
+	// outMutex serializes the progress output of the workers.
+	outMutex := &sync.Mutex{}
 	wg := &sync.WaitGroup{}
-	// The context allows us to exit early if any of the cancellation fails.
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	// errChan allows the first request that panics to propagate the error message
-	// up to us.
-	errChan := make(chan error, 1)
-
-	for _, taskID := range tasks {
+	progress := client.Reporter(progressOut, "cancelling tasks")
+	progress.Started(len(tasks))
+
+	for i := 0; i < concurrency && i < len(tasks); i++ {
 		wg.Add(1)
-		go func(taskID string) {
-			// If we paniced on the way out, abort all the other tasks.
-			defer func() {
-				//recover from panic and abort
-				if err := recover(); err != nil {
-					if e, ok := err.(error); ok {
-						errChan <- e
-					} else {
-						errChan <- fmt.Errorf("%v", err)
-					}
-					cancel()
-				}
-			}()
-
-			fmt.Fprintf(out, "cancelling task %s\n", taskID)
-			c := make(chan error, 1)
-			go func() { _, err := q.CancelTask(taskID); c <- err }()
-
-			// we select the first that returns or closes:
-			// - ctx.Done() if we aborted;
-			// - c if we got a completed cancellation.
-			select {
-			case <-ctx.Done():
-				// nothing because we can't cancel the existing requests.
-			case err := <-c:
-				if err != nil {
-					panic(fmt.Errorf("could not cancel task %s: %v", taskID, err))
-				}
+		go func() {
+			defer wg.Done()
+			for taskID := range todo {
+				outMutex.Lock()
+				fmt.Fprintf(out, "cancelling task %s\n", taskID)
+				outMutex.Unlock()
+				_, err := q.CancelTask(taskID)
+				results <- cancelResult{taskID: taskID, err: err}
 			}
-			// if we exited normally, we indicate that we completed.
-			wg.Done()
-		}(taskID)
+		}()
 	}
-	// change the semantics of waitgroup to close a channel instead of blocking
-	// the main thread.
-	regularExit := make(chan bool)
-	go func() { wg.Wait(); close(regularExit) }()
-
-	// We select the first that closes:
-	// - ctx.Done() if we aborted;
-	// - regularExit if all the goroutine exited manually.
-	select {
-	case <-ctx.Done():
-		return fmt.Errorf("could not cancel all tasks: %v", <-errChan)
-	case <-regularExit:
-		return nil
+	go func() {
+		for _, taskID := range tasks {
+			todo <- taskID
+		}
+		close(todo)
+		wg.Wait()
+		close(results)
+	}()
+
+	failures := make([]cancelResult, 0)
+	for result := range results {
+		progress.Completed(result.taskID, result.err)
+		if result.err != nil {
+			failures = append(failures, result)
+		}
+	}
+	progress.Done()
+
+	// sort the failures to make the summary deterministic
+	sort.Slice(failures, func(i, j int) bool { return failures[i].taskID < failures[j].taskID })
+	for _, failure := range failures {
+		fmt.Fprintf(out, "could not cancel task %s: %v\n", failure.taskID, failure.err)
+	}
+	fmt.Fprintf(out, "Cancelled %d of %d tasks (%d failed).\n", len(tasks)-len(failures), len(tasks), len(failures))
+
+	if len(failures) > 0 {
+		return fmt.Errorf("could not cancel %d of %d tasks", len(failures), len(tasks))
+	}
+	return nil
+}
+
+// cancelResult is the outcome of the cancellation of a single task.
+type cancelResult struct {
+	taskID string
+	err    error
+}
+
+// cancellableStates are the task states that runCancel considers, unless
+// --filter state=... is given.
+var cancellableStates = []string{"unscheduled", "pending", "running"}
+
+// stateFilter returns the set of task states given by the --filter flags, or
+// the cancellable states if there are none. Filters have the form
+// key=value[,value...], and state is the only key supported so far.
+func stateFilter(flags *pflag.FlagSet) (map[string]bool, error) {
+	states := make(map[string]bool)
+	filters, _ := flags.GetStringArray("filter")
+	for _, filter := range filters {
+		parts := strings.SplitN(filter, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, fmt.Errorf("invalid filter %q: expected key=value[,value...]", filter)
+		}
+		if parts[0] != "state" {
+			return nil, fmt.Errorf("invalid filter %q: unknown key %q", filter, parts[0])
+		}
+		for _, state := range strings.Split(parts[1], ",") {
+			states[strings.TrimSpace(state)] = true
+		}
+	}
+	if len(states) == 0 {
+		for _, state := range cancellableStates {
+			states[state] = true
+		}
 	}
+	return states, nil
 }
 
 // filterTask takes a task and returns whether or not this task should be
-// set for cancellation, based on the specified filters through flags
-func filterTask(status tcqueue.TaskStatusStructure, flags *pflag.FlagSet) bool {
-	// first check - only delete tasks that are unscheduled, pending, running
-	if status.State != "unscheduled" && status.State != "pending" && status.State != "running" {
+// set for cancellation, based on the given states and the filters specified
+// through flags
+func filterTask(status tcqueue.TaskStatusStructure, states map[string]bool, flags *pflag.FlagSet) bool {
+	// first check - only delete tasks in one of the given states
+	if !states[status.State] {
 		return false
 	}
 
@@ -222,25 +266,33 @@ func runStatus(credentials *tcclient.Credentials, args []string, out io.Writer,
 	q := makeQueue(credentials)
 	groupID := args[0]
 
-	counter := make(map[string]int)
+	limit, _ := flags.GetInt("limit")
+	format, err := formatter.FromFlags(flags)
+	if err != nil {
+		return err
+	}
 
-	cont := ""
+	counter := make(map[string]int)
 
-	for {
-		// get next TaskGroup for groupID
-		ts, err := q.ListTaskGroup(groupID, cont, "")
-		if err != nil {
-			return fmt.Errorf("could not fetch tasks for group %s: %v", groupID, err)
-		}
+	err = forEachTask(q, groupID, limit, func(t tcqueue.TaskDefinitionAndStatus) error {
+		counter[t.Status.State]++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-		for _, t := range ts.Tasks {
-			counter[t.Status.State]++
+	if format != formatter.Text {
+		states := make([]string, 0, len(counter))
+		for state := range counter {
+			states = append(states, state)
 		}
-
-		// break if there are no more tasks for that groupID
-		if cont = ts.ContinuationToken; cont == "" {
-			break
+		sort.Strings(states)
+		rows := formatter.Rows{Header: []string{"STATE", "COUNT"}}
+		for _, state := range states {
+			rows.Rows = append(rows.Rows, []string{state, fmt.Sprint(counter[state])})
 		}
+		return formatter.Write(out, format, counter, rows)
 	}
 
 	for status, count := range counter {
@@ -257,33 +309,36 @@ func runList(credentials *tcclient.Credentials, args []string, out io.Writer, fl
 	q := makeQueue(credentials)
 	groupID := args[0]
 
-	cont := ""
-
-	templ := template.Must(template.New("listFormat").Parse(strings.Join([]string{listFormat, "\n"}, "")))
-
-	for {
-		// get next TaskGroup for groupID
-		ts, err := q.ListTaskGroup(groupID, cont, "")
-		if err != nil {
-			return fmt.Errorf("could not fetch tasks for group %s: %v", groupID, err)
-		}
+	limit, _ := flags.GetInt("limit")
+	format, err := formatter.FromFlags(flags)
+	if err != nil {
+		return err
+	}
 
-		for _, t := range ts.Tasks {
+	if format != formatter.Text {
+		tasks := make([]tcqueue.TaskDefinitionAndStatus, 0)
+		rows := formatter.Rows{Header: []string{"TASK ID", "NAME", "STATE"}}
+		err := forEachTask(q, groupID, limit, func(t tcqueue.TaskDefinitionAndStatus) error {
 			if filterListTask(t.Status, flags) {
-				err := templ.Execute(out, t)
-				if err != nil {
-					return err
-				}
+				tasks = append(tasks, t)
+				rows.Rows = append(rows.Rows, []string{t.Status.TaskID, t.Task.Metadata.Name, t.Status.State})
 			}
+			return nil
+		})
+		if err != nil {
+			return err
 		}
-
-		// break if there are no more tasks for that groupID
-		if cont = ts.ContinuationToken; cont == "" {
-			break
-		}
+		return formatter.Write(out, format, tasks, rows)
 	}
 
-	return nil
+	templ := template.Must(template.New("listFormat").Parse(strings.Join([]string{listFormat, "\n"}, "")))
+
+	return forEachTask(q, groupID, limit, func(t tcqueue.TaskDefinitionAndStatus) error {
+		if filterListTask(t.Status, flags) {
+			return templ.Execute(out, t)
+		}
+		return nil
+	})
 }
 
 // filterListTask takes a task and returns whether or not this task should be
@@ -2,35 +2,44 @@ package group
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"net/http"
-	"net/http/httptest"
 	"testing"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcmock"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
 	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
 )
 
 const fakeTaskID = "ANnmjMocTymeTID0tlNJAw"
 const fakeGroupID = "e4WPAAeSdaSdKxeWzDCBA"
 const badGroupID = "AAAAAAAAAAAAAAAAAAAAA"
+const pagedGroupID = "KuvZEDwIRlKb0wltLCtTKQ"
 
 type FakeServerSuite struct {
 	suite.Suite
-	testServer *httptest.Server
+	testServer *tcmock.Server
 }
 
 func (suite *FakeServerSuite) SetupSuite() {
 	// set up a fake server that knows how to answer the `task()` method
-	handler := http.NewServeMux()
-
-	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/cancel", cancelHandler)
-	handler.HandleFunc("/api/queue/v1/task-group/"+fakeGroupID+"/list", listTaskGroupHandler)
-
-	suite.testServer = httptest.NewServer(handler)
+	suite.testServer = tcmock.NewServer()
+
+	suite.testServer.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/cancel", cancelHandler)
+	suite.testServer.HandleFunc("/api/queue/v1/task-group/"+fakeGroupID+"/list", listTaskGroupHandler)
+	// one task per page, in two pages
+	suite.testServer.TaskGroup(pagedGroupID, 1,
+		pagedTask("tmsGg0FTRpKBwMBWrrFMZQ", "running"),
+		pagedTask("SKIuQXbBQNqMAJWsI3KrZg", "completed"),
+	)
+	suite.testServer.HandleFunc("/api/queue/v1/task-group/"+failedGroupID+"/list", listFailedTaskGroupHandler)
+	suite.testServer.HandleFunc("/api/queue/v1/task-group/"+reportGroupID+"/list", listReportTaskGroupHandler)
+	suite.testServer.HandleFunc("/api/queue/v1/task-group/"+graphGroupID+"/list", listGraphTaskGroupHandler)
 
 	// set the base URL the subcommands use to point to the fake server
 	config.SetRootURL(suite.testServer.URL)
@@ -105,6 +114,15 @@ func listTaskGroupHandler(w http.ResponseWriter, _ *http.Request) {
 	_, _ = io.WriteString(w, list)
 }
 
+// pagedTask returns a task of the paged group.
+func pagedTask(taskID, state string) tcqueue.TaskDefinitionAndStatus {
+	var t tcqueue.TaskDefinitionAndStatus
+	t.Status.TaskID = taskID
+	t.Status.State = state
+	t.Task.Metadata.Name = "paged-task"
+	return t
+}
+
 func setUpCommand() (*bytes.Buffer, *cobra.Command) {
 	buf := &bytes.Buffer{}
 	cmd := &cobra.Command{}
@@ -117,12 +135,44 @@ func (suite *FakeServerSuite) TestRunCancel() {
 	// set up to run a command and capture output
 	buf, cmd := setUpCommand()
 	cmd.Flags().Bool("force", true, "")
+	progress := &bytes.Buffer{}
+	defer func(orig io.Writer) { progressOut = orig }(progressOut)
+	progressOut = progress
 
 	// run the command
 	args := []string{fakeGroupID}
 	assert.NoError(suite.T(), runCancel(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
 
-	suite.Equal("cancelling task ANnmjMocTymeTID0tlNJAw\n", buf.String())
+	suite.Equal("cancelling task ANnmjMocTymeTID0tlNJAw\nCancelled 1 of 1 tasks (0 failed).\n", buf.String())
+	suite.Equal("cancelling tasks: started, 1 in total\n"+
+		"cancelling tasks: [1/1] ANnmjMocTymeTID0tlNJAw\n"+
+		"cancelling tasks: done, 1 of 1 succeeded (0 failed)\n", progress.String())
+}
+
+func (suite *FakeServerSuite) TestRunCancelStateFilter() {
+	// set up to run a command and capture output
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("force", true, "")
+	cmd.Flags().StringArray("filter", []string{"state=running,unscheduled"}, "")
+
+	// run the command
+	args := []string{fakeGroupID}
+	assert.NoError(suite.T(), runCancel(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("No suitable tasks found for cancellation.\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestRunCancelBadFilter() {
+	// set up to run a command and capture output
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("force", true, "")
+	cmd.Flags().StringArray("filter", []string{"color=pending"}, "")
+
+	// run the command
+	args := []string{fakeGroupID}
+	assert.Error(suite.T(), runCancel(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("", buf.String())
 }
 
 func (suite *FakeServerSuite) TestRunStatus() {
@@ -192,3 +242,68 @@ func (suite *FakeServerSuite) TestRunListBadGraphId() {
 
 	suite.Equal("", buf.String())
 }
+
+func (suite *FakeServerSuite) TestRunListPaged() {
+	// set up to run a command and capture output
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("all", true, "")
+
+	// run the command
+	args := []string{pagedGroupID}
+	assert.NoError(suite.T(), runList(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("tmsGg0FTRpKBwMBWrrFMZQ paged-task running\nSKIuQXbBQNqMAJWsI3KrZg paged-task completed\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestRunListLimit() {
+	// set up to run a command and capture output
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("all", true, "")
+	cmd.Flags().Int("limit", 1, "")
+
+	// run the command
+	args := []string{pagedGroupID}
+	assert.NoError(suite.T(), runList(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("tmsGg0FTRpKBwMBWrrFMZQ paged-task running\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestRunStatusLimit() {
+	// set up to run a command and capture output
+	buf, cmd := setUpCommand()
+	cmd.Flags().Int("limit", 1, "")
+
+	// run the command
+	args := []string{pagedGroupID}
+	assert.NoError(suite.T(), runStatus(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("running: 1\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestRunListJSON() {
+	// set up to run a command and capture output
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("all", true, "")
+	cmd.Flags().String("output", "json", "")
+
+	// run the command
+	args := []string{pagedGroupID}
+	assert.NoError(suite.T(), runList(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	var tasks []map[string]interface{}
+	assert.NoError(suite.T(), json.Unmarshal(buf.Bytes(), &tasks))
+	suite.Len(tasks, 2)
+	suite.Equal("SKIuQXbBQNqMAJWsI3KrZg", tasks[1]["status"].(map[string]interface{})["taskId"])
+}
+
+func (suite *FakeServerSuite) TestRunStatusTable() {
+	// set up to run a command and capture output
+	buf, cmd := setUpCommand()
+	cmd.Flags().String("output", "table", "")
+
+	// run the command
+	args := []string{pagedGroupID}
+	assert.NoError(suite.T(), runStatus(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("STATE      COUNT\ncompleted  1\nrunning    1\n", buf.String())
+}
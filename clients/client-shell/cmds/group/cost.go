@@ -0,0 +1,216 @@
+package group
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/formatter"
+)
+
+func init() {
+	costCmd := &cobra.Command{
+		Use:   "cost <taskGroupId>",
+		Short: "Estimate the compute cost of a task group",
+		Long: `Estimate the compute cost of a task group, by multiplying the time taken
+by the runs of its tasks by the hourly cost of their worker type.
+
+The costs are read from a YAML file mapping <provisionerId>/<workerType> to
+a cost per hour; <provisionerId>/* matches all worker types of a provisioner,
+and default all other worker types:
+
+  proj-releng/linux: 0.12
+  proj-releng/*: 0.40
+  default: 0.10
+
+Tasks of worker types without a cost are listed as such, and not included
+in the total.`,
+		RunE: executeHelperE(runCost),
+	}
+	costCmd.Flags().String("costs", "", "YAML file of the hourly costs of worker types (required).")
+	costCmd.Flags().Int("top", 10, "Number of most expensive tasks to list in text output (0 for all).")
+
+	Command.AddCommand(costCmd)
+}
+
+// costTable maps worker types, or patterns, to a cost per hour.
+type costTable map[string]float64
+
+// lookup returns the hourly cost of a worker type.
+func (c costTable) lookup(provisionerID, workerType string) (float64, bool) {
+	for _, key := range []string{provisionerID + "/" + workerType, provisionerID + "/*", "default"} {
+		if cost, ok := c[key]; ok {
+			return cost, true
+		}
+	}
+	return 0, false
+}
+
+// groupCost is the cost estimate of a group computed by runCost.
+type groupCost struct {
+	TaskGroupID  string       `json:"taskGroupId"`
+	Total        float64      `json:"total"`
+	RunTime      seconds      `json:"runTimeSeconds"`
+	ByWorkerType []costBucket `json:"byWorkerType"`
+	Tasks        []taskCost   `json:"tasks"`
+	// Uncosted are the worker types without a cost in the table.
+	Uncosted []string `json:"uncosted"`
+}
+
+// costBucket is the cost of the tasks of a worker type.
+type costBucket struct {
+	WorkerType string  `json:"workerType"`
+	Tasks      int     `json:"tasks"`
+	RunTime    seconds `json:"runTimeSeconds"`
+	Cost       float64 `json:"cost"`
+}
+
+// taskCost is the cost of the runs of a task.
+type taskCost struct {
+	TaskID     string  `json:"taskId"`
+	Name       string  `json:"name"`
+	WorkerType string  `json:"workerType"`
+	RunTime    seconds `json:"runTimeSeconds"`
+	Cost       float64 `json:"cost"`
+}
+
+// runCost fetches all tasks of a group and prints the estimate of their
+// cost, by worker type and by task, the most expensive first.
+func runCost(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	q := makeQueue(credentials)
+	groupID := args[0]
+
+	format, err := formatter.FromFlags(flags)
+	if err != nil {
+		return err
+	}
+	costs, err := readCosts(flags)
+	if err != nil {
+		return err
+	}
+
+	estimate := groupCost{TaskGroupID: groupID, ByWorkerType: []costBucket{}, Tasks: []taskCost{}, Uncosted: []string{}}
+	byWorkerType := make(map[string]*costBucket)
+	uncosted := make(map[string]bool)
+	err = forEachTask(q, groupID, 0, func(t tcqueue.TaskDefinitionAndStatus) error {
+		workerType := t.Status.ProvisionerID + "/" + t.Status.WorkerType
+		runTime := taskRunTime(t.Status)
+		estimate.RunTime += seconds(runTime)
+
+		hourly, ok := costs.lookup(t.Status.ProvisionerID, t.Status.WorkerType)
+		if !ok {
+			uncosted[workerType] = true
+			return nil
+		}
+		cost := hourly * runTime.Hours()
+		estimate.Total += cost
+		estimate.Tasks = append(estimate.Tasks, taskCost{
+			TaskID:     t.Status.TaskID,
+			Name:       t.Task.Metadata.Name,
+			WorkerType: workerType,
+			RunTime:    seconds(runTime),
+			Cost:       cost,
+		})
+		b, ok := byWorkerType[workerType]
+		if !ok {
+			b = &costBucket{WorkerType: workerType}
+			byWorkerType[workerType] = b
+		}
+		b.Tasks++
+		b.RunTime += seconds(runTime)
+		b.Cost += cost
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, b := range byWorkerType {
+		estimate.ByWorkerType = append(estimate.ByWorkerType, *b)
+	}
+	sort.Slice(estimate.ByWorkerType, func(i, j int) bool {
+		a, b := estimate.ByWorkerType[i], estimate.ByWorkerType[j]
+		if a.Cost != b.Cost {
+			return a.Cost > b.Cost
+		}
+		return a.WorkerType < b.WorkerType
+	})
+	sort.Slice(estimate.Tasks, func(i, j int) bool {
+		a, b := estimate.Tasks[i], estimate.Tasks[j]
+		if a.Cost != b.Cost {
+			return a.Cost > b.Cost
+		}
+		return a.TaskID < b.TaskID
+	})
+	for workerType := range uncosted {
+		estimate.Uncosted = append(estimate.Uncosted, workerType)
+	}
+	sort.Strings(estimate.Uncosted)
+
+	if format != formatter.Text {
+		rows := formatter.Rows{Header: []string{"TASK ID", "NAME", "WORKER TYPE", "RUN TIME", "COST"}}
+		for _, t := range estimate.Tasks {
+			rows.Rows = append(rows.Rows, []string{t.TaskID, t.Name, t.WorkerType, t.RunTime.String(), formatCost(t.Cost)})
+		}
+		return formatter.Write(out, format, estimate, rows)
+	}
+
+	fmt.Fprintf(out, "Estimated cost: %s (run time: %s)\n", formatCost(estimate.Total), estimate.RunTime)
+	if len(estimate.Uncosted) > 0 {
+		fmt.Fprintf(out, "Worker types without a cost: %s\n", strings.Join(estimate.Uncosted, ", "))
+	}
+	if len(estimate.Tasks) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(out, "\nBy worker type:")
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "  COST\tTASKS\tRUN TIME\tWORKER TYPE")
+	for _, b := range estimate.ByWorkerType {
+		fmt.Fprintf(w, "  %s\t%d\t%s\t%s\n", formatCost(b.Cost), b.Tasks, b.RunTime, b.WorkerType)
+	}
+	w.Flush()
+
+	tasks := estimate.Tasks
+	if top, _ := flags.GetInt("top"); top > 0 && top < len(tasks) {
+		tasks = tasks[:top]
+	}
+	fmt.Fprintf(out, "\nMost expensive tasks (%d of %d):\n", len(tasks), len(estimate.Tasks))
+	w = tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "  COST\tRUN TIME\tTASK ID\tNAME")
+	for _, t := range tasks {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", formatCost(t.Cost), t.RunTime, t.TaskID, t.Name)
+	}
+	return w.Flush()
+}
+
+// readCosts reads the cost table given by --costs.
+func readCosts(flags *pflag.FlagSet) (costTable, error) {
+	file, _ := flags.GetString("costs")
+	if file == "" {
+		return nil, errors.New("--costs is required")
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the costs: %w", err)
+	}
+	var costs costTable
+	if err := yaml.Unmarshal(data, &costs); err != nil {
+		return nil, fmt.Errorf("could not parse the costs in %s: %w", file, err)
+	}
+	return costs, nil
+}
+
+// formatCost formats a cost with the precision of cents.
+func formatCost(cost float64) string {
+	return fmt.Sprintf("%.2f", cost)
+}
@@ -0,0 +1,64 @@
+package group
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+)
+
+// writeCosts writes a cost table to a temporary file, and returns its path.
+func (suite *FakeServerSuite) writeCosts(costs string) string {
+	dir, err := ioutil.TempDir("", "taskcluster-costs")
+	suite.NoError(err)
+	file := filepath.Join(dir, "costs.yml")
+	suite.NoError(ioutil.WriteFile(file, []byte(costs), 0644))
+	return file
+}
+
+func (suite *FakeServerSuite) TestRunCost() {
+	costs := suite.writeCosts("proj/linux: 6\n")
+	defer os.RemoveAll(filepath.Dir(costs))
+
+	buf, cmd := setUpCommand()
+	cmd.Flags().String("costs", costs, "")
+	cmd.Flags().Int("top", 2, "")
+
+	// the report group has 13m of linux runs, and 2m of windows runs
+	assert.NoError(suite.T(), runCost(&tcclient.Credentials{}, []string{reportGroupID}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal(`Estimated cost: 1.15 (run time: 13m30s)
+Worker types without a cost: proj/windows
+
+By worker type:
+  COST  TASKS  RUN TIME  WORKER TYPE
+  1.15  3      11m30s    proj/linux
+
+Most expensive tasks (2 of 3):
+  COST  RUN TIME  TASK ID                 NAME
+  1.00  10m0s     Ku6Z4hmBRDWmZC7ywYwHNw  build
+  0.10  1m0s      DT1yP6ahQ4e3aRHSVs-HLw  test-1
+`, buf.String())
+}
+
+func (suite *FakeServerSuite) TestRunCostDefault() {
+	costs := suite.writeCosts("proj/linux: 6\nproj/*: 3\n")
+	defer os.RemoveAll(filepath.Dir(costs))
+
+	buf, cmd := setUpCommand()
+	cmd.Flags().String("costs", costs, "")
+	cmd.Flags().StringP("output", "o", "json", "")
+
+	assert.NoError(suite.T(), runCost(&tcclient.Credentials{}, []string{reportGroupID}, cmd.OutOrStdout(), cmd.Flags()))
+	suite.Contains(buf.String(), `"total": 1.25`)
+	suite.Contains(buf.String(), `"uncosted": []`)
+}
+
+func (suite *FakeServerSuite) TestRunCostMissingTable() {
+	_, cmd := setUpCommand()
+	cmd.Flags().String("costs", "", "")
+
+	assert.EqualError(suite.T(), runCost(&tcclient.Credentials{}, []string{reportGroupID}, cmd.OutOrStdout(), cmd.Flags()), "--costs is required")
+}
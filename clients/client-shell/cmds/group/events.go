@@ -0,0 +1,101 @@
+package group
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/streadway/amqp"
+	pulsego "github.com/taskcluster/pulse-go/pulse"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueueevents"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/formatter"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/pulse"
+)
+
+// consumePulse is replaced in tests.
+var consumePulse = pulse.Consume
+
+func init() {
+	eventsCmd := &cobra.Command{
+		Use:   "events <taskGroupId>",
+		Short: "Stream the state transitions of the tasks of a group, as published on Pulse.",
+		Long: `Print the state transitions of the tasks of a group as they happen, from
+the messages the queue publishes on Pulse, until the group is resolved: a
+line with the number of tasks by state, and then a line per transition. With
+-o json, the messages are printed as JSON objects, one per line, as by
+` + "`pulse listen`" + `.
+
+Pulse credentials are read from $PULSE_USERNAME and $PULSE_PASSWORD.`,
+		RunE: executeHelperE(runEvents),
+	}
+	eventsCmd.Flags().Duration("timeout", 0, "Give up if the group is not resolved after this long, e.g. 1h (0 to wait forever).")
+	formatter.RegisterFlag(eventsCmd.Flags())
+	pulse.AddConnectionFlags(eventsCmd.Flags())
+
+	Command.AddCommand(eventsCmd)
+}
+
+// runEvents prints the state transitions of the tasks of a group until the
+// group is resolved.
+func runEvents(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	q := makeQueue(credentials)
+	groupID := args[0]
+	format, err := formatter.FromFlags(flags)
+	if err != nil {
+		return err
+	}
+	if format != formatter.Text && format != formatter.JSON {
+		return fmt.Errorf("group events only supports the text and json output formats")
+	}
+	timeout, _ := flags.GetDuration("timeout")
+
+	resolved := tcqueueevents.TaskGroupResolved{RoutingKeyKind: "primary", TaskGroupID: groupID}
+	err = consumePulse(flags, pulse.Subscription{
+		Bindings: append(pulse.TaskBindings("", groupID), pulsego.Binding(resolved)),
+		Timeout:  timeout,
+		// the tasks may have changed before the queue was bound, or while
+		// reconnecting
+		Subscribed: func() (bool, error) {
+			counter := make(map[string]int)
+			err := forEachTask(q, groupID, 0, func(t tcqueue.TaskDefinitionAndStatus) error {
+				counter[t.Status.State]++
+				return nil
+			})
+			if err != nil {
+				return true, err
+			}
+			if format == formatter.Text {
+				fmt.Fprintf(out, "%s %s\n", time.Now().UTC().Format("15:04:05"), watchSummary(counter))
+			}
+			unresolved := counter["unscheduled"] + counter["pending"] + counter["running"]
+			return len(counter) > 0 && unresolved == 0, nil
+		},
+		Handle: func(d amqp.Delivery) (bool, error) {
+			if d.Exchange == resolved.ExchangeName() {
+				if format == formatter.Text {
+					fmt.Fprintf(out, "%s task group %s resolved\n", time.Now().UTC().Format("15:04:05"), groupID)
+					return true, nil
+				}
+				return true, pulse.WriteMessage(out, format, d)
+			}
+			if format == formatter.JSON {
+				return false, pulse.WriteMessage(out, format, d)
+			}
+			e, err := pulse.ParseTaskEvent(d)
+			if err != nil {
+				return true, err
+			}
+			fmt.Fprintln(out, e)
+			return false, nil
+		},
+	})
+	if errors.Is(err, pulse.ErrTimeout) {
+		return fmt.Errorf("the task group %s was not resolved within %s", groupID, timeout)
+	}
+	return err
+}
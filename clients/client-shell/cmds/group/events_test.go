@@ -0,0 +1,82 @@
+package group
+
+import (
+	"github.com/spf13/pflag"
+	"github.com/streadway/amqp"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/formatter"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/pulse"
+)
+
+// withFakePulse replaces consumePulse with a function which handles the given
+// messages once subscribed, and records the bindings it was given.
+func withFakePulse(exchanges *[]string, messages ...amqp.Delivery) func() {
+	orig := consumePulse
+	consumePulse = func(_ *pflag.FlagSet, s pulse.Subscription) error {
+		for _, b := range s.Bindings {
+			*exchanges = append(*exchanges, b.ExchangeName())
+		}
+		if done, err := s.Subscribed(); done || err != nil {
+			return err
+		}
+		for _, m := range messages {
+			if done, err := s.Handle(m); done || err != nil {
+				return err
+			}
+		}
+		return pulse.ErrTimeout
+	}
+	return func() { consumePulse = orig }
+}
+
+func groupEvents(groupID string) (string, error) {
+	buf, cmd := setUpCommand()
+	cmd.Flags().Duration("timeout", 0, "")
+	formatter.RegisterFlag(cmd.Flags())
+	err := runEvents(&tcclient.Credentials{}, []string{groupID}, cmd.OutOrStdout(), cmd.Flags())
+	return buf.String(), err
+}
+
+func (suite *FakeServerSuite) TestRunEvents() {
+	var exchanges []string
+	defer withFakePulse(&exchanges,
+		amqp.Delivery{
+			Exchange: "exchange/taskcluster-queue/v1/task-running",
+			Body:     []byte(`{"runId": 0, "workerGroup": "wg", "workerId": "wid", "status": {"taskId": "ANnmjMocTymeTID0tlNJAw", "state": "running"}}`),
+		},
+		amqp.Delivery{
+			Exchange: "exchange/taskcluster-queue/v1/task-group-resolved",
+			Body:     []byte(`{"taskGroupId": "` + fakeGroupID + `"}`),
+		},
+		amqp.Delivery{
+			Exchange: "exchange/taskcluster-queue/v1/task-pending",
+			Body:     []byte(`{"status": {"taskId": "unexpected"}}`),
+		},
+	)()
+
+	out, err := groupEvents(fakeGroupID)
+	suite.NoError(err)
+	suite.Regexp(`^\d\d:\d\d:\d\d pending: 1
+\d\d:\d\d:\d\d ANnmjMocTymeTID0tlNJAw running run 0 on wg/wid
+\d\d:\d\d:\d\d task group `+fakeGroupID+` resolved
+$`, out)
+	suite.Len(exchanges, 7)
+	suite.Equal("exchange/taskcluster-queue/v1/task-group-resolved", exchanges[6])
+}
+
+func (suite *FakeServerSuite) TestRunEventsResolved() {
+	var exchanges []string
+	defer withFakePulse(&exchanges)()
+
+	out, err := groupEvents(failedGroupID)
+	suite.NoError(err)
+	suite.Regexp(`^\d\d:\d\d:\d\d completed: 1, failed: 1\n$`, out)
+}
+
+func (suite *FakeServerSuite) TestRunEventsTimeout() {
+	var exchanges []string
+	defer withFakePulse(&exchanges)()
+
+	_, err := groupEvents(fakeGroupID)
+	suite.EqualError(err, "the task group "+fakeGroupID+" was not resolved within 0s")
+}
@@ -0,0 +1,262 @@
+package group
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/formatter"
+)
+
+func init() {
+	graphCmd := &cobra.Command{
+		Use:   "graph <taskGroupId>",
+		Short: "Show the dependencies of the tasks of a task group",
+		Long: `Show the dependencies of the tasks of a task group, and their states.
+
+By default, the graph is printed as a tree of the tasks which do not depend on
+other tasks of the group, with the tasks depending on them below them. Tasks
+with several dependencies are listed under each of them, and expanded only
+once. Unscheduled tasks list the dependencies they are waiting on, which
+explains why a graph is stalled.
+
+With --format dot or mermaid, the graph is output in the language of Graphviz
+or Mermaid, to be rendered, e.g. with:
+
+  taskcluster group graph --format dot <taskGroupId> | dot -Tsvg > graph.svg`,
+		RunE: executeHelperE(runGraph),
+	}
+	graphCmd.Flags().String("format", "tree", "Graph format, one of: tree, dot, mermaid.")
+
+	Command.AddCommand(graphCmd)
+}
+
+// graphNode is a task of a group, as output by runGraph.
+type graphNode struct {
+	TaskID       string   `json:"taskId"`
+	Name         string   `json:"name"`
+	State        string   `json:"state"`
+	Dependencies []string `json:"dependencies"`
+	// Dependents are the tasks of the group which depend on this one.
+	Dependents []string `json:"dependents"`
+}
+
+// taskGraph is the dependency graph of the tasks of a group.
+type taskGraph struct {
+	nodes map[string]*graphNode
+	// ids are the ids of the tasks, sorted by name.
+	ids []string
+}
+
+// runGraph fetches all tasks of a group and prints their dependency graph.
+func runGraph(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	q := makeQueue(credentials)
+	groupID := args[0]
+
+	format, err := formatter.FromFlags(flags)
+	if err != nil {
+		return err
+	}
+	graphFormat, _ := flags.GetString("format")
+	if graphFormat != "tree" && graphFormat != "dot" && graphFormat != "mermaid" {
+		return fmt.Errorf("unknown graph format %q", graphFormat)
+	}
+
+	g := &taskGraph{nodes: make(map[string]*graphNode)}
+	err = forEachTask(q, groupID, 0, func(t tcqueue.TaskDefinitionAndStatus) error {
+		g.nodes[t.Status.TaskID] = &graphNode{
+			TaskID:       t.Status.TaskID,
+			Name:         t.Task.Metadata.Name,
+			State:        t.Status.State,
+			Dependencies: t.Task.Dependencies,
+			Dependents:   []string{},
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	g.link()
+
+	if format != formatter.Text {
+		nodes := make([]*graphNode, len(g.ids))
+		rows := formatter.Rows{Header: []string{"TASK ID", "NAME", "STATE", "DEPENDENCIES"}}
+		for i, id := range g.ids {
+			n := g.nodes[id]
+			nodes[i] = n
+			rows.Rows = append(rows.Rows, []string{n.TaskID, n.Name, n.State, strings.Join(n.Dependencies, ",")})
+		}
+		return formatter.Write(out, format, nodes, rows)
+	}
+
+	switch graphFormat {
+	case "dot":
+		g.writeDot(out, groupID)
+	case "mermaid":
+		g.writeMermaid(out)
+	default:
+		g.writeTree(out)
+	}
+	return nil
+}
+
+// link sorts the tasks and records the dependents of each task.
+func (g *taskGraph) link() {
+	for id := range g.nodes {
+		g.ids = append(g.ids, id)
+	}
+	sort.Slice(g.ids, func(i, j int) bool {
+		a, b := g.nodes[g.ids[i]], g.nodes[g.ids[j]]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.TaskID < b.TaskID
+	})
+	for _, id := range g.ids {
+		for _, dep := range g.nodes[id].Dependencies {
+			if d, ok := g.nodes[dep]; ok {
+				d.Dependents = append(d.Dependents, id)
+			}
+		}
+	}
+}
+
+// label returns the description of a task, or of a task outside the group.
+func (g *taskGraph) label(id string) string {
+	if n, ok := g.nodes[id]; ok {
+		return fmt.Sprintf("%s (%s) %s", n.Name, n.TaskID, n.State)
+	}
+	return id + " (outside the group)"
+}
+
+// waitingOn returns the dependencies of a task which are not completed.
+func (g *taskGraph) waitingOn(n *graphNode) []string {
+	var waiting []string
+	for _, dep := range n.Dependencies {
+		if d, ok := g.nodes[dep]; !ok || d.State != "completed" {
+			waiting = append(waiting, dep)
+		}
+	}
+	return waiting
+}
+
+// writeTree prints the tasks without dependencies in the group, and the
+// tasks depending on them below them.
+func (g *taskGraph) writeTree(out io.Writer) {
+	shown := make(map[string]bool)
+	var write func(id, prefix, childPrefix string)
+	write = func(id, prefix, childPrefix string) {
+		n := g.nodes[id]
+		line := prefix + g.label(id)
+		if n.State == "unscheduled" {
+			if waiting := g.waitingOn(n); len(waiting) > 0 {
+				line += ", waiting on " + strings.Join(waiting, ", ")
+			}
+		}
+		if shown[id] {
+			fmt.Fprintln(out, line+" (see above)")
+			return
+		}
+		fmt.Fprintln(out, line)
+		shown[id] = true
+		for i, dependent := range n.Dependents {
+			if i == len(n.Dependents)-1 {
+				write(dependent, childPrefix+"└── ", childPrefix+"    ")
+			} else {
+				write(dependent, childPrefix+"├── ", childPrefix+"│   ")
+			}
+		}
+	}
+
+	for _, id := range g.ids {
+		if g.isRoot(g.nodes[id]) {
+			write(id, "", "")
+		}
+	}
+	// tasks in dependency cycles are not below any root
+	for _, id := range g.ids {
+		if !shown[id] {
+			write(id, "", "")
+		}
+	}
+}
+
+// isRoot returns whether a task depends on no other task of the group.
+func (g *taskGraph) isRoot(n *graphNode) bool {
+	for _, dep := range n.Dependencies {
+		if _, ok := g.nodes[dep]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// stateColors are the colors of the tasks in each state, in dot and
+// mermaid output.
+var stateColors = map[string]string{
+	"completed":   "palegreen",
+	"failed":      "salmon",
+	"exception":   "plum",
+	"running":     "lightblue",
+	"pending":     "lightyellow",
+	"unscheduled": "lightgrey",
+}
+
+func (g *taskGraph) writeDot(out io.Writer, groupID string) {
+	fmt.Fprintf(out, "digraph %q {\n", groupID)
+	fmt.Fprintln(out, "  node [shape=box, style=filled];")
+	for _, id := range g.ids {
+		n := g.nodes[id]
+		color, ok := stateColors[n.State]
+		if !ok {
+			color = "white"
+		}
+		fmt.Fprintf(out, "  %q [label=%q, fillcolor=%s];\n", id, n.Name+"\n"+n.State, color)
+	}
+	for _, id := range g.ids {
+		for _, dep := range g.nodes[id].Dependencies {
+			fmt.Fprintf(out, "  %q -> %q;\n", dep, id)
+		}
+	}
+	fmt.Fprintln(out, "}")
+}
+
+func (g *taskGraph) writeMermaid(out io.Writer) {
+	// slugids may start with a dash, and contain underscores, which are not
+	// valid in mermaid node ids
+	mermaidIDs := make(map[string]string)
+	mermaidID := func(id string) string {
+		if _, ok := mermaidIDs[id]; !ok {
+			mermaidIDs[id] = fmt.Sprintf("t%d", len(mermaidIDs))
+		}
+		return mermaidIDs[id]
+	}
+	escape := strings.NewReplacer(`"`, "#quot;").Replace
+
+	fmt.Fprintln(out, "graph TD")
+	for _, id := range g.ids {
+		n := g.nodes[id]
+		fmt.Fprintf(out, "  %s[\"%s<br>%s\"]:::%s\n", mermaidID(id), escape(n.Name), n.State, n.State)
+	}
+	for _, id := range g.ids {
+		for _, dep := range g.nodes[id].Dependencies {
+			if _, ok := g.nodes[dep]; !ok && mermaidIDs[dep] == "" {
+				fmt.Fprintf(out, "  %s[\"%s<br>outside the group\"]\n", mermaidID(dep), dep)
+			}
+			fmt.Fprintf(out, "  %s --> %s\n", mermaidID(dep), mermaidID(id))
+		}
+	}
+	states := make([]string, 0, len(stateColors))
+	for state := range stateColors {
+		states = append(states, state)
+	}
+	sort.Strings(states)
+	for _, state := range states {
+		fmt.Fprintf(out, "  classDef %s fill:%s\n", state, stateColors[state])
+	}
+}
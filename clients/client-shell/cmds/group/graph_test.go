@@ -0,0 +1,82 @@
+package group
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/stretchr/testify/assert"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+)
+
+const graphGroupID = "Vn6UzEsRRbWFqF4EzfYjNw"
+
+// returns a group where a task depends on a completed and a failed task
+func listGraphTaskGroupHandler(w http.ResponseWriter, _ *http.Request) {
+	list := `{
+			  "taskGroupId": "` + graphGroupID + `",
+			  "tasks": [
+			    {
+			      "status": {"taskId": "CkVCvOXYTnOTvBXDo8X1vw", "state": "unscheduled"},
+			      "task": {"metadata": {"name": "upload"}, "dependencies": ["Bq2KG9QWRzyG3iY3f6l2rA", "Ji5sNEAbRi2rbh-2BZRsoA"]}
+			    },
+			    {
+			      "status": {"taskId": "Bq2KG9QWRzyG3iY3f6l2rA", "state": "completed"},
+			      "task": {"metadata": {"name": "build"}, "dependencies": []}
+			    },
+			    {
+			      "status": {"taskId": "Ji5sNEAbRi2rbh-2BZRsoA", "state": "failed"},
+			      "task": {"metadata": {"name": "test"}, "dependencies": ["Bq2KG9QWRzyG3iY3f6l2rA"]}
+			    }
+			  ]
+			}`
+
+	_, _ = io.WriteString(w, list)
+}
+
+func (suite *FakeServerSuite) TestRunGraphTree() {
+	buf, cmd := setUpCommand()
+	cmd.Flags().String("format", "tree", "")
+
+	assert.NoError(suite.T(), runGraph(&tcclient.Credentials{}, []string{graphGroupID}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal(`build (Bq2KG9QWRzyG3iY3f6l2rA) completed
+├── test (Ji5sNEAbRi2rbh-2BZRsoA) failed
+│   └── upload (CkVCvOXYTnOTvBXDo8X1vw) unscheduled, waiting on Ji5sNEAbRi2rbh-2BZRsoA
+└── upload (CkVCvOXYTnOTvBXDo8X1vw) unscheduled, waiting on Ji5sNEAbRi2rbh-2BZRsoA (see above)
+`, buf.String())
+}
+
+func (suite *FakeServerSuite) TestRunGraphDot() {
+	buf, cmd := setUpCommand()
+	cmd.Flags().String("format", "dot", "")
+
+	assert.NoError(suite.T(), runGraph(&tcclient.Credentials{}, []string{graphGroupID}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal(`digraph "Vn6UzEsRRbWFqF4EzfYjNw" {
+  node [shape=box, style=filled];
+  "Bq2KG9QWRzyG3iY3f6l2rA" [label="build\ncompleted", fillcolor=palegreen];
+  "Ji5sNEAbRi2rbh-2BZRsoA" [label="test\nfailed", fillcolor=salmon];
+  "CkVCvOXYTnOTvBXDo8X1vw" [label="upload\nunscheduled", fillcolor=lightgrey];
+  "Bq2KG9QWRzyG3iY3f6l2rA" -> "Ji5sNEAbRi2rbh-2BZRsoA";
+  "Bq2KG9QWRzyG3iY3f6l2rA" -> "CkVCvOXYTnOTvBXDo8X1vw";
+  "Ji5sNEAbRi2rbh-2BZRsoA" -> "CkVCvOXYTnOTvBXDo8X1vw";
+}
+`, buf.String())
+}
+
+func (suite *FakeServerSuite) TestRunGraphMermaid() {
+	buf, cmd := setUpCommand()
+	cmd.Flags().String("format", "mermaid", "")
+
+	assert.NoError(suite.T(), runGraph(&tcclient.Credentials{}, []string{graphGroupID}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Contains(buf.String(), "graph TD\n  t0[\"build<br>completed\"]:::completed\n")
+	suite.Contains(buf.String(), "  t0 --> t1\n  t0 --> t2\n  t1 --> t2\n")
+}
+
+func (suite *FakeServerSuite) TestRunGraphBadFormat() {
+	_, cmd := setUpCommand()
+	cmd.Flags().String("format", "png", "")
+
+	assert.Error(suite.T(), runGraph(&tcclient.Credentials{}, []string{graphGroupID}, cmd.OutOrStdout(), cmd.Flags()))
+}
@@ -3,6 +3,7 @@ package group
 
 import (
 	"github.com/spf13/cobra"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/formatter"
 	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
 )
 
@@ -15,5 +16,6 @@ var (
 )
 
 func init() {
+	formatter.RegisterFlag(Command.PersistentFlags())
 	root.Command.AddCommand(Command)
 }
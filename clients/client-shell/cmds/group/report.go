@@ -0,0 +1,184 @@
+package group
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/formatter"
+)
+
+func init() {
+	reportCmd := &cobra.Command{
+		Use:   "report <taskGroupId>",
+		Short: "Summarize the failures of a task group",
+		Long: `Summarize the failures of a task group.
+
+Fetches all tasks of the group, and buckets the failed tasks and tasks with
+an exception by worker type, by task name and by the reason their last run
+was resolved, along with the time their runs took.`,
+		RunE: executeHelperE(runReport),
+	}
+
+	Command.AddCommand(reportCmd)
+}
+
+// groupReport is the summary of a task group computed by runReport.
+type groupReport struct {
+	TaskGroupID string         `json:"taskGroupId"`
+	Tasks       int            `json:"tasks"`
+	States      map[string]int `json:"states"`
+	// RunTime is the total time taken by all runs of all tasks.
+	RunTime      seconds         `json:"runTimeSeconds"`
+	Failures     int             `json:"failures"`
+	ByWorkerType []failureBucket `json:"byWorkerType"`
+	ByName       []failureBucket `json:"byName"`
+	ByReason     []failureBucket `json:"byReason"`
+}
+
+// failureBucket holds the failed tasks that have something in common.
+type failureBucket struct {
+	Key     string   `json:"key"`
+	TaskIDs []string `json:"taskIds"`
+	// RunTime is the total time taken by all runs of the tasks.
+	RunTime seconds `json:"runTimeSeconds"`
+}
+
+// seconds is a duration that is marshaled to json as a number of seconds.
+type seconds time.Duration
+
+func (s seconds) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatFloat(time.Duration(s).Seconds(), 'f', -1, 64)), nil
+}
+
+func (s seconds) String() string {
+	return time.Duration(s).String()
+}
+
+// runReport fetches all tasks of a group and prints a summary of its
+// failures.
+func runReport(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	q := makeQueue(credentials)
+	groupID := args[0]
+
+	format, err := formatter.FromFlags(flags)
+	if err != nil {
+		return err
+	}
+
+	report := groupReport{TaskGroupID: groupID, States: make(map[string]int)}
+	byWorkerType := make(map[string]*failureBucket)
+	byName := make(map[string]*failureBucket)
+	byReason := make(map[string]*failureBucket)
+
+	err = forEachTask(q, groupID, 0, func(t tcqueue.TaskDefinitionAndStatus) error {
+		report.Tasks++
+		report.States[t.Status.State]++
+		runTime := taskRunTime(t.Status)
+		report.RunTime += seconds(runTime)
+
+		if t.Status.State != "failed" && t.Status.State != "exception" {
+			return nil
+		}
+		report.Failures++
+		reason := t.Status.State
+		if len(t.Status.Runs) > 0 && t.Status.Runs[len(t.Status.Runs)-1].ReasonResolved != "" {
+			reason = t.Status.Runs[len(t.Status.Runs)-1].ReasonResolved
+		}
+		addToBucket(byWorkerType, t.Status.ProvisionerID+"/"+t.Status.WorkerType, t.Status.TaskID, runTime)
+		addToBucket(byName, t.Task.Metadata.Name, t.Status.TaskID, runTime)
+		addToBucket(byReason, reason, t.Status.TaskID, runTime)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	report.ByWorkerType = sortedBuckets(byWorkerType)
+	report.ByName = sortedBuckets(byName)
+	report.ByReason = sortedBuckets(byReason)
+
+	if format != formatter.Text {
+		rows := formatter.Rows{Header: []string{"BY", "KEY", "FAILURES", "RUN TIME"}}
+		for _, by := range []struct {
+			name    string
+			buckets []failureBucket
+		}{
+			{"worker type", report.ByWorkerType},
+			{"name", report.ByName},
+			{"reason", report.ByReason},
+		} {
+			for _, b := range by.buckets {
+				rows.Rows = append(rows.Rows, []string{by.name, b.Key, fmt.Sprint(len(b.TaskIDs)), b.RunTime.String()})
+			}
+		}
+		return formatter.Write(out, format, report, rows)
+	}
+
+	fmt.Fprintf(out, "Tasks: %d (%s)\n", report.Tasks, watchSummary(report.States))
+	fmt.Fprintf(out, "Total run time: %s\n", report.RunTime)
+	fmt.Fprintf(out, "Failures: %d\n", report.Failures)
+	if report.Failures == 0 {
+		return nil
+	}
+	writeBuckets(out, "worker type", report.ByWorkerType)
+	writeBuckets(out, "name", report.ByName)
+	writeBuckets(out, "reason", report.ByReason)
+	return nil
+}
+
+// taskRunTime returns the total time taken by the runs of a task that have
+// started and been resolved.
+func taskRunTime(status tcqueue.TaskStatusStructure) time.Duration {
+	var total time.Duration
+	for _, run := range status.Runs {
+		started, resolved := time.Time(run.Started), time.Time(run.Resolved)
+		if !started.IsZero() && !resolved.IsZero() {
+			total += resolved.Sub(started)
+		}
+	}
+	return total
+}
+
+func addToBucket(buckets map[string]*failureBucket, key, taskID string, runTime time.Duration) {
+	b, ok := buckets[key]
+	if !ok {
+		b = &failureBucket{Key: key}
+		buckets[key] = b
+	}
+	b.TaskIDs = append(b.TaskIDs, taskID)
+	b.RunTime += seconds(runTime)
+}
+
+// sortedBuckets returns the buckets with the most failures first.
+func sortedBuckets(buckets map[string]*failureBucket) []failureBucket {
+	sorted := make([]failureBucket, 0, len(buckets))
+	for _, b := range buckets {
+		sorted = append(sorted, *b)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i].TaskIDs) != len(sorted[j].TaskIDs) {
+			return len(sorted[i].TaskIDs) > len(sorted[j].TaskIDs)
+		}
+		return sorted[i].Key < sorted[j].Key
+	})
+	return sorted
+}
+
+func writeBuckets(out io.Writer, by string, buckets []failureBucket) {
+	fmt.Fprintf(out, "\nFailures by %s:\n", by)
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "  FAILURES\tRUN TIME\t%s\n", strings.ToUpper(by))
+	for _, b := range buckets {
+		fmt.Fprintf(w, "  %d\t%s\t%s\n", len(b.TaskIDs), b.RunTime, b.Key)
+	}
+	w.Flush()
+}
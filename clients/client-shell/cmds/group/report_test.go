@@ -0,0 +1,90 @@
+package group
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/stretchr/testify/assert"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+)
+
+const reportGroupID = "Pj1xmmtYRmqfRw9EYvrrvg"
+
+// returns a group with a completed task, two failed tasks and an exception
+func listReportTaskGroupHandler(w http.ResponseWriter, _ *http.Request) {
+	list := `{
+			  "taskGroupId": "` + reportGroupID + `",
+			  "tasks": [
+			    {
+			      "status": {"taskId": "Ku6Z4hmBRDWmZC7ywYwHNw", "provisionerId": "proj", "workerType": "linux", "state": "completed",
+			        "runs": [{"runId": 0, "state": "completed", "reasonResolved": "completed", "started": "2020-03-29T15:00:00.000Z", "resolved": "2020-03-29T15:10:00.000Z"}]},
+			      "task": {"metadata": {"name": "build"}}
+			    },
+			    {
+			      "status": {"taskId": "DT1yP6ahQ4e3aRHSVs-HLw", "provisionerId": "proj", "workerType": "linux", "state": "failed",
+			        "runs": [{"runId": 0, "state": "failed", "reasonResolved": "failed", "started": "2020-03-29T15:00:00.000Z", "resolved": "2020-03-29T15:01:00.000Z"}]},
+			      "task": {"metadata": {"name": "test-1"}}
+			    },
+			    {
+			      "status": {"taskId": "ZtpTjXxNSJ-cy5rNNa_jCw", "provisionerId": "proj", "workerType": "windows", "state": "failed",
+			        "runs": [{"runId": 0, "state": "failed", "reasonResolved": "failed", "started": "2020-03-29T15:00:00.000Z", "resolved": "2020-03-29T15:02:00.000Z"}]},
+			      "task": {"metadata": {"name": "test-1"}}
+			    },
+			    {
+			      "status": {"taskId": "Xx0b2VCDQBGwqaVIEJRVmA", "provisionerId": "proj", "workerType": "linux", "state": "exception",
+			        "runs": [{"runId": 0, "state": "exception", "reasonResolved": "claim-expired", "started": "2020-03-29T15:00:00.000Z", "resolved": "2020-03-29T15:00:30.000Z"}]},
+			      "task": {"metadata": {"name": "test-2"}}
+			    }
+			  ]
+			}`
+
+	_, _ = io.WriteString(w, list)
+}
+
+func (suite *FakeServerSuite) TestRunReport() {
+	// set up to run a command and capture output
+	buf, cmd := setUpCommand()
+
+	// run the command
+	args := []string{reportGroupID}
+	assert.NoError(suite.T(), runReport(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal(`Tasks: 4 (completed: 1, exception: 1, failed: 2)
+Total run time: 13m30s
+Failures: 3
+
+Failures by worker type:
+  FAILURES  RUN TIME  WORKER TYPE
+  2         1m30s     proj/linux
+  1         2m0s      proj/windows
+
+Failures by name:
+  FAILURES  RUN TIME  NAME
+  2         3m0s      test-1
+  1         30s       test-2
+
+Failures by reason:
+  FAILURES  RUN TIME  REASON
+  2         3m0s      failed
+  1         30s       claim-expired
+`, buf.String())
+}
+
+func (suite *FakeServerSuite) TestRunReportJSON() {
+	// set up to run a command and capture output
+	buf, cmd := setUpCommand()
+	cmd.Flags().String("output", "json", "")
+
+	// run the command
+	args := []string{reportGroupID}
+	assert.NoError(suite.T(), runReport(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	var report map[string]interface{}
+	assert.NoError(suite.T(), json.Unmarshal(buf.Bytes(), &report))
+	suite.Equal(float64(810), report["runTimeSeconds"])
+	suite.Equal(float64(3), report["failures"])
+	byReason := report["byReason"].([]interface{})
+	suite.Equal("failed", byReason[0].(map[string]interface{})["key"])
+	suite.Equal([]interface{}{"DT1yP6ahQ4e3aRHSVs-HLw", "ZtpTjXxNSJ-cy5rNNa_jCw"}, byReason[0].(map[string]interface{})["taskIds"])
+}
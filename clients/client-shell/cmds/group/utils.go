@@ -1,12 +1,15 @@
 package group
 
 import (
+	"context"
 	"fmt"
 	"io"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/paginate"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
 	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
 )
 
@@ -26,3 +29,17 @@ func executeHelperE(f Executor) func(*cobra.Command, []string) error {
 		return f(creds, args, cmd.OutOrStdout(), cmd.Flags())
 	}
 }
+
+// forEachTask calls f with each task of the given group, as
+// paginate.ForEachTaskInGroup does, or with the first limit tasks, if limit
+// is positive.
+func forEachTask(q *tcqueue.Queue, groupID string, limit int, f func(tcqueue.TaskDefinitionAndStatus) error) error {
+	count := 0
+	return paginate.ForEachTaskInGroup(context.Background(), q, groupID, func(t tcqueue.TaskDefinitionAndStatus) error {
+		if limit > 0 && count >= limit {
+			return paginate.Stop
+		}
+		count++
+		return f(t)
+	})
+}
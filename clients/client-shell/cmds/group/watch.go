@@ -0,0 +1,87 @@
+package group
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+)
+
+func init() {
+	watchCmd := &cobra.Command{
+		Use:   "watch <taskGroupId>",
+		Short: "Show the progress of a task group as it changes",
+		RunE:  executeHelperE(runWatch),
+	}
+	watchCmd.Flags().BoolP("until-complete", "u", false, "Exit once no task is unscheduled, pending or running, with an error if any task failed.")
+	watchCmd.Flags().DurationP("interval", "i", 10*time.Second, "Time to wait between polls of the task group.")
+
+	Command.AddCommand(watchCmd)
+}
+
+// runWatch polls the given group and prints a summary of the states of its
+// tasks whenever it changes.
+//
+// With --until-complete, it returns once all tasks are resolved, with an
+// error if any of them failed or had an exception, so that the exit code of
+// the command reflects the outcome of the group.
+func runWatch(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	q := makeQueue(credentials)
+	groupID := args[0]
+
+	untilComplete, _ := flags.GetBool("until-complete")
+	interval, err := flags.GetDuration("interval")
+	if err != nil {
+		interval = 10 * time.Second
+	}
+
+	last := ""
+	for {
+		counter := make(map[string]int)
+		err := forEachTask(q, groupID, 0, func(t tcqueue.TaskDefinitionAndStatus) error {
+			counter[t.Status.State]++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		summary := watchSummary(counter)
+		if summary != last {
+			fmt.Fprintf(out, "%s %s\n", time.Now().UTC().Format("15:04:05"), summary)
+			last = summary
+		}
+
+		if untilComplete && counter["unscheduled"]+counter["pending"]+counter["running"] == 0 {
+			if failed := counter["failed"] + counter["exception"]; failed > 0 {
+				return fmt.Errorf("%d tasks of group %s failed", failed, groupID)
+			}
+			return nil
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// watchSummary formats the task counts by state, sorted by state.
+func watchSummary(counter map[string]int) string {
+	if len(counter) == 0 {
+		return "no tasks"
+	}
+	states := make([]string, 0, len(counter))
+	for state := range counter {
+		states = append(states, state)
+	}
+	sort.Strings(states)
+	parts := make([]string, len(states))
+	for i, state := range states {
+		parts[i] = fmt.Sprintf("%s: %d", state, counter[state])
+	}
+	return strings.Join(parts, ", ")
+}
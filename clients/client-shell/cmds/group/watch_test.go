@@ -0,0 +1,45 @@
+package group
+
+import (
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+)
+
+const failedGroupID = "Z1L5ESg8TUKa2PlBLF2Vdw"
+
+// returns a group with one completed and one failed task
+func listFailedTaskGroupHandler(w http.ResponseWriter, _ *http.Request) {
+	list := `{
+			  "taskGroupId": "` + failedGroupID + `",
+			  "tasks": [
+			    {"status": {"taskId": "OhDwZ5rbQWCaWaFHKAGSiw", "state": "completed", "runs": []}, "task": {}},
+			    {"status": {"taskId": "Gsu8ea5ZRlqPJnxvG1cABA", "state": "failed", "runs": []}, "task": {}}
+			  ]
+			}`
+
+	_, _ = io.WriteString(w, list)
+}
+
+func (suite *FakeServerSuite) TestRunWatchUntilComplete() {
+	// set up to run a command and capture output
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("until-complete", true, "")
+	cmd.Flags().Duration("interval", time.Millisecond, "")
+
+	// run the command
+	args := []string{failedGroupID}
+	err := runWatch(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags())
+	assert.EqualError(suite.T(), err, "1 tasks of group "+failedGroupID+" failed")
+
+	suite.Regexp(regexp.MustCompile(`^\d\d:\d\d:\d\d completed: 1, failed: 1\n$`), buf.String())
+}
+
+func (suite *FakeServerSuite) TestWatchSummary() {
+	suite.Equal("no tasks", watchSummary(map[string]int{}))
+	suite.Equal("pending: 2, running: 1", watchSummary(map[string]int{"running": 1, "pending": 2}))
+}
@@ -0,0 +1,272 @@
+// Package hooks implements the hooks subcommands.
+package hooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tchooks"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+var (
+	// Command is the root of the hooks subtree.
+	Command = &cobra.Command{
+		Use:   "hooks",
+		Short: "Provides hook-related actions and commands.",
+	}
+
+	// stdin is where definitions and payloads given as - are read from.
+	stdin io.Reader = os.Stdin
+
+	// now returns the current time, from which schedules are previewed.
+	now = time.Now
+)
+
+func init() {
+	listCmd := &cobra.Command{
+		Use:   "list [<hookGroupId>]",
+		Short: "List the hook groups, or the hooks of a hook group.",
+		RunE:  root.ExecuteHelperE(runList, 0, 1),
+	}
+
+	getCmd := &cobra.Command{
+		Use:   "get <hookGroupId>/<hookId>",
+		Short: "Get the definition of a hook.",
+		RunE:  root.ExecuteHelperE(runGet, 1, 1),
+	}
+
+	createCmd := &cobra.Command{
+		Use:   "create <hookGroupId>/<hookId> -f <file>",
+		Short: "Create a hook from a YAML or JSON definition.",
+		RunE:  root.ExecuteHelperE(runCreate, 1, 1),
+	}
+	createCmd.Flags().StringP("file", "f", "", "File holding the hook definition, or - to read it from stdin.")
+
+	updateCmd := &cobra.Command{
+		Use:   "update <hookGroupId>/<hookId> -f <file>",
+		Short: "Update a hook from a YAML or JSON definition.",
+		RunE:  root.ExecuteHelperE(runUpdate, 1, 1),
+	}
+	updateCmd.Flags().StringP("file", "f", "", "File holding the hook definition, or - to read it from stdin.")
+
+	triggerCmd := &cobra.Command{
+		Use:   "trigger <hookGroupId>/<hookId>",
+		Short: "Trigger a hook, and print the taskId of the task it created.",
+		RunE:  root.ExecuteHelperE(runTrigger, 1, 1),
+	}
+	triggerCmd.Flags().StringP("payload", "p", "", "File holding the JSON payload of the trigger, or - to read it from stdin (defaults to {}).")
+
+	scheduleCmd := &cobra.Command{
+		Use:   "schedule <hookGroupId>/<hookId>",
+		Short: "Preview the next times a hook fires on its schedule.",
+		Long: `Preview the next times (in UTC) a hook fires, according to the cron
+expressions of its schedule, whose fields are: second, minute, hour, day of
+month, month and day of week.`,
+		RunE: root.ExecuteHelperE(runSchedule, 1, 1),
+	}
+	scheduleCmd.Flags().IntP("count", "n", 5, "Number of fire times to show.")
+
+	Command.AddCommand(listCmd, getCmd, createCmd, updateCmd, triggerCmd, scheduleCmd)
+	root.Command.AddCommand(Command)
+}
+
+func makeHooks(credentials *tcclient.Credentials) *tchooks.Hooks {
+	return tchooks.New(credentials, config.RootURL())
+}
+
+// splitHook splits <hookGroupId>/<hookId>; hookIds may contain slashes, but
+// hookGroupIds may not.
+func splitHook(arg string) (hookGroupID, hookID string, err error) {
+	parts := strings.SplitN(arg, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid hook %q, expected <hookGroupId>/<hookId>", arg)
+	}
+	return parts[0], parts[1], nil
+}
+
+// readInput reads the file given by the named flag, or stdin if it is -.
+func readInput(flags *pflag.FlagSet, flag string) ([]byte, error) {
+	file, _ := flags.GetString(flag)
+	switch file {
+	case "":
+		return nil, nil
+	case "-":
+		return ioutil.ReadAll(stdin)
+	default:
+		return ioutil.ReadFile(file)
+	}
+}
+
+// runList lists the hook groups, or the hooks of the given group.
+func runList(credentials *tcclient.Credentials, args []string, out io.Writer, _ *pflag.FlagSet) error {
+	h := makeHooks(credentials)
+	if len(args) == 0 {
+		groups, err := h.ListHookGroups()
+		if err != nil {
+			return fmt.Errorf("could not list hook groups: %w", err)
+		}
+		for _, group := range groups.Groups {
+			fmt.Fprintln(out, group)
+		}
+		return nil
+	}
+
+	hooks, err := h.ListHooks(args[0])
+	if err != nil {
+		return fmt.Errorf("could not list the hooks of %s: %w", args[0], err)
+	}
+	for _, hook := range hooks.Hooks {
+		fmt.Fprintf(out, "%s/%s %s\n", hook.HookGroupID, hook.HookID, hook.Metadata.Name)
+	}
+	return nil
+}
+
+// runGet prints the definition of a hook as JSON.
+func runGet(credentials *tcclient.Credentials, args []string, out io.Writer, _ *pflag.FlagSet) error {
+	hookGroupID, hookID, err := splitHook(args[0])
+	if err != nil {
+		return err
+	}
+
+	hook, err := makeHooks(credentials).Hook(hookGroupID, hookID)
+	if err != nil {
+		return fmt.Errorf("could not get hook %s: %w", args[0], err)
+	}
+
+	def, err := json.MarshalIndent(hook, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal hook %s into json: %w", args[0], err)
+	}
+	fmt.Fprintln(out, string(def))
+	return nil
+}
+
+// readDefinition reads the hook definition given with --file, as YAML or
+// JSON.
+func readDefinition(flags *pflag.FlagSet) (*tchooks.HookCreationRequest, error) {
+	data, err := readInput(flags, "file")
+	if err != nil {
+		return nil, fmt.Errorf("could not read hook definition: %w", err)
+	}
+	if data == nil {
+		return nil, errors.New("a hook definition must be given with --file")
+	}
+	def := &tchooks.HookCreationRequest{}
+	if err := yaml.Unmarshal(data, def); err != nil {
+		return nil, fmt.Errorf("could not parse hook definition: %w", err)
+	}
+	return def, nil
+}
+
+// runCreate creates a hook from the definition given with --file.
+func runCreate(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	hookGroupID, hookID, err := splitHook(args[0])
+	if err != nil {
+		return err
+	}
+	def, err := readDefinition(flags)
+	if err != nil {
+		return err
+	}
+
+	if _, err := makeHooks(credentials).CreateHook(hookGroupID, hookID, def); err != nil {
+		return fmt.Errorf("could not create hook %s: %w", args[0], err)
+	}
+	fmt.Fprintf(out, "Hook %s created\n", args[0])
+	return nil
+}
+
+// runUpdate updates a hook with the definition given with --file.
+func runUpdate(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	hookGroupID, hookID, err := splitHook(args[0])
+	if err != nil {
+		return err
+	}
+	def, err := readDefinition(flags)
+	if err != nil {
+		return err
+	}
+
+	if _, err := makeHooks(credentials).UpdateHook(hookGroupID, hookID, def); err != nil {
+		return fmt.Errorf("could not update hook %s: %w", args[0], err)
+	}
+	fmt.Fprintf(out, "Hook %s updated\n", args[0])
+	return nil
+}
+
+// runTrigger triggers a hook with the payload given with --payload, and
+// prints the taskId of the resulting task.
+func runTrigger(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	hookGroupID, hookID, err := splitHook(args[0])
+	if err != nil {
+		return err
+	}
+	payload, err := readInput(flags, "payload")
+	if err != nil {
+		return fmt.Errorf("could not read payload: %w", err)
+	}
+	if payload == nil {
+		payload = []byte("{}")
+	}
+	if !json.Valid(payload) {
+		return errors.New("the payload must be JSON")
+	}
+
+	req := tchooks.TriggerHookRequest(payload)
+	resp, err := makeHooks(credentials).TriggerHook(hookGroupID, hookID, &req)
+	if err != nil {
+		return fmt.Errorf("could not trigger hook %s: %w", args[0], err)
+	}
+
+	// hooks whose task template renders to nothing create no task
+	var task struct {
+		Status struct {
+			TaskID string `json:"taskId"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(*resp, &task); err != nil || task.Status.TaskID == "" {
+		fmt.Fprintf(out, "Hook %s triggered, but created no task\n", args[0])
+		return nil
+	}
+	fmt.Fprintln(out, task.Status.TaskID)
+	return nil
+}
+
+// runSchedule prints the next times a hook fires on its schedule.
+func runSchedule(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	hookGroupID, hookID, err := splitHook(args[0])
+	if err != nil {
+		return err
+	}
+	count, _ := flags.GetInt("count")
+
+	hook, err := makeHooks(credentials).Hook(hookGroupID, hookID)
+	if err != nil {
+		return fmt.Errorf("could not get hook %s: %w", args[0], err)
+	}
+	if len(hook.Schedule) == 0 {
+		fmt.Fprintf(out, "Hook %s has no schedule\n", args[0])
+		return nil
+	}
+
+	fires, err := nextFires(hook.Schedule, now().UTC(), count)
+	if err != nil {
+		return err
+	}
+	for _, fire := range fires {
+		fmt.Fprintln(out, fire.Format(time.RFC3339))
+	}
+	return nil
+}
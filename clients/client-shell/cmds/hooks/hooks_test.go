@@ -0,0 +1,120 @@
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/suite"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+const hookDefinition = `{
+  "hookGroupId": "project-app",
+  "hookId": "nightly/build",
+  "metadata": {"name": "Nightly build", "description": "", "owner": "a@example.com"},
+  "schedule": ["0 0 3 * * *"],
+  "task": {},
+  "triggerSchema": {}
+}`
+
+type FakeServerSuite struct {
+	suite.Suite
+	testServer *httptest.Server
+	// received holds the body of the last request which had one
+	received map[string]interface{}
+}
+
+func (suite *FakeServerSuite) SetupSuite() {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/hooks/v1/hooks", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"groups": ["project-app", "project-lib"]}`)
+	})
+	handler.HandleFunc("/api/hooks/v1/hooks/project-app", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"hooks": [`+hookDefinition+`]}`)
+	})
+	handler.HandleFunc("/api/hooks/v1/hooks/project-app/", func(w http.ResponseWriter, r *http.Request) {
+		suite.received = nil
+		_ = json.NewDecoder(r.Body).Decode(&suite.received)
+		if strings.HasSuffix(r.URL.Path, "/trigger") {
+			_, _ = io.WriteString(w, `{"status": {"taskId": "fN1SbArXTPSVFNUvaOlinQ"}}`)
+			return
+		}
+		_, _ = io.WriteString(w, hookDefinition)
+	})
+
+	suite.testServer = httptest.NewServer(handler)
+	config.SetRootURL(suite.testServer.URL)
+}
+
+func (suite *FakeServerSuite) TearDownSuite() {
+	suite.testServer.Close()
+	config.SetRootURL("")
+}
+
+func TestFakeServerSuite(t *testing.T) {
+	suite.Run(t, new(FakeServerSuite))
+}
+
+func inputFlags(flag, value string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("hooks", pflag.ContinueOnError)
+	flags.String(flag, value, "")
+	return flags
+}
+
+func (suite *FakeServerSuite) TestList() {
+	buf := &bytes.Buffer{}
+	suite.NoError(runList(nil, nil, buf, nil))
+	suite.Equal("project-app\nproject-lib\n", buf.String())
+
+	buf.Reset()
+	suite.NoError(runList(nil, []string{"project-app"}, buf, nil))
+	suite.Equal("project-app/nightly/build Nightly build\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestGet() {
+	buf := &bytes.Buffer{}
+	suite.NoError(runGet(nil, []string{"project-app/nightly/build"}, buf, nil))
+	suite.JSONEq(hookDefinition, buf.String())
+
+	suite.Error(runGet(nil, []string{"project-app"}, buf, nil), "get should error without a hookId")
+}
+
+func (suite *FakeServerSuite) TestCreate() {
+	defer func(orig io.Reader) { stdin = orig }(stdin)
+	stdin = strings.NewReader(`{"metadata": {"name": "Nightly build"}, "schedule": ["0 0 3 * * *"], "task": {"provisionerId": "proj"}}`)
+
+	buf := &bytes.Buffer{}
+	suite.NoError(runCreate(nil, []string{"project-app/nightly/build"}, buf, inputFlags("file", "-")))
+	suite.Equal("Hook project-app/nightly/build created\n", buf.String())
+	suite.Equal(map[string]interface{}{"provisionerId": "proj"}, suite.received["task"])
+
+	suite.Error(runUpdate(nil, []string{"project-app/nightly/build"}, buf, inputFlags("file", "")), "update should error without --file")
+}
+
+func (suite *FakeServerSuite) TestTrigger() {
+	defer func(orig io.Reader) { stdin = orig }(stdin)
+	stdin = strings.NewReader(`{"revision": "abc"}`)
+
+	buf := &bytes.Buffer{}
+	suite.NoError(runTrigger(nil, []string{"project-app/nightly/build"}, buf, inputFlags("payload", "-")))
+	suite.Equal("fN1SbArXTPSVFNUvaOlinQ\n", buf.String())
+	suite.Equal(map[string]interface{}{"revision": "abc"}, suite.received)
+}
+
+func (suite *FakeServerSuite) TestSchedule() {
+	defer func(orig func() time.Time) { now = orig }(now)
+	now = func() time.Time { return time.Date(2020, 1, 15, 10, 30, 0, 0, time.UTC) }
+
+	buf := &bytes.Buffer{}
+	flags := pflag.NewFlagSet("schedule", pflag.ContinueOnError)
+	flags.Int("count", 2, "")
+	suite.NoError(runSchedule(nil, []string{"project-app/nightly/build"}, buf, flags))
+	suite.Equal("2020-01-16T03:00:00Z\n2020-01-17T03:00:00Z\n", buf.String())
+}
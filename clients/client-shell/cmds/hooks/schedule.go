@@ -0,0 +1,186 @@
+package hooks
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronField describes one field of a cron expression: its range of values,
+// and the names which may be used instead of numbers.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+// cronFields are the fields of the cron expressions of hook schedules, which
+// start with the seconds.
+var cronFields = []cronField{
+	{name: "second", min: 0, max: 59},
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// schedule is a parsed cron expression, with the set of values each field
+// matches.
+type schedule struct {
+	fields [6]map[int]bool
+	// restricted day of month and day of week fields are or'ed together,
+	// as in cron
+	domRestricted, dowRestricted bool
+}
+
+// parseSchedule parses a cron expression with six fields: second, minute,
+// hour, day of month, month and day of week. Fields are lists of values,
+// ranges (a-b) and wildcards (*), each optionally with a step (/n).
+func parseSchedule(expr string) (*schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("invalid schedule %q: expected %d fields, got %d", expr, len(cronFields), len(parts))
+	}
+	s := &schedule{}
+	for i, part := range parts {
+		values, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", expr, err)
+		}
+		s.fields[i] = values
+	}
+	// sunday is both 0 and 7
+	if s.fields[5][7] {
+		s.fields[5][0] = true
+	}
+	s.domRestricted = parts[3] != "*" && parts[3] != "?"
+	s.dowRestricted = parts[5] != "*" && parts[5] != "?"
+	return s, nil
+}
+
+func parseCronField(part string, field cronField) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, item := range strings.Split(part, ",") {
+		step := 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(item[i+1:]); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in %s field %q", field.name, item)
+			}
+			item = item[:i]
+		}
+
+		low, high := field.min, field.max
+		if item != "*" && item != "?" {
+			bounds := strings.SplitN(item, "-", 2)
+			var err error
+			if low, err = parseCronValue(bounds[0], field); err != nil {
+				return nil, err
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = parseCronValue(bounds[1], field); err != nil {
+					return nil, err
+				}
+			} else if step > 1 {
+				// a/n means from a to the end of the range
+				high = field.max
+			}
+			if high < low {
+				return nil, fmt.Errorf("invalid range in %s field %q", field.name, item)
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+func parseCronValue(value string, field cronField) (int, error) {
+	for i, name := range field.names {
+		if strings.EqualFold(value, name) {
+			// month names start at 1, day names at 0
+			return i + field.min, nil
+		}
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil || v < field.min || v > field.max {
+		return 0, fmt.Errorf("invalid value %q in %s field", value, field.name)
+	}
+	return v, nil
+}
+
+// matchesDay reports whether the schedule fires on the day of t.
+func (s *schedule) matchesDay(t time.Time) bool {
+	dom := s.fields[3][t.Day()]
+	dow := s.fields[5][int(t.Weekday())]
+	if s.domRestricted && s.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// next returns the first time after t when the schedule fires, in the same
+// location as t, or the zero time if it does not fire within five years.
+func (s *schedule) next(t time.Time) time.Time {
+	t = t.Truncate(time.Second).Add(time.Second)
+	limit := t.AddDate(5, 0, 0)
+	loc := t.Location()
+	for t.Before(limit) {
+		switch {
+		case !s.fields[4][int(t.Month())]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !s.fields[2][t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !s.fields[1][t.Minute()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+		case !s.fields[0][t.Second()]:
+			t = t.Add(time.Second)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// nextFires returns the next count times after t at which any of the given
+// cron expressions fires, in order and without duplicates.
+func nextFires(exprs []string, t time.Time, count int) ([]time.Time, error) {
+	schedules := make([]*schedule, len(exprs))
+	nexts := make([]time.Time, len(exprs))
+	for i, expr := range exprs {
+		s, err := parseSchedule(expr)
+		if err != nil {
+			return nil, err
+		}
+		schedules[i] = s
+		nexts[i] = s.next(t)
+	}
+
+	fires := make([]time.Time, 0, count)
+	for len(fires) < count {
+		first := -1
+		for i, next := range nexts {
+			if !next.IsZero() && (first < 0 || next.Before(nexts[first])) {
+				first = i
+			}
+		}
+		if first < 0 {
+			break
+		}
+		fire := nexts[first]
+		fires = append(fires, fire)
+		for i, next := range nexts {
+			if next.Equal(fire) {
+				nexts[i] = schedules[i].next(fire)
+			}
+		}
+	}
+	return fires, nil
+}
@@ -0,0 +1,64 @@
+package hooks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextFire(t *testing.T) {
+	// a Wednesday
+	from := time.Date(2020, 1, 15, 10, 30, 0, 0, time.UTC)
+	for _, test := range []struct {
+		expr string
+		next time.Time
+	}{
+		{"0 0 0 * * *", time.Date(2020, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 */15 * * * *", time.Date(2020, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"30 30 10 * * *", time.Date(2020, 1, 15, 10, 30, 30, 0, time.UTC)},
+		{"0 0 9-17 * * mon-fri", time.Date(2020, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"0 0 6 * * sat,sun", time.Date(2020, 1, 18, 6, 0, 0, 0, time.UTC)},
+		{"0 0 6 * * 7", time.Date(2020, 1, 19, 6, 0, 0, 0, time.UTC)},
+		{"0 0 0 1 feb *", time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 0 29 2 *", time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 0 31 * *", time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC)},
+		// restricted day of month and day of week are or'ed
+		{"0 0 0 20 * 5", time.Date(2020, 1, 17, 0, 0, 0, 0, time.UTC)},
+	} {
+		s, err := parseSchedule(test.expr)
+		require.NoError(t, err, test.expr)
+		require.Equal(t, test.next, s.next(from), test.expr)
+	}
+}
+
+func TestNextFireNever(t *testing.T) {
+	s, err := parseSchedule("0 0 0 31 2 *")
+	require.NoError(t, err)
+	require.True(t, s.next(time.Now()).IsZero())
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, expr := range []string{
+		"0 0 * * *",
+		"60 * * * * *",
+		"* * * 0 * *",
+		"* * 5-3 * * *",
+		"* */0 * * * *",
+		"* * * * foo *",
+	} {
+		_, err := parseSchedule(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestNextFires(t *testing.T) {
+	from := time.Date(2020, 1, 15, 10, 30, 0, 0, time.UTC)
+	fires, err := nextFires([]string{"0 0 12 * * *", "0 0 */12 * * *"}, from, 3)
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{
+		time.Date(2020, 1, 15, 12, 0, 0, 0, time.UTC),
+		time.Date(2020, 1, 16, 0, 0, 0, 0, time.UTC),
+		time.Date(2020, 1, 16, 12, 0, 0, 0, time.UTC),
+	}, fires)
+}
@@ -0,0 +1,226 @@
+// Package index implements the index subcommands.
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcindex"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/artifacts"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/formatter"
+	fromNow "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/from-now"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+var (
+	// Command is the root of the index subtree.
+	Command = &cobra.Command{
+		Use:   "index",
+		Short: "Provides index-related actions and commands.",
+	}
+)
+
+func init() {
+	formatter.RegisterFlag(Command.PersistentFlags())
+
+	findCmd := &cobra.Command{
+		Use:   "find <namespace>",
+		Short: "Find the taskId indexed under a namespace.",
+		RunE:  root.ExecuteHelperE(runFind, 1, 1),
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list <prefix>",
+		Short: "List the namespaces and indexed tasks directly under a prefix.",
+		RunE:  root.ExecuteHelperE(runList, 1, 1),
+	}
+	listCmd.Flags().IntP("limit", "l", 0, "Only list the first <limit> namespaces and tasks (0 for all).")
+
+	insertCmd := &cobra.Command{
+		Use:   "insert <namespace> <taskId>",
+		Short: "Index a task under a namespace.",
+		RunE:  root.ExecuteHelperE(runInsert, 2, 2),
+	}
+	insertCmd.Flags().Float64("rank", 0, "Rank of the task; a task only replaces an indexed task of lower or equal rank.")
+	insertCmd.Flags().String("expires", "1 year", "Lifetime of the index entry, e.g. '1 year' or '30 days'.")
+	insertCmd.Flags().String("data", "{}", "JSON object stored with the index entry.")
+
+	artifactCmd := &cobra.Command{
+		Use:   "artifact <namespace> <name>",
+		Short: "Download an artifact of the latest run of the task indexed under a namespace.",
+		RunE:  root.ExecuteHelperE(runArtifact, 2, 2),
+	}
+	artifactCmd.Flags().StringP("dest", "d", ".", "Directory to download the artifact to, preserving the directory structure of its name.")
+	artifactCmd.Flags().Int("retries", 5, "Number of times a failed download is retried.")
+
+	Command.AddCommand(findCmd, listCmd, insertCmd, artifactCmd)
+	root.Command.AddCommand(Command)
+}
+
+func makeIndex(credentials *tcclient.Credentials) *tcindex.Index {
+	return tcindex.New(credentials, config.RootURL())
+}
+
+// runFind prints the taskId indexed under a namespace.
+func runFind(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	format, err := formatter.FromFlags(flags)
+	if err != nil {
+		return err
+	}
+
+	task, err := makeIndex(credentials).FindTask(args[0])
+	if err != nil {
+		return fmt.Errorf("could not find the task indexed under %s: %w", args[0], err)
+	}
+
+	if format != formatter.Text {
+		rows := formatter.Rows{
+			Header: []string{"NAMESPACE", "TASK ID", "RANK", "EXPIRES"},
+			Rows:   [][]string{{task.Namespace, task.TaskID, fmt.Sprint(task.Rank), task.Expires.String()}},
+		}
+		return formatter.Write(out, format, task, rows)
+	}
+	fmt.Fprintln(out, task.TaskID)
+	return nil
+}
+
+// listing is the result of runList.
+type listing struct {
+	Namespaces []tcindex.Namespace `json:"namespaces"`
+	Tasks      []tcindex.Task      `json:"tasks"`
+}
+
+// runList lists the namespaces and the tasks directly under a prefix,
+// following continuation tokens.
+func runList(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	format, err := formatter.FromFlags(flags)
+	if err != nil {
+		return err
+	}
+	limit, _ := flags.GetInt("limit")
+	index := makeIndex(credentials)
+	prefix := args[0]
+
+	result := listing{Namespaces: []tcindex.Namespace{}, Tasks: []tcindex.Task{}}
+	continuation := ""
+	for limit <= 0 || len(result.Namespaces) < limit {
+		resp, err := index.ListNamespaces(prefix, continuation, "")
+		if err != nil {
+			return fmt.Errorf("could not list the namespaces under %s: %w", prefix, err)
+		}
+		result.Namespaces = append(result.Namespaces, resp.Namespaces...)
+		if continuation = resp.ContinuationToken; continuation == "" {
+			break
+		}
+	}
+	continuation = ""
+	for limit <= 0 || len(result.Namespaces)+len(result.Tasks) < limit {
+		resp, err := index.ListTasks(prefix, continuation, "")
+		if err != nil {
+			return fmt.Errorf("could not list the tasks under %s: %w", prefix, err)
+		}
+		result.Tasks = append(result.Tasks, resp.Tasks...)
+		if continuation = resp.ContinuationToken; continuation == "" {
+			break
+		}
+	}
+	if limit > 0 {
+		if len(result.Namespaces) > limit {
+			result.Namespaces = result.Namespaces[:limit]
+		}
+		if len(result.Namespaces)+len(result.Tasks) > limit {
+			result.Tasks = result.Tasks[:limit-len(result.Namespaces)]
+		}
+	}
+
+	if format != formatter.Text {
+		rows := formatter.Rows{Header: []string{"NAMESPACE", "TASK ID", "EXPIRES"}}
+		for _, n := range result.Namespaces {
+			rows.Rows = append(rows.Rows, []string{n.Namespace + "/", "", n.Expires.String()})
+		}
+		for _, t := range result.Tasks {
+			rows.Rows = append(rows.Rows, []string{t.Namespace, t.TaskID, t.Expires.String()})
+		}
+		return formatter.Write(out, format, result, rows)
+	}
+	for _, n := range result.Namespaces {
+		fmt.Fprintf(out, "%s/\n", n.Namespace)
+	}
+	for _, t := range result.Tasks {
+		fmt.Fprintf(out, "%s %s\n", t.Namespace, t.TaskID)
+	}
+	return nil
+}
+
+// runInsert indexes a task under a namespace.
+func runInsert(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	namespace, taskID := args[0], args[1]
+
+	expires, _ := flags.GetString("expires")
+	expiry, err := fromNow.Parse(expires)
+	if err != nil {
+		return fmt.Errorf("invalid --expires: %w", err)
+	}
+	data, _ := flags.GetString("data")
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &object); err != nil || object == nil {
+		return errors.New("--data must be a JSON object")
+	}
+	rank, _ := flags.GetFloat64("rank")
+
+	task, err := makeIndex(credentials).InsertTask(namespace, &tcindex.InsertTaskRequest{
+		Data:    json.RawMessage(data),
+		Expires: tcclient.Time(expiry),
+		Rank:    rank,
+		TaskID:  taskID,
+	})
+	if err != nil {
+		return fmt.Errorf("could not index task %s under %s: %w", taskID, namespace, err)
+	}
+
+	fmt.Fprintf(out, "Task %s indexed under %s\n", task.TaskID, task.Namespace)
+	return nil
+}
+
+// runArtifact downloads an artifact of the latest run of the task indexed
+// under a namespace.
+func runArtifact(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	namespace, name := args[0], args[1]
+
+	task, err := makeIndex(credentials).FindTask(namespace)
+	if err != nil {
+		return fmt.Errorf("could not find the task indexed under %s: %w", namespace, err)
+	}
+
+	q := tcqueue.New(credentials, config.RootURL())
+	s, err := q.Status(task.TaskID)
+	if err != nil {
+		return fmt.Errorf("could not get the status of the task %s: %w", task.TaskID, err)
+	}
+	if len(s.Status.Runs) == 0 {
+		return fmt.Errorf("task %s has no runs", task.TaskID)
+	}
+	runID := strconv.FormatInt(s.Status.Runs[len(s.Status.Runs)-1].RunID, 10)
+
+	dest, _ := flags.GetString("dest")
+	path, err := artifacts.Path(dest, name)
+	if err != nil {
+		return err
+	}
+	d := &artifacts.Downloader{Queue: q}
+	d.Retries, _ = flags.GetInt("retries")
+	if err := d.Download(task.TaskID, runID, name, path); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "downloaded %s of task %s to %s\n", name, task.TaskID, path)
+	return nil
+}
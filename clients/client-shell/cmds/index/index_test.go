@@ -0,0 +1,134 @@
+package index
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/suite"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/formatter"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+const indexedTaskID = "ANnmjMocTymeTID0tlNJAw"
+
+type FakeServerSuite struct {
+	suite.Suite
+	testServer *httptest.Server
+	// inserted holds the last request to insert a task
+	inserted map[string]interface{}
+}
+
+func (suite *FakeServerSuite) SetupSuite() {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/index/v1/task/project.app.latest", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			suite.inserted = nil
+			_ = json.NewDecoder(r.Body).Decode(&suite.inserted)
+		}
+		_, _ = io.WriteString(w, `{"namespace": "project.app.latest", "taskId": "`+indexedTaskID+`", "rank": 0, "data": {}, "expires": "2030-01-01T00:00:00.000Z"}`)
+	})
+	handler.HandleFunc("/api/index/v1/namespaces/project", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("continuationToken") == "" {
+			_, _ = io.WriteString(w, `{"namespaces": [{"namespace": "project.app", "name": "app", "expires": "2030-01-01T00:00:00.000Z"}], "continuationToken": "next"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"namespaces": [{"namespace": "project.lib", "name": "lib", "expires": "2030-01-01T00:00:00.000Z"}]}`)
+	})
+	handler.HandleFunc("/api/index/v1/tasks/project", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"tasks": [{"namespace": "project.latest", "taskId": "`+indexedTaskID+`", "rank": 0, "data": {}, "expires": "2030-01-01T00:00:00.000Z"}]}`)
+	})
+	handler.HandleFunc("/api/queue/v1/task/"+indexedTaskID+"/status", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"status": {"taskId": "`+indexedTaskID+`", "state": "completed", "runs": [{"runId": 0}, {"runId": 1}]}}`)
+	})
+	handler.HandleFunc("/api/queue/v1/task/"+indexedTaskID+"/runs/1/artifacts/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "binary")
+	})
+
+	suite.testServer = httptest.NewServer(handler)
+	config.SetRootURL(suite.testServer.URL)
+}
+
+func (suite *FakeServerSuite) TearDownSuite() {
+	suite.testServer.Close()
+	config.SetRootURL("")
+}
+
+func TestFakeServerSuite(t *testing.T) {
+	suite.Run(t, new(FakeServerSuite))
+}
+
+func newFlags(output string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("index", pflag.ContinueOnError)
+	formatter.RegisterFlag(flags)
+	_ = flags.Set("output", output)
+	return flags
+}
+
+func (suite *FakeServerSuite) TestFind() {
+	buf := &bytes.Buffer{}
+	suite.NoError(runFind(nil, []string{"project.app.latest"}, buf, newFlags("text")))
+	suite.Equal(indexedTaskID+"\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestList() {
+	buf := &bytes.Buffer{}
+	flags := newFlags("text")
+	flags.Int("limit", 0, "")
+	suite.NoError(runList(nil, []string{"project"}, buf, flags))
+	suite.Equal("project.app/\nproject.lib/\nproject.latest "+indexedTaskID+"\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestListLimitJSON() {
+	buf := &bytes.Buffer{}
+	flags := newFlags("json")
+	flags.Int("limit", 2, "")
+	suite.NoError(runList(nil, []string{"project"}, buf, flags))
+
+	var result listing
+	suite.NoError(json.Unmarshal(buf.Bytes(), &result))
+	suite.Len(result.Namespaces, 2)
+	suite.Len(result.Tasks, 0)
+}
+
+func (suite *FakeServerSuite) TestInsert() {
+	buf := &bytes.Buffer{}
+	flags := newFlags("text")
+	flags.Float64("rank", 3, "")
+	flags.String("expires", "1 day", "")
+	flags.String("data", `{"revision": "abc"}`, "")
+	suite.NoError(runInsert(nil, []string{"project.app.latest", indexedTaskID}, buf, flags))
+
+	suite.Equal("Task "+indexedTaskID+" indexed under project.app.latest\n", buf.String())
+	suite.Equal(indexedTaskID, suite.inserted["taskId"])
+	suite.Equal(3.0, suite.inserted["rank"])
+	suite.Equal(map[string]interface{}{"revision": "abc"}, suite.inserted["data"])
+
+	_ = flags.Set("data", "[]")
+	suite.Error(runInsert(nil, []string{"project.app.latest", indexedTaskID}, buf, flags))
+}
+
+func (suite *FakeServerSuite) TestArtifact() {
+	dir, err := ioutil.TempDir("", "index-artifact")
+	suite.Require().NoError(err)
+	defer os.RemoveAll(dir)
+
+	buf := &bytes.Buffer{}
+	flags := newFlags("text")
+	flags.String("dest", dir, "")
+	flags.Int("retries", 0, "")
+	suite.NoError(runArtifact(nil, []string{"project.app.latest", "public/build/app.bin"}, buf, flags))
+
+	path := filepath.Join(dir, "public", "build", "app.bin")
+	suite.Equal("downloaded public/build/app.bin of task "+indexedTaskID+" to "+path+"\n", buf.String())
+	content, err := ioutil.ReadFile(path)
+	suite.NoError(err)
+	suite.Equal("binary", string(content))
+}
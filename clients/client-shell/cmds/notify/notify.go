@@ -0,0 +1,209 @@
+// Package notify implements the notify subcommands.
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcnotify"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+var (
+	// Command is the root of the notify subtree.
+	Command = &cobra.Command{
+		Use:   "notify",
+		Short: "Sends notifications through the notify service.",
+	}
+
+	// stdin is where bodies and messages given as - are read from.
+	stdin io.Reader = os.Stdin
+)
+
+func init() {
+	emailCmd := &cobra.Command{
+		Use:   "email --to <address> --subject <subject> (--body <text> | --body-file <file>)",
+		Short: "Send an email, whose body is markdown.",
+		RunE:  root.ExecuteHelperE(runEmail, 0, 0),
+	}
+	emailCmd.Flags().String("to", "", "Address to send the email to.")
+	emailCmd.Flags().String("subject", "", "Subject of the email.")
+	addBodyFlags(emailCmd, "Body of the email, as markdown.")
+	emailCmd.Flags().String("reply-to", "", "Reply-to address of the email.")
+	emailCmd.Flags().String("link-href", "", "URL of a link shown as a button in the email.")
+	emailCmd.Flags().String("link-text", "", "Text of the link given with --link-href.")
+	emailCmd.Flags().String("template", "", "Template of the email: simple or fullscreen.")
+
+	matrixCmd := &cobra.Command{
+		Use:   "matrix --room <roomId> (--body <text> | --body-file <file>)",
+		Short: "Send a notice to a Matrix room.",
+		RunE:  root.ExecuteHelperE(runMatrix, 0, 0),
+	}
+	matrixCmd.Flags().String("room", "", "Fully qualified id of the room, e.g. '!whDRjjSmICCgrhFHsQ:mozilla.org'.")
+	addBodyFlags(matrixCmd, "Unformatted text of the notice.")
+	matrixCmd.Flags().String("format", "", "Format of --formatted-body, e.g. org.matrix.custom.html.")
+	matrixCmd.Flags().String("formatted-body", "", "Text of the notice in the format given with --format.")
+
+	pulseCmd := &cobra.Command{
+		Use:   "pulse --routing-key <key> (--message <json> | --message-file <file>)",
+		Short: "Publish a JSON message on pulse.",
+		RunE:  root.ExecuteHelperE(runPulse, 0, 0),
+	}
+	pulseCmd.Flags().String("routing-key", "", "Routing key of the message.")
+	pulseCmd.Flags().String("message", "", "The message, as JSON.")
+	pulseCmd.Flags().String("message-file", "", "File holding the JSON message, or - to read it from stdin.")
+
+	Command.AddCommand(emailCmd, matrixCmd, pulseCmd)
+	root.Command.AddCommand(Command)
+}
+
+func makeNotify(credentials *tcclient.Credentials) *tcnotify.Notify {
+	return tcnotify.New(credentials, config.RootURL())
+}
+
+func addBodyFlags(cmd *cobra.Command, usage string) {
+	cmd.Flags().String("body", "", usage)
+	cmd.Flags().String("body-file", "", "File holding the body, or - to read it from stdin.")
+}
+
+// readText returns the value of the named flag, or the content of the file
+// given by the flag of the same name with a -file suffix.
+func readText(flags *pflag.FlagSet, name string) (string, error) {
+	text, _ := flags.GetString(name)
+	file, _ := flags.GetString(name + "-file")
+	if text != "" && file != "" {
+		return "", fmt.Errorf("only one of --%s and --%s-file can be given", name, name)
+	}
+
+	var data []byte
+	var err error
+	switch file {
+	case "":
+		if text == "" {
+			return "", fmt.Errorf("--%s or --%s-file is required", name, name)
+		}
+		return text, nil
+	case "-":
+		data, err = ioutil.ReadAll(stdin)
+	default:
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not read %s: %w", name, err)
+	}
+	return string(data), nil
+}
+
+// required returns the value of the named flag, or an error if it is empty.
+func required(flags *pflag.FlagSet, name string) (string, error) {
+	value, _ := flags.GetString(name)
+	if value == "" {
+		return "", fmt.Errorf("--%s is required", name)
+	}
+	return value, nil
+}
+
+// emailRequest is tcnotify.SendEmailRequest with an optional link, as the
+// generated type always sends one, and the service rejects empty links.
+type emailRequest struct {
+	Address  string         `json:"address"`
+	Content  string         `json:"content"`
+	Link     *tcnotify.Link `json:"link,omitempty"`
+	ReplyTo  string         `json:"replyTo,omitempty"`
+	Subject  string         `json:"subject"`
+	Template string         `json:"template,omitempty"`
+}
+
+// runEmail sends an email.
+func runEmail(credentials *tcclient.Credentials, _ []string, out io.Writer, flags *pflag.FlagSet) error {
+	to, err := required(flags, "to")
+	if err != nil {
+		return err
+	}
+	subject, err := required(flags, "subject")
+	if err != nil {
+		return err
+	}
+	body, err := readText(flags, "body")
+	if err != nil {
+		return err
+	}
+
+	req := &emailRequest{Address: to, Subject: subject, Content: body}
+	req.ReplyTo, _ = flags.GetString("reply-to")
+	req.Template, _ = flags.GetString("template")
+	href, _ := flags.GetString("link-href")
+	text, _ := flags.GetString("link-text")
+	if href != "" || text != "" {
+		if href == "" || text == "" {
+			return errors.New("--link-href and --link-text must be given together")
+		}
+		req.Link = &tcnotify.Link{Href: href, Text: text}
+	}
+
+	cd := tcclient.Client(*makeNotify(credentials))
+	if _, _, err := cd.APICall(req, "POST", "/email", nil, nil); err != nil {
+		return fmt.Errorf("could not send email to %s: %w", to, err)
+	}
+	fmt.Fprintf(out, "Email sent to %s\n", to)
+	return nil
+}
+
+// runMatrix sends a notice to a Matrix room.
+func runMatrix(credentials *tcclient.Credentials, _ []string, out io.Writer, flags *pflag.FlagSet) error {
+	room, err := required(flags, "room")
+	if err != nil {
+		return err
+	}
+	body, err := readText(flags, "body")
+	if err != nil {
+		return err
+	}
+
+	req := &tcnotify.SendMatrixNoticeRequest{RoomID: room, Body: body}
+	req.Format, _ = flags.GetString("format")
+	req.FormattedBody, _ = flags.GetString("formatted-body")
+	if (req.Format == "") != (req.FormattedBody == "") {
+		return errors.New("--format and --formatted-body must be given together")
+	}
+
+	if err := makeNotify(credentials).Matrix(req); err != nil {
+		return fmt.Errorf("could not send notice to %s: %w", room, err)
+	}
+	fmt.Fprintf(out, "Notice sent to %s\n", room)
+	return nil
+}
+
+// runPulse publishes a message on pulse.
+func runPulse(credentials *tcclient.Credentials, _ []string, out io.Writer, flags *pflag.FlagSet) error {
+	routingKey, err := required(flags, "routing-key")
+	if err != nil {
+		return err
+	}
+	message, err := readText(flags, "message")
+	if err != nil {
+		return err
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(message), &object); err != nil || object == nil {
+		return errors.New("the message must be a JSON object")
+	}
+
+	err = makeNotify(credentials).Pulse(&tcnotify.PostPulseMessageRequest{
+		RoutingKey: routingKey,
+		Message:    json.RawMessage(message),
+	})
+	if err != nil {
+		return fmt.Errorf("could not publish message with routing key %s: %w", routingKey, err)
+	}
+	fmt.Fprintf(out, "Message published with routing key %s\n", routingKey)
+	return nil
+}
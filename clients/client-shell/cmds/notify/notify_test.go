@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/suite"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+type FakeServerSuite struct {
+	suite.Suite
+	testServer *httptest.Server
+	// sent holds the requests received by the fake server, by path
+	sent map[string]string
+}
+
+func (suite *FakeServerSuite) SetupSuite() {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		suite.sent[r.URL.Path] = string(body)
+		_, _ = io.WriteString(w, "{}")
+	}
+	suite.testServer = httptest.NewServer(http.HandlerFunc(handler))
+	config.SetRootURL(suite.testServer.URL)
+}
+
+func (suite *FakeServerSuite) SetupTest() {
+	suite.sent = map[string]string{}
+}
+
+func (suite *FakeServerSuite) TearDownSuite() {
+	suite.testServer.Close()
+	config.SetRootURL("")
+}
+
+func TestFakeServerSuite(t *testing.T) {
+	suite.Run(t, new(FakeServerSuite))
+}
+
+// flags returns the flags of cmd, set to the given values.
+func (suite *FakeServerSuite) flags(cmd string, values map[string]string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet(cmd, pflag.ContinueOnError)
+	for _, name := range []string{"to", "subject", "body", "body-file", "reply-to", "link-href", "link-text", "template",
+		"room", "format", "formatted-body", "routing-key", "message", "message-file"} {
+		flags.String(name, "", "")
+	}
+	for name, value := range values {
+		suite.Require().NoError(flags.Set(name, value))
+	}
+	return flags
+}
+
+func (suite *FakeServerSuite) TestEmail() {
+	buf := &bytes.Buffer{}
+	err := runEmail(nil, nil, buf, suite.flags("email", map[string]string{
+		"to":      "me@example.com",
+		"subject": "Build done",
+		"body":    "**All** good",
+	}))
+	suite.NoError(err)
+	suite.Equal("Email sent to me@example.com\n", buf.String())
+	suite.JSONEq(`{"address": "me@example.com", "subject": "Build done", "content": "**All** good"}`, suite.sent["/api/notify/v1/email"])
+}
+
+func (suite *FakeServerSuite) TestEmailLinkAndStdin() {
+	defer func(orig io.Reader) { stdin = orig }(stdin)
+	stdin = strings.NewReader("from stdin")
+
+	err := runEmail(nil, nil, ioutil.Discard, suite.flags("email", map[string]string{
+		"to":        "me@example.com",
+		"subject":   "Build done",
+		"body-file": "-",
+		"link-href": "https://example.com/build",
+		"link-text": "Build",
+	}))
+	suite.NoError(err)
+	var sent map[string]interface{}
+	suite.Require().NoError(json.Unmarshal([]byte(suite.sent["/api/notify/v1/email"]), &sent))
+	suite.Equal("from stdin", sent["content"])
+	suite.Equal(map[string]interface{}{"href": "https://example.com/build", "text": "Build"}, sent["link"])
+}
+
+func (suite *FakeServerSuite) TestEmailInvalid() {
+	err := runEmail(nil, nil, ioutil.Discard, suite.flags("email", map[string]string{"to": "me@example.com", "subject": "s"}))
+	suite.EqualError(err, "--body or --body-file is required")
+
+	err = runEmail(nil, nil, ioutil.Discard, suite.flags("email", map[string]string{"to": "me@example.com", "subject": "s", "body": "b", "link-text": "Build"}))
+	suite.EqualError(err, "--link-href and --link-text must be given together")
+	suite.Empty(suite.sent)
+}
+
+func (suite *FakeServerSuite) TestMatrix() {
+	buf := &bytes.Buffer{}
+	err := runMatrix(nil, nil, buf, suite.flags("matrix", map[string]string{
+		"room":           "!room:example.com",
+		"body":           "done",
+		"format":         "org.matrix.custom.html",
+		"formatted-body": "<b>done</b>",
+	}))
+	suite.NoError(err)
+	suite.Equal("Notice sent to !room:example.com\n", buf.String())
+	suite.JSONEq(`{"roomId": "!room:example.com", "body": "done", "format": "org.matrix.custom.html", "formattedBody": "<b>done</b>"}`, suite.sent["/api/notify/v1/matrix"])
+}
+
+func (suite *FakeServerSuite) TestPulse() {
+	buf := &bytes.Buffer{}
+	err := runPulse(nil, nil, buf, suite.flags("pulse", map[string]string{
+		"routing-key": "project.app.done",
+		"message":     `{"status": "ok"}`,
+	}))
+	suite.NoError(err)
+	suite.Equal("Message published with routing key project.app.done\n", buf.String())
+	suite.JSONEq(`{"routingKey": "project.app.done", "message": {"status": "ok"}}`, suite.sent["/api/notify/v1/pulse"])
+
+	err = runPulse(nil, nil, ioutil.Discard, suite.flags("pulse", map[string]string{
+		"routing-key": "project.app.done",
+		"message":     `[1, 2]`,
+	}))
+	suite.EqualError(err, "the message must be a JSON object")
+}
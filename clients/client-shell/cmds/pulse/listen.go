@@ -0,0 +1,233 @@
+package pulse
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/streadway/amqp"
+	pulsego "github.com/taskcluster/pulse-go/pulse"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/pulse/filter"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/pulse/sink"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/formatter"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
+)
+
+func init() {
+	listenCmd := &cobra.Command{
+		Use:   "listen --binding <exchange> [--routing-key <pattern>]",
+		Short: "Print the messages published on Pulse exchanges.",
+		Long: `Print the messages published on Pulse exchanges as they arrive, e.g.
+
+  taskcluster pulse listen --binding exchange/taskcluster-queue/v1/task-completed --routing-key 'route.index.#'
+
+--binding can be repeated to listen to several exchanges. --routing-key is the
+pattern the routing keys of the messages must match, where * matches a word
+and # any number of words; it is given once for all bindings, or once per
+binding, and defaults to #.
+
+--filter selects the messages to print with an expression evaluated against
+their exchange, routingKey, routes, redelivered and payload fields, e.g.
+
+  --filter 'payload.status.state in ["failed", "exception"] && routes[0] =~ "^route[.]index[.]"'
+
+It supports the ||, &&, !, ==, !=, <, <=, >, >=, in and =~ operators, and
+quoted strings, numbers, true, false, null and lists; missing fields are null.
+Messages which do not match are not counted by --count.
+
+With --sink, the messages are also archived as JSON lines, with the time they
+were received: --sink file:<path> appends them to a file, rotated with
+--rotate-size or --rotate-interval, and --sink s3://<bucket>/<prefix> uploads
+them in batches of --batch-size messages, at least every --batch-interval,
+with the AWS credentials and region of the environment.
+
+With --metrics-address, e.g. :9090, the number of messages received by
+exchange, the time taken to print them, and the number of reconnections are
+served at /metrics in the Prometheus format.
+
+Messages are printed as a line with the exchange and routing key, or with
+-o json, as one JSON object per line with the payload of the message.
+
+The connection is re-established if it is lost, although messages published
+in the meantime are missed, unless --queue names a durable queue, which keeps
+them until listening again with the same --queue. Listening stops after
+--count messages, or after --timeout, which is an error if --count messages
+were not received.`,
+		RunE: root.ExecuteHelperE(runListen, 0, 0),
+	}
+	addListenFlags(listenCmd.Flags())
+
+	Command.AddCommand(listenCmd)
+}
+
+func addListenFlags(flags *pflag.FlagSet) {
+	addSelectionFlags(flags)
+	flags.Int("count", 0, "Exit after receiving this many messages (0 for no limit).")
+	flags.Duration("timeout", 0, "Exit after listening this long, e.g. 10m (0 for no limit).")
+	flags.String("metrics-address", "", "Serve Prometheus metrics at /metrics on this address, e.g. :9090.")
+	addSinkFlags(flags)
+	AddQueueFlags(flags)
+	formatter.RegisterFlag(flags)
+	AddConnectionFlags(flags)
+}
+
+// addSelectionFlags adds the flags selecting the messages to consume.
+func addSelectionFlags(flags *pflag.FlagSet) {
+	flags.StringArray("binding", nil, "(can be repeated) Exchange to listen to, e.g. exchange/taskcluster-queue/v1/task-completed.")
+	flags.StringArray("routing-key", nil, "(can be repeated) Routing key pattern of the messages, once for all bindings or once per binding.")
+	flags.String("filter", "", "Only consume the messages matching this expression, e.g. 'payload.runId > 0'.")
+}
+
+// selection returns the bindings and the filter given by the flags added
+// with addSelectionFlags; the filter is nil if there is none.
+func selection(flags *pflag.FlagSet) ([]pulsego.Binding, func(amqp.Delivery) bool, error) {
+	exchanges, _ := flags.GetStringArray("binding")
+	routingKeys, _ := flags.GetStringArray("routing-key")
+	bindings, err := parseBindings(exchanges, routingKeys)
+	if err != nil {
+		return nil, nil, err
+	}
+	expr, _ := flags.GetString("filter")
+	if expr == "" {
+		return bindings, nil, nil
+	}
+	f, err := filter.Parse(expr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bindings, f.MatchDelivery, nil
+}
+
+// message is the JSON representation of a delivery.
+type message struct {
+	Exchange   string `json:"exchange"`
+	RoutingKey string `json:"routingKey"`
+	// Routes are the additional routing keys of the message, such as the
+	// routes of a task.
+	Routes      []string        `json:"routes,omitempty"`
+	Redelivered bool            `json:"redelivered"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// runListen prints the messages received on the --binding exchanges. Pulse
+// has credentials of its own, so the Taskcluster credentials are unused.
+func runListen(_ *tcclient.Credentials, _ []string, out io.Writer, flags *pflag.FlagSet) error {
+	bindings, match, err := selection(flags)
+	if err != nil {
+		return err
+	}
+	format, err := formatter.FromFlags(flags)
+	if err != nil {
+		return err
+	}
+	if format != formatter.Text && format != formatter.JSON {
+		return fmt.Errorf("pulse listen only supports the text and json output formats")
+	}
+	count, _ := flags.GetInt("count")
+	timeout, _ := flags.GetDuration("timeout")
+	metricsAddress, _ := flags.GetString("metrics-address")
+	queue, queueOpts, err := queueFlags(flags)
+	if err != nil {
+		return err
+	}
+	sinks, err := openSinks(flags)
+	if err != nil {
+		return err
+	}
+
+	received := 0
+	err = Consume(flags, Subscription{
+		Bindings:       bindings,
+		Timeout:        timeout,
+		Filter:         match,
+		MetricsAddress: metricsAddress,
+		QueueName:      queue,
+		Queue:          queueOpts,
+		Handle: func(d amqp.Delivery) (bool, error) {
+			if err := WriteMessage(out, format, d); err != nil {
+				return true, err
+			}
+			if err := sink.Write(sink.NewRecord(d), sinks...); err != nil {
+				return true, err
+			}
+			received++
+			return count > 0 && received >= count, nil
+		},
+	})
+	closeErr := closeSinks(sinks)
+	if errors.Is(err, ErrTimeout) {
+		err = nil
+		if count > 0 {
+			err = fmt.Errorf("received %d of %d messages before the timeout of %s", received, count, timeout)
+		}
+	}
+	if err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// parseBindings pairs the exchanges given with --binding and the routing keys
+// given with --routing-key.
+func parseBindings(exchanges, routingKeys []string) ([]pulsego.Binding, error) {
+	if len(exchanges) == 0 {
+		return nil, errors.New("at least one exchange must be given with --binding")
+	}
+	if len(routingKeys) > 1 && len(routingKeys) != len(exchanges) {
+		return nil, fmt.Errorf("--routing-key must be given once, or once per --binding; got %d routing keys for %d bindings", len(routingKeys), len(exchanges))
+	}
+	bindings := make([]pulsego.Binding, len(exchanges))
+	for i, exchange := range exchanges {
+		routingKey := "#"
+		if len(routingKeys) == 1 {
+			routingKey = routingKeys[0]
+		} else if len(routingKeys) > 1 {
+			routingKey = routingKeys[i]
+		}
+		bindings[i] = pulsego.Bind(routingKey, exchange)
+	}
+	return bindings, nil
+}
+
+// WriteMessage prints a delivery as a line with its exchange and routing key,
+// or with the JSON format, as a JSON object on a line.
+func WriteMessage(out io.Writer, format formatter.Format, d amqp.Delivery) error {
+	if format == formatter.Text {
+		_, err := fmt.Fprintf(out, "%s %s %s\n", time.Now().UTC().Format("15:04:05"), d.Exchange, d.RoutingKey)
+		return err
+	}
+	m := message{
+		Exchange:    d.Exchange,
+		RoutingKey:  d.RoutingKey,
+		Routes:      routes(d),
+		Redelivered: d.Redelivered,
+		Payload:     d.Body,
+	}
+	if !json.Valid(d.Body) {
+		m.Payload, _ = json.Marshal(string(d.Body))
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("could not render json: %w", err)
+	}
+	_, err = fmt.Fprintln(out, string(data))
+	return err
+}
+
+// routes returns the additional routing keys of a delivery, which Pulse
+// publishers give in the CC header.
+func routes(d amqp.Delivery) []string {
+	cc, _ := d.Headers["CC"].([]interface{})
+	var routes []string
+	for _, route := range cc {
+		if r, ok := route.(string); ok {
+			routes = append(routes, r)
+		}
+	}
+	return routes
+}
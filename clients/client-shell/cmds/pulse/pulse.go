@@ -0,0 +1,232 @@
+// Package pulse implements the pulse subcommands, which consume the messages
+// Taskcluster services publish on Pulse.
+package pulse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/streadway/amqp"
+	pulsego "github.com/taskcluster/pulse-go/pulse"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/pulse/consumer"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/client"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
+)
+
+// defaultURL is the Pulse instance of the Mozilla deployments.
+const defaultURL = "amqps://pulse.mozilla.org:5671"
+
+var (
+	// Command is the root of the pulse subtree.
+	Command = &cobra.Command{
+		Use:   "pulse",
+		Short: "Listen to the messages published on Pulse.",
+	}
+
+	// stderr is where reconnections are reported.
+	stderr io.Writer = os.Stderr
+)
+
+func init() {
+	root.Command.AddCommand(Command)
+}
+
+// AddConnectionFlags adds the flags giving the Pulse instance to connect to,
+// and its credentials.
+func AddConnectionFlags(flags *pflag.FlagSet) {
+	flags.String("pulse-url", "", "AMQP URL of the Pulse instance, whose path is the vhost, with the query parameters of RabbitMQ URIs such as heartbeat or cacertfile; credentials are taken from $PULSE_USERNAME and $PULSE_PASSWORD unless given in the URL (default: $PULSE_URL, or "+defaultURL+").")
+	flags.String("pulse-ca-cert", "", "PEM file of the CA certificate the certificate of the Pulse instance is verified with.")
+	flags.String("pulse-cert", "", "PEM file of the client certificate, which authenticates the user of the URL if it has no password.")
+	flags.String("pulse-key", "", "PEM file of the key of the client certificate.")
+}
+
+// AddQueueFlags adds the flags giving a named queue, which keeps the messages
+// published while disconnected, instead of a queue deleted once disconnected.
+func AddQueueFlags(flags *pflag.FlagSet) {
+	flags.String("queue", "", "Name of a durable queue, which keeps the messages published while disconnected, e.g. my-listener (default: a queue deleted once disconnected).")
+	flags.Duration("queue-expires", 0, "Delete the --queue once it is unused this long, e.g. 72h (0 for never).")
+	flags.Int("max-length", 0, "Most messages the --queue keeps while disconnected; the oldest are discarded beyond (0 for no limit).")
+}
+
+// queueFlags returns the name and the options of the queue given by the
+// flags added with AddQueueFlags.
+func queueFlags(flags *pflag.FlagSet) (string, consumer.QueueOptions, error) {
+	name, _ := flags.GetString("queue")
+	expires, _ := flags.GetDuration("queue-expires")
+	maxLength, _ := flags.GetInt("max-length")
+	if name == "" && (expires != 0 || maxLength != 0) {
+		return "", consumer.QueueOptions{}, errors.New("--queue-expires and --max-length require --queue")
+	}
+	return name, consumer.QueueOptions{Expires: expires, MaxLength: maxLength}, nil
+}
+
+// connectionOptions returns the options of the connection to the Pulse
+// instance given by the flags added with AddConnectionFlags.
+func connectionOptions(flags *pflag.FlagSet) (consumer.Options, error) {
+	pulseURL, err := connectionURL(flags)
+	if err != nil {
+		return consumer.Options{}, err
+	}
+	opts, err := consumer.ParseURL(pulseURL)
+	if err != nil {
+		return consumer.Options{}, err
+	}
+	caCert, _ := flags.GetString("pulse-ca-cert")
+	cert, _ := flags.GetString("pulse-cert")
+	key, _ := flags.GetString("pulse-key")
+	if caCert == "" && cert == "" && key == "" {
+		return opts, nil
+	}
+	if !strings.HasPrefix(opts.URL, "amqps://") {
+		return consumer.Options{}, errors.New("--pulse-ca-cert, --pulse-cert and --pulse-key require an amqps:// pulse URL")
+	}
+	files := consumer.TLSFiles{CACert: caCert, Cert: cert, Key: key}
+	if opts.TLS != nil {
+		files.ServerName, files.Insecure = opts.TLS.ServerName, opts.TLS.InsecureSkipVerify
+	}
+	opts.TLS, err = consumer.TLSConfig(files)
+	return opts, err
+}
+
+// connectionURL returns the URL of the Pulse instance given with --pulse-url
+// or PULSE_URL, with credentials.
+func connectionURL(flags *pflag.FlagSet) (string, error) {
+	value, _ := flags.GetString("pulse-url")
+	if value == "" {
+		value = os.Getenv("PULSE_URL")
+	}
+	if value == "" {
+		value = defaultURL
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "amqp" && u.Scheme != "amqps") {
+		return "", fmt.Errorf("invalid pulse URL %q: it must be an amqp:// or amqps:// URL", value)
+	}
+	if u.User == nil {
+		username, password := os.Getenv("PULSE_USERNAME"), os.Getenv("PULSE_PASSWORD")
+		if username == "" {
+			return "", errors.New("pulse credentials must be given with PULSE_USERNAME and PULSE_PASSWORD, or in the pulse URL")
+		}
+		u.User = url.UserPassword(username, password)
+		if password == "" && flags.Changed("pulse-cert") {
+			// the client certificate authenticates the user
+			u.User = url.User(username)
+		}
+	}
+	return u.String(), nil
+}
+
+// messageConsumer is the part of *consumer.Consumer the commands use.
+type messageConsumer interface {
+	Run(context.Context, consumer.Handler) error
+}
+
+// newConsumer is replaced in tests.
+var newConsumer = func(opts consumer.Options) (messageConsumer, error) {
+	c, err := consumer.New(opts)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// reconnectDelay is the delay before attempting to reconnect to Pulse again,
+// which doubles after each failure, up to maxReconnectDelay.
+var (
+	reconnectDelay    = time.Second
+	maxReconnectDelay = time.Minute
+)
+
+// ErrTimeout is returned by Consume when the timeout of the subscription
+// expires.
+var ErrTimeout = errors.New("timed out")
+
+// A Subscription describes the messages a command consumes, and how.
+type Subscription struct {
+	Bindings []pulsego.Binding
+	// Timeout is how long to consume messages for, if not 0.
+	Timeout time.Duration
+	// Filter, if set, selects the messages passed to Handle.
+	Filter func(amqp.Delivery) bool
+	// MetricsAddress, if set, is the address the Prometheus metrics of the
+	// consumer are served on at /metrics.
+	MetricsAddress string
+	// QueueName, if set, is the name of a durable queue, which keeps the
+	// messages published while disconnected; Queue configures it.
+	QueueName string
+	Queue     consumer.QueueOptions
+	// Subscribed, if set, is called once the queue is bound, on each
+	// connection, e.g. to catch up with the messages published before or
+	// while reconnecting. It returns true if no message needs to be
+	// consumed.
+	Subscribed func() (bool, error)
+	// Handle is called with each message, and returns true once done.
+	Handle func(amqp.Delivery) (bool, error)
+}
+
+// Consume connects to the Pulse instance given by the flags added with
+// AddConnectionFlags, and calls the handler of the subscription with each
+// message until it is done or fails, reconnecting whenever the connection is
+// lost. It returns ErrTimeout once the timeout of the subscription expires.
+func Consume(flags *pflag.FlagSet, s Subscription) error {
+	opts, err := connectionOptions(flags)
+	if err != nil {
+		return err
+	}
+
+	opts.Bindings = s.Bindings
+	opts.AutoAck = true
+	opts.Filter = s.Filter
+	opts.MetricsAddress = s.MetricsAddress
+	opts.QueueName, opts.Queue = s.QueueName, s.Queue
+	opts.MinBackoff, opts.MaxBackoff = reconnectDelay, maxReconnectDelay
+	connected := false
+	opts.Connected = func() error {
+		if connected {
+			fmt.Fprintln(client.Progress(stderr), "Reconnected to pulse.")
+		}
+		connected = true
+		if s.Subscribed == nil {
+			return nil
+		}
+		return stop(s.Subscribed())
+	}
+	opts.Disconnected = func(err error, retry time.Duration) {
+		fmt.Fprintf(client.Progress(stderr), "%v; retrying in %s\n", err, retry)
+	}
+	c, err := newConsumer(opts)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	err = c.Run(ctx, func(d amqp.Delivery) error {
+		return stop(s.Handle(d))
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	return err
+}
+
+// stop converts the result of the callbacks of a subscription to that of the
+// callbacks of a consumer.
+func stop(done bool, err error) error {
+	if err == nil && done {
+		return consumer.Stop
+	}
+	return err
+}
@@ -0,0 +1,91 @@
+package pulse
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/spf13/pflag"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/pulse/sink"
+)
+
+// addSinkFlags adds the flags giving the sinks the messages are archived to.
+func addSinkFlags(flags *pflag.FlagSet) {
+	flags.StringArray("sink", nil, "(can be repeated) Also write the messages as JSON lines to file:<path>, or to objects in s3://<bucket>/<prefix>.")
+	flags.Int64("rotate-size", 0, "Rotate the files of file sinks once they reach this many bytes (0 for no limit).")
+	flags.Duration("rotate-interval", 0, "Rotate the files of file sinks after this long, e.g. 24h (0 for no limit).")
+	flags.Int("batch-size", 1000, "Most messages in an object of S3 sinks.")
+	flags.Duration("batch-interval", 5*time.Minute, "Longest time messages are held before being written to S3 sinks.")
+}
+
+// newS3Client is replaced in tests.
+var newS3Client = func() (s3iface.S3API, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("could not create an AWS session: %w", err)
+	}
+	return s3.New(sess), nil
+}
+
+// openSinks opens the sinks given with --sink.
+func openSinks(flags *pflag.FlagSet) ([]sink.Sink, error) {
+	specs, _ := flags.GetStringArray("sink")
+	var sinks []sink.Sink
+	for _, spec := range specs {
+		s, err := openSink(spec, flags)
+		if err != nil {
+			_ = closeSinks(sinks)
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+	return sinks, nil
+}
+
+func openSink(spec string, flags *pflag.FlagSet) (sink.Sink, error) {
+	switch {
+	case strings.HasPrefix(spec, "file:") && len(spec) > len("file:"):
+		maxSize, _ := flags.GetInt64("rotate-size")
+		maxAge, _ := flags.GetDuration("rotate-interval")
+		return sink.NewFile(sink.FileOptions{
+			Path:    strings.TrimPrefix(spec, "file:"),
+			MaxSize: maxSize,
+			MaxAge:  maxAge,
+		})
+	case strings.HasPrefix(spec, "s3://") && len(spec) > len("s3://"):
+		bucket := strings.TrimPrefix(spec, "s3://")
+		prefix := ""
+		if i := strings.Index(bucket, "/"); i >= 0 {
+			bucket, prefix = bucket[:i], bucket[i+1:]
+		}
+		if prefix != "" && !strings.HasSuffix(prefix, "/") {
+			prefix += "/"
+		}
+		client, err := newS3Client()
+		if err != nil {
+			return nil, err
+		}
+		maxRecords, _ := flags.GetInt("batch-size")
+		maxAge, _ := flags.GetDuration("batch-interval")
+		return sink.NewBatch(sink.BatchOptions{
+			Uploader:   &sink.S3{Client: client, Bucket: bucket, Prefix: prefix},
+			MaxRecords: maxRecords,
+			MaxAge:     maxAge,
+		})
+	}
+	return nil, fmt.Errorf("invalid sink %q: it must be file:<path> or s3://<bucket>/<prefix>", spec)
+}
+
+// closeSinks closes the sinks, and returns the first error.
+func closeSinks(sinks []sink.Sink) error {
+	var err error
+	for _, s := range sinks {
+		if closeErr := s.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
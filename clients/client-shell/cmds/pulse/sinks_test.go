@@ -0,0 +1,69 @@
+package pulse
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/streadway/amqp"
+	assert "github.com/stretchr/testify/require"
+)
+
+// fakeS3 records the keys of the objects put, and their number of lines.
+type fakeS3 struct {
+	s3iface.S3API
+	objects map[string]int
+}
+
+func (f *fakeS3) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	body, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.objects[aws.StringValue(input.Bucket)+" "+aws.StringValue(input.Key)[:len("events/")]] += strings.Count(string(body), "\n")
+	return &s3.PutObjectOutput{}, nil
+}
+
+func TestListenSinks(t *testing.T) {
+	assert := assert.New(t)
+	fake := &fakePulse{sessions: [][]amqp.Delivery{{
+		{Exchange: "exchange/e", RoutingKey: "a", Body: []byte(`{"runId": 0}`)},
+		{Exchange: "exchange/e", RoutingKey: "b", Body: []byte(`{"runId": 1}`)},
+		{Exchange: "exchange/e", RoutingKey: "c", Body: []byte(`{"runId": 2}`)},
+	}}}
+	defer fake.install()()
+	client := &fakeS3{objects: map[string]int{}}
+	oldNewS3Client := newS3Client
+	newS3Client = func() (s3iface.S3API, error) { return client, nil }
+	defer func() { newS3Client = oldNewS3Client }()
+	dir, err := ioutil.TempDir("", "sinks")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.jsonl")
+
+	assert.NoError(runListen(nil, nil, &bytes.Buffer{}, listenFlags(t, "--binding", "exchange/e", "--count", "3",
+		"--sink", "file:"+path, "--sink", "s3://archive/events", "--batch-size", "2")))
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(lines, 3)
+	assert.Regexp(`^{"exchange":"exchange/e","routingKey":"a","redelivered":false,"received":"[^"]+","payload":{"runId":0}}$`, lines[0])
+	// a batch of 2 messages, and the last one once closed
+	assert.Equal(map[string]int{"archive events/": 3}, client.objects)
+}
+
+func TestOpenSinksErrors(t *testing.T) {
+	assert := assert.New(t)
+	for _, spec := range []string{"events.jsonl", "file:", "s3://", "gs://bucket"} {
+		_, err := openSinks(listenFlags(t, "--sink", spec))
+		assert.EqualError(err, `invalid sink "`+spec+`": it must be file:<path> or s3://<bucket>/<prefix>`)
+	}
+	_, err := openSinks(listenFlags(t, "--sink", "file:"+filepath.Join("missing", "dir", "events.jsonl")))
+	assert.Error(err)
+}
@@ -0,0 +1,96 @@
+package pulse
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/streadway/amqp"
+	pulsego "github.com/taskcluster/pulse-go/pulse"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueueevents"
+)
+
+// TaskBindings returns the bindings to the messages of the queue about the
+// state transitions of the given task, or of the tasks of the given group.
+func TaskBindings(taskID, taskGroupID string) []pulsego.Binding {
+	return []pulsego.Binding{
+		tcqueueevents.TaskDefined{RoutingKeyKind: "primary", TaskID: taskID, TaskGroupID: taskGroupID},
+		tcqueueevents.TaskPending{RoutingKeyKind: "primary", TaskID: taskID, TaskGroupID: taskGroupID},
+		tcqueueevents.TaskRunning{RoutingKeyKind: "primary", TaskID: taskID, TaskGroupID: taskGroupID},
+		tcqueueevents.TaskCompleted{RoutingKeyKind: "primary", TaskID: taskID, TaskGroupID: taskGroupID},
+		tcqueueevents.TaskFailed{RoutingKeyKind: "primary", TaskID: taskID, TaskGroupID: taskGroupID},
+		tcqueueevents.TaskException{RoutingKeyKind: "primary", TaskID: taskID, TaskGroupID: taskGroupID},
+	}
+}
+
+// A TaskEvent is a state transition of a task, from a message of the queue.
+type TaskEvent struct {
+	// Event is the name of the exchange, without its task- prefix, e.g.
+	// pending or exception.
+	Event  string
+	Status tcqueueevents.TaskStatusStructure
+	// RunID is the run the event is about, or -1 for task-defined.
+	RunID       int64
+	WorkerGroup string
+	WorkerID    string
+}
+
+// ParseTaskEvent decodes a message of the exchanges of TaskBindings.
+func ParseTaskEvent(d amqp.Delivery) (*TaskEvent, error) {
+	var payload struct {
+		Status      tcqueueevents.TaskStatusStructure `json:"status"`
+		RunID       *int64                            `json:"runId"`
+		WorkerGroup string                            `json:"workerGroup"`
+		WorkerID    string                            `json:"workerId"`
+	}
+	if err := json.Unmarshal(d.Body, &payload); err != nil {
+		return nil, fmt.Errorf("could not decode the message of %s: %w", d.Exchange, err)
+	}
+	e := &TaskEvent{
+		Event:       strings.TrimPrefix(path.Base(d.Exchange), "task-"),
+		Status:      payload.Status,
+		RunID:       -1,
+		WorkerGroup: payload.WorkerGroup,
+		WorkerID:    payload.WorkerID,
+	}
+	if payload.RunID != nil {
+		e.RunID = *payload.RunID
+	}
+	return e, nil
+}
+
+// Resolved returns whether the task is resolved after the event; it is not
+// after an exception which is retried.
+func (e *TaskEvent) Resolved() bool {
+	switch e.Status.State {
+	case "completed", "failed", "exception":
+		return true
+	}
+	return false
+}
+
+// String formats the event on a line, e.g.
+// "12:00:00 fN1SbArXTPSVFNUvaOlinQ exception run 0 on wg/wid: worker-shutdown".
+func (e *TaskEvent) String() string {
+	s := fmt.Sprintf("%s %s %s", time.Now().UTC().Format("15:04:05"), e.Status.TaskID, e.Event)
+	if e.RunID < 0 {
+		return s
+	}
+	s += fmt.Sprintf(" run %d", e.RunID)
+	if e.WorkerGroup != "" || e.WorkerID != "" {
+		s += fmt.Sprintf(" on %s/%s", e.WorkerGroup, e.WorkerID)
+	}
+	if e.Event == "exception" {
+		for _, run := range e.Status.Runs {
+			if run.RunID == e.RunID && run.ReasonResolved != "" {
+				s += ": " + run.ReasonResolved
+			}
+		}
+		if !e.Resolved() {
+			s += " (retried)"
+		}
+	}
+	return s
+}
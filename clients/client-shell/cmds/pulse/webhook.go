@@ -0,0 +1,114 @@
+package pulse
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/streadway/amqp"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/pulse/webhook"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
+)
+
+func init() {
+	webhookCmd := &cobra.Command{
+		Use:   "webhook --binding <exchange> --url <endpoint>",
+		Short: "POST the messages published on Pulse exchanges to HTTP endpoints.",
+		Long: `POST the JSON payload of the messages published on Pulse exchanges to HTTP
+endpoints as they arrive, e.g.
+
+  taskcluster pulse webhook --binding exchange/taskcluster-queue/v1/task-failed \
+    --filter 'payload.status.workerType == "ci"' --url https://ci.example.com/hooks/taskcluster
+
+--binding, --routing-key and --filter select the messages as with pulse
+listen; --url can be repeated to deliver each message to several endpoints.
+
+The exchange and routing key of a message are sent in the X-Pulse-Exchange
+and X-Pulse-Routing-Key headers. With --secret, or $PULSE_WEBHOOK_SECRET,
+the X-Pulse-Signature header is sha256=<hex>, the HMAC-SHA256 of the body
+with the secret.
+
+Requests are retried with backoff when the endpoint cannot be reached, or
+responds with a 429 or 5xx status, up to --attempts times; each attempt is
+logged on a line. Messages which cannot be delivered are logged and
+dropped.
+
+With --queue, the messages published while the command is not running are
+kept in a durable queue, and forwarded once it runs again.`,
+		RunE: root.ExecuteHelperE(runWebhook, 0, 0),
+	}
+	addWebhookFlags(webhookCmd.Flags())
+
+	Command.AddCommand(webhookCmd)
+}
+
+func addWebhookFlags(flags *pflag.FlagSet) {
+	addSelectionFlags(flags)
+	flags.StringArray("url", nil, "(can be repeated) Endpoint to POST the messages to.")
+	flags.String("secret", "", "Key of the signatures of the requests (default: $PULSE_WEBHOOK_SECRET).")
+	flags.Int("attempts", 3, "How many times a request is made before giving up on an endpoint.")
+	flags.Duration("request-timeout", 30*time.Second, "How long a request can take.")
+	flags.String("metrics-address", "", "Serve Prometheus metrics at /metrics on this address, e.g. :9090.")
+	AddQueueFlags(flags)
+	AddConnectionFlags(flags)
+}
+
+// webhookBackoff is the delay before retrying a request; it is replaced in
+// tests.
+var webhookBackoff = time.Second
+
+// runWebhook forwards the messages received on the --binding exchanges to
+// the --url endpoints.
+func runWebhook(_ *tcclient.Credentials, _ []string, out io.Writer, flags *pflag.FlagSet) error {
+	bindings, match, err := selection(flags)
+	if err != nil {
+		return err
+	}
+	endpoints, _ := flags.GetStringArray("url")
+	secret, _ := flags.GetString("secret")
+	if secret == "" {
+		secret = os.Getenv("PULSE_WEBHOOK_SECRET")
+	}
+	attempts, _ := flags.GetInt("attempts")
+	if attempts < 1 {
+		return fmt.Errorf("--attempts must be at least 1")
+	}
+	timeout, _ := flags.GetDuration("request-timeout")
+	metricsAddress, _ := flags.GetString("metrics-address")
+	queue, queueOpts, err := queueFlags(flags)
+	if err != nil {
+		return err
+	}
+
+	f, err := webhook.New(webhook.Options{
+		Endpoints: endpoints,
+		Secret:    secret,
+		Attempts:  attempts,
+		Backoff:   webhookBackoff,
+		Timeout:   timeout,
+		Log: func(a webhook.Attempt) {
+			fmt.Fprintf(out, "%s %s\n", time.Now().UTC().Format("15:04:05"), a)
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return Consume(flags, Subscription{
+		Bindings:       bindings,
+		Filter:         match,
+		MetricsAddress: metricsAddress,
+		QueueName:      queue,
+		Queue:          queueOpts,
+		Handle: func(d amqp.Delivery) (bool, error) {
+			// the attempts are logged, and messages are consumed with
+			// AutoAck, so those which are not delivered are dropped
+			_ = f.Forward(d)
+			return false, nil
+		},
+	})
+}
@@ -0,0 +1,75 @@
+package pulse
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/streadway/amqp"
+	assert "github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/pulse/webhook"
+)
+
+func webhookFlags(t *testing.T, args ...string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("webhook", pflag.ContinueOnError)
+	addWebhookFlags(flags)
+	assert.NoError(t, flags.Parse(args))
+	return flags
+}
+
+func TestWebhook(t *testing.T) {
+	assert := assert.New(t)
+	fake := &fakePulse{closed: true, sessions: [][]amqp.Delivery{{
+		{Exchange: "exchange/e", RoutingKey: "a", Body: []byte(`{"runId": 0}`)},
+		{Exchange: "exchange/e", RoutingKey: "b", Body: []byte(`{"runId": 1}`)},
+		{Exchange: "exchange/e", RoutingKey: "c", Body: []byte(`{"runId": 2}`)},
+	}}}
+	defer fake.install()()
+	oldBackoff := webhookBackoff
+	webhookBackoff = time.Millisecond
+	defer func() { webhookBackoff = oldBackoff }()
+	os.Setenv("PULSE_WEBHOOK_SECRET", "s3cr3t")
+	defer os.Unsetenv("PULSE_WEBHOOK_SECRET")
+
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if !webhook.Verify("s3cr3t", body, r.Header.Get(webhook.SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("X-Pulse-Routing-Key") == "c" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		bodies = append(bodies, string(body))
+	}))
+	defer server.Close()
+
+	out := &bytes.Buffer{}
+	assert.NoError(runWebhook(nil, nil, out, webhookFlags(t, "--binding", "exchange/e", "--filter", "payload.runId > 0", "--url", server.URL, "--attempts", "2")))
+	assert.Equal([]string{`{"runId": 1}`}, bodies)
+	assert.Equal([]string{"exchange/e #"}, fake.bindings)
+	assert.Regexp(`^\d\d:\d\d:\d\d exchange/e b -> `+server.URL+` \(attempt 1, [^)]+\): delivered
+\d\d:\d\d:\d\d exchange/e c -> `+server.URL+` \(attempt 1, [^)]+\): the endpoint responded with 502 Bad Gateway
+\d\d:\d\d:\d\d exchange/e c -> `+server.URL+` \(attempt 2, [^)]+\): the endpoint responded with 502 Bad Gateway
+$`, out.String())
+}
+
+func TestWebhookErrors(t *testing.T) {
+	assert := assert.New(t)
+	fake := &fakePulse{closed: true}
+	defer fake.install()()
+
+	err := runWebhook(nil, nil, &bytes.Buffer{}, webhookFlags(t, "--binding", "exchange/e"))
+	assert.EqualError(err, "at least one endpoint is required")
+	err = runWebhook(nil, nil, &bytes.Buffer{}, webhookFlags(t, "--binding", "exchange/e", "--url", "https://example.com", "--attempts", "0"))
+	assert.EqualError(err, "--attempts must be at least 1")
+	err = runWebhook(nil, nil, &bytes.Buffer{}, webhookFlags(t, "--url", "https://example.com"))
+	assert.EqualError(err, "at least one exchange must be given with --binding")
+}
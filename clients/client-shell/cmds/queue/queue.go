@@ -0,0 +1,183 @@
+// Package queue implements the queue subcommands.
+package queue
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+var (
+	// Command is the root of the queue subtree.
+	Command = &cobra.Command{
+		Use:   "queue",
+		Short: "Provides queue-wide actions, such as inspecting pending tasks.",
+	}
+
+	// sleep waits between polls; it is replaced in tests.
+	sleep = time.Sleep
+)
+
+func init() {
+	pendingCmd := &cobra.Command{
+		Use:   "pending [<provisionerId>/<workerType>]",
+		Short: "Show the number of pending tasks of a worker type, or with --all of every worker type.",
+		Long: `Show the number of pending tasks of a worker type, or with --all of every
+worker type known to the queue.
+
+With --watch, the counts are polled every --interval and printed along with
+their change since the previous poll.`,
+		RunE: runPendingE,
+	}
+	pendingCmd.Flags().BoolP("all", "a", false, "Show every worker type of every provisioner.")
+	pendingCmd.Flags().BoolP("watch", "w", false, "Keep polling, and show the changes of the counts.")
+	pendingCmd.Flags().DurationP("interval", "i", 30*time.Second, "Time to wait between polls with --watch.")
+	pendingCmd.Flags().IntP("count", "n", 0, "Number of polls with --watch (0 for no limit).")
+
+	Command.AddCommand(pendingCmd)
+	root.Command.AddCommand(Command)
+}
+
+func makeQueue(credentials *tcclient.Credentials) *tcqueue.Queue {
+	return tcqueue.New(credentials, config.RootURL())
+}
+
+func runPendingE(cmd *cobra.Command, args []string) error {
+	var creds *tcclient.Credentials
+	if config.Credentials != nil {
+		creds = config.Credentials.ToClientCredentials()
+	}
+
+	all, _ := cmd.Flags().GetBool("all")
+	if all && len(args) != 0 || !all && len(args) != 1 {
+		return fmt.Errorf("%s expects either argument <provisionerId>/<workerType> or --all", cmd.Name())
+	}
+	return runPending(creds, args, cmd.OutOrStdout(), cmd.Flags())
+}
+
+// workerType identifies a worker type by its provisioner.
+type workerType struct {
+	ProvisionerID string
+	WorkerType    string
+}
+
+func (wt workerType) String() string {
+	return wt.ProvisionerID + "/" + wt.WorkerType
+}
+
+// runPending prints the pending counts of the worker types given as argument
+// or with --all, once or repeatedly with --watch.
+func runPending(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	q := makeQueue(credentials)
+	watch, _ := flags.GetBool("watch")
+	interval, _ := flags.GetDuration("interval")
+	count, _ := flags.GetInt("count")
+
+	var workerTypes []workerType
+	if len(args) == 1 {
+		parts := strings.Split(args[0], "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid worker type %q, expected <provisionerId>/<workerType>", args[0])
+		}
+		workerTypes = []workerType{{parts[0], parts[1]}}
+	} else {
+		var err error
+		if workerTypes, err = allWorkerTypes(q); err != nil {
+			return err
+		}
+	}
+
+	var previous map[workerType]int64
+	for poll := 1; ; poll++ {
+		counts := make(map[workerType]int64, len(workerTypes))
+		for _, wt := range workerTypes {
+			resp, err := q.PendingTasks(wt.ProvisionerID, wt.WorkerType)
+			if err != nil {
+				return fmt.Errorf("could not count the pending tasks of %s: %w", wt, err)
+			}
+			counts[wt] = resp.PendingTasks
+		}
+
+		if watch {
+			fmt.Fprintln(out, time.Now().UTC().Format("15:04:05"))
+		}
+		if err := writeCounts(out, workerTypes, counts, previous); err != nil {
+			return err
+		}
+
+		if !watch || count > 0 && poll >= count {
+			return nil
+		}
+		previous = counts
+		sleep(interval)
+	}
+}
+
+// allWorkerTypes lists the worker types of all provisioners, sorted.
+func allWorkerTypes(q *tcqueue.Queue) ([]workerType, error) {
+	var provisioners []string
+	continuation := ""
+	for {
+		resp, err := q.ListProvisioners(continuation, "")
+		if err != nil {
+			return nil, fmt.Errorf("could not list provisioners: %w", err)
+		}
+		for _, p := range resp.Provisioners {
+			provisioners = append(provisioners, p.ProvisionerID)
+		}
+		if continuation = resp.ContinuationToken; continuation == "" {
+			break
+		}
+	}
+
+	var workerTypes []workerType
+	for _, provisionerID := range provisioners {
+		continuation := ""
+		for {
+			resp, err := q.ListWorkerTypes(provisionerID, continuation, "")
+			if err != nil {
+				return nil, fmt.Errorf("could not list the worker types of %s: %w", provisionerID, err)
+			}
+			for _, wt := range resp.WorkerTypes {
+				workerTypes = append(workerTypes, workerType{provisionerID, wt.WorkerType})
+			}
+			if continuation = resp.ContinuationToken; continuation == "" {
+				break
+			}
+		}
+	}
+
+	sort.Slice(workerTypes, func(i, j int) bool {
+		return workerTypes[i].String() < workerTypes[j].String()
+	})
+	return workerTypes, nil
+}
+
+// writeCounts writes a table of the pending counts, with their change since
+// the previous counts if there are any.
+func writeCounts(out io.Writer, workerTypes []workerType, counts, previous map[workerType]int64) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	if previous == nil {
+		fmt.Fprintln(w, "WORKER TYPE\tPENDING")
+	} else {
+		fmt.Fprintln(w, "WORKER TYPE\tPENDING\tCHANGE")
+	}
+	for _, wt := range workerTypes {
+		if previous == nil {
+			fmt.Fprintf(w, "%s\t%d\n", wt, counts[wt])
+		} else {
+			fmt.Fprintf(w, "%s\t%d\t%+d\n", wt, counts[wt], counts[wt]-previous[wt])
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,120 @@
+package queue
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/suite"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+type FakeServerSuite struct {
+	suite.Suite
+	testServer *httptest.Server
+	// polls counts the pending requests of proj-app/ci
+	polls int
+}
+
+func (suite *FakeServerSuite) SetupSuite() {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/queue/v1/provisioners", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("continuationToken") == "" {
+			_, _ = io.WriteString(w, `{"provisioners": [{"provisionerId": "proj-app"}], "continuationToken": "next"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"provisioners": [{"provisionerId": "aws"}]}`)
+	})
+	handler.HandleFunc("/api/queue/v1/provisioners/proj-app/worker-types", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"workerTypes": [{"provisionerId": "proj-app", "workerType": "ci"}, {"provisionerId": "proj-app", "workerType": "build"}]}`)
+	})
+	handler.HandleFunc("/api/queue/v1/provisioners/aws/worker-types", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"workerTypes": [{"provisionerId": "aws", "workerType": "gpu"}]}`)
+	})
+	handler.HandleFunc("/api/queue/v1/pending/proj-app/ci", func(w http.ResponseWriter, _ *http.Request) {
+		suite.polls++
+		fmt.Fprintf(w, `{"provisionerId": "proj-app", "workerType": "ci", "pendingTasks": %d}`, 10*suite.polls)
+	})
+	handler.HandleFunc("/api/queue/v1/pending/proj-app/build", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"provisionerId": "proj-app", "workerType": "build", "pendingTasks": 3}`)
+	})
+	handler.HandleFunc("/api/queue/v1/pending/aws/gpu", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"provisionerId": "aws", "workerType": "gpu", "pendingTasks": 0}`)
+	})
+
+	suite.testServer = httptest.NewServer(handler)
+	config.SetRootURL(suite.testServer.URL)
+	sleep = func(time.Duration) {}
+}
+
+func (suite *FakeServerSuite) SetupTest() {
+	suite.polls = 0
+}
+
+func (suite *FakeServerSuite) TearDownSuite() {
+	suite.testServer.Close()
+	config.SetRootURL("")
+	sleep = time.Sleep
+}
+
+func TestFakeServerSuite(t *testing.T) {
+	suite.Run(t, new(FakeServerSuite))
+}
+
+func pendingFlags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("pending", pflag.ContinueOnError)
+	flags.Bool("all", false, "")
+	flags.Bool("watch", false, "")
+	flags.Duration("interval", time.Second, "")
+	flags.Int("count", 0, "")
+	return flags
+}
+
+func (suite *FakeServerSuite) TestPending() {
+	buf := &bytes.Buffer{}
+	err := runPending(&tcclient.Credentials{}, []string{"proj-app/ci"}, buf, pendingFlags())
+	suite.NoError(err)
+	suite.Equal("WORKER TYPE  PENDING\nproj-app/ci  10\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestPendingInvalid() {
+	err := runPending(&tcclient.Credentials{}, []string{"proj-app"}, &bytes.Buffer{}, pendingFlags())
+	suite.EqualError(err, `invalid worker type "proj-app", expected <provisionerId>/<workerType>`)
+}
+
+func (suite *FakeServerSuite) TestPendingAll() {
+	flags := pendingFlags()
+	suite.NoError(flags.Set("all", "true"))
+
+	buf := &bytes.Buffer{}
+	err := runPending(&tcclient.Credentials{}, nil, buf, flags)
+	suite.NoError(err)
+	suite.Equal("WORKER TYPE     PENDING\n"+
+		"aws/gpu         0\n"+
+		"proj-app/build  3\n"+
+		"proj-app/ci     10\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestPendingWatch() {
+	flags := pendingFlags()
+	suite.NoError(flags.Set("watch", "true"))
+	suite.NoError(flags.Set("count", "3"))
+
+	buf := &bytes.Buffer{}
+	err := runPending(&tcclient.Credentials{}, []string{"proj-app/ci"}, buf, flags)
+	suite.NoError(err)
+	suite.Equal(3, suite.polls)
+
+	timestamps := regexp.MustCompile(`(?m)^\d\d:\d\d:\d\d$`)
+	suite.Equal("T\nWORKER TYPE  PENDING\nproj-app/ci  10\n"+
+		"T\nWORKER TYPE  PENDING  CHANGE\nproj-app/ci  20       +10\n"+
+		"T\nWORKER TYPE  PENDING  CHANGE\nproj-app/ci  30       +10\n",
+		timestamps.ReplaceAllString(buf.String(), "T"))
+}
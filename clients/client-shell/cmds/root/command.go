@@ -1,7 +1,20 @@
 // Package root defines the root of the application command tree.
 package root
 
-import "github.com/spf13/cobra"
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/client"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+// ManagesProfile is the annotation of commands which handle --profile
+// themselves, rather than running with the credentials of the profile.
+const ManagesProfile = "managesProfile"
 
 var (
 	// Command is the root of the command tree.
@@ -9,5 +22,193 @@ var (
 		Use:   "taskcluster",
 		Short: "Taskcluster Shell client.",
 		Long:  "A shell interface to Taskcluster",
+		// Errors returned by subcommands are reported on stderr by
+		// ReportError and result in a non-zero exit code; the usage would
+		// only obscure them.
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		PersistentPreRunE: configure,
 	}
 )
+
+func init() {
+	Command.PersistentFlags().String("root-url", "", "Root URL of the Taskcluster deployment, from which the URLs of all services are derived (default: $TASKCLUSTER_ROOT_URL, or the rootUrl config option).")
+	Command.PersistentFlags().String("env", "", "Use the root URL and profile of the named environment of cli.yml (default: $TASKCLUSTER_ENV, or the environment of cli.yml).")
+	Command.PersistentFlags().String("profile", "", "Use the root URL and credentials of the named profile, as saved by `taskcluster signin --profile` (default: $TASKCLUSTER_PROFILE).")
+	Command.PersistentFlags().String("ca-cert", "", "File of PEM certificates of CAs to trust in addition to the system ones, e.g. for deployments with a private CA.")
+	Command.PersistentFlags().Bool("insecure-skip-verify", false, "Do not verify TLS certificates. This is insecure, and only meant for testing.")
+	Command.PersistentFlags().BoolP("debug", "v", false, "Log the HTTP requests made, and their responses, to stderr; credentials and signatures are redacted.")
+	Command.PersistentFlags().Bool("profile-cli", false, "Record the latency of the HTTP requests made, and print a breakdown by API endpoint to stderr when the command ends; nothing is sent anywhere.")
+	Command.PersistentFlags().Duration("cache-ttl", 0, "Reuse the responses of read-only API calls, such as task definitions, task group and index listings, for this long, e.g. 10m (default: $TASKCLUSTER_CACHE_TTL, or 0, disabling the cache).")
+	Command.PersistentFlags().Bool("no-cache", false, "Do not use cached responses, regardless of --cache-ttl.")
+	Command.PersistentFlags().String("record", "", "Record the HTTP requests made, and their responses, to this JSON file, e.g. to replay them in tests with --replay; credentials, signatures and secrets are redacted.")
+	Command.PersistentFlags().String("replay", "", "Serve the responses recorded by --record to this JSON file instead of making HTTP requests.")
+	Command.PersistentFlags().Bool("quiet", false, "Do not write progress output to stderr; results, warnings and errors are still written.")
+	Command.PersistentFlags().Int("retries", client.DefaultRetries, "Number of times API calls failing with network errors, 5xx or 429 responses are retried, with exponential backoff.")
+}
+
+// Profile returns the name of the profile selected with --profile or
+// TASKCLUSTER_PROFILE, if any.
+func Profile(cmd *cobra.Command) string {
+	if profile, _ := cmd.Flags().GetString("profile"); profile != "" {
+		return profile
+	}
+	return os.Getenv("TASKCLUSTER_PROFILE")
+}
+
+// Environment returns the name of the environment selected with --env,
+// TASKCLUSTER_ENV or cli.yml, if any.
+func Environment(cmd *cobra.Command) string {
+	if env, _ := cmd.Flags().GetString("env"); env != "" {
+		return env
+	}
+	if env := os.Getenv("TASKCLUSTER_ENV"); env != "" {
+		return env
+	}
+	if config.CLI != nil {
+		return config.CLI.Environment
+	}
+	return ""
+}
+
+// configure applies the global flags before running a command: it applies
+// the default flags of cli.yml, loads the root URL and profile of the
+// selected environment and the credentials of the selected profile, if any,
+// and then the root URL given by --root-url, which takes precedence over
+// those, and sets up the HTTP transport, the response cache, the timings of
+// requests, their recording or replaying, the retries of API calls and
+// progress output.
+func configure(cmd *cobra.Command, _ []string) error {
+	if err := applyDefaults(cmd); err != nil {
+		return err
+	}
+	if env := Environment(cmd); env != "" && cmd.Annotations[ManagesProfile] == "" {
+		if config.CLI == nil {
+			return fmt.Errorf("no environment named %q", env)
+		}
+		if err := config.CLI.UseEnvironment(env); err != nil {
+			return err
+		}
+	}
+	if profile := Profile(cmd); profile != "" && cmd.Annotations[ManagesProfile] == "" {
+		if err := config.UseProfile(profile); err != nil {
+			return err
+		}
+	}
+	if rootURL, _ := cmd.Flags().GetString("root-url"); rootURL != "" {
+		config.SetRootURL(strings.TrimRight(rootURL, "/"))
+	}
+	caCert, _ := cmd.Flags().GetString("ca-cert")
+	insecure, _ := cmd.Flags().GetBool("insecure-skip-verify")
+	if insecure {
+		fmt.Fprintln(cmd.ErrOrStderr(), "WARNING: --insecure-skip-verify disables the verification of TLS certificates; "+
+			"connections to Taskcluster can be intercepted, and credentials stolen. Only use it for testing.")
+	}
+	client.Quiet, _ = cmd.Flags().GetBool("quiet")
+	client.Debug = nil
+	if debug, _ := cmd.Flags().GetBool("debug"); debug {
+		client.Debug = cmd.ErrOrStderr()
+	}
+	client.Timings = nil
+	if profile, _ := cmd.Flags().GetBool("profile-cli"); profile {
+		client.Timings = client.NewRecorder()
+	}
+	ttl, err := cacheTTL(cmd)
+	if err != nil {
+		return err
+	}
+	client.CacheTTL = ttl
+	client.RecordFile, _ = cmd.Flags().GetString("record")
+	client.ReplayFile, _ = cmd.Flags().GetString("replay")
+	if err := client.ConfigureTransport(caCert, insecure); err != nil {
+		return err
+	}
+	// commands with a --retries flag of their own shadow the global one
+	retries, err := cmd.Root().PersistentFlags().GetInt("retries")
+	if err != nil {
+		retries = client.DefaultRetries
+	}
+	if retries < 0 {
+		return fmt.Errorf("--retries must not be negative, got %d", retries)
+	}
+	client.UseRetries(retries)
+	return nil
+}
+
+// ReportTimings writes the latency of the HTTP requests of the last command
+// to stderr, if it was run with --profile-cli, and forgets them.
+func ReportTimings() {
+	if client.Timings != nil {
+		client.Timings.WriteReport(Command.ErrOrStderr())
+		client.Timings = nil
+	}
+}
+
+// cacheTTL returns how long responses are cached for, as given by
+// --cache-ttl or TASKCLUSTER_CACHE_TTL, unless --no-cache is given.
+func cacheTTL(cmd *cobra.Command) (time.Duration, error) {
+	if noCache, _ := cmd.Flags().GetBool("no-cache"); noCache {
+		return 0, nil
+	}
+	if flag := cmd.Flags().Lookup("cache-ttl"); flag != nil && flag.Changed {
+		return cmd.Flags().GetDuration("cache-ttl")
+	}
+	value := os.Getenv("TASKCLUSTER_CACHE_TTL")
+	if value == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid TASKCLUSTER_CACHE_TTL: %w", err)
+	}
+	return ttl, nil
+}
+
+// applyDefaults sets the flags of cmd which were not given to their default
+// in cli.yml, if any. Defaults of parent commands for flags cmd does not
+// have are ignored.
+func applyDefaults(cmd *cobra.Command) error {
+	if config.CLI == nil {
+		return nil
+	}
+	path := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name())
+	defaults, exact := config.CLI.DefaultFlags(path)
+	for name, values := range defaults {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			if exact[name] {
+				return fmt.Errorf("invalid default in cli.yml: %s has no flag --%s", cmd.CommandPath(), name)
+			}
+			continue
+		}
+		if flag.Changed {
+			continue
+		}
+		for _, value := range values {
+			if err := cmd.Flags().Set(name, value); err != nil {
+				return fmt.Errorf("invalid default in cli.yml for --%s of %s: %w", name, cmd.CommandPath(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// ExpandAlias replaces the first of args with the arguments of the alias of
+// that name in cli.yml, if any, unless it is the name of a command.
+func ExpandAlias(args []string) []string {
+	if config.CLI == nil || len(args) == 0 {
+		return args
+	}
+	alias, ok := config.CLI.Aliases[args[0]]
+	if !ok {
+		return args
+	}
+	for _, cmd := range Command.Commands() {
+		for _, name := range append([]string{cmd.Name()}, cmd.Aliases...) {
+			if name == args[0] {
+				return args
+			}
+		}
+	}
+	return append(append([]string{}, alias...), args[1:]...)
+}
@@ -0,0 +1,182 @@
+package root
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	assert "github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/client"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+func TestRootURLFlag(t *testing.T) {
+	assert := assert.New(t)
+	defer config.SetRootURL("")
+
+	config.SetRootURL("https://tc.example.com")
+	cmd := &cobra.Command{}
+	cmd.Flags().String("root-url", "", "")
+	cmd.Flags().String("profile", "", "")
+
+	assert.NoError(configure(cmd, nil))
+	assert.Equal("https://tc.example.com", config.RootURL(), "root URL should be unchanged without --root-url")
+
+	assert.NoError(cmd.Flags().Set("root-url", "https://staging.example.com/"))
+	assert.NoError(configure(cmd, nil))
+	assert.Equal("https://staging.example.com", config.RootURL())
+}
+
+func TestRetriesFlag(t *testing.T) {
+	assert := assert.New(t)
+	defer client.UseRetries(client.DefaultRetries)
+
+	cmd := &cobra.Command{}
+	cmd.PersistentFlags().Int("retries", client.DefaultRetries, "")
+
+	assert.NoError(configure(cmd, nil))
+	assert.Equal(client.DefaultRetries, client.Retries)
+
+	assert.NoError(cmd.PersistentFlags().Set("retries", "2"))
+	assert.NoError(configure(cmd, nil))
+	assert.Equal(2, client.Retries)
+
+	assert.NoError(cmd.PersistentFlags().Set("retries", "-1"))
+	assert.Error(configure(cmd, nil), "negative retries should be rejected")
+}
+
+func TestTLSFlags(t *testing.T) {
+	assert := assert.New(t)
+	defer client.ConfigureTransport("", false)
+
+	buf := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOutput(buf)
+	cmd.Flags().String("ca-cert", "", "")
+	cmd.Flags().Bool("insecure-skip-verify", false, "")
+
+	assert.NoError(cmd.Flags().Set("ca-cert", "/does/not/exist.pem"))
+	assert.Error(configure(cmd, nil), "a missing CA file should be reported")
+	assert.Empty(buf.String())
+
+	assert.NoError(cmd.Flags().Set("ca-cert", ""))
+	assert.NoError(cmd.Flags().Set("insecure-skip-verify", "true"))
+	assert.NoError(configure(cmd, nil))
+	assert.Contains(buf.String(), "WARNING: --insecure-skip-verify")
+}
+
+func TestCacheFlags(t *testing.T) {
+	assert := assert.New(t)
+	defer os.Unsetenv("TASKCLUSTER_CACHE_TTL")
+
+	cmd := &cobra.Command{}
+	cmd.Flags().Duration("cache-ttl", 0, "")
+	cmd.Flags().Bool("no-cache", false, "")
+
+	ttl, err := cacheTTL(cmd)
+	assert.NoError(err)
+	assert.Equal(time.Duration(0), ttl, "the cache should be disabled by default")
+
+	os.Setenv("TASKCLUSTER_CACHE_TTL", "5m")
+	ttl, err = cacheTTL(cmd)
+	assert.NoError(err)
+	assert.Equal(5*time.Minute, ttl)
+
+	assert.NoError(cmd.Flags().Set("cache-ttl", "1h"))
+	ttl, err = cacheTTL(cmd)
+	assert.NoError(err)
+	assert.Equal(time.Hour, ttl, "--cache-ttl should take precedence over the environment")
+
+	assert.NoError(cmd.Flags().Set("no-cache", "true"))
+	ttl, err = cacheTTL(cmd)
+	assert.NoError(err)
+	assert.Equal(time.Duration(0), ttl)
+
+	os.Setenv("TASKCLUSTER_CACHE_TTL", "soon")
+	cmd = &cobra.Command{}
+	_, err = cacheTTL(cmd)
+	assert.Error(err)
+}
+
+func TestApplyDefaults(t *testing.T) {
+	assert := assert.New(t)
+	defer func(cli *config.CLIConfig) { config.CLI = cli }(config.CLI)
+	config.CLI = &config.CLIConfig{Defaults: map[string]map[string]config.FlagValue{
+		"group":      {"output": {"json"}, "limit": {"5"}},
+		"group list": {"set": {"a", "b"}},
+	}}
+
+	newList := func() *cobra.Command {
+		parent := &cobra.Command{Use: "taskcluster"}
+		group := &cobra.Command{Use: "group"}
+		list := &cobra.Command{Use: "list"}
+		parent.AddCommand(group)
+		group.AddCommand(list)
+		list.Flags().String("output", "text", "")
+		list.Flags().StringArray("set", nil, "")
+		return list
+	}
+
+	list := newList()
+	assert.NoError(applyDefaults(list))
+	output, _ := list.Flags().GetString("output")
+	assert.Equal("json", output)
+	sets, _ := list.Flags().GetStringArray("set")
+	assert.Equal([]string{"a", "b"}, sets)
+
+	list = newList()
+	assert.NoError(list.Flags().Set("output", "yaml"))
+	assert.NoError(applyDefaults(list))
+	output, _ = list.Flags().GetString("output")
+	assert.Equal("yaml", output, "given flags should take precedence over defaults")
+
+	config.CLI.Defaults["group list"]["limit"] = config.FlagValue{"5"}
+	assert.Error(applyDefaults(newList()), "a default for a missing flag of the command should be reported")
+}
+
+func TestExpandAlias(t *testing.T) {
+	assert := assert.New(t)
+	defer func(cli *config.CLIConfig) { config.CLI = cli }(config.CLI)
+	Command.AddCommand(&cobra.Command{Use: "aliased-test"})
+	config.CLI = &config.CLIConfig{Aliases: map[string]config.Args{
+		"follow":       {"task", "log", "--follow"},
+		"aliased-test": {"other"},
+	}}
+
+	assert.Equal([]string{"task", "log", "--follow", "abc"}, ExpandAlias([]string{"follow", "abc"}))
+	assert.Equal([]string{"aliased-test"}, ExpandAlias([]string{"aliased-test"}), "commands should not be shadowed by aliases")
+	assert.Equal([]string{"task", "def"}, ExpandAlias([]string{"task", "def"}))
+	assert.Empty(ExpandAlias(nil))
+}
+
+func TestEnvironmentFlag(t *testing.T) {
+	assert := assert.New(t)
+	defer config.SetRootURL("")
+	defer func(cli *config.CLIConfig) { config.CLI = cli }(config.CLI)
+	config.CLI = &config.CLIConfig{
+		Environments: map[string]config.Environment{
+			"staging":    {RootURL: "https://staging.example.com"},
+			"production": {RootURL: "https://tc.example.com"},
+		},
+		Environment: "production",
+	}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("env", "", "")
+	cmd.Flags().String("root-url", "", "")
+	assert.NoError(configure(cmd, nil))
+	assert.Equal("https://tc.example.com", config.RootURL(), "the environment of cli.yml should be the default")
+
+	assert.NoError(cmd.Flags().Set("env", "staging"))
+	assert.NoError(configure(cmd, nil))
+	assert.Equal("https://staging.example.com", config.RootURL())
+
+	assert.NoError(cmd.Flags().Set("root-url", "https://other.example.com"))
+	assert.NoError(configure(cmd, nil))
+	assert.Equal("https://other.example.com", config.RootURL(), "--root-url should take precedence over the environment")
+
+	assert.NoError(cmd.Flags().Set("env", "missing"))
+	assert.Error(configure(cmd, nil))
+}
@@ -0,0 +1,66 @@
+package root
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/client"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/formatter"
+)
+
+// Execute runs the command tree with the arguments of the process, once
+// aliases are expanded, and reports the error of the command run, if any,
+// with ReportError, and its timings with ReportTimings.
+func Execute() error {
+	Command.SetArgs(ExpandAlias(os.Args[1:]))
+	cmd, err := Command.ExecuteC()
+	ReportTimings()
+	if err != nil {
+		ReportError(cmd, err)
+	}
+	return err
+}
+
+// ReportError writes the error of cmd to its stderr: as a JSON client.Error
+// if cmd was run with `-o json`, so that wrappers can parse it, or as text.
+func ReportError(cmd *cobra.Command, err error) {
+	if format, _ := formatter.FromFlags(cmd.Flags()); format == formatter.JSON {
+		data, jsonErr := json.MarshalIndent(client.NewError(err), "", "  ")
+		if jsonErr == nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), string(data))
+			return
+		}
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Error: %v\n", err)
+}
+
+// ExitError is the error of a command which exits with a specific code,
+// rather than 1, such as the combined status of the tasks it waited for.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code of the process for the error returned by
+// Execute.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return 1
+}
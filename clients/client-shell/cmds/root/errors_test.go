@@ -0,0 +1,37 @@
+package root
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/cobra"
+	assert "github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/formatter"
+)
+
+func TestReportError(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOutput(buf)
+	formatter.RegisterFlag(cmd.Flags())
+
+	ReportError(cmd, errors.New("could not get task"))
+	assert.Equal("Error: could not get task\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(cmd.Flags().Set("output", "json"))
+	ReportError(cmd, errors.New("could not get task"))
+	assert.JSONEq(`{"message": "could not get task", "error": "could not get task"}`, buf.String())
+}
+
+func TestExitCode(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(0, ExitCode(nil))
+	assert.Equal(1, ExitCode(errors.New("could not get task")))
+	assert.Equal(3, ExitCode(fmt.Errorf("await: %w", &ExitError{Code: 3, Err: errors.New("timeout")})))
+}
@@ -0,0 +1,33 @@
+package root
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+// Executor represents the function interface of the subcommands: it is
+// given the configured credentials, if any, the arguments and flags of the
+// command, and the output to write its results to.
+type Executor func(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error
+
+// ExecuteHelperE returns the RunE of a subcommand, which checks that it got
+// between min and max arguments, or at least min if max is negative, and
+// runs f with the configured credentials.
+func ExecuteHelperE(f Executor, min, max int) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) < min || (max >= 0 && len(args) > max) {
+			return fmt.Errorf("wrong number of arguments; usage: %s", cmd.UseLine())
+		}
+
+		var creds *tcclient.Credentials
+		if config.Credentials != nil {
+			creds = config.Credentials.ToClientCredentials()
+		}
+		return f(creds, args, cmd.OutOrStdout(), cmd.Flags())
+	}
+}
@@ -0,0 +1,32 @@
+package root
+
+import (
+	"io"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	assert "github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+)
+
+func TestExecuteHelperE(t *testing.T) {
+	assert := assert.New(t)
+
+	var got []string
+	dummyExecutor := func(_ *tcclient.Credentials, args []string, _ io.Writer, _ *pflag.FlagSet) error {
+		got = args
+		return nil
+	}
+	cmd := &cobra.Command{Use: "show <provisionerId>/<workerType> [<workerId>]"}
+
+	runable := ExecuteHelperE(dummyExecutor, 1, 2)
+	assert.EqualError(runable(cmd, []string{}), "wrong number of arguments; usage: show <provisionerId>/<workerType> [<workerId>]")
+	assert.Error(runable(cmd, []string{"one", "two", "three"}))
+	assert.NoError(runable(cmd, []string{"one", "two"}))
+	assert.Equal([]string{"one", "two"}, got)
+
+	runable = ExecuteHelperE(dummyExecutor, 1, -1)
+	assert.Error(runable(cmd, []string{}), "at least one argument is expected")
+	assert.NoError(runable(cmd, []string{"one", "two", "three"}))
+}
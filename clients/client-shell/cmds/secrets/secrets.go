@@ -0,0 +1,208 @@
+// Package secrets implements the secrets subcommands.
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcsecrets"
+	fromNow "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/from-now"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+var (
+	// Command is the root of the secrets subtree.
+	Command = &cobra.Command{
+		Use:   "secrets",
+		Short: "Provides secret-related actions.",
+	}
+
+	// stdin is where set reads the secret from, unless --file is given.
+	stdin io.Reader = os.Stdin
+
+	// envName matches the keys of a secret that can be environment variables.
+	envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+func init() {
+	getCmd := &cobra.Command{
+		Use:   "get <name>",
+		Short: "Get the value of a secret.",
+		Long: `Get the value of a secret, as JSON, or with --format env as environment
+variables for use like this:
+
+$ eval ` + "`taskcluster secrets get --format env project/app/deploy`" + `
+
+The env format requires a secret whose keys are valid variable names; values
+which are not strings are exported as JSON.`,
+		RunE: root.ExecuteHelperE(runGet, 1, 1),
+	}
+	getCmd.Flags().StringP("format", "f", "json", "Output format, one of: json, env.")
+
+	setCmd := &cobra.Command{
+		Use:   "set <name>",
+		Short: "Set the value of a secret.",
+		Long: `Set the value of a secret to the JSON object read from stdin, or from the
+file given with --file. Secret values are never taken from the command line,
+where they would be visible to other users and kept in the shell history.`,
+		RunE: root.ExecuteHelperE(runSet, 1, 1),
+	}
+	setCmd.Flags().String("file", "", "File to read the secret from, instead of stdin.")
+	setCmd.Flags().String("expires", "1 year", "Lifetime of the secret, e.g. '1 year' or '30 days'.")
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the names of the secrets.",
+		RunE:  root.ExecuteHelperE(runList, 0, 0),
+	}
+
+	removeCmd := &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Remove a secret.",
+		RunE:  root.ExecuteHelperE(runRemove, 1, 1),
+	}
+
+	Command.AddCommand(getCmd, setCmd, listCmd, removeCmd)
+	root.Command.AddCommand(Command)
+}
+
+func makeSecrets(credentials *tcclient.Credentials) *tcsecrets.Secrets {
+	return tcsecrets.New(credentials, config.RootURL())
+}
+
+// runGet writes the value of a secret in the format given by --format.
+func runGet(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	format, _ := flags.GetString("format")
+	if format != "json" && format != "env" {
+		return fmt.Errorf("unknown format %q, expected json or env", format)
+	}
+
+	secret, err := makeSecrets(credentials).Get(args[0])
+	if err != nil {
+		return fmt.Errorf("could not get secret %s: %w", args[0], err)
+	}
+
+	if format == "json" {
+		buf := &bytes.Buffer{}
+		if err := json.Indent(buf, secret.Secret, "", "  "); err != nil {
+			return fmt.Errorf("could not render secret %s: %w", args[0], err)
+		}
+		fmt.Fprintln(buf)
+		_, err = buf.WriteTo(out)
+		return err
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(secret.Secret, &values); err != nil {
+		return fmt.Errorf("secret %s is not an object: %w", args[0], err)
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if !envName.MatchString(key) {
+			return fmt.Errorf("key %q of secret %s is not a valid variable name", key, args[0])
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// render everything before writing, so that nothing is output if any
+	// value is invalid
+	buf := &bytes.Buffer{}
+	for _, key := range keys {
+		var value string
+		if err := json.Unmarshal(values[key], &value); err != nil {
+			value = string(values[key])
+		}
+		fmt.Fprintf(buf, "export %s=%s\n", key, shellQuote(value))
+	}
+	_, err = buf.WriteTo(out)
+	return err
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// runSet sets a secret to the JSON object read from stdin or --file.
+func runSet(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	expires, _ := flags.GetString("expires")
+	expiry, err := fromNow.Parse(expires)
+	if err != nil {
+		return fmt.Errorf("invalid --expires: %w", err)
+	}
+
+	var data []byte
+	if file, _ := flags.GetString("file"); file != "" {
+		data, err = ioutil.ReadFile(file)
+	} else {
+		data, err = ioutil.ReadAll(stdin)
+	}
+	if err != nil {
+		return fmt.Errorf("could not read secret: %w", err)
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil || object == nil {
+		return errors.New("the secret must be a JSON object")
+	}
+
+	err = makeSecrets(credentials).Set(args[0], &tcsecrets.Secret{
+		Expires: tcclient.Time(expiry),
+		Secret:  json.RawMessage(data),
+	})
+	if err != nil {
+		return fmt.Errorf("could not set secret %s: %w", args[0], redact(err))
+	}
+
+	fmt.Fprintf(out, "Secret %s set\n", args[0])
+	return nil
+}
+
+// redact removes the request body, which holds the secret, from the call
+// summary of an API error, which includes it when TASKCLUSTER_DEBUG is set.
+func redact(err error) error {
+	if e, ok := err.(*tcclient.APICallException); ok && e.CallSummary != nil {
+		e.CallSummary.HTTPRequestBody = "<redacted>"
+		e.CallSummary.HTTPRequestObject = nil
+	}
+	return err
+}
+
+// runList lists the names of all secrets, following continuation tokens.
+func runList(credentials *tcclient.Credentials, _ []string, out io.Writer, _ *pflag.FlagSet) error {
+	s := makeSecrets(credentials)
+	continuation := ""
+	for {
+		resp, err := s.List(continuation, "")
+		if err != nil {
+			return fmt.Errorf("could not list secrets: %w", err)
+		}
+		for _, name := range resp.Secrets {
+			fmt.Fprintln(out, name)
+		}
+		if continuation = resp.ContinuationToken; continuation == "" {
+			return nil
+		}
+	}
+}
+
+// runRemove removes a secret.
+func runRemove(credentials *tcclient.Credentials, args []string, out io.Writer, _ *pflag.FlagSet) error {
+	if err := makeSecrets(credentials).Remove(args[0]); err != nil {
+		return fmt.Errorf("could not remove secret %s: %w", args[0], err)
+	}
+	fmt.Fprintf(out, "Secret %s removed\n", args[0])
+	return nil
+}
@@ -0,0 +1,153 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/suite"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+type FakeServerSuite struct {
+	suite.Suite
+	testServer *httptest.Server
+	// stored holds the secrets set through the fake server, by name
+	stored map[string]json.RawMessage
+}
+
+func (suite *FakeServerSuite) SetupSuite() {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/api/secrets/v1/secret/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/api/secrets/v1/secret/")
+		switch r.Method {
+		case "PUT":
+			var secret struct {
+				Secret json.RawMessage `json:"secret"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&secret)
+			suite.stored[name] = secret.Secret
+			_, _ = io.WriteString(w, "{}")
+		case "DELETE":
+			delete(suite.stored, name)
+			_, _ = io.WriteString(w, "{}")
+		default:
+			_, _ = io.WriteString(w, `{"expires": "2030-01-01T00:00:00.000Z", "secret": `+string(suite.stored[name])+`}`)
+		}
+	})
+	handler.HandleFunc("/api/secrets/v1/secrets", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("continuationToken") == "" {
+			_, _ = io.WriteString(w, `{"secrets": ["project/a"], "continuationToken": "next"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"secrets": ["project/b"]}`)
+	})
+
+	suite.testServer = httptest.NewServer(handler)
+	config.SetRootURL(suite.testServer.URL)
+}
+
+func (suite *FakeServerSuite) SetupTest() {
+	suite.stored = map[string]json.RawMessage{
+		"project/deploy": json.RawMessage(`{"TOKEN": "it's secret", "PORT": 8080, "nested": {"a": 1}}`),
+	}
+}
+
+func (suite *FakeServerSuite) TearDownSuite() {
+	suite.testServer.Close()
+	config.SetRootURL("")
+}
+
+func TestFakeServerSuite(t *testing.T) {
+	suite.Run(t, new(FakeServerSuite))
+}
+
+func getFlags(format string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("get", pflag.ContinueOnError)
+	flags.String("format", format, "")
+	return flags
+}
+
+func setFlags(file string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("set", pflag.ContinueOnError)
+	flags.String("file", file, "")
+	flags.String("expires", "1 year", "")
+	return flags
+}
+
+func (suite *FakeServerSuite) TestGetJSON() {
+	buf := &bytes.Buffer{}
+	suite.NoError(runGet(nil, []string{"project/deploy"}, buf, getFlags("json")))
+	suite.JSONEq(string(suite.stored["project/deploy"]), buf.String())
+}
+
+func (suite *FakeServerSuite) TestGetEnv() {
+	suite.stored["project/deploy"] = json.RawMessage(`{"TOKEN": "it's secret", "PORT": 8080}`)
+
+	buf := &bytes.Buffer{}
+	suite.NoError(runGet(nil, []string{"project/deploy"}, buf, getFlags("env")))
+	suite.Equal("export PORT='8080'\nexport TOKEN='it'\\''s secret'\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestGetEnvInvalidKey() {
+	buf := &bytes.Buffer{}
+	suite.stored["project/deploy"] = json.RawMessage(`{"TOKEN": "x", "not-a-name": "y"}`)
+	suite.Error(runGet(nil, []string{"project/deploy"}, buf, getFlags("env")))
+	suite.Empty(buf.String(), "nothing should be exported for an invalid secret")
+}
+
+func (suite *FakeServerSuite) TestSetStdin() {
+	defer func(orig io.Reader) { stdin = orig }(stdin)
+	stdin = strings.NewReader(`{"password": "hunter2"}`)
+
+	buf := &bytes.Buffer{}
+	suite.NoError(runSet(nil, []string{"project/new"}, buf, setFlags("")))
+	suite.Equal("Secret project/new set\n", buf.String())
+	suite.JSONEq(`{"password": "hunter2"}`, string(suite.stored["project/new"]))
+}
+
+func (suite *FakeServerSuite) TestSetFile() {
+	dir, err := ioutil.TempDir("", "secrets")
+	suite.Require().NoError(err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "secret.json")
+	suite.Require().NoError(ioutil.WriteFile(file, []byte(`{"key": "value"}`), 0600))
+
+	suite.NoError(runSet(nil, []string{"project/new"}, ioutil.Discard, setFlags(file)))
+	suite.JSONEq(`{"key": "value"}`, string(suite.stored["project/new"]))
+
+	suite.Require().NoError(ioutil.WriteFile(file, []byte(`"not an object"`), 0600))
+	suite.Error(runSet(nil, []string{"project/new"}, ioutil.Discard, setFlags(file)))
+}
+
+func (suite *FakeServerSuite) TestListAndRemove() {
+	buf := &bytes.Buffer{}
+	suite.NoError(runList(nil, nil, buf, nil))
+	suite.Equal("project/a\nproject/b\n", buf.String())
+
+	buf.Reset()
+	suite.NoError(runRemove(nil, []string{"project/deploy"}, buf, nil))
+	suite.Equal("Secret project/deploy removed\n", buf.String())
+	suite.NotContains(suite.stored, "project/deploy")
+}
+
+func TestRedact(t *testing.T) {
+	err := &tcclient.APICallException{
+		CallSummary: &tcclient.CallSummary{HTTPRequestBody: `{"secret": {"password": "hunter2"}}`},
+		RootCause:   errors.New("bad request"),
+	}
+	redact(err)
+	if strings.Contains(err.CallSummary.HTTPRequestBody, "hunter2") {
+		t.Fatalf("secret not redacted from %q", err.CallSummary.HTTPRequestBody)
+	}
+}
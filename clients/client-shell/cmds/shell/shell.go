@@ -0,0 +1,394 @@
+// Package shell implements the interactive shell.
+package shell
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const (
+	prompt = "taskcluster> "
+
+	// maxRecentIDs is the number of taskIds and taskGroupIds offered for
+	// completion.
+	maxRecentIDs = 50
+)
+
+var (
+	// Command is the shell command.
+	Command = &cobra.Command{
+		Use:   "shell",
+		Short: "Run taskcluster commands from an interactive prompt.",
+		Long: `Run taskcluster commands from an interactive prompt, without the
+leading "taskcluster", e.g. "task status <taskId>".
+
+All commands run with the credentials and root URL of the shell, so that
+--profile or --root-url only need to be given once, to the shell itself.
+The up and down keys recall previous commands, and the tab key completes
+commands, flags, and the taskIds and taskGroupIds seen during the session.
+
+Type "history" to list the previous commands, and "exit" or Ctrl-D to leave.`,
+		RunE: runShell,
+	}
+
+	// slugPattern matches the slugids in the output of commands; see
+	// slugid.RegexpSlugV4.
+	slugPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_-])([A-Za-z0-9_-]{8}[Q-T][A-Za-z0-9_-][CGKOSWaeimquy26-][A-Za-z0-9_-]{10}[AQgw])`)
+
+	// builtins are the commands handled by the shell itself.
+	builtins = []string{"exit", "history", "quit"}
+)
+
+func init() {
+	root.Command.AddCommand(Command)
+}
+
+// lineReader reads the commands of the session.
+type lineReader interface {
+	ReadLine() (string, error)
+}
+
+// session is the state of an interactive shell.
+type session struct {
+	// root is the tree of the commands run by the shell.
+	root *cobra.Command
+	// out is where the output of commands goes, and list is where the
+	// candidates of ambiguous completions are listed.
+	out, list io.Writer
+	history   []string
+	// recentIDs are the slugids seen in the session, most recent first.
+	recentIDs []string
+}
+
+func runShell(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%s expects no arguments", cmd.Name())
+	}
+	s := &session{root: cmd.Root(), out: cmd.OutOrStdout()}
+
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		// commands are piped in: run them without prompting
+		s.list = ioutil.Discard
+		return s.run(&scannerReader{bufio.NewScanner(os.Stdin)}, nil)
+	}
+
+	term := terminal.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, prompt)
+	term.AutoCompleteCallback = s.complete
+	s.list = term
+	return s.run(term, func(read func() (string, error)) (string, error) {
+		// the terminal is only raw while a line is being edited, so that
+		// commands run as usual
+		state, err := terminal.MakeRaw(fd)
+		if err != nil {
+			return "", fmt.Errorf("could not set up the terminal: %w", err)
+		}
+		defer terminal.Restore(fd, state)
+		return read()
+	})
+}
+
+// run runs the commands read from r until it is exhausted or the session
+// is exited. If wrap is not nil, each line is read through it.
+func (s *session) run(r lineReader, wrap func(func() (string, error)) (string, error)) error {
+	recorder := &idRecorder{s: s}
+	s.root.SetOut(io.MultiWriter(s.out, recorder))
+	defer s.root.SetOut(nil)
+	defer s.root.SetArgs(nil)
+
+	for {
+		var line string
+		var err error
+		if wrap != nil {
+			line, err = wrap(r.ReadLine)
+		} else {
+			line, err = r.ReadLine()
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		words, err := splitWords(line)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			continue
+		}
+		if len(words) == 0 {
+			continue
+		}
+		s.history = append(s.history, strings.TrimSpace(line))
+
+		switch words[0] {
+		case "exit", "quit":
+			return nil
+		case "history":
+			for i, l := range s.history {
+				fmt.Fprintf(s.out, "%4d  %s\n", i+1, l)
+			}
+			continue
+		case "shell":
+			fmt.Fprintln(os.Stderr, "Error: already in the shell")
+			continue
+		}
+
+		s.remember(strings.Join(words, " "))
+		s.root.SetArgs(root.ExpandAlias(words))
+		// errors only end the command
+		cmd, err := s.root.ExecuteC()
+		root.ReportTimings()
+		if err != nil {
+			root.ReportError(cmd, err)
+		}
+		recorder.flush()
+		resetFlags(s.root)
+	}
+}
+
+// remember records the slugids found in text, as the most recent ones.
+func (s *session) remember(text string) {
+	for _, m := range slugPattern.FindAllStringSubmatch(text, -1) {
+		id := m[1]
+		for i, seen := range s.recentIDs {
+			if seen == id {
+				s.recentIDs = append(s.recentIDs[:i], s.recentIDs[i+1:]...)
+				break
+			}
+		}
+		s.recentIDs = append([]string{id}, s.recentIDs...)
+	}
+	if len(s.recentIDs) > maxRecentIDs {
+		s.recentIDs = s.recentIDs[:maxRecentIDs]
+	}
+}
+
+// complete is the completion callback of the terminal: on tab, it
+// completes the word before the cursor with the subcommands or flags of
+// the command being typed, or with the recently seen slugids.
+func (s *session) complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+	before := line[:pos]
+	words := strings.Fields(before)
+	partial := ""
+	if len(words) > 0 && !strings.HasSuffix(before, " ") {
+		partial = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	var candidates []string
+	for _, c := range s.candidates(words, partial) {
+		if strings.HasPrefix(c, partial) {
+			candidates = append(candidates, c)
+		}
+	}
+	sort.Strings(candidates)
+
+	var completion string
+	switch len(candidates) {
+	case 0:
+		return "", 0, false
+	case 1:
+		completion = candidates[0] + " "
+	default:
+		completion = commonPrefix(candidates)
+		if completion == partial {
+			fmt.Fprintln(s.list, strings.Join(candidates, "  "))
+			return "", 0, false
+		}
+	}
+	newLine := before[:len(before)-len(partial)] + completion
+	return newLine + line[pos:], len(newLine), true
+}
+
+// candidates returns the possible completions of the word following words.
+func (s *session) candidates(words []string, partial string) []string {
+	cmd := s.root
+	arguments := 0
+	for _, w := range words {
+		if strings.HasPrefix(w, "-") {
+			continue
+		}
+		if sub := subcommand(cmd, w); sub != nil && arguments == 0 {
+			cmd = sub
+		} else {
+			arguments++
+		}
+	}
+
+	var candidates []string
+	switch {
+	case strings.HasPrefix(partial, "-"):
+		add := func(f *pflag.Flag) {
+			if !f.Hidden {
+				candidates = append(candidates, "--"+f.Name)
+			}
+		}
+		cmd.Flags().VisitAll(add)
+		cmd.InheritedFlags().VisitAll(add)
+	case cmd.HasSubCommands() && arguments == 0:
+		for _, sub := range cmd.Commands() {
+			if sub.IsAvailableCommand() {
+				candidates = append(candidates, sub.Name())
+			}
+		}
+		if cmd == s.root {
+			candidates = append(candidates, builtins...)
+		}
+	default:
+		candidates = s.recentIDs
+	}
+	return candidates
+}
+
+// subcommand returns the subcommand of cmd with the given name or alias.
+func subcommand(cmd *cobra.Command, name string) *cobra.Command {
+	for _, sub := range cmd.Commands() {
+		if sub.Name() == name {
+			return sub
+		}
+		for _, alias := range sub.Aliases {
+			if alias == name {
+				return sub
+			}
+		}
+	}
+	return nil
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// resetFlags gives back their default values to the flags of cmd and its
+// subcommands, as the commands of the session share them.
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if !f.Changed {
+			return
+		}
+		if v, ok := f.Value.(pflag.SliceValue); ok {
+			var def []string
+			if d := strings.Trim(f.DefValue, "[]"); d != "" {
+				def = strings.Split(d, ",")
+			}
+			_ = v.Replace(def)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+// splitWords splits a command line into words, as a POSIX shell would
+// without expansions: words are separated by spaces unless quoted, and
+// backslashes escape the next character outside of single quotes.
+func splitWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, c := range line {
+		switch {
+		case escaped:
+			word.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(c)
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or escape")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// scannerReader reads lines from a non-interactive input.
+type scannerReader struct {
+	*bufio.Scanner
+}
+
+func (r *scannerReader) ReadLine() (string, error) {
+	if !r.Scan() {
+		if err := r.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.Text(), nil
+}
+
+// idRecorder remembers the slugids in the output of commands, line by line.
+type idRecorder struct {
+	s       *session
+	partial []byte
+}
+
+func (r *idRecorder) Write(p []byte) (int, error) {
+	data := append(r.partial, p...)
+	end := bytes.LastIndexByte(data, '\n') + 1
+	r.s.remember(string(data[:end]))
+	r.partial = append([]byte(nil), data[end:]...)
+	if len(r.partial) > 4096 {
+		r.s.remember(string(r.partial))
+		r.partial = nil
+	}
+	return len(p), nil
+}
+
+// flush remembers the slugids of the last line, if it was not terminated.
+func (r *idRecorder) flush() {
+	r.s.remember(string(r.partial))
+	r.partial = nil
+}
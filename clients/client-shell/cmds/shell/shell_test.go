@@ -0,0 +1,114 @@
+package shell
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	assert "github.com/stretchr/testify/require"
+)
+
+const (
+	taskID  = "FOH9mI0mQ1C90yoMo3ajsg"
+	groupID = "fNSvgZZ2TMKu4xM0Q4TNTA"
+)
+
+// newSession returns a session running a small command tree, where `task
+// status` prints its arguments and flags.
+func newSession() (*session, *bytes.Buffer) {
+	rootCmd := &cobra.Command{Use: "taskcluster"}
+	taskCmd := &cobra.Command{Use: "task"}
+	statusCmd := &cobra.Command{
+		Use: "status <taskId>",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all, _ := cmd.Flags().GetBool("all")
+			fields, _ := cmd.Flags().GetStringSlice("field")
+			fmt.Fprintf(cmd.OutOrStdout(), "%v all=%v fields=%v group %s\n", args, all, fields, groupID)
+			return nil
+		},
+	}
+	statusCmd.Flags().Bool("all", false, "")
+	statusCmd.Flags().StringSlice("field", nil, "")
+	taskCmd.AddCommand(statusCmd, &cobra.Command{Use: "cancel", Run: func(*cobra.Command, []string) {}})
+	rootCmd.AddCommand(taskCmd)
+
+	buf := &bytes.Buffer{}
+	return &session{root: rootCmd, out: buf, list: buf}, buf
+}
+
+func run(s *session, input string) error {
+	return s.run(&scannerReader{bufio.NewScanner(strings.NewReader(input))}, nil)
+}
+
+func TestRun(t *testing.T) {
+	assert := assert.New(t)
+	s, buf := newSession()
+
+	err := run(s, "task status --all --field a "+taskID+"\n\ntask status 'two words'\nhistory\nexit\ntask status never\n")
+	assert.NoError(err)
+	assert.Equal("["+taskID+"] all=true fields=[a] group "+groupID+"\n"+
+		// the flags of the previous command are reset
+		"[two words] all=false fields=[] group "+groupID+"\n"+
+		"   1  task status --all --field a "+taskID+"\n"+
+		"   2  task status 'two words'\n"+
+		"   3  history\n", buf.String())
+	assert.Equal([]string{groupID, taskID}, s.recentIDs)
+}
+
+func TestComplete(t *testing.T) {
+	assert := assert.New(t)
+	s, buf := newSession()
+	s.recentIDs = []string{groupID, taskID}
+
+	complete := func(line string) string {
+		newLine, newPos, ok := s.complete(line, len(line), '\t')
+		if !ok {
+			return line
+		}
+		assert.Equal(len(newLine), newPos)
+		return newLine
+	}
+
+	assert.Equal("task ", complete("ta"))
+	assert.Equal("task status ", complete("task s"))
+	assert.Equal("task status --all ", complete("task status --a"))
+	assert.Equal("task status "+taskID+" ", complete("task status F"))
+	assert.Equal("task status --all "+groupID+" ", complete("task status --all f"))
+
+	// ambiguous completions are listed
+	assert.Equal("task ", complete("task "))
+	assert.Equal("cancel  status\n", buf.String())
+
+	_, _, ok := s.complete("ta", 2, 'x')
+	assert.False(ok, "only tab should complete")
+}
+
+func TestSplitWords(t *testing.T) {
+	assert := assert.New(t)
+
+	words, err := splitWords(`task  create "my task" --field 'a b'\ c x\"y`)
+	assert.NoError(err)
+	assert.Equal([]string{"task", "create", "my task", "--field", "a b c", `x"y`}, words)
+
+	words, err = splitWords(`api queue task ''`)
+	assert.NoError(err)
+	assert.Equal([]string{"api", "queue", "task", ""}, words)
+
+	_, err = splitWords(`task "create`)
+	assert.Error(err)
+}
+
+func TestRemember(t *testing.T) {
+	assert := assert.New(t)
+	s, _ := newSession()
+
+	for i := 0; i < maxRecentIDs+5; i++ {
+		s.remember(fmt.Sprintf("task%04dQQCAAAAAAAAAAA", i))
+	}
+	s.remember("https://tc.example.com/tasks/" + taskID + "/runs")
+	assert.Len(s.recentIDs, maxRecentIDs)
+	assert.Equal(taskID, s.recentIDs[0])
+}
@@ -0,0 +1,89 @@
+// Package signedURL implements the signed-url command.
+package signedURL
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	fromNow "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/from-now"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+func init() {
+	cmd := &cobra.Command{
+		Use:   "signed-url [GET] <url>",
+		Short: "Returns a URL that is signed with the current credentials.",
+		Long: `Returns a URL that is signed with the current credentials, using a Hawk
+bewit, so that it can be shared to give time-limited access to e.g. a private
+artifact without sharing the credentials.
+
+The URL can be absolute, or relative to the root URL, e.g.
+
+$ taskcluster signed-url api/queue/v1/task/<taskId>/artifacts/private/build.zip --expires 30m
+
+Bewits only authenticate GET requests, so GET is the only supported method.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var creds *tcclient.Credentials
+			if config.Credentials != nil {
+				creds = config.Credentials.ToClientCredentials()
+			}
+			expires, _ := cmd.Flags().GetString("expires")
+			return runSignedURL(creds, args, expires, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().String("expires", "1h", "Lifetime of the signed URL, e.g. 30m or '1 day'.")
+
+	root.Command.AddCommand(cmd)
+}
+
+// runSignedURL signs the URL given in args, which may be preceded by the
+// method, and writes it to out.
+func runSignedURL(credentials *tcclient.Credentials, args []string, expires string, out io.Writer) error {
+	if credentials == nil || credentials.ClientID == "" {
+		return errors.New("signing a URL requires credentials; set TASKCLUSTER_CLIENT_ID and TASKCLUSTER_ACCESS_TOKEN")
+	}
+
+	if len(args) == 2 {
+		if method := strings.ToUpper(args[0]); method != "GET" {
+			return fmt.Errorf("cannot sign a URL for method %s: only GET requests can be authenticated with a signed URL", method)
+		}
+		args = args[1:]
+	}
+
+	expiry, err := fromNow.Parse(expires)
+	if err != nil {
+		return fmt.Errorf("invalid --expires: %s", err)
+	}
+
+	u, err := url.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid URL %s: %s", args[0], err)
+	}
+	if u.Host == "" {
+		u, err = url.Parse(strings.TrimRight(config.RootURL(), "/") + "/" + strings.TrimLeft(args[0], "/"))
+		if err != nil {
+			return fmt.Errorf("invalid URL %s: %s", args[0], err)
+		}
+	}
+	// SignedURL re-encodes the query, sorted by key, once the bewit has been
+	// added, so sign the URL with its query encoded the same way.
+	query := u.Query()
+	u.RawQuery = query.Encode()
+
+	client := tcclient.Client{Credentials: credentials, RootURL: config.RootURL()}
+	signed, err := client.SignedURL(u.String(), query, time.Until(expiry))
+	if err != nil {
+		return fmt.Errorf("could not sign URL %s: %s", u, err)
+	}
+
+	_, err = fmt.Fprintln(out, signed.String())
+	return err
+}
@@ -0,0 +1,50 @@
+package signedURL
+
+import (
+	"bytes"
+	"net/url"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+var creds = &tcclient.Credentials{
+	ClientID:    "tester",
+	AccessToken: "no-secret",
+}
+
+func TestSignedURL(t *testing.T) {
+	assert := assert.New(t)
+	config.SetRootURL("https://tc.example.com")
+	defer config.SetRootURL("")
+
+	for _, args := range [][]string{
+		{"api/queue/v1/task/abc/artifacts/private/build.zip?b=1&a=2"},
+		{"get", "https://tc.example.com/api/queue/v1/task/abc/artifacts/private/build.zip?b=1&a=2"},
+	} {
+		buf := &bytes.Buffer{}
+		assert.NoError(runSignedURL(creds, args, "30m", buf))
+
+		u, err := url.Parse(buf.String()[:buf.Len()-1])
+		assert.NoError(err)
+		assert.Equal("tc.example.com", u.Host)
+		assert.Equal("/api/queue/v1/task/abc/artifacts/private/build.zip", u.Path)
+		assert.Equal("1", u.Query().Get("b"), "existing query parameters should be kept")
+		assert.Equal("2", u.Query().Get("a"), "existing query parameters should be kept")
+		assert.NotEmpty(u.Query().Get("bewit"))
+	}
+}
+
+func TestSignedURLErrors(t *testing.T) {
+	assert := assert.New(t)
+	config.SetRootURL("https://tc.example.com")
+	defer config.SetRootURL("")
+
+	buf := &bytes.Buffer{}
+	assert.Error(runSignedURL(nil, []string{"https://example.com"}, "1h", buf), "credentials are required")
+	assert.Error(runSignedURL(creds, []string{"POST", "https://example.com"}, "1h", buf), "only GET can be signed")
+	assert.Error(runSignedURL(creds, []string{"https://example.com"}, "later", buf), "expires must be valid")
+	assert.Empty(buf.String())
+}
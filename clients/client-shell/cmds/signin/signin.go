@@ -17,6 +17,8 @@ import (
 	libUrls "github.com/taskcluster/taskcluster-lib-urls"
 	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
 	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcauth"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/client"
+	fromNow "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/from-now"
 	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
 	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
 	graceful "gopkg.in/tylerb/graceful.v1"
@@ -39,9 +41,17 @@ tc-signin() { eval ` + "`" + `taskcluster signin "${@}"` + "`" + `; }
 
 This will set environment variables in your shell session containing the credentials.
 Note that the JS and Python client recognize the same environment variables, so any
-tools using those libraries can also benefit from this signin method.`,
+tools using those libraries can also benefit from this signin method.
 
-		RunE: cmdSignin,
+Alternatively, the credentials can be saved under a named profile:
+
+$ taskcluster signin --profile staging
+
+Other commands then use them when given the same --profile, or when
+TASKCLUSTER_PROFILE is set to the name of the profile.`,
+
+		RunE:        cmdSignin,
+		Annotations: map[string]string{root.ManagesProfile: "true"},
 	}
 	cmd.Flags().Bool("check", false, "Check whether you are already signed in")
 	cmd.Flags().Bool("csh", false, "Output csh-style environment variables (default is Bourne shell)")
@@ -54,12 +64,24 @@ tools using those libraries can also benefit from this signin method.`,
 }
 
 func cmdSignin(cmd *cobra.Command, _ []string) error {
+	profile := root.Profile(cmd)
 	if check, _ := cmd.Flags().GetBool("check"); check {
+		if profile != "" {
+			if err := config.UseProfile(profile); err != nil {
+				return err
+			}
+		}
 		return checkSignin()
 	}
 
+	expires, _ := cmd.Flags().GetString("expires")
+	expiry, err := fromNow.Parse(expires)
+	if err != nil {
+		return fmt.Errorf("invalid --expires: %s", err)
+	}
+
 	// Load configuration
-	fmt.Fprintln(cmd.OutOrStderr(), "Starting")
+	fmt.Fprintln(client.Progress(cmd.OutOrStderr()), "Starting")
 
 	// Find port, choose 0 meaning random port, if none
 	port, _ := cmd.Flags().GetInt("port")
@@ -75,7 +97,21 @@ func cmdSignin(cmd *cobra.Command, _ []string) error {
 		qs := r.URL.Query()
 		csh, _ := cmd.Flags().GetBool("csh")
 		rootURL := config.RootURL()
-		if csh {
+		if profile != "" {
+			err := config.SaveProfile(profile, config.Profile{
+				RootURL:     rootURL,
+				ClientID:    qs.Get("clientId"),
+				AccessToken: qs.Get("accessToken"),
+				Expires:     expiry,
+			})
+			if err != nil {
+				fmt.Fprintln(cmd.OutOrStderr(), err)
+				http.Error(w, "Failed to save credentials", http.StatusInternalServerError)
+				s.Stop(50 * time.Millisecond)
+				return
+			}
+			fmt.Fprintf(cmd.OutOrStderr(), "Credentials saved to profile %s\n", profile)
+		} else if csh {
 			fmt.Fprintln(cmd.OutOrStdout(), "setenv TASKCLUSTER_CLIENT_ID '"+qs.Get("clientId")+"'")
 			fmt.Fprintln(cmd.OutOrStdout(), "setenv TASKCLUSTER_ACCESS_TOKEN '"+qs.Get("accessToken")+"'")
 			fmt.Fprintln(cmd.OutOrStdout(), "setenv TASKCLUSTER_ROOT_URL '"+rootURL+"'")
@@ -84,7 +120,9 @@ func cmdSignin(cmd *cobra.Command, _ []string) error {
 			fmt.Fprintln(cmd.OutOrStdout(), "export TASKCLUSTER_ACCESS_TOKEN='"+qs.Get("accessToken")+"'")
 			fmt.Fprintln(cmd.OutOrStdout(), "export TASKCLUSTER_ROOT_URL='"+rootURL+"'")
 		}
-		fmt.Fprintln(cmd.OutOrStderr(), "Credentials output as environment variables")
+		if profile == "" {
+			fmt.Fprintln(cmd.OutOrStderr(), "Credentials output as environment variables")
+		}
 
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte(`
@@ -116,7 +154,6 @@ func cmdSignin(cmd *cobra.Command, _ []string) error {
 	description := url.QueryEscape("Temporary client for use on the command line")
 	name, _ := cmd.Flags().GetString("name")
 	scopes, _ := cmd.Flags().GetStringArray("scope")
-	expires, _ := cmd.Flags().GetString("expires")
 	var loginURL string
 
 	if config.RootURL() == "https://taskcluster.net" {
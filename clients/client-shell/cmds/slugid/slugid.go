@@ -63,6 +63,17 @@ func init() {
 			Short: "Encode an UUID into a slug.",
 			RunE:  encode,
 		},
+		// v4 and nice, as in the JS client
+		&cobra.Command{
+			Use:   "v4",
+			Short: "Generate a V4 UUID and output its slug (same as generate).",
+			Run:   v4,
+		},
+		&cobra.Command{
+			Use:   "nice",
+			Short: "Generate a 'nice' slug (same as generate --nice).",
+			Run:   nice,
+		},
 	)
 
 	// Add the slugid subtree to the root.
@@ -80,6 +91,16 @@ func generate(cmd *cobra.Command, args []string) {
 	}
 }
 
+// v4 generates the slug of a v4 uuid
+func v4(cmd *cobra.Command, args []string) {
+	fmt.Fprintln(cmd.OutOrStdout(), sluglib.V4())
+}
+
+// nice generates a slug that respects tighter format constraints
+func nice(cmd *cobra.Command, args []string) {
+	fmt.Fprintln(cmd.OutOrStdout(), sluglib.Nice())
+}
+
 // decode decodes a slug into a uuid
 func decode(cmd *cobra.Command, args []string) error {
 	if len(args) < 1 {
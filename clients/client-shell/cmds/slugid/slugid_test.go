@@ -56,6 +56,18 @@ func TestSlugidNice(t *testing.T) {
 	}
 }
 
+func TestSlugidV4AndNiceCommands(t *testing.T) {
+	assert := assert.New(t)
+
+	buf, cmd := setUpCommand()
+	v4(cmd, []string{})
+	assert.Regexp(RegexpSlugV4, buf.String()[:buf.Len()-1])
+
+	buf.Reset()
+	nice(cmd, []string{})
+	assert.Regexp(RegexpSlugNice, buf.String()[:buf.Len()-1])
+}
+
 func TestInsufficientDecode(t *testing.T) {
 	assert := assert.New(t)
 
@@ -3,7 +3,6 @@ package task
 import (
 	"fmt"
 	"io"
-	"os"
 	"time"
 
 	"github.com/spf13/pflag"
@@ -21,20 +20,19 @@ func runCancel(credentials *tcclient.Credentials, args []string, out io.Writer,
 	taskID := args[0]
 
 	if noop {
-		return displayNoopMsg("Would cancel", credentials, args)
+		return displayNoopMsg("Would cancel", credentials, args, out)
 	}
 
 	if confirm {
-		var confirm = confirmMsg("Cancels", credentials, args)
-		if !confirm {
-			return nil
+		proceed, err := confirmMsg("Cancels", credentials, args, out)
+		if err != nil || !proceed {
+			return err
 		}
 	}
 
 	c, err := q.CancelTask(taskID)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		return fmt.Errorf("could not cancel the task %s: %v", taskID, err)
+		return fmt.Errorf("could not cancel the task %s: %w", taskID, err)
 	}
 
 	run := c.Status.Runs[len(c.Status.Runs)-1]
@@ -52,20 +50,20 @@ func runRerun(credentials *tcclient.Credentials, args []string, out io.Writer, f
 	taskID := args[0]
 
 	if noop {
-		return displayNoopMsg("Would re-run", credentials, args)
+		return displayNoopMsg("Would re-run", credentials, args, out)
 	}
 
 	if confirm {
-		var confirm = confirmMsg("Will re-run", credentials, args)
-		if !confirm {
-			return nil
+		proceed, err := confirmMsg("Will re-run", credentials, args, out)
+		if err != nil || !proceed {
+			return err
 		}
 	}
 
 	if !force {
 		s, err := q.Status(taskID)
 		if err != nil {
-			return fmt.Errorf("could not get status of the task %s: %v", taskID, err)
+			return fmt.Errorf("could not get status of the task %s: %w", taskID, err)
 		}
 		if s.Status.State != "failed" && s.Status.State != "exception" {
 			return fmt.Errorf("Task %s is in state %s. Disallowing rerun of a non-failed and non-exception task without --force", taskID, s.Status.State)
@@ -74,7 +72,7 @@ func runRerun(credentials *tcclient.Credentials, args []string, out io.Writer, f
 
 	c, err := q.RerunTask(taskID)
 	if err != nil {
-		return fmt.Errorf("could not rerun the task %s: %v", taskID, err)
+		return fmt.Errorf("could not rerun the task %s: %w", taskID, err)
 
 	}
 
@@ -92,13 +90,16 @@ func runRerun(credentials *tcclient.Credentials, args []string, out io.Writer, f
 //
 // Otherwise, default behavior is to omit those as taskcluster-tools does:
 // https://github.com/taskcluster/taskcluster-tools/blob/e8b6d45f10e7520f717b7a9f5db87d550c74d15e/src/views/UnifiedInspector/ActionsMenu.jsx#L141-L158
+//
+// The new definition can be changed with '--set', or in an editor with
+// '--edit', before it is submitted.
 func runRetrigger(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
 	q := makeQueue(credentials)
 	taskID := args[0]
 
 	t, err := q.Task(taskID)
 	if err != nil {
-		return fmt.Errorf("could not get the task %s: %v", taskID, err)
+		return fmt.Errorf("could not get the task %s: %w", taskID, err)
 	}
 
 	exactRetrigger, _ := flagSet.GetBool("exact")
@@ -151,9 +152,22 @@ func runRetrigger(credentials *tcclient.Credentials, args []string, out io.Write
 		Tags:          t.Tags,
 	}
 
+	edit, _ := flagSet.GetBool("edit")
+	sets, _ := flagSet.GetStringArray("set")
+	if edit || len(sets) > 0 {
+		newT, err = editTaskDefinition(newT, sets, edit)
+		if err != nil {
+			return err
+		}
+		if newT == nil {
+			fmt.Fprintln(out, "Retrigger aborted.")
+			return nil
+		}
+	}
+
 	c, err := q.CreateTask(newTaskID, newT)
 	if err != nil {
-		return fmt.Errorf("could not create task: %v", err)
+		return fmt.Errorf("could not create task: %w", err)
 	}
 
 	// If we got no error, that means the task was successfully submitted
@@ -171,17 +185,17 @@ func runComplete(credentials *tcclient.Credentials, args []string, out io.Writer
 
 	s, err := q.Status(taskID)
 	if err != nil {
-		return fmt.Errorf("could not get the status of the task %s: %v", taskID, err)
+		return fmt.Errorf("could not get the status of the task %s: %w", taskID, err)
 	}
 
 	if noop {
-		return displayNoopMsg("Would complete", credentials, args)
+		return displayNoopMsg("Would complete", credentials, args, out)
 	}
 
 	if confirm {
-		var confirm = confirmMsg("Will complete", credentials, args)
-		if !confirm {
-			return nil
+		proceed, err := confirmMsg("Will complete", credentials, args, out)
+		if err != nil || !proceed {
+			return err
 		}
 	}
 
@@ -190,7 +204,7 @@ func runComplete(credentials *tcclient.Credentials, args []string, out io.Writer
 		WorkerID:    "taskcluster-cli",
 	})
 	if err != nil {
-		return fmt.Errorf("could not claim the task %s: %v", taskID, err)
+		return fmt.Errorf("could not claim the task %s: %w", taskID, err)
 	}
 
 	wq := makeQueue(&tcclient.Credentials{
@@ -200,7 +214,7 @@ func runComplete(credentials *tcclient.Credentials, args []string, out io.Writer
 	})
 	r, err := wq.ReportCompleted(taskID, fmt.Sprint(c.RunID))
 	if err != nil {
-		return fmt.Errorf("could not complete the task %s: %v", taskID, err)
+		return fmt.Errorf("could not complete the task %s: %w", taskID, err)
 	}
 
 	fmt.Fprintln(out, getRunStatusString(r.Status.Runs[c.RunID].State, r.Status.Runs[c.RunID].ReasonResolved))
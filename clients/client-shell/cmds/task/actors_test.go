@@ -3,6 +3,7 @@ package task
 import (
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/stretchr/testify/assert"
 	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
@@ -74,6 +75,33 @@ func (suite *FakeServerSuite) TestRunCancelCommand() {
 	suite.Equal("cancelled 'cancelled'\n", buf.String())
 }
 
+func (suite *FakeServerSuite) TestRunCancelCommandNoop() {
+	// set up to run a command and capture output
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("noop", true, "")
+
+	// run the command
+	args := []string{fakeTaskID}
+	assert.NoError(suite.T(), runCancel(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("Would cancel my-test taskid: "+fakeTaskID+" (state: completed)\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestRunCancelCommandConfirmDeclined() {
+	// set up to run a command and capture output
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("confirm", true, "")
+	defer func(orig io.Reader) { stdin = orig }(stdin)
+	stdin = strings.NewReader("maybe\nn\n")
+
+	// run the command
+	args := []string{fakeTaskID}
+	assert.NoError(suite.T(), runCancel(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	prompt := "Cancels my-test taskId: " + fakeTaskID + " (state: completed). Are you sure you want to proceed?(y/N) "
+	suite.Equal(prompt+prompt, buf.String())
+}
+
 func (suite *FakeServerSuite) TestRunRerunCommandForce() {
 	// set up to run a command and capture output
 	buf, cmd := setUpCommand()
@@ -0,0 +1,236 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/paginate"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+// The exit codes of `task await`, other than 0 when all tasks completed.
+const (
+	awaitFailed    = 1
+	awaitException = 2
+	awaitTimeout   = 3
+)
+
+// blocked is the state reported for unscheduled tasks which will never run,
+// because a task they depend on was not completed.
+const blocked = "blocked"
+
+func init() {
+	awaitCmd := &cobra.Command{
+		Use:   "await <taskId>...",
+		Short: "Wait until tasks are resolved.",
+		Long: `Wait until the given tasks, or all tasks of a group with --group, are
+resolved, printing their states as they change.
+
+Unscheduled tasks depending on a task which failed, or had an exception, will
+never run unless that task is rerun; they are reported as blocked, rather
+than waited for.
+
+The exit code reflects the combined status of the tasks:
+
+  0  all tasks completed
+  1  a task failed or is blocked, or the tasks could not be checked
+  2  a task had an exception, and none failed
+  3  the tasks were not all resolved before --timeout`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var creds *tcclient.Credentials
+			if config.Credentials != nil {
+				creds = config.Credentials.ToClientCredentials()
+			}
+			group, _ := cmd.Flags().GetString("group")
+			if (group == "") == (len(args) == 0) {
+				return errors.New("await expects either <taskId> arguments or --group")
+			}
+			return runAwait(creds, args, cmd.OutOrStdout(), cmd.Flags())
+		},
+	}
+	awaitCmd.Flags().StringP("group", "g", "", "Wait for all tasks of the given task group.")
+	awaitCmd.Flags().Duration("timeout", 0, "Give up after this long, e.g. 2h (0 to wait forever).")
+
+	Command.AddCommand(awaitCmd)
+}
+
+// awaitedTask is the state of a task waited for by runAwait.
+type awaitedTask struct {
+	state        string
+	requires     string
+	dependencies []string
+}
+
+// runAwait polls the given tasks until they are all resolved, or blocked.
+func runAwait(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	q := makeQueue(credentials)
+	group, _ := flags.GetString("group")
+	timeout, _ := flags.GetDuration("timeout")
+	start := time.Now()
+
+	printed := make(map[string]string)
+	// depStates caches the states of resolved dependencies which are not
+	// waited for
+	depStates := make(map[string]string)
+	for {
+		tasks, order, err := pollAwaited(q, group, args)
+		if err != nil {
+			return err
+		}
+
+		// blocking propagates to the tasks depending on blocked tasks
+		for changed := true; changed; {
+			changed = false
+			for _, taskID := range order {
+				t := tasks[taskID]
+				if t.state != "unscheduled" {
+					continue
+				}
+				dep, err := failedDependency(q, t, tasks, depStates)
+				if err != nil {
+					return err
+				}
+				if dep != "" {
+					t.state = blocked
+					changed = true
+				}
+			}
+		}
+
+		pending := 0
+		for _, taskID := range order {
+			t := tasks[taskID]
+			if t.state != printed[taskID] {
+				fmt.Fprintf(out, "%s %s %s\n", time.Now().UTC().Format("15:04:05"), taskID, t.state)
+				printed[taskID] = t.state
+			}
+			if !isResolved(t.state) {
+				pending++
+			}
+		}
+
+		if pending == 0 {
+			return awaitResult(tasks, out)
+		}
+		if timeout > 0 && time.Since(start)+pollInterval > timeout {
+			return &root.ExitError{
+				Code: awaitTimeout,
+				Err:  fmt.Errorf("%d of %d tasks were not resolved after %s", pending, len(tasks), timeout),
+			}
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// pollAwaited returns the tasks waited for, by taskId, and their ids in
+// order.
+func pollAwaited(q *tcqueue.Queue, group string, taskIDs []string) (map[string]*awaitedTask, []string, error) {
+	tasks := make(map[string]*awaitedTask)
+	var order []string
+
+	if group == "" {
+		for _, taskID := range taskIDs {
+			s, err := q.Status(taskID)
+			if err != nil {
+				return nil, nil, fmt.Errorf("could not get the status of the task %s: %w", taskID, err)
+			}
+			t := &awaitedTask{state: s.Status.State}
+			if t.state == "unscheduled" {
+				def, err := q.Task(taskID)
+				if err != nil {
+					return nil, nil, fmt.Errorf("could not get the task %s: %w", taskID, err)
+				}
+				t.requires, t.dependencies = def.Requires, def.Dependencies
+			}
+			if _, ok := tasks[taskID]; !ok {
+				order = append(order, taskID)
+			}
+			tasks[taskID] = t
+		}
+		return tasks, order, nil
+	}
+
+	err := paginate.ForEachTaskInGroup(context.Background(), q, group, func(t tcqueue.TaskDefinitionAndStatus) error {
+		tasks[t.Status.TaskID] = &awaitedTask{
+			state:        t.Status.State,
+			requires:     t.Task.Requires,
+			dependencies: t.Task.Dependencies,
+		}
+		order = append(order, t.Status.TaskID)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return tasks, order, nil
+}
+
+// failedDependency returns the id of a dependency of an unscheduled task
+// which failed or had an exception, if the task requires all its
+// dependencies to complete.
+func failedDependency(q *tcqueue.Queue, t *awaitedTask, tasks map[string]*awaitedTask, depStates map[string]string) (string, error) {
+	if t.requires == "all-resolved" {
+		return "", nil
+	}
+	for _, dep := range t.dependencies {
+		state := depStates[dep]
+		if d, ok := tasks[dep]; ok {
+			state = d.state
+		} else if state == "" {
+			s, err := q.Status(dep)
+			if err != nil {
+				return "", fmt.Errorf("could not get the status of the task %s: %w", dep, err)
+			}
+			if state = s.Status.State; isResolved(state) {
+				depStates[dep] = state
+			}
+		}
+		if state == "failed" || state == "exception" || state == blocked {
+			return dep, nil
+		}
+	}
+	return "", nil
+}
+
+func isResolved(state string) bool {
+	return state == "completed" || state == "failed" || state == "exception" || state == blocked
+}
+
+// awaitResult prints the number of resolved tasks in each state, and
+// returns the error reflecting their combined status, if they did not all
+// complete.
+func awaitResult(tasks map[string]*awaitedTask, out io.Writer) error {
+	counter := make(map[string]int)
+	for _, t := range tasks {
+		counter[t.state]++
+	}
+	states := make([]string, 0, len(counter))
+	for state := range counter {
+		states = append(states, state)
+	}
+	sort.Strings(states)
+	parts := make([]string, len(states))
+	for i, state := range states {
+		parts[i] = fmt.Sprintf("%s: %d", state, counter[state])
+	}
+	summary := strings.Join(parts, ", ")
+	fmt.Fprintf(out, "Resolved %d tasks (%s).\n", len(tasks), summary)
+
+	switch {
+	case counter["failed"]+counter[blocked] > 0:
+		return &root.ExitError{Code: awaitFailed, Err: errors.New("tasks did not all complete")}
+	case counter["exception"] > 0:
+		return &root.ExitError{Code: awaitException, Err: errors.New("tasks did not all complete")}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package task
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
+)
+
+const (
+	awaitCompletedID = "Ag7wv0mvQ3eURFXVWb2nKw"
+	awaitFailedID    = "FL0ZjY1zRzK3q8CR2c5ZAw"
+	awaitBlockedID   = "Bl5ap8tZT8GBpzFQ2Gc6dQ"
+	awaitPendingID   = "Pe3lO6T3SuuVeZrvqN0m1g"
+)
+
+// returns a status handler reporting the given state
+func awaitStatusHandler(state string) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"status": {"state": "`+state+`", "runs": []}}`)
+	}
+}
+
+// returns a task depending on the failed task
+func awaitBlockedTaskHandler(w http.ResponseWriter, _ *http.Request) {
+	_, _ = io.WriteString(w, `{"requires": "all-completed", "dependencies": ["`+awaitFailedID+`"], "metadata": {"name": "blocked"}}`)
+}
+
+// runs `task await` with a timeout of 50ms, returning its output
+func await(args ...string) (string, error) {
+	buf, cmd := setUpCommand()
+	cmd.Flags().String("group", "", "")
+	cmd.Flags().Duration("timeout", 50*time.Millisecond, "")
+	err := runAwait(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags())
+	return buf.String(), err
+}
+
+func (suite *FakeServerSuite) TestRunAwait() {
+	defer func(orig time.Duration) { pollInterval = orig }(pollInterval)
+	pollInterval = time.Millisecond
+
+	out, err := await(awaitCompletedID)
+	suite.NoError(err)
+	suite.Regexp(`^\d\d:\d\d:\d\d `+awaitCompletedID+` completed
+Resolved 1 tasks \(completed: 1\).
+$`, out)
+
+	out, err = await(awaitCompletedID, awaitBlockedID)
+	suite.Error(err)
+	suite.Equal(1, root.ExitCode(err))
+	suite.Contains(out, awaitBlockedID+" blocked\n")
+	suite.Contains(out, "Resolved 2 tasks (blocked: 1, completed: 1).\n")
+
+	_, err = await(awaitCompletedID, awaitPendingID)
+	assert.EqualError(suite.T(), err, "1 of 2 tasks were not resolved after 50ms")
+	suite.Equal(3, root.ExitCode(err))
+}
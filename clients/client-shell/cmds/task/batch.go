@@ -0,0 +1,176 @@
+package task
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/client"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// progressOut returns where the progress bar of batch operations is drawn:
+// stderr, if it is a terminal.
+var progressOut = func() io.Writer {
+	if terminal.IsTerminal(int(os.Stderr.Fd())) {
+		return client.Progress(os.Stderr)
+	}
+	return ioutil.Discard
+}
+
+// addBatchFlags adds the flags of batchHelperE to a command.
+func addBatchFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("stdin", false, "Read newline-separated taskIds from stdin, instead of a <taskId> argument, and act on all of them; "+
+		"only the first word of each line is used, so the output of e.g. `group list` can be piped in.")
+	cmd.Flags().IntP("concurrency", "j", 20, "Maximum number of tasks acted on concurrently with --stdin.")
+}
+
+// batchHelperE is executeHelperE for the commands acting on a task which
+// can also act on a list of tasks read from stdin, with --stdin. The tasks
+// are then processed concurrently, and a summary of the results is printed
+// once they are all done.
+func batchHelperE(f Executor) func(*cobra.Command, []string) error {
+	single := executeHelperE(f)
+	return func(cmd *cobra.Command, args []string) error {
+		if fromStdin, _ := cmd.Flags().GetBool("stdin"); !fromStdin {
+			return single(cmd, args)
+		}
+		if len(args) > 0 {
+			return fmt.Errorf("%s --stdin does not take a <taskId> argument", cmd.Name())
+		}
+		if confirm, _ := cmd.Flags().GetBool("confirm"); confirm {
+			return errors.New("--confirm cannot be used with --stdin, which is where the taskIds are read from")
+		}
+		if edit, _ := cmd.Flags().GetBool("edit"); edit {
+			return errors.New("--edit cannot be used with --stdin; use --set to change all tasks")
+		}
+
+		var creds *tcclient.Credentials
+		if config.Credentials != nil {
+			creds = config.Credentials.ToClientCredentials()
+		}
+		taskIDs, err := readTaskIDs(stdin)
+		if err != nil {
+			return err
+		}
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		return runBatch(f, creds, taskIDs, cmd.OutOrStdout(), progressOut(), cmd.Flags(), concurrency)
+	}
+}
+
+// readTaskIDs returns the first word of each non-empty line of r, without
+// duplicates.
+func readTaskIDs(r io.Reader) ([]string, error) {
+	var taskIDs []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || seen[fields[0]] {
+			continue
+		}
+		seen[fields[0]] = true
+		taskIDs = append(taskIDs, fields[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read taskIds from stdin: %w", err)
+	}
+	return taskIDs, nil
+}
+
+// batchResult is the outcome of an Executor for a single task.
+type batchResult struct {
+	output string
+	err    error
+}
+
+// runBatch calls f for each task, concurrently, drawing a progress bar on
+// progress, and then prints the result of each task, in order, to out.
+func runBatch(f Executor, credentials *tcclient.Credentials, taskIDs []string, out, progress io.Writer, flags *pflag.FlagSet, concurrency int) error {
+	if len(taskIDs) == 0 {
+		return errors.New("no taskIds given on stdin")
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// A fixed pool of workers takes the indexes of the tasks from the todo
+	// channel, as in `group cancel`; a failure does not stop the others.
+	results := make([]batchResult, len(taskIDs))
+	todo := make(chan int)
+	// progressMutex serializes the updates of the progress bar.
+	progressMutex := &sync.Mutex{}
+	done := 0
+	wg := &sync.WaitGroup{}
+
+	writeProgress(progress, done, len(taskIDs))
+	for i := 0; i < concurrency && i < len(taskIDs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range todo {
+				buf := &bytes.Buffer{}
+				err := f(credentials, []string{taskIDs[i]}, buf, flags)
+				results[i] = batchResult{output: buf.String(), err: err}
+
+				progressMutex.Lock()
+				done++
+				writeProgress(progress, done, len(taskIDs))
+				progressMutex.Unlock()
+			}
+		}()
+	}
+	for i := range taskIDs {
+		todo <- i
+	}
+	close(todo)
+	wg.Wait()
+	fmt.Fprintln(progress)
+
+	failures := 0
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	for i, taskID := range taskIDs {
+		if err := results[i].err; err != nil {
+			failures++
+			fmt.Fprintf(w, "%s\terror: %s\n", taskID, errorSummary(err))
+			continue
+		}
+		output := strings.Join(strings.Fields(strings.Replace(results[i].output, "\n", "; ", -1)), " ")
+		fmt.Fprintf(w, "%s\t%s\n", taskID, strings.TrimSuffix(output, ";"))
+	}
+	w.Flush()
+	fmt.Fprintf(out, "Succeeded for %d of %d tasks (%d failed).\n", len(taskIDs)-failures, len(taskIDs), failures)
+
+	if failures > 0 {
+		return fmt.Errorf("failed for %d of %d tasks", failures, len(taskIDs))
+	}
+	return nil
+}
+
+// errorSummary returns the error of a task on a single line, with the
+// message of the service for failed API calls.
+func errorSummary(err error) string {
+	e := client.NewError(err)
+	if e.Message != e.Error {
+		return e.Error + ": " + e.Message
+	}
+	return e.Error
+}
+
+// writeProgress draws a progress bar, replacing the previous one.
+func writeProgress(w io.Writer, done, total int) {
+	const width = 30
+	filled := width * done / total
+	fmt.Fprintf(w, "\r[%s%s] %d/%d", strings.Repeat("=", filled), strings.Repeat(" ", width-filled), done, total)
+}
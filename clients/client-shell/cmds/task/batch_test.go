@@ -0,0 +1,49 @@
+package task
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+)
+
+func (suite *FakeServerSuite) TestRunCancelStdin() {
+	defer func(orig io.Reader) { stdin = orig }(stdin)
+	stdin = strings.NewReader(fakeTaskID + " build completed\n\n" + fakeTaskID + "\nmissingTaskId\n")
+
+	buf, cmd := setUpCommand()
+	addBatchFlags(cmd)
+	cmd.Flags().Bool("confirm", false, "")
+	assert.NoError(suite.T(), cmd.Flags().Set("stdin", "true"))
+
+	err := batchHelperE(runCancel)(cmd, nil)
+	assert.EqualError(suite.T(), err, "failed for 1 of 2 tasks")
+
+	lines := strings.Split(buf.String(), "\n")
+	suite.Equal(fakeTaskID+"  cancelled 'cancelled'", lines[0])
+	suite.Regexp(`^missingTaskId +error: could not cancel the task missingTaskId: .*404`, lines[1])
+	suite.Equal("Succeeded for 1 of 2 tasks (1 failed).", lines[2])
+}
+
+func (suite *FakeServerSuite) TestRunBatchProgress() {
+	buf, cmd := setUpCommand()
+	progress := &bytes.Buffer{}
+
+	err := runBatch(runCancel, &tcclient.Credentials{}, []string{fakeTaskID}, cmd.OutOrStdout(), progress, cmd.Flags(), 1)
+	assert.NoError(suite.T(), err)
+
+	suite.Equal("\r["+strings.Repeat(" ", 30)+"] 0/1\r["+strings.Repeat("=", 30)+"] 1/1\n", progress.String())
+	suite.Equal(fakeTaskID+"  cancelled 'cancelled'\nSucceeded for 1 of 1 tasks (0 failed).\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestRunCancelStdinConfirm() {
+	_, cmd := setUpCommand()
+	addBatchFlags(cmd)
+	cmd.Flags().Bool("confirm", true, "")
+	assert.NoError(suite.T(), cmd.Flags().Set("stdin", "true"))
+
+	assert.Error(suite.T(), batchHelperE(runCancel)(cmd, nil), "--confirm should be rejected with --stdin")
+	assert.Error(suite.T(), batchHelperE(runCancel)(cmd, []string{fakeTaskID}), "arguments should be rejected with --stdin")
+}
@@ -0,0 +1,223 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/taskcluster/slugid-go/slugid"
+	tcurls "github.com/taskcluster/taskcluster-lib-urls"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+	fromNow "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/from-now"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+func init() {
+	createCmd := &cobra.Command{
+		Use:   "create (-f <file> | --interactive)",
+		Short: "Creates a task from a YAML or JSON task definition.",
+		Long: `Creates a task from a YAML or JSON task definition, and prints its taskId
+and the URL to inspect it.
+
+Before the definition is parsed, the following template expressions are
+replaced:
+
+  {{ env.NAME }}          the value of the environment variable NAME
+  {{ now }}               the current time
+  {{ fromNow "1 day" }}   the time which is the given duration ahead
+
+Properties can then be set with --set, e.g. --set metadata.name=test or
+--set 'payload.command=["echo", "hello"]' (values are parsed as JSON if
+possible). Missing created and deadline properties default to now and a day
+from now.
+
+With --interactive, the worker pool, with suggestions from the deployment,
+image, command, scopes and artifacts of the task are asked for instead, and
+the assembled definition is shown for confirmation before it is created.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var creds *tcclient.Credentials
+			if config.Credentials != nil {
+				creds = config.Credentials.ToClientCredentials()
+			}
+			return runCreate(creds, args, cmd.OutOrStdout(), cmd.Flags())
+		},
+	}
+	createCmd.Flags().StringP("file", "f", "", "File holding the task definition, or - to read it from stdin.")
+	createCmd.Flags().StringArray("set", nil, "(can be repeated) Set a property of the task definition (format: path.to.property=VALUE).")
+	createCmd.Flags().BoolP("interactive", "i", false, "Ask for the properties of the task, rather than reading its definition from a file.")
+	createCmd.Flags().String("task-id", "", "TaskId of the new task (defaults to a new slugid).")
+
+	Command.AddCommand(createCmd)
+}
+
+// runCreate creates the task defined in the file given by --file, or
+// assembled by the wizard with --interactive.
+func runCreate(credentials *tcclient.Credentials, _ []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	file := stringFlagHelper(flagSet, "file")
+	interactive, _ := flagSet.GetBool("interactive")
+	if file == "" && !interactive {
+		return errors.New("a task definition must be given with --file, or --interactive")
+	}
+	if file != "" && interactive {
+		return errors.New("--file cannot be used with --interactive")
+	}
+	sets, _ := flagSet.GetStringArray("set")
+	taskID := stringFlagHelper(flagSet, "task-id")
+	if taskID == "" {
+		taskID = slugid.Nice()
+	}
+
+	var data []byte
+	var err error
+	switch {
+	case interactive:
+		if data, err = runWizard(credentials, out, flagSet); err == nil && data == nil {
+			fmt.Fprintln(out, "Task creation aborted.")
+			return nil
+		}
+	case file == "-":
+		data, err = ioutil.ReadAll(stdin)
+	default:
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return fmt.Errorf("could not read task definition: %w", err)
+	}
+
+	def, err := parseTaskDefinition(data, sets, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	q := makeQueue(credentials)
+	if _, err := q.CreateTask(taskID, def); err != nil {
+		return fmt.Errorf("could not create task: %w", err)
+	}
+
+	fmt.Fprintf(out, "Task %s created\n", taskID)
+	fmt.Fprintln(out, tcurls.UI(config.RootURL(), "tasks/"+taskID))
+	return nil
+}
+
+// templateExpression matches the template expressions of task definitions.
+var templateExpression = regexp.MustCompile(`\{\{\s*(.*?)\s*\}\}`)
+
+// fromNowExpression matches the arguments of fromNow template expressions.
+var fromNowExpression = regexp.MustCompile(`^fromNow\s+(?:"([^"]*)"|'([^']*)')$`)
+
+// expandTemplate replaces the template expressions in a task definition.
+func expandTemplate(data []byte, now time.Time) ([]byte, error) {
+	var err error
+	expanded := templateExpression.ReplaceAllFunc(data, func(match []byte) []byte {
+		expression := string(templateExpression.FindSubmatch(match)[1])
+		switch {
+		case strings.HasPrefix(expression, "env."):
+			value, ok := os.LookupEnv(strings.TrimPrefix(expression, "env."))
+			if !ok && err == nil {
+				err = fmt.Errorf("environment variable %s is not set", strings.TrimPrefix(expression, "env."))
+			}
+			return []byte(value)
+		case expression == "now":
+			return []byte(now.Format(time.RFC3339))
+		case fromNowExpression.MatchString(expression):
+			groups := fromNowExpression.FindStringSubmatch(expression)
+			t, parseErr := fromNow.Parse(groups[1] + groups[2])
+			if parseErr != nil && err == nil {
+				err = parseErr
+			}
+			return []byte(t.UTC().Format(time.RFC3339))
+		}
+		if err == nil {
+			err = fmt.Errorf("unknown template expression %s", match)
+		}
+		return match
+	})
+	return expanded, err
+}
+
+// parseTaskDefinition expands the template expressions of a YAML or JSON
+// task definition, parses it, and applies the --set overrides.
+func parseTaskDefinition(data []byte, sets []string, now time.Time) (*tcqueue.TaskDefinitionRequest, error) {
+	data, err := expandTemplate(data, now)
+	if err != nil {
+		return nil, fmt.Errorf("could not expand task definition: %w", err)
+	}
+
+	// YAML is a superset of JSON, so this handles both
+	var def map[string]interface{}
+	if err := yaml.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("could not parse task definition: %w", err)
+	}
+	if def == nil {
+		def = make(map[string]interface{})
+	}
+
+	if err := applySets(def, sets); err != nil {
+		return nil, err
+	}
+
+	if _, ok := def["created"]; !ok {
+		def["created"] = now.Format(time.RFC3339)
+	}
+	if _, ok := def["deadline"]; !ok {
+		def["deadline"] = now.Add(24 * time.Hour).Format(time.RFC3339)
+	}
+
+	data, err = json.Marshal(def)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal task definition: %w", err)
+	}
+	var request tcqueue.TaskDefinitionRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		return nil, fmt.Errorf("invalid task definition: %w", err)
+	}
+	return &request, nil
+}
+
+// applySets applies --set overrides, of the form path.to.property=VALUE, to
+// a task definition. Values are parsed as JSON if possible.
+func applySets(def map[string]interface{}, sets []string) error {
+	for _, set := range sets {
+		p := strings.SplitN(set, "=", 2)
+		if len(p) != 2 || p[0] == "" {
+			return fmt.Errorf("invalid --set option: %s", set)
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(p[1]), &value); err != nil {
+			value = p[1]
+		}
+		if err := setProperty(def, strings.Split(p[0], "."), value); err != nil {
+			return fmt.Errorf("invalid --set option: %s: %w", set, err)
+		}
+	}
+	return nil
+}
+
+// setProperty sets the property at path in object to value, creating any
+// missing intermediate objects.
+func setProperty(object map[string]interface{}, path []string, value interface{}) error {
+	for _, name := range path[:len(path)-1] {
+		child, ok := object[name]
+		if !ok || child == nil {
+			child = make(map[string]interface{})
+			object[name] = child
+		}
+		childObject, ok := child.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s is not an object", name)
+		}
+		object = childObject
+	}
+	object[path[len(path)-1]] = value
+	return nil
+}
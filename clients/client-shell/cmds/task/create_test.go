@@ -0,0 +1,117 @@
+package task
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+)
+
+const createdTaskID = "fN1SbArXTPSVFNUvaOlinQ"
+
+// createdTask holds the last task definition received by createTaskHandler
+var createdTask map[string]interface{}
+
+// records the task definition and returns its status
+func createTaskHandler(w http.ResponseWriter, r *http.Request) {
+	createdTask = nil
+	if err := json.NewDecoder(r.Body).Decode(&createdTask); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, _ = w.Write([]byte(`{"status": {"taskId": "` + createdTaskID + `", "state": "pending", "runs": []}}`))
+}
+
+func TestExpandTemplate(t *testing.T) {
+	require := require.New(t)
+	defer os.Setenv("TC_CREATE_TEST", os.Getenv("TC_CREATE_TEST"))
+	require.NoError(os.Setenv("TC_CREATE_TEST", "hello"))
+	now := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	expanded, err := expandTemplate([]byte(`{"a": "{{ env.TC_CREATE_TEST }}", "b": "{{now}}", "c": {"d": 1}}`), now)
+	require.NoError(err)
+	require.Equal(`{"a": "hello", "b": "2020-03-01T12:00:00Z", "c": {"d": 1}}`, string(expanded))
+
+	expanded, err = expandTemplate([]byte(`{{ fromNow '1 day' }}`), now)
+	require.NoError(err)
+	deadline, err := time.Parse(time.RFC3339, string(expanded))
+	require.NoError(err)
+	require.WithinDuration(time.Now().Add(24*time.Hour), deadline, time.Minute)
+
+	_, err = expandTemplate([]byte(`{{ env.TC_CREATE_TEST_UNSET_VARIABLE }}`), now)
+	require.Error(err, "unset environment variables should be an error")
+	_, err = expandTemplate([]byte(`{{ whatever }}`), now)
+	require.Error(err, "unknown expressions should be an error")
+}
+
+func TestParseTaskDefinition(t *testing.T) {
+	require := require.New(t)
+	now := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	def, err := parseTaskDefinition([]byte(`
+provisionerId: proj
+workerType: linux
+metadata:
+  name: original
+payload:
+  command: [echo]
+`), []string{"metadata.name=changed", `payload.command=["echo", "hello"]`, "extra.retrigger.count=2"}, now)
+	require.NoError(err)
+	require.Equal("proj", def.ProvisionerID)
+	require.Equal("changed", def.Metadata.Name)
+	require.JSONEq(`{"command": ["echo", "hello"]}`, string(def.Payload))
+	require.JSONEq(`{"retrigger": {"count": 2}}`, string(def.Extra))
+	require.Equal(now, time.Time(def.Created).UTC())
+	require.Equal(now.Add(24*time.Hour), time.Time(def.Deadline).UTC())
+
+	_, err = parseTaskDefinition([]byte(`{"metadata": "name"}`), []string{"metadata.name=x"}, now)
+	require.Error(err, "setting a property of a non-object should be an error")
+	_, err = parseTaskDefinition([]byte(`{}`), []string{"novalue"}, now)
+	require.Error(err, "--set without a value should be an error")
+}
+
+func (suite *FakeServerSuite) TestCreateCommand() {
+	dir, err := ioutil.TempDir("", "task-create")
+	suite.Require().NoError(err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "task.json")
+	suite.Require().NoError(ioutil.WriteFile(file, []byte(`{"provisionerId": "proj", "workerType": "linux", "metadata": {"name": "test"}}`), 0644))
+
+	// set up to run a command and capture output
+	buf, cmd := setUpCommand()
+	cmd.Flags().String("file", file, "")
+	cmd.Flags().StringArray("set", []string{"priority=high"}, "")
+	cmd.Flags().String("task-id", createdTaskID, "")
+
+	// run the command
+	assert.NoError(suite.T(), runCreate(&tcclient.Credentials{}, nil, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("Task "+createdTaskID+" created\n"+suite.testServer.URL+"/tasks/"+createdTaskID+"\n", buf.String())
+	suite.Equal("high", createdTask["priority"])
+	suite.Equal("test", createdTask["metadata"].(map[string]interface{})["name"])
+}
+
+func (suite *FakeServerSuite) TestCreateCommandStdin() {
+	defer func(orig io.Reader) { stdin = orig }(stdin)
+	stdin = strings.NewReader(`{"provisionerId": "proj", "workerType": "linux"}`)
+
+	// set up to run a command and capture output
+	_, cmd := setUpCommand()
+	cmd.Flags().String("file", "-", "")
+	cmd.Flags().StringArray("set", nil, "")
+	cmd.Flags().String("task-id", createdTaskID, "")
+
+	// run the command
+	assert.NoError(suite.T(), runCreate(&tcclient.Credentials{}, nil, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("proj", createdTask["provisionerId"])
+}
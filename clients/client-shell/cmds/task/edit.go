@@ -0,0 +1,116 @@
+package task
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+)
+
+// editFile opens a file in the editor of the user, given by $VISUAL or
+// $EDITOR, and returns once it is closed.
+var editFile = func(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// the editor may come with arguments, e.g. "code --wait"
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not run the editor %s: %w", editor, err)
+	}
+	return nil
+}
+
+// editTaskDefinition applies the --set overrides to a task definition, and
+// opens it in an editor if edit is set. The created, deadline and expires
+// timestamps are then moved to now, keeping the durations between them as
+// edited. It returns nil if the edited definition is empty.
+func editTaskDefinition(t *tcqueue.TaskDefinitionRequest, sets []string, edit bool) (*tcqueue.TaskDefinitionRequest, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal task definition: %w", err)
+	}
+	var def map[string]interface{}
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("could not unmarshal task definition: %w", err)
+	}
+	if err := applySets(def, sets); err != nil {
+		return nil, err
+	}
+
+	if edit {
+		if def, err = editDefinition(def); err != nil || def == nil {
+			return nil, err
+		}
+	}
+
+	if created, err := time.Parse(time.RFC3339, fmt.Sprint(def["created"])); err == nil {
+		shift := time.Now().UTC().Sub(created)
+		for _, name := range []string{"created", "deadline", "expires"} {
+			if ts, err := time.Parse(time.RFC3339, fmt.Sprint(def[name])); err == nil {
+				def[name] = ts.Add(shift).UTC().Format(time.RFC3339)
+			}
+		}
+	}
+
+	if data, err = json.Marshal(def); err != nil {
+		return nil, fmt.Errorf("could not marshal task definition: %w", err)
+	}
+	var request tcqueue.TaskDefinitionRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		return nil, fmt.Errorf("invalid task definition: %w", err)
+	}
+	return &request, nil
+}
+
+// editDefinition writes a task definition as YAML to a temporary file, opens
+// it with editFile, and returns the definition read back, or nil if it was
+// emptied.
+func editDefinition(def map[string]interface{}) (map[string]interface{}, error) {
+	data, err := yaml.Marshal(def)
+	if err != nil {
+		return nil, fmt.Errorf("could not render the task definition: %w", err)
+	}
+	f, err := ioutil.TempFile("", "taskcluster-task-*.yml")
+	if err != nil {
+		return nil, fmt.Errorf("could not create a temporary file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	header := "# Edit the definition of the new task; it is created once the file is\n" +
+		"# saved and closed, or not at all if the file is emptied.\n"
+	_, err = f.WriteString(header + string(data))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not write %s: %w", f.Name(), err)
+	}
+
+	if err := editFile(f.Name()); err != nil {
+		return nil, err
+	}
+
+	data, err = ioutil.ReadFile(f.Name())
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", f.Name(), err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	var edited map[string]interface{}
+	if err := yaml.Unmarshal(data, &edited); err != nil {
+		return nil, fmt.Errorf("could not parse the edited task definition: %w", err)
+	}
+	return edited, nil
+}
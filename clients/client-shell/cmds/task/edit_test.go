@@ -0,0 +1,96 @@
+package task
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+)
+
+// retriggerDefinition returns a definition created a week ago, as given to
+// editTaskDefinition by runRetrigger.
+func retriggerDefinition() *tcqueue.TaskDefinitionRequest {
+	created := time.Now().UTC().Add(-7 * 24 * time.Hour).Truncate(time.Second)
+	return &tcqueue.TaskDefinitionRequest{
+		Created:       tcclient.Time(created),
+		Deadline:      tcclient.Time(created.Add(24 * time.Hour)),
+		Expires:       tcclient.Time(created.Add(30 * 24 * time.Hour)),
+		ProvisionerID: "proj-test",
+		WorkerType:    "linux",
+		Payload:       json.RawMessage(`{"env": {"A": "1"}, "maxRunTime": 600}`),
+		Metadata: tcqueue.TaskMetadata{
+			Name:  "test",
+			Owner: "test@example.com",
+		},
+	}
+}
+
+// withEditor replaces editFile with edit, until the returned function is
+// called.
+func withEditor(edit func(content string) string) func() {
+	orig := editFile
+	editFile = func(path string) error {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(path, []byte(edit(string(data))), 0600)
+	}
+	return func() { editFile = orig }
+}
+
+func TestEditTaskDefinitionSet(t *testing.T) {
+	require := require.New(t)
+	defer withEditor(func(string) string {
+		t.Fatal("the editor should not be opened without --edit")
+		return ""
+	})()
+
+	def, err := editTaskDefinition(retriggerDefinition(), []string{"payload.env.FOO=bar", "metadata.name=debug"}, false)
+	require.NoError(err)
+	require.JSONEq(`{"env": {"A": "1", "FOO": "bar"}, "maxRunTime": 600}`, string(def.Payload))
+	require.Equal("debug", def.Metadata.Name)
+
+	created, deadline := time.Time(def.Created), time.Time(def.Deadline)
+	require.WithinDuration(time.Now(), created, time.Minute)
+	require.Equal(24*time.Hour, deadline.Sub(created))
+	require.Equal(30*24*time.Hour, time.Time(def.Expires).Sub(created))
+}
+
+func TestEditTaskDefinitionEditor(t *testing.T) {
+	require := require.New(t)
+	var shown string
+	defer withEditor(func(content string) string {
+		shown = content
+		content = strings.Replace(content, "maxRunTime: 600", "maxRunTime: 3600", 1)
+		return strings.Replace(content, "workerType: linux", "workerType: linux-debug", 1)
+	})()
+
+	def, err := editTaskDefinition(retriggerDefinition(), []string{"payload.env.FOO=bar"}, true)
+	require.NoError(err)
+	require.Contains(shown, "FOO: bar", "the --set overrides should be applied before editing")
+	require.Equal("linux-debug", def.WorkerType)
+	require.JSONEq(`{"env": {"A": "1", "FOO": "bar"}, "maxRunTime": 3600}`, string(def.Payload))
+	require.WithinDuration(time.Now(), time.Time(def.Created), time.Minute)
+}
+
+func TestEditTaskDefinitionEmptied(t *testing.T) {
+	require := require.New(t)
+	defer withEditor(func(string) string { return "\n" })()
+
+	def, err := editTaskDefinition(retriggerDefinition(), nil, true)
+	require.NoError(err)
+	require.Nil(def)
+}
+
+func TestEditTaskDefinitionInvalid(t *testing.T) {
+	defer withEditor(func(string) string { return "created: [" })()
+
+	_, err := editTaskDefinition(retriggerDefinition(), nil, true)
+	require.Error(t, err)
+}
@@ -0,0 +1,84 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/streadway/amqp"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/formatter"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/pulse"
+)
+
+// consumePulse is replaced in tests.
+var consumePulse = pulse.Consume
+
+func init() {
+	eventsCmd := &cobra.Command{
+		Use:   "events <taskId>",
+		Short: "Stream the state transitions of a task, as published on Pulse.",
+		Long: `Print the state transitions of a task as they happen, from the messages
+the queue publishes on Pulse, until the task is resolved: a line with the
+current state of the task, and then a line per transition. With -o json, the
+messages are printed as JSON objects, one per line, as by ` + "`pulse listen`" + `.
+
+Pulse credentials are read from $PULSE_USERNAME and $PULSE_PASSWORD.`,
+		RunE: executeHelperE(runEvents),
+	}
+	eventsCmd.Flags().Duration("timeout", 0, "Give up if the task is not resolved after this long, e.g. 1h (0 to wait forever).")
+	formatter.RegisterFlag(eventsCmd.Flags())
+	pulse.AddConnectionFlags(eventsCmd.Flags())
+
+	Command.AddCommand(eventsCmd)
+}
+
+// runEvents prints the state transitions of a task until it is resolved.
+func runEvents(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	q := makeQueue(credentials)
+	taskID := args[0]
+	format, err := formatter.FromFlags(flags)
+	if err != nil {
+		return err
+	}
+	if format != formatter.Text && format != formatter.JSON {
+		return fmt.Errorf("task events only supports the text and json output formats")
+	}
+	timeout, _ := flags.GetDuration("timeout")
+
+	err = consumePulse(flags, pulse.Subscription{
+		Bindings: pulse.TaskBindings(taskID, ""),
+		Timeout:  timeout,
+		// the task may have changed before the queue was bound, or while
+		// reconnecting
+		Subscribed: func() (bool, error) {
+			s, err := q.Status(taskID)
+			if err != nil {
+				return true, fmt.Errorf("could not get the status of the task %s: %w", taskID, err)
+			}
+			if format == formatter.Text {
+				fmt.Fprintf(out, "%s %s is %s\n", time.Now().UTC().Format("15:04:05"), taskID, s.Status.State)
+			}
+			return isResolved(s.Status.State), nil
+		},
+		Handle: func(d amqp.Delivery) (bool, error) {
+			e, err := pulse.ParseTaskEvent(d)
+			if err != nil {
+				return true, err
+			}
+			if format == formatter.Text {
+				fmt.Fprintln(out, e)
+			} else if err := pulse.WriteMessage(out, format, d); err != nil {
+				return true, err
+			}
+			return e.Resolved(), nil
+		},
+	})
+	if errors.Is(err, pulse.ErrTimeout) {
+		return fmt.Errorf("the task %s was not resolved within %s", taskID, timeout)
+	}
+	return err
+}
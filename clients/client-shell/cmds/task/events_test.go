@@ -0,0 +1,105 @@
+package task
+
+import (
+	"github.com/spf13/pflag"
+	"github.com/streadway/amqp"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/formatter"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/pulse"
+)
+
+// withFakePulse replaces consumePulse with a function which handles the given
+// messages once subscribed, and records the routing keys it was bound with.
+func withFakePulse(routingKeys *[]string, messages ...amqp.Delivery) func() {
+	orig := consumePulse
+	consumePulse = func(_ *pflag.FlagSet, s pulse.Subscription) error {
+		for _, b := range s.Bindings {
+			*routingKeys = append(*routingKeys, b.ExchangeName()+" "+b.RoutingKey())
+		}
+		if done, err := s.Subscribed(); done || err != nil {
+			return err
+		}
+		for _, m := range messages {
+			if done, err := s.Handle(m); done || err != nil {
+				return err
+			}
+		}
+		return pulse.ErrTimeout
+	}
+	return func() { consumePulse = orig }
+}
+
+// events runs `task events`, returning its output
+func events(taskID string, format formatter.Format) (string, error) {
+	buf, cmd := setUpCommand()
+	cmd.Flags().Duration("timeout", 0, "")
+	formatter.RegisterFlag(cmd.Flags())
+	_ = cmd.Flags().Set("output", string(format))
+	err := runEvents(&tcclient.Credentials{}, []string{taskID}, cmd.OutOrStdout(), cmd.Flags())
+	return buf.String(), err
+}
+
+func (suite *FakeServerSuite) TestRunEvents() {
+	var routingKeys []string
+	defer withFakePulse(&routingKeys,
+		amqp.Delivery{
+			Exchange: "exchange/taskcluster-queue/v1/task-running",
+			Body:     []byte(`{"runId": 0, "workerGroup": "wg", "workerId": "wid", "status": {"taskId": "` + awaitPendingID + `", "state": "running"}}`),
+		},
+		amqp.Delivery{
+			Exchange: "exchange/taskcluster-queue/v1/task-exception",
+			Body: []byte(`{"runId": 0, "workerGroup": "wg", "workerId": "wid", "status": {"taskId": "` + awaitPendingID + `", "state": "pending",
+			  "runs": [{"runId": 0, "reasonResolved": "worker-shutdown"}, {"runId": 1}]}}`),
+		},
+		amqp.Delivery{
+			Exchange: "exchange/taskcluster-queue/v1/task-completed",
+			Body:     []byte(`{"runId": 1, "workerGroup": "wg", "workerId": "wid2", "status": {"taskId": "` + awaitPendingID + `", "state": "completed"}}`),
+		},
+		amqp.Delivery{
+			Exchange: "exchange/taskcluster-queue/v1/task-defined",
+			Body:     []byte(`{"status": {"taskId": "unexpected"}}`),
+		},
+	)()
+
+	out, err := events(awaitPendingID, formatter.Text)
+	suite.NoError(err)
+	suite.Regexp(`^\d\d:\d\d:\d\d `+awaitPendingID+` is pending
+\d\d:\d\d:\d\d `+awaitPendingID+` running run 0 on wg/wid
+\d\d:\d\d:\d\d `+awaitPendingID+` exception run 0 on wg/wid: worker-shutdown \(retried\)
+\d\d:\d\d:\d\d `+awaitPendingID+` completed run 1 on wg/wid2
+$`, out)
+	suite.Contains(routingKeys, "exchange/taskcluster-queue/v1/task-completed primary."+awaitPendingID+".*.*.*.*.*.*.*.#")
+	suite.Len(routingKeys, 6)
+}
+
+func (suite *FakeServerSuite) TestRunEventsResolved() {
+	var routingKeys []string
+	defer withFakePulse(&routingKeys)()
+
+	out, err := events(awaitCompletedID, formatter.Text)
+	suite.NoError(err)
+	suite.Regexp(`^\d\d:\d\d:\d\d `+awaitCompletedID+` is completed\n$`, out)
+}
+
+func (suite *FakeServerSuite) TestRunEventsJSON() {
+	var routingKeys []string
+	defer withFakePulse(&routingKeys, amqp.Delivery{
+		Exchange:   "exchange/taskcluster-queue/v1/task-failed",
+		RoutingKey: "primary." + awaitPendingID,
+		Body:       []byte(`{"status":{"taskId":"` + awaitPendingID + `","state":"failed"}}`),
+	})()
+
+	out, err := events(awaitPendingID, formatter.JSON)
+	suite.NoError(err)
+	suite.Equal(`{"exchange":"exchange/taskcluster-queue/v1/task-failed","routingKey":"primary.`+awaitPendingID+`","redelivered":false,`+
+		`"payload":{"status":{"taskId":"`+awaitPendingID+`","state":"failed"}}}
+`, out)
+}
+
+func (suite *FakeServerSuite) TestRunEventsTimeout() {
+	var routingKeys []string
+	defer withFakePulse(&routingKeys)()
+
+	_, err := events(awaitPendingID, formatter.Text)
+	suite.EqualError(err, "the task "+awaitPendingID+" was not resolved within 0s")
+}
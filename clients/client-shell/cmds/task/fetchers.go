@@ -6,10 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 	tcurls "github.com/taskcluster/taskcluster-lib-urls"
@@ -18,6 +20,9 @@ import (
 	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
 )
 
+// stdin is where confirmMsg reads the user's response from.
+var stdin io.Reader = os.Stdin
+
 func makeQueue(credentials *tcclient.Credentials) *tcqueue.Queue {
 	return tcqueue.New(credentials, config.RootURL())
 }
@@ -29,7 +34,7 @@ func runStatus(credentials *tcclient.Credentials, args []string, out io.Writer,
 
 	s, err := q.Status(taskID)
 	if err != nil {
-		return fmt.Errorf("could not get the status of the task %s: %v", taskID, err)
+		return fmt.Errorf("could not get the status of the task %s: %w", taskID, err)
 	}
 
 	allRuns, _ := flagSet.GetBool("all-runs")
@@ -57,58 +62,66 @@ func runStatus(credentials *tcclient.Credentials, args []string, out io.Writer,
 	return nil
 }
 
-// confirmMsg displays confirmation message when --confirm is used
-func confirmMsg(command string, credentials *tcclient.Credentials, args []string) bool {
-
-	q := makeQueue(credentials)
-	taskID := args[0]
-
-	c, err := q.Status(taskID)
+// confirmMsg displays confirmation message when --confirm is used, and
+// returns whether the user agreed to proceed.
+func confirmMsg(command string, credentials *tcclient.Credentials, args []string, out io.Writer) (bool, error) {
+	name, state, err := taskNameAndState(credentials, args[0])
 	if err != nil {
-		log.Fatal(err)
+		return false, err
 	}
-	run := c.Status.Runs[len(c.Status.Runs)-1]
 
-	t, err := q.Task(taskID)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	reader := bufio.NewReader(os.Stdin)
+	reader := bufio.NewReader(stdin)
 
 	for {
-		fmt.Printf("%s %s taskId: %s (state: %s). Are you sure you want to proceed?(y/N) ", command, t.Metadata.Name, taskID, run.State)
+		fmt.Fprintf(out, "%s %s taskId: %s (state: %s). Are you sure you want to proceed?(y/N) ", command, name, args[0], state)
 
 		response, err := reader.ReadString('\n')
 		if err != nil {
-			log.Fatal(err)
+			return false, fmt.Errorf("could not read confirmation: %w", err)
 		}
 
 		response = strings.ToLower(strings.TrimSpace(response))
 
 		if response == "y" || response == "yes" {
-			return true
+			return true, nil
 		} else if response == "n" || response == "no" {
-			return false
+			return false, nil
 		}
 	}
 }
 
 // displayNoopMsg displays details when --noop is used
-func displayNoopMsg(command string, credentials *tcclient.Credentials, args []string) error {
+func displayNoopMsg(command string, credentials *tcclient.Credentials, args []string, out io.Writer) error {
+	name, state, err := taskNameAndState(credentials, args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "%s %s taskid: %s (state: %s)\n", command, name, args[0], state)
+	return nil
+}
+
+// taskNameAndState gets the name of a given task and the state of its
+// latest run.
+func taskNameAndState(credentials *tcclient.Credentials, taskID string) (name, state string, err error) {
 	q := makeQueue(credentials)
-	taskID := args[0]
 
-	c, _ := q.Status(taskID)
-	run := c.Status.Runs[len(c.Status.Runs)-1]
+	c, err := q.Status(taskID)
+	if err != nil {
+		return "", "", fmt.Errorf("could not get the status of the task %s: %w", taskID, err)
+	}
+	if len(c.Status.Runs) > 0 {
+		state = c.Status.Runs[len(c.Status.Runs)-1].State
+	} else {
+		state = c.Status.State
+	}
 
 	t, err := q.Task(taskID)
 	if err != nil {
-		return fmt.Errorf("could not get the task %s: %v", taskID, err)
+		return "", "", fmt.Errorf("could not get the task %s: %w", taskID, err)
 	}
 
-	fmt.Printf("%s %s taskid: %s (state: %s)\n", command, t.Metadata.Name, taskID, run.State)
-	return nil
+	return t.Metadata.Name, state, nil
 }
 
 // runName gets the name of a given task.
@@ -118,7 +131,7 @@ func runName(credentials *tcclient.Credentials, args []string, out io.Writer, _
 
 	t, err := q.Task(taskID)
 	if err != nil {
-		return fmt.Errorf("could not get the task %s: %v", taskID, err)
+		return fmt.Errorf("could not get the task %s: %w", taskID, err)
 	}
 
 	fmt.Fprintln(out, t.Metadata.Name)
@@ -132,12 +145,12 @@ func runDef(credentials *tcclient.Credentials, args []string, out io.Writer, _ *
 
 	t, err := q.Task(taskID)
 	if err != nil {
-		return fmt.Errorf("could not get the task %s: %v", taskID, err)
+		return fmt.Errorf("could not get the task %s: %w", taskID, err)
 	}
 
 	def, err := json.MarshalIndent(t, "", "  ")
 	if err != nil {
-		return fmt.Errorf("unable to marshal task %s into json: %v", taskID, err)
+		return fmt.Errorf("unable to marshal task %s into json: %w", taskID, err)
 	}
 
 	fmt.Fprintln(out, string(def))
@@ -151,7 +164,7 @@ func runGroup(credentials *tcclient.Credentials, args []string, out io.Writer, _
 
 	t, err := q.Task(taskID)
 	if err != nil {
-		return fmt.Errorf("could not get the task %s: %v", taskID, err)
+		return fmt.Errorf("could not get the task %s: %w", taskID, err)
 	}
 
 	fmt.Fprintln(out, t.TaskGroupID)
@@ -165,7 +178,7 @@ func runArtifacts(credentials *tcclient.Credentials, args []string, out io.Write
 
 	s, err := q.Status(taskID)
 	if err != nil {
-		return fmt.Errorf("could not get the status of the task %s: %v", taskID, err)
+		return fmt.Errorf("could not get the status of the task %s: %w", taskID, err)
 	}
 
 	runID, _ := flagSet.GetInt("run")
@@ -176,17 +189,27 @@ func runArtifacts(credentials *tcclient.Credentials, args []string, out io.Write
 		runID = len(s.Status.Runs) - 1
 	}
 
+	limit, _ := flagSet.GetInt("limit")
+
 	buf := bytes.NewBufferString("")
 	continuation := ""
+	count := 0
 	for {
 		a, err := q.ListArtifacts(taskID, fmt.Sprint(runID), continuation, "")
 		if err != nil {
-			return fmt.Errorf("could not fetch artifacts for task %s run %v: %v", taskID, runID, err)
+			return fmt.Errorf("could not fetch artifacts for task %s run %v: %w", taskID, runID, err)
 		}
 
 		for _, ar := range a.Artifacts {
+			if limit > 0 && count >= limit {
+				break
+			}
+			count++
 			fmt.Fprintf(buf, "%s\n", ar.Name)
 		}
+		if limit > 0 && count >= limit {
+			break
+		}
 
 		continuation = a.ContinuationToken
 		if continuation == "" {
@@ -198,13 +221,20 @@ func runArtifacts(credentials *tcclient.Credentials, args []string, out io.Write
 	return err
 }
 
+// runLog streams the live log of a given task.
 func runLog(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
 	q := makeQueue(credentials)
 	taskID := args[0]
+	follow, _ := flagSet.GetBool("follow")
+	stripANSI, _ := flagSet.GetBool("strip-ansi")
+
+	if follow {
+		return followLog(q, taskID, out, stripANSI)
+	}
 
 	s, err := q.Status(taskID)
 	if err != nil {
-		return fmt.Errorf("could not get the status of the task %s: %v", taskID, err)
+		return fmt.Errorf("could not get the status of the task %s: %w", taskID, err)
 	}
 
 	state := s.Status.State
@@ -212,23 +242,120 @@ func runLog(credentials *tcclient.Credentials, args []string, out io.Writer, fla
 		return fmt.Errorf("could not fetch the logs of task %s because it's in a %s state", taskID, state)
 	}
 
+	return streamLog(taskID, out, 0, stripANSI)
+}
+
+var (
+	// pollInterval is the delay between checks of the state of a task
+	pollInterval = 5 * time.Second
+
+	// maxLogReconnects is the number of times in a row that a followed log
+	// is reconnected without receiving anything, before giving up
+	maxLogReconnects = 5
+
+	// ansiEscape matches ANSI CSI (colors, cursor movements) and OSC (window
+	// titles, hyperlinks) escape sequences
+	ansiEscape = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)`)
+)
+
+// followLog waits for the task to start running, streams its live log,
+// reconnecting if it is interrupted, and returns an error if the task is not
+// completed successfully.
+func followLog(q *tcqueue.Queue, taskID string, out io.Writer, stripANSI bool) error {
+	for {
+		s, err := q.Status(taskID)
+		if err != nil {
+			return fmt.Errorf("could not get the status of the task %s: %w", taskID, err)
+		}
+		if state := s.Status.State; state != "unscheduled" && state != "pending" {
+			break
+		}
+		time.Sleep(pollInterval)
+	}
+
+	if err := streamLog(taskID, out, maxLogReconnects, stripANSI); err != nil {
+		return err
+	}
+
+	// the log may be closed shortly before the run is resolved
+	for {
+		s, err := q.Status(taskID)
+		if err != nil {
+			return fmt.Errorf("could not get the status of the task %s: %w", taskID, err)
+		}
+		switch s.Status.State {
+		case "running":
+			time.Sleep(pollInterval)
+		case "completed":
+			return nil
+		default:
+			return fmt.Errorf("task %s is %s", taskID, s.Status.State)
+		}
+	}
+}
+
+// streamLog copies the live log of a task to out until the log is closed
+// when the task is resolved. If the connection fails, it is re-established
+// up to reconnects times in a row without progress, skipping the part of the
+// log which was already copied.
+func streamLog(taskID string, out io.Writer, reconnects int, stripANSI bool) error {
 	path := tcurls.API(config.RootURL(), "queue", "v1", "task/"+taskID+"/artifacts/public/logs/live.log")
 
+	var offset int64
+	failures := 0
+	for {
+		n, err := copyLog(path, offset, out, stripANSI)
+		if err == nil {
+			return nil
+		}
+		if n > 0 {
+			failures = 0
+		}
+		offset += n
+		failures++
+		if failures > reconnects {
+			return err
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// copyLog copies the log at path to out, line by line, after skipping the
+// first skip bytes. It returns the number of bytes of the log it copied.
+func copyLog(path string, skip int64, out io.Writer, stripANSI bool) (int64, error) {
 	resp, err := http.Get(path)
 	if err != nil {
-		return fmt.Errorf("Error making request to %v: %v", path, err)
+		return 0, fmt.Errorf("Error making request to %v: %w", path, err)
 	}
 	defer resp.Body.Close()
 
-	// Read line by line for live logs.
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		fmt.Fprintln(out, scanner.Text())
+	if resp.StatusCode/100 != 2 {
+		return 0, fmt.Errorf("Received unexpected response code %v", resp.StatusCode)
 	}
 
-	if resp.StatusCode/100 != 2 {
-		return fmt.Errorf("Received unexpected response code %v", resp.StatusCode)
+	if _, err := io.CopyN(ioutil.Discard, resp.Body, skip); err != nil {
+		return 0, fmt.Errorf("could not skip the %d bytes of the log already shown: %w", skip, err)
 	}
 
-	return nil
+	// Read line by line for live logs.
+	reader := bufio.NewReader(resp.Body)
+	var copied int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		copied += int64(len(line))
+		if stripANSI {
+			line = ansiEscape.ReplaceAll(line, nil)
+		}
+		if len(line) > 0 {
+			if _, werr := out.Write(line); werr != nil {
+				return copied, werr
+			}
+		}
+		if err == io.EOF {
+			return copied, nil
+		}
+		if err != nil {
+			return copied, fmt.Errorf("could not read the log: %w", err)
+		}
+	}
 }
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
@@ -29,10 +30,24 @@ func (suite *FakeServerSuite) SetupSuite() {
 	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID, taskHandler)
 	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/status", manifestHandler)
 	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/runs/"+fakeRunID+"/artifacts", artifactsHandler)
+	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/artifacts/public/logs/live.log", liveLogHandler)
 	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/cancel", cancelHandler)
 	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/rerun", reRunHandler)
 	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/runs/"+fakeRunID+"/claim", claimTaskHandler)
 	handler.HandleFunc("/api/queue/v1/task/"+fakeTaskID+"/runs/"+fakeRunID+"/completed", manifestHandler)
+	handler.HandleFunc("/api/queue/v1/task/"+createdTaskID, createTaskHandler)
+	handler.HandleFunc("/api/queue/v1/task/"+awaitCompletedID+"/status", awaitStatusHandler("completed"))
+	handler.HandleFunc("/api/queue/v1/task/"+awaitFailedID+"/status", awaitStatusHandler("failed"))
+	handler.HandleFunc("/api/queue/v1/task/"+awaitBlockedID+"/status", awaitStatusHandler("unscheduled"))
+	handler.HandleFunc("/api/queue/v1/task/"+awaitBlockedID, awaitBlockedTaskHandler)
+	handler.HandleFunc("/api/queue/v1/task/"+awaitPendingID+"/status", awaitStatusHandler("pending"))
+	handler.HandleFunc("/api/queue/v1/task/"+timingsTaskID+"/status", timingsStatusHandler)
+	handler.HandleFunc("/api/worker-manager/v1/worker-pools", listWorkerPoolsHandler)
+	handler.HandleFunc("/api/queue/v1/task/"+interactiveTaskID+"/status", interactiveStatusHandler)
+	handler.HandleFunc("/api/queue/v1/task/"+interactiveTaskID+"/runs/0/artifacts", interactiveArtifactsHandler)
+	handler.HandleFunc("/api/queue/v1/task/"+timingsTaskID+"/runs/1/artifacts/public/logs/live_backing.log", timingsLogHandler)
+	handler.HandleFunc("/api/queue/v1/task/"+createdTaskID+"/status", runStatusHandler)
+	handler.HandleFunc("/api/queue/v1/task/"+createdTaskID+"/artifacts/public/logs/live.log", runLogHandler)
 
 	suite.testServer = httptest.NewServer(handler)
 
@@ -182,6 +197,18 @@ func (suite *FakeServerSuite) TestArtifactsCommand() {
 
 }
 
+func (suite *FakeServerSuite) TestArtifactsCommandLimit() {
+	// set up to run a command and capture output
+	buf, cmd := setUpCommand()
+	cmd.Flags().Int("limit", 1, "")
+
+	// run the command
+	args := []string{fakeTaskID}
+
+	assert.NoError(suite.T(), runArtifacts(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+	suite.Equal("fake_live.log\n", buf.String())
+}
+
 func (suite *FakeServerSuite) TestGroupCommand() {
 	// set up to run a command and capture output
 	buf, cmd := setUpCommand()
@@ -215,3 +242,53 @@ func (suite *FakeServerSuite) TestStatusCommand() {
 
 	suite.Equal("Run #0: completed 'completed'\n", buf.String())
 }
+
+const fakeLog = "\x1b[31mred\x1b[0m line\nhalf line\ndone\n"
+
+// logRequests counts the requests for the live log of the fake task; when
+// logInterrupted is set, the first one breaks off in the middle of a line.
+var logRequests int
+var logInterrupted bool
+
+func liveLogHandler(w http.ResponseWriter, _ *http.Request) {
+	logRequests++
+	if logInterrupted && logRequests == 1 {
+		_, _ = io.WriteString(w, fakeLog[:len(fakeLog)-12])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	_, _ = io.WriteString(w, fakeLog)
+}
+
+func (suite *FakeServerSuite) TestLogCommand() {
+	logRequests, logInterrupted = 0, false
+
+	// set up to run a command and capture output
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("follow", false, "")
+	cmd.Flags().Bool("strip-ansi", false, "")
+
+	// run the command
+	args := []string{fakeTaskID}
+	suite.NoError(runLog(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal(fakeLog, buf.String())
+}
+
+func (suite *FakeServerSuite) TestLogCommandFollow() {
+	defer func(orig time.Duration) { pollInterval = orig }(pollInterval)
+	pollInterval = time.Millisecond
+	logRequests, logInterrupted = 0, true
+
+	// set up to run a command and capture output
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("follow", true, "")
+	cmd.Flags().Bool("strip-ansi", true, "")
+
+	// run the command
+	args := []string{fakeTaskID}
+	suite.NoError(runLog(&tcclient.Credentials{}, args, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal("red line\nhalf line\ndone\n", buf.String())
+	suite.Equal(2, logRequests, "the interrupted log should be reconnected")
+}
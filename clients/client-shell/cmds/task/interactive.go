@@ -0,0 +1,150 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/pkg/browser"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcurls "github.com/taskcluster/taskcluster-lib-urls"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+// The suffixes of the names of the artifacts exposing the interactive
+// sessions of docker-worker and generic-worker tasks, e.g.
+// private/docker-worker/shell.html.
+const (
+	shellArtifactSuffix   = "/shell.html"
+	displayArtifactSuffix = "/display.html"
+)
+
+// openURL opens a URL in the browser of the user.
+var openURL = func(url string) error {
+	// Discard whatever the browser dumps to stdout / stderr
+	browser.Stderr = ioutil.Discard
+	browser.Stdout = ioutil.Discard
+	return browser.OpenURL(url)
+}
+
+func init() {
+	interactiveCmd := &cobra.Command{
+		Use:   "interactive <taskId>",
+		Short: "Attach to the interactive session of a task.",
+		Long: `Wait for the interactive shell of a running task, as exposed by the
+interactive feature of docker-worker and generic-worker, and print the URL to
+connect to it, signed with the current credentials.
+
+With --display, the URL of the VNC display is printed instead, and with
+--open, the URL is also opened in the browser.`,
+		RunE: executeHelperE(runInteractive),
+	}
+	interactiveCmd.Flags().Bool("display", false, "Attach to the VNC display of the task rather than its shell.")
+	interactiveCmd.Flags().Bool("open", false, "Open the URL in the browser.")
+	interactiveCmd.Flags().Duration("timeout", 10*time.Minute, "Give up if the session is not available after this long (0 to wait forever).")
+	interactiveCmd.Flags().Duration("expires", 15*time.Minute, "How long the URL can be used to connect.")
+
+	Command.AddCommand(interactiveCmd)
+}
+
+// runInteractive waits for the interactive session artifact of the latest
+// run of a task, and prints, or opens, its URL.
+func runInteractive(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	q := makeQueue(credentials)
+	taskID := args[0]
+	display, _ := flagSet.GetBool("display")
+	timeout, _ := flagSet.GetDuration("timeout")
+	expires, _ := flagSet.GetDuration("expires")
+
+	suffix, session := shellArtifactSuffix, "shell"
+	if display {
+		suffix, session = displayArtifactSuffix, "display"
+	}
+
+	start := time.Now()
+	waiting := false
+	for {
+		runID, name, err := findSessionArtifact(q, taskID, suffix)
+		if err != nil {
+			return err
+		}
+		if name != "" {
+			u, err := sessionURL(q, credentials, taskID, runID, name, expires)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(out, u)
+			if open, _ := flagSet.GetBool("open"); open {
+				if err := openURL(u); err != nil {
+					return fmt.Errorf("could not open the browser: %w", err)
+				}
+			}
+			return nil
+		}
+
+		if timeout > 0 && time.Since(start)+pollInterval > timeout {
+			return fmt.Errorf("the interactive %s of the task %s was not available after %s", session, taskID, timeout)
+		}
+		if !waiting {
+			fmt.Fprintf(out, "Waiting for the interactive %s of the task %s...\n", session, taskID)
+			waiting = true
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// findSessionArtifact returns the latest run of a task, and the name of its
+// artifact ending with suffix, if it is there yet. It fails if the task is
+// resolved, as its interactive sessions are gone.
+func findSessionArtifact(q *tcqueue.Queue, taskID, suffix string) (int64, string, error) {
+	s, err := q.Status(taskID)
+	if err != nil {
+		return 0, "", fmt.Errorf("could not get the status of the task %s: %w", taskID, err)
+	}
+	switch s.Status.State {
+	case "unscheduled", "pending":
+		return 0, "", nil
+	case "running":
+	default:
+		return 0, "", fmt.Errorf("the task %s is %s; interactive sessions are only available while it runs", taskID, s.Status.State)
+	}
+
+	runID := s.Status.Runs[len(s.Status.Runs)-1].RunID
+	continuation := ""
+	for {
+		a, err := q.ListArtifacts(taskID, fmt.Sprint(runID), continuation, "")
+		if err != nil {
+			return 0, "", fmt.Errorf("could not fetch artifacts for task %s run %v: %w", taskID, runID, err)
+		}
+		for _, artifact := range a.Artifacts {
+			if strings.HasSuffix(artifact.Name, suffix) {
+				return runID, artifact.Name, nil
+			}
+		}
+		if continuation = a.ContinuationToken; continuation == "" {
+			return runID, "", nil
+		}
+	}
+}
+
+// sessionURL returns the URL of an interactive session artifact, signed
+// with the given credentials, as the artifacts are usually private.
+func sessionURL(q *tcqueue.Queue, credentials *tcclient.Credentials, taskID string, runID int64, name string, expires time.Duration) (string, error) {
+	if credentials == nil {
+		return tcurls.API(config.RootURL(), "queue", "v1", fmt.Sprintf("task/%s/runs/%d/artifacts/%s", taskID, runID, name)), nil
+	}
+	if expires <= 0 {
+		return "", errors.New("--expires must be positive")
+	}
+	u, err := q.GetArtifact_SignedURL(taskID, fmt.Sprint(runID), name, expires)
+	if err != nil {
+		return "", fmt.Errorf("could not sign the URL of %s: %w", name, err)
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,79 @@
+package task
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+)
+
+const interactiveTaskID = "In3eRaCtQ2aVYW0bE1ifsw"
+
+// interactivePolls counts the requests to interactiveStatusHandler
+var interactivePolls int
+
+// returns a pending task on the first request, and a running one after
+func interactiveStatusHandler(w http.ResponseWriter, _ *http.Request) {
+	interactivePolls++
+	if interactivePolls == 1 {
+		_, _ = io.WriteString(w, `{"status": {"state": "pending", "runs": [{"runId": 0, "state": "pending"}]}}`)
+		return
+	}
+	_, _ = io.WriteString(w, `{"status": {"state": "running", "runs": [{"runId": 0, "state": "running"}]}}`)
+}
+
+func interactiveArtifactsHandler(w http.ResponseWriter, _ *http.Request) {
+	_, _ = io.WriteString(w, `{"artifacts": [
+	  {"name": "public/logs/live.log"},
+	  {"name": "private/docker-worker/shell.html"},
+	  {"name": "private/docker-worker/display.html"}
+	]}`)
+}
+
+func (suite *FakeServerSuite) TestRunInteractive() {
+	defer func(orig time.Duration) { pollInterval = orig }(pollInterval)
+	pollInterval = time.Millisecond
+	defer func(orig func(string) error) { openURL = orig }(openURL)
+	var opened string
+	openURL = func(url string) error {
+		opened = url
+		return nil
+	}
+	interactivePolls = 0
+
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("display", false, "")
+	cmd.Flags().Bool("open", true, "")
+	cmd.Flags().Duration("timeout", time.Minute, "")
+	cmd.Flags().Duration("expires", time.Minute, "")
+
+	creds := &tcclient.Credentials{ClientID: "tester", AccessToken: "no-secret"}
+	require.NoError(suite.T(), runInteractive(creds, []string{interactiveTaskID}, cmd.OutOrStdout(), cmd.Flags()))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(suite.T(), lines, 2)
+	suite.Equal("Waiting for the interactive shell of the task "+interactiveTaskID+"...", lines[0])
+	suite.Contains(lines[1], "/api/queue/v1/task/"+interactiveTaskID+"/runs/0/artifacts/private%2Fdocker-worker%2Fshell.html?bewit=")
+	suite.Equal(lines[1], opened)
+}
+
+func (suite *FakeServerSuite) TestRunInteractiveDisplay() {
+	interactivePolls = 1
+
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("display", true, "")
+
+	assert.NoError(suite.T(), runInteractive(nil, []string{interactiveTaskID}, cmd.OutOrStdout(), cmd.Flags()))
+	suite.Equal(suite.testServer.URL+"/api/queue/v1/task/"+interactiveTaskID+"/runs/0/artifacts/private/docker-worker/display.html\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestRunInteractiveResolved() {
+	_, cmd := setUpCommand()
+
+	err := runInteractive(nil, []string{timingsTaskID}, cmd.OutOrStdout(), cmd.Flags())
+	suite.EqualError(err, "the task "+timingsTaskID+" is completed; interactive sessions are only available while it runs")
+}
@@ -17,8 +17,21 @@ import (
 
 var (
 	runCmd = &cobra.Command{
-		Use:     "run <image> <command>",
-		Short:   "creates and schedules a task through a 'docker run'-like interface.",
+		Use:   "run [<image> <command>]",
+		Short: "creates and schedules a task through a 'docker run'-like interface.",
+		Long: `Creates and schedules a task through a 'docker run'-like interface, then
+streams its live log until it is resolved.
+
+The image and command can be given as arguments, or with --image and
+--command, in which case the command is run by a shell:
+
+  taskcluster task run --provisioner proj-misc --worker-type ci \
+      --image ubuntu --command 'echo hello; uname -a'
+
+With --generic-worker, a generic-worker payload is created instead of a
+docker-worker payload, and no image is needed.
+
+The exit status is non-zero if the task does not complete successfully.`,
 		PreRunE: checkRunFlags,
 		RunE:    runRunTask,
 	}
@@ -44,6 +57,11 @@ func init() {
 
 	fs.StringVar(&runPayload.ProvisionerID, "provisioner", "", "ID of the provisioner to use")
 	fs.StringVar(&runPayload.WorkerType, "worker-type", "", "Type of worker to use within the provisioner")
+	fs.String("image", "", "Docker image to run the command in")
+	fs.String("command", "", "Shell command to run, instead of the command given as arguments")
+	fs.Bool("generic-worker", false, "Create a generic-worker payload, rather than a docker-worker payload")
+	fs.BoolP("detach", "d", false, "Print the taskId and exit once the task is created, without streaming its log")
+	fs.String("task-id", "", "TaskId of the new task (defaults to a new slugid)")
 	fs.StringSliceP("env", "e", []string{}, "Environment variable to add to the task's environment (repeatable) (format: VARIABLE=VALUE)")
 	fs.StringVar(&runPayload.Metadata.Name, "name", "Taskcluster-cli Task", "Human readable name of the task")
 	fs.StringVar(&runPayload.Metadata.Description, "description", "Created by Taskcluster-cli", "Human readable description of the task")
@@ -79,7 +97,27 @@ func checkRunFlags(cmd *cobra.Command, args []string) error {
 
 // runRunTask takes the task creation payload and runs the task.
 func runRunTask(cmd *cobra.Command, args []string) error {
-	if len(args) < 2 {
+	image, _ := cmd.Flags().GetString("image")
+	shellCommand, _ := cmd.Flags().GetString("command")
+	genericWorker, _ := cmd.Flags().GetBool("generic-worker")
+	detach, _ := cmd.Flags().GetBool("detach")
+
+	// The image is the first argument, unless given with --image or not
+	// needed at all
+	if image == "" && !genericWorker {
+		if len(args) == 0 {
+			return errors.New("run requires at least 2 arguments: image and command")
+		}
+		image, args = args[0], args[1:]
+	}
+	command := args
+	if shellCommand != "" {
+		if len(args) > 0 {
+			return errors.New("a command cannot be given both as arguments and with --command")
+		}
+		command = []string{"/bin/bash", "-c", shellCommand}
+	}
+	if len(command) == 0 {
 		return errors.New("run requires at least 2 arguments: image and command")
 	}
 
@@ -89,7 +127,10 @@ func runRunTask(cmd *cobra.Command, args []string) error {
 	}
 
 	// Generate a new taskID
-	taskID := slugid.Nice()
+	taskID, _ := cmd.Flags().GetString("task-id")
+	if taskID == "" {
+		taskID = slugid.Nice()
+	}
 	runPayload.TaskGroupID = taskID
 
 	// Build the environment variables.
@@ -111,29 +152,50 @@ func runRunTask(cmd *cobra.Command, args []string) error {
 	}
 
 	// Build the task payload.
-	runPayload.Payload, err = json.Marshal(struct {
-		Image       string            `json:"image"`
-		Command     []string          `json:"command"`
-		Environment map[string]string `json:"env"`
-		MaxRunTime  int               `json:"maxRunTime"`
-	}{
-		Image:       args[0],
-		Command:     args[1:],
-		Environment: env,
-		MaxRunTime:  7200, // 2 hours
-	})
+	runPayload.Payload, err = buildRunPayload(image, command, env, genericWorker)
 	if err != nil {
-		return fmt.Errorf("could not marshal execution payload: %v", err)
+		return fmt.Errorf("could not marshal execution payload: %w", err)
 	}
 
 	q := tcqueue.New(creds, config.RootURL())
 	resp, err := q.CreateTask(taskID, runPayload)
 	if err != nil {
-		return fmt.Errorf("could not create task: %v", err)
+		return fmt.Errorf("could not create task: %w", err)
 	}
 
 	// If we got no error, that means the task was successfully rund
-	fmt.Fprintf(cmd.OutOrStdout(), "Task %s created\n", resp.Status.TaskID)
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Task %s created\n", resp.Status.TaskID)
+	if detach {
+		return nil
+	}
 
-	return nil
+	return followLog(q, taskID, out, false)
+}
+
+// buildRunPayload returns a payload running command in the given docker
+// image, or directly on the worker for generic-worker.
+func buildRunPayload(image string, command []string, env map[string]string, genericWorker bool) (json.RawMessage, error) {
+	if genericWorker {
+		return json.Marshal(struct {
+			Command     [][]string        `json:"command"`
+			Environment map[string]string `json:"env"`
+			MaxRunTime  int               `json:"maxRunTime"`
+		}{
+			Command:     [][]string{command},
+			Environment: env,
+			MaxRunTime:  7200, // 2 hours
+		})
+	}
+	return json.Marshal(struct {
+		Image       string            `json:"image"`
+		Command     []string          `json:"command"`
+		Environment map[string]string `json:"env"`
+		MaxRunTime  int               `json:"maxRunTime"`
+	}{
+		Image:       image,
+		Command:     command,
+		Environment: env,
+		MaxRunTime:  7200, // 2 hours
+	})
 }
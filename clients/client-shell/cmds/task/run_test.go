@@ -1,7 +1,11 @@
 package task
 
 import (
+	"bytes"
+	"io"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	assert "github.com/stretchr/testify/require"
@@ -15,3 +19,57 @@ func TestInvalidTaskCreate(t *testing.T) {
 	assert.Error(runRunTask(cmd, []string{}), "create task should error with insufficient args")
 	assert.Error(runRunTask(cmd, []string{"ubuntu:14.04"}), "create task should error with insufficient args")
 }
+
+func TestBuildRunPayload(t *testing.T) {
+	assert := assert.New(t)
+
+	payload, err := buildRunPayload("ubuntu", []string{"echo", "hello"}, map[string]string{"A": "b"}, false)
+	assert.NoError(err)
+	assert.JSONEq(`{"image": "ubuntu", "command": ["echo", "hello"], "env": {"A": "b"}, "maxRunTime": 7200}`, string(payload))
+
+	payload, err = buildRunPayload("", []string{"echo", "hello"}, map[string]string{}, true)
+	assert.NoError(err)
+	assert.JSONEq(`{"command": [["echo", "hello"]], "env": {}, "maxRunTime": 7200}`, string(payload))
+}
+
+// runStatusChecks counts the status requests for the created task, which is
+// running for the first one and completed afterwards
+var runStatusChecks int
+
+func runStatusHandler(w http.ResponseWriter, _ *http.Request) {
+	state := "completed"
+	if runStatusChecks == 0 {
+		state = "running"
+	}
+	runStatusChecks++
+	_, _ = io.WriteString(w, `{"status": {"taskId": "`+createdTaskID+`", "state": "`+state+`", "runs": []}}`)
+}
+
+func runLogHandler(w http.ResponseWriter, _ *http.Request) {
+	_, _ = io.WriteString(w, "hello\nworld\n")
+}
+
+func (suite *FakeServerSuite) TestRunCommandFollow() {
+	defer func(orig time.Duration) { pollInterval = orig }(pollInterval)
+	pollInterval = time.Millisecond
+	runStatusChecks = 0
+
+	buf := &bytes.Buffer{}
+	runCmd.SetOutput(buf)
+	defer runCmd.SetOutput(nil)
+	fs := runCmd.Flags()
+	suite.Require().NoError(fs.Set("image", "ubuntu"))
+	suite.Require().NoError(fs.Set("command", "echo hello; echo world"))
+	suite.Require().NoError(fs.Set("task-id", createdTaskID))
+	defer func() {
+		for _, f := range []string{"image", "command", "task-id"} {
+			_ = fs.Set(f, "")
+		}
+	}()
+
+	suite.NoError(runRunTask(runCmd, nil))
+
+	suite.Equal("Task "+createdTaskID+" created\nhello\nworld\n", buf.String())
+	suite.Equal("ubuntu", createdTask["payload"].(map[string]interface{})["image"])
+	suite.Equal([]interface{}{"/bin/bash", "-c", "echo hello; echo world"}, createdTask["payload"].(map[string]interface{})["command"])
+}
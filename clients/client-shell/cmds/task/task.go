@@ -18,6 +18,11 @@ var (
 		Short: "Get the status of a task.",
 		RunE:  executeHelperE(runStatus),
 	}
+	logCmd = &cobra.Command{
+		Use:   "log <taskId>",
+		Short: "Streams the log until completion.",
+		RunE:  executeHelperE(runLog),
+	}
 	artifactsCmd = &cobra.Command{
 		Use:   "artifacts <taskId>",
 		Short: "Get the name of the artifacts of a task.",
@@ -26,18 +31,27 @@ var (
 	retriggerCmd = &cobra.Command{
 		Use:   "retrigger <taskId>",
 		Short: "Re-trigger a task (new taskId, updated timestamps).",
-		RunE:  executeHelperE(runRetrigger),
+		Long: `Re-trigger a task: create a copy of it with a new taskId, and timestamps
+moved to now.
+
+The definition of the copy can be changed with --set, e.g. --set
+payload.env.DEBUG=1 (values are parsed as JSON if possible), or with --edit,
+which opens it in $EDITOR (or $VISUAL) as YAML. The task is created once the
+editor is closed, or not at all if the file is emptied. The timestamps are
+moved to the time the task is created, keeping the deadline and expires
+durations, as edited.`,
+		RunE: batchHelperE(runRetrigger),
 	}
 	rerunCmd = &cobra.Command{
 		Use:   "rerun <taskId>",
 		Short: "Rerun a task.",
-		RunE:  executeHelperE(runRerun),
+		RunE:  batchHelperE(runRerun),
 	}
 
 	runcancelCmd = &cobra.Command{
 		Use:   "cancel <taskId>",
 		Short: "Cancel a task.",
-		RunE:  executeHelperE(runCancel),
+		RunE:  batchHelperE(runCancel),
 	}
 
 	runcompleteCmd = &cobra.Command{
@@ -52,8 +66,14 @@ func init() {
 	statusCmd.Flags().IntP("run", "r", -1, "Specifies which run to consider.")
 
 	artifactsCmd.Flags().IntP("run", "r", -1, "Specifies which run to consider.")
+	artifactsCmd.Flags().IntP("limit", "l", 0, "Only list the first <limit> artifacts (0 for all).")
+
+	logCmd.Flags().BoolP("follow", "f", false, "Wait for the task to start, reconnect if the log is interrupted, and exit with the resolution of the task.")
+	logCmd.Flags().Bool("strip-ansi", false, "Remove ANSI escape sequences (colors, cursor movements) from the log.")
 
 	retriggerCmd.Flags().BoolP("exact", "e", false, "Retrigger in exact mode. WARNING: THIS MAY HAVE SIDE EFFECTS. USE AFTER YOU READ THE SOURCE CODE.")
+	retriggerCmd.Flags().Bool("edit", false, "Edit the definition of the new task in $EDITOR before it is created.")
+	retriggerCmd.Flags().StringArray("set", nil, "(can be repeated) Set a property of the definition of the new task (format: path.to.property=VALUE).")
 
 	rerunCmd.Flags().BoolP("noop", "n", false, "Using this flag, will tell the command to not actually run, but prints out what it would do.")
 	rerunCmd.Flags().BoolP("confirm", "c", false, "Prompts user with a confirmation (y/n) before performing any changes.")
@@ -62,6 +82,10 @@ func init() {
 	runcancelCmd.Flags().BoolP("noop", "n", false, "Using this flag, will tell the command to not actually run, but prints out what it would do.")
 	runcancelCmd.Flags().BoolP("confirm", "c", false, "Prompts user with a confirmation (y/n) before performing any changes.")
 
+	for _, cmd := range []*cobra.Command{runcancelCmd, rerunCmd, retriggerCmd} {
+		addBatchFlags(cmd)
+	}
+
 	runcompleteCmd.Flags().BoolP("noop", "n", false, "Using this flag, will tell the command to not actually run, but prints out what it would do.")
 	runcompleteCmd.Flags().BoolP("confirm", "c", false, "Prompts user with a confirmation (y/n) before performing any changes.")
 	// Commands that fetch information
@@ -89,11 +113,7 @@ func init() {
 		// artifacts
 		artifactsCmd,
 		// log
-		&cobra.Command{
-			Use:   "log <taskId>",
-			Short: "Streams the log until completion.",
-			RunE:  executeHelperE(runLog),
-		},
+		logCmd,
 	)
 
 	// Commands that take actions
@@ -0,0 +1,166 @@
+package task
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcurls "github.com/taskcluster/taskcluster-lib-urls"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+func init() {
+	timingsCmd := &cobra.Command{
+		Use:   "timings <taskId>",
+		Short: "Show how long the runs of a task waited and ran.",
+		Long: `Show how long each run of a task waited in the queue, between being
+scheduled and started, and ran, between being started and resolved.
+
+With --steps, the logs of the runs are fetched, and the time of each step
+logged by the worker, on lines starting with [taskcluster <time>], is shown
+too.`,
+		RunE: executeHelperE(runTimings),
+	}
+	timingsCmd.Flags().Bool("steps", false, "Show the time taken by each step logged by the worker.")
+
+	Command.AddCommand(timingsCmd)
+}
+
+// runTimings prints the timings of each run of a task.
+func runTimings(credentials *tcclient.Credentials, args []string, out io.Writer, flagSet *pflag.FlagSet) error {
+	q := makeQueue(credentials)
+	taskID := args[0]
+
+	s, err := q.Status(taskID)
+	if err != nil {
+		return fmt.Errorf("could not get the status of the task %s: %w", taskID, err)
+	}
+	if len(s.Status.Runs) == 0 {
+		fmt.Fprintf(out, "Task %s has no runs.\n", taskID)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "RUN\tSTATE\tWORKER\tSCHEDULED\tQUEUE WAIT\tRUN TIME")
+	for _, run := range s.Status.Runs {
+		scheduled, started, resolved := time.Time(run.Scheduled), time.Time(run.Started), time.Time(run.Resolved)
+		worker := "-"
+		if run.WorkerGroup != "" || run.WorkerID != "" {
+			worker = run.WorkerGroup + "/" + run.WorkerID
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", run.RunID, getRunStatusString(run.State, run.ReasonResolved), worker,
+			formatTime(scheduled), between(scheduled, started), between(started, resolved))
+	}
+	w.Flush()
+
+	if steps, _ := flagSet.GetBool("steps"); !steps {
+		return nil
+	}
+	for _, run := range s.Status.Runs {
+		if time.Time(run.Started).IsZero() {
+			continue
+		}
+		fmt.Fprintf(out, "\nSteps of run %d:\n", run.RunID)
+		if err := writeSteps(taskID, run, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatTime formats t, or returns - if it is not set.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// between returns the time between from and to, or - if either is not set.
+func between(from, to time.Time) string {
+	if from.IsZero() || to.IsZero() {
+		return "-"
+	}
+	return to.Sub(from).Round(time.Second).String()
+}
+
+var (
+	// stepLine matches the lines logged by workers at the start of each
+	// step, e.g. "[taskcluster 2020-03-29T15:00:00.000Z] Downloading image".
+	stepLine = regexp.MustCompile(`^\[taskcluster (\d{4}-\d\d-\d\d[T ]\d\d:\d\d:\d\d(?:\.\d+)?Z)\] (.*)$`)
+
+	// stepTimeLayouts are the formats of the times of steps, as logged by
+	// the generic and docker workers.
+	stepTimeLayouts = []string{"2006-01-02T15:04:05.999999999Z", "2006-01-02 15:04:05.999999999Z"}
+)
+
+// logStep is a step of a run, as found in its log.
+type logStep struct {
+	start time.Time
+	name  string
+}
+
+// writeSteps prints the steps logged in the log of a run, with the time
+// since the run started and their duration, up to the next step, or the
+// resolution of the run.
+func writeSteps(taskID string, run tcqueue.RunInformation, out io.Writer) error {
+	path := tcurls.API(config.RootURL(), "queue", "v1", fmt.Sprintf("task/%s/runs/%d/artifacts/public/logs/live_backing.log", taskID, run.RunID))
+	resp, err := http.Get(path)
+	if err != nil {
+		return fmt.Errorf("could not get the log of run %d: %w", run.RunID, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		fmt.Fprintf(out, "  no log available (%s)\n", resp.Status)
+		return nil
+	}
+
+	steps, err := parseSteps(resp.Body)
+	if err != nil {
+		return fmt.Errorf("could not read the log of run %d: %w", run.RunID, err)
+	}
+	if len(steps) == 0 {
+		fmt.Fprintln(out, "  no steps logged")
+		return nil
+	}
+
+	started, resolved := time.Time(run.Started), time.Time(run.Resolved)
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "  AT\tDURATION\tSTEP")
+	for i, step := range steps {
+		end := resolved
+		if i+1 < len(steps) {
+			end = steps[i+1].start
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", between(started, step.start), between(step.start, end), step.name)
+	}
+	return w.Flush()
+}
+
+// parseSteps returns the steps logged by the worker in a log.
+func parseSteps(log io.Reader) ([]logStep, error) {
+	var steps []logStep
+	scanner := bufio.NewScanner(log)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		m := stepLine.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		for _, layout := range stepTimeLayouts {
+			if t, err := time.Parse(layout, m[1]); err == nil {
+				steps = append(steps, logStep{start: t, name: m[2]})
+				break
+			}
+		}
+	}
+	return steps, scanner.Err()
+}
@@ -0,0 +1,52 @@
+package task
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/stretchr/testify/assert"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+)
+
+const timingsTaskID = "Ti2n8zWfRFu5hXyNGnGbsQ"
+
+// returns a task with an exception run and a completed run
+func timingsStatusHandler(w http.ResponseWriter, _ *http.Request) {
+	_, _ = io.WriteString(w, `{"status": {"state": "completed", "runs": [
+	  {"runId": 0, "state": "exception", "reasonResolved": "worker-shutdown", "workerGroup": "us-east-1", "workerId": "i-1",
+	   "scheduled": "2020-03-29T15:00:00.000Z", "started": "2020-03-29T15:01:00.000Z", "resolved": "2020-03-29T15:02:00.000Z"},
+	  {"runId": 1, "state": "completed", "reasonResolved": "completed", "workerGroup": "us-east-1", "workerId": "i-2",
+	   "scheduled": "2020-03-29T15:02:00.000Z", "started": "2020-03-29T15:12:00.000Z", "resolved": "2020-03-29T15:20:30.000Z"}
+	]}}`)
+}
+
+func timingsLogHandler(w http.ResponseWriter, _ *http.Request) {
+	_, _ = io.WriteString(w, `[taskcluster 2020-03-29T15:12:01.000Z] Worker Type (linux) settings:
+  "config": {}
+[taskcluster 2020-03-29 15:12:05.000Z] Downloading image
+[taskcluster 2020-03-29T15:14:05.000Z] Executing command 0: make
+build output
+[taskcluster:error] not a step
+`)
+}
+
+func (suite *FakeServerSuite) TestRunTimings() {
+	buf, cmd := setUpCommand()
+	cmd.Flags().Bool("steps", true, "")
+
+	assert.NoError(suite.T(), runTimings(&tcclient.Credentials{}, []string{timingsTaskID}, cmd.OutOrStdout(), cmd.Flags()))
+
+	suite.Equal(`RUN  STATE                        WORKER         SCHEDULED             QUEUE WAIT  RUN TIME
+0    exception 'worker-shutdown'  us-east-1/i-1  2020-03-29T15:00:00Z  1m0s        1m0s
+1    completed 'completed'        us-east-1/i-2  2020-03-29T15:02:00Z  10m0s       8m30s
+
+Steps of run 0:
+  no log available (404 Not Found)
+
+Steps of run 1:
+  AT    DURATION  STEP
+  1s    4s        Worker Type (linux) settings:
+  5s    2m0s      Downloading image
+  2m5s  6m25s     Executing command 0: make
+`, buf.String())
+}
@@ -0,0 +1,271 @@
+package task
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcworkermanager"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+// maxSuggestions is the number of matching worker pools listed at once.
+const maxSuggestions = 20
+
+// wizard asks the questions of `task create --interactive` on out, and
+// reads the answers from in.
+type wizard struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask prints a question, with its default answer if any, and returns the
+// answer, or the default if the answer is empty.
+func (w *wizard) ask(question, defaultAnswer string) (string, error) {
+	if defaultAnswer != "" {
+		fmt.Fprintf(w.out, "%s [%s]: ", question, defaultAnswer)
+	} else {
+		fmt.Fprintf(w.out, "%s: ", question)
+	}
+	answer, err := w.in.ReadString('\n')
+	if err != nil && (err != io.EOF || answer == "") {
+		return "", fmt.Errorf("could not read the answer: %w", err)
+	}
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return defaultAnswer, nil
+	}
+	return answer, nil
+}
+
+// askList asks for a comma-separated list.
+func (w *wizard) askList(question string) ([]string, error) {
+	answer, err := w.ask(question, "")
+	if err != nil {
+		return nil, err
+	}
+	var items []string
+	for _, item := range strings.Split(answer, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// askWorkerPool asks for a worker pool, suggesting the known worker pools
+// matching the answer until one is picked.
+func (w *wizard) askWorkerPool(pools []string) (string, error) {
+	var suggestions []string
+	for {
+		answer, err := w.ask("Worker pool (<provisionerId>/<workerType>, or part of one to search)", "")
+		if err != nil {
+			return "", err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(suggestions) {
+			return suggestions[n-1], nil
+		}
+
+		suggestions = nil
+		for _, pool := range pools {
+			if pool == answer {
+				return pool, nil
+			}
+			if strings.Contains(pool, answer) {
+				suggestions = append(suggestions, pool)
+			}
+		}
+		switch {
+		case len(suggestions) == 1:
+			return suggestions[0], nil
+		case len(suggestions) == 0 && strings.Count(answer, "/") == 1:
+			fmt.Fprintf(w.out, "Warning: %s is not a known worker pool.\n", answer)
+			return answer, nil
+		case len(suggestions) == 0:
+			fmt.Fprintln(w.out, "No matching worker pools.")
+			continue
+		}
+		if len(suggestions) > maxSuggestions {
+			fmt.Fprintf(w.out, "%d matching worker pools, including:\n", len(suggestions))
+			suggestions = suggestions[:maxSuggestions]
+		}
+		for i, pool := range suggestions {
+			fmt.Fprintf(w.out, "  %d) %s\n", i+1, pool)
+		}
+		fmt.Fprintln(w.out, "Pick one by number, or refine the search.")
+	}
+}
+
+// listWorkerPools returns the ids of the worker pools of the deployment,
+// which are suggested by the wizard. Errors, e.g. due to missing scopes,
+// only result in no suggestions.
+func listWorkerPools(credentials *tcclient.Credentials) []string {
+	wm := tcworkermanager.New(credentials, config.RootURL())
+	var pools []string
+	continuation := ""
+	for {
+		resp, err := wm.ListWorkerPools(continuation, "")
+		if err != nil {
+			return pools
+		}
+		for _, pool := range resp.WorkerPools {
+			pools = append(pools, pool.WorkerPoolID)
+		}
+		if continuation = resp.ContinuationToken; continuation == "" {
+			sort.Strings(pools)
+			return pools
+		}
+	}
+}
+
+// runWizard asks for the properties of a task, and returns its definition
+// once confirmed, or nil if the user gave up.
+func runWizard(credentials *tcclient.Credentials, out io.Writer, flagSet *pflag.FlagSet) ([]byte, error) {
+	w := &wizard{in: bufio.NewReader(stdin), out: out}
+
+	pool, err := w.askWorkerPool(listWorkerPools(credentials))
+	if err != nil {
+		return nil, err
+	}
+	image, err := w.ask("Docker image (empty for workers running commands directly, such as generic-worker)", "")
+	if err != nil {
+		return nil, err
+	}
+	command, err := w.ask("Command, run with bash", "")
+	if err != nil {
+		return nil, err
+	}
+	maxRunTime, err := w.ask("Maximum run time, in seconds", "3600")
+	if err != nil {
+		return nil, err
+	}
+	name, err := w.ask("Name", "")
+	if err != nil {
+		return nil, err
+	}
+	owner, err := w.ask("Owner email", "")
+	if err != nil {
+		return nil, err
+	}
+	scopes, err := w.askList("Scopes (comma-separated)")
+	if err != nil {
+		return nil, err
+	}
+	artifacts, err := w.askList("Artifacts, as <path> or <name>=<path>, with a trailing / for directories (comma-separated)")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	def, err := wizardDefinition(pool, image, command, maxRunTime, name, owner, scopes, artifacts, now)
+	if err != nil {
+		return nil, err
+	}
+	data, err := yaml.Marshal(def)
+	if err != nil {
+		return nil, fmt.Errorf("could not render the task definition: %w", err)
+	}
+
+	// show the definition with the --set overrides applied, as created
+	sets, _ := flagSet.GetStringArray("set")
+	final, err := parseTaskDefinition(data, sets, now)
+	if err != nil {
+		return nil, err
+	}
+	shown, err := yaml.Marshal(final)
+	if err != nil {
+		return nil, fmt.Errorf("could not render the task definition: %w", err)
+	}
+	fmt.Fprintf(w.out, "\n%s\n", shown)
+	for {
+		answer, err := w.ask("Create this task? (y/N)", "n")
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return data, nil
+		case "n", "no":
+			return nil, nil
+		}
+	}
+}
+
+// wizardDefinition assembles the definition of a task from the answers of
+// the wizard, with the payload of docker-worker if an image is given, or
+// that of generic-worker. The task and its artifacts expire after 30 days.
+func wizardDefinition(pool, image, command, maxRunTime, name, owner string, scopes, artifacts []string, now time.Time) (map[string]interface{}, error) {
+	p := strings.SplitN(pool, "/", 2)
+	if len(p) != 2 || p[0] == "" || p[1] == "" {
+		return nil, fmt.Errorf("invalid worker pool %q, expected <provisionerId>/<workerType>", pool)
+	}
+	runTime, err := strconv.Atoi(maxRunTime)
+	if err != nil || runTime <= 0 {
+		return nil, fmt.Errorf("invalid maximum run time %q", maxRunTime)
+	}
+	if name == "" {
+		name = "Task created with taskcluster task create --interactive"
+	}
+
+	shell := []string{"/bin/bash", "-c", command}
+	payload := map[string]interface{}{"maxRunTime": runTime}
+	if image != "" {
+		payload["image"] = image
+		payload["command"] = shell
+	} else {
+		payload["command"] = [][]string{shell}
+	}
+
+	if len(artifacts) > 0 {
+		dockerArtifacts := make(map[string]interface{})
+		var genericArtifacts []interface{}
+		for _, artifact := range artifacts {
+			artifactName, artifactPath := "", artifact
+			if a := strings.SplitN(artifact, "=", 2); len(a) == 2 {
+				artifactName, artifactPath = a[0], a[1]
+			}
+			artifactType := "file"
+			if strings.HasSuffix(artifactPath, "/") {
+				artifactType = "directory"
+				artifactPath = strings.TrimSuffix(artifactPath, "/")
+			}
+			if artifactName == "" {
+				artifactName = "public/" + path.Base(artifactPath)
+			}
+			if image != "" {
+				dockerArtifacts[artifactName] = map[string]interface{}{"type": artifactType, "path": artifactPath}
+			} else {
+				genericArtifacts = append(genericArtifacts, map[string]interface{}{"type": artifactType, "path": artifactPath, "name": artifactName})
+			}
+		}
+		if image != "" {
+			payload["artifacts"] = dockerArtifacts
+		} else {
+			payload["artifacts"] = genericArtifacts
+		}
+	}
+
+	def := map[string]interface{}{
+		"provisionerId": p[0],
+		"workerType":    p[1],
+		"expires":       now.AddDate(0, 0, 30).Format(time.RFC3339),
+		"payload":       payload,
+		"metadata": map[string]interface{}{
+			"name":        name,
+			"description": name,
+			"owner":       owner,
+			"source":      config.RootURL() + "/tasks/create",
+		},
+	}
+	if len(scopes) > 0 {
+		def["scopes"] = scopes
+	}
+	return def, nil
+}
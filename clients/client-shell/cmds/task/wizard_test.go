@@ -0,0 +1,78 @@
+package task
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+)
+
+// returns the worker pools suggested by the wizard
+func listWorkerPoolsHandler(w http.ResponseWriter, _ *http.Request) {
+	_, _ = io.WriteString(w, `{"workerPools": [
+	  {"workerPoolId": "proj/linux-large"},
+	  {"workerPoolId": "proj/linux-small"},
+	  {"workerPoolId": "proj/windows"}
+	]}`)
+}
+
+// runs `task create --interactive` with the given answers
+func runCreateInteractive(answers ...string) (string, error) {
+	defer func(orig io.Reader) { stdin = orig }(stdin)
+	stdin = strings.NewReader(strings.Join(answers, "\n") + "\n")
+
+	buf, cmd := setUpCommand()
+	cmd.Flags().String("file", "", "")
+	cmd.Flags().Bool("interactive", true, "")
+	cmd.Flags().StringArray("set", []string{"priority=high"}, "")
+	cmd.Flags().String("task-id", createdTaskID, "")
+	err := runCreate(&tcclient.Credentials{}, nil, cmd.OutOrStdout(), cmd.Flags())
+	return buf.String(), err
+}
+
+func (suite *FakeServerSuite) TestCreateInteractive() {
+	createdTask = nil
+	out, err := runCreateInteractive(
+		"linux",                    // worker pool search
+		"2",                        // pick proj/linux-small
+		"ubuntu:20.04",             // image
+		"make test",                // command
+		"",                         // default max run time
+		"tests",                    // name
+		"me@example.com",           // owner
+		"secrets:get:a",            // scopes
+		"out/log.txt, dist=build/", // artifacts
+		"y",
+	)
+	assert.NoError(suite.T(), err)
+
+	suite.Contains(out, "  1) proj/linux-large\n  2) proj/linux-small\n")
+	suite.Contains(out, "priority: high\n", "the definition should be shown with the --set overrides")
+	suite.Contains(out, "Task "+createdTaskID+" created\n")
+
+	suite.Equal("proj", createdTask["provisionerId"])
+	suite.Equal("linux-small", createdTask["workerType"])
+	suite.Equal("high", createdTask["priority"])
+	suite.Equal([]interface{}{"secrets:get:a"}, createdTask["scopes"])
+	payload := createdTask["payload"].(map[string]interface{})
+	suite.Equal("ubuntu:20.04", payload["image"])
+	suite.Equal([]interface{}{"/bin/bash", "-c", "make test"}, payload["command"])
+	suite.Equal(float64(3600), payload["maxRunTime"])
+	suite.NotContains(createdTask["expires"], "0001-", "the task should expire")
+	suite.Equal(map[string]interface{}{
+		"public/log.txt": map[string]interface{}{"type": "file", "path": "out/log.txt"},
+		"dist":           map[string]interface{}{"type": "directory", "path": "build"},
+	}, payload["artifacts"])
+}
+
+func (suite *FakeServerSuite) TestCreateInteractiveAborted() {
+	createdTask = nil
+	out, err := runCreateInteractive("proj/windows", "", "dir", "", "", "", "", "", "n")
+	assert.NoError(suite.T(), err)
+
+	suite.Contains(out, "  command:\n  -\n    - /bin/bash\n", "generic-worker commands should be used without an image")
+	suite.Contains(out, "Task creation aborted.\n")
+	suite.Nil(createdTask)
+}
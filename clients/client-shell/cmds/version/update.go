@@ -0,0 +1,294 @@
+package version
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	s "strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Asset describes a download url for a published releases.
+type Asset struct {
+	Name     string `json:"name"`
+	Download string `json:"browser_download_url"`
+}
+
+// Release provided through GitHub for new tc-client shells
+type Release struct {
+	Name    string  `json:"name"`
+	Assets  []Asset `json:"assets"`
+	Message string  `json:"message"`
+}
+
+// checksumsAsset is the release asset listing the SHA-256 checksums of the
+// other assets, in the format of sha256sum; signatureAsset is its detached
+// ed25519 signature, base64-encoded.
+const (
+	checksumsAsset = "SHA256SUMS"
+	signatureAsset = "SHA256SUMS.sig"
+)
+
+var (
+	// Updcommand  is the cobra command to check for a new update.
+	Updcommand = &cobra.Command{
+		Use:   "update",
+		Short: "Updates Taskcluster",
+		Long: `Download the latest release of taskcluster for this platform, verify it,
+and replace the running binary with it.
+
+The download is verified against the SHA256SUMS asset of the release, and the
+signature of SHA256SUMS against the release signing key embedded in this build.
+Releases without a signature are not installed, and builds without the key only
+print the download URL of the release. The binary is replaced atomically, so an
+interrupted update leaves the current version in place.`,
+		RunE: update,
+	}
+
+	// releasesURL is the GitHub API endpoint of the latest release.
+	releasesURL = "https://api.github.com/repos/taskcluster/taskcluster/releases/latest"
+
+	// releaseKey is the base64-encoded ed25519 public key signing the
+	// checksums of releases. It is set at build time, with
+	// -ldflags "-X .../cmds/version.releaseKey=<key>"; if it is empty, the
+	// authenticity of releases can't be verified, and update only prints
+	// the download URL of the latest release.
+	releaseKey = ""
+
+	// executable returns the path of the running binary.
+	executable = os.Executable
+)
+
+func init() {
+	Updcommand.Flags().Bool("force", false, "Install the latest release even if it is not newer.")
+}
+
+func update(cmd *cobra.Command, _ []string) error {
+	out := cmd.OutOrStdout()
+	release, err := latestRelease()
+	if err != nil {
+		return err
+	}
+	if force, _ := cmd.Flags().GetBool("force"); !force && !newerVersion(release.Name, VersionNumber) {
+		fmt.Fprintf(out, "taskcluster is already on the most recent version, %s.\n", release.Name)
+		return nil
+	}
+
+	name := assetName()
+	binary, ok := release.asset(name)
+	if !ok {
+		return fmt.Errorf("no update available for %s/%s in %s", runtime.GOOS, runtime.GOARCH, release.Name)
+	}
+	if releaseKey == "" {
+		// Without a key, the download can't be verified, so only report
+		// where to get it.
+		fmt.Fprintf(out, "This build of taskcluster can't verify releases; to update to %s, download it with:\n", release.Name)
+		fmt.Fprintf(out, "# %s\n", binary.Download)
+		fmt.Fprintf(out, "curl -L %s -o taskcluster\n", binary.Download)
+		return nil
+	}
+	sums, err := release.checksums()
+	if err != nil {
+		return err
+	}
+	expected, ok := sums[name]
+	if !ok {
+		return fmt.Errorf("%s of %s has no checksum for %s", checksumsAsset, release.Name, name)
+	}
+
+	exe, err := executable()
+	if err != nil {
+		return fmt.Errorf("could not find the taskcluster binary: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return fmt.Errorf("could not find the taskcluster binary: %w", err)
+	}
+	if err := install(binary.Download, expected, exe); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Updated taskcluster from v%s to %s.\n", VersionNumber, release.Name)
+	return nil
+}
+
+// latestRelease fetches the latest release from GitHub.
+func latestRelease() (*Release, error) {
+	body, err := fetch(releasesURL)
+	R := &Release{}
+	// GitHub reports rate limits with a 403 and a message.
+	if json.Unmarshal(body, R) == nil && s.Contains(R.Message, "API rate limit") {
+		return nil, fmt.Errorf("GitHub API Rate limit exceeded")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch the latest release: %w", err)
+	}
+	if err := json.Unmarshal(body, R); err != nil {
+		return nil, fmt.Errorf("could not parse the latest release: %w", err)
+	}
+	if R.Name == "" {
+		return nil, errors.New("could not parse the latest release: it has no name")
+	}
+	return R, nil
+}
+
+// asset returns the asset of the release with the given name.
+func (r *Release) asset(name string) (Asset, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset, true
+		}
+	}
+	return Asset{}, false
+}
+
+// checksums downloads the checksums of the release, verifies their signature
+// with the release key, and returns them by asset name.
+func (r *Release) checksums() (map[string]string, error) {
+	asset, ok := r.asset(checksumsAsset)
+	if !ok {
+		return nil, fmt.Errorf("%s has no %s to verify the download against", r.Name, checksumsAsset)
+	}
+	sums, err := fetch(asset.Download)
+	if err != nil {
+		return nil, fmt.Errorf("could not download %s: %w", checksumsAsset, err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(releaseKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("the release signing key of this build is invalid")
+	}
+	asset, ok = r.asset(signatureAsset)
+	if !ok {
+		return nil, fmt.Errorf("%s has no %s to verify the download against", r.Name, signatureAsset)
+	}
+	sig, err := fetch(asset.Download)
+	if err != nil {
+		return nil, fmt.Errorf("could not download %s: %w", signatureAsset, err)
+	}
+	sig, err = base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sig)))
+	if err != nil || !ed25519.Verify(key, sums, sig) {
+		return nil, fmt.Errorf("the signature of %s of %s is invalid", checksumsAsset, r.Name)
+	}
+
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := s.Fields(scanner.Text())
+		if len(fields) == 2 {
+			checksums[s.TrimPrefix(fields[1], "*")] = s.ToLower(fields[0])
+		}
+	}
+	return checksums, nil
+}
+
+// install downloads a binary next to exe, checks its SHA-256 checksum, and
+// renames it over exe.
+func install(url, checksum, exe string) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return fmt.Errorf("could not find the taskcluster binary: %w", err)
+	}
+	// The download goes in the same directory, so that renaming it is atomic.
+	tmp, err := ioutil.TempFile(filepath.Dir(exe), ".taskcluster-update-")
+	if err != nil {
+		return fmt.Errorf("could not create the new taskcluster binary: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	resp, err := http.Get(url)
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		tmp.Close()
+		return fmt.Errorf("could not download %s: %s", url, resp.Status)
+	}
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, hash), resp.Body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("could not download %s: %w", url, err)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != checksum {
+		return fmt.Errorf("the checksum of %s is %s, expected %s; not updating", url, sum, checksum)
+	}
+
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("could not make the new taskcluster binary executable: %w", err)
+	}
+	if runtime.GOOS == "windows" {
+		// A running binary can't be replaced on Windows, but it can be renamed.
+		old := exe + ".old"
+		os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return fmt.Errorf("could not replace the taskcluster binary: %w", err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		return fmt.Errorf("could not replace the taskcluster binary: %w", err)
+	}
+	return nil
+}
+
+// fetch returns the body of a GET request, which is also returned when the
+// request fails with an error status.
+func fetch(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return body, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return body, nil
+}
+
+// assetName is the name of the release asset for this platform.
+func assetName() string {
+	name := fmt.Sprintf("taskcluster-%s-%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// newerVersion returns whether the release name, e.g. v27.1.0, is a newer
+// version than current, e.g. 27.0.0.
+func newerVersion(name, current string) bool {
+	a, b := s.Split(s.TrimPrefix(name, "v"), "."), s.Split(current, ".")
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x, _ = strconv.Atoi(a[i])
+		}
+		if i < len(b) {
+			y, _ = strconv.Atoi(b[i])
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	return false
+}
@@ -2,6 +2,17 @@ package version
 
 import (
 	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/spf13/cobra"
@@ -12,27 +23,233 @@ func setUpCommand() (*bytes.Buffer, *cobra.Command) {
 	buf := &bytes.Buffer{}
 	cmd := &cobra.Command{}
 	cmd.SetOutput(buf)
+	cmd.Flags().Bool("check", false, "")
+	cmd.Flags().Bool("force", false, "")
 
 	return buf, cmd
 }
 
+// fakeRelease serves a release named name, with a binary for this platform
+// and the given checksums and signature, and points releasesURL at it.
+type fakeRelease struct {
+	name      string
+	binary    []byte
+	checksums string
+	signature string
+}
+
+func (f *fakeRelease) serve() func() {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	assets := fmt.Sprintf(`{"name": %q, "browser_download_url": "%s/%s"}`, assetName(), server.URL, assetName())
+	if f.checksums != "" {
+		assets += fmt.Sprintf(`, {"name": "SHA256SUMS", "browser_download_url": "%s/SHA256SUMS"}`, server.URL)
+	}
+	if f.signature != "" {
+		assets += fmt.Sprintf(`, {"name": "SHA256SUMS.sig", "browser_download_url": "%s/SHA256SUMS.sig"}`, server.URL)
+	}
+	mux.HandleFunc("/latest", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, `{"name": %q, "assets": [%s]}`, f.name, assets)
+	})
+	mux.HandleFunc("/"+assetName(), func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write(f.binary) })
+	mux.HandleFunc("/SHA256SUMS", func(w http.ResponseWriter, _ *http.Request) { fmt.Fprint(w, f.checksums) })
+	mux.HandleFunc("/SHA256SUMS.sig", func(w http.ResponseWriter, _ *http.Request) { fmt.Fprint(w, f.signature) })
+
+	oldURL := releasesURL
+	releasesURL = server.URL + "/latest"
+	return func() {
+		releasesURL = oldURL
+		server.Close()
+	}
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// withExecutable points executable at a fake binary, returning its path.
+func withExecutable(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "taskcluster-update")
+	assert.NoError(t, err)
+	exe := filepath.Join(dir, "taskcluster")
+	assert.NoError(t, ioutil.WriteFile(exe, []byte("old binary"), 0755))
+
+	oldExecutable := executable
+	executable = func() (string, error) { return exe, nil }
+	return exe, func() {
+		executable = oldExecutable
+		os.RemoveAll(dir)
+	}
+}
+
 func TestVersionCommand(t *testing.T) {
 	assert := assert.New(t)
 
 	buf, cmd := setUpCommand()
 
-	printVersion(cmd, nil)
+	assert.NoError(printVersion(cmd, nil))
 
 	assert.Contains(buf.String(), VersionNumber, "VersionNumber not found in version output")
 }
 
+func TestVersionCheck(t *testing.T) {
+	assert := assert.New(t)
+	defer (&fakeRelease{name: "v1000.0.0"}).serve()()
+
+	buf, cmd := setUpCommand()
+	assert.NoError(cmd.Flags().Set("check", "true"))
+	assert.NoError(printVersion(cmd, nil))
+	assert.Contains(buf.String(), "A newer version, v1000.0.0, is available")
+}
+
+// withReleaseKey sets releaseKey to a new key, returning a function signing
+// checksums with it.
+func withReleaseKey(t *testing.T) (func(string) string, func()) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	oldKey := releaseKey
+	releaseKey = base64.StdEncoding.EncodeToString(public)
+	sign := func(data string) string {
+		return base64.StdEncoding.EncodeToString(ed25519.Sign(private, []byte(data)))
+	}
+	return sign, func() { releaseKey = oldKey }
+}
+
 func TestUpdateCommand(t *testing.T) {
+	binary := []byte("new binary")
+	sums := fmt.Sprintf("%s  %s\n%s  other\n", checksum(binary), assetName(), checksum(nil))
+
+	t.Run("unsigned build", func(t *testing.T) {
+		assert := assert.New(t)
+		defer (&fakeRelease{name: "v1000.0.0", binary: binary, checksums: sums}).serve()()
+		exe, cleanup := withExecutable(t)
+		defer cleanup()
+
+		buf, cmd := setUpCommand()
+		err := update(cmd, nil)
+		assert.NoError(err)
+		assert.Contains(buf.String(), "/"+assetName()+" -o taskcluster\n")
+		data, err := ioutil.ReadFile(exe)
+		assert.NoError(err)
+		assert.Equal("old binary", string(data))
+	})
+
+	sign, restore := withReleaseKey(t)
+	defer restore()
+
+	t.Run("unsigned release", func(t *testing.T) {
+		assert := assert.New(t)
+		defer (&fakeRelease{name: "v1000.0.0", binary: binary, checksums: sums}).serve()()
+		exe, cleanup := withExecutable(t)
+		defer cleanup()
+
+		_, cmd := setUpCommand()
+		err := update(cmd, nil)
+		assert.Error(err)
+		assert.Contains(err.Error(), "has no SHA256SUMS.sig")
+		data, err := ioutil.ReadFile(exe)
+		assert.NoError(err)
+		assert.Equal("old binary", string(data))
+	})
+
+	t.Run("signed release", func(t *testing.T) {
+		assert := assert.New(t)
+		defer (&fakeRelease{name: "v1000.0.0", binary: binary, checksums: sums, signature: sign(sums) + "\n"}).serve()()
+		exe, cleanup := withExecutable(t)
+		defer cleanup()
+
+		buf, cmd := setUpCommand()
+		assert.NoError(update(cmd, nil))
+		assert.Equal(fmt.Sprintf("Updated taskcluster from v%s to v1000.0.0.\n", VersionNumber), buf.String())
+
+		data, err := ioutil.ReadFile(exe)
+		assert.NoError(err)
+		assert.Equal(binary, data)
+		info, err := os.Stat(exe)
+		assert.NoError(err)
+		assert.Equal(os.FileMode(0755), info.Mode().Perm())
+		files, err := ioutil.ReadDir(filepath.Dir(exe))
+		assert.NoError(err)
+		assert.Len(files, 1, "the download should be renamed over the binary")
+	})
+}
+
+func TestUpdateUpToDate(t *testing.T) {
 	assert := assert.New(t)
+	defer (&fakeRelease{name: "v" + VersionNumber}).serve()()
 
 	buf, cmd := setUpCommand()
+	assert.NoError(update(cmd, nil))
+	assert.Equal(fmt.Sprintf("taskcluster is already on the most recent version, v%s.\n", VersionNumber), buf.String())
+}
+
+func TestUpdateBadChecksum(t *testing.T) {
+	assert := assert.New(t)
+	sign, restore := withReleaseKey(t)
+	defer restore()
+	sums := fmt.Sprintf("%s  %s\n", checksum([]byte("new binary")), assetName())
+	defer (&fakeRelease{
+		name:      "v1000.0.0",
+		binary:    []byte("tampered binary"),
+		checksums: sums,
+		signature: sign(sums),
+	}).serve()()
+	exe, cleanup := withExecutable(t)
+	defer cleanup()
 
-	update(cmd, nil)
+	_, cmd := setUpCommand()
+	err := update(cmd, nil)
+	assert.Error(err)
+	assert.Contains(err.Error(), "not updating")
 
-	assert.Contains(buf.String(), "taskcluster", "Update command not returning a valid output")
+	data, err := ioutil.ReadFile(exe)
+	assert.NoError(err)
+	assert.Equal("old binary", string(data))
+	files, err := ioutil.ReadDir(filepath.Dir(exe))
+	assert.NoError(err)
+	assert.Len(files, 1, "the download should be removed")
+}
+
+func TestUpdateNoChecksums(t *testing.T) {
+	assert := assert.New(t)
+	_, restore := withReleaseKey(t)
+	defer restore()
+	defer (&fakeRelease{name: "v1000.0.0", binary: []byte("new binary")}).serve()()
+	_, cleanup := withExecutable(t)
+	defer cleanup()
+
+	_, cmd := setUpCommand()
+	err := update(cmd, nil)
+	assert.Error(err)
+	assert.Contains(err.Error(), "has no SHA256SUMS")
+}
 
+func TestUpdateBadSignature(t *testing.T) {
+	assert := assert.New(t)
+	sign, restore := withReleaseKey(t)
+	defer restore()
+	binary := []byte("new binary")
+	sums := fmt.Sprintf("%s  %s\n", checksum(binary), assetName())
+	defer (&fakeRelease{name: "v1000.0.0", binary: binary, checksums: sums, signature: sign("other sums")}).serve()()
+	exe, cleanup := withExecutable(t)
+	defer cleanup()
+
+	_, cmd := setUpCommand()
+	err := update(cmd, nil)
+	assert.Error(err)
+	assert.Contains(err.Error(), "signature of SHA256SUMS of v1000.0.0 is invalid")
+	data, err := ioutil.ReadFile(exe)
+	assert.NoError(err)
+	assert.Equal("old binary", string(data))
+}
+
+func TestNewerVersion(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(newerVersion("v27.0.1", "27.0.0"))
+	assert.True(newerVersion("v27.1.0", "27.0.9"))
+	assert.True(newerVersion("v28.0.0", "27.10.0"))
+	assert.False(newerVersion("v27.0.0", "27.0.0"))
+	assert.False(newerVersion("v26.9.9", "27.0.0"))
+	assert.False(newerVersion("v27.0.10", "27.1.0"))
 }
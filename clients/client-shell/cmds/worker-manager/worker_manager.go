@@ -0,0 +1,198 @@
+// Package workerManager implements the worker-manager subcommands.
+package workerManager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcworkermanager"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+var (
+	// Command is the root of the worker-manager subtree.
+	Command = &cobra.Command{
+		Use:   "worker-manager",
+		Short: "Provides worker pool and worker related actions.",
+	}
+)
+
+func init() {
+	poolsCmd := &cobra.Command{
+		Use:   "pools",
+		Short: "List the worker pools.",
+		RunE:  root.ExecuteHelperE(runPools, 0, 0),
+	}
+
+	poolCmd := &cobra.Command{
+		Use:   "pool <workerPoolId>",
+		Short: "Get the definition of a worker pool, including its config.",
+		RunE:  root.ExecuteHelperE(runPool, 1, 1),
+	}
+
+	workersCmd := &cobra.Command{
+		Use:   "workers <workerPoolId>",
+		Short: "List the workers of a worker pool, with their state and capacity.",
+		RunE:  root.ExecuteHelperE(runWorkers, 1, 1),
+	}
+	workersCmd.Flags().StringP("state", "s", "", "Only list the workers in this state (requested, running or stopped).")
+
+	terminateCmd := &cobra.Command{
+		Use:   "terminate <workerPoolId> <workerGroup> <workerId>",
+		Short: "Terminate a worker.",
+		Long: `Terminate a worker, through the provider that started it. Some providers
+do not support removing workers, and the worker may remain visible for a
+while after it is terminated.`,
+		RunE: root.ExecuteHelperE(runTerminate, 3, 3),
+	}
+
+	errorsCmd := &cobra.Command{
+		Use:   "errors <workerPoolId>",
+		Short: "Show the most recent provisioning errors of a worker pool.",
+		RunE:  root.ExecuteHelperE(runErrors, 1, 1),
+	}
+	errorsCmd.Flags().IntP("limit", "l", 10, "Number of errors to show (0 for all).")
+
+	Command.AddCommand(poolsCmd, poolCmd, workersCmd, terminateCmd, errorsCmd)
+	root.Command.AddCommand(Command)
+}
+
+func makeWorkerManager(credentials *tcclient.Credentials) *tcworkermanager.WorkerManager {
+	return tcworkermanager.New(credentials, config.RootURL())
+}
+
+// runPools lists the worker pools, following continuation tokens.
+func runPools(credentials *tcclient.Credentials, _ []string, out io.Writer, _ *pflag.FlagSet) error {
+	wm := makeWorkerManager(credentials)
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "WORKER POOL\tPROVIDER\tOWNER")
+	continuation := ""
+	for {
+		resp, err := wm.ListWorkerPools(continuation, "")
+		if err != nil {
+			return fmt.Errorf("could not list worker pools: %w", err)
+		}
+		for _, pool := range resp.WorkerPools {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", pool.WorkerPoolID, pool.ProviderID, pool.Owner)
+		}
+		if continuation = resp.ContinuationToken; continuation == "" {
+			break
+		}
+	}
+	return w.Flush()
+}
+
+// runPool prints the definition of a worker pool as JSON.
+func runPool(credentials *tcclient.Credentials, args []string, out io.Writer, _ *pflag.FlagSet) error {
+	pool, err := makeWorkerManager(credentials).WorkerPool(args[0])
+	if err != nil {
+		return fmt.Errorf("could not get worker pool %s: %w", args[0], err)
+	}
+
+	def, err := json.MarshalIndent(pool, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal worker pool %s into json: %w", args[0], err)
+	}
+	fmt.Fprintln(out, string(def))
+	return nil
+}
+
+// runWorkers lists the workers of a worker pool, optionally only those in the
+// state given with --state, followed by their total capacity.
+func runWorkers(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	wm := makeWorkerManager(credentials)
+	state, _ := flags.GetString("state")
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "WORKER GROUP\tWORKER ID\tSTATE\tCAPACITY\tPROVIDER\tCREATED")
+	count, capacity := 0, int64(0)
+	continuation := ""
+	for {
+		resp, err := wm.ListWorkersForWorkerPool(args[0], continuation, "")
+		if err != nil {
+			return fmt.Errorf("could not list the workers of %s: %w", args[0], err)
+		}
+		for _, worker := range resp.Workers {
+			if state != "" && worker.State != state {
+				continue
+			}
+			count++
+			capacity += worker.Capacity
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n",
+				worker.WorkerGroup, worker.WorkerID, worker.State, worker.Capacity,
+				worker.ProviderID, time.Time(worker.Created).UTC().Format(time.RFC3339))
+		}
+		if continuation = resp.ContinuationToken; continuation == "" {
+			break
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "%d workers, total capacity %d\n", count, capacity)
+	return nil
+}
+
+// runTerminate removes a worker.
+func runTerminate(credentials *tcclient.Credentials, args []string, out io.Writer, _ *pflag.FlagSet) error {
+	workerPoolID, workerGroup, workerID := args[0], args[1], args[2]
+	if err := makeWorkerManager(credentials).RemoveWorker(workerPoolID, workerGroup, workerID); err != nil {
+		return fmt.Errorf("could not terminate worker %s/%s of %s: %w", workerGroup, workerID, workerPoolID, err)
+	}
+	fmt.Fprintf(out, "Worker %s/%s of %s terminated\n", workerGroup, workerID, workerPoolID)
+	return nil
+}
+
+// runErrors shows the most recent provisioning errors of a worker pool,
+// latest first.
+func runErrors(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	wm := makeWorkerManager(credentials)
+	limit, _ := flags.GetInt("limit")
+
+	// the service doesn't guarantee any order, so all errors are fetched to
+	// find the most recent ones
+	var poolErrors []tcworkermanager.WorkerPoolError
+	continuation := ""
+	for {
+		resp, err := wm.ListWorkerPoolErrors(args[0], continuation, "")
+		if err != nil {
+			return fmt.Errorf("could not list the errors of %s: %w", args[0], err)
+		}
+		poolErrors = append(poolErrors, resp.WorkerPoolErrors...)
+		if continuation = resp.ContinuationToken; continuation == "" {
+			break
+		}
+	}
+
+	sort.SliceStable(poolErrors, func(i, j int) bool {
+		return time.Time(poolErrors[i].Reported).After(time.Time(poolErrors[j].Reported))
+	})
+	if limit > 0 && len(poolErrors) > limit {
+		poolErrors = poolErrors[:limit]
+	}
+
+	if len(poolErrors) == 0 {
+		fmt.Fprintf(out, "No errors reported for %s\n", args[0])
+		return nil
+	}
+	for i, e := range poolErrors {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "%s %s (%s)\n", time.Time(e.Reported).UTC().Format(time.RFC3339), e.Title, e.Kind)
+		if e.Description != "" {
+			fmt.Fprintln(out, e.Description)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package workerManager
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/suite"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+type FakeServerSuite struct {
+	suite.Suite
+	testServer *httptest.Server
+	// removed holds the paths of the workers removed through the fake server
+	removed []string
+}
+
+func (suite *FakeServerSuite) SetupSuite() {
+	// worker pool ids hold an escaped slash, so requests are routed by their
+	// escaped path rather than with a ServeMux
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/api/worker-manager/v1/worker-pools":
+			if r.URL.Query().Get("continuationToken") == "" {
+				_, _ = io.WriteString(w, `{"workerPools": [{"workerPoolId": "proj/ci", "providerId": "aws", "owner": "ci@example.com"}], "continuationToken": "next"}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"workerPools": [{"workerPoolId": "proj/gpu", "providerId": "gcp", "owner": "gpu@example.com"}]}`)
+		case "/api/worker-manager/v1/worker-pool/proj%2Fci":
+			_, _ = io.WriteString(w, `{"workerPoolId": "proj/ci", "providerId": "aws", "owner": "ci@example.com", "config": {"maxCapacity": 10}}`)
+		case "/api/worker-manager/v1/workers/proj%2Fci":
+			_, _ = io.WriteString(w, `{"workers": [
+				{"workerPoolId": "proj/ci", "workerGroup": "us-east-1", "workerId": "i-1", "state": "running", "capacity": 2, "providerId": "aws", "created": "2020-01-01T00:00:00.000Z"},
+				{"workerPoolId": "proj/ci", "workerGroup": "us-east-1", "workerId": "i-2", "state": "stopped", "capacity": 1, "providerId": "aws", "created": "2020-01-02T00:00:00.000Z"}
+			]}`)
+		case "/api/worker-manager/v1/workers/proj%2Fci/us-east-1/i-1":
+			suite.removed = append(suite.removed, r.Method+" "+r.URL.EscapedPath())
+			_, _ = io.WriteString(w, `{}`)
+		case "/api/worker-manager/v1/worker-pool-errors/proj%2Fci":
+			_, _ = io.WriteString(w, `{"workerPoolErrors": [
+				{"workerPoolId": "proj/ci", "kind": "quota", "title": "Quota Exceeded", "description": "old", "reported": "2020-01-01T00:00:00.000Z"},
+				{"workerPoolId": "proj/ci", "kind": "launch", "title": "Launch Failed", "description": "newest", "reported": "2020-01-03T00:00:00.000Z"},
+				{"workerPoolId": "proj/ci", "kind": "quota", "title": "Quota Exceeded", "description": "newer", "reported": "2020-01-02T00:00:00.000Z"}
+			]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}
+
+	suite.testServer = httptest.NewServer(http.HandlerFunc(handler))
+	config.SetRootURL(suite.testServer.URL)
+}
+
+func (suite *FakeServerSuite) SetupTest() {
+	suite.removed = nil
+}
+
+func (suite *FakeServerSuite) TearDownSuite() {
+	suite.testServer.Close()
+	config.SetRootURL("")
+}
+
+func TestFakeServerSuite(t *testing.T) {
+	suite.Run(t, new(FakeServerSuite))
+}
+
+func (suite *FakeServerSuite) TestPools() {
+	buf := &bytes.Buffer{}
+	err := runPools(&tcclient.Credentials{}, nil, buf, pflag.NewFlagSet("pools", pflag.ContinueOnError))
+	suite.NoError(err)
+	suite.Equal("WORKER POOL  PROVIDER  OWNER\n"+
+		"proj/ci      aws       ci@example.com\n"+
+		"proj/gpu     gcp       gpu@example.com\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestPool() {
+	buf := &bytes.Buffer{}
+	err := runPool(&tcclient.Credentials{}, []string{"proj/ci"}, buf, pflag.NewFlagSet("pool", pflag.ContinueOnError))
+	suite.NoError(err)
+	suite.Contains(buf.String(), `"workerPoolId": "proj/ci"`)
+	suite.Contains(buf.String(), `"maxCapacity": 10`)
+}
+
+func (suite *FakeServerSuite) TestWorkers() {
+	flags := pflag.NewFlagSet("workers", pflag.ContinueOnError)
+	flags.String("state", "", "")
+
+	buf := &bytes.Buffer{}
+	err := runWorkers(&tcclient.Credentials{}, []string{"proj/ci"}, buf, flags)
+	suite.NoError(err)
+	suite.Equal("WORKER GROUP  WORKER ID  STATE    CAPACITY  PROVIDER  CREATED\n"+
+		"us-east-1     i-1        running  2         aws       2020-01-01T00:00:00Z\n"+
+		"us-east-1     i-2        stopped  1         aws       2020-01-02T00:00:00Z\n"+
+		"2 workers, total capacity 3\n", buf.String())
+
+	suite.NoError(flags.Set("state", "running"))
+	buf.Reset()
+	err = runWorkers(&tcclient.Credentials{}, []string{"proj/ci"}, buf, flags)
+	suite.NoError(err)
+	suite.NotContains(buf.String(), "i-2")
+	suite.Contains(buf.String(), "1 workers, total capacity 2\n")
+}
+
+func (suite *FakeServerSuite) TestTerminate() {
+	buf := &bytes.Buffer{}
+	err := runTerminate(&tcclient.Credentials{}, []string{"proj/ci", "us-east-1", "i-1"}, buf, pflag.NewFlagSet("terminate", pflag.ContinueOnError))
+	suite.NoError(err)
+	suite.Equal([]string{"DELETE /api/worker-manager/v1/workers/proj%2Fci/us-east-1/i-1"}, suite.removed)
+	suite.Equal("Worker us-east-1/i-1 of proj/ci terminated\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestErrors() {
+	flags := pflag.NewFlagSet("errors", pflag.ContinueOnError)
+	flags.Int("limit", 2, "")
+
+	buf := &bytes.Buffer{}
+	err := runErrors(&tcclient.Credentials{}, []string{"proj/ci"}, buf, flags)
+	suite.NoError(err)
+	suite.Equal("2020-01-03T00:00:00Z Launch Failed (launch)\nnewest\n\n"+
+		"2020-01-02T00:00:00Z Quota Exceeded (quota)\nnewer\n", buf.String())
+}
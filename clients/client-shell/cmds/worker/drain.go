@@ -0,0 +1,138 @@
+package worker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcworkermanager"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+// pollInterval is the delay between checks of the tasks of a drained worker.
+var pollInterval = 30 * time.Second
+
+func init() {
+	drainCmd := &cobra.Command{
+		Use:   "drain <provisionerId>/<workerType> <workerGroup>/<workerId>",
+		Short: "Quarantine a worker, wait for its tasks to finish, and terminate it.",
+		Long: `Drain a worker: quarantine it, so that it doesn't claim new tasks, wait
+for the tasks it is running to be resolved, and terminate it through the
+worker-manager. With --force, the worker is terminated without waiting, and
+its tasks are resolved as exceptions once their claims expire.
+
+With --reason, the reason is recorded as an error report of the worker pool,
+as for ` + "`worker quarantine`" + `.
+
+The worker can also be given as <workerPoolId> <workerGroup> <workerId>, as
+in the worker-manager commands.`,
+		RunE: executeWorkerHelperE(runDrain),
+	}
+	drainCmd.Flags().String("duration", "1 day", "Duration of the quarantine, in case the worker is not terminated.")
+	drainCmd.Flags().String("reason", "", "Reason of the drain, recorded in the errors of the worker pool.")
+	drainCmd.Flags().Bool("force", false, "Terminate the worker without waiting for its tasks.")
+
+	Command.AddCommand(drainCmd)
+}
+
+// runDrain quarantines a worker, waits for the runs it claimed to be
+// resolved, and removes it.
+func runDrain(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	provisionerID, workerType, workerGroup, workerID, err := splitWorker(args)
+	if err != nil {
+		return err
+	}
+	until, err := quarantineEnd(flags)
+	if err != nil {
+		return err
+	}
+
+	if err := quarantine(credentials, provisionerID, workerType, workerGroup, workerID, until); err != nil {
+		return err
+	}
+	title := fmt.Sprintf("Worker %s quarantined until %s", args[1], formatTime(tcclient.Time(until)))
+	fmt.Fprintln(out, title)
+	reason, _ := flags.GetString("reason")
+	if err := recordReason(credentials, provisionerID, workerType, workerGroup, workerID, "Draining: "+title, reason); err != nil {
+		return err
+	}
+
+	if force, _ := flags.GetBool("force"); !force {
+		waitingFor := ""
+		for {
+			running, err := runningTasks(credentials, provisionerID, workerType, workerGroup, workerID)
+			if err != nil {
+				return err
+			}
+			if len(running) == 0 {
+				break
+			}
+			if list := strings.Join(running, ", "); list != waitingFor {
+				fmt.Fprintf(out, "Waiting for %d running tasks: %s\n", len(running), list)
+				waitingFor = list
+			}
+			time.Sleep(pollInterval)
+		}
+	}
+
+	workerPoolID := provisionerID + "/" + workerType
+	if err := makeWorkerManager(credentials).RemoveWorker(workerPoolID, workerGroup, workerID); err != nil {
+		return fmt.Errorf("could not terminate worker %s: %w", args[1], err)
+	}
+	fmt.Fprintf(out, "Worker %s of %s terminated\n", args[1], workerPoolID)
+	return nil
+}
+
+// runningTasks returns the runs claimed recently by a worker which are still
+// running, as <taskId>/<runId>.
+func runningTasks(credentials *tcclient.Credentials, provisionerID, workerType, workerGroup, workerID string) ([]string, error) {
+	q := makeQueue(credentials)
+	worker, err := q.GetWorker(provisionerID, workerType, workerGroup, workerID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get worker %s/%s: %w", workerGroup, workerID, err)
+	}
+
+	var running []string
+	for _, run := range worker.RecentTasks {
+		s, err := q.Status(run.TaskID)
+		if err != nil {
+			return nil, fmt.Errorf("could not get the status of the task %s: %w", run.TaskID, err)
+		}
+		for _, r := range s.Status.Runs {
+			if r.RunID == run.RunID && r.State == "running" {
+				running = append(running, fmt.Sprintf("%s/%d", run.TaskID, run.RunID))
+			}
+		}
+	}
+	return running, nil
+}
+
+func makeWorkerManager(credentials *tcclient.Credentials) *tcworkermanager.WorkerManager {
+	return tcworkermanager.New(credentials, config.RootURL())
+}
+
+// recordReason records the reason of a quarantine or drain, if any, as an
+// error report of the worker pool, where it is visible to its administrators,
+// as the queue has no place to store it.
+func recordReason(credentials *tcclient.Credentials, provisionerID, workerType, workerGroup, workerID, title, reason string) error {
+	if reason == "" {
+		return nil
+	}
+	_, err := makeWorkerManager(credentials).ReportWorkerError(provisionerID+"/"+workerType, &tcworkermanager.WorkerErrorReport{
+		Kind:        "worker-quarantine",
+		Title:       title,
+		Description: reason,
+		Extra:       json.RawMessage(`{}`),
+		WorkerGroup: workerGroup,
+		WorkerID:    workerID,
+	})
+	if err != nil {
+		return fmt.Errorf("could not record the reason of the quarantine of worker %s/%s: %w", workerGroup, workerID, err)
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+// Package worker implements the worker subcommands.
+package worker
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-go/tcqueue"
+	fromNow "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/from-now"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/root"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+var (
+	// Command is the root of the worker subtree.
+	Command = &cobra.Command{
+		Use:   "worker",
+		Short: "Provides actions to inspect and quarantine the workers known to the queue.",
+	}
+
+	// now returns the current time, against which quarantines are checked.
+	now = time.Now
+)
+
+func init() {
+	listCmd := &cobra.Command{
+		Use:   "list <provisionerId>/<workerType>",
+		Short: "List the active workers of a worker type.",
+		RunE:  root.ExecuteHelperE(runList, 1, 1),
+	}
+	listCmd.Flags().BoolP("quarantined", "q", false, "Only list the quarantined workers.")
+
+	showCmd := &cobra.Command{
+		Use:   "show <provisionerId>/<workerType> <workerGroup>/<workerId>",
+		Short: "Show a worker, its quarantine state and the tasks it claimed recently.",
+		RunE:  executeWorkerHelperE(runShow),
+	}
+
+	quarantineCmd := &cobra.Command{
+		Use:   "quarantine <provisionerId>/<workerType> <workerGroup>/<workerId>",
+		Short: "Quarantine a worker, so that it doesn't claim tasks.",
+		Long: `Quarantine a worker: it stays alive, but doesn't claim any task until the
+quarantine ends. A quarantine is lifted early with --lift.
+
+With --reason, the reason is recorded as an error report of the worker pool,
+where it is visible to its administrators, e.g. with
+` + "`worker-manager errors`" + `. This requires the scopes
+assume:worker-pool:<workerPoolId> and assume:worker-id:<workerGroup>/<workerId>.
+
+The worker can also be given as <workerPoolId> <workerGroup> <workerId>, as
+in the worker-manager commands.`,
+		RunE: executeWorkerHelperE(runQuarantine),
+	}
+	quarantineCmd.Flags().String("duration", "1 day", "Duration of the quarantine, e.g. '4h', '6 hours' or '2 days'.")
+	quarantineCmd.Flags().String("until", "", "Duration of the quarantine.")
+	_ = quarantineCmd.Flags().MarkDeprecated("until", "use --duration instead")
+	quarantineCmd.Flags().String("reason", "", "Reason of the quarantine, recorded in the errors of the worker pool.")
+	quarantineCmd.Flags().Bool("lift", false, "Lift the quarantine of the worker.")
+
+	Command.AddCommand(listCmd, showCmd, quarantineCmd)
+	root.Command.AddCommand(Command)
+}
+
+// executeWorkerHelperE is root.ExecuteHelperE for the commands acting on a
+// worker, which also accept it as <workerPoolId> <workerGroup> <workerId>.
+func executeWorkerHelperE(f root.Executor) func(*cobra.Command, []string) error {
+	helper := root.ExecuteHelperE(f, 2, 2)
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) == 3 {
+			args = []string{args[0], args[1] + "/" + args[2]}
+		}
+		return helper(cmd, args)
+	}
+}
+
+func makeQueue(credentials *tcclient.Credentials) *tcqueue.Queue {
+	return tcqueue.New(credentials, config.RootURL())
+}
+
+// splitPair splits an argument of the form <first>/<second>, as described by
+// usage.
+func splitPair(arg, usage string) (string, string, error) {
+	parts := strings.Split(arg, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid argument %q, expected %s", arg, usage)
+	}
+	return parts[0], parts[1], nil
+}
+
+// splitWorker splits the arguments of the show and quarantine commands.
+func splitWorker(args []string) (provisionerID, workerType, workerGroup, workerID string, err error) {
+	if provisionerID, workerType, err = splitPair(args[0], "<provisionerId>/<workerType>"); err != nil {
+		return
+	}
+	workerGroup, workerID, err = splitPair(args[1], "<workerGroup>/<workerId>")
+	return
+}
+
+// formatTime formats t for display, or returns "-" if it is unset.
+func formatTime(t tcclient.Time) string {
+	if time.Time(t).IsZero() {
+		return "-"
+	}
+	return time.Time(t).UTC().Format(time.RFC3339)
+}
+
+// quarantined returns whether a worker quarantined until the given time is
+// still quarantined.
+func quarantined(until tcclient.Time) bool {
+	return time.Time(until).After(now())
+}
+
+// runList lists the workers of a worker type, following continuation tokens.
+func runList(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	provisionerID, workerType, err := splitPair(args[0], "<provisionerId>/<workerType>")
+	if err != nil {
+		return err
+	}
+	filter := ""
+	if onlyQuarantined, _ := flags.GetBool("quarantined"); onlyQuarantined {
+		filter = "true"
+	}
+
+	q := makeQueue(credentials)
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "WORKER GROUP\tWORKER ID\tFIRST CLAIM\tLATEST TASK\tQUARANTINED UNTIL")
+	continuation := ""
+	for {
+		resp, err := q.ListWorkers(provisionerID, workerType, continuation, "", filter)
+		if err != nil {
+			return fmt.Errorf("could not list the workers of %s: %w", args[0], err)
+		}
+		for _, worker := range resp.Workers {
+			latest := "-"
+			if worker.LatestTask.TaskID != "" {
+				latest = fmt.Sprintf("%s/%d", worker.LatestTask.TaskID, worker.LatestTask.RunID)
+			}
+			until := "-"
+			if quarantined(worker.QuarantineUntil) {
+				until = formatTime(worker.QuarantineUntil)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", worker.WorkerGroup, worker.WorkerID, formatTime(worker.FirstClaim), latest, until)
+		}
+		if continuation = resp.ContinuationToken; continuation == "" {
+			break
+		}
+	}
+	return w.Flush()
+}
+
+// runShow shows a worker and the state of the runs of the tasks it claimed
+// recently.
+func runShow(credentials *tcclient.Credentials, args []string, out io.Writer, _ *pflag.FlagSet) error {
+	provisionerID, workerType, workerGroup, workerID, err := splitWorker(args)
+	if err != nil {
+		return err
+	}
+
+	q := makeQueue(credentials)
+	worker, err := q.GetWorker(provisionerID, workerType, workerGroup, workerID)
+	if err != nil {
+		return fmt.Errorf("could not get worker %s: %w", args[1], err)
+	}
+
+	fmt.Fprintf(out, "Worker: %s/%s/%s/%s\n", provisionerID, workerType, workerGroup, workerID)
+	fmt.Fprintf(out, "First claim: %s\n", formatTime(worker.FirstClaim))
+	fmt.Fprintf(out, "Expires: %s\n", formatTime(worker.Expires))
+	if quarantined(worker.QuarantineUntil) {
+		fmt.Fprintf(out, "Quarantined until: %s\n", formatTime(worker.QuarantineUntil))
+	} else {
+		fmt.Fprintln(out, "Quarantined: no")
+	}
+
+	if len(worker.RecentTasks) == 0 {
+		fmt.Fprintln(out, "\nNo recent tasks.")
+		return nil
+	}
+	fmt.Fprintln(out, "\nRecent tasks:")
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "  TASK ID\tRUN\tSTATE")
+	for _, run := range worker.RecentTasks {
+		s, err := q.Status(run.TaskID)
+		if err != nil {
+			return fmt.Errorf("could not get the status of the task %s: %w", run.TaskID, err)
+		}
+		state := "unknown"
+		for _, r := range s.Status.Runs {
+			if r.RunID == run.RunID {
+				state = getRunStatusString(r.State, r.ReasonResolved)
+			}
+		}
+		fmt.Fprintf(w, "  %s\t%d\t%s\n", run.TaskID, run.RunID, state)
+	}
+	return w.Flush()
+}
+
+// getRunStatusString takes the state and resolved strings and crafts a printable summary string.
+func getRunStatusString(state, resolved string) string {
+	if resolved != "" {
+		return fmt.Sprintf("%s '%s'", state, resolved)
+	}
+	return state
+}
+
+// runQuarantine quarantines a worker for the duration given with --duration,
+// or lifts its quarantine with --lift, and records the reason given with
+// --reason.
+func runQuarantine(credentials *tcclient.Credentials, args []string, out io.Writer, flags *pflag.FlagSet) error {
+	provisionerID, workerType, workerGroup, workerID, err := splitWorker(args)
+	if err != nil {
+		return err
+	}
+
+	until := now()
+	lift, _ := flags.GetBool("lift")
+	if !lift {
+		if until, err = quarantineEnd(flags); err != nil {
+			return err
+		}
+	}
+
+	if err := quarantine(credentials, provisionerID, workerType, workerGroup, workerID, until); err != nil {
+		return err
+	}
+
+	title := fmt.Sprintf("Worker %s quarantined until %s", args[1], formatTime(tcclient.Time(until)))
+	if lift {
+		title = fmt.Sprintf("Quarantine of worker %s lifted", args[1])
+	}
+	fmt.Fprintln(out, title)
+
+	reason, _ := flags.GetString("reason")
+	return recordReason(credentials, provisionerID, workerType, workerGroup, workerID, title, reason)
+}
+
+// quarantineEnd returns the end of a quarantine starting now, for the
+// duration given with --duration, or the deprecated --until.
+func quarantineEnd(flags *pflag.FlagSet) (time.Time, error) {
+	name := "duration"
+	if flags.Changed("until") || flags.Lookup("duration") == nil {
+		name = "until"
+	}
+	duration, _ := flags.GetString(name)
+	until, err := fromNow.Parse(duration)
+	if err != nil {
+		return until, fmt.Errorf("invalid --%s: %w", name, err)
+	}
+	return until, nil
+}
+
+// quarantine quarantines a worker until the given time, which lifts its
+// quarantine if it is not in the future.
+func quarantine(credentials *tcclient.Credentials, provisionerID, workerType, workerGroup, workerID string, until time.Time) error {
+	_, err := makeQueue(credentials).QuarantineWorker(provisionerID, workerType, workerGroup, workerID, &tcqueue.QuarantineWorkerRequest{
+		QuarantineUntil: tcclient.Time(until),
+	})
+	if err != nil {
+		return fmt.Errorf("could not quarantine worker %s/%s: %w", workerGroup, workerID, err)
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package worker
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/suite"
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/config"
+)
+
+const (
+	workerPath = "/api/queue/v1/provisioners/proj-app/worker-types/ci/workers"
+	taskA      = "ANnmjMocTymeTID0tlNJAw"
+	taskB      = "BNnmjMocTymeTID0tlNJAw"
+	taskC      = "CNnmjMocTymeTID0tlNJAw"
+)
+
+type FakeServerSuite struct {
+	suite.Suite
+	testServer *httptest.Server
+	// quarantineUntil holds the quarantineUntil of the last quarantine request
+	quarantineUntil time.Time
+	// taskCPolls counts the status requests of taskC, which runs until the
+	// second one
+	taskCPolls int
+	// workerManagerCalls holds the requests to the worker-manager
+	workerManagerCalls []string
+}
+
+func (suite *FakeServerSuite) SetupSuite() {
+	handler := http.NewServeMux()
+	handler.HandleFunc(workerPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("quarantined") == "true" {
+			_, _ = io.WriteString(w, `{"workers": [{"workerGroup": "us-east-1", "workerId": "i-2", "firstClaim": "2020-01-01T00:00:00.000Z", "quarantineUntil": "2030-01-01T00:00:00.000Z"}]}`)
+			return
+		}
+		if r.URL.Query().Get("continuationToken") == "" {
+			_, _ = io.WriteString(w, `{"workers": [{"workerGroup": "us-east-1", "workerId": "i-1", "firstClaim": "2020-01-01T00:00:00.000Z", "latestTask": {"taskId": "`+taskA+`", "runId": 0}}], "continuationToken": "next"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"workers": [{"workerGroup": "us-east-1", "workerId": "i-2", "firstClaim": "2020-01-01T00:00:00.000Z", "quarantineUntil": "2030-01-01T00:00:00.000Z"}]}`)
+	})
+	handler.HandleFunc(workerPath+"/us-east-1/i-1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			var req struct {
+				QuarantineUntil time.Time `json:"quarantineUntil"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			suite.quarantineUntil = req.QuarantineUntil
+		}
+		_, _ = io.WriteString(w, `{"provisionerId": "proj-app", "workerType": "ci", "workerGroup": "us-east-1", "workerId": "i-1",
+			"firstClaim": "2020-01-01T00:00:00.000Z", "expires": "2020-02-01T00:00:00.000Z", "quarantineUntil": "2019-01-01T00:00:00.000Z",
+			"recentTasks": [{"taskId": "`+taskA+`", "runId": 0}, {"taskId": "`+taskB+`", "runId": 1}], "actions": []}`)
+	})
+	handler.HandleFunc("/api/queue/v1/task/"+taskA+"/status", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"status": {"taskId": "`+taskA+`", "runs": [{"runId": 0, "state": "running"}]}}`)
+	})
+	handler.HandleFunc("/api/queue/v1/task/"+taskB+"/status", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"status": {"taskId": "`+taskB+`", "runs": [{"runId": 0, "state": "exception", "reasonResolved": "worker-shutdown"}, {"runId": 1, "state": "failed", "reasonResolved": "failed"}]}}`)
+	})
+
+	handler.HandleFunc(workerPath+"/us-east-1/i-3", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			var req struct {
+				QuarantineUntil time.Time `json:"quarantineUntil"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			suite.quarantineUntil = req.QuarantineUntil
+		}
+		_, _ = io.WriteString(w, `{"provisionerId": "proj-app", "workerType": "ci", "workerGroup": "us-east-1", "workerId": "i-3",
+			"firstClaim": "2020-01-01T00:00:00.000Z", "expires": "2020-02-01T00:00:00.000Z", "quarantineUntil": "2019-01-01T00:00:00.000Z",
+			"recentTasks": [{"taskId": "`+taskB+`", "runId": 1}, {"taskId": "`+taskC+`", "runId": 0}], "actions": []}`)
+	})
+	handler.HandleFunc("/api/queue/v1/task/"+taskC+"/status", func(w http.ResponseWriter, _ *http.Request) {
+		suite.taskCPolls++
+		state := "running"
+		if suite.taskCPolls > 1 {
+			state = "completed"
+		}
+		_, _ = io.WriteString(w, `{"status": {"taskId": "`+taskC+`", "runs": [{"runId": 0, "state": "`+state+`"}]}}`)
+	})
+	// worker pool ids hold an escaped slash, so worker-manager requests are
+	// recorded with their escaped path
+	handler.HandleFunc("/api/worker-manager/", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		suite.workerManagerCalls = append(suite.workerManagerCalls, strings.TrimSpace(r.Method+" "+r.URL.EscapedPath()+" "+string(body)))
+		_, _ = io.WriteString(w, `{}`)
+	})
+
+	suite.testServer = httptest.NewServer(handler)
+	config.SetRootURL(suite.testServer.URL)
+	now = func() time.Time { return time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC) }
+}
+
+func (suite *FakeServerSuite) TearDownSuite() {
+	suite.testServer.Close()
+	config.SetRootURL("")
+	now = time.Now
+}
+
+func (suite *FakeServerSuite) SetupTest() {
+	suite.taskCPolls = 0
+	suite.workerManagerCalls = nil
+}
+
+func TestFakeServerSuite(t *testing.T) {
+	suite.Run(t, new(FakeServerSuite))
+}
+
+func (suite *FakeServerSuite) TestList() {
+	flags := pflag.NewFlagSet("list", pflag.ContinueOnError)
+	flags.Bool("quarantined", false, "")
+
+	buf := &bytes.Buffer{}
+	err := runList(&tcclient.Credentials{}, []string{"proj-app/ci"}, buf, flags)
+	suite.NoError(err)
+	suite.Equal("WORKER GROUP  WORKER ID  FIRST CLAIM           LATEST TASK               QUARANTINED UNTIL\n"+
+		"us-east-1     i-1        2020-01-01T00:00:00Z  "+taskA+"/0  -\n"+
+		"us-east-1     i-2        2020-01-01T00:00:00Z  -                         2030-01-01T00:00:00Z\n", buf.String())
+
+	suite.NoError(flags.Set("quarantined", "true"))
+	buf.Reset()
+	err = runList(&tcclient.Credentials{}, []string{"proj-app/ci"}, buf, flags)
+	suite.NoError(err)
+	suite.NotContains(buf.String(), "i-1")
+	suite.Contains(buf.String(), "i-2")
+}
+
+func (suite *FakeServerSuite) TestListInvalid() {
+	flags := pflag.NewFlagSet("list", pflag.ContinueOnError)
+	flags.Bool("quarantined", false, "")
+
+	err := runList(&tcclient.Credentials{}, []string{"proj-app"}, &bytes.Buffer{}, flags)
+	suite.EqualError(err, `invalid argument "proj-app", expected <provisionerId>/<workerType>`)
+}
+
+func (suite *FakeServerSuite) TestShow() {
+	buf := &bytes.Buffer{}
+	err := runShow(&tcclient.Credentials{}, []string{"proj-app/ci", "us-east-1/i-1"}, buf, pflag.NewFlagSet("show", pflag.ContinueOnError))
+	suite.NoError(err)
+	suite.Equal("Worker: proj-app/ci/us-east-1/i-1\n"+
+		"First claim: 2020-01-01T00:00:00Z\n"+
+		"Expires: 2020-02-01T00:00:00Z\n"+
+		"Quarantined: no\n"+
+		"\nRecent tasks:\n"+
+		"  TASK ID                 RUN  STATE\n"+
+		"  "+taskA+"  0    running\n"+
+		"  "+taskB+"  1    failed 'failed'\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestQuarantine() {
+	flags := pflag.NewFlagSet("quarantine", pflag.ContinueOnError)
+	flags.String("until", "2 days", "")
+	flags.Bool("lift", false, "")
+
+	buf := &bytes.Buffer{}
+	err := runQuarantine(&tcclient.Credentials{}, []string{"proj-app/ci", "us-east-1/i-1"}, buf, flags)
+	suite.NoError(err)
+	suite.WithinDuration(time.Now().Add(48*time.Hour), suite.quarantineUntil, time.Minute)
+	suite.Contains(buf.String(), "Worker us-east-1/i-1 quarantined until ")
+
+	suite.NoError(flags.Set("lift", "true"))
+	buf.Reset()
+	err = runQuarantine(&tcclient.Credentials{}, []string{"proj-app/ci", "us-east-1/i-1"}, buf, flags)
+	suite.NoError(err)
+	suite.Equal(now(), suite.quarantineUntil)
+	suite.Equal("Quarantine of worker us-east-1/i-1 lifted\n", buf.String())
+}
+
+func (suite *FakeServerSuite) TestQuarantineReason() {
+	flags := pflag.NewFlagSet("quarantine", pflag.ContinueOnError)
+	flags.String("duration", "4h", "")
+	flags.String("until", "", "")
+	flags.String("reason", "disk full", "")
+	flags.Bool("lift", false, "")
+
+	buf := &bytes.Buffer{}
+	err := runQuarantine(&tcclient.Credentials{}, []string{"proj-app/ci", "us-east-1/i-3"}, buf, flags)
+	suite.NoError(err)
+	suite.WithinDuration(time.Now().Add(4*time.Hour), suite.quarantineUntil, time.Minute)
+	suite.Require().Len(suite.workerManagerCalls, 1)
+	suite.Contains(suite.workerManagerCalls[0], "POST /api/worker-manager/v1/worker-pool-errors/proj-app%2Fci ")
+	suite.Contains(suite.workerManagerCalls[0], `"description":"disk full"`)
+	suite.Contains(suite.workerManagerCalls[0], `"workerId":"i-3"`)
+	suite.Contains(suite.workerManagerCalls[0], `"title":"`+strings.TrimSpace(buf.String())+`"`)
+}
+
+func (suite *FakeServerSuite) TestDrain() {
+	defer func(orig time.Duration) { pollInterval = orig }(pollInterval)
+	pollInterval = time.Millisecond
+	flags := pflag.NewFlagSet("drain", pflag.ContinueOnError)
+	flags.String("duration", "1 day", "")
+	flags.String("reason", "", "")
+	flags.Bool("force", false, "")
+
+	buf := &bytes.Buffer{}
+	err := runDrain(&tcclient.Credentials{}, []string{"proj-app/ci", "us-east-1/i-3"}, buf, flags)
+	suite.NoError(err)
+	suite.WithinDuration(time.Now().Add(24*time.Hour), suite.quarantineUntil, time.Minute)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	suite.Require().Len(lines, 3)
+	suite.Contains(lines[0], "Worker us-east-1/i-3 quarantined until ")
+	suite.Equal("Waiting for 1 running tasks: "+taskC+"/0", lines[1])
+	suite.Equal("Worker us-east-1/i-3 of proj-app/ci terminated", lines[2])
+	suite.Equal([]string{"DELETE /api/worker-manager/v1/workers/proj-app%2Fci/us-east-1/i-3"}, suite.workerManagerCalls)
+}
+
+func (suite *FakeServerSuite) TestDrainForce() {
+	flags := pflag.NewFlagSet("drain", pflag.ContinueOnError)
+	flags.String("duration", "1 day", "")
+	flags.String("reason", "bad disk", "")
+	flags.Bool("force", true, "")
+
+	buf := &bytes.Buffer{}
+	err := runDrain(&tcclient.Credentials{}, []string{"proj-app/ci", "us-east-1/i-3"}, buf, flags)
+	suite.NoError(err)
+	suite.Equal(0, suite.taskCPolls, "the tasks should not be waited for with --force")
+	suite.Require().Len(suite.workerManagerCalls, 2)
+	suite.Contains(suite.workerManagerCalls[0], `"title":"Draining: Worker us-east-1/i-3 quarantined until `)
+	suite.Equal("DELETE /api/worker-manager/v1/workers/proj-app%2Fci/us-east-1/i-3", suite.workerManagerCalls[1])
+}
+
+func (suite *FakeServerSuite) TestWorkerManagerArgs() {
+	var got []string
+	cmd := &cobra.Command{
+		Use: "show <provisionerId>/<workerType> <workerGroup>/<workerId>",
+		RunE: executeWorkerHelperE(func(_ *tcclient.Credentials, args []string, _ io.Writer, _ *pflag.FlagSet) error {
+			got = args
+			return nil
+		}),
+	}
+
+	suite.NoError(cmd.RunE(cmd, []string{"proj-app/ci", "us-east-1", "i-3"}))
+	suite.Equal([]string{"proj-app/ci", "us-east-1/i-3"}, got)
+	suite.NoError(cmd.RunE(cmd, []string{"proj-app/ci", "us-east-1/i-3"}))
+	suite.Equal([]string{"proj-app/ci", "us-east-1/i-3"}, got)
+	suite.EqualError(cmd.RunE(cmd, []string{"proj-app/ci"}), "wrong number of arguments; usage: show <provisionerId>/<workerType> <workerGroup>/<workerId>")
+}
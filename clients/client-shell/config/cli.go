@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// CLIConfig is the configuration of the command line itself, as opposed to
+// the options of the configuration file, read from cli.yml:
+//
+//	# default flags, by command; those of a command also apply to its
+//	# subcommands
+//	defaults:
+//	  group:
+//	    output: json
+//	  worker-manager workers:
+//	    state: running
+//	# aliases, expanded when given as the first argument
+//	aliases:
+//	  follow: task log --follow
+//	  failed: [group, list, --failed]
+//	# environments, selected with --env, TASKCLUSTER_ENV or environment
+//	environments:
+//	  staging:
+//	    rootUrl: https://staging.taskcluster.example.com
+//	    profile: staging
+//	environment: staging
+type CLIConfig struct {
+	Defaults     map[string]map[string]FlagValue `yaml:"defaults"`
+	Aliases      map[string]Args                 `yaml:"aliases"`
+	Environments map[string]Environment          `yaml:"environments"`
+	Environment  string                          `yaml:"environment"`
+}
+
+// FlagValue holds the values of a flag: a single one, or several for flags
+// which can be repeated.
+type FlagValue []string
+
+// UnmarshalYAML reads a scalar or a list of scalars.
+func (v *FlagValue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var values []interface{}
+	if err := unmarshal(&values); err != nil {
+		var value interface{}
+		if err := unmarshal(&value); err != nil {
+			return err
+		}
+		values = []interface{}{value}
+	}
+	*v = make(FlagValue, len(values))
+	for i, value := range values {
+		(*v)[i] = fmt.Sprint(value)
+	}
+	return nil
+}
+
+// Args are the arguments an alias expands to, given as a list, or as a
+// string split on whitespace.
+type Args []string
+
+// UnmarshalYAML reads a list of arguments, or a string.
+func (a *Args) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var args []string
+	if err := unmarshal(&args); err != nil {
+		var line string
+		if err := unmarshal(&line); err != nil {
+			return err
+		}
+		args = strings.Fields(line)
+	}
+	*a = args
+	return nil
+}
+
+// An Environment is a Taskcluster deployment commands can be pointed at.
+type Environment struct {
+	RootURL string `yaml:"rootUrl"`
+	// Profile is the name of the profile whose credentials are used.
+	Profile string `yaml:"profile"`
+}
+
+// CLI is the configuration loaded from cli.yml by Setup, if any.
+var CLI *CLIConfig
+
+// cliConfigFile is the location of cli.yml.
+func cliConfigFile() string {
+	return filepath.Join(configFolder(), "taskcluster", "cli.yml")
+}
+
+// LoadCLIConfig reads cli.yml, returning an empty configuration if there is
+// none.
+func LoadCLIConfig() (*CLIConfig, error) {
+	cli := &CLIConfig{}
+	file := cliConfigFile()
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return cli, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cli config file %s, error: %s", file, err)
+	}
+	if err = yaml.UnmarshalStrict(data, cli); err != nil {
+		return nil, fmt.Errorf("read cli config file %s, but failed to parse YAML, error: %s", file, err)
+	}
+	if cli.Environment != "" {
+		if _, ok := cli.Environments[cli.Environment]; !ok {
+			return nil, fmt.Errorf("invalid cli config file %s: environment %q is not defined", file, cli.Environment)
+		}
+	}
+	return cli, nil
+}
+
+// DefaultFlags returns the default flags of a command, given by its path
+// without the name of the program, e.g. "group list". The defaults of the
+// parent commands are included, unless overridden; exact tells whether each
+// flag was given for the command itself.
+func (cli *CLIConfig) DefaultFlags(path string) (flags map[string]FlagValue, exact map[string]bool) {
+	flags = make(map[string]FlagValue)
+	exact = make(map[string]bool)
+	words := strings.Fields(path)
+	for i := 1; i <= len(words); i++ {
+		for name, value := range cli.Defaults[strings.Join(words[:i], " ")] {
+			flags[name] = value
+			exact[name] = i == len(words)
+		}
+	}
+	return flags, exact
+}
+
+// UseEnvironment loads the root URL and the credentials of the profile of
+// the named environment.
+func (cli *CLIConfig) UseEnvironment(name string) error {
+	env, ok := cli.Environments[name]
+	if !ok {
+		return fmt.Errorf("no environment named %q in %s", name, cliConfigFile())
+	}
+	if env.Profile != "" {
+		if err := UseProfile(env.Profile); err != nil {
+			return err
+		}
+	}
+	if env.RootURL != "" {
+		rootURL = strings.TrimRight(env.RootURL, "/")
+	}
+	return nil
+}
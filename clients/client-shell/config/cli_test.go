@@ -0,0 +1,114 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/client"
+)
+
+// withCLIConfig points the config folder to a temporary one, with the given
+// cli.yml, until the returned function is called.
+func withCLIConfig(t *testing.T, content string) func() {
+	dir, err := ioutil.TempDir("", "taskcluster-cli-config")
+	assert.NoError(t, err)
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "taskcluster"), 0700))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "taskcluster", "cli.yml"), []byte(content), 0600))
+	orig := os.Getenv("XDG_CONFIG_HOME")
+	assert.NoError(t, os.Setenv("XDG_CONFIG_HOME", dir))
+	return func() {
+		os.Setenv("XDG_CONFIG_HOME", orig)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestLoadCLIConfig(t *testing.T) {
+	assert := assert.New(t)
+	defer withCLIConfig(t, `
+defaults:
+  group:
+    output: json
+  group list:
+    output: table
+    limit: 10
+  task create:
+    set: [metadata.owner=me@example.com, priority=low]
+aliases:
+  follow: task log --follow
+  failed: [group, list, --failed]
+environments:
+  staging:
+    rootUrl: https://staging.example.com/
+environment: staging
+`)()
+
+	cli, err := LoadCLIConfig()
+	assert.NoError(err)
+	assert.Equal("staging", cli.Environment)
+	assert.Equal(Args{"task", "log", "--follow"}, cli.Aliases["follow"])
+	assert.Equal(Args{"group", "list", "--failed"}, cli.Aliases["failed"])
+
+	flags, exact := cli.DefaultFlags("group list")
+	assert.Equal(map[string]FlagValue{"output": {"table"}, "limit": {"10"}}, flags)
+	assert.Equal(map[string]bool{"output": true, "limit": true}, exact)
+
+	flags, exact = cli.DefaultFlags("group status")
+	assert.Equal(map[string]FlagValue{"output": {"json"}}, flags, "defaults of parent commands should apply")
+	assert.False(exact["output"])
+
+	flags, _ = cli.DefaultFlags("task create")
+	assert.Equal(FlagValue{"metadata.owner=me@example.com", "priority=low"}, flags["set"])
+
+	flags, _ = cli.DefaultFlags("worker list")
+	assert.Empty(flags)
+}
+
+func TestLoadCLIConfigMissing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "taskcluster-cli-config")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	assert.NoError(t, os.Setenv("XDG_CONFIG_HOME", dir))
+
+	cli, err := LoadCLIConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, &CLIConfig{}, cli)
+}
+
+func TestLoadCLIConfigInvalid(t *testing.T) {
+	for _, content := range []string{
+		"default:\n  group:\n    output: json\n",
+		"environment: production\n",
+		"aliases: [x]\n",
+	} {
+		func() {
+			defer withCLIConfig(t, content)()
+			_, err := LoadCLIConfig()
+			assert.Error(t, err, content)
+		}()
+	}
+}
+
+func TestUseEnvironment(t *testing.T) {
+	assert := assert.New(t)
+	defer withCLIConfig(t, "")()
+	defer func(r string, c *client.Credentials) { rootURL, Credentials = r, c }(rootURL, Credentials)
+
+	assert.NoError(SaveProfile("staging", Profile{RootURL: "https://profile.example.com", ClientID: "me", AccessToken: "secret"}))
+	cli := &CLIConfig{Environments: map[string]Environment{
+		"staging": {RootURL: "https://staging.example.com/", Profile: "staging"},
+		"public":  {RootURL: "https://public.example.com"},
+	}}
+
+	assert.NoError(cli.UseEnvironment("staging"))
+	assert.Equal("https://staging.example.com", RootURL(), "the root URL of the environment should take precedence")
+	assert.Equal("me", Credentials.ClientID)
+
+	assert.NoError(cli.UseEnvironment("public"))
+	assert.Equal("https://public.example.com", RootURL())
+
+	assert.Error(cli.UseEnvironment("production"))
+}
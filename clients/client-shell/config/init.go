@@ -24,13 +24,14 @@ var (
 // Defer erroring out on a missing RootURL until we actually need one..
 func RootURL() string {
 	if rootURL == "" {
-		fmt.Fprintln(os.Stderr, "No Root URL specified; set TASKCLUSTER_ROOT_URL")
+		fmt.Fprintln(os.Stderr, "No Root URL specified; use --root-url or set TASKCLUSTER_ROOT_URL")
 		os.Exit(1)
 	}
 	return rootURL
 }
 
-// set the root URL -- this is used only for testing
+// SetRootURL overrides the root URL loaded by Setup, as for --root-url (and
+// in tests).
 func SetRootURL(newRootURL string) {
 	rootURL = newRootURL
 }
@@ -49,6 +50,13 @@ func Setup() {
 		os.Exit(1)
 	}
 
+	// load the configuration of the command line
+	CLI, err = LoadCLIConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	// load root URL
 	rootURL = Configuration["config"]["rootUrl"].(string)
 
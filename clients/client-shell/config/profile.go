@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/client"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// A Profile is a named set of credentials for a Taskcluster deployment, as
+// saved by `taskcluster signin --profile <name>`.
+type Profile struct {
+	RootURL     string `yaml:"rootUrl"`
+	ClientID    string `yaml:"clientId"`
+	AccessToken string `yaml:"accessToken"`
+	Certificate string `yaml:"certificate,omitempty"`
+	// Expires is when the credentials expire, if known.
+	Expires time.Time `yaml:"expires,omitempty"`
+}
+
+// profilesFile is the location of the file holding the profiles. It is kept
+// separate from the configuration file, as it only holds credentials.
+func profilesFile() string {
+	return filepath.Join(configFolder(), "taskcluster", "profiles.yml")
+}
+
+// LoadProfiles returns all saved profiles, by name.
+func LoadProfiles() (map[string]Profile, error) {
+	profiles := make(map[string]Profile)
+	file := profilesFile()
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles file %s, error: %s", file, err)
+	}
+	if err = yaml.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("read profiles file %s, but failed to parse YAML, error: %s", file, err)
+	}
+	return profiles, nil
+}
+
+// SaveProfile saves profile under the given name, replacing any existing
+// profile of that name.
+func SaveProfile(name string, profile Profile) error {
+	profiles, err := LoadProfiles()
+	if err != nil {
+		return err
+	}
+	profiles[name] = profile
+
+	data, err := yaml.Marshal(profiles)
+	if err != nil {
+		return fmt.Errorf("failed to serialize profiles, error: %s", err)
+	}
+
+	// the file holds credentials, so only the user may read it
+	file := profilesFile()
+	if err = os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return fmt.Errorf("failed to create folder for profiles file %s, error: %s", file, err)
+	}
+	if err = ioutil.WriteFile(file, data, 0600); err != nil {
+		return fmt.Errorf("failed to write profiles file %s, error: %s", file, err)
+	}
+	return nil
+}
+
+// UseProfile replaces the root URL and credentials loaded by Setup with
+// those of the named profile.
+func UseProfile(name string) error {
+	profiles, err := LoadProfiles()
+	if err != nil {
+		return err
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		return fmt.Errorf("no profile named %q; create it with `taskcluster signin --profile %s`", name, name)
+	}
+	if !profile.Expires.IsZero() && time.Now().After(profile.Expires) {
+		return fmt.Errorf("the credentials of profile %q expired at %s; renew them with `taskcluster signin --profile %s`",
+			name, profile.Expires.Format(time.RFC3339), name)
+	}
+
+	if profile.RootURL != "" {
+		rootURL = profile.RootURL
+	}
+	Credentials = &client.Credentials{
+		ClientID:    profile.ClientID,
+		AccessToken: profile.AccessToken,
+		Certificate: profile.Certificate,
+	}
+	return nil
+}
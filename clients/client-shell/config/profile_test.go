@@ -0,0 +1,50 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+	"github.com/taskcluster/taskcluster/v27/clients/client-shell/client"
+)
+
+func TestProfiles(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "taskcluster-profiles")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	assert.NoError(os.Setenv("XDG_CONFIG_HOME", dir))
+	defer func(r string, c *client.Credentials) { rootURL, Credentials = r, c }(rootURL, Credentials)
+
+	profiles, err := LoadProfiles()
+	assert.NoError(err)
+	assert.Empty(profiles, "there should be no profiles before any is saved")
+	assert.Error(UseProfile("staging"), "using a missing profile should fail")
+
+	assert.NoError(SaveProfile("staging", Profile{
+		RootURL:     "https://tc.example.com",
+		ClientID:    "me",
+		AccessToken: "secret",
+		Expires:     time.Now().Add(time.Hour),
+	}))
+	assert.NoError(SaveProfile("old", Profile{
+		RootURL: "https://tc.example.com",
+		Expires: time.Now().Add(-time.Hour),
+	}))
+
+	info, err := os.Stat(filepath.Join(dir, "taskcluster", "profiles.yml"))
+	assert.NoError(err)
+	assert.Equal(os.FileMode(0600), info.Mode().Perm(), "profiles file should only be readable by the user")
+
+	assert.NoError(UseProfile("staging"))
+	assert.Equal("https://tc.example.com", RootURL())
+	assert.Equal("me", Credentials.ClientID)
+	assert.Equal("secret", Credentials.AccessToken)
+
+	assert.Error(UseProfile("old"), "using an expired profile should fail")
+}
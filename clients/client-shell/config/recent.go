@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// maxRecentIDs is the number of ids of each kind kept by RememberIDs.
+const maxRecentIDs = 50
+
+// recentIDsFile is the location of the file holding the ids recently given
+// to commands, which shell completion suggests.
+func recentIDsFile() string {
+	return filepath.Join(configFolder(), "taskcluster", "recent-ids.yml")
+}
+
+func loadRecentIDs() (map[string][]string, error) {
+	recent := make(map[string][]string)
+	file := recentIDsFile()
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return recent, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recent ids file %s, error: %s", file, err)
+	}
+	if err = yaml.Unmarshal(data, &recent); err != nil {
+		return nil, fmt.Errorf("read recent ids file %s, but failed to parse YAML, error: %s", file, err)
+	}
+	return recent, nil
+}
+
+// RecentIDs returns the ids of the given kind, such as "taskId", recently
+// given to commands, most recent first.
+func RecentIDs(kind string) ([]string, error) {
+	recent, err := loadRecentIDs()
+	if err != nil {
+		return nil, err
+	}
+	return recent[kind], nil
+}
+
+// RememberIDs records ids as the most recent ids of the given kind.
+func RememberIDs(kind string, ids ...string) error {
+	recent, err := loadRecentIDs()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		updated := []string{id}
+		for _, other := range recent[kind] {
+			if other != id {
+				updated = append(updated, other)
+			}
+		}
+		recent[kind] = updated
+	}
+	if len(recent[kind]) > maxRecentIDs {
+		recent[kind] = recent[kind][:maxRecentIDs]
+	}
+
+	data, err := yaml.Marshal(recent)
+	if err != nil {
+		return fmt.Errorf("failed to serialize recent ids, error: %s", err)
+	}
+	file := recentIDsFile()
+	if err = os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return fmt.Errorf("failed to create folder for recent ids file %s, error: %s", file, err)
+	}
+	if err = ioutil.WriteFile(file, data, 0600); err != nil {
+		return fmt.Errorf("failed to write recent ids file %s, error: %s", file, err)
+	}
+	return nil
+}
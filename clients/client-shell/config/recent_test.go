@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestRecentIDs(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "taskcluster-recent")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	assert.NoError(os.Setenv("XDG_CONFIG_HOME", dir))
+
+	ids, err := RecentIDs("taskId")
+	assert.NoError(err)
+	assert.Empty(ids)
+
+	assert.NoError(RememberIDs("taskId", "a", "b"))
+	assert.NoError(RememberIDs("taskGroupId", "g"))
+	assert.NoError(RememberIDs("taskId", "a"))
+
+	ids, err = RecentIDs("taskId")
+	assert.NoError(err)
+	assert.Equal([]string{"a", "b"}, ids, "ids should be most recent first, without duplicates")
+	ids, err = RecentIDs("taskGroupId")
+	assert.NoError(err)
+	assert.Equal([]string{"g"}, ids)
+
+	for i := 0; i < maxRecentIDs+10; i++ {
+		assert.NoError(RememberIDs("taskId", fmt.Sprint(i)))
+	}
+	ids, err = RecentIDs("taskId")
+	assert.NoError(err)
+	assert.Len(ids, maxRecentIDs)
+	assert.Equal(fmt.Sprint(maxRecentIDs+9), ids[0])
+}
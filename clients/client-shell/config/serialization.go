@@ -12,8 +12,8 @@ import (
 	yaml "gopkg.in/yaml.v2"
 )
 
-// configFile is the location of the configuration file
-func configFile() string {
+// configFolder is the folder holding the configuration files
+func configFolder() string {
 	configFolder := os.Getenv("XDG_CONFIG_HOME")
 	if configFolder == "" {
 		homeFolder := os.Getenv("HOME")
@@ -24,7 +24,12 @@ func configFile() string {
 			configFolder = filepath.Join(homeFolder, ".config")
 		}
 	}
-	return filepath.Join(configFolder, "taskcluster.yml")
+	return configFolder
+}
+
+// configFile is the location of the configuration file
+func configFile() string {
+	return filepath.Join(configFolder(), "taskcluster.yml")
 }
 
 // Load will load configuration file, and initialize a default configuration
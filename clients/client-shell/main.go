@@ -12,8 +12,8 @@ func main() {
 	config.Setup()
 
 	// gentlemen, START YOUR ENGINES
-	if err := root.Command.Execute(); err != nil {
-		os.Exit(1)
+	if err := root.Execute(); err != nil {
+		os.Exit(root.ExitCode(err))
 	} else {
 		os.Exit(0)
 	}
@@ -5,12 +5,26 @@ package main
 
 import (
 	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/apis"
+	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/artifact"
+	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/auth"
 	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/completions"
 	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/config"
+	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/creds"
 	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/from-now"
+	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/github"
 	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/group"
+	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/hooks"
+	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/index"
+	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/notify"
+	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/pulse"
+	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/queue"
+	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/secrets"
+	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/shell"
+	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/signed-url"
 	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/signin"
 	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/slugid"
 	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/task"
 	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/version"
+	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/worker"
+	_ "github.com/taskcluster/taskcluster/v27/clients/client-shell/cmds/worker-manager"
 )
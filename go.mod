@@ -19,6 +19,7 @@ require (
 	github.com/golang/snappy v0.0.1 // indirect
 	github.com/gorilla/websocket v1.4.1
 	github.com/iancoleman/strcase v0.0.0-20191112232945-16388991a334
+	github.com/json-e/json-e/v4 v4.4.3
 	github.com/kr/text v0.2.0
 	github.com/mholt/archiver v2.1.0+incompatible
 	github.com/mitchellh/go-homedir v1.1.0
@@ -1,4 +1,5 @@
 const Octokit = require('@octokit/rest');
+const crypto = require('crypto');
 const fs = require('fs');
 const glob = require('glob');
 const util = require('util');
@@ -16,6 +17,7 @@ const {
 } = require('../utils');
 
 const readFile = util.promisify(fs.readFile);
+const writeFile = util.promisify(fs.writeFile);
 
 module.exports = ({tasks, cmdOptions, credentials, baseDir}) => {
   const artifactsDir = path.join(baseDir, 'release-artifacts');
@@ -115,6 +117,15 @@ module.exports = ({tasks, cmdOptions, credentials, baseDir}) => {
           return `taskcluster-${os}-${arch}`;
         });
 
+      // `taskcluster update` verifies its downloads against these checksums
+      const sums = [];
+      for (let name of artifacts) {
+        const hash = crypto.createHash('sha256').update(await readFile(path.join(artifactsDir, name)));
+        sums.push(`${hash.digest('hex')}  ${name}\n`);
+      }
+      await writeFile(path.join(artifactsDir, 'SHA256SUMS'), sums.join(''));
+      artifacts.push('SHA256SUMS');
+
       return {
         'client-shell-artifacts': artifacts,
       };
@@ -0,0 +1,216 @@
+// Package progress reports the progress of operations over many items, such
+// as downloading the artifacts of a task, so that both people watching a
+// terminal and CI logs get sensible output.
+//
+//	p := progress.New(os.Stderr, "downloading artifacts")
+//	p.Started(len(names))
+//	for _, name := range names {
+//		p.Completed(name, download(name))
+//	}
+//	p.Done()
+//
+// On a terminal, a progress bar is drawn and redrawn in place; otherwise
+// every event is written on a line of its own.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// A Reporter is told about the progress of an operation: it is started with
+// the number of items to process, each item is completed, possibly after
+// being retried, and the operation is then done. The methods of a Reporter
+// may be called concurrently.
+type Reporter interface {
+	// Started is called before the items are processed.
+	Started(total int)
+	// Completed is called once an item is processed, with its error if it
+	// failed.
+	Completed(item string, err error)
+	// Retry is called when an item is about to be processed again, after
+	// attempt failed with err.
+	Retry(item string, attempt int, err error)
+	// Done is called once all the items are processed.
+	Done()
+}
+
+// Discard is a Reporter that reports nothing.
+var Discard Reporter = discard{}
+
+type discard struct{}
+
+func (discard) Started(int)              {}
+func (discard) Completed(string, error)  {}
+func (discard) Retry(string, int, error) {}
+func (discard) Done()                    {}
+
+// isTerminal is replaced in tests.
+var isTerminal = func(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	return ok && terminal.IsTerminal(int(f.Fd()))
+}
+
+// New returns a Reporter writing to out: a Bar if out is a terminal, and a
+// Log otherwise. The operation is a short description of what is done, such
+// as "cancelling tasks".
+func New(out io.Writer, operation string) Reporter {
+	if isTerminal(out) {
+		return NewBar(out, operation)
+	}
+	return NewLog(out, operation)
+}
+
+// counts are the items processed so far.
+type counts struct {
+	total, done, failed int
+}
+
+// progress returns the number of items processed, and the total if known.
+func (c counts) progress() string {
+	if c.total > 0 {
+		return fmt.Sprintf("%d/%d", c.done, c.total)
+	}
+	return fmt.Sprint(c.done)
+}
+
+// Log is a Reporter writing one line per event, for output that is not a
+// terminal, such as CI logs.
+type Log struct {
+	out       io.Writer
+	operation string
+
+	mu sync.Mutex
+	counts
+}
+
+// NewLog returns a Log writing to out.
+func NewLog(out io.Writer, operation string) *Log {
+	return &Log{out: out, operation: operation}
+}
+
+// Started implements Reporter.
+func (l *Log) Started(total int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.total = total
+	fmt.Fprintf(l.out, "%s: started, %d in total\n", l.operation, total)
+}
+
+// Completed implements Reporter.
+func (l *Log) Completed(item string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.done++
+	if err != nil {
+		l.failed++
+		fmt.Fprintf(l.out, "%s: [%s] %s failed: %v\n", l.operation, l.progress(), item, err)
+		return
+	}
+	fmt.Fprintf(l.out, "%s: [%s] %s\n", l.operation, l.progress(), item)
+}
+
+// Retry implements Reporter.
+func (l *Log) Retry(item string, attempt int, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.out, "%s: retrying %s after attempt %d failed: %v\n", l.operation, item, attempt, err)
+}
+
+// Done implements Reporter.
+func (l *Log) Done() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.out, "%s: done, %d of %d succeeded (%d failed)\n", l.operation, l.done-l.failed, l.done, l.failed)
+}
+
+// barWidth is the number of characters of the bar of a Bar.
+const barWidth = 30
+
+// Bar is a Reporter drawing a progress bar on a terminal, redrawn in place
+// as items are processed. Failures are written above the bar, since they are
+// worth keeping on screen.
+type Bar struct {
+	out       io.Writer
+	operation string
+
+	mu sync.Mutex
+	counts
+	// width is the length of the line last drawn, which is blanked out
+	// when a shorter one replaces it.
+	width int
+}
+
+// NewBar returns a Bar drawing on out.
+func NewBar(out io.Writer, operation string) *Bar {
+	return &Bar{out: out, operation: operation}
+}
+
+// draw replaces the current line with the bar, followed by status.
+func (b *Bar) draw(status string) {
+	filled := 0
+	if b.total > 0 {
+		filled = barWidth * b.done / b.total
+	}
+	line := fmt.Sprintf("%s [%s%s] %s", b.operation, strings.Repeat("=", filled), strings.Repeat(" ", barWidth-filled), b.progress())
+	if b.failed > 0 {
+		line += fmt.Sprintf(" (%d failed)", b.failed)
+	}
+	if status != "" {
+		line += " " + status
+	}
+	padding := ""
+	if len(line) < b.width {
+		padding = strings.Repeat(" ", b.width-len(line))
+	}
+	fmt.Fprintf(b.out, "\r%s%s", line, padding)
+	b.width = len(line)
+}
+
+// clear blanks out the current line, so that a message can be written
+// instead.
+func (b *Bar) clear() {
+	fmt.Fprintf(b.out, "\r%s\r", strings.Repeat(" ", b.width))
+	b.width = 0
+}
+
+// Started implements Reporter.
+func (b *Bar) Started(total int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.total = total
+	b.draw("")
+}
+
+// Completed implements Reporter.
+func (b *Bar) Completed(item string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.done++
+	if err != nil {
+		b.failed++
+		b.clear()
+		fmt.Fprintf(b.out, "%s failed: %v\n", item, err)
+	}
+	b.draw("")
+}
+
+// Retry implements Reporter.
+func (b *Bar) Retry(item string, attempt int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.draw(fmt.Sprintf("retrying %s", item))
+}
+
+// Done implements Reporter.
+func (b *Bar) Done() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.draw("")
+	fmt.Fprintln(b.out)
+}
@@ -0,0 +1,77 @@
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func run(r Reporter) {
+	r.Started(3)
+	r.Completed("a", nil)
+	r.Retry("b", 1, errors.New("timeout"))
+	r.Completed("b", errors.New("not found"))
+	r.Completed("c", nil)
+	r.Done()
+}
+
+func TestLog(t *testing.T) {
+	out := &bytes.Buffer{}
+	run(NewLog(out, "fetching"))
+	expected := "" +
+		"fetching: started, 3 in total\n" +
+		"fetching: [1/3] a\n" +
+		"fetching: retrying b after attempt 1 failed: timeout\n" +
+		"fetching: [2/3] b failed: not found\n" +
+		"fetching: [3/3] c\n" +
+		"fetching: done, 2 of 3 succeeded (1 failed)\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, out.String())
+	}
+}
+
+func TestBar(t *testing.T) {
+	out := &bytes.Buffer{}
+	run(NewBar(out, "fetching"))
+	bar := func(filled int) string {
+		return "[" + strings.Repeat("=", filled) + strings.Repeat(" ", barWidth-filled) + "]"
+	}
+	expected := "" +
+		"\rfetching " + bar(0) + " 0/3" +
+		"\rfetching " + bar(10) + " 1/3" +
+		"\rfetching " + bar(10) + " 1/3 retrying b" +
+		"\r" + strings.Repeat(" ", 56) + "\r" + "b failed: not found\n" +
+		"\rfetching " + bar(20) + " 2/3 (1 failed)" +
+		"\rfetching " + bar(30) + " 3/3 (1 failed)" +
+		"\rfetching " + bar(30) + " 3/3 (1 failed)" +
+		"\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%q\nGot:\n%q", expected, out.String())
+	}
+}
+
+func TestBarPadding(t *testing.T) {
+	out := &bytes.Buffer{}
+	b := NewBar(out, "fetching")
+	b.Started(1)
+	b.Retry("a-long-item-name", 1, errors.New("timeout"))
+	out.Reset()
+	b.Completed("a-long-item-name", nil)
+	if !strings.HasSuffix(out.String(), " 1/1"+strings.Repeat(" ", len(" retrying a-long-item-name"))) {
+		t.Errorf("Expected the retry status to be blanked out, got %q", out.String())
+	}
+}
+
+func TestNew(t *testing.T) {
+	defer func(orig func(io.Writer) bool) { isTerminal = orig }(isTerminal)
+	isTerminal = func(io.Writer) bool { return true }
+	if _, ok := New(&bytes.Buffer{}, "fetching").(*Bar); !ok {
+		t.Error("Expected a Bar on a terminal")
+	}
+	isTerminal = func(io.Writer) bool { return false }
+	if _, ok := New(&bytes.Buffer{}, "fetching").(*Log); !ok {
+		t.Error("Expected a Log otherwise")
+	}
+}
@@ -0,0 +1,343 @@
+// Package apireference2go generates go client packages for Taskcluster
+// services from their API references, using jsonschema2go to generate the
+// request and response types from the schemas that the API reference refers
+// to.
+//
+// The generated client methods wrap tcclient.Client, in the same way as the
+// clients of the taskcluster go client (see
+// https://github.com/taskcluster/taskcluster/tree/master/clients/client-go).
+package apireference2go
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/taskcluster/taskcluster/v27/tools/jsonschema2go"
+	"github.com/taskcluster/taskcluster/v27/tools/jsonschema2go/text"
+)
+
+type (
+	// Job generates a go client package from the API reference at
+	// ReferenceURL.
+	Job struct {
+		// Package is the name of the generated go package.
+		Package string
+		// ReferenceURL is the http, https or file URL of the API reference
+		// json document, e.g.
+		// "https://community-tc.services.mozilla.com/references/queue/v1/api.json".
+		ReferenceURL string
+		// SchemasURL is the URL that the input and output schemas of the
+		// API entries are relative to, apart from the service name, e.g.
+		// "https://community-tc.services.mozilla.com/schemas". If empty,
+		// it is derived from ReferenceURL, by replacing the "references"
+		// path segment and everything after it with "schemas".
+		SchemasURL string
+		// Types configures the generation of the request and response
+		// types. Its Package and URLs are set by Execute. If nil, exported
+		// types are generated, with DisableNestedStructs set and date-time
+		// strings generated as tcclient.Time.
+		Types *jsonschema2go.Job
+	}
+
+	// Result contains the generated source code of the client package.
+	Result struct {
+		// SourceCode is the source code of the client type and its
+		// methods.
+		SourceCode []byte
+		// Types is the result of generating the request and response
+		// types, which are in the same package as the client, but a
+		// separate source file.
+		Types *jsonschema2go.Result
+	}
+
+	// reference is the subset of an API reference (see
+	// https://schemas.taskcluster.net/common/api-reference-v0.json) needed
+	// to generate a client.
+	reference struct {
+		ServiceName string  `json:"serviceName"`
+		APIVersion  string  `json:"apiVersion"`
+		Title       string  `json:"title"`
+		Description string  `json:"description"`
+		Entries     []entry `json:"entries"`
+	}
+
+	entry struct {
+		Type        string          `json:"type"`
+		Method      string          `json:"method"`
+		Route       string          `json:"route"`
+		Args        []string        `json:"args"`
+		Query       []string        `json:"query"`
+		Name        string          `json:"name"`
+		Stability   string          `json:"stability"`
+		Scopes      json.RawMessage `json:"scopes"`
+		Input       string          `json:"input"`
+		Output      string          `json:"output"`
+		Title       string          `json:"title"`
+		Description string          `json:"description"`
+
+		methodName string
+		inputURL   string
+		outputURL  string
+	}
+)
+
+// Execute loads the API reference, generates the request and response types
+// of its entries, and then generates the client code.
+func (job *Job) Execute() (*Result, error) {
+	ref, err := loadReference(job.ReferenceURL)
+	if err != nil {
+		return nil, err
+	}
+	schemasURL := job.SchemasURL
+	if schemasURL == "" {
+		i := strings.LastIndex(job.ReferenceURL, "/references/")
+		if i == -1 {
+			return nil, fmt.Errorf("Cannot derive schemas URL from API reference URL %v - please specify one", job.ReferenceURL)
+		}
+		schemasURL = job.ReferenceURL[:i] + "/schemas"
+	}
+	typesJob := job.Types
+	if typesJob == nil {
+		typesJob = &jsonschema2go.Job{
+			ExportTypes:          true,
+			DisableNestedStructs: true,
+			DateTime:             &jsonschema2go.TaskclusterDateTime,
+		}
+	}
+	typesJob.Package = job.Package
+	typesJob.URLs = []string{}
+	clientName := text.GoIdentifierFrom(ref.ServiceName, true, map[string]bool{})
+	if typesJob.TypeNameBlacklist == nil {
+		typesJob.TypeNameBlacklist = jsonschema2go.StringSet{}
+	}
+	// reserved package members
+	for _, member := range []string{clientName, "New", "NewFromEnv"} {
+		typesJob.TypeNameBlacklist[member] = true
+	}
+
+	functions := []*entry{}
+	methods := map[string]bool{}
+	for i := range ref.Entries {
+		e := &ref.Entries[i]
+		if e.Type != "function" {
+			continue
+		}
+		e.methodName = text.GoIdentifierFrom(e.Name, true, methods)
+		if e.Input != "" {
+			e.inputURL = schemasURL + "/" + ref.ServiceName + "/" + e.Input
+			typesJob.URLs = append(typesJob.URLs, e.inputURL)
+		}
+		// "blob" outputs are not json, so have no go type
+		if e.Output != "" && e.Output != "blob" {
+			e.outputURL = schemasURL + "/" + ref.ServiceName + "/" + e.Output
+			typesJob.URLs = append(typesJob.URLs, e.outputURL)
+		}
+		functions = append(functions, e)
+	}
+	if len(functions) == 0 {
+		return nil, fmt.Errorf("API reference %v defines no functions", job.ReferenceURL)
+	}
+
+	result := &Result{}
+	result.Types, err = typesJob.Execute()
+	if err != nil {
+		return nil, err
+	}
+	content := generateClient(job.Package, clientName, ref, functions, result.Types.SchemaSet)
+	result.SourceCode, err = format.Source([]byte(content))
+	if err != nil {
+		return result, fmt.Errorf("Formatting error: %v\n%s", err, content)
+	}
+	return result, nil
+}
+
+func loadReference(URL string) (*reference, error) {
+	u, err := url.Parse(URL)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	switch u.Scheme {
+	case "http", "https":
+		resp, err := http.Get(URL)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("Could not fetch API reference %v: HTTP %v", URL, resp.Status)
+		}
+		data, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+	case "file":
+		data, err = ioutil.ReadFile(u.Path)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported URL scheme in API reference URL %v", URL)
+	}
+	ref := new(reference)
+	err = json.Unmarshal(data, ref)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse API reference %v: %v", URL, err)
+	}
+	return ref, nil
+}
+
+func generateClient(packageName, clientName string, ref *reference, functions []*entry, schemaSet *jsonschema2go.SchemaSet) string {
+	receiver := strings.ToLower(clientName[:1]) + clientName[1:]
+	if receiver == clientName || receiver == packageName {
+		receiver = "my" + clientName
+	}
+	content := "// This source code file is AUTO-GENERATED by github.com/taskcluster/jsonschema2go/apireference2go\n\n"
+	if ref.Description != "" {
+		content += text.Indent(strings.TrimRight(ref.Description, "\n")+"\n", "// ")
+	}
+	content += `package ` + packageName + `
+
+import (
+	"net/url"
+	"time"
+
+	tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+)
+
+// ` + clientName + ` is a client for the ` + ref.ServiceName + ` service.
+type ` + clientName + ` tcclient.Client
+
+// New returns ` + text.IndefiniteArticle(clientName) + ` ` + clientName + ` client for the deployment at rootURL. Pass in nil
+// credentials to create a client without authentication.
+func New(credentials *tcclient.Credentials, rootURL string) *` + clientName + ` {
+	return &` + clientName + `{
+		Credentials:  credentials,
+		RootURL:      rootURL,
+		ServiceName:  "` + ref.ServiceName + `",
+		APIVersion:   "` + ref.APIVersion + `",
+		Authenticate: credentials != nil,
+	}
+}
+
+// NewFromEnv returns ` + text.IndefiniteArticle(clientName) + ` ` + clientName + ` client configured from the
+// TASKCLUSTER_* environment variables.
+func NewFromEnv() *` + clientName + ` {
+	c := tcclient.CredentialsFromEnvVars()
+	return &` + clientName + `{
+		Credentials:  c,
+		RootURL:      tcclient.RootURLFromEnvVars(),
+		ServiceName:  "` + ref.ServiceName + `",
+		APIVersion:   "` + ref.APIVersion + `",
+		Authenticate: c.ClientID != "",
+	}
+}
+`
+	usesTime := false
+	for _, e := range functions {
+		content += e.method(receiver, clientName, schemaSet)
+		if signedURL := e.signedURLMethod(receiver, clientName); signedURL != "" {
+			content += signedURL
+			usesTime = true
+		}
+	}
+	if !usesTime {
+		content = strings.Replace(content, "\t\"time\"\n", "", 1)
+	}
+	return content
+}
+
+// parameters returns the go parameters for the route arguments and query
+// string parameters of the entry, and the code to build the query string.
+func (e *entry) parameters() (params []string, queryCode, queryExpr string) {
+	args := append([]string{}, e.Args...)
+	queryExpr = "nil"
+	if len(e.Query) > 0 {
+		query := append([]string{}, e.Query...)
+		sort.Strings(query)
+		queryExpr = "v"
+		queryCode = "\tv := url.Values{}\n"
+		for _, q := range query {
+			args = append(args, q)
+			queryCode += "\tif " + q + " != \"\" {\n\t\tv.Add(\"" + q + "\", " + q + ")\n\t}\n"
+		}
+	}
+	if len(args) > 0 {
+		params = append(params, strings.Join(args, ", ")+" string")
+	}
+	return
+}
+
+// route returns a go expression for the route of the entry, with its
+// arguments substituted.
+func (e *entry) route() string {
+	expr := `"` + strings.Replace(strings.Replace(e.Route, "<", `" + url.QueryEscape(`, -1), ">", `) + "`, -1) + `"`
+	return strings.Replace(expr, ` + ""`, "", -1)
+}
+
+func (e *entry) comment() string {
+	comment := "\n"
+	if e.Stability != "" && e.Stability != "stable" {
+		comment += "// Stability: *** " + strings.ToUpper(e.Stability) + " ***\n//\n"
+	}
+	if e.Description != "" {
+		comment += text.Indent(strings.TrimRight(e.Description, "\n")+"\n", "// ")
+	}
+	if scopes := scopesComment(e.Scopes); scopes != "" {
+		comment += "//\n" + scopes
+	}
+	return comment
+}
+
+func (e *entry) method(receiver, clientName string, schemaSet *jsonschema2go.SchemaSet) string {
+	params, queryCode, queryExpr := e.parameters()
+	payload := "nil"
+	if e.inputURL != "" {
+		payload = "payload"
+		params = append(params, "payload *"+schemaSet.SubSchema(e.inputURL).TypeName)
+	}
+	returns := "error"
+	if e.outputURL != "" {
+		returns = "(*" + schemaSet.SubSchema(e.outputURL).TypeName + ", error)"
+	}
+	content := e.comment()
+	content += "func (" + receiver + " *" + clientName + ") " + e.methodName + "(" + strings.Join(params, ", ") + ") " + returns + " {\n"
+	content += queryCode
+	content += "\tcd := tcclient.Client(*" + receiver + ")\n"
+	method := strings.ToUpper(e.Method)
+	if e.outputURL != "" {
+		outputType := schemaSet.SubSchema(e.outputURL).TypeName
+		content += "\tresponseObject, _, err := (&cd).APICall(" + payload + ", \"" + method + "\", " + e.route() + ", new(" + outputType + "), " + queryExpr + ")\n"
+		content += "\treturn responseObject.(*" + outputType + "), err\n"
+	} else {
+		content += "\t_, _, err := (&cd).APICall(" + payload + ", \"" + method + "\", " + e.route() + ", nil, " + queryExpr + ")\n"
+		content += "\treturn err\n"
+	}
+	return content + "}\n"
+}
+
+// signedURLMethod returns a method generating a signed URL for GET entries
+// that require scopes, or an empty string for other entries.
+func (e *entry) signedURLMethod(receiver, clientName string) string {
+	if strings.ToUpper(e.Method) != "GET" || len(e.Scopes) == 0 {
+		return ""
+	}
+	params, queryCode, queryExpr := e.parameters()
+	params = append(params, "duration time.Duration")
+	content := "\n// " + e.methodName + "_SignedURL returns a signed URL for " + e.methodName + ", valid for the\n"
+	content += "// specified duration.\n"
+	if scopes := scopesComment(e.Scopes); scopes != "" {
+		content += "//\n" + scopes
+	}
+	content += "func (" + receiver + " *" + clientName + ") " + e.methodName + "_SignedURL(" + strings.Join(params, ", ") + ") (*url.URL, error) {\n"
+	content += queryCode
+	content += "\tcd := tcclient.Client(*" + receiver + ")\n"
+	content += "\treturn (&cd).SignedURL(" + e.route() + ", " + queryExpr + ", duration)\n"
+	return content + "}\n"
+}
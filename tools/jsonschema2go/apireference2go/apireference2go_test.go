@@ -0,0 +1,73 @@
+package apireference2go
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecute(t *testing.T) {
+	testdata, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := &Job{
+		Package:      "tchello",
+		ReferenceURL: "file://" + filepath.Join(testdata, "references", "hello", "v1", "api.json"),
+	}
+	result, err := job.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := string(result.Types.SourceCode)
+	for _, snippet := range []string{
+		"package tchello",
+		"GreetingRequest struct {",
+		"GreetingResponse struct {",
+		"Created tcclient.Time",
+	} {
+		if !strings.Contains(types, snippet) {
+			t.Errorf("expected generated types to contain %q:\n%s", snippet, types)
+		}
+	}
+	client := string(result.SourceCode)
+	for _, snippet := range []string{
+		"package tchello",
+		"type Hello tcclient.Client",
+		"func (hello *Hello) Ping() error {",
+		"func (hello *Hello) CreateGreeting(name string, payload *GreetingRequest) (*GreetingResponse, error) {",
+		`(&cd).APICall(payload, "PUT", "/greeting/"+url.QueryEscape(name), new(GreetingResponse), nil)`,
+		"func (hello *Hello) Greeting(name, language string) (*GreetingResponse, error) {",
+		"func (hello *Hello) Greeting_SignedURL(name, language string, duration time.Duration) (*url.URL, error) {",
+		"// Stability: *** EXPERIMENTAL ***",
+		"// Required scopes:",
+		"All of:",
+		"* hello:greet:<name>",
+		"* If loud:",
+		"hello:read:<name>",
+	} {
+		if !strings.Contains(client, snippet) {
+			t.Errorf("expected generated client to contain %q:\n%s", snippet, client)
+		}
+	}
+	if strings.Contains(client, "Greeted") {
+		t.Errorf("expected exchange entries to be ignored:\n%s", client)
+	}
+}
+
+func TestDescribeScopes(t *testing.T) {
+	for _, test := range []struct {
+		expression  interface{}
+		description string
+	}{
+		{"a", "a"},
+		{map[string]interface{}{"AnyOf": []interface{}{"a", "b"}}, "Any of:\n- a\n- b"},
+		{map[string]interface{}{"AllOf": []interface{}{"a"}}, "a"},
+		{map[string]interface{}{"for": "x", "in": "xs", "each": "s:<x>"}, "For x in xs each s:<x>"},
+		{[]interface{}{[]interface{}{"a", "b"}, []interface{}{"c"}}, "Any of:\n- All of:\n  * a\n  * b\n- c"},
+	} {
+		if got := describeScopes(test.expression); got != test.description {
+			t.Errorf("describeScopes(%#v): expected %q but got %q", test.expression, test.description, got)
+		}
+	}
+}
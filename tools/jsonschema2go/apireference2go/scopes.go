@@ -0,0 +1,79 @@
+package apireference2go
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/taskcluster/taskcluster/v27/tools/jsonschema2go/text"
+)
+
+// scopesComment returns a go comment describing the given scope expression
+// template (see
+// https://schemas.taskcluster.net/common/api-reference-v0.json#/definitions/scopeExpressionTemplate),
+// or an empty string if no scopes are required.
+func scopesComment(scopes json.RawMessage) string {
+	if len(scopes) == 0 || string(scopes) == "null" {
+		return ""
+	}
+	var expression interface{}
+	err := json.Unmarshal(scopes, &expression)
+	if err != nil {
+		return ""
+	}
+	return text.Indent("Required scopes:\n"+text.Indent(describeScopes(expression)+"\n", "  "), "// ")
+}
+
+// describeScopes returns a human readable description of a scope expression
+// template, which has been unmarshaled into an interface{}.
+func describeScopes(expression interface{}) string {
+	switch e := expression.(type) {
+	case string:
+		return e
+	case []interface{}:
+		// old style scope sets: any of all of
+		anyOf := make([]interface{}, len(e))
+		for i, scopeSet := range e {
+			anyOf[i] = map[string]interface{}{"AllOf": scopeSet}
+		}
+		return describeScopes(map[string]interface{}{"AnyOf": anyOf})
+	case map[string]interface{}:
+		if allOf, ok := e["AllOf"].([]interface{}); ok {
+			return describeList("All of:", "* ", allOf)
+		}
+		if anyOf, ok := e["AnyOf"].([]interface{}); ok {
+			return describeList("Any of:", "- ", anyOf)
+		}
+		if condition, ok := e["if"]; ok {
+			return fmt.Sprintf("If %v:\n", condition) + text.Indent(describeScopes(e["then"]), "  ")
+		}
+		if _, ok := e["for"]; ok {
+			return fmt.Sprintf("For %v in %v each %v", e["for"], e["in"], e["each"])
+		}
+		keys := make([]string, 0, len(e))
+		for k := range e {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return "Unrecognised scope expression with keys " + strings.Join(keys, ", ")
+	}
+	return fmt.Sprintf("Unrecognised scope expression %v", expression)
+}
+
+func describeList(heading, bullet string, expressions []interface{}) string {
+	switch len(expressions) {
+	case 0:
+		return ""
+	case 1:
+		return describeScopes(expressions[0])
+	}
+	description := heading
+	for _, expression := range expressions {
+		x := text.Indent(describeScopes(expression), "  ")
+		if len(x) >= 2 {
+			description += "\n" + bullet + x[2:]
+		}
+	}
+	return description
+}
@@ -0,0 +1,180 @@
+package jsonschema2go
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// benchmarks returns the source code of a _test.go file containing, for each
+// generated type, benchmarks of unmarshaling and marshaling a sample json
+// document conforming to its schema, plus a test that its allocations stay
+// within budget if Job.AllocationBudgets has an entry for it.
+func (job *Job) benchmarks() ([]byte, error) {
+	schemas := make([]*JsonSubSchema, 0, len(job.result.SchemaSet.used))
+	for _, s := range job.result.SchemaSet.used {
+		schemas = append(schemas, s)
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].TypeName < schemas[j].TypeName })
+	content := new(bytes.Buffer)
+	content.WriteString(generatedCodeHeader + `
+
+package ` + job.Package + `
+
+import (
+	"encoding/json"
+	"testing"
+)
+`)
+	for _, s := range schemas {
+		sample, err := json.Marshal(s.sampleValue(map[*JsonSubSchema]bool{}))
+		if err != nil {
+			return nil, fmt.Errorf("Could not marshal sample json for %v: %v", s.SourceURL, err)
+		}
+		name := strings.ToUpper(s.TypeName[:1]) + s.TypeName[1:]
+		literal := "`" + string(sample) + "`"
+		if strings.Contains(literal[1:len(literal)-1], "`") {
+			literal = strconv.Quote(string(sample))
+		}
+		content.WriteString(`
+// sample` + name + ` is a json document that conforms to the schema that
+// ` + s.TypeName + ` was generated from, with every property set.
+const sample` + name + ` = ` + literal + `
+
+func Benchmark` + name + `Unmarshal(b *testing.B) {
+	data := []byte(sample` + name + `)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v ` + s.TypeName + `
+		if err := json.Unmarshal(data, &v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Benchmark` + name + `Marshal(b *testing.B) {
+	var v ` + s.TypeName + `
+	if err := json.Unmarshal([]byte(sample` + name + `), &v); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(&v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+`)
+		budget, hasBudget := job.AllocationBudgets[s.TypeName]
+		if !hasBudget {
+			continue
+		}
+		content.WriteString(`
+func Test` + name + `AllocationBudget(t *testing.T) {
+	data := []byte(sample` + name + `)
+	allocs := testing.AllocsPerRun(100, func() {
+		var v ` + s.TypeName + `
+		if err := json.Unmarshal(data, &v); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := json.Marshal(&v); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs > ` + strconv.Itoa(budget) + ` {
+		t.Errorf("Unmarshaling and marshaling ` + s.TypeName + ` took %v allocations, but the budget is ` + strconv.Itoa(budget) + `", allocs)
+	}
+}
+`)
+	}
+	sourceCode, err := format.Source(content.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("Formatting error: %v\n%s", err, content.Bytes())
+	}
+	return sourceCode, nil
+}
+
+// sampleValue returns a value that conforms to subSchema, for marshaling as
+// sample json. Constants, enums and defaults are used where given, all
+// properties are set and arrays have a single item. A schema that is already
+// being sampled (i.e. a recursive schema) is sampled as null, since the
+// generated go type must be able to hold null for it to terminate.
+func (subSchema *JsonSubSchema) sampleValue(sampling map[*JsonSubSchema]bool) interface{} {
+	s := subSchema.TargetSchema()
+	if sampling[s] {
+		return nil
+	}
+	sampling[s] = true
+	defer delete(sampling, s)
+	switch {
+	case s.Const != nil:
+		return *s.Const
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	case s.Default != nil:
+		return *s.Default
+	}
+	var nonNull []string
+	if s.Type != nil {
+		nonNull = s.Type.NonNull()
+	}
+	if len(nonNull) == 0 {
+		for _, items := range []*Items{s.AllOf, s.AnyOf, s.OneOf} {
+			if items != nil && len(items.Items) > 0 {
+				return items.Items[0].sampleValue(sampling)
+			}
+		}
+		return nil
+	}
+	switch nonNull[0] {
+	case "object":
+		object := map[string]interface{}{}
+		if p := s.Properties; p != nil {
+			for _, name := range p.SortedPropertyNames {
+				object[name] = p.Properties[name].sampleValue(sampling)
+			}
+		}
+		if ap := s.AdditionalProperties; ap != nil && ap.Properties != nil && s.Properties == nil {
+			object["key"] = ap.Properties.sampleValue(sampling)
+		}
+		return object
+	case "array":
+		if s.Items == nil {
+			return []interface{}{}
+		}
+		return []interface{}{s.Items.sampleValue(sampling)}
+	case "string":
+		if f := s.Format; f != nil && *f == "date-time" {
+			return "2006-01-02T15:04:05.000Z"
+		}
+		if e := s.ContentEncoding; e != nil && *e == "base64" {
+			return "c2FtcGxl"
+		}
+		value := "sample"
+		if min := s.MinLength; min != nil && *min > len(value) {
+			value += strings.Repeat("s", *min-len(value))
+		}
+		if max := s.MaxLength; max != nil && *max < len(value) {
+			value = value[:*max]
+		}
+		return value
+	case "integer":
+		if min := s.Minimum; min != nil {
+			return *min
+		}
+		return 1
+	case "number":
+		if min := s.Minimum; min != nil {
+			return *min
+		}
+		return 1.5
+	case "boolean":
+		return true
+	}
+	return nil
+}
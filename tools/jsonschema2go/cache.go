@@ -0,0 +1,149 @@
+package jsonschema2go
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Cache stores generated source code on disk, so that a Job whose inputs have
+// not changed since a previous run can skip code generation entirely.
+//
+// Entries are keyed by a sha256 hash of the Job settings together with the
+// content of every schema document that was loaded while executing the Job.
+// Since all documents referenced via $ref (transitively) are loaded, a change
+// to any of them results in a different key, and therefore a cache miss.
+//
+// Note, the TypeNameGenerator, MemberNameGenerator and ArrayItemName
+// functions of a Job cannot be hashed, so if these are customised between
+// runs, a different cache directory should be used.
+type Cache struct {
+	// Dir is the directory that cache entries are written to. It will be
+	// created if it does not already exist.
+	Dir string
+}
+
+func (cache *Cache) entryPath(key string) string {
+	return filepath.Join(cache.Dir, key+".go.cache")
+}
+
+func (cache *Cache) get(key string) (sourceCode []byte, found bool) {
+	sourceCode, err := ioutil.ReadFile(cache.entryPath(key))
+	if err != nil {
+		return nil, false
+	}
+	return sourceCode, true
+}
+
+func (cache *Cache) put(key string, sourceCode []byte) error {
+	err := os.MkdirAll(cache.Dir, 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(cache.entryPath(key), sourceCode, 0644)
+}
+
+// cacheKey returns a hex encoded hash of the job settings that affect the
+// generated code, and the content of all schema documents that were loaded.
+func (job *Job) cacheKey(blacklist []string) string {
+	h := sha256.New()
+	write := func(s string) {
+		// length prefix avoids ambiguity between adjacent values
+		fmt.Fprintf(h, "%d:%s", len(s), s)
+	}
+	write(job.Package)
+	write(strconv.FormatBool(job.ExportTypes))
+	write(strconv.FormatBool(job.HideStructMembers))
+	write(strconv.FormatBool(job.DisableNestedStructs))
+	write(strconv.FormatBool(job.EnforceRequiredFields))
+	write(strconv.FormatBool(job.PointerOptionals))
+	write(strconv.FormatBool(job.CanonicalJSON))
+	write(strconv.FormatBool(job.EmbedSchemas))
+	write(strconv.FormatBool(job.DeduplicateTypes))
+	write(strconv.FormatBool(job.PromoteSharedStructs))
+	write(strconv.Itoa(int(job.EmptyObjects)))
+	write(strconv.Itoa(int(job.Omit)))
+	write(strconv.FormatBool(job.JSONv2))
+	// generated code is only cached once it has been type checked, so a
+	// cache entry from a run without TypeCheck must not satisfy one with it
+	write(strconv.FormatBool(job.TypeCheck))
+	if job.DateTime != nil {
+		write(job.DateTime.Type)
+		write(job.DateTime.Import)
+	} else {
+		write("")
+	}
+	for _, URL := range job.URLs {
+		write(URL)
+	}
+	for _, filters := range [][]*regexp.Regexp{job.IncludeURLs, job.ExcludeURLs} {
+		write(strconv.Itoa(len(filters)))
+		for _, re := range filters {
+			write(re.String())
+		}
+	}
+	for _, globs := range [][]string{job.IncludeTypes, job.ExcludeTypes} {
+		write(strconv.Itoa(len(globs)))
+		for _, glob := range globs {
+			write(glob)
+		}
+	}
+	overridden := make([]string, 0, len(job.PropertyOverrides))
+	for URL := range job.PropertyOverrides {
+		overridden = append(overridden, URL)
+	}
+	sort.Strings(overridden)
+	for _, URL := range overridden {
+		write(URL)
+		write(strconv.FormatBool(job.PropertyOverrides[URL].Skip))
+		write(job.PropertyOverrides[URL].MemberName)
+	}
+	for _, name := range blacklist {
+		write(name)
+	}
+	documentURLs := make([]string, 0, len(job.result.SchemaSet.documentHashes))
+	for URL := range job.result.SchemaSet.documentHashes {
+		documentURLs = append(documentURLs, URL)
+	}
+	sort.Strings(documentURLs)
+	for _, URL := range documentURLs {
+		write(URL)
+		write(job.result.SchemaSet.documentHashes[URL])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedSourceCode returns previously generated source code for this job, if
+// the cache contains an entry for the current inputs.
+func (job *Job) cachedSourceCode(blacklist []string) (key string, sourceCode []byte, found bool) {
+	key = job.cacheKey(blacklist)
+	sourceCode, found = job.Cache.get(key)
+	if found {
+		log.Printf("Using cached source code for package %v (key %v)", job.Package, key)
+	}
+	return
+}
+
+// sortedMembers returns the members of the set in lexical order.
+func (set StringSet) sortedMembers() []string {
+	members := make([]string, 0, len(set))
+	for member, included := range set {
+		if included {
+			members = append(members, member)
+		}
+	}
+	sort.Strings(members)
+	return members
+}
+
+func hashDocument(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
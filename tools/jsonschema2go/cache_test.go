@@ -0,0 +1,104 @@
+package jsonschema2go
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonschema2go-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	schema, err := ioutil.ReadFile(filepath.Join("testdata", "person.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemaFile := filepath.Join(dir, "person.json")
+	err = ioutil.WriteFile(schemaFile, schema, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	execute := func() *Result {
+		job := &Job{
+			Package:     "main",
+			ExportTypes: true,
+			URLs:        []string{"file://" + schemaFile},
+			Cache:       &Cache{Dir: filepath.Join(dir, "cache")},
+		}
+		result, err := job.Execute()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	first := execute()
+	if first.FromCache {
+		t.Fatal("first run should not be served from cache")
+	}
+	second := execute()
+	if !second.FromCache {
+		t.Fatal("second run should be served from cache")
+	}
+	if string(first.SourceCode) != string(second.SourceCode) {
+		t.Fatalf("cached source code differs from generated source code:\n%s\n%s", first.SourceCode, second.SourceCode)
+	}
+
+	// changing the schema should invalidate the cache
+	err = ioutil.WriteFile(schemaFile, []byte(`{"title": "person", "type": "object", "additionalProperties": false, "properties": {"name": {"type": "string"}}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	third := execute()
+	if third.FromCache {
+		t.Fatal("run after schema change should not be served from cache")
+	}
+}
+
+func TestCacheTypeCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonschema2go-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	schemaFile := filepath.Join(dir, "outer.json")
+	err = ioutil.WriteFile(schemaFile, []byte(`{"title": "Outer", "type": "object", "additionalProperties": false, "properties": {"inner": {"type": "object", "additionalProperties": false, "properties": {"x": {"type": "boolean"}}}}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	execute := func(typeCheck bool) (*Result, error) {
+		job := &Job{
+			Package:              "main",
+			ExportTypes:          true,
+			DisableNestedStructs: true,
+			TypeCheck:            typeCheck,
+			URLs:                 []string{"file://" + schemaFile},
+			Cache:                &Cache{Dir: filepath.Join(dir, "cache")},
+			// deliberately generate clashing type names
+			TypeNameGenerator: func(name string, exported bool, blacklist map[string]bool) string {
+				return "Same"
+			},
+		}
+		return job.Execute()
+	}
+
+	_, err = execute(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := execute(true)
+	if _, ok := err.(*TypeCheckError); !ok {
+		t.Fatalf("expected *TypeCheckError after a run without type checking but got %#v", err)
+	}
+	if result != nil && result.FromCache {
+		t.Fatal("run with type checking should not be served from cache of a run without")
+	}
+}
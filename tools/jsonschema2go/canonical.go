@@ -0,0 +1,53 @@
+package jsonschema2go
+
+import (
+	"io"
+)
+
+// canonicalJSONMethods writes a CanonicalJSON method for each of the given
+// generated types, plus the helper function they share.
+//
+// encoding/json already marshals struct members in declaration order (which
+// is sorted by property name) and map entries sorted by key, but
+// json.RawMessage values are copied verbatim, so may contain keys in any
+// order. Re-encoding via interface{} sorts the keys at every level.
+func canonicalJSONMethods(w io.Writer, typeNames []string) {
+	for _, typeName := range typeNames {
+		io.WriteString(w, `
+
+// CanonicalJSON returns the json encoding of `+typeName+`, with object keys
+// sorted at every level, no insignificant whitespace and no escaping of <, >
+// and &, so that equal values always produce identical bytes, e.g. for
+// hashing or signing.
+func (this `+typeName+`) CanonicalJSON() ([]byte, error) {
+	return canonicalJSON(&this)
+}`)
+	}
+	io.WriteString(w, `
+
+// canonicalJSON marshals v, and then re-encodes the result via interface{},
+// so that object keys are sorted at every level. Numbers are preserved
+// exactly as originally encoded, and strings are not HTML escaped.
+func canonicalJSON(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic interface{}
+	err = decoder.Decode(&generic)
+	if err != nil {
+		return nil, err
+	}
+	var canonical bytes.Buffer
+	encoder := json.NewEncoder(&canonical)
+	encoder.SetEscapeHTML(false)
+	err = encoder.Encode(generic)
+	if err != nil {
+		return nil, err
+	}
+	// Encode terminates the json with a newline
+	return bytes.TrimSuffix(canonical.Bytes(), []byte("\n")), nil
+}`)
+}
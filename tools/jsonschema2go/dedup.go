@@ -0,0 +1,99 @@
+package jsonschema2go
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"sort"
+	"strings"
+)
+
+// TypeMerge records that a generated type was not generated, since it had
+// the same structure as another generated type, which is used in its place.
+type TypeMerge struct {
+	// From is the name the merged type would have had.
+	From string
+	// Into is the name of the type used instead.
+	Into string
+	// SourceURL is the URL of the schema of the merged type.
+	SourceURL string
+}
+
+// structuralHash returns a hash of the go struct generated for subSchema,
+// ignoring comments, so that schemas with identical structure but different
+// descriptions have the same hash. An empty string is returned for schemas
+// that do not generate a struct.
+func (subSchema *JsonSubSchema) structuralHash(disableNested bool) string {
+	_, typ := subSchema.typeDefinition(disableNested, true, StringSet{}, StringSet{})
+	if !strings.HasPrefix(typ, "struct {") {
+		return ""
+	}
+	h := sha256.New()
+	for _, line := range strings.Split(typ, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		h.Write([]byte(line + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// deduplicateTypes merges generated struct types of untitled schemas into
+// structurally identical types, so that identical anonymous objects defined
+// in several places share a single go type. Since merging types can make
+// the types that refer to them identical too, this is repeated until no
+// more types can be merged. Titled schemas always keep their own type, but
+// may absorb untitled ones.
+func (job *Job) deduplicateTypes() {
+	schemaSet := job.result.SchemaSet
+	for merged := true; merged; {
+		merged = false
+		byHash := map[string][]*JsonSubSchema{}
+		for _, subSchema := range schemaSet.used {
+			if hash := subSchema.structuralHash(job.DisableNestedStructs); hash != "" {
+				byHash[hash] = append(byHash[hash], subSchema)
+			}
+		}
+		hashes := make([]string, 0, len(byHash))
+		for hash := range byHash {
+			hashes = append(hashes, hash)
+		}
+		sort.Strings(hashes)
+		for _, hash := range hashes {
+			schemas := byHash[hash]
+			if len(schemas) < 2 {
+				continue
+			}
+			// prefer titled schemas, then the lowest type name
+			sort.Slice(schemas, func(i, j int) bool {
+				if titled(schemas[i]) != titled(schemas[j]) {
+					return titled(schemas[i])
+				}
+				return schemas[i].TypeName < schemas[j].TypeName
+			})
+			into := schemas[0]
+			for _, subSchema := range schemas[1:] {
+				if titled(subSchema) {
+					continue
+				}
+				log.Printf("Merging type %v (%v) into identical type %v", subSchema.TypeName, subSchema.SourceURL, into.TypeName)
+				job.result.TypeMerges = append(job.result.TypeMerges, TypeMerge{
+					From:      subSchema.TypeName,
+					Into:      into.TypeName,
+					SourceURL: subSchema.SourceURL,
+				})
+				delete(schemaSet.used, subSchema.SourceURL)
+				delete(schemaSet.TypeNames, subSchema.TypeName)
+				// references to the merged schema now use the type it was
+				// merged into
+				subSchema.TypeName = into.TypeName
+				merged = true
+			}
+		}
+	}
+}
+
+func titled(subSchema *JsonSubSchema) bool {
+	return subSchema.Title != nil && *subSchema.Title != ""
+}
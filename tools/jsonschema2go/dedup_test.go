@@ -0,0 +1,93 @@
+package jsonschema2go
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDeduplicateTypes(t *testing.T) {
+	job := &Job{
+		Package:              "main",
+		ExportTypes:          true,
+		DisableNestedStructs: true,
+		DeduplicateTypes:     true,
+		URLs:                 []string{"-"},
+		Stdin: strings.NewReader(`{
+		"title": "Copy",
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"from": {
+				"description": "Where to copy from",
+				"type": "object",
+				"additionalProperties": false,
+				"properties": {
+					"location": {
+						"type": "object",
+						"additionalProperties": false,
+						"properties": {"url": {"type": "string"}}
+					}
+				}
+			},
+			"to": {
+				"description": "Where to copy to",
+				"type": "object",
+				"additionalProperties": false,
+				"properties": {
+					"location": {
+						"type": "object",
+						"additionalProperties": false,
+						"properties": {"url": {"type": "string"}}
+					}
+				}
+			}
+		}
+	}`),
+	}
+	result, err := job.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourceCode := string(result.SourceCode)
+	assertContains(t, sourceCode,
+		"From From `json:\"from,omitempty\"`",
+		"To From `json:\"to,omitempty\"`",
+		"Location Location `json:\"location,omitempty\"`",
+	)
+	for _, merged := range []string{"To struct", "Location1 struct"} {
+		if strings.Contains(sourceCode, merged) {
+			t.Errorf("expected %q to be merged:\n%s", merged, sourceCode)
+		}
+	}
+	expected := []TypeMerge{
+		{From: "Location1", Into: "Location", SourceURL: "stdin://#/properties/to/properties/location"},
+		{From: "To", Into: "From", SourceURL: "stdin://#/properties/to"},
+	}
+	if !reflect.DeepEqual(result.TypeMerges, expected) {
+		t.Fatalf("expected type merges %v but got %v", expected, result.TypeMerges)
+	}
+}
+
+func TestTypeMerges(t *testing.T) {
+	schemaFile, err := filepath.Abs(filepath.Join("testdata", "person.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := &Job{
+		Package:              "main",
+		ExportTypes:          true,
+		URLs:                 []string{"file://" + schemaFile},
+		DisableNestedStructs: true,
+		DeduplicateTypes:     true,
+	}
+	result, err := job.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// hobbies and dislikes refer to the same schema, so nothing to merge
+	if !reflect.DeepEqual(result.TypeMerges, []TypeMerge(nil)) {
+		t.Fatalf("expected no type merges, but got %v", result.TypeMerges)
+	}
+}
@@ -0,0 +1,163 @@
+package jsonschema2go
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// itemsIndex matches the "[<n>]" suffix used in SourceURLs of allOf, anyOf
+// and oneOf entries, e.g. ".../anyOf[2]"
+var itemsIndex = regexp.MustCompile(`^(.*)\[([0-9]+)\]$`)
+
+// lookupJSONPointer returns the value inside the json document doc (as decoded
+// into an interface{}) located at the given json pointer (see
+// https://tools.ietf.org/html/rfc6901). SourceURL style array indexes (e.g.
+// "/allOf[1]") are also accepted.
+func lookupJSONPointer(doc interface{}, pointer string) (interface{}, error) {
+	if pointer == "" {
+		return doc, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("Invalid json pointer %q - must start with '/'", pointer)
+	}
+	tokens := []string{}
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		if m := itemsIndex.FindStringSubmatch(token); m != nil {
+			tokens = append(tokens, m[1], m[2])
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	current := doc
+	for _, token := range tokens {
+		switch c := current.(type) {
+		case map[string]interface{}:
+			value, ok := c[token]
+			if !ok {
+				return nil, fmt.Errorf("Json pointer %q: no property %q", pointer, token)
+			}
+			current = value
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, fmt.Errorf("Json pointer %q: invalid array index %q", pointer, token)
+			}
+			current = c[i]
+		default:
+			return nil, fmt.Errorf("Json pointer %q: cannot descend into %T with %q", pointer, current, token)
+		}
+	}
+	return current, nil
+}
+
+// rawSchema returns the indented json of the given subschema, as it appears
+// in the document it was loaded from.
+func (schemaSet *SchemaSet) rawSchema(subSchema *JsonSubSchema) (string, error) {
+	hash := strings.Index(subSchema.SourceURL, "#")
+	doc, loaded := schemaSet.documents[subSchema.SourceURL[:hash+1]]
+	if !loaded {
+		return "", fmt.Errorf("Document for %v not loaded", subSchema.SourceURL)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	// preserve numbers exactly as they appear in the document
+	decoder.UseNumber()
+	var generic interface{}
+	err := decoder.Decode(&generic)
+	if err != nil {
+		return "", err
+	}
+	value, err := lookupJSONPointer(generic, subSchema.SourceURL[hash+1:])
+	if err != nil {
+		return "", err
+	}
+	raw, err := json.MarshalIndent(value, "", "  ")
+	return string(raw), err
+}
+
+// schemaEmbeddings writes, for each generated type, a constant containing the
+// json schema it was generated from, and a Schema() method returning it.
+func schemaEmbeddings(w io.Writer, schemaSet *SchemaSet) error {
+	schemas := make([]*JsonSubSchema, 0, len(schemaSet.used))
+	for _, s := range schemaSet.used {
+		schemas = append(schemas, s)
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].TypeName < schemas[j].TypeName })
+	for _, s := range schemas {
+		raw, err := schemaSet.rawSchema(s)
+		if err != nil {
+			return err
+		}
+		constName := "schema" + strings.ToUpper(s.TypeName[:1]) + s.TypeName[1:]
+		literal := "`" + raw + "`"
+		if strings.Contains(raw, "`") {
+			literal = strconv.Quote(raw)
+		}
+		comment := "\n\n// " + constName + " is the json schema that " + s.TypeName + " was generated from.\n"
+		// as for type definitions, don't refer to local files
+		if isPublicURL(s.SourceURL) {
+			comment += "//\n// See " + s.SourceURL + "\n"
+		}
+		io.WriteString(w, comment+"const "+constName+" = "+literal)
+		if s.hasMember("Schema") {
+			// a struct member called Schema would clash with the method
+			log.Printf("Not generating %v.Schema() method since it has a Schema member", s.TypeName)
+			continue
+		}
+		io.WriteString(w, `
+
+// Schema returns the json schema that `+s.TypeName+` was generated from, e.g.
+// for registering with a json schema validator.
+func (this `+s.TypeName+`) Schema() string {
+	return `+constName+`
+}`)
+	}
+	return nil
+}
+
+func (subSchema *JsonSubSchema) hasMember(name string) bool {
+	if p := subSchema.Properties; p != nil {
+		for _, member := range p.MemberNames {
+			if member == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// subSchemaURL returns the SourceURL of the subschema of the document at
+// rootURL (ending in '#') that is located at the given json pointer. Pointer
+// tokens are unescaped as per RFC 6901 (including percent-encoding, as used
+// in URL fragments), and array indexes may be given either as separate
+// tokens (e.g. "/allOf/1") or in SourceURL style (e.g. "/allOf[1]"). A
+// pointer of "/" refers to the whole document. If no such subschema has
+// been loaded, the returned URL will not be found in the schema set.
+func (schemaSet *SchemaSet) subSchemaURL(rootURL, pointer string) string {
+	if unescaped, err := url.PathUnescape(pointer); err == nil {
+		pointer = unescaped
+	}
+	if pointer == "" || pointer == "/" {
+		return rootURL
+	}
+	current := rootURL
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		if _, err := strconv.Atoi(token); err == nil {
+			if _, isItem := schemaSet.all[current+"["+token+"]"]; isItem {
+				current += "[" + token + "]"
+				continue
+			}
+		}
+		current += "/" + token
+	}
+	return current
+}
@@ -0,0 +1,85 @@
+package jsonschema2go
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLookupJSONPointer(t *testing.T) {
+	doc := map[string]interface{}{
+		"definitions": map[string]interface{}{
+			"a/b": map[string]interface{}{"type": "string"},
+		},
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": "integer"},
+			map[string]interface{}{"type": "boolean"},
+		},
+	}
+	for pointer, expected := range map[string]interface{}{
+		"":                  doc,
+		"/definitions/a~1b": map[string]interface{}{"type": "string"},
+		"/anyOf/1/type":     "boolean",
+		"/anyOf[0]/type":    "integer",
+	} {
+		value, err := lookupJSONPointer(doc, pointer)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", pointer, err)
+			continue
+		}
+		if !reflect.DeepEqual(value, expected) {
+			t.Errorf("expected %v for %q but got %v", expected, pointer, value)
+		}
+	}
+	for _, pointer := range []string{"definitions", "/nope", "/anyOf/2", "/anyOf/0/type/x"} {
+		if _, err := lookupJSONPointer(doc, pointer); err == nil {
+			t.Errorf("expected error for %q", pointer)
+		}
+	}
+}
+
+func TestEmbedSchemas(t *testing.T) {
+	sourceCode := generate(t, &Job{EmbedSchemas: true, DisableNestedStructs: true}, `{
+		"title": "Outer",
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"inner": {
+				"title": "Inner",
+				"type": "object",
+				"additionalProperties": false,
+				"properties": {"schema": {"type": "number", "maximum": 10}}
+			}
+		}
+	}`)
+	assertContains(t, sourceCode,
+		"const schemaOuter = `{",
+		"const schemaInner = `{\n  \"additionalProperties\": false,",
+		"func (this Outer) Schema() string {",
+		"\"maximum\": 10",
+	)
+	if strings.Contains(sourceCode, "func (this Inner) Schema() string") {
+		t.Errorf("did not expect Schema() method for type with Schema member:\n%s", sourceCode)
+	}
+	output := run(t, sourceCode, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var schema struct {
+		Title      string
+		Properties map[string]json.RawMessage
+	}
+	if err := json.Unmarshal([]byte(Outer{}.Schema()), &schema); err != nil {
+		panic(err)
+	}
+	fmt.Println(schema.Title, len(schema.Properties))
+}
+`)
+	if output != "Outer 1\n" {
+		t.Errorf("expected Outer.Schema() to return the Outer schema, but got %q", output)
+	}
+}
@@ -0,0 +1,122 @@
+package jsonschema2go
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// schemaFileExtensions are the file extensions of schema files that are
+// loaded when a directory is given in Job.URLs.
+var schemaFileExtensions = map[string]bool{
+	".json": true,
+	".yml":  true,
+	".yaml": true,
+}
+
+// expandURLs replaces file URLs in urls that refer to directories or contain
+// glob patterns with the file URLs of the schemas they match, in lexical
+// order. Directories are searched recursively for files with a json or yaml
+// file extension. In glob patterns, "**" matches any number of nested
+// directories, e.g. file:///schemas/**/*.yml. Other URLs are returned
+// unchanged.
+func expandURLs(urls []string) ([]string, error) {
+	expanded := make([]string, 0, len(urls))
+	for _, URL := range urls {
+		if !strings.HasPrefix(URL, "file://") || strings.ContainsRune(URL, '#') {
+			expanded = append(expanded, URL)
+			continue
+		}
+		path := URL[7:]
+		var files []string
+		var err error
+		if strings.ContainsAny(path, "*?[") {
+			files, err = glob(path)
+		} else if info, statErr := os.Stat(path); statErr == nil && info.IsDir() {
+			files, err = schemaFilesIn(path)
+		} else {
+			expanded = append(expanded, URL)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			expanded = append(expanded, "file://"+filepath.ToSlash(file))
+		}
+	}
+	return expanded, nil
+}
+
+// schemaFilesIn returns the schema files found (recursively) in dir.
+func schemaFilesIn(dir string) ([]string, error) {
+	files := []string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && schemaFileExtensions[strings.ToLower(filepath.Ext(path))] {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// glob returns the files matching pattern, which may contain "**" path
+// segments to match any number of nested directories.
+func glob(pattern string) ([]string, error) {
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	// walk from the deepest directory that contains no pattern characters
+	base := 0
+	for base < len(segments) && !strings.ContainsAny(segments[base], "*?[") {
+		base++
+	}
+	root := strings.Join(segments[:base], "/")
+	if root == "" {
+		root = "/"
+	}
+	files := []string{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if matchSegments(segments[base:], strings.Split(filepath.ToSlash(rel), "/")) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+func matchSegments(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		// match zero or more path segments
+		for i := 0; i <= len(path); i++ {
+			if matchSegments(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	if matched, err := filepath.Match(pattern[0], path[0]); err != nil || !matched {
+		return false
+	}
+	return matchSegments(pattern[1:], path[1:])
+}
@@ -0,0 +1,49 @@
+package jsonschema2go
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandURLs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonschema2go-expand")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, file := range []string{"a.json", "b.yml", "notes.txt", "sub/c.yml", "sub/deeper/d.yaml", "sub/deeper/e.json"} {
+		path := filepath.Join(dir, file)
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(path, []byte("{}"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	url := func(file string) string {
+		return "file://" + filepath.ToSlash(filepath.Join(dir, file))
+	}
+	for pattern, expected := range map[string][]string{
+		url(""):                              {url("a.json"), url("b.yml"), url("sub/c.yml"), url("sub/deeper/d.yaml"), url("sub/deeper/e.json")},
+		url("sub"):                           {url("sub/c.yml"), url("sub/deeper/d.yaml"), url("sub/deeper/e.json")},
+		url("*.json"):                        {url("a.json")},
+		url("**/*.yml"):                      {url("b.yml"), url("sub/c.yml")},
+		url("sub/**/*.json"):                 {url("sub/deeper/e.json")},
+		url("a.json"):                        {url("a.json")},
+		url("a.json#/definitions/x"):         {url("a.json#/definitions/x")},
+		"https://example.com/schemas/*.json": {"https://example.com/schemas/*.json"},
+	} {
+		got, err := expandURLs([]string{pattern})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected %v to expand to %v but got %v", pattern, expected, got)
+		}
+	}
+}
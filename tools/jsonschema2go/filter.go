@@ -0,0 +1,76 @@
+package jsonschema2go
+
+import (
+	"log"
+	"path"
+	"sort"
+	"strings"
+)
+
+// filtered returns true if the given subschema should not be generated,
+// according to the exclude filters of the job, or, for subschemas loaded
+// directly from Job.URLs, the include filters.
+func (job *Job) filtered(subSchema *JsonSubSchema, root bool) bool {
+	for _, re := range job.ExcludeURLs {
+		if re.MatchString(subSchema.SourceURL) {
+			return true
+		}
+	}
+	if subSchema.TypeName != "" {
+		for _, glob := range job.ExcludeTypes {
+			if matched, _ := path.Match(glob, subSchema.TypeName); matched {
+				return true
+			}
+		}
+	}
+	if !root || len(job.IncludeURLs)+len(job.IncludeTypes) == 0 {
+		return false
+	}
+	for _, re := range job.IncludeURLs {
+		if re.MatchString(subSchema.SourceURL) {
+			return false
+		}
+	}
+	for _, glob := range job.IncludeTypes {
+		if matched, _ := path.Match(glob, subSchema.TypeName); matched {
+			return false
+		}
+	}
+	return true
+}
+
+// applyFilters marks all subschemas that are filtered out by the job's
+// include/exclude filters (see filtered), and all the subschemas nested
+// inside them, as Excluded, and removes them from the set of types to
+// generate. Properties that refer to excluded subschemas are generated as
+// json.RawMessage.
+func (job *Job) applyFilters(roots []*JsonSubSchema) {
+	if len(job.IncludeURLs)+len(job.IncludeTypes)+len(job.ExcludeURLs)+len(job.ExcludeTypes) == 0 {
+		return
+	}
+	schemaSet := job.result.SchemaSet
+	isRoot := make(map[*JsonSubSchema]bool, len(roots))
+	for _, r := range roots {
+		isRoot[r] = true
+	}
+	excludedURLs := []string{}
+	for URL, subSchema := range schemaSet.all {
+		if job.filtered(subSchema, isRoot[subSchema]) {
+			excludedURLs = append(excludedURLs, URL)
+		}
+	}
+	sort.Strings(excludedURLs)
+	for URL, subSchema := range schemaSet.all {
+		for _, excluded := range excludedURLs {
+			if URL == excluded || strings.HasPrefix(URL, excluded) && strings.ContainsAny(URL[len(excluded):len(excluded)+1], "/[") {
+				subSchema.Excluded = true
+				break
+			}
+		}
+		if used, isUsed := schemaSet.used[URL]; isUsed && subSchema.Excluded {
+			log.Printf("Excluding %v (type %v)", URL, used.TypeName)
+			delete(schemaSet.used, URL)
+			delete(schemaSet.TypeNames, used.TypeName)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package jsonschema2go
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const filterSchema = `{
+	"title": "Task",
+	"type": "object",
+	"additionalProperties": false,
+	"properties": {
+		"metadata": {
+			"title": "Task Metadata",
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {"name": {"type": "string"}}
+		},
+		"payload": {
+			"title": "Payload",
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {
+				"env": {
+					"title": "Env",
+					"type": "object",
+					"additionalProperties": false,
+					"properties": {"path": {"type": "string"}}
+				}
+			}
+		}
+	}
+}`
+
+func TestExcludeURLs(t *testing.T) {
+	sourceCode := generate(t, &Job{
+		DisableNestedStructs: true,
+		ExcludeURLs:          []*regexp.Regexp{regexp.MustCompile(`/properties/payload$`)},
+	}, filterSchema)
+	assertContains(t, sourceCode,
+		"TaskMetadata struct {",
+		"Payload json.RawMessage `json:\"payload,omitempty\"`",
+	)
+	// nested schemas are excluded too
+	for _, excluded := range []string{"Payload struct", "Env struct"} {
+		if strings.Contains(sourceCode, excluded) {
+			t.Errorf("expected %q to be excluded:\n%s", excluded, sourceCode)
+		}
+	}
+}
+
+func TestExcludeTypes(t *testing.T) {
+	sourceCode := generate(t, &Job{
+		DisableNestedStructs: true,
+		ExcludeTypes:         []string{"Pay*"},
+	}, filterSchema)
+	assertContains(t, sourceCode,
+		"Task struct {",
+		"Payload json.RawMessage `json:\"payload,omitempty\"`",
+	)
+}
+
+func TestIncludeTypes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonschema2go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, title := range map[string]string{"a.json": "Wanted", "b.json": "Unwanted"} {
+		schema := `{"title": "` + title + `", "type": "object", "additionalProperties": false, "properties": {"x": {"type": "string"}}}`
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(schema), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	job := &Job{
+		Package:      "main",
+		ExportTypes:  true,
+		URLs:         []string{"file://" + dir},
+		IncludeTypes: []string{"Want*"},
+	}
+	result, err := job.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourceCode := string(result.SourceCode)
+	assertContains(t, sourceCode, "Wanted struct {")
+	if strings.Contains(sourceCode, "Unwanted") {
+		t.Errorf("expected Unwanted to be excluded:\n%s", sourceCode)
+	}
+}
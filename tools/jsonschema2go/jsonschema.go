@@ -86,6 +86,7 @@
 package jsonschema2go
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -97,11 +98,15 @@ import (
 	"net/url"
 	"os"
 	"reflect"
+	"regexp"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/ghodss/yaml"
+	"github.com/taskcluster/taskcluster/v27/internal/progress"
 	"github.com/taskcluster/taskcluster/v27/tools/jsonschema2go/text"
 )
 
@@ -120,6 +125,8 @@ type (
 		AllOf                *Items                 `json:"allOf,omitempty"`
 		AnyOf                *Items                 `json:"anyOf,omitempty"`
 		Const                *interface{}           `json:"const,omitempty"`
+		ContentEncoding      *string                `json:"contentEncoding,omitempty"`
+		ContentMediaType     *string                `json:"contentMediaType,omitempty"`
 		Default              *interface{}           `json:"default,omitempty"`
 		Definitions          *Properties            `json:"definitions,omitempty"`
 		Dependencies         map[string]*Dependency `json:"dependencies,omitempty"`
@@ -147,7 +154,7 @@ type (
 		Required             []string               `json:"required,omitempty"`
 		Schema               *string                `json:"$schema,omitempty"`
 		Title                *string                `json:"title,omitempty"`
-		Type                 *string                `json:"type,omitempty"`
+		Type                 *TypeList              `json:"type,omitempty"`
 		UniqueItems          *bool                  `json:"uniqueItems,omitempty"`
 
 		// non-json fields used for sorting/tracking
@@ -171,6 +178,26 @@ type (
 		RefSchemaURL string         `json:"REF_SCHEMA_URL,omitempty"`
 		RefSubSchema *JsonSubSchema `json:"REF_SUBSCHEMA,omitempty"`
 		IsRequired   bool           `json:"IS_REQUIRED"`
+
+		// If this schema allows several primitive json types (e.g.
+		// "type": ["string", "integer"]), UnionTypeName is the name of the
+		// generated go type that can hold any of them.
+		UnionTypeName string `json:"UNION_TYPE_NAME,omitempty"`
+
+		// Excluded is true if the include/exclude filters of the Job
+		// prevent a go type being generated for this schema.
+		Excluded bool `json:"EXCLUDED,omitempty"`
+
+		// the go type for date-time strings, as configured in the Job
+		dateTime *DateTimeType
+
+		// the go type for this schema, if it is an object schema with no
+		// properties, as configured in the Job
+		emptyObject EmptyObjectType
+
+		// true if this schema is generated as a top level type, since it is
+		// shared by several parents (see Job.PromoteSharedStructs)
+		promoted bool
 	}
 
 	Items struct {
@@ -183,6 +210,14 @@ type (
 		MemberNames         map[string]string
 		SortedPropertyNames []string
 		SourceURL           string
+
+		// the json struct tag options of optional properties, as
+		// configured in the Job
+		omit OmitOptions
+
+		// true if struct members of scalar properties are pointers, as
+		// configured in the Job (see Job.PointerOptionals)
+		pointerOptionals bool
 	}
 
 	AdditionalProperties struct {
@@ -195,6 +230,11 @@ type (
 		PropertyDependency *[]string
 	}
 
+	// TypeList holds the json schema type(s) of a subschema. In json, the
+	// "type" property may be either a single type name, or an array of type
+	// names, such as ["string", "null"] for a nullable string.
+	TypeList []string
+
 	canPopulate interface {
 		postPopulate(*Job) error
 		setSourceURL(string)
@@ -214,11 +254,158 @@ type (
 		SkipCodeGen          bool
 		TypeNameBlacklist    StringSet
 		DisableNestedStructs bool
+		// EnforceRequiredFields causes MarshalJSON methods to be generated
+		// for structs with required properties, which return an error if a
+		// required property has not been set, rather than marshaling json
+		// that does not conform to the schema (see requiredFieldCheck).
+		EnforceRequiredFields bool
+		// PointerOptionals causes struct members of boolean, number,
+		// integer and string properties to be generated as pointers, so
+		// that a property which has not been set (nil) can be told apart
+		// from one set to false, 0 or "". Optional properties set to their
+		// zero value are then marshaled rather than omitted, and the
+		// MarshalJSON methods generated for EnforceRequiredFields report
+		// required properties of these types that have not been set.
+		PointerOptionals bool
+		// CanonicalJSON causes a CanonicalJSON method to be generated for
+		// every type, which produces byte-stable json, suitable for hashing
+		// or signing.
+		CanonicalJSON bool
+		// JSONv2 causes MarshalJSONTo and UnmarshalJSONFrom methods to be
+		// generated for struct types, so that encoding/json/v2 streams them
+		// one property at a time. The generated code then requires go to
+		// be built with GOEXPERIMENT=jsonv2, including for TypeCheck.
+		JSONv2 bool
+		// EmbedSchemas causes the json schema of every generated type to be
+		// embedded in the generated code as a string constant, together
+		// with a Schema() method that returns it.
+		EmbedSchemas bool
+		// TypeCheck causes the generated code to be type checked before it
+		// is returned, so that problems are found at generation time. Errors
+		// are returned as a *TypeCheckError.
+		TypeCheck bool
+		// Cache, if set, is used to look up source code generated by a
+		// previous run with identical inputs, and to store newly generated
+		// source code.
+		Cache *Cache
+		// Stdin is read from to load the schema with URL StdinURL (or "-").
+		// If nil, os.Stdin is used.
+		Stdin io.Reader
+		// PackageDir, if set, is the directory of the package that the
+		// generated code will be written to. Generated type names avoid the
+		// names declared by the go files already in the directory (other
+		// than test files and previously generated files).
+		PackageDir string
+		// ArrayItemName, if set, returns the name that the type name of
+		// the (untitled) items of an array property is generated from, given
+		// the name of the property. By default " entry" is appended to the
+		// property name (e.g. "routes entry"), whereas text.Singular gives
+		// more natural names (e.g. "route"). If the returned name is empty,
+		// or the same as the property name, the default is used. Note, if
+		// not set, object items promoted by DisableNestedStructs are named
+		// from their title or description only.
+		ArrayItemName func(propertyName string) string
+		// PropertyOverrides customises the struct members generated for
+		// the properties of schemas that can't be changed at source. It is
+		// keyed by the URL of the property's schema, including the json
+		// pointer to it, e.g.
+		// "https://example.com/task.json#/properties/payload".
+		PropertyOverrides map[string]PropertyOverride
+		// ExcludeURLs and ExcludeTypes prevent types being generated for
+		// the schemas whose SourceURL matches one of the regular
+		// expressions, or whose type name matches one of the glob patterns
+		// (see path.Match), together with all of the schemas nested inside
+		// them. Properties referring to excluded schemas are generated as
+		// json.RawMessage. Note, nested structs only have type names if
+		// DisableNestedStructs is set.
+		ExcludeURLs  []*regexp.Regexp
+		ExcludeTypes []string
+		// IncludeURLs and IncludeTypes, if set, restrict the schemas given
+		// in URLs (e.g. after expanding a directory) to those matching at
+		// least one of the regular expressions or glob patterns. Other
+		// schemas are excluded as per ExcludeURLs.
+		IncludeURLs  []*regexp.Regexp
+		IncludeTypes []string
+		// PromoteSharedStructs causes structs that would be nested inside
+		// more than one parent type to be generated as named top level types
+		// instead, which the parents then refer to. This is only relevant
+		// if DisableNestedStructs is not set, since otherwise all structs
+		// are top level types.
+		PromoteSharedStructs bool
+		// DeduplicateTypes causes untitled object schemas that would
+		// generate the same go struct as another schema (ignoring comments)
+		// to use that struct, rather than generating an identical one with
+		// a numbered name. The merge decisions are listed in
+		// Result.TypeMerges.
+		DeduplicateTypes bool
+		// DateTime is the go type used for json strings with format
+		// "date-time". If nil, TaskclusterDateTime is used; set it to
+		// &StdlibDateTime for generated code that does not depend on the
+		// taskcluster go client.
+		DateTime *DateTimeType
+		// EmptyObjects determines the go type generated for object schemas
+		// that define no properties, such as {"type": "object"}.
+		EmptyObjects EmptyObjectType
+		// Omit determines the json struct tag options of struct members
+		// generated for optional properties.
+		Omit OmitOptions
+		// Benchmarks causes the source code of a _test.go file to be
+		// generated too (see Result.BenchmarkSourceCode), which benchmarks
+		// unmarshaling and marshaling each generated type.
+		Benchmarks bool
+		// AllocationBudgets maps generated type names to the maximum number
+		// of allocations allowed when unmarshaling and then marshaling a
+		// sample value of the type. If Benchmarks is set, a test is
+		// generated for each, which fails if the budget is exceeded.
+		AllocationBudgets map[string]int
+		// Progress, if set, is told about the loading of the schemas given
+		// in URLs (after expansion), not counting the schemas they
+		// reference.
+		Progress progress.Reporter
+	}
+
+	// DateTimeType is a go type that json strings with format "date-time"
+	// are (un)marshaled as.
+	DateTimeType struct {
+		// Type is the go type, e.g. "time.Time".
+		Type string
+		// Import is the import spec of the package declaring Type, e.g.
+		// `"time"`.
+		Import string
+	}
+
+	// EmptyObjectType is a choice of go type for object schemas that
+	// define no properties, and allow arbitrary additional properties.
+	EmptyObjectType int
+
+	// OmitOptions is a choice of json struct tag options for struct
+	// members of optional properties, which determine when they are left
+	// out of marshaled json.
+	OmitOptions int
+
+	// PropertyOverride customises the struct member generated for a json
+	// schema property.
+	PropertyOverride struct {
+		// Skip causes no struct member to be generated for the property, as
+		// if it were not defined in the schema.
+		Skip bool `json:"skip,omitempty"`
+		// MemberName, if set, is used as the struct member name, rather
+		// than a name generated by Job.MemberNameGenerator.
+		MemberName string `json:"memberName,omitempty"`
 	}
 
 	Result struct {
 		SourceCode []byte
 		SchemaSet  *SchemaSet
+		// FromCache is true if SourceCode was read from Job.Cache rather
+		// than generated, i.e. none of the input schemas have changed.
+		FromCache bool
+		// TypeMerges lists the types that were merged into structurally
+		// identical types when Job.DeduplicateTypes is set.
+		TypeMerges []TypeMerge
+		// BenchmarkSourceCode is the source code of a _test.go file for the
+		// same package as SourceCode, generated if Job.Benchmarks is set.
+		BenchmarkSourceCode []byte
 	}
 
 	// SchemaSet contains the JsonSubSchemas objects read when performing a Job.
@@ -227,11 +414,79 @@ type (
 		used      map[string]*JsonSubSchema
 		populated []canPopulate
 		TypeNames StringSet
+		// sha256 hash of the raw content of each loaded schema document,
+		// keyed by document URL
+		documentHashes map[string]string
+		// generated union types, keyed by their comma separated json types
+		unionTypes map[string]*unionType
+		// json content of each loaded schema document, keyed by document
+		// URL; only retained if Job.EmbedSchemas is set
+		documents map[string][]byte
+		// number of yaml documents in each loaded multi-document file,
+		// keyed by document URL (see documentToJSON)
+		multiDocuments map[string]int
 	}
 
 	StringSet map[string]bool
 )
 
+var (
+	// StdlibDateTime maps date-time strings to time.Time, which is
+	// (un)marshaled in RFC3339 format.
+	StdlibDateTime = DateTimeType{
+		Type:   "time.Time",
+		Import: `"time"`,
+	}
+	// TaskclusterDateTime maps date-time strings to tcclient.Time, which
+	// always marshals times in UTC with millisecond precision (e.g.
+	// "2015-10-27T20:36:19.255Z"), as Taskcluster services expect. Note,
+	// generated code then depends on the taskcluster go client.
+	TaskclusterDateTime = DateTimeType{
+		Type:   "tcclient.Time",
+		Import: `tcclient "` + tcclientImportPath + `"`,
+	}
+)
+
+const (
+	// EmptyObjectRawMessage generates json.RawMessage, which preserves the
+	// json exactly as it was received.
+	EmptyObjectRawMessage EmptyObjectType = iota
+	// EmptyObjectMap generates map[string]interface{}, so that properties
+	// can be read without further unmarshaling.
+	EmptyObjectMap
+	// EmptyObjectStruct generates a named empty struct, which discards any
+	// properties when unmarshaling.
+	EmptyObjectStruct
+)
+
+const (
+	// OmitEmpty generates `json:",omitempty"` tags, which omit false, 0,
+	// nil pointers, nil interfaces and empty strings, slices and maps.
+	OmitEmpty OmitOptions = iota
+	// OmitZero generates `json:",omitzero"` tags, which omit zero values,
+	// including zero structs and zero times (e.g. time.Time{}), so they
+	// don't need to be pointers. Requires go 1.24 or later.
+	OmitZero
+	// OmitEmptyAndZero generates `json:",omitempty,omitzero"` tags, which
+	// omit a value if either option would. Requires go 1.24 or later.
+	OmitEmptyAndZero
+)
+
+// tagOptions returns the json struct tag options for optional properties.
+func (omit OmitOptions) tagOptions() string {
+	switch omit {
+	case OmitZero:
+		return ",omitzero"
+	case OmitEmptyAndZero:
+		return ",omitempty,omitzero"
+	}
+	return ",omitempty"
+}
+
+// StdinURL is the URL used to refer to a schema read from standard input.
+// A URL of "-" in Job.URLs is equivalent.
+const StdinURL = "stdin://"
+
 // Ensure url contains "#" by adding it to end if needed
 func sanitizeURL(url string) string {
 	if strings.ContainsRune(url, '#') {
@@ -240,6 +495,16 @@ func sanitizeURL(url string) string {
 	return url + "#"
 }
 
+// isPublicURL returns true if URL may usefully be referred to in generated
+// code, i.e. it is not a local file or standard input.
+func isPublicURL(URL string) bool {
+	if URL == "" {
+		return false
+	}
+	u, err := url.Parse(URL)
+	return err == nil && u.Scheme != "file" && !strings.HasPrefix(URL, StdinURL)
+}
+
 func (schemaSet *SchemaSet) SubSchema(url string) *JsonSubSchema {
 	return schemaSet.all[sanitizeURL(url)]
 }
@@ -255,6 +520,68 @@ func (schemaSet *SchemaSet) SortedSanitizedURLs() []string {
 	return keys
 }
 
+// SchemaForType returns the schema that the generated go type with the given
+// name represents, or nil if no such type was generated.
+func (schemaSet *SchemaSet) SchemaForType(typeName string) *JsonSubSchema {
+	for _, subSchema := range schemaSet.used {
+		if subSchema.TypeName == typeName {
+			return subSchema
+		}
+	}
+	return nil
+}
+
+// RequiredFields returns the sorted json property names that are required
+// by the schema of the generated go type with the given name. It returns nil
+// if no such type was generated.
+func (schemaSet *SchemaSet) RequiredFields(typeName string) []string {
+	subSchema := schemaSet.SchemaForType(typeName)
+	if subSchema == nil {
+		return nil
+	}
+	required := make([]string, len(subSchema.Required))
+	copy(required, subSchema.Required)
+	sort.Strings(required)
+	return required
+}
+
+// TypesByService returns the names of the generated go types, grouped by the
+// name of the Taskcluster service that defines the schema they were generated
+// from. The service name is taken from the schema URL path segment following
+// "schemas", e.g. "queue" for
+// https://community-tc.services.mozilla.com/schemas/queue/v1/task.json, or
+// the first path segment for URLs under https://schemas.taskcluster.net/.
+// Types from schemas whose URL does not identify a service are listed under
+// the empty string. Type names are sorted within each service.
+func (schemaSet *SchemaSet) TypesByService() map[string][]string {
+	typesByService := map[string][]string{}
+	for _, subSchema := range schemaSet.used {
+		service := serviceName(subSchema.SourceURL)
+		typesByService[service] = append(typesByService[service], subSchema.TypeName)
+	}
+	for _, typeNames := range typesByService {
+		sort.Strings(typeNames)
+	}
+	return typesByService
+}
+
+func serviceName(sourceURL string) string {
+	u, err := url.Parse(sourceURL)
+	if err != nil {
+		return ""
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i < len(segments)-1; i++ {
+		if segments[i] == "schemas" {
+			return segments[i+1]
+		}
+	}
+	if u.Host == "schemas.taskcluster.net" && len(segments) > 1 {
+		return segments[0]
+	}
+	return ""
+}
+
 // May panic - this is recovered by fmt package, but care should be taken to
 // capture panics when calling String() directly
 func (subSchema JsonSubSchema) String() string {
@@ -276,6 +603,10 @@ func (jsonSubSchema *JsonSubSchema) typeDefinition(disableNested bool, topLevel
 	if p := jsonSubSchema.RefSubSchema; p != nil {
 		return p.typeDefinition(disableNested, topLevel, extraPackages, rawMessageTypes)
 	}
+	if jsonSubSchema.Excluded {
+		extraPackages["\"encoding/json\""] = true
+		return "\n// Not generated, since excluded by filters\n", "json.RawMessage"
+	}
 	comment = "\n"
 	if d := jsonSubSchema.Description; d != nil {
 		comment += text.Indent(*d, "// ")
@@ -329,6 +660,12 @@ func (jsonSubSchema *JsonSubSchema) typeDefinition(disableNested bool, topLevel
 	if regex := jsonSubSchema.Pattern; regex != nil {
 		metadata += "// Syntax:     " + *regex + "\n"
 	}
+	if encoding := jsonSubSchema.ContentEncoding; encoding != nil {
+		metadata += "// Encoding:   " + *encoding + "\n"
+	}
+	if mediaType := jsonSubSchema.ContentMediaType; mediaType != nil {
+		metadata += "// Media type: " + *mediaType + "\n"
+	}
 	if minItems := jsonSubSchema.MinLength; minItems != nil {
 		metadata += "// Min length: " + strconv.Itoa(*minItems) + "\n"
 	}
@@ -365,8 +702,18 @@ func (jsonSubSchema *JsonSubSchema) typeDefinition(disableNested bool, topLevel
 		comment += "//\n" + metadata
 	}
 	typ = "json.RawMessage"
+	nullable := false
 	if p := jsonSubSchema.Type; p != nil {
-		typ = *p
+		nullable = p.Nullable()
+		// multiple non-null types can only be represented by a single go
+		// type if they are all primitive types, in which case a union type
+		// will have been generated, otherwise they are left as
+		// json.RawMessage
+		if nonNull := p.NonNull(); len(nonNull) == 1 {
+			typ = nonNull[0]
+		} else if u := jsonSubSchema.UnionTypeName; u != "" && !topLevel {
+			typ = u
+		}
 	}
 	switch typ {
 	case "array":
@@ -390,7 +737,7 @@ func (jsonSubSchema *JsonSubSchema) typeDefinition(disableNested bool, topLevel
 		if noExtraProperties {
 			// If we are sure no additional properties are allowed, we can
 			// generate a struct with all allowed property names.
-			if !topLevel && disableNested {
+			if !topLevel && (disableNested || jsonSubSchema.promoted) {
 				typ = jsonSubSchema.getTypeName()
 			} else {
 				typ = jsonSubSchema.Properties.AsStruct(disableNested, extraPackages, rawMessageTypes)
@@ -406,6 +753,13 @@ func (jsonSubSchema *JsonSubSchema) typeDefinition(disableNested bool, topLevel
 				// subComment already contains leading newline char (\n)
 				comment += "//\n// Map entries:" + subComment
 			}
+		} else if jsonSubSchema.emptyObject == EmptyObjectMap {
+			typ = "map[string]interface{}"
+		} else if jsonSubSchema.emptyObject == EmptyObjectStruct {
+			typ = "struct{}"
+			if !topLevel {
+				typ = jsonSubSchema.getTypeName()
+			}
 		} else {
 			// Either *arbitrarily structured* additional properties are
 			// allowed, or the additional properties are of a fixed form, but
@@ -433,22 +787,35 @@ func (jsonSubSchema *JsonSubSchema) typeDefinition(disableNested bool, topLevel
 	case "boolean":
 		typ = "bool"
 	// json type string maps to go type string, so only need to test case of when
-	// string is a json date-time, so we can convert to go type Time...
+	// string is a json date-time, so we can convert to go type Time, or
+	// base64 encoded binary data, so we can convert to go type []byte...
 	case "string":
 		if f := jsonSubSchema.Format; f != nil {
-			if *f == "date-time" {
-				typ = "tcclient.Time"
-				extraPackages["tcclient \"github.com/taskcluster/taskcluster/v27/clients/client-go\""] = true
+			if *f == "date-time" && jsonSubSchema.dateTime != nil {
+				typ = jsonSubSchema.dateTime.Type
+				extraPackages[jsonSubSchema.dateTime.Import] = true
 			}
 		}
+		if e := jsonSubSchema.ContentEncoding; e != nil && *e == "base64" {
+			// encoding/json (un)marshals []byte values as base64 encoded
+			// strings, so no custom (un)marshalers are required
+			typ = "[]byte"
+		}
 	}
 
-	if URL := jsonSubSchema.SourceURL; URL != "" {
-		u, err := url.Parse(URL)
-		if err == nil && u.Scheme != "file" {
-			comment += "//\n// See " + URL + "\n"
+	if nullable {
+		comment += "//\n// May be null\n"
+		// Slices, maps and json.RawMessage can already represent null, but
+		// other types need to be pointers. Top level types are left as they
+		// are, since their users can refer to them via a pointer if needed.
+		if !topLevel && typ != "json.RawMessage" && !strings.HasPrefix(typ, "[]") && !strings.HasPrefix(typ, "map[") {
+			typ = "*" + typ
 		}
 	}
+
+	if URL := jsonSubSchema.SourceURL; isPublicURL(URL) {
+		comment += "//\n// See " + URL + "\n"
+	}
 	for strings.Index(comment, "\n//\n") == 0 {
 		comment = "\n" + comment[4:]
 	}
@@ -479,12 +846,15 @@ func (p Properties) String() string {
 
 func (p *Properties) prepare(job *Job) error {
 	log.Printf("In PREPARE (properties): %v", p.SourceURL)
+	p.omit = job.Omit
+	p.pointerOptionals = job.PointerOptionals
 	for _, j := range p.SortedPropertyNames {
 		if p.Properties[j].TargetSchema().Properties != nil {
 			if job.DisableNestedStructs {
 				job.add(p.Properties[j].TargetSchema())
 			}
 		}
+		job.addEmptyStruct(p.Properties[j].TargetSchema())
 	}
 	return nil
 }
@@ -504,8 +874,26 @@ func (p *Properties) postPopulate(job *Job) error {
 		sort.Strings(p.SortedPropertyNames)
 		members := make(StringSet, len(p.SortedPropertyNames))
 		p.MemberNames = make(map[string]string, len(p.SortedPropertyNames))
+		// apply overrides first, so that generated member names cannot
+		// clash with overridden ones
+		kept := make([]string, 0, len(p.SortedPropertyNames))
 		for _, j := range p.SortedPropertyNames {
-			p.MemberNames[j] = job.MemberNameGenerator(j, !job.HideStructMembers, members)
+			override := job.PropertyOverrides[p.Properties[j].SourceURL]
+			if override.Skip {
+				log.Printf("Skipping property %v", p.Properties[j].SourceURL)
+				continue
+			}
+			if override.MemberName != "" {
+				p.MemberNames[j] = override.MemberName
+				members[override.MemberName] = true
+			}
+			kept = append(kept, j)
+		}
+		p.SortedPropertyNames = kept
+		for _, j := range p.SortedPropertyNames {
+			if p.MemberNames[j] == "" {
+				p.MemberNames[j] = job.MemberNameGenerator(j, !job.HideStructMembers, members)
+			}
 			// subschemas also need to be triggered to postPopulate...
 			err := p.Properties[j].postPopulate(job)
 			if err != nil {
@@ -533,11 +921,20 @@ func (job *Job) SetTypeName(subSchema *JsonSubSchema, blacklist map[string]bool)
 	subSchema.TypeName = job.TypeNameGenerator(subSchema.TypeNameRaw(), job.ExportTypes, blacklist)
 	if subSchema.Items != nil {
 		log.Printf("Type %v is an array - will set type for items too...", subSchema.SourceURL)
-		subSchema.Items.TargetSchema().PropertyName = subSchema.PropertyName + " entry"
+		subSchema.Items.TargetSchema().PropertyName = job.arrayItemName(subSchema.PropertyName)
 		job.SetTypeName(subSchema.Items, blacklist)
 	}
 }
 
+func (job *Job) arrayItemName(propertyName string) string {
+	if job.ArrayItemName != nil && propertyName != "" {
+		if name := job.ArrayItemName(propertyName); name != "" && name != propertyName {
+			return name
+		}
+	}
+	return propertyName + " entry"
+}
+
 func (p *Properties) setSourceURL(url string) {
 	p.SourceURL = url
 }
@@ -576,6 +973,47 @@ func (aP *AdditionalProperties) UnmarshalJSON(bytes []byte) (err error) {
 	return
 }
 
+func (tl *TypeList) UnmarshalJSON(bytes []byte) (err error) {
+	s := new(string)
+	if err = json.Unmarshal(bytes, s); err == nil {
+		*tl = TypeList{*s}
+		return
+	}
+	return json.Unmarshal(bytes, (*[]string)(tl))
+}
+
+func (tl TypeList) MarshalJSON() ([]byte, error) {
+	if len(tl) == 1 {
+		return json.Marshal(tl[0])
+	}
+	return json.Marshal([]string(tl))
+}
+
+// Nullable returns true if "null" is one of the listed types.
+func (tl TypeList) Nullable() bool {
+	for _, t := range tl {
+		if t == "null" {
+			return true
+		}
+	}
+	return false
+}
+
+// NonNull returns the listed types, excluding "null".
+func (tl TypeList) NonNull() []string {
+	nonNull := make([]string, 0, len(tl))
+	for _, t := range tl {
+		if t != "null" {
+			nonNull = append(nonNull, t)
+		}
+	}
+	return nonNull
+}
+
+func (tl TypeList) String() string {
+	return strings.Join(tl, ",")
+}
+
 func (aP AdditionalProperties) String() string {
 	if aP.Boolean != nil {
 		return strconv.FormatBool(*aP.Boolean)
@@ -681,6 +1119,28 @@ func (subSchema *JsonSubSchema) prepare(job *Job) (err error) {
 	subSchema.OneOf.MergeIn(subSchema, map[string]bool{"OneOf": true, "ID": true})
 
 	subSchema.Type = subSchema.inferType()
+	if subSchema.Type != nil {
+		subSchema.UnionTypeName = job.unionTypeName(*subSchema.Type)
+	}
+	if f := subSchema.Format; f != nil && *f == "date-time" {
+		subSchema.dateTime = &TaskclusterDateTime
+		if job.DateTime != nil {
+			subSchema.dateTime = job.DateTime
+		}
+	}
+	if subSchema.isEmptyObject() {
+		subSchema.emptyObject = job.EmptyObjects
+	}
+	if subSchema.Items != nil {
+		items := subSchema.Items.TargetSchema()
+		if job.EmptyObjects == EmptyObjectStruct && items.PropertyName == "" && subSchema.PropertyName != "" && items.isEmptyObject() {
+			items.PropertyName = job.arrayItemName(subSchema.PropertyName)
+		}
+		job.addEmptyStruct(items)
+	}
+	if ap := subSchema.AdditionalProperties; ap != nil && ap.Properties != nil {
+		job.addEmptyStruct(ap.Properties.TargetSchema())
+	}
 
 	// Mark subschema properties that are in required list as being required (IsRequired property)
 	for _, req := range subSchema.Required {
@@ -696,7 +1156,13 @@ func (subSchema *JsonSubSchema) prepare(job *Job) (err error) {
 	if job.DisableNestedStructs {
 		// If this subschema is an array of objects, then add the object type to the top level types
 		if subSchema.Items != nil && subSchema.Items.TargetSchema().Properties != nil {
-			job.add(subSchema.Items.TargetSchema())
+			// name the items after the array property, unless that would
+			// change the names of types generated without ArrayItemName
+			items := subSchema.Items.TargetSchema()
+			if job.ArrayItemName != nil && items.PropertyName == "" && subSchema.PropertyName != "" {
+				items.PropertyName = job.arrayItemName(subSchema.PropertyName)
+			}
+			job.add(items)
 		}
 		// If this subschema is a map of strings to objects, then add the object type to the top level types
 		if subSchema.AdditionalProperties != nil && subSchema.AdditionalProperties.Properties != nil && subSchema.AdditionalProperties.Properties.TargetSchema().Properties != nil {
@@ -852,6 +1318,12 @@ func (job *Job) loadJsonSchema(URL string) (subSchema *JsonSubSchema, err error)
 		if err != nil {
 			return
 		}
+	} else if strings.HasPrefix(URL, StdinURL) {
+		stdin := job.Stdin
+		if stdin == nil {
+			stdin = os.Stdin
+		}
+		body = ioutil.NopCloser(stdin)
 	} else {
 		var u *url.URL
 		u, err = url.Parse(URL)
@@ -876,11 +1348,18 @@ func (job *Job) loadJsonSchema(URL string) (subSchema *JsonSubSchema, err error)
 	if err != nil {
 		return
 	}
-	// json is valid YAML, so we can safely convert, even if it is already json
-	j, err := yaml.YAMLToJSON(data)
+	job.result.SchemaSet.documentHashes[sanitizeURL(URL)] = hashDocument(data)
+	j, documents, err := documentToJSON(data)
 	if err != nil {
 		return
 	}
+	if documents > 1 {
+		log.Printf("Loaded %v yaml documents from %v", documents, URL)
+		job.result.SchemaSet.multiDocuments[sanitizeURL(URL)] = documents
+	}
+	if job.EmbedSchemas {
+		job.result.SchemaSet.documents[sanitizeURL(URL)] = j
+	}
 	subSchema = new(JsonSubSchema)
 	err = json.Unmarshal(j, subSchema)
 	if err != nil {
@@ -922,6 +1401,9 @@ func (job *Job) cacheJsonSchema(url string) (*JsonSubSchema, error) {
 
 	// check that the required subschema is contained in the document we loaded
 	subschema, found := job.result.SchemaSet.all[sanitizedURL]
+	if !found {
+		subschema, found = job.result.SchemaSet.all[job.result.SchemaSet.subSchemaURL(rootSchemaURL, subschemaPath)]
+	}
 	if !found {
 		return nil, fmt.Errorf("Subschema %v not found under URL %v", subschemaPath, rootSchemaURL)
 	}
@@ -931,28 +1413,75 @@ func (job *Job) cacheJsonSchema(url string) (*JsonSubSchema, error) {
 // This is where we generate nested and compoound types in go to represent json payloads
 // which are used as inputs and outputs for the REST API endpoints, and also for Pulse
 // message bodies for the Exchange APIs.
-// Returns the generated code content, and a map of keys of extra packages to import, e.g.
+// Writes the generated code content to w, and returns a map of keys of extra packages to import, e.g.
 // a generated type might use time.Time, so if not imported, this would have to be added.
 // using a map of strings -> bool to simulate a set - true => include
-func generateGoTypes(disableNested bool, schemaSet *SchemaSet) (string, StringSet, StringSet) {
+//
+// Type definitions are rendered concurrently, one top level type at a time
+// per CPU core. Since the output is sorted by type name, the generated code
+// is identical to that of a serial run.
+func generateGoTypes(w io.Writer, disableNested bool, schemaSet *SchemaSet) (StringSet, StringSet) {
+	type renderedType struct {
+		typeName        string
+		definition      string
+		extraPackages   StringSet
+		rawMessageTypes StringSet
+	}
+	schemas := make(chan *JsonSubSchema)
+	rendered := make(chan renderedType)
+	workers := runtime.NumCPU()
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range schemas {
+				log.Printf("Type name: '%v' - %v", i.getTypeName(), i.SourceURL)
+				// each type gets its own sets, so that workers don't need
+				// to synchronise access; they are merged below
+				r := renderedType{
+					typeName:        i.getTypeName(),
+					extraPackages:   make(StringSet),
+					rawMessageTypes: make(StringSet),
+				}
+				newComment, newType := i.typeDefinition(disableNested, true, r.extraPackages, r.rawMessageTypes)
+				r.definition = text.Indent(newComment+i.TypeName+" "+newType, "\t")
+				rendered <- r
+			}
+		}()
+	}
+	go func() {
+		// Loop through all json schemas that were found referenced inside the API json schemas...
+		for _, i := range schemaSet.used {
+			schemas <- i
+		}
+		close(schemas)
+		wg.Wait()
+		close(rendered)
+	}()
+
 	extraPackages := make(StringSet)
 	rawMessageTypes := make(StringSet)
-	content := "type (" // intentionally no \n here since each type starts with one already
-	// Loop through all json schemas that were found referenced inside the API json schemas...
 	typeDefinitions := make(map[string]string)
 	typeNames := make([]string, 0, len(schemaSet.used))
-	for _, i := range schemaSet.used {
-		log.Printf("Type name: '%v' - %v", i.getTypeName(), i.SourceURL)
-		var newComment, newType string
-		newComment, newType = i.typeDefinition(disableNested, true, extraPackages, rawMessageTypes)
-		typeDefinitions[i.TypeName] = text.Indent(newComment+i.TypeName+" "+newType, "\t")
-		typeNames = append(typeNames, i.getTypeName())
+	for r := range rendered {
+		for p := range r.extraPackages {
+			extraPackages[p] = true
+		}
+		for t := range r.rawMessageTypes {
+			rawMessageTypes[t] = true
+		}
+		typeDefinitions[r.typeName] = r.definition
+		typeNames = append(typeNames, r.typeName)
 	}
 	sort.Strings(typeNames)
+	io.WriteString(w, "type (") // intentionally no \n here since each type starts with one already
 	for _, t := range typeNames {
-		content += typeDefinitions[t] + "\n"
+		io.WriteString(w, typeDefinitions[t])
+		io.WriteString(w, "\n")
 	}
-	return content + ")\n\n", extraPackages, rawMessageTypes
+	io.WriteString(w, ")\n\n")
+	return extraPackages, rawMessageTypes
 }
 
 func (job *Job) Execute() (*Result, error) {
@@ -961,30 +1490,69 @@ func (job *Job) Execute() (*Result, error) {
 	// of strings.
 	job.result = new(Result)
 	job.result.SchemaSet = &SchemaSet{
-		all:       make(map[string]*JsonSubSchema),
-		used:      make(map[string]*JsonSubSchema),
-		populated: make([]canPopulate, 0, len(job.URLs)),
-		TypeNames: make(StringSet),
+		all:            make(map[string]*JsonSubSchema),
+		used:           make(map[string]*JsonSubSchema),
+		populated:      make([]canPopulate, 0, len(job.URLs)),
+		TypeNames:      make(StringSet),
+		documentHashes: make(map[string]string),
+		unionTypes:     make(map[string]*unionType),
+		documents:      make(map[string][]byte),
+		multiDocuments: make(map[string]int),
 	}
 	if job.TypeNameBlacklist == nil {
 		job.TypeNameBlacklist = make(StringSet)
 	}
+	err := job.reserveTypeNames()
+	if err != nil {
+		return nil, err
+	}
+	// the blacklist is extended as type names are generated, so take a copy
+	// of the initial names for the cache key
+	initialBlacklist := job.TypeNameBlacklist.sortedMembers()
 	if job.TypeNameGenerator == nil {
 		job.TypeNameGenerator = text.GoIdentifierFrom
 	}
 	if job.MemberNameGenerator == nil {
 		job.MemberNameGenerator = text.GoIdentifierFrom
 	}
-	for _, URL := range job.URLs {
+	urls, err := expandURLs(job.URLs)
+	if err != nil {
+		return nil, err
+	}
+	p := job.Progress
+	if p == nil {
+		p = progress.Discard
+	}
+	p.Started(len(urls))
+	// the schemas loaded from urls, as opposed to schemas they reference
+	roots := make([]*JsonSubSchema, 0, len(urls))
+	for _, URL := range urls {
+		if URL == "-" || strings.HasPrefix(URL, "-#") {
+			URL = StdinURL + URL[1:]
+		}
 		j, err := job.cacheJsonSchema(URL)
+		p.Completed(URL, err)
 		if err != nil {
+			p.Done()
 			return nil, err
 		}
+		// a multi-document yaml file has no schema of its own, so add each
+		// of the documents instead
+		if documents, isMultiDoc := job.result.SchemaSet.multiDocuments[sanitizeURL(URL)]; isMultiDoc {
+			for i := 0; i < documents; i++ {
+				root := j.Definitions.Properties[strconv.Itoa(i)].TargetSchema()
+				job.add(root)
+				roots = append(roots, root)
+			}
+			continue
+		}
 		// note we don't add inside cacheJsonSchema/loadJsonSchema
 		// since we don't want to add e.g. top level items if only
 		// definitions inside the schema are referenced
 		job.add(j.TargetSchema())
+		roots = append(roots, j.TargetSchema())
 	}
+	p.Done()
 	for _, subSchema := range job.result.SchemaSet.all {
 		err := subSchema.link(job)
 		if err != nil {
@@ -998,42 +1566,118 @@ func (job *Job) Execute() (*Result, error) {
 		}
 	}
 
-	var err error
-	if job.SkipCodeGen {
-		return job.result, err
+	if job.PromoteSharedStructs {
+		job.promoteSharedStructs()
+	}
+	job.applyFilters(roots)
+	if job.DeduplicateTypes {
+		job.deduplicateTypes()
 	}
-	types, extraPackages, rawMessageTypes := generateGoTypes(job.DisableNestedStructs, job.result.SchemaSet)
-	content := `// This source code file is AUTO-GENERATED by github.com/taskcluster/jsonschema2go
-
-package ` + job.Package + `
 
-`
-	extraPackagesContent := ""
-	for j, k := range extraPackages {
-		if k {
-			extraPackagesContent += text.Indent(""+j+"\n", "\t")
+	if job.SkipCodeGen {
+		return job.result, nil
+	}
+	// benchmarks aren't cached, and only depend on the schemas
+	if job.Benchmarks {
+		job.result.BenchmarkSourceCode, err = job.benchmarks()
+		if err != nil {
+			return job.result, err
+		}
+	}
+	var cacheKey string
+	if job.Cache != nil {
+		var sourceCode []byte
+		var found bool
+		cacheKey, sourceCode, found = job.cachedSourceCode(initialBlacklist)
+		if found {
+			job.result.SourceCode = sourceCode
+			job.result.FromCache = true
+			return job.result, nil
 		}
 	}
+	// Types are generated first, since the imports depend on them, but
+	// they are written after the imports.
+	types := new(bytes.Buffer)
+	extraPackages, rawMessageTypes := generateGoTypes(types, job.DisableNestedStructs, job.result.SchemaSet)
+	if job.EnforceRequiredFields && requiredFieldMarshalers(types, job.DisableNestedStructs, job.result.SchemaSet) {
+		extraPackages["\"encoding/json\""] = true
+		extraPackages["\"errors\""] = true
+	}
+	if job.CanonicalJSON && len(job.result.SchemaSet.used) > 0 {
+		typeNames := make([]string, 0, len(job.result.SchemaSet.used))
+		for _, subSchema := range job.result.SchemaSet.used {
+			typeNames = append(typeNames, subSchema.TypeName)
+		}
+		sort.Strings(typeNames)
+		canonicalJSONMethods(types, typeNames)
+		extraPackages["\"bytes\""] = true
+		extraPackages["\"encoding/json\""] = true
+	}
+	if job.JSONv2 && jsonv2Methods(types, job.DisableNestedStructs, job.EnforceRequiredFields, job.Omit, job.result.SchemaSet) {
+		for _, spec := range jsonv2Imports {
+			extraPackages[spec] = true
+		}
+	}
+	if job.EmbedSchemas {
+		err = schemaEmbeddings(types, job.result.SchemaSet)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(job.result.SchemaSet.unionTypes) > 0 {
+		extraPackages["\"encoding/json\""] = true
+		extraPackages["\"errors\""] = true
+		extraPackages["\"fmt\""] = true
+	}
+	content := new(bytes.Buffer)
+	content.WriteString(generatedCodeHeader + `
 
-	if extraPackagesContent != "" {
-		content += `import (
-` + extraPackagesContent + `)
+package ` + job.Package + `
 
-`
+`)
+	if imports := extraPackages.sortedMembers(); len(imports) > 0 {
+		content.WriteString("import (\n")
+		for _, j := range imports {
+			content.WriteString("\t" + j + "\n")
+		}
+		content.WriteString(")\n\n")
 	}
-	content += types
-	content += jsonRawMessageImplementors(rawMessageTypes)
+	types.WriteTo(content)
+	jsonRawMessageImplementors(content, rawMessageTypes)
+	unionTypeDefinitions(content, job.result.SchemaSet.unionTypes)
 	// format it
-	job.result.SourceCode, err = format.Source([]byte(content))
+	job.result.SourceCode, err = format.Source(content.Bytes())
 	if err != nil {
-		err = fmt.Errorf("Formatting error: %v\n%v", err, content)
+		return job.result, fmt.Errorf("Formatting error: %v\n%s", err, content.Bytes())
+	}
+	if job.TypeCheck {
+		err = typeCheck(job.result.SourceCode, job.result.SchemaSet)
+		if err != nil {
+			return job.result, err
+		}
+	}
+	if job.Cache != nil {
+		err = job.Cache.put(cacheKey, job.result.SourceCode)
 	}
 	return job.result, err
 	// imports should be good, so no need to run
 	// https://godoc.org/golang.org/x/tools/imports#Process
 }
 
-func jsonRawMessageImplementors(rawMessageTypes StringSet) string {
+// ExecuteTo performs the job in the same way as Execute, and then writes the
+// generated source code to w, e.g. an *os.File. The source code is generated
+// in full before any of it is written, and nothing is written if
+// job.SkipCodeGen is set, or if an error occurs.
+func (job *Job) ExecuteTo(w io.Writer) (*Result, error) {
+	result, err := job.Execute()
+	if err != nil {
+		return result, err
+	}
+	_, err = w.Write(result.SourceCode)
+	return result, err
+}
+
+func jsonRawMessageImplementors(w io.Writer, rawMessageTypes StringSet) {
 	// first sort the order of the rawMessageTypes since when we rebuild, we
 	// don't want to generate functions in a different order and introduce
 	// diffs against the previous version
@@ -1044,27 +1688,25 @@ func jsonRawMessageImplementors(rawMessageTypes StringSet) string {
 		i++
 	}
 	sort.Strings(sortedRawMessageTypes)
-	content := ""
 	for _, goType := range sortedRawMessageTypes {
-		content += `
+		io.WriteString(w, `
 
 	// MarshalJSON calls json.RawMessage method of the same name. Required since
-	// ` + goType + ` is of type json.RawMessage...
-	func (this *` + goType + `) MarshalJSON() ([]byte, error) {
+	// `+goType+` is of type json.RawMessage...
+	func (this *`+goType+`) MarshalJSON() ([]byte, error) {
 		x := json.RawMessage(*this)
 		return (&x).MarshalJSON()
 	}
 
 	// UnmarshalJSON is a copy of the json.RawMessage implementation.
-	func (this *` + goType + `) UnmarshalJSON(data []byte) error {
+	func (this *`+goType+`) UnmarshalJSON(data []byte) error {
 		if this == nil {
-			return errors.New("` + goType + `: UnmarshalJSON on nil pointer")
+			return errors.New("`+goType+`: UnmarshalJSON on nil pointer")
 		}
 		*this = append((*this)[0:0], data...)
 		return nil
-	}`
+	}`)
 	}
-	return content
 }
 
 func (s *Properties) AsStruct(disableNested bool, extraPackages StringSet, rawMessageTypes StringSet) (typ string) {
@@ -1074,10 +1716,10 @@ func (s *Properties) AsStruct(disableNested bool, extraPackages StringSet, rawMe
 			// recursive call to build structs inside structs
 			var subComment, subType string
 			subMember := s.MemberNames[j]
-			subComment, subType = s.Properties[j].typeDefinition(disableNested, false, extraPackages, rawMessageTypes)
+			subComment, subType = s.memberType(j, disableNested, extraPackages, rawMessageTypes)
 			jsonStructTagOptions := ""
 			if !s.Properties[j].IsRequired {
-				jsonStructTagOptions = ",omitempty"
+				jsonStructTagOptions = s.omit.tagOptions()
 			}
 			// struct member name and type, as part of struct definition
 			typ += text.Indent(fmt.Sprintf("%v%v %v `json:\"%v%v\"`", subComment, subMember, subType, j, jsonStructTagOptions), "\t") + "\n"
@@ -1087,6 +1729,53 @@ func (s *Properties) AsStruct(disableNested bool, extraPackages StringSet, rawMe
 	return
 }
 
+// memberType returns the comment and go type of the struct member generated
+// for the named property.
+func (s *Properties) memberType(name string, disableNested bool, extraPackages StringSet, rawMessageTypes StringSet) (comment, typ string) {
+	property := s.Properties[name]
+	comment, typ = property.typeDefinition(disableNested, false, extraPackages, rawMessageTypes)
+	if !s.pointerOptionals || strings.HasPrefix(typ, "*") || strings.HasPrefix(typ, "[]") {
+		// already nillable, e.g. nullable or base64 encoded
+		return
+	}
+	if t := property.TargetSchema().Type; t != nil {
+		if nonNull := t.NonNull(); len(nonNull) == 1 {
+			switch nonNull[0] {
+			case "boolean", "number", "integer", "string":
+				typ = "*" + typ
+			}
+		}
+	}
+	return
+}
+
+// isEmptyObject returns true if subSchema is an object schema that defines
+// no properties, and places no restrictions on additional properties.
+func (subSchema *JsonSubSchema) isEmptyObject() bool {
+	if subSchema.Type == nil || subSchema.RefSubSchema != nil {
+		return false
+	}
+	if nonNull := subSchema.Type.NonNull(); len(nonNull) != 1 || nonNull[0] != "object" {
+		return false
+	}
+	if subSchema.AllOf != nil || subSchema.AnyOf != nil || subSchema.OneOf != nil {
+		return false
+	}
+	if p := subSchema.Properties; p != nil && len(p.Properties) > 0 {
+		return false
+	}
+	ap := subSchema.AdditionalProperties
+	return ap == nil || (ap.Boolean != nil && *ap.Boolean)
+}
+
+// addEmptyStruct adds the given schema as a top level type, if it is an empty
+// object schema for which the Job generates a named empty struct.
+func (job *Job) addEmptyStruct(subSchema *JsonSubSchema) {
+	if job.EmptyObjects == EmptyObjectStruct && subSchema.isEmptyObject() {
+		job.add(subSchema)
+	}
+}
+
 func (jsonSubSchema *JsonSubSchema) getTypeName() string {
 	if jsonSubSchema.Ref != nil {
 		return jsonSubSchema.RefSubSchema.getTypeName()
@@ -1097,7 +1786,7 @@ func (jsonSubSchema *JsonSubSchema) getTypeName() string {
 // inferType is a cheeky little function that tries to set the type, if it can
 // infer it from other information, such as if all OneOf subschemas share the
 // same type, for example.
-func (subSchema *JsonSubSchema) inferType() *string {
+func (subSchema *JsonSubSchema) inferType() *TypeList {
 
 	// 1) If already set, nothing to do...
 	if subSchema.Type != nil {
@@ -1113,7 +1802,7 @@ func (subSchema *JsonSubSchema) inferType() *string {
 		inferredType = "array"
 	}
 	if inferredType != "" {
-		return &inferredType
+		return &TypeList{inferredType}
 	}
 
 	// 3) If all items in subSchema.AllOf/subSchema.AnyOf/subSchema.OneOf have
@@ -1124,20 +1813,21 @@ func (subSchema *JsonSubSchema) inferType() *string {
 		subSchema.OneOf,
 	} {
 		if items != nil {
+			var commonType *TypeList
 			for _, subSubSchema := range items.Items {
 				subType := subSubSchema.inferType()
 				if subType == nil {
 					return nil
 				}
-				if inferredType == "" {
-					inferredType = *subType
+				if commonType == nil {
+					commonType = subType
 					continue
 				}
-				if inferredType != *subType {
+				if commonType.String() != subType.String() {
 					return nil
 				}
 			}
-			return &inferredType
+			return commonType
 		}
 	}
 
@@ -1150,22 +1840,22 @@ func (subSchema *JsonSubSchema) inferType() *string {
 	for _, enumItem := range subSchema.Enum {
 		enumType := jsonSchemaTypeFromValue(enumItem)
 		if inferredType == "" {
-			inferredType = *enumType
+			inferredType = enumType.String()
 			continue
 		}
-		if inferredType != *enumType {
+		if inferredType != enumType.String() {
 			return nil
 		}
 	}
 	if inferredType != "" {
-		return &inferredType
+		return &TypeList{inferredType}
 	}
 
 	// 6) Cannot infer type
 	return nil
 }
 
-func jsonSchemaTypeFromValue(v interface{}) *string {
+func jsonSchemaTypeFromValue(v interface{}) *TypeList {
 	var inferredType string
 	switch t := v.(type) {
 	case bool:
@@ -1183,5 +1873,5 @@ func jsonSchemaTypeFromValue(v interface{}) *string {
 	default:
 		log.Fatalf("What the? %v", t)
 	}
-	return &inferredType
+	return &TypeList{inferredType}
 }
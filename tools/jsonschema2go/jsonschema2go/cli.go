@@ -6,10 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
 
 	docopt "github.com/docopt/docopt-go"
+	"github.com/taskcluster/taskcluster/v27/internal/progress"
 	"github.com/taskcluster/taskcluster/v27/tools/jsonschema2go"
 )
 
@@ -50,20 +52,39 @@ reference. It will automatically download json schema definitions referred to
 in the provided schemas, if there are cross references to external json schemas
 hosted on an available url (i.e. $ref property of json schema). You pass urls
 via standard in (one per line), e.g. by generating a list of schema urls and
-then piping to jsonschema2go -o <some-package-name>.
+then piping to jsonschema2go -o <some-package-name>. Alternatively, urls can be
+given as arguments, in which case the url "-" refers to a schema provided via
+standard in.
 
 The go type names will be "normalised" from the json subschema Title element.
 
-  Example:
+  Examples:
     cat urls.txt | jsonschema2go -o main
+    curl -s https://example.com/schemas/task.json | jsonschema2go -o main -
 
   Usage:
-    jsonschema2go -o GO-PACKAGE-NAME
+    jsonschema2go -o GO-PACKAGE-NAME [-c CACHE-DIR] [-d PACKAGE-DIR] [--stdlib-time] [-b BENCHMARK-FILE] [--check GO-FILE] [URL...]
     jsonschema2go --help
 
   Options:
     -h --help               Display this help text.
     -o GO-PACKAGE-NAME      The package name to use in the generated file.
+    -c CACHE-DIR            Directory to cache generated code in. If none of
+                            the schemas (or schemas they reference) have
+                            changed since the last run, the cached code is
+                            output without being regenerated.
+    -d PACKAGE-DIR          Directory of the package the generated code will
+                            be written to. Generated type names will not clash
+                            with names declared in the package's other files.
+    --stdlib-time           Generate date-time strings as time.Time, rather
+                            than tcclient.Time from the taskcluster go client.
+    -b BENCHMARK-FILE       Also write benchmarks of unmarshaling and
+                            marshaling each generated type to BENCHMARK-FILE,
+                            which should be a _test.go file in the package.
+    --check GO-FILE         Rather than outputting the generated code, check
+                            that GO-FILE contains exactly the code that would
+                            be generated. If not, the differences are shown
+                            and the exit code is 1.
 `
 )
 
@@ -71,13 +92,47 @@ func main() {
 	// Parse the docopt string and exit on any error or help message.
 	arguments, err := docopt.ParseArgs(usage, nil, version)
 	exitOnFail(err)
+	urls := arguments["URL"].([]string)
+	if len(urls) == 0 {
+		urls = parseStandardIn()
+	}
 	job := &jsonschema2go.Job{
 		Package:              arguments["-o"].(string),
 		ExportTypes:          true,
-		URLs:                 parseStandardIn(),
+		URLs:                 urls,
 		DisableNestedStructs: true,
+		Progress:             progress.New(os.Stderr, "loading schemas"),
+	}
+	if cacheDir, ok := arguments["-c"].(string); ok {
+		job.Cache = &jsonschema2go.Cache{Dir: cacheDir}
+	}
+	if packageDir, ok := arguments["-d"].(string); ok {
+		job.PackageDir = packageDir
+	}
+	if arguments["--stdlib-time"].(bool) {
+		job.DateTime = &jsonschema2go.StdlibDateTime
+	}
+	benchmarkFile, writeBenchmarks := arguments["-b"].(string)
+	job.Benchmarks = writeBenchmarks
+	if goFile, ok := arguments["--check"].(string); ok {
+		existing, err := ioutil.ReadFile(goFile)
+		exitOnFail(err)
+		_, err = job.Verify(existing)
+		if outOfDate, ok := err.(*jsonschema2go.OutOfDateError); ok {
+			fmt.Fprintf(os.Stderr, "%v is out of date:\n%v", goFile, outOfDate.Diff)
+			os.Exit(1)
+		}
+		exitOnFail(err)
+		return
+	}
+	result, err := job.ExecuteTo(os.Stdout)
+	if err == nil {
+		// the output has always ended with an empty line
+		_, err = fmt.Println()
+	}
+	if err == nil && writeBenchmarks {
+		err = ioutil.WriteFile(benchmarkFile, result.BenchmarkSourceCode, 0644)
 	}
-	result, err := job.Execute()
 	if err != nil {
 		log.Printf("%#v", err)
 		switch j := err.(type) {
@@ -87,9 +142,6 @@ func main() {
 		}
 	}
 	exitOnFail(err)
-	// simply output the generated file name, in the case of success, for
-	// super-easy parsing
-	fmt.Println(string(result.SourceCode))
 }
 
 func exitOnFail(err error) {
@@ -0,0 +1,781 @@
+package jsonschema2go
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/taskcluster/taskcluster/v27/internal/progress"
+	"github.com/taskcluster/taskcluster/v27/tools/jsonschema2go/text"
+)
+
+// generate writes the given schema to a temporary file, and returns the go
+// source code generated for it by the given job. The source code is type
+// checked, unless it needs GOEXPERIMENT=jsonv2 (see Job.JSONv2).
+func generate(t *testing.T, job *Job, schema string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "jsonschema2go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	schemaFile := filepath.Join(dir, "schema.json")
+	err = ioutil.WriteFile(schemaFile, []byte(schema), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Package == "" {
+		job.Package = "main"
+	}
+	job.ExportTypes = true
+	job.URLs = []string{"file://" + schemaFile}
+	job.TypeCheck = !job.JSONv2
+	result, err := job.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(result.SourceCode)
+}
+
+// run builds and runs a program made of the generated sourceCode and
+// mainSource, which declares func main in package main, and returns its
+// output. The program is built with the same go environment as the tests.
+func run(t *testing.T, sourceCode, mainSource string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "jsonschema2go-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"go.mod":       "module generated\n",
+		"generated.go": sourceCode,
+		"main.go":      mainSource,
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOTOOLCHAIN=local")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Could not run generated code: %v\n%s\n%s", err, output, sourceCode)
+	}
+	return string(output)
+}
+
+func assertContains(t *testing.T, sourceCode string, snippets ...string) {
+	t.Helper()
+	for _, snippet := range snippets {
+		if !strings.Contains(sourceCode, snippet) {
+			t.Errorf("expected generated code to contain %q:\n%s", snippet, sourceCode)
+		}
+	}
+}
+
+func TestNullableTypes(t *testing.T) {
+	sourceCode := generate(t, &Job{}, `{
+		"title": "Nullables",
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": ["string", "null"]},
+			"count": {"type": ["integer", "null"]},
+			"tags": {"type": ["array", "null"], "items": {"type": "string"}},
+			"plain": {"type": "string"}
+		},
+		"required": ["name"]
+	}`)
+	assertContains(t, sourceCode,
+		"Name *string `json:\"name\"`",
+		"Count *int64 `json:\"count,omitempty\"`",
+		"Tags []string `json:\"tags,omitempty\"`",
+		"Plain string `json:\"plain,omitempty\"`",
+		"// May be null",
+	)
+	output := run(t, sourceCode, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	for _, input := range []string{
+		`+"`"+`{"name": null, "count": 0, "tags": null}`+"`"+`,
+		`+"`"+`{"name": "", "count": null, "plain": "x"}`+"`"+`,
+	} {
+		var nullables Nullables
+		if err := json.Unmarshal([]byte(input), &nullables); err != nil {
+			panic(err)
+		}
+		data, err := json.Marshal(nullables)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(data))
+	}
+}
+`)
+	expected := `{"count":0,"name":null}
+{"name":"","plain":"x"}
+`
+	if output != expected {
+		t.Errorf("expected output\n%s\nbut got\n%s", expected, output)
+	}
+}
+
+func TestUnionTypes(t *testing.T) {
+	sourceCode := generate(t, &Job{}, `{
+		"title": "Unions",
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"id": {"type": ["string", "integer"]},
+			"other": {"type": ["integer", "string", "null"]},
+			"mixed": {"type": ["string", "object"]}
+		}
+	}`)
+	assertContains(t, sourceCode,
+		"ID IntegerOrString `json:\"id,omitempty\"`",
+		"Other *IntegerOrString `json:\"other,omitempty\"`",
+		"Mixed json.RawMessage `json:\"mixed,omitempty\"`",
+		"Integer *int64",
+		"String  *string",
+		"func (this *IntegerOrString) UnmarshalJSON(data []byte) error {",
+	)
+	output := run(t, sourceCode, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	for _, input := range []string{
+		`+"`"+`{"id": 7, "other": "x", "mixed": {"a": 1}}`+"`"+`,
+		`+"`"+`{"id": "7", "other": null}`+"`"+`,
+		`+"`"+`{"id": true}`+"`"+`,
+	} {
+		var unions Unions
+		if err := json.Unmarshal([]byte(input), &unions); err != nil {
+			fmt.Println(err)
+			continue
+		}
+		data, err := json.Marshal(unions)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(unions.ID.Integer != nil, unions.ID.String != nil, string(data))
+	}
+}
+`)
+	expected := `true false {"id":7,"mixed":{"a":1},"other":"x"}
+false true {"id":"7"}
+IntegerOrString: cannot unmarshal true
+`
+	if output != expected {
+		t.Errorf("expected output\n%s\nbut got\n%s", expected, output)
+	}
+}
+
+func TestContentEncoding(t *testing.T) {
+	sourceCode := generate(t, &Job{}, `{
+		"title": "Blob",
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"data": {"type": "string", "contentEncoding": "base64", "contentMediaType": "image/png"},
+			"text": {"type": "string", "contentMediaType": "text/plain"}
+		}
+	}`)
+	assertContains(t, sourceCode,
+		"Data []byte `json:\"data,omitempty\"`",
+		"Text string `json:\"text,omitempty\"`",
+		"// Encoding:   base64\n",
+		"// Media type: image/png\n",
+		"// Media type: text/plain\n",
+	)
+	output := run(t, sourceCode, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	var blob Blob
+	if err := json.Unmarshal([]byte(`+"`"+`{"data": "iVBORw0KGgo=", "text": "aGk="}`+"`"+`), &blob); err != nil {
+		panic(err)
+	}
+	fmt.Printf("%q %q\n", blob.Data, blob.Text)
+	data, err := json.Marshal(blob)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(data))
+}
+`)
+	expected := `"\x89PNG\r\n\x1a\n" "aGk="
+{"data":"iVBORw0KGgo=","text":"aGk="}
+`
+	if output != expected {
+		t.Errorf("expected output\n%s\nbut got\n%s", expected, output)
+	}
+}
+
+func TestEnforceRequiredFields(t *testing.T) {
+	schema := `{
+		"title": "Task",
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 1},
+			"label": {"type": "string"},
+			"retries": {"type": "integer"},
+			"routes": {"type": "array", "items": {"type": "string"}},
+			"optional": {"type": "array", "items": {"type": "string"}}
+		},
+		"required": ["name", "label", "retries", "routes"]
+	}`
+	sourceCode := generate(t, &Job{EnforceRequiredFields: true}, schema)
+	output := run(t, sourceCode, `package main
+
+import "fmt"
+
+func main() {
+	for _, task := range []Task{
+		{Routes: []string{}},
+		{Name: "build"},
+		{Name: "build", Routes: []string{}},
+	} {
+		data, err := task.MarshalJSON()
+		fmt.Println(string(data), err)
+	}
+}
+`)
+	expected := ` Task: required property 'name' is not set
+ Task: required property 'routes' is not set
+{"label":"","name":"build","retries":0,"routes":[]} <nil>
+`
+	if output != expected {
+		t.Errorf("expected output\n%s\nbut got\n%s", expected, output)
+	}
+
+	if sourceCode := generate(t, &Job{}, schema); strings.Contains(sourceCode, "MarshalJSON") {
+		t.Errorf("did not expect MarshalJSON to be generated without EnforceRequiredFields:\n%s", sourceCode)
+	}
+}
+
+func TestPointerOptionals(t *testing.T) {
+	sourceCode := generate(t, &Job{EnforceRequiredFields: true, PointerOptionals: true}, `{
+		"title": "Task",
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"label": {"type": "string"},
+			"retries": {"type": "integer"},
+			"priority": {"type": "number"},
+			"privileged": {"type": "boolean"},
+			"data": {"type": "string", "contentEncoding": "base64"},
+			"routes": {"type": "array", "items": {"type": "string"}}
+		},
+		"required": ["label", "retries"]
+	}`)
+	assertContains(t, sourceCode,
+		"Label *string `json:\"label\"`",
+		"Retries *int64 `json:\"retries\"`",
+		"Priority *float64 `json:\"priority,omitempty\"`",
+		"Privileged *bool `json:\"privileged,omitempty\"`",
+		"Data []byte `json:\"data,omitempty\"`",
+		"Routes []string `json:\"routes,omitempty\"`",
+	)
+	output := run(t, sourceCode, `package main
+
+import "fmt"
+
+func main() {
+	label, retries, privileged := "", int64(0), false
+	for _, task := range []Task{
+		{Label: &label},
+		{Label: &label, Retries: &retries},
+		{Label: &label, Retries: &retries, Privileged: &privileged},
+	} {
+		data, err := task.MarshalJSON()
+		fmt.Println(string(data), err)
+	}
+}
+`)
+	expected := ` Task: required property 'retries' is not set
+{"label":"","retries":0} <nil>
+{"label":"","privileged":false,"retries":0} <nil>
+`
+	if output != expected {
+		t.Errorf("expected output\n%s\nbut got\n%s", expected, output)
+	}
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	sourceCode := generate(t, &Job{CanonicalJSON: true}, `{
+		"title": "Payload",
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"command": {"type": "string"},
+			"env": {"type": "object"}
+		}
+	}`)
+	output := run(t, sourceCode, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	payload := Payload{
+		Command: "make <all> && echo 'done'",
+		Env:     json.RawMessage(`+"`"+`{"Z": 1.50, "A": {"y": [true, null], "x": "<>"}}`+"`"+`),
+	}
+	data, err := payload.CanonicalJSON()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(string(data))
+}
+`)
+	expected := `{"command":"make <all> && echo 'done'","env":{"A":{"x":"<>","y":[true,null]},"Z":1.50}}`
+	if output != expected {
+		t.Errorf("expected canonical json\n%s\nbut got\n%s", expected, output)
+	}
+}
+
+func TestStdinSchema(t *testing.T) {
+	job := &Job{
+		Package:     "main",
+		ExportTypes: true,
+		URLs:        []string{"-"},
+		Stdin:       strings.NewReader(`{"title": "Piped", "type": "object", "additionalProperties": false, "properties": {"x": {"type": "boolean"}}}`),
+	}
+	result, err := job.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, string(result.SourceCode), "Piped struct {", "X bool `json:\"x,omitempty\"`")
+	if strings.Contains(string(result.SourceCode), StdinURL) {
+		t.Errorf("did not expect generated code to refer to %v:\n%s", StdinURL, result.SourceCode)
+	}
+}
+
+func TestArrayItemName(t *testing.T) {
+	schema := `{
+		"title": "Task",
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"artifacts": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"additionalProperties": false,
+					"properties": {"name": {"type": "string"}}
+				}
+			},
+			"env": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"additionalProperties": false,
+					"properties": {"value": {"type": "string"}}
+				}
+			}
+		}
+	}`
+	sourceCode := generate(t, &Job{DisableNestedStructs: true, ArrayItemName: text.Singular}, schema)
+	assertContains(t, sourceCode,
+		"Artifact struct {",
+		"Artifacts []Artifact `json:\"artifacts,omitempty\"`",
+		// "env" is not a plural, so falls back to the default
+		"EnvEntry struct {",
+	)
+}
+
+func TestSubschemaURLs(t *testing.T) {
+	personSchema, err := filepath.Abs(filepath.Join("testdata", "person.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for fragment, typeName := range map[string]string{
+		"":                             "Person",
+		"#/":                           "Person",
+		"#/definitions/activities":     "Activities",
+		"#%2Fdefinitions%2Factivities": "Activities",
+		"#/properties/address":         "Address",
+	} {
+		job := &Job{
+			Package:     "main",
+			ExportTypes: true,
+			URLs:        []string{"file://" + personSchema + fragment},
+		}
+		result, err := job.Execute()
+		if err != nil {
+			t.Fatalf("%q: %v", fragment, err)
+		}
+		if len(result.SchemaSet.used) != 1 && typeName != "Person" {
+			t.Errorf("%q: expected only one type to be generated, but got %v", fragment, result.SchemaSet.TypeNames)
+		}
+		assertContains(t, string(result.SourceCode), "\t"+typeName+" ")
+	}
+}
+
+func TestProgress(t *testing.T) {
+	personSchema, err := filepath.Abs(filepath.Join("testdata", "person.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	URL := "file://" + personSchema
+	out := &bytes.Buffer{}
+	job := &Job{
+		Package:     "main",
+		ExportTypes: true,
+		URLs:        []string{URL},
+		Progress:    progress.NewLog(out, "loading schemas"),
+	}
+	if _, err := job.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	expected := "loading schemas: started, 1 in total\n" +
+		"loading schemas: [1/1] " + URL + "\n" +
+		"loading schemas: done, 1 of 1 succeeded (0 failed)\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, out.String())
+	}
+
+	out.Reset()
+	job.URLs = []string{URL + ".missing"}
+	job.Progress = progress.NewLog(out, "loading schemas")
+	if _, err := job.Execute(); err == nil {
+		t.Fatal("expected an error for a missing schema")
+	}
+	if !strings.Contains(out.String(), "loading schemas: [1/1] "+URL+".missing failed: ") || !strings.HasSuffix(out.String(), "done, 0 of 1 succeeded (1 failed)\n") {
+		t.Errorf("expected the failure to be reported, got:\n%s", out.String())
+	}
+}
+
+func TestPropertyOverrides(t *testing.T) {
+	personSchema, err := filepath.Abs(filepath.Join("testdata", "person.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	URL := "file://" + personSchema
+	job := &Job{
+		Package:     "main",
+		ExportTypes: true,
+		URLs:        []string{URL},
+		PropertyOverrides: map[string]PropertyOverride{
+			URL + "#/properties/dislikes":                       {Skip: true},
+			URL + "#/properties/address":                        {MemberName: "Hobbies"},
+			URL + "#/definitions/activities/properties/snooker": {MemberName: "Snooker147"},
+		},
+	}
+	result, err := job.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourceCode := string(result.SourceCode)
+	assertContains(t, sourceCode,
+		"Hobbies []string `json:\"address\"`",
+		"Hobbies1 struct {",
+		"Snooker147 bool `json:\"snooker\"`",
+	)
+	if strings.Contains(sourceCode, "dislikes") {
+		t.Errorf("expected dislikes property to be skipped:\n%s", sourceCode)
+	}
+}
+
+func TestDateTimeType(t *testing.T) {
+	schema := `{
+		"title": "Event",
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"created": {"type": "string", "format": "date-time"}
+		}
+	}`
+	sourceCode := generate(t, &Job{}, schema)
+	assertContains(t, sourceCode,
+		"tcclient \"github.com/taskcluster/taskcluster/v27/clients/client-go\"",
+		"Created tcclient.Time `json:\"created,omitempty\"`",
+	)
+	sourceCode = generate(t, &Job{DateTime: &StdlibDateTime}, schema)
+	assertContains(t, sourceCode,
+		"\"time\"",
+		"Created time.Time `json:\"created,omitempty\"`",
+	)
+}
+
+func TestPromoteSharedStructs(t *testing.T) {
+	sourceCode := generate(t, &Job{PromoteSharedStructs: true}, `{
+		"title": "Copy",
+		"type": "object",
+		"additionalProperties": false,
+		"definitions": {
+			"location": {
+				"title": "Location",
+				"type": "object",
+				"additionalProperties": false,
+				"properties": {
+					"url": {"type": "string"},
+					"auth": {
+						"type": "object",
+						"additionalProperties": false,
+						"properties": {"token": {"type": "string"}}
+					}
+				}
+			}
+		},
+		"properties": {
+			"from": {"$ref": "#/definitions/location"},
+			"to": {"$ref": "#/definitions/location"},
+			"via": {"type": "array", "items": {"$ref": "#/definitions/location"}},
+			"options": {
+				"type": "object",
+				"additionalProperties": false,
+				"properties": {"force": {"type": "boolean"}}
+			}
+		}
+	}`)
+	assertContains(t, sourceCode,
+		"From Location `json:\"from,omitempty\"`",
+		"To Location `json:\"to,omitempty\"`",
+		"Via []Location `json:\"via,omitempty\"`",
+		"Location struct {",
+		// only used by Location, so not promoted
+		"Auth struct {",
+		// only used by Copy, so not promoted
+		"Options struct {",
+	)
+	if strings.Contains(sourceCode, "Options Options") {
+		t.Errorf("expected Options to remain nested:\n%s", sourceCode)
+	}
+}
+
+func TestEmptyObjects(t *testing.T) {
+	schema := `{
+		"title": "Task",
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"env": {"type": "object"},
+			"extra": {"type": "object", "additionalProperties": true},
+			"features": {"type": "array", "items": {"type": "object"}}
+		}
+	}`
+	assertContains(t, generate(t, &Job{}, schema),
+		"Env json.RawMessage `json:\"env,omitempty\"`",
+		"Features []json.RawMessage `json:\"features,omitempty\"`",
+	)
+	assertContains(t, generate(t, &Job{EmptyObjects: EmptyObjectMap}, schema),
+		"Env map[string]interface{} `json:\"env,omitempty\"`",
+		"Extra map[string]interface{} `json:\"extra,omitempty\"`",
+		"Features []map[string]interface{} `json:\"features,omitempty\"`",
+	)
+	sourceCode := generate(t, &Job{EmptyObjects: EmptyObjectStruct}, schema)
+	assertContains(t, sourceCode,
+		"Env Env `json:\"env,omitempty\"`",
+		"Extra Extra `json:\"extra,omitempty\"`",
+		"Env struct{}",
+		"Extra struct{}",
+		"Features []FeaturesEntry `json:\"features,omitempty\"`",
+	)
+	if strings.Contains(sourceCode, "RawMessage") {
+		t.Errorf("did not expect json.RawMessage to be generated:\n%s", sourceCode)
+	}
+}
+
+func TestOmitOptions(t *testing.T) {
+	schema := `{
+		"title": "Task",
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string"},
+			"deadline": {"type": "string", "format": "date-time"}
+		},
+		"required": ["name"]
+	}`
+	for omit, tag := range map[OmitOptions]string{
+		OmitEmpty:        "`json:\"deadline,omitempty\"`",
+		OmitZero:         "`json:\"deadline,omitzero\"`",
+		OmitEmptyAndZero: "`json:\"deadline,omitempty,omitzero\"`",
+	} {
+		assertContains(t, generate(t, &Job{Omit: omit}, schema),
+			"Deadline tcclient.Time "+tag,
+			"Name string `json:\"name\"`",
+		)
+	}
+
+	// go 1.24 or later omits the zero time with omitzero, but not omitempty
+	for omit, expected := range map[OmitOptions]string{
+		OmitEmpty:        `{"deadline":"0001-01-01T00:00:00Z","name":""}`,
+		OmitZero:         `{"name":""}`,
+		OmitEmptyAndZero: `{"name":""}`,
+	} {
+		sourceCode := generate(t, &Job{Omit: omit, DateTime: &StdlibDateTime}, schema)
+		output := run(t, sourceCode, `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	data, err := json.Marshal(Task{})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(string(data))
+}
+`)
+		if output != expected {
+			t.Errorf("expected Task{} to marshal as\n%s\nwith Omit %d, but got\n%s", expected, omit, output)
+		}
+	}
+}
+
+func TestJSONv2(t *testing.T) {
+	sourceCode := generate(t, &Job{JSONv2: true, EnforceRequiredFields: true}, `{
+		"title": "Task",
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 1},
+			"payload": {"type": "object"}
+		},
+		"required": ["name"]
+	}`)
+	assertContains(t, sourceCode,
+		"jsonv2 \"encoding/json/v2\"",
+		"func (this Task) MarshalJSONTo(enc *jsontext.Encoder) error {",
+		"return errors.New(\"Task: required property 'name' is not set\")",
+		"if !omitJSONv2(this.Payload) {",
+		"func (this *Task) UnmarshalJSONFrom(dec *jsontext.Decoder) error {",
+		"err = jsonv2.UnmarshalDecode(dec, &this.Name)",
+		"func omitJSONv2(v interface{}) bool {",
+	)
+}
+
+func TestBenchmarks(t *testing.T) {
+	job := &Job{
+		Package:              "main",
+		ExportTypes:          true,
+		DisableNestedStructs: true,
+		Benchmarks:           true,
+		AllocationBudgets:    map[string]int{"Task": 50},
+		URLs:                 []string{"-"},
+		Stdin: strings.NewReader(`{
+			"title": "Task",
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {
+				"created": {"type": "string", "format": "date-time"},
+				"priority": {"enum": ["high", "low"]},
+				"retries": {"type": "integer", "minimum": 3},
+				"dependencies": {"type": "array", "items": {"$ref": "#"}},
+				"payload": {
+					"type": "object",
+					"additionalProperties": false,
+					"properties": {"command": {"type": "string", "minLength": 10}}
+				}
+			}
+		}`),
+	}
+	result, err := job.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, string(result.BenchmarkSourceCode),
+		"package main",
+		"func BenchmarkTaskUnmarshal(b *testing.B) {",
+		"func BenchmarkTaskMarshal(b *testing.B) {",
+		"func BenchmarkPayloadUnmarshal(b *testing.B) {",
+		"func TestTaskAllocationBudget(t *testing.T) {",
+		"const samplePayload = `{\"command\":\"samplessss\"}`",
+		`"created":"2006-01-02T15:04:05.000Z"`,
+		`"dependencies":[null]`,
+		`"priority":"high"`,
+		`"retries":3`,
+	)
+	if strings.Contains(string(result.BenchmarkSourceCode), "TestPayloadAllocationBudget") {
+		t.Errorf("did not expect an allocation budget test for Payload:\n%s", result.BenchmarkSourceCode)
+	}
+}
+
+func TestConcurrentGenerationIsDeterministic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonschema2go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// enough top level types, each with nested types, imports and raw
+	// messages, to keep every worker busy
+	var URLs []string
+	for _, name := range []string{"alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel"} {
+		schemaFile := filepath.Join(dir, name+".json")
+		err = ioutil.WriteFile(schemaFile, []byte(`{
+			"title": "`+name+`",
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {
+				"created": {"type": "string", "format": "date-time"},
+				"extra": {"type": "object"},
+				"id": {"type": ["string", "integer"]},
+				"items": {
+					"type": "array",
+					"items": {
+						"type": "object",
+						"additionalProperties": false,
+						"properties": {"name": {"type": "string"}, "size": {"type": "integer"}}
+					}
+				},
+				"payload": {
+					"type": "object",
+					"additionalProperties": false,
+					"properties": {"command": {"type": "string"}}
+				}
+			}
+		}`), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		URLs = append(URLs, "file://"+schemaFile)
+	}
+
+	var first []byte
+	for i := 0; i < 20; i++ {
+		job := &Job{
+			Package:     "main",
+			ExportTypes: true,
+			URLs:        URLs,
+		}
+		result, err := job.Execute()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first == nil {
+			first = result.SourceCode
+			continue
+		}
+		if !bytes.Equal(first, result.SourceCode) {
+			t.Fatalf("run %v generated different code:\n%s\n\nfirst run:\n%s", i, result.SourceCode, first)
+		}
+	}
+}
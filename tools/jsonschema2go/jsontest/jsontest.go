@@ -0,0 +1,70 @@
+// Package jsontest provides helpers for regression testing the go code that
+// jsonschema2go generates, by comparing it against golden files. This is
+// useful when customising code generation, e.g. with a custom
+// TypeNameGenerator, to make sure that changes are deliberate.
+//
+// Golden files can be (re)written by setting the environment variable
+// JSONSCHEMA2GO_UPDATE_GOLDEN to a non-empty value when running tests, e.g.
+//
+//	JSONSCHEMA2GO_UPDATE_GOLDEN=1 go test ./...
+package jsontest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/taskcluster/taskcluster/v27/tools/jsonschema2go"
+)
+
+// UpdateEnvVar is the name of the environment variable that, if set, causes
+// CheckGolden to write golden files rather than compare against them.
+const UpdateEnvVar = "JSONSCHEMA2GO_UPDATE_GOLDEN"
+
+// CheckGolden generates go code for the schema file at schemaPath using job
+// (whose URLs are replaced), type checks it, and then compares it with the
+// golden file at goldenPath. Any problem is reported as a test failure.
+func CheckGolden(t testing.TB, job *jsonschema2go.Job, schemaPath, goldenPath string) {
+	t.Helper()
+	absSchemaPath, err := filepath.Abs(schemaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.URLs = []string{"file://" + filepath.ToSlash(absSchemaPath)}
+	if job.Package == "" {
+		job.Package = "generated"
+	}
+
+	if os.Getenv(UpdateEnvVar) != "" {
+		result, err := job.Execute()
+		if err != nil {
+			t.Fatalf("Could not generate code for %v: %v", schemaPath, err)
+		}
+		if err := jsonschema2go.TypeCheck(result.SourceCode); err != nil {
+			t.Fatalf("Code generated for %v does not compile: %v\n%s", schemaPath, err, result.SourceCode)
+		}
+		if err := ioutil.WriteFile(goldenPath, result.SourceCode, 0644); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("Updated golden file %v", goldenPath)
+		return
+	}
+
+	golden, err := ioutil.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("Could not read golden file (set %v to create it): %v", UpdateEnvVar, err)
+	}
+	result, err := job.Verify(golden)
+	if result != nil && result.SourceCode != nil {
+		if err := jsonschema2go.TypeCheck(result.SourceCode); err != nil {
+			t.Errorf("Code generated for %v does not compile: %v\n%s", schemaPath, err, result.SourceCode)
+		}
+	}
+	if outOfDate, ok := err.(*jsonschema2go.OutOfDateError); ok {
+		t.Fatalf("Code generated for %v differs from golden file %v (set %v to update it):\n%v", schemaPath, goldenPath, UpdateEnvVar, outOfDate.Diff)
+	}
+	if err != nil {
+		t.Fatalf("Could not generate code for %v: %v", schemaPath, err)
+	}
+}
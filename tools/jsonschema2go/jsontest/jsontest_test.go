@@ -0,0 +1,17 @@
+package jsontest_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/taskcluster/taskcluster/v27/tools/jsonschema2go"
+	"github.com/taskcluster/taskcluster/v27/tools/jsonschema2go/jsontest"
+)
+
+func TestPersonGolden(t *testing.T) {
+	job := &jsonschema2go.Job{
+		ExportTypes:          true,
+		DisableNestedStructs: true,
+	}
+	jsontest.CheckGolden(t, job, filepath.Join("..", "testdata", "person.json"), filepath.Join("testdata", "person.go.golden"))
+}
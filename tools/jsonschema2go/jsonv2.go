@@ -0,0 +1,158 @@
+package jsonschema2go
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonv2Imports are the imports required by the code that jsonv2Methods
+// writes.
+var jsonv2Imports = []string{
+	`"encoding/json/jsontext"`,
+	`jsonv2 "encoding/json/v2"`,
+	`"errors"`,
+	`"reflect"`,
+}
+
+// jsonv2Methods writes MarshalJSONTo and UnmarshalJSONFrom methods for all
+// generated struct types, which encoding/json/v2 calls in preference to
+// MarshalJSON and UnmarshalJSON. They encode and decode one property at a
+// time, directly to and from the jsontext stream, so that e.g. a huge task
+// definition is never held in memory as json in its entirety. Returns false
+// if there are no struct types, in which case nothing is written.
+func jsonv2Methods(w io.Writer, disableNested bool, enforceRequiredFields bool, omit OmitOptions, schemaSet *SchemaSet) (generated bool) {
+	schemas := make([]*JsonSubSchema, 0, len(schemaSet.used))
+	for _, s := range schemaSet.used {
+		schemas = append(schemas, s)
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].TypeName < schemas[j].TypeName })
+	for _, s := range schemas {
+		// only interested in schemas that generate a go struct
+		_, typ := s.typeDefinition(disableNested, true, StringSet{}, StringSet{})
+		if !strings.HasPrefix(typ, "struct {") {
+			continue
+		}
+		generated = true
+		properties := s.TargetSchema().Properties
+		checks := ""
+		if enforceRequiredFields {
+			// otherwise MarshalJSONTo would bypass the checks made by
+			// MarshalJSON
+			checks = requiredFieldChecks(s, disableNested, "")
+		}
+		encode := ""
+		decode := ""
+		if properties != nil {
+			for _, name := range properties.SortedPropertyNames {
+				member := properties.MemberNames[name]
+				write := `
+	if err := enc.WriteToken(jsontext.String(` + strconv.Quote(name) + `)); err != nil {
+		return err
+	}
+	if err := jsonv2.MarshalEncode(enc, this.` + member + `); err != nil {
+		return err
+	}`
+				if !properties.Properties[name].IsRequired {
+					write = `
+	if !omitJSONv2(this.` + member + `) {` + strings.Replace(write, "\n", "\n\t", -1) + `
+	}`
+				}
+				encode += write
+				decode += `
+		case ` + strconv.Quote(name) + `:
+			err = jsonv2.UnmarshalDecode(dec, &this.` + member + `)`
+			}
+		}
+		io.WriteString(w, `
+
+// MarshalJSONTo encodes `+s.TypeName+` to enc one property at a time, for
+// encoding/json/v2.
+func (this `+s.TypeName+`) MarshalJSONTo(enc *jsontext.Encoder) error {`+checks+`
+	if err := enc.WriteToken(jsontext.BeginObject); err != nil {
+		return err
+	}`+encode+`
+	return enc.WriteToken(jsontext.EndObject)
+}
+
+// UnmarshalJSONFrom decodes `+s.TypeName+` from dec one property at a time,
+// for encoding/json/v2. Unknown properties are skipped, and null leaves
+// `+s.TypeName+` unchanged.
+func (this *`+s.TypeName+`) UnmarshalJSONFrom(dec *jsontext.Decoder) error {
+	token, err := dec.ReadToken()
+	if err != nil {
+		return err
+	}
+	switch token.Kind() {
+	case 'n':
+		return nil
+	case '{':
+	default:
+		return errors.New("`+s.TypeName+`: cannot unmarshal json " + token.Kind().String() + " into object")
+	}
+	for dec.PeekKind() != '}' {
+		token, err := dec.ReadToken()
+		if err != nil {
+			return err
+		}
+		switch token.String() {`+decode+`
+		default:
+			err = dec.SkipValue()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err = dec.ReadToken()
+	return err
+}`)
+	}
+	if generated {
+		omitJSONv2Function(w, omit)
+	}
+	return
+}
+
+// omitJSONv2Function writes the omitJSONv2 helper function, which matches the
+// behaviour of the json struct tag options generated for optional properties.
+func omitJSONv2Function(w io.Writer, omit OmitOptions) {
+	empty := `
+	switch value.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return value.Len() == 0
+	case reflect.Bool:
+		return !value.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return value.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return value.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return value.IsNil()
+	}
+	return false`
+	zero := `
+	if isZeroer, ok := v.(interface{ IsZero() bool }); ok {
+		return isZeroer.IsZero()
+	}
+	return value.IsZero()`
+	switch omit {
+	case OmitZero:
+		empty = zero
+	case OmitEmptyAndZero:
+		empty = strings.Replace(empty, "\n\treturn false", zero, 1)
+	}
+	io.WriteString(w, `
+
+// omitJSONv2 returns true if v, the value of an optional property, should
+// not be encoded by MarshalJSONTo, as for its json struct tag.
+func omitJSONv2(v interface{}) bool {
+	value := reflect.ValueOf(v)
+	if !value.IsValid() {
+		// nil interface
+		return true
+	}`+empty+`
+}`)
+}
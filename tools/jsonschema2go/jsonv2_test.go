@@ -0,0 +1,62 @@
+//go:build goexperiment.jsonv2
+// +build goexperiment.jsonv2
+
+package jsonschema2go
+
+import (
+	"strings"
+	"testing"
+)
+
+// jsonv2RoundTrip is a program using the code generated in TestJSONv2RoundTrip,
+// which is built with the same GOEXPERIMENT as the test.
+const jsonv2RoundTrip = `package main
+
+import (
+	jsonv2 "encoding/json/v2"
+	"fmt"
+)
+
+func main() {
+	var task Task
+	err := jsonv2.Unmarshal([]byte(` + "`" + `{"unknown": [1], "payload": {"b": 2, "a": 1}, "name": "build"}` + "`" + `), &task)
+	if err != nil {
+		panic(err)
+	}
+	data, err := jsonv2.Marshal(task)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(data))
+	_, err = jsonv2.Marshal(Task{})
+	fmt.Println(err)
+}
+`
+
+// TestJSONv2RoundTrip builds and runs a program that unmarshals and
+// marshals a generated type with encoding/json/v2, and so can only run when
+// the experiment is enabled, e.g. with GOEXPERIMENT=jsonv2 go test ./...
+func TestJSONv2RoundTrip(t *testing.T) {
+	sourceCode := generate(t, &Job{JSONv2: true, EnforceRequiredFields: true}, `{
+		"title": "Task",
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 1},
+			"payload": {"type": "object"},
+			"retries": {"type": "integer"}
+		},
+		"required": ["name"]
+	}`)
+	output := run(t, sourceCode, jsonv2RoundTrip)
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected output:\n%s", output)
+	}
+	if expected := `{"name":"build","payload":{"b":2,"a":1}}`; lines[0] != expected {
+		t.Errorf("expected round trip to give %s but got %s", expected, lines[0])
+	}
+	if !strings.Contains(lines[1], "Task: required property 'name' is not set") {
+		t.Errorf("expected an error for the missing required property, but got %q", lines[1])
+	}
+}
@@ -0,0 +1,54 @@
+package jsonschema2go
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+
+	"github.com/ghodss/yaml"
+)
+
+var (
+	// a line containing only a yaml document separator
+	yamlDocumentSeparator = regexp.MustCompile(`(?m)^---[ \t]*(#.*)?$`)
+	// a line that is blank or only contains a yaml comment
+	yamlBlankLine = regexp.MustCompile(`(?m)^[ \t]*(#.*)?$`)
+)
+
+// splitYAMLDocuments splits yaml data into its separate (non-empty)
+// documents. Json data is always a single document.
+func splitYAMLDocuments(data []byte) [][]byte {
+	documents := [][]byte{}
+	for _, document := range yamlDocumentSeparator.Split(string(data), -1) {
+		if len(bytes.TrimSpace(yamlBlankLine.ReplaceAll([]byte(document), nil))) == 0 {
+			continue
+		}
+		documents = append(documents, []byte(document))
+	}
+	return documents
+}
+
+// documentToJSON converts the given json or yaml data to json. If data
+// contains multiple yaml documents, they are returned as the definitions of
+// a single json schema, keyed by their (zero based) position in the file, so
+// that e.g. the second document of file:///schemas.yml can be referred to
+// as file:///schemas.yml#/definitions/1. The number of documents is also
+// returned.
+func documentToJSON(data []byte) (j []byte, documents int, err error) {
+	yamlDocuments := splitYAMLDocuments(data)
+	if len(yamlDocuments) < 2 {
+		// json is valid YAML, so we can safely convert, even if it is already json
+		j, err = yaml.YAMLToJSON(data)
+		return j, 1, err
+	}
+	definitions := make(map[string]json.RawMessage, len(yamlDocuments))
+	for i, document := range yamlDocuments {
+		definitions[strconv.Itoa(i)], err = yaml.YAMLToJSON(document)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	j, err = json.Marshal(map[string]interface{}{"definitions": definitions})
+	return j, len(yamlDocuments), err
+}
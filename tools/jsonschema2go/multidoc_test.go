@@ -0,0 +1,34 @@
+package jsonschema2go
+
+import (
+	"testing"
+)
+
+func TestSplitYAMLDocuments(t *testing.T) {
+	for data, expected := range map[string]int{
+		`{"type": "string"}`:                            1,
+		"type: string\n":                                1,
+		"---\ntype: string\n":                           1,
+		"# comment\n---\ntype: string\n---\n# empty\n":  1,
+		"type: string\n---\ntype: integer\n":            2,
+		"---\ntype: string\n--- # next\ntype: number\n": 2,
+	} {
+		if got := len(splitYAMLDocuments([]byte(data))); got != expected {
+			t.Errorf("expected %v documents but got %v in:\n%s", expected, got, data)
+		}
+	}
+}
+
+func TestMultiDocumentYAML(t *testing.T) {
+	sourceCode := generate(t, &Job{}, `---
+{"title": "First", "type": "object", "additionalProperties": false, "properties": {"a": {"type": "string"}}}
+---
+{"title": "Second", "type": "object", "additionalProperties": false, "properties": {"b": {"type": "integer"}}}
+`)
+	assertContains(t, sourceCode,
+		"First struct {",
+		"A string `json:\"a,omitempty\"`",
+		"Second struct {",
+		"B int64 `json:\"b,omitempty\"`",
+	)
+}
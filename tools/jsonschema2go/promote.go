@@ -0,0 +1,71 @@
+package jsonschema2go
+
+import (
+	"log"
+	"sort"
+	"strings"
+)
+
+// promoteSharedStructs adds, as top level types, the schemas of nested
+// structs that appear inside more than one parent schema of the generated
+// types, so that a single named type is generated for them, rather than an
+// identical anonymous struct inside each parent.
+func (job *Job) promoteSharedStructs() {
+	schemaSet := job.result.SchemaSet
+	parents := map[*JsonSubSchema]map[*JsonSubSchema]bool{}
+	visited := map[*JsonSubSchema]bool{}
+	var visit func(subSchema *JsonSubSchema)
+	child := func(parent, subSchema *JsonSubSchema) {
+		target := subSchema.TargetSchema()
+		if parents[target] == nil {
+			parents[target] = map[*JsonSubSchema]bool{}
+		}
+		parents[target][parent] = true
+		visit(target)
+	}
+	visit = func(subSchema *JsonSubSchema) {
+		if visited[subSchema] {
+			return
+		}
+		visited[subSchema] = true
+		if p := subSchema.Properties; p != nil {
+			for _, name := range p.SortedPropertyNames {
+				child(subSchema, p.Properties[name])
+			}
+		}
+		if subSchema.Items != nil {
+			child(subSchema, subSchema.Items)
+		}
+		if ap := subSchema.AdditionalProperties; ap != nil && ap.Properties != nil {
+			child(subSchema, ap.Properties)
+		}
+	}
+	used := make([]*JsonSubSchema, 0, len(schemaSet.used))
+	for _, subSchema := range schemaSet.used {
+		used = append(used, subSchema)
+	}
+	sort.Slice(used, func(i, j int) bool { return used[i].SourceURL < used[j].SourceURL })
+	for _, subSchema := range used {
+		visit(subSchema)
+	}
+	shared := []*JsonSubSchema{}
+	for target, p := range parents {
+		if len(p) < 2 {
+			continue
+		}
+		if _, isUsed := schemaSet.used[target.SourceURL]; isUsed {
+			continue
+		}
+		if _, typ := target.typeDefinition(job.DisableNestedStructs, true, StringSet{}, StringSet{}); !strings.HasPrefix(typ, "struct {") {
+			continue
+		}
+		shared = append(shared, target)
+	}
+	// add in a consistent order, so that type names are stable
+	sort.Slice(shared, func(i, j int) bool { return shared[i].SourceURL < shared[j].SourceURL })
+	for _, target := range shared {
+		log.Printf("Promoting %v to a top level type, since it is shared by %v parents", target.SourceURL, len(parents[target]))
+		target.promoted = true
+		job.add(target)
+	}
+}
@@ -0,0 +1,91 @@
+package jsonschema2go
+
+import (
+	"io"
+	"sort"
+	"strings"
+)
+
+// requiredFieldCheck returns a go expression that evaluates to true if the
+// struct member holding the given required property of `this` has not been
+// set, or an empty string if there is no way to tell. Only members that can
+// be nil (pointers, slices, maps and json.RawMessage) and strings that must
+// not be empty can be checked, since e.g. false or 0 are legitimate values
+// for required booleans and numbers. Job.PointerOptionals makes booleans,
+// numbers and strings pointers, so that they can be checked too.
+func requiredFieldCheck(member, goType string, property *JsonSubSchema) string {
+	target := property.TargetSchema()
+	if target.Type != nil && target.Type.Nullable() {
+		// null is an allowed value, so nil is fine
+		return ""
+	}
+	switch {
+	case strings.HasPrefix(goType, "*"),
+		strings.HasPrefix(goType, "[]"),
+		strings.HasPrefix(goType, "map["),
+		goType == "json.RawMessage":
+		return "this." + member + " == nil"
+	case goType == "string" && target.MinLength != nil && *target.MinLength > 0:
+		return "this." + member + ` == ""`
+	}
+	return ""
+}
+
+// requiredFieldMarshalers writes MarshalJSON methods for all generated struct
+// types that have required properties which can be checked (see
+// requiredFieldCheck). The methods return an error if a required property
+// has not been set, rather than producing json that does not conform to the
+// schema.
+func requiredFieldMarshalers(w io.Writer, disableNested bool, schemaSet *SchemaSet) (generated bool) {
+	schemas := make([]*JsonSubSchema, 0, len(schemaSet.used))
+	for _, s := range schemaSet.used {
+		schemas = append(schemas, s)
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].TypeName < schemas[j].TypeName })
+	for _, s := range schemas {
+		// only interested in schemas that generate a go struct
+		_, typ := s.typeDefinition(disableNested, true, StringSet{}, StringSet{})
+		if !strings.HasPrefix(typ, "struct {") {
+			continue
+		}
+		checks := requiredFieldChecks(s, disableNested, "nil, ")
+		if checks == "" {
+			continue
+		}
+		generated = true
+		io.WriteString(w, `
+
+// MarshalJSON returns an error if a required property of `+s.TypeName+` is
+// not set, and otherwise marshals it in the usual way.
+func (this `+s.TypeName+`) MarshalJSON() ([]byte, error) {`+checks+`
+	// conversion to a type without methods avoids infinite recursion
+	type plain `+s.TypeName+`
+	return json.Marshal(plain(this))
+}`)
+	}
+	return
+}
+
+// requiredFieldChecks returns go statements that return an error from a
+// method of the struct type generated for s, if a required property has not
+// been set (see requiredFieldCheck). The error is preceded in the return
+// statement by otherResults, e.g. "nil, " for a method returning ([]byte,
+// error).
+func requiredFieldChecks(s *JsonSubSchema, disableNested bool, otherResults string) (checks string) {
+	properties := s.TargetSchema().Properties
+	for _, name := range properties.SortedPropertyNames {
+		property := properties.Properties[name]
+		if !property.IsRequired {
+			continue
+		}
+		member := properties.MemberNames[name]
+		_, goType := properties.memberType(name, disableNested, StringSet{}, StringSet{})
+		if check := requiredFieldCheck(member, goType, property); check != "" {
+			checks += `
+	if ` + check + ` {
+		return ` + otherResults + `errors.New("` + s.TypeName + `: required property '` + name + `' is not set")
+	}`
+		}
+	}
+	return
+}
@@ -0,0 +1,112 @@
+package jsonschema2go
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// generatedCodeHeader is the first line of all generated source code.
+const generatedCodeHeader = "// This source code file is AUTO-GENERATED by github.com/taskcluster/jsonschema2go"
+
+// predeclaredIdentifiers are the identifiers that are implicitly declared in
+// the universe block, which a generated type would shadow. See
+// https://golang.org/ref/spec#Predeclared_identifiers
+var predeclaredIdentifiers = []string{
+	// types
+	"bool", "byte", "complex64", "complex128", "error", "float32", "float64",
+	"int", "int8", "int16", "int32", "int64", "rune", "string",
+	"uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+	// constants
+	"true", "false", "iota",
+	// zero value
+	"nil",
+	// functions
+	"append", "cap", "close", "complex", "copy", "delete", "imag", "len",
+	"make", "new", "panic", "print", "println", "real", "recover",
+}
+
+// importedPackageNames are the names of the packages that generated code may
+// import, which a generated type must not shadow.
+var importedPackageNames = []string{
+	"bytes", "errors", "fmt", "json", "jsontext", "jsonv2", "reflect",
+	"tcclient", "time",
+}
+
+// reserveTypeNames adds to the type name blacklist the predeclared
+// identifiers, the names of packages imported by generated code, and, if
+// job.PackageDir is set, the names declared by the (hand written) go files
+// already in the destination package.
+func (job *Job) reserveTypeNames() error {
+	for _, name := range predeclaredIdentifiers {
+		job.TypeNameBlacklist[name] = true
+	}
+	for _, name := range importedPackageNames {
+		job.TypeNameBlacklist[name] = true
+	}
+	if job.PackageDir == "" {
+		return nil
+	}
+	declared, err := packageDeclarations(job.PackageDir)
+	if err != nil {
+		return err
+	}
+	for name := range declared {
+		job.TypeNameBlacklist[name] = true
+	}
+	return nil
+}
+
+// packageDeclarations returns the names of the top level types, functions,
+// constants and variables declared in the go files of directory dir. Test
+// files, and files generated by jsonschema2go (which are about to be
+// regenerated), are skipped.
+func packageDeclarations(dir string) (StringSet, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	declared := StringSet{}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.HasPrefix(src, []byte(generatedCodeHeader)) {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, src, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range f.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				// methods don't clash with type names
+				if d.Recv == nil {
+					declared[d.Name.Name] = true
+				}
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						declared[s.Name.Name] = true
+					case *ast.ValueSpec:
+						for _, name := range s.Names {
+							declared[name.Name] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	return declared, nil
+}
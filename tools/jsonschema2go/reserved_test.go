@@ -0,0 +1,60 @@
+package jsonschema2go
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPredeclaredTypeNames(t *testing.T) {
+	job := &Job{
+		Package: "main",
+		URLs:    []string{"file://" + filepath.Join("testdata", "person.json")},
+	}
+	_, err := job.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"string", "error", "json", "time"} {
+		if !job.TypeNameBlacklist[name] {
+			t.Errorf("expected %q to be reserved", name)
+		}
+	}
+}
+
+func TestPackageDirTypeNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonschema2go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"person.go":      "package main\n\ntype Person struct{}\n\nfunc (p Person) Activities() {}\n",
+		"person_test.go": "package main\n\nvar Activities = 1\n",
+		"generated.go":   generatedCodeHeader + "\n\npackage main\n\ntype Activities struct{}\n",
+	}
+	for name, content := range files {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	sourceCode := generate(t, &Job{PackageDir: dir, DisableNestedStructs: true}, `{
+		"title": "person",
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"hobbies": {
+				"title": "activities",
+				"type": "object",
+				"additionalProperties": false,
+				"properties": {"snooker": {"type": "boolean"}}
+			}
+		}
+	}`)
+	assertContains(t, sourceCode,
+		"Person1 struct {",
+		"Activities struct {",
+	)
+}
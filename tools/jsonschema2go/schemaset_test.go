@@ -0,0 +1,94 @@
+package jsonschema2go
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSchemaSetQueries(t *testing.T) {
+	schemaFile, err := filepath.Abs(filepath.Join("testdata", "person.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := &Job{
+		Package:              "main",
+		ExportTypes:          true,
+		URLs:                 []string{"file://" + schemaFile},
+		DisableNestedStructs: true,
+		SkipCodeGen:          true,
+	}
+	result, err := job.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemaSet := result.SchemaSet
+
+	if s := schemaSet.SchemaForType("Person"); s == nil || *s.Title != "person" {
+		t.Fatalf("expected schema with title 'person' for type Person, got %v", s)
+	}
+	if s := schemaSet.SchemaForType("NoSuchType"); s != nil {
+		t.Fatalf("expected no schema for NoSuchType, got %v", s)
+	}
+	if got, want := schemaSet.RequiredFields("Activities"), []string{"cooking", "snooker"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected required fields %v but got %v", want, got)
+	}
+	if got, want := schemaSet.TypesByService(), map[string][]string{"": {"Activities", "Person"}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected types by service %v but got %v", want, got)
+	}
+}
+
+func TestServiceName(t *testing.T) {
+	for URL, service := range map[string]string{
+		"https://community-tc.services.mozilla.com/schemas/queue/v1/task.json#": "queue",
+		"https://schemas.taskcluster.net/auth/v1/client.json#/properties/x":     "auth",
+		"http://127.0.0.1:34567/schemas/hooks/v1/hook-definition.json#":         "hooks",
+		"file:///tmp/person.json#": "",
+	} {
+		if got := serviceName(URL); got != service {
+			t.Errorf("expected service %q for %v but got %q", service, URL, got)
+		}
+	}
+}
+
+func TestSchemaSetJSON(t *testing.T) {
+	schemaFile, err := filepath.Abs(filepath.Join("testdata", "person.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := &Job{
+		Package:              "main",
+		ExportTypes:          true,
+		URLs:                 []string{"file://" + schemaFile},
+		DisableNestedStructs: true,
+		SkipCodeGen:          true,
+	}
+	result, err := job.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported, err := json.Marshal(result.SchemaSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemaSet, err := LoadSchemaSet(bytes.NewReader(exported))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hobbies := schemaSet.SchemaForType("Person").Properties.Properties["hobbies"]
+	if hobbies.RefSubSchema != schemaSet.SchemaForType("Activities") {
+		t.Fatalf("expected hobbies property to refer to Activities type, but got %v", hobbies.RefSubSchema)
+	}
+	if got, want := schemaSet.RequiredFields("Activities"), []string{"cooking", "snooker"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected required fields %v but got %v", want, got)
+	}
+	reexported, err := json.Marshal(schemaSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(exported, reexported) {
+		t.Fatalf("schema set changed after loading:\n%s\n%s", exported, reexported)
+	}
+}
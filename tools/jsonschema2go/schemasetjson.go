@@ -0,0 +1,241 @@
+package jsonschema2go
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+type (
+	// schemaSetJSON is the json representation of a SchemaSet, as produced
+	// by SchemaSet.MarshalJSON.
+	schemaSetJSON struct {
+		// Schemas contains every loaded subschema, keyed by SourceURL.
+		Schemas map[string]*schemaNode `json:"schemas"`
+		// Used lists the SourceURLs of the schemas that go types were
+		// generated for.
+		Used      []string `json:"used"`
+		TypeNames []string `json:"typeNames"`
+	}
+
+	// schemaNode is the json representation of a single subschema, in which
+	// nested subschemas are replaced by their SourceURLs, so that every
+	// subschema appears exactly once, and recursive schemas can be
+	// represented.
+	schemaNode struct {
+		*JsonSubSchema
+		// either a bool or the SourceURL of the additional properties
+		// schema
+		AdditionalProperties interface{}       `json:"additionalProperties,omitempty"`
+		AllOf                []string          `json:"allOf,omitempty"`
+		AnyOf                []string          `json:"anyOf,omitempty"`
+		OneOf                []string          `json:"oneOf,omitempty"`
+		Definitions          map[string]string `json:"definitions,omitempty"`
+		Items                string            `json:"items,omitempty"`
+		Properties           map[string]string `json:"properties,omitempty"`
+		MemberNames          map[string]string `json:"MEMBER_NAMES,omitempty"`
+	}
+)
+
+// MarshalJSON returns the fully resolved schema graph of the schema set,
+// including the TypeName, SourceURL and resolved $ref URL (REF_SCHEMA_URL)
+// of every loaded subschema, and the struct member names of their
+// properties, so that other tools can make use of it. Nested subschemas are
+// referred to by their SourceURLs. The patternProperties and dependencies
+// keywords, which are not used for code generation, are omitted.
+//
+// LoadSchemaSet reads the json back into a SchemaSet.
+func (schemaSet *SchemaSet) MarshalJSON() ([]byte, error) {
+	out := schemaSetJSON{
+		Schemas:   make(map[string]*schemaNode, len(schemaSet.all)),
+		Used:      make([]string, 0, len(schemaSet.used)),
+		TypeNames: schemaSet.TypeNames.sortedMembers(),
+	}
+	for URL, subSchema := range schemaSet.all {
+		out.Schemas[URL] = newSchemaNode(subSchema)
+	}
+	for URL := range schemaSet.used {
+		out.Used = append(out.Used, URL)
+	}
+	sort.Strings(out.Used)
+	return json.Marshal(out)
+}
+
+func newSchemaNode(subSchema *JsonSubSchema) *schemaNode {
+	// shallow copy, without nested subschemas
+	keywords := *subSchema
+	keywords.AdditionalProperties = nil
+	keywords.AllOf = nil
+	keywords.AnyOf = nil
+	keywords.OneOf = nil
+	keywords.Definitions = nil
+	keywords.Items = nil
+	keywords.Properties = nil
+	keywords.PatternProperties = nil
+	keywords.Dependencies = nil
+	keywords.RefSubSchema = nil
+	node := &schemaNode{JsonSubSchema: &keywords}
+	if ap := subSchema.AdditionalProperties; ap != nil {
+		if ap.Properties != nil {
+			node.AdditionalProperties = ap.Properties.SourceURL
+		} else if ap.Boolean != nil {
+			node.AdditionalProperties = *ap.Boolean
+		}
+	}
+	node.AllOf = itemURLs(subSchema.AllOf)
+	node.AnyOf = itemURLs(subSchema.AnyOf)
+	node.OneOf = itemURLs(subSchema.OneOf)
+	if d := subSchema.Definitions; d != nil {
+		node.Definitions = propertyURLs(d)
+	}
+	if subSchema.Items != nil {
+		node.Items = subSchema.Items.SourceURL
+	}
+	if p := subSchema.Properties; p != nil {
+		node.Properties = propertyURLs(p)
+		node.MemberNames = p.MemberNames
+	}
+	return node
+}
+
+func itemURLs(items *Items) []string {
+	if items == nil {
+		return nil
+	}
+	URLs := make([]string, len(items.Items))
+	for i, item := range items.Items {
+		URLs[i] = item.SourceURL
+	}
+	return URLs
+}
+
+func propertyURLs(p *Properties) map[string]string {
+	URLs := make(map[string]string, len(p.Properties))
+	for name, subSchema := range p.Properties {
+		URLs[name] = subSchema.SourceURL
+	}
+	return URLs
+}
+
+// LoadSchemaSet reads a SchemaSet from json produced by
+// SchemaSet.MarshalJSON, restoring the links between subschemas.
+func LoadSchemaSet(r io.Reader) (*SchemaSet, error) {
+	in := new(schemaSetJSON)
+	err := json.NewDecoder(r).Decode(in)
+	if err != nil {
+		return nil, err
+	}
+	schemaSet := &SchemaSet{
+		all:            make(map[string]*JsonSubSchema, len(in.Schemas)),
+		used:           make(map[string]*JsonSubSchema, len(in.Used)),
+		TypeNames:      make(StringSet, len(in.TypeNames)),
+		documentHashes: make(map[string]string),
+		unionTypes:     make(map[string]*unionType),
+		documents:      make(map[string][]byte),
+		multiDocuments: make(map[string]int),
+	}
+	for URL, node := range in.Schemas {
+		if node.JsonSubSchema == nil {
+			node.JsonSubSchema = new(JsonSubSchema)
+		}
+		schemaSet.all[URL] = node.JsonSubSchema
+	}
+	lookup := func(URL string) (*JsonSubSchema, error) {
+		subSchema, found := schemaSet.all[URL]
+		if !found {
+			return nil, fmt.Errorf("Schema set refers to schema %v, which it does not contain", URL)
+		}
+		return subSchema, nil
+	}
+	items := func(URLs []string, sourceURL string) (*Items, error) {
+		if URLs == nil {
+			return nil, nil
+		}
+		result := &Items{SourceURL: sourceURL, Items: make([]*JsonSubSchema, len(URLs))}
+		for i, URL := range URLs {
+			if result.Items[i], err = lookup(URL); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+	properties := func(URLs map[string]string, sourceURL string) (*Properties, error) {
+		if URLs == nil {
+			return nil, nil
+		}
+		result := &Properties{
+			SourceURL:           sourceURL,
+			Properties:          make(map[string]*JsonSubSchema, len(URLs)),
+			SortedPropertyNames: make([]string, 0, len(URLs)),
+		}
+		for name, URL := range URLs {
+			if result.Properties[name], err = lookup(URL); err != nil {
+				return nil, err
+			}
+			result.SortedPropertyNames = append(result.SortedPropertyNames, name)
+		}
+		sort.Strings(result.SortedPropertyNames)
+		return result, nil
+	}
+	for URL, node := range in.Schemas {
+		subSchema := node.JsonSubSchema
+		switch ap := node.AdditionalProperties.(type) {
+		case bool:
+			subSchema.AdditionalProperties = &AdditionalProperties{Boolean: &ap}
+		case string:
+			target, err := lookup(ap)
+			if err != nil {
+				return nil, err
+			}
+			subSchema.AdditionalProperties = &AdditionalProperties{Properties: target}
+		}
+		if subSchema.AllOf, err = items(node.AllOf, URL+"/allOf"); err != nil {
+			return nil, err
+		}
+		if subSchema.AnyOf, err = items(node.AnyOf, URL+"/anyOf"); err != nil {
+			return nil, err
+		}
+		if subSchema.OneOf, err = items(node.OneOf, URL+"/oneOf"); err != nil {
+			return nil, err
+		}
+		if subSchema.Definitions, err = properties(node.Definitions, URL+"/definitions"); err != nil {
+			return nil, err
+		}
+		if subSchema.Properties, err = properties(node.Properties, URL+"/properties"); err != nil {
+			return nil, err
+		}
+		if subSchema.Properties != nil {
+			subSchema.Properties.MemberNames = node.MemberNames
+			// skipped properties (see PropertyOverride) have no member name
+			if node.MemberNames != nil {
+				kept := make([]string, 0, len(node.MemberNames))
+				for _, name := range subSchema.Properties.SortedPropertyNames {
+					if _, hasMember := node.MemberNames[name]; hasMember {
+						kept = append(kept, name)
+					}
+				}
+				subSchema.Properties.SortedPropertyNames = kept
+			}
+		}
+		if node.Items != "" {
+			if subSchema.Items, err = lookup(node.Items); err != nil {
+				return nil, err
+			}
+		}
+		if subSchema.RefSchemaURL != "" {
+			if subSchema.RefSubSchema, err = lookup(subSchema.RefSchemaURL); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, URL := range in.Used {
+		if schemaSet.used[URL], err = lookup(URL); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range in.TypeNames {
+		schemaSet.TypeNames[name] = true
+	}
+	return schemaSet, nil
+}
@@ -0,0 +1,97 @@
+package text
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/fatih/camelcase"
+)
+
+// irregularPlurals maps plural nouns that do not follow the usual english
+// rules to their singular forms.
+var irregularPlurals = map[string]string{
+	"children": "child",
+	"criteria": "criterion",
+	"feet":     "foot",
+	"indices":  "index",
+	"matrices": "matrix",
+	"men":      "man",
+	"mice":     "mouse",
+	"people":   "person",
+	"teeth":    "tooth",
+	"vertices": "vertex",
+	"women":    "woman",
+}
+
+// uncountableNouns have the same singular and plural form.
+var uncountableNouns = map[string]bool{
+	"data":        true,
+	"equipment":   true,
+	"information": true,
+	"metadata":    true,
+	"news":        true,
+	"series":      true,
+	"species":     true,
+}
+
+// singularSuffixes are the suffix replacements used to singularize regular
+// plural nouns, in the order they are tried.
+var singularSuffixes = []struct {
+	plural   string
+	singular string
+}{
+	{"sses", "ss"},
+	{"ches", "ch"},
+	{"shes", "sh"},
+	{"xes", "x"},
+	{"zzes", "z"},
+	{"ies", "y"},
+	// nouns ending in these are usually already singular, e.g. class,
+	// status, analysis
+	{"ss", "ss"},
+	{"us", "us"},
+	{"is", "is"},
+	{"s", ""},
+}
+
+// Singular returns the singular form of the (english) plural noun at the end
+// of name, e.g. "artifacts" becomes "artifact", "task dependencies" becomes
+// "task dependency" and "routingKeys" becomes "routingKey". The case of the
+// final letter is preserved, so that "ROUTES" becomes "ROUTE". If the noun is
+// not recognised as a plural, name is returned unchanged.
+func Singular(name string) string {
+	start := strings.LastIndexFunc(name, func(c rune) bool {
+		return !unicode.IsLetter(c)
+	}) + 1
+	if start == len(name) {
+		return name
+	}
+	// the final word may be the last part of a camel case word
+	parts := camelcase.Split(name[start:])
+	word := parts[len(parts)-1]
+	prefix := name[:len(name)-len(word)]
+	lower := strings.ToLower(word)
+	if uncountableNouns[lower] {
+		return name
+	}
+	if singular, irregular := irregularPlurals[lower]; irregular {
+		switch {
+		case word == strings.ToUpper(word):
+			singular = strings.ToUpper(singular)
+		case unicode.IsUpper(rune(word[0])):
+			singular = strings.ToUpper(singular[:1]) + singular[1:]
+		}
+		return prefix + singular
+	}
+	for _, suffix := range singularSuffixes {
+		if strings.HasSuffix(lower, suffix.plural) && len(lower) > len(suffix.plural) {
+			stem := word[:len(word)-len(suffix.plural)]
+			singular := suffix.singular
+			if unicode.IsUpper(rune(word[len(word)-1])) {
+				singular = strings.ToUpper(singular)
+			}
+			return prefix + stem + singular
+		}
+	}
+	return name
+}
@@ -171,6 +171,96 @@ func StarOut(text string) string {
 // Non-existence is equivalent to existence with a value of `false`; therefore
 // it is recommended to only store `true` values.
 func GoIdentifierFrom(name string, exported bool, blacklist map[string]bool) (identifier string) {
+	return defaultIdentifierGenerator.GoIdentifierFrom(name, exported, blacklist)
+}
+
+var defaultIdentifierGenerator = &IdentifierGenerator{}
+
+// IdentifierGenerator generates go identifiers in the same way as
+// GoIdentifierFrom, but allows the set of recognised initialisms, and the
+// identifiers generated for specific names, to be customised. Its
+// GoIdentifierFrom method can be used wherever a function with the signature
+// of GoIdentifierFrom is required, e.g. as the TypeNameGenerator or
+// MemberNameGenerator of a jsonschema2go Job.
+type IdentifierGenerator struct {
+	// Initialisms are the (upper case) subwords that should be written
+	// entirely in upper case, e.g. "URL" so that "baseUrl" becomes "BaseURL".
+	// If nil, the common initialisms used by golint are used (see
+	// CommonInitialisms).
+	Initialisms map[string]bool
+	// PluralInitialisms, if true, causes plurals of initialisms to be written
+	// with an upper case initialism and a lower case "s", e.g. "taskIds"
+	// becomes "TaskIDs" rather than "TaskIds".
+	PluralInitialisms bool
+	// Transliterate, if true, causes names to be converted to ASCII with the
+	// Transliterate function before identifiers are generated from them, and
+	// identifiers that would otherwise start with a digit to be prefixed with
+	// "X" (or "x", if not exported) rather than "_", so that exported
+	// identifiers really are exported. When deduplicating an identifier that
+	// ends in a digit, "_" is inserted before the integer suffix, so that e.g.
+	// "v2" and "v21" cannot clash.
+	Transliterate bool
+	// Overrides maps names to the identifiers that should be generated for
+	// them, bypassing steps 1 to 9 of GoIdentifierFrom. Overridden
+	// identifiers are still deduplicated against the blacklist.
+	Overrides map[string]string
+}
+
+// CommonInitialisms returns a new set containing the initialisms recognised by
+// GoIdentifierFrom, together with any additional initialisms given, e.g.
+// CommonInitialisms("AWS", "GPU"). The result can be used as the Initialisms
+// of an IdentifierGenerator.
+func CommonInitialisms(additional ...string) map[string]bool {
+	initialisms := make(map[string]bool, len(commonInitialisms)+len(additional))
+	for initialism := range commonInitialisms {
+		initialisms[initialism] = true
+	}
+	for _, initialism := range additional {
+		initialisms[strings.ToUpper(initialism)] = true
+	}
+	return initialisms
+}
+
+// GoIdentifierFrom returns a go identifier for the given name, as per the
+// package level GoIdentifierFrom function, but using the initialisms and
+// overrides of the IdentifierGenerator.
+func (generator *IdentifierGenerator) GoIdentifierFrom(name string, exported bool, blacklist map[string]bool) (identifier string) {
+	if override, ok := generator.Overrides[name]; ok {
+		identifier = override
+	} else {
+		if generator.Transliterate {
+			name = Transliterate(name)
+		}
+		identifier = generator.identifierFrom(name, exported)
+	}
+	separator := ""
+	if generator.Transliterate {
+		if strings.HasPrefix(identifier, "_") && len(identifier) > 1 && unicode.IsDigit(rune(identifier[1])) {
+			if exported {
+				identifier = "X" + identifier[1:]
+			} else {
+				identifier = "x" + identifier[1:]
+			}
+		}
+		if last, _ := utf8.DecodeLastRuneInString(identifier); unicode.IsDigit(last) {
+			separator = "_"
+		}
+	}
+
+	// If name already exists, add an integer suffix to name. Start with "1" and increment
+	// by 1 until an unused name is found. Example: if name FooBar was generated four times
+	// , the first instance would be called FooBar, then the next would be FooBar1, the next
+	// FooBar2 and the last would be assigned a name of FooBar3. We do this to guarantee we
+	// don't use duplicate names for different logical entities.
+	for k, baseName := 1, identifier; blacklist[identifier] || reservedKeyWords[identifier]; {
+		identifier = fmt.Sprintf("%v%v%v", baseName, separator, k)
+		k++
+	}
+	blacklist[identifier] = true
+	return
+}
+
+func (generator *IdentifierGenerator) identifierFrom(name string, exported bool) (identifier string) {
 	if !utf8.ValidString(name) {
 		name = ""
 	}
@@ -182,7 +272,7 @@ func GoIdentifierFrom(name string, exported bool, blacklist map[string]bool) (id
 	) {
 		caseAdaptedWord := ""
 		for j, subWord := range camelcase.Split(word) {
-			caseAdaptedWord += fixCase(subWord, i == 0 && j == 0 && !exported)
+			caseAdaptedWord += generator.fixCase(subWord, i == 0 && j == 0 && !exported)
 		}
 		identifier += caseAdaptedWord
 	}
@@ -199,31 +289,27 @@ func GoIdentifierFrom(name string, exported bool, blacklist map[string]bool) (id
 	if identifier == "" || identifier == "_" {
 		identifier = "Identifier"
 	}
-
-	// If name already exists, add an integer suffix to name. Start with "1" and increment
-	// by 1 until an unused name is found. Example: if name FooBar was generated four times
-	// , the first instance would be called FooBar, then the next would be FooBar1, the next
-	// FooBar2 and the last would be assigned a name of FooBar3. We do this to guarantee we
-	// don't use duplicate names for different logical entities.
-	for k, baseName := 1, identifier; blacklist[identifier] || reservedKeyWords[identifier]; {
-		identifier = fmt.Sprintf("%v%v", baseName, k)
-		k++
-	}
-	blacklist[identifier] = true
 	return
 }
 
-func fixCase(word string, makeLower bool) string {
+func (generator *IdentifierGenerator) fixCase(word string, makeLower bool) string {
 	if word == "" {
 		return ""
 	}
 	if makeLower {
 		return strings.ToLower(word)
 	}
+	initialisms := generator.Initialisms
+	if initialisms == nil {
+		initialisms = commonInitialisms
+	}
 	upper := strings.ToUpper(word)
-	if commonInitialisms[upper] {
+	if initialisms[upper] {
 		return upper
 	}
+	if generator.PluralInitialisms && strings.HasSuffix(word, "s") && initialisms[upper[:len(upper)-1]] {
+		return upper[:len(upper)-1] + "s"
+	}
 	firstRune, size := utf8.DecodeRuneInString(word)
 	remainingString := word[size:]
 	return string(unicode.ToUpper(firstRune)) + remainingString
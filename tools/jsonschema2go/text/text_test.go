@@ -166,3 +166,119 @@ func ExampleGoIdentifierFrom() {
 	// pdfDocument
 	// continue1
 }
+
+func ExampleIdentifierGenerator() {
+	generator := &text.IdentifierGenerator{
+		Initialisms:       text.CommonInitialisms("AWS", "GPU"),
+		PluralInitialisms: true,
+		Overrides: map[string]string{
+			"x-taskcluster-scopes": "Scopes",
+		},
+	}
+	blacklist := make(map[string]bool)
+	fmt.Println(generator.GoIdentifierFrom("taskId", true, blacklist))
+	fmt.Println(generator.GoIdentifierFrom("awsRegion", true, blacklist))
+	fmt.Println(generator.GoIdentifierFrom("awsRegion", false, blacklist))
+	fmt.Println(generator.GoIdentifierFrom("gpuCount", true, blacklist))
+	fmt.Println(generator.GoIdentifierFrom("roleIds", true, blacklist))
+	fmt.Println(generator.GoIdentifierFrom("artifactUrls", true, blacklist))
+	fmt.Println(generator.GoIdentifierFrom("status", true, blacklist))
+	fmt.Println(generator.GoIdentifierFrom("x-taskcluster-scopes", true, blacklist))
+	fmt.Println(generator.GoIdentifierFrom("scopes", true, blacklist))
+
+	// the package level function is unaffected
+	fmt.Println(text.GoIdentifierFrom("awsRegion", true, blacklist))
+	fmt.Println(text.GoIdentifierFrom("roleIds", true, blacklist))
+
+	// Output:
+	// TaskID
+	// AWSRegion
+	// awsRegion
+	// GPUCount
+	// RoleIDs
+	// ArtifactURLs
+	// Status
+	// Scopes
+	// Scopes1
+	// AwsRegion
+	// RoleIds
+}
+
+func ExampleTransliterate() {
+	fmt.Printf("%q\n", text.Transliterate("Grüße"))
+	fmt.Printf("%q\n", text.Transliterate("crème brûlée"))
+	fmt.Printf("%q\n", text.Transliterate("hello, 世界"))
+	fmt.Printf("%q\n", text.Transliterate("docker.io/image:latest"))
+	fmt.Printf("%q\n", text.Transliterate("snake_case\xe2\x28\xa1"))
+
+	// Output:
+	// "Grusse"
+	// "creme brulee"
+	// "hello   U4E16  U754C "
+	// "docker io image latest"
+	// "snake_case   "
+}
+
+func ExampleIdentifierGenerator_transliterate() {
+	generator := &text.IdentifierGenerator{
+		Transliterate: true,
+	}
+	blacklist := make(map[string]bool)
+	fmt.Println(generator.GoIdentifierFrom("größe", true, blacklist))
+	fmt.Println(generator.GoIdentifierFrom("größe", false, blacklist))
+	fmt.Println(generator.GoIdentifierFrom("世界", true, blacklist))
+	fmt.Println(generator.GoIdentifierFrom("x-amz.meta/owner", true, blacklist))
+	fmt.Println(generator.GoIdentifierFrom("3d", true, blacklist))
+	fmt.Println(generator.GoIdentifierFrom("3d", false, blacklist))
+	fmt.Println(generator.GoIdentifierFrom("v2", true, blacklist))
+	fmt.Println(generator.GoIdentifierFrom("v2", true, blacklist))
+	fmt.Println(generator.GoIdentifierFrom("v21", true, blacklist))
+
+	// Output:
+	// Grosse
+	// grosse
+	// U4E16U754C
+	// XAmzMetaOwner
+	// X3D
+	// x3D
+	// V2
+	// V2_1
+	// V21
+}
+
+func ExampleSingular() {
+	for _, name := range []string{
+		"artifacts",
+		"routes",
+		"task dependencies",
+		"routingKeys",
+		"taskIDs",
+		"ROUTES",
+		"addresses",
+		"matches",
+		"boxes",
+		"People",
+		"status",
+		"metadata",
+		"env",
+		"tags[]",
+	} {
+		fmt.Println(text.Singular(name))
+	}
+
+	// Output:
+	// artifact
+	// route
+	// task dependency
+	// routingKey
+	// taskID
+	// ROUTE
+	// address
+	// match
+	// box
+	// Person
+	// status
+	// metadata
+	// env
+	// tags[]
+}
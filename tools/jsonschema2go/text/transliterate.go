@@ -0,0 +1,79 @@
+package text
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// latinTransliterations maps the letters of the Latin-1 Supplement and Latin
+// Extended-A unicode blocks to their closest ASCII equivalents, which for
+// accented letters is the letter without its diacritic.
+var latinTransliterations = map[rune]string{
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "A", 'Å': "A", 'Æ': "AE",
+	'Ç': "C", 'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ì': "I", 'Í': "I",
+	'Î': "I", 'Ï': "I", 'Ð': "D", 'Ñ': "N", 'Ò': "O", 'Ó': "O", 'Ô': "O",
+	'Õ': "O", 'Ö': "O", 'Ø': "O", 'Ù': "U", 'Ú': "U", 'Û': "U", 'Ü': "U",
+	'Ý': "Y", 'Þ': "TH", 'ß': "ss", 'à': "a", 'á': "a", 'â': "a",
+	'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae", 'ç': "c", 'è': "e", 'é': "e",
+	'ê': "e", 'ë': "e", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ð': "d",
+	'ñ': "n", 'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ý': "y", 'þ': "th", 'ÿ': "y",
+	'Ā': "A", 'ā': "a", 'Ă': "A", 'ă': "a", 'Ą': "A", 'ą': "a", 'Ć': "C",
+	'ć': "c", 'Ĉ': "C", 'ĉ': "c", 'Ċ': "C", 'ċ': "c", 'Č': "C", 'č': "c",
+	'Ď': "D", 'ď': "d", 'Đ': "D", 'đ': "d", 'Ē': "E", 'ē': "e", 'Ĕ': "E",
+	'ĕ': "e", 'Ė': "E", 'ė': "e", 'Ę': "E", 'ę': "e", 'Ě': "E", 'ě': "e",
+	'Ĝ': "G", 'ĝ': "g", 'Ğ': "G", 'ğ': "g", 'Ġ': "G", 'ġ': "g", 'Ģ': "G",
+	'ģ': "g", 'Ĥ': "H", 'ĥ': "h", 'Ħ': "H", 'ħ': "h", 'Ĩ': "I", 'ĩ': "i",
+	'Ī': "I", 'ī': "i", 'Ĭ': "I", 'ĭ': "i", 'Į': "I", 'į': "i", 'İ': "I",
+	'ı': "i", 'Ĳ': "IJ", 'ĳ': "ij", 'Ĵ': "J", 'ĵ': "j", 'Ķ': "K",
+	'ķ': "k", 'ĸ': "q", 'Ĺ': "L", 'ĺ': "l", 'Ļ': "L", 'ļ': "l", 'Ľ': "L",
+	'ľ': "l", 'Ŀ': "L", 'ŀ': "l", 'Ł': "L", 'ł': "l", 'Ń': "N", 'ń': "n",
+	'Ņ': "N", 'ņ': "n", 'Ň': "N", 'ň': "n", 'ŉ': "n", 'Ŋ': "NG",
+	'ŋ': "ng", 'Ō': "O", 'ō': "o", 'Ŏ': "O", 'ŏ': "o", 'Ő': "O", 'ő': "o",
+	'Œ': "OE", 'œ': "oe", 'Ŕ': "R", 'ŕ': "r", 'Ŗ': "R", 'ŗ': "r",
+	'Ř': "R", 'ř': "r", 'Ś': "S", 'ś': "s", 'Ŝ': "S", 'ŝ': "s", 'Ş': "S",
+	'ş': "s", 'Š': "S", 'š': "s", 'Ţ': "T", 'ţ': "t", 'Ť': "T", 'ť': "t",
+	'Ũ': "U", 'ũ': "u", 'Ū': "U", 'ū': "u", 'Ŭ': "U", 'ŭ': "u", 'Ů': "U",
+	'ů': "u", 'Ű': "U", 'ű': "u", 'Ų': "U", 'ų': "u", 'Ŵ': "W", 'ŵ': "w",
+	'Ŷ': "Y", 'ŷ': "y", 'Ÿ': "Y", 'Ź': "Z", 'ź': "z", 'Ż': "Z", 'ż': "z",
+	'Ž': "Z", 'ž': "z", 'ſ': "s",
+	'Ŧ': "T", 'ŧ': "t",
+}
+
+// Transliterate returns an ASCII only version of s, which can be used as a
+// name for generating identifiers that are easy to type and read. Latin
+// letters with diacritics are replaced by the corresponding ASCII letters
+// (e.g. "Grüße" becomes "Grusse"), and any other non-ASCII letters or digits
+// are replaced by a separate word holding their code point (e.g. "世" becomes
+// " U4E16 "), so that distinct names remain distinct. All other non-ASCII
+// characters, and invalid UTF-8 bytes, are replaced by spaces, as are ASCII
+// punctuation characters, other than '_'.
+//
+// The result is deterministic, so generated identifiers are stable between
+// runs.
+func Transliterate(s string) string {
+	result := strings.Builder{}
+	for i, w := 0, 0; i < len(s); i += w {
+		c, width := utf8.DecodeRuneInString(s[i:])
+		w = width
+		switch {
+		case c == utf8.RuneError && width <= 1:
+			result.WriteRune(' ')
+		case c < utf8.RuneSelf:
+			if c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c) {
+				result.WriteRune(c)
+			} else {
+				result.WriteRune(' ')
+			}
+		case latinTransliterations[c] != "":
+			result.WriteString(latinTransliterations[c])
+		case unicode.IsLetter(c) || unicode.IsNumber(c):
+			fmt.Fprintf(&result, " U%X ", c)
+		default:
+			result.WriteRune(' ')
+		}
+	}
+	return result.String()
+}
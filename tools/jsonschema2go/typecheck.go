@@ -0,0 +1,138 @@
+package jsonschema2go
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// tcclientImportPath is the import path of the tcclient package, which
+// generated code imports when schemas contain date-time strings.
+const tcclientImportPath = "github.com/taskcluster/taskcluster/v27/clients/client-go"
+
+// tcclientSource is a minimal stand-in for the tcclient package, so that
+// generated code can be type checked without access to its source.
+const tcclientSource = `package tcclient
+
+import "time"
+
+type Time time.Time
+
+func (t Time) MarshalJSON() ([]byte, error) { return time.Time(t).MarshalJSON() }
+
+func (t *Time) UnmarshalJSON(data []byte) error { return (*time.Time)(t).UnmarshalJSON(data) }
+
+func (t Time) String() string { return time.Time(t).String() }
+`
+
+// TypeCheckError is returned when generated code fails to type check.
+type TypeCheckError struct {
+	// Errors lists the type checking errors, each annotated with the schema
+	// URL of the type that the error occurred in, where known.
+	Errors []string
+}
+
+func (err *TypeCheckError) Error() string {
+	return "Generated code does not compile:\n" + strings.Join(err.Errors, "\n")
+}
+
+// TypeCheck parses and type checks generated go source code, resolving
+// standard library imports and the tcclient package. Any errors are
+// returned as a *TypeCheckError.
+func TypeCheck(sourceCode []byte) error {
+	return typeCheck(sourceCode, nil)
+}
+
+// typeCheck type checks sourceCode. If schemaSet is not nil, errors are
+// annotated with the URL of the schema that the type containing the error
+// was generated from.
+func typeCheck(sourceCode []byte, schemaSet *SchemaSet) error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "generated.go", sourceCode, 0)
+	if err != nil {
+		return &TypeCheckError{Errors: []string{err.Error()}}
+	}
+	errs := []string{}
+	conf := types.Config{
+		Importer: &tcclientImporter{
+			fset:     fset,
+			fallback: importer.Default(),
+		},
+		Error: func(err error) {
+			message := err.Error()
+			if typeErr, ok := err.(types.Error); ok && schemaSet != nil {
+				if typeName := enclosingTypeName(file, typeErr.Pos); typeName != "" {
+					if subSchema := schemaSet.SchemaForType(typeName); subSchema != nil {
+						message += fmt.Sprintf(" (in type %v generated from %v)", typeName, subSchema.SourceURL)
+					}
+				}
+			}
+			errs = append(errs, message)
+		},
+	}
+	// errors are collected by conf.Error
+	_, _ = conf.Check(file.Name.Name, fset, []*ast.File{file}, nil)
+	if len(errs) > 0 {
+		return &TypeCheckError{Errors: errs}
+	}
+	return nil
+}
+
+// enclosingTypeName returns the name of the type whose declaration (or
+// method declaration) contains pos, or an empty string if there is none.
+func enclosingTypeName(file *ast.File, pos token.Pos) string {
+	for _, decl := range file.Decls {
+		if pos < decl.Pos() || pos > decl.End() {
+			continue
+		}
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				if typeSpec, ok := spec.(*ast.TypeSpec); ok && pos >= typeSpec.Pos() && pos <= typeSpec.End() {
+					return typeSpec.Name.Name
+				}
+			}
+		case *ast.FuncDecl:
+			if d.Recv != nil && len(d.Recv.List) == 1 {
+				recv := d.Recv.List[0].Type
+				if star, ok := recv.(*ast.StarExpr); ok {
+					recv = star.X
+				}
+				if ident, ok := recv.(*ast.Ident); ok {
+					return ident.Name
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// tcclientImporter imports tcclientSource in place of the tcclient package,
+// and delegates all other imports to fallback.
+type tcclientImporter struct {
+	fset     *token.FileSet
+	fallback types.Importer
+	tcclient *types.Package
+}
+
+func (i *tcclientImporter) Import(path string) (*types.Package, error) {
+	if path != tcclientImportPath {
+		return i.fallback.Import(path)
+	}
+	if i.tcclient == nil {
+		file, err := parser.ParseFile(i.fset, "tcclient.go", tcclientSource, 0)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse tcclient stand-in: %v", err)
+		}
+		conf := types.Config{Importer: i.fallback}
+		i.tcclient, err = conf.Check(path, i.fset, []*ast.File{file}, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return i.tcclient, nil
+}
@@ -0,0 +1,69 @@
+package jsonschema2go
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTypeCheck(t *testing.T) {
+	err := TypeCheck([]byte(`package x
+
+import tcclient "github.com/taskcluster/taskcluster/v27/clients/client-go"
+
+type T struct {
+	Created tcclient.Time
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = TypeCheck([]byte("package x\n\ntype T struct {\n\tX UndefinedType\n}\n"))
+	if _, ok := err.(*TypeCheckError); !ok {
+		t.Fatalf("expected *TypeCheckError for undefined type but got %#v", err)
+	}
+}
+
+func TestTypeCheckJob(t *testing.T) {
+	// every feature that generates methods, to make sure they all compile
+	sourceCode := generate(t, &Job{
+		TypeCheck:             true,
+		EnforceRequiredFields: true,
+		CanonicalJSON:         true,
+		EmbedSchemas:          true,
+	}, `{
+		"title": "Everything",
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"created": {"type": "string", "format": "date-time"},
+			"id": {"type": ["string", "integer", "null"]},
+			"data": {"type": "string", "contentEncoding": "base64"},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"extra": {"type": "object"}
+		},
+		"required": ["tags"]
+	}`)
+	assertContains(t, sourceCode, "Everything struct {")
+}
+
+func TestTypeCheckErrorMentionsSchema(t *testing.T) {
+	job := &Job{
+		Package:              "main",
+		ExportTypes:          true,
+		DisableNestedStructs: true,
+		TypeCheck:            true,
+		URLs:                 []string{"-"},
+		Stdin:                strings.NewReader(`{"title": "Outer", "type": "object", "additionalProperties": false, "properties": {"inner": {"type": "object", "additionalProperties": false, "properties": {"x": {"type": "boolean"}}}}}`),
+		// deliberately generate clashing type names
+		TypeNameGenerator: func(name string, exported bool, blacklist map[string]bool) string {
+			return "Same"
+		},
+	}
+	_, err := job.Execute()
+	if _, ok := err.(*TypeCheckError); !ok {
+		t.Fatalf("expected *TypeCheckError but got %#v", err)
+	}
+	if !strings.Contains(err.Error(), "(in type Same generated from stdin://#") {
+		t.Fatalf("expected error to mention schema URL, but got: %v", err)
+	}
+}
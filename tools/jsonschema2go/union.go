@@ -0,0 +1,123 @@
+package jsonschema2go
+
+import (
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/taskcluster/taskcluster/v27/tools/jsonschema2go/text"
+)
+
+// unionMembers lists the json types that may be members of a generated union
+// type, in the order in which json values are attempted to be unmarshaled
+// into them. Note, integer must be tried before number, since any json
+// integer is also a json number.
+var unionMembers = []struct {
+	jsonType string
+	goType   string
+}{
+	{"boolean", "bool"},
+	{"integer", "int64"},
+	{"number", "float64"},
+	{"string", "string"},
+}
+
+// unionType represents a generated go type that can hold a json value of any
+// one of several primitive json types, e.g. "type": ["string", "integer"].
+type unionType struct {
+	name      string
+	jsonTypes StringSet
+}
+
+// unionTypeName returns the name of the go union type to use for the given
+// json types, generating a new union type if required. If the types do not
+// require a union type (i.e. there is only one non-null type) or cannot be
+// represented by one (e.g. if one is an object or array), an empty string is
+// returned.
+func (job *Job) unionTypeName(types TypeList) string {
+	nonNull := types.NonNull()
+	if len(nonNull) < 2 {
+		return ""
+	}
+	jsonTypes := make(StringSet, len(nonNull))
+	for _, t := range nonNull {
+		jsonTypes[t] = true
+	}
+	members := make([]string, 0, len(jsonTypes))
+	for _, m := range unionMembers {
+		if jsonTypes[m.jsonType] {
+			members = append(members, m.jsonType)
+		}
+	}
+	if len(members) != len(jsonTypes) {
+		// not all types are primitive types
+		return ""
+	}
+	key := strings.Join(members, ",")
+	if u, exists := job.result.SchemaSet.unionTypes[key]; exists {
+		return u.name
+	}
+	name := job.TypeNameGenerator(strings.Join(members, " or "), job.ExportTypes, job.TypeNameBlacklist)
+	job.result.SchemaSet.TypeNames[name] = true
+	job.result.SchemaSet.unionTypes[key] = &unionType{
+		name:      name,
+		jsonTypes: jsonTypes,
+	}
+	return name
+}
+
+// unionTypeDefinitions writes the type definitions and json (un)marshalers for
+// the given union types, sorted by name.
+func unionTypeDefinitions(w io.Writer, unionTypes map[string]*unionType) {
+	sorted := make([]*unionType, 0, len(unionTypes))
+	for _, u := range unionTypes {
+		sorted = append(sorted, u)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+	for _, u := range sorted {
+		var fields, jsonTypes, unmarshal, marshal string
+		for _, m := range unionMembers {
+			if !u.jsonTypes[m.jsonType] {
+				continue
+			}
+			field := text.GoIdentifierFrom(m.jsonType, true, StringSet{})
+			jsonTypes += "\n// * " + m.jsonType
+			fields += "\n" + field + " *" + m.goType
+			unmarshal += `
+	var ` + m.jsonType + `Value ` + m.goType + `
+	if err := json.Unmarshal(data, &` + m.jsonType + `Value); err == nil {
+		this.` + field + ` = &` + m.jsonType + `Value
+		return nil
+	}`
+			marshal += `
+	if this.` + field + ` != nil {
+		return json.Marshal(*this.` + field + `)
+	}`
+		}
+		io.WriteString(w, `
+
+// `+u.name+` holds a json value of one of the following types:
+//`+jsonTypes+`
+//
+// Exactly one member will be set after unmarshaling.
+type `+u.name+` struct {`+fields+`
+}
+
+// UnmarshalJSON sets the member of `+u.name+` that matches the json type of data.
+func (this *`+u.name+`) UnmarshalJSON(data []byte) error {
+	if this == nil {
+		return errors.New("`+u.name+`: UnmarshalJSON on nil pointer")
+	}
+	*this = `+u.name+`{}
+	if string(data) == "null" {
+		return nil
+	}`+unmarshal+`
+	return fmt.Errorf("`+u.name+`: cannot unmarshal %s", data)
+}
+
+// MarshalJSON marshals whichever member of `+u.name+` is set, or null if none are.
+func (this `+u.name+`) MarshalJSON() ([]byte, error) {`+marshal+`
+	return []byte("null"), nil
+}`)
+	}
+}
@@ -0,0 +1,79 @@
+package jsonschema2go
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// OutOfDateError is returned by Job.Verify when existing source code differs
+// from the source code that would be generated.
+type OutOfDateError struct {
+	// Diff describes the differing lines, with lines only in the existing
+	// source code prefixed by "-", and lines only in the generated source
+	// code prefixed by "+".
+	Diff string
+}
+
+func (err *OutOfDateError) Error() string {
+	return "Generated code is out of date:\n" + err.Diff
+}
+
+// Verify generates source code for the job in memory, and compares it to
+// existing, e.g. the content of a previously generated file that has been
+// committed to version control. If they differ, an *OutOfDateError is
+// returned. This is useful as a CI check or a pre-commit hook.
+func (job *Job) Verify(existing []byte) (*Result, error) {
+	result, err := job.Execute()
+	if err != nil {
+		return result, err
+	}
+	if !bytes.Equal(existing, result.SourceCode) {
+		return result, &OutOfDateError{Diff: diff(string(existing), string(result.SourceCode))}
+	}
+	return result, nil
+}
+
+// diff returns a simple description of the differences between a and b,
+// by trimming their common leading and trailing lines, and listing the
+// remaining lines of each, with some lines of context.
+func diff(a, b string) string {
+	const context = 3
+	aLines := strings.SplitAfter(a, "\n")
+	bLines := strings.SplitAfter(b, "\n")
+	prefix := 0
+	for prefix < len(aLines) && prefix < len(bLines) && aLines[prefix] == bLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(aLines)-prefix && suffix < len(bLines)-prefix && aLines[len(aLines)-1-suffix] == bLines[len(bLines)-1-suffix] {
+		suffix++
+	}
+	start := prefix - context
+	if start < 0 {
+		start = 0
+	}
+	end := suffix - context
+	if end < 0 {
+		end = 0
+	}
+	var out strings.Builder
+	fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", start+1, len(aLines)-end-start, start+1, len(bLines)-end-start)
+	for _, line := range aLines[start:prefix] {
+		out.WriteString(" " + line)
+	}
+	for _, line := range aLines[prefix : len(aLines)-suffix] {
+		out.WriteString("-" + line)
+	}
+	for _, line := range bLines[prefix : len(bLines)-suffix] {
+		out.WriteString("+" + line)
+	}
+	for _, line := range aLines[len(aLines)-suffix : len(aLines)-end] {
+		out.WriteString(" " + line)
+	}
+	result := out.String()
+	if !strings.HasSuffix(result, "\n") {
+		result += "\n"
+	}
+	return result
+}
@@ -0,0 +1,46 @@
+package jsonschema2go
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n"
+	b := "1\n2\n3\n4\nfive\n6\n7\n8\n9\n"
+	expected := "@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n"
+	if got := diff(a, b); got != expected {
+		t.Errorf("expected diff:\n%s\nbut got:\n%s", expected, got)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	schemaFile, err := filepath.Abs(filepath.Join("testdata", "person.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newJob := func() *Job {
+		return &Job{
+			Package:     "main",
+			ExportTypes: true,
+			URLs:        []string{"file://" + schemaFile},
+		}
+	}
+	result, err := newJob().Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = newJob().Verify(result.SourceCode)
+	if err != nil {
+		t.Fatalf("expected freshly generated code to verify, but got: %v", err)
+	}
+	stale, err := ioutil.ReadFile(filepath.Join("testdata", "person.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = newJob().Verify(stale)
+	if _, outOfDate := err.(*OutOfDateError); !outOfDate {
+		t.Fatalf("expected *OutOfDateError but got %#v", err)
+	}
+}
@@ -66,6 +66,7 @@ func generateTypes(input, output, constraint string) []byte {
 		URLs:                 []string{input},
 		SkipCodeGen:          false,
 		DisableNestedStructs: true,
+		DateTime:             &jsonschema2go.TaskclusterDateTime,
 	}
 	result, err := job.Execute()
 	if err != nil {